@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// e2e runs a scripted scenario against a running list daemon over real HTTP and exits
+// with a non-zero exit code if any response does not match what was expected. It is
+// meant to be ran against the docker-compose stack or as a post-deploy smoke check.
+func main() {
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Error("end-to-end scenario failed")
+
+			os.Exit(1)
+		}
+	}()
+
+	addr := flag.String("addr", "http://localhost:3000", "base URL of the list daemon to run the scenario against")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each request made to the list daemon")
+	flag.Parse()
+
+	c := listclient.New(*addr)
+	c.HTTPClient.Timeout = *timeout
+
+	s := scenario{
+		client: c,
+	}
+
+	if err = s.run(context.Background()); err != nil {
+		return
+	}
+
+	log.Info("end-to-end scenario passed")
+}
+
+// scenario contains what is needed to run the end-to-end scenario against a list
+// daemon.
+type scenario struct {
+	client *listclient.Client
+}
+
+// run executes each step of the scenario in order, stopping at the first step that
+// fails: create list → add items → update → delete.
+func (s *scenario) run(ctx context.Context) error {
+	name := fmt.Sprintf("e2e-%d", time.Now().UnixNano())
+
+	// Create a list and read it back.
+	l, err := s.client.CreateList(ctx, name)
+	if err != nil {
+		return err
+	}
+	step("create list")
+
+	if l.ID == 0 || l.Name != name {
+		return errors.Errorf("create list: expected list named %q with an id, got %+v", name, l)
+	}
+
+	// Make sure the list gets removed even if a later step fails. If the scenario made it
+	// to the end this is a no-op since the list is already deleted.
+	defer func() {
+		_ = s.client.DeleteList(ctx, l.ID)
+	}()
+
+	got, err := s.client.List(ctx, l.ID)
+	if err != nil {
+		return err
+	}
+	step("get created list")
+
+	if got.ID != l.ID || got.Name != l.Name {
+		return errors.Errorf("get created list: expected %+v, got %+v", l, got)
+	}
+
+	// Add items to the list.
+	milk, err := s.client.CreateItem(ctx, listclient.Item{ListID: l.ID, Name: "Milk", Quantity: 2})
+	if err != nil {
+		return err
+	}
+	step("create first item")
+
+	if _, err := s.client.CreateItem(ctx, listclient.Item{ListID: l.ID, Name: "Eggs", Quantity: 12}); err != nil {
+		return err
+	}
+	step("create second item")
+
+	items, err := s.client.Items(ctx, l.ID)
+	if err != nil {
+		return err
+	}
+	step("get items")
+
+	if len(items) != 2 {
+		return errors.Errorf("get items: expected 2 items, got %d", len(items))
+	}
+
+	// Update the list and one of its items.
+	renamed := name + "-renamed"
+	if _, err := s.client.UpdateList(ctx, l.ID, renamed); err != nil {
+		return err
+	}
+	step("update list")
+
+	if got, err = s.client.List(ctx, l.ID); err != nil {
+		return err
+	}
+	step("get updated list")
+
+	if got.Name != renamed {
+		return errors.Errorf("get updated list: expected name %q, got %q", renamed, got.Name)
+	}
+
+	milk.Quantity = 3
+	if _, err := s.client.UpdateItem(ctx, milk); err != nil {
+		return err
+	}
+	step("update item")
+
+	gotItem, err := s.client.Item(ctx, l.ID, milk.ID)
+	if err != nil {
+		return err
+	}
+	step("get updated item")
+
+	if gotItem.Quantity != 3 {
+		return errors.Errorf("get updated item: expected quantity 3, got %d", gotItem.Quantity)
+	}
+
+	// Delete an item, then the list along with its remaining item.
+	if err := s.client.DeleteItem(ctx, l.ID, milk.ID); err != nil {
+		return err
+	}
+	step("delete item")
+
+	if _, err := s.client.Item(ctx, l.ID, milk.ID); !listclient.IsNotFound(err) {
+		return errors.Errorf("get deleted item: expected not found, got: %v", err)
+	}
+	step("get deleted item")
+
+	if err := s.client.DeleteList(ctx, l.ID); err != nil {
+		return err
+	}
+	step("delete list")
+
+	if _, err := s.client.List(ctx, l.ID); !listclient.IsNotFound(err) {
+		return errors.Errorf("get deleted list: expected not found, got: %v", err)
+	}
+	step("get deleted list")
+
+	return nil
+}
+
+// step logs that the step of the scenario with the given name passed.
+func step(name string) {
+	log.WithField("step", name).Info("completed step")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// healthcheck performs a single HTTP GET against the readiness probe of a list daemon
+// and exits with 0 when it responded with 200 OK and 1 otherwise. It is meant to be
+// used as a Docker HEALTHCHECK in images that don't ship curl or wget.
+func main() {
+	url := flag.String("url", "http://localhost:3000/ready", "URL of the probe to check")
+	timeout := flag.Duration("timeout", 2*time.Second, "time allowed for the probe to respond")
+	flag.Parse()
+
+	if err := check(*url, *timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// check performs a GET request to the given URL and returns an error if the request
+// did not complete within the timeout or did not respond with 200 OK.
+func check(url string, timeout time.Duration) error {
+	client := http.Client{
+		Timeout: timeout,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection is closed cleanly.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/pkg/errors"
+)
+
+// timeFormat is the format times are printed in within tables.
+const timeFormat = "2006-01-02 15:04"
+
+// listCommands contains the subcommands of the list resource.
+var listCommands = []command{
+	{name: "ls", usage: "print every list", run: listLs},
+	{name: "get", usage: "print a single list: list get <id>", run: listGet},
+	{name: "add", usage: "create a list: list add <name>", run: listAdd},
+	{name: "rename", usage: "rename a list: list rename <id> <name>", run: listRename},
+	{name: "rm", usage: "delete a list along with its items: list rm <id>", run: listRm},
+}
+
+// itemCommands contains the subcommands of the item resource.
+var itemCommands = []command{
+	{name: "ls", usage: "print every item of a list: item ls --list <id>", run: itemLs},
+	{name: "add", usage: "add an item to a list: item add --list <id> [--qty <n>] [--unit <unit>] <name>", run: itemAdd},
+	{name: "set", usage: "update an item: item set --list <id> <item id> <name> [--qty <n>] [--unit <unit>]", run: itemSet},
+	{name: "rm", usage: "delete an item: item rm --list <id> <item id>", run: itemRm},
+}
+
+// printLists prints the given lists.
+func printLists(e env, v interface{}, lists ...listclient.List) error {
+	return e.print(v, "ID\tNAME\tCREATED\tMODIFIED", func(w io.Writer) {
+		for _, l := range lists {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", l.ID, l.Name, l.Created.Local().Format(timeFormat), l.Modified.Local().Format(timeFormat))
+		}
+	})
+}
+
+// printItems prints the given items.
+func printItems(e env, v interface{}, items ...listclient.Item) error {
+	return e.print(v, "ID\tLIST\tNAME\tQUANTITY\tUNIT\tMODIFIED", func(w io.Writer) {
+		for _, i := range items {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\n", i.ID, i.ListID, i.Name, i.Quantity, i.Unit, i.Modified.Local().Format(timeFormat))
+		}
+	})
+}
+
+// id parses a positional argument holding an id.
+func id(name, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Errorf("expected %s to be an integer, got %q", name, s)
+	}
+
+	return n, nil
+}
+
+func listLs(e env, args []string) error {
+	if _, err := parse(flag.NewFlagSet("list ls", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	lists, err := e.client.Lists(e.context())
+	if err != nil {
+		return err
+	}
+
+	return printLists(e, lists, lists...)
+}
+
+func listGet(e env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("list get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	lid, err := id("list id", pos[0])
+	if err != nil {
+		return err
+	}
+
+	l, err := e.client.List(e.context(), lid)
+	if err != nil {
+		return err
+	}
+
+	return printLists(e, l, l)
+}
+
+func listAdd(e env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("list add", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	l, err := e.client.CreateList(e.context(), pos[0])
+	if err != nil {
+		return err
+	}
+
+	return printLists(e, l, l)
+}
+
+func listRename(e env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("list rename", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+
+	lid, err := id("list id", pos[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := e.client.UpdateList(e.context(), lid, pos[1]); err != nil {
+		return err
+	}
+
+	// The update response does not carry the creation time, so the list is read back.
+	l, err := e.client.List(e.context(), lid)
+	if err != nil {
+		return err
+	}
+
+	return printLists(e, l, l)
+}
+
+func listRm(e env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("list rm", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	lid, err := id("list id", pos[0])
+	if err != nil {
+		return err
+	}
+
+	return e.client.DeleteList(e.context(), lid)
+}
+
+func itemLs(e env, args []string) error {
+	fs := flag.NewFlagSet("item ls", flag.ContinueOnError)
+	lid := fs.Int("list", 0, "id of the list")
+
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	items, err := e.client.Items(e.context(), *lid)
+	if err != nil {
+		return err
+	}
+
+	return printItems(e, items, items...)
+}
+
+func itemAdd(e env, args []string) error {
+	fs := flag.NewFlagSet("item add", flag.ContinueOnError)
+	lid := fs.Int("list", 0, "id of the list")
+	qty := fs.Int("qty", 1, "quantity of the item")
+	unit := fs.String("unit", "", "unit the quantity is given in, e.g. kg")
+
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	i, err := e.client.CreateItem(e.context(), listclient.Item{ListID: *lid, Name: pos[0], Quantity: *qty, Unit: *unit})
+	if err != nil {
+		return err
+	}
+
+	return printItems(e, i, i)
+}
+
+func itemSet(e env, args []string) error {
+	fs := flag.NewFlagSet("item set", flag.ContinueOnError)
+	lid := fs.Int("list", 0, "id of the list")
+	qty := fs.Int("qty", 1, "quantity of the item")
+	unit := fs.String("unit", "", "unit the quantity is given in, e.g. kg")
+
+	pos, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+
+	iid, err := id("item id", pos[0])
+	if err != nil {
+		return err
+	}
+
+	i, err := e.client.UpdateItem(e.context(), listclient.Item{ID: iid, ListID: *lid, Name: pos[1], Quantity: *qty, Unit: *unit})
+	if err != nil {
+		return err
+	}
+
+	return printItems(e, i, i)
+}
+
+func itemRm(e env, args []string) error {
+	fs := flag.NewFlagSet("item rm", flag.ContinueOnError)
+	lid := fs.Int("list", 0, "id of the list")
+
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	iid, err := id("item id", pos[0])
+	if err != nil {
+		return err
+	}
+
+	return e.client.DeleteItem(e.context(), *lid, iid)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// completion runs listctl completion <shell>, writing a completion script for the given
+// shell generated from the resources and their subcommands.
+func completion(out io.Writer, args []string) error {
+	pos, err := parse(flag.NewFlagSet("completion", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+
+	script := bashCompletion()
+
+	switch pos[0] {
+	case "bash":
+	case "zsh":
+		// zsh runs the bash script through its bash compatible completion system.
+		script = "#compdef listctl\n\nautoload -U +X bashcompinit && bashcompinit\n\n" + script
+	default:
+		return errors.Errorf("unknown shell %q, expected bash or zsh", pos[0])
+	}
+
+	_, err = io.WriteString(out, script)
+	return errors.Wrap(err, "write output")
+}
+
+// bashCompletion returns a bash completion script completing the global flags, the
+// resources, and the subcommands of every resource.
+func bashCompletion() string {
+	var cases strings.Builder
+	for _, resource := range resourceNames {
+		names := make([]string, 0, len(resources[resource]))
+		for _, c := range resources[resource] {
+			names = append(names, c.name)
+		}
+
+		fmt.Fprintf(&cases, "\t%s) words=%q ;;\n", resource, strings.Join(names, " "))
+	}
+	fmt.Fprintf(&cases, "\tcompletion) words=\"bash zsh\" ;;\n")
+
+	top := strings.Join(append(append([]string{}, resourceNames...), "completion", "interactive"), " ")
+
+	return `# listctl completion, generated by listctl completion.
+_listctl() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local words="" positional=() i
+
+	case $prev in
+	-o) COMPREPLY=($(compgen -W "table json" -- "$cur")); return ;;
+	-addr|-timeout) return ;;
+	esac
+
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		-addr|-o|-timeout) ((i++)) ;;
+		-*) ;;
+		*) positional+=("${COMP_WORDS[i]}") ;;
+		esac
+	done
+
+	if [[ $cur == -* && ${#positional[@]} -eq 0 ]]; then
+		COMPREPLY=($(compgen -W "-addr -o -timeout" -- "$cur"))
+		return
+	fi
+
+	case ${#positional[@]} in
+	0) words="` + top + `" ;;
+	1)
+		case ${positional[0]} in
+` + indent(cases.String(), "\t") + `		esac
+		;;
+	esac
+
+	COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+complete -F _listctl listctl
+`
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+
+	return strings.Join(lines, "")
+}
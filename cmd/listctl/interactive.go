@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/pkg/errors"
+)
+
+// interactive runs listctl interactive, a prompt to pick a list and check its items off
+// one by one, e.g. while shopping. Checking an item off deletes it from the list.
+func interactive(e env, args []string) error {
+	if _, err := parse(flag.NewFlagSet("interactive", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	p := prompt{env: e, in: bufio.NewScanner(e.in)}
+
+	for {
+		l, ok, err := p.pickList()
+		if err != nil || !ok {
+			return err
+		}
+
+		if ok, err = p.checkOff(l); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// prompt reads the commands of an interactive session line by line.
+type prompt struct {
+	env
+	in *bufio.Scanner
+}
+
+// read prints the prompt and returns the next command along with its argument, e.g. a
+// and Milk for "a Milk". ok is false once the input is exhausted.
+func (p prompt) read(prompt string) (cmd, arg string, ok bool, err error) {
+	fmt.Fprintf(p.out, "%s> ", prompt)
+
+	if !p.in.Scan() {
+		fmt.Fprintln(p.out)
+		return "", "", false, errors.Wrap(p.in.Err(), "read input")
+	}
+
+	line := strings.TrimSpace(p.in.Text())
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		return line[:i], strings.TrimSpace(line[i+1:]), true, nil
+	}
+
+	return line, "", true, nil
+}
+
+// pick returns the 1-based position cmd names among n entries, or 0 if it names none.
+func pick(cmd string, n int) int {
+	i, err := strconv.Atoi(cmd)
+	if err != nil || i < 1 || i > n {
+		return 0
+	}
+
+	return i
+}
+
+// pickList prints every list and lets the user pick one of them, or add a new one. ok is
+// false when the user quits.
+func (p prompt) pickList() (l listclient.List, ok bool, err error) {
+	for {
+		lists, err := p.client.Lists(p.context())
+		if err != nil {
+			return l, false, err
+		}
+
+		w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+		for i, l := range lists {
+			fmt.Fprintf(w, "%3d\t%s\n", i+1, l.Name)
+		}
+		if err := w.Flush(); err != nil {
+			return l, false, errors.Wrap(err, "write output")
+		}
+
+		fmt.Fprintln(p.out, "Pick a list by its number, add one with a <name>, or quit with q.")
+
+		cmd, arg, ok, err := p.read("lists")
+		if err != nil || !ok {
+			return l, false, err
+		}
+
+		switch {
+		case cmd == "q":
+			return l, false, nil
+		case cmd == "a" && arg != "":
+			if _, err := p.client.CreateList(p.context(), arg); err != nil {
+				fmt.Fprintf(p.out, "error: %v\n", err)
+			}
+		case pick(cmd, len(lists)) > 0:
+			return lists[pick(cmd, len(lists))-1], true, nil
+		default:
+			fmt.Fprintf(p.out, "unknown command %q\n", cmd)
+		}
+	}
+}
+
+// checkOff prints the items of l and lets the user check them off, or add new ones. ok
+// is false when the user quits rather than going back to the lists.
+func (p prompt) checkOff(l listclient.List) (ok bool, err error) {
+	for {
+		items, err := p.client.Items(p.context(), l.ID)
+		if err != nil {
+			return false, err
+		}
+
+		w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\n", l.Name)
+		for i, it := range items {
+			fmt.Fprintf(w, "%3d\t%s\t%d %s\n", i+1, it.Name, it.Quantity, it.Unit)
+		}
+		if err := w.Flush(); err != nil {
+			return false, errors.Wrap(err, "write output")
+		}
+
+		fmt.Fprintln(p.out, "Check an item off by its number, add one with a <name>, go back with b, or quit with q.")
+
+		cmd, arg, ok, err := p.read(l.Name)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		switch {
+		case cmd == "q":
+			return false, nil
+		case cmd == "b":
+			return true, nil
+		case cmd == "a" && arg != "":
+			if _, err := p.client.CreateItem(p.context(), listclient.Item{ListID: l.ID, Name: arg, Quantity: 1}); err != nil {
+				fmt.Fprintf(p.out, "error: %v\n", err)
+			}
+		case pick(cmd, len(items)) > 0:
+			it := items[pick(cmd, len(items))-1]
+			if err := p.client.DeleteItem(p.context(), l.ID, it.ID); err != nil {
+				fmt.Fprintf(p.out, "error: %v\n", err)
+				continue
+			}
+
+			fmt.Fprintf(p.out, "Checked off %s.\n", it.Name)
+		default:
+			fmt.Fprintf(p.out, "unknown command %q\n", cmd)
+		}
+	}
+}
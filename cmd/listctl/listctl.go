@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/pkg/errors"
+)
+
+// listctl is a command-line client for the list daemon built on pkg/listclient.
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "listctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// These constants define the supported output formats.
+const (
+	// formatTable prints results as an aligned table.
+	formatTable = "table"
+
+	// formatJSON prints results as indented JSON.
+	formatJSON = "json"
+)
+
+// env is a command-line client setup shared by every subcommand.
+type env struct {
+	client *listclient.Client
+	format string
+	in     io.Reader
+	out    io.Writer
+}
+
+// command is a subcommand of a resource, e.g. ls in listctl list ls.
+type command struct {
+	name  string
+	usage string
+	run   func(e env, args []string) error
+}
+
+// resources contains the subcommands of every resource.
+var resources = map[string][]command{
+	"list": listCommands,
+	"item": itemCommands,
+}
+
+// resourceNames contains the name of every resource in the order they are listed in.
+var resourceNames = []string{"list", "item"}
+
+// run parses the global flags and runs the requested subcommand, reading the input of
+// interactive mode from in.
+func run(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("listctl", flag.ContinueOnError)
+	fs.Usage = func() { usage(fs) }
+
+	addr := fs.String("addr", envOr("LISTCTL_ADDR", "http://localhost:3000"), "base URL of the list daemon (env: LISTCTL_ADDR)")
+	format := fs.String("o", envOr("LISTCTL_OUTPUT", formatTable), "output format, table or json (env: LISTCTL_OUTPUT)")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request made to the list daemon")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != formatTable && *format != formatJSON {
+		return errors.Errorf("unknown output format %q, expected table or json", *format)
+	}
+
+	client := listclient.New(*addr)
+	client.HTTPClient.Timeout = *timeout
+
+	e := env{client: client, format: *format, in: in, out: out}
+
+	switch fs.Arg(0) {
+	case "completion":
+		return completion(out, fs.Args()[1:])
+	case "interactive":
+		return interactive(e, fs.Args()[1:])
+	}
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("expected a resource and a command")
+	}
+
+	resource, name := fs.Arg(0), fs.Arg(1)
+
+	for _, c := range resources[resource] {
+		if c.name != name {
+			continue
+		}
+
+		return c.run(e, fs.Args()[2:])
+	}
+
+	fs.Usage()
+	return errors.Errorf("unknown command %q", resource+" "+name)
+}
+
+// usage prints the global flags and every subcommand to stderr.
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage: listctl [flags] <resource> <command> [arguments]\n\nCommands:\n")
+	for _, resource := range resourceNames {
+		for _, c := range resources[resource] {
+			fmt.Fprintf(os.Stderr, "  %s %-8s %s\n", resource, c.name, c.usage)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  %-13s %s\n", "interactive", "pick a list and check its items off one by one")
+	fmt.Fprintf(os.Stderr, "  %-13s %s\n", "completion", "print a completion script: completion <bash|zsh>")
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+// envOr returns the value of the given environment variable, or def if it isn't set.
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+
+	return def
+}
+
+// parse parses args using fs, allowing flags to be mixed with positional arguments as
+// in listctl item add --list 3 "Milk" --qty 2. The positional arguments are returned
+// and their amount has to equal want.
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != want {
+		return nil, errors.Errorf("%s: expected %d argument(s), got %d", fs.Name(), want, len(positional))
+	}
+
+	return positional, nil
+}
+
+// print writes v in the output format, rows is used to write v as a table below the
+// given header.
+func (e env) print(v interface{}, header string, rows func(w io.Writer)) error {
+	if e.format == formatJSON {
+		enc := json.NewEncoder(e.out)
+		enc.SetIndent("", "  ")
+
+		return errors.Wrap(enc.Encode(v), "write output")
+	}
+
+	w := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, header)
+	rows(w)
+
+	return errors.Wrap(w.Flush(), "write output")
+}
+
+// context returns the context every request is made with.
+func (e env) context() context.Context {
+	return context.Background()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+)
+
+// These words are combined into the fake names used by anonymize.
+var (
+	adjectives = []string{
+		"Amber", "Brisk", "Cobalt", "Dusty", "Eager", "Fuzzy", "Gentle", "Hollow",
+		"Ivory", "Jolly", "Keen", "Lunar", "Mellow", "Nimble", "Olive", "Plucky",
+		"Quiet", "Rusty", "Silver", "Tidy", "Umber", "Vivid", "Windy", "Zesty",
+	}
+
+	nouns = []string{
+		"Anchor", "Badger", "Candle", "Dune", "Ember", "Falcon", "Garden", "Harbor",
+		"Island", "Juniper", "Kettle", "Lantern", "Meadow", "Nutmeg", "Orchard", "Pebble",
+		"Quill", "Ridge", "Saddle", "Thistle", "Upland", "Valley", "Willow", "Yarrow",
+	}
+)
+
+// anonymizer replaces names with deterministic fakes: the same name and salt always
+// yield the same fake, which keeps exports of the same data comparable.
+type anonymizer struct {
+	salt string
+
+	// taken holds the fake list names handed out so far, list names have to stay unique
+	// for the export to be importable.
+	taken map[string]bool
+}
+
+// fake returns the fake name of the given kind for name.
+func (a *anonymizer) fake(kind, name string) string {
+	sum := sha256.Sum256([]byte(a.salt + "\x00" + kind + "\x00" + name))
+	n := binary.BigEndian.Uint64(sum[:8])
+
+	return fmt.Sprintf("%s %s", adjectives[n%uint64(len(adjectives))], nouns[(n/uint64(len(adjectives)))%uint64(len(nouns))])
+}
+
+// anonymize replaces the name of every list and item in doc with a fake. IDs and
+// timestamps are kept so that the references between lists and items stay intact.
+func anonymize(doc *exporter.Document, salt string) {
+	a := anonymizer{
+		salt:  salt,
+		taken: make(map[string]bool, len(doc.Lists)),
+	}
+
+	for i := range doc.Lists {
+		l := &doc.Lists[i]
+
+		name := a.fake("list", l.Name)
+		for n := 2; a.taken[name]; n++ {
+			name = fmt.Sprintf("%s %d", a.fake("list", l.Name), n)
+		}
+		a.taken[name] = true
+
+		l.Name = name
+
+		for j := range l.Items {
+			l.Items[j].Name = a.fake("item", l.Items[j].Name)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+)
+
+func TestAnonymize(t *testing.T) {
+	newDoc := func() exporter.Document {
+		return exporter.Document{
+			Lists: []exporter.List{
+				{
+					List:  list.List{ID: 1, Name: "Grocery"},
+					Items: []item.Item{{ID: 1, ListID: 1, Name: "Milk", Quantity: 2}},
+				},
+				{
+					List:  list.List{ID: 2, Name: "To-do"},
+					Items: []item.Item{{ID: 2, ListID: 2, Name: "Milk", Quantity: 1}},
+				},
+			},
+		}
+	}
+
+	doc := newDoc()
+	anonymize(&doc, "salt")
+
+	again := newDoc()
+	anonymize(&again, "salt")
+
+	salted := newDoc()
+	anonymize(&salted, "pepper")
+
+	if doc.Lists[0].Name == "Grocery" || doc.Lists[0].Items[0].Name == "Milk" {
+		t.Errorf("expected names to be replaced, got %+v", doc.Lists[0])
+	}
+
+	if e, a := doc.Lists[0].Name, again.Lists[0].Name; e != a {
+		t.Errorf("expected deterministic list name: %v, got list name: %v", e, a)
+	}
+
+	if e, a := doc.Lists[0].Items[0].Name, doc.Lists[1].Items[0].Name; e != a {
+		t.Errorf("expected equal item names to get the same fake: %v, got: %v", e, a)
+	}
+
+	if doc.Lists[0].Name == doc.Lists[1].Name {
+		t.Errorf("expected list names to stay unique, got %q twice", doc.Lists[0].Name)
+	}
+
+	if doc.Lists[0].Name == salted.Lists[0].Name && doc.Lists[1].Name == salted.Lists[1].Name {
+		t.Error("expected a different salt to yield different fakes")
+	}
+
+	if e, a := 1, doc.Lists[0].Items[0].ListID; e != a {
+		t.Errorf("expected list id: %v, got list id: %v", e, a)
+	}
+}
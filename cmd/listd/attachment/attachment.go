@@ -0,0 +1,121 @@
+// Package attachment keeps track of the files attached to items. The files themselves
+// are kept in a storage.Storage under the key of their row, the rows only describe them.
+package attachment
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// Attachment is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of a file attached to an item, which is identified by its name within
+// the item. The key it is stored under is never handed out, clients download it through
+// a signed URL.
+type Attachment struct {
+	ID          int       `json:"id" db:"attachment_id"`
+	ItemID      int       `json:"itemID" db:"item_id"`
+	Name        string    `json:"name" db:"name"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Key         string    `json:"-" db:"storage_key"`
+	Created     time.Time `json:"created" db:"created"`
+	Modified    time.Time `json:"modified" db:"modified"`
+}
+
+// NewKey returns a new, unique key to store the file of an attachment under. Files are
+// never overwritten, a replaced file is stored under a new key.
+func NewKey() string {
+	return "attachments/" + uuid.New()
+}
+
+// SelectAttachments selects all rows from the attachment table of the item given by
+// itemID and listID, ordered by name. sql.ErrNoRows is returned if the list does not
+// contain the item.
+func SelectAttachments(dbc db.Executor, itemID, listID int) ([]Attachment, error) {
+	if _, err := item.SelectItem(dbc, itemID, listID); errors.Cause(err) == sql.ErrNoRows {
+		return nil, sql.ErrNoRows
+	}
+
+	attachments := make([]Attachment, 0)
+
+	if err := db.Select(dbc, &attachments, selectOfItem, itemID); err != nil {
+		return nil, errors.Wrap(err, "select rows from attachment table given an item_id")
+	}
+
+	return attachments, nil
+}
+
+// SelectAttachment selects a single row from the attachment table based off of the given
+// name and the item_id and list_id of its item.
+func SelectAttachment(dbc db.Executor, name string, itemID, listID int) (Attachment, error) {
+	var a Attachment
+	if err := db.Get(dbc, &a, selectByName, name, itemID, listID); err != nil {
+		return Attachment{}, errors.Wrap(err, "select attachment row by name")
+	}
+
+	return a, nil
+}
+
+// SelectKeysOfItem selects the keys the files of the attachments of the item given by
+// itemID and listID are stored under. Deleting an item deletes its attachments, so their
+// keys are selected beforehand to delete the files once the deletion is committed.
+func SelectKeysOfItem(dbc db.Executor, itemID, listID int) ([]string, error) {
+	var keys []string
+	if err := dbc.Select(&keys, selectKeysOfItem, itemID, listID); err != nil {
+		return nil, errors.Wrap(err, "select storage keys of attachments given an item_id")
+	}
+
+	return keys, nil
+}
+
+// SelectKeysOfLists selects the keys the files of the attachments of the items of the
+// lists given by ids are stored under, see SelectKeysOfItem.
+func SelectKeysOfLists(dbc db.Executor, ids []int) ([]string, error) {
+	var keys []string
+	if err := dbc.Select(&keys, selectKeysOfLists, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "select storage keys of attachments given list_ids")
+	}
+
+	return keys, nil
+}
+
+// PutAttachment inserts a new row into the attachment table for the item of the list
+// given by listID, or replaces the file of the attachment of the item with the same name.
+// The file must have been stored under its key beforehand. It reports whether the row was
+// inserted, and returns the key of the replaced file otherwise, which is to be deleted
+// once the change is committed. sql.ErrNoRows is returned if the list does not contain
+// the item.
+func PutAttachment(dbc db.Executor, listID int, a Attachment) (Attachment, bool, string, error) {
+	if _, err := item.SelectItem(dbc, a.ItemID, listID); errors.Cause(err) == sql.ErrNoRows {
+		return Attachment{}, false, "", sql.ErrNoRows
+	}
+
+	var row struct {
+		Attachment
+		Inserted    bool   `db:"inserted"`
+		ReplacedKey string `db:"replaced_key"`
+	}
+
+	if err := dbc.Get(&row, upsert, a.ItemID, a.Name, a.ContentType, a.Size, a.Key, time.Now()); err != nil {
+		return Attachment{}, false, "", errors.Wrap(err, "upsert attachment row")
+	}
+
+	return row.Attachment, row.Inserted, row.ReplacedKey, nil
+}
+
+// DeleteAttachment deletes the row of the attachment table given by name, itemID, and
+// listID and returns it, so its file can be deleted once the deletion is committed.
+func DeleteAttachment(dbc db.Executor, name string, itemID, listID int) (Attachment, error) {
+	var a Attachment
+	if err := dbc.Get(&a, del, name, itemID, listID); err != nil {
+		return Attachment{}, errors.Wrap(err, "delete attachment row")
+	}
+
+	return a, nil
+}
@@ -0,0 +1,40 @@
+package attachment
+
+// PostgreSQL queries for the attachment table, all used in the attachment package.
+const (
+	// selectOfItem is a query that selects all rows in the attachment table filtered by
+	// item_id and ordered by name.
+	selectOfItem = "SELECT * FROM attachment WHERE item_id = $1 ORDER BY name;"
+
+	// selectByName is a query that selects a row in the attachment table filtered by name,
+	// item_id, and the list_id of the item.
+	selectByName = `SELECT a.* FROM attachment a JOIN item i ON i.item_id = a.item_id
+		WHERE a.name = $1 AND a.item_id = $2 AND i.list_id = $3;`
+
+	// selectKeysOfItem is a query that selects the storage_key of every row in the
+	// attachment table of the item given by item_id and the list_id of the item.
+	selectKeysOfItem = `SELECT a.storage_key FROM attachment a JOIN item i ON i.item_id = a.item_id
+		WHERE a.item_id = $1 AND i.list_id = $2;`
+
+	// selectKeysOfLists is a query that selects the storage_key of every row in the
+	// attachment table of the items of the lists given by an array of list_id.
+	selectKeysOfLists = `SELECT a.storage_key FROM attachment a JOIN item i ON i.item_id = a.item_id
+		WHERE i.list_id = ANY($1);`
+
+	// upsert is a query that inserts a row into the attachment table using the values
+	// given in order for item_id, name, content_type, size, storage_key, and created, or
+	// when the item already has an attachment with the same name, replaces its
+	// content_type, size, and storage_key and sets its modified. The row is returned along
+	// with whether it was inserted and the storage_key it had before.
+	upsert = `WITH old AS (SELECT storage_key FROM attachment WHERE item_id = $1 AND name = $2 FOR UPDATE)
+		INSERT INTO attachment (item_id, name, content_type, size, storage_key, created, modified) VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (item_id, name) DO UPDATE
+		SET content_type = EXCLUDED.content_type, size = EXCLUDED.size, storage_key = EXCLUDED.storage_key, modified = EXCLUDED.modified
+		RETURNING *, xmax = 0 AS inserted, COALESCE((SELECT storage_key FROM old), '') AS replaced_key;`
+
+	// del is a query that deletes a row in the attachment table given a name, item_id,
+	// and the list_id of the item, and returns the deleted row.
+	del = `DELETE FROM attachment a USING item i
+		WHERE a.name = $1 AND a.item_id = $2 AND i.item_id = a.item_id AND i.list_id = $3
+		RETURNING a.*;`
+)
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/backup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// backUp writes a backup of every table to stdout, or to the file given by -out, as a
+// gzipped SQL script.
+func backUp(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := fs.String("out", "", "file to write the backup to instead of stdout, such as list.sql.gz")
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return errors.Wrap(err, "create backup file")
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	m, err := backup.Write(context.Background(), dbc, w)
+	if err != nil {
+		return err
+	}
+
+	logger.WithField("rows", m.Rows).Info("backed up database")
+
+	return nil
+}
+
+// restore replaces every row of the database by those of a backup read from stdin, or
+// from the file given by -in. Pending migrations are applied first unless -skip-migrate
+// is given, since the backup only holds rows.
+func restore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := fs.String("in", "", "file to read the backup from instead of stdin, such as list.sql.gz")
+	skipMigrate := fs.Bool("skip-migrate", false, "don't apply pending database migrations before restoring")
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	if !*skipMigrate {
+		if _, err := db.Migrate(dbc, logger); err != nil {
+			return errors.Wrap(err, "migrate database")
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return errors.Wrap(err, "open backup file")
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	m, err := backup.Restore(context.Background(), dbc, r)
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(log.Fields{
+		"created": m.Created,
+		"rows":    m.Rows,
+	}).Info("restored database")
+
+	return nil
+}
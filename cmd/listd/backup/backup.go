@@ -0,0 +1,340 @@
+// Package backup writes the rows of every table of the database to a gzipped SQL script
+// and restores them from one. It is a logical backup that needs neither pg_dump nor
+// access to the database server, and can be restored by listd or by piping the script to
+// psql alike.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// These constants are the queries a backup is written with.
+const (
+	// selectTables is a query that selects the tables of the current schema that are
+	// backed up, which is every table but the one keeping track of migrations.
+	selectTables = `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name <> 'schema_migration'
+		ORDER BY table_name;`
+
+	// selectReferences is a query that selects every pair of tables of the current schema
+	// where the first has a foreign key to the second.
+	selectReferences = `SELECT child.relname AS child, parent.relname AS parent FROM pg_constraint c
+		JOIN pg_class child ON child.oid = c.conrelid
+		JOIN pg_class parent ON parent.oid = c.confrelid
+		JOIN pg_namespace n ON n.oid = c.connamespace
+		WHERE c.contype = 'f' AND n.nspname = current_schema() AND c.conrelid <> c.confrelid;`
+
+	// selectSequences is a query that selects the state of every sequence of the current
+	// schema, last_value is NULL for sequences that were never used.
+	selectSequences = `SELECT sequencename, start_value, last_value FROM pg_sequences
+		WHERE schemaname = current_schema()
+		ORDER BY sequencename;`
+)
+
+// batchSize is the amount of rows inserted by each statement of a backup.
+const batchSize = 500
+
+// Write writes a backup of every table of dbc to w as a gzipped SQL script and returns
+// its manifest. The tables are read within a read-only transaction bound to ctx, so the
+// backup is a consistent snapshot.
+func Write(ctx context.Context, dbc *sqlx.DB, w io.Writer) (Manifest, error) {
+	statuses, err := db.Migrations(dbc)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "get migration status")
+	}
+
+	tx, err := dbc.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "begin transaction")
+	}
+
+	// Nothing is written, the transaction is only ever rolled back.
+	defer tx.Rollback()
+
+	tables, err := orderedTables(tx)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	m := Manifest{
+		Created: time.Now().UTC(),
+		Rows:    make(map[string]int, len(tables)),
+	}
+
+	for _, s := range statuses {
+		if s.Applied != nil {
+			m.Migrations = append(m.Migrations, s.Version)
+		}
+	}
+
+	zw := gzip.NewWriter(w)
+
+	s, err := newScriptWriter(zw, m)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	quoted := make([]string, len(tables))
+	for i, t := range tables {
+		quoted[i] = pq.QuoteIdentifier(t)
+	}
+
+	// Triggers are disabled while restoring since the rows are restored as they were,
+	// such as the item counts of lists, which the triggers would count again.
+	s.statement("BEGIN;")
+	s.statement("SET LOCAL standard_conforming_strings = on;")
+	for _, t := range quoted {
+		s.statement("ALTER TABLE %s DISABLE TRIGGER USER;", t)
+	}
+	s.statement("TRUNCATE %s;", strings.Join(quoted, ", "))
+
+	for _, t := range tables {
+		if m.Rows[t], err = writeRows(ctx, tx, s, t); err != nil {
+			return Manifest{}, errors.Wrapf(err, "back up %s table", t)
+		}
+	}
+
+	if err := writeSequences(tx, s); err != nil {
+		return Manifest{}, err
+	}
+
+	for _, t := range quoted {
+		s.statement("ALTER TABLE %s ENABLE TRIGGER USER;", t)
+	}
+	s.statement("COMMIT;")
+
+	if err := s.close(m); err != nil {
+		return Manifest{}, err
+	}
+
+	return m, errors.Wrap(zw.Close(), "compress backup")
+}
+
+// orderedTables returns the tables that are backed up, those referenced by others before
+// them so they can be inserted in order.
+func orderedTables(tx *sqlx.Tx) ([]string, error) {
+	var tables []string
+	if err := tx.Select(&tables, selectTables); err != nil {
+		return nil, errors.Wrap(err, "select tables")
+	}
+
+	var refs []reference
+	if err := tx.Select(&refs, selectReferences); err != nil {
+		return nil, errors.Wrap(err, "select foreign keys")
+	}
+
+	return order(tables, refs)
+}
+
+// reference is a foreign key of the table Child to the table Parent.
+type reference struct {
+	Child  string `db:"child"`
+	Parent string `db:"parent"`
+}
+
+// order orders tables so that every table comes after those it references, otherwise
+// keeping them in order. Tables that reference each other in a cycle can't be ordered.
+func order(tables []string, refs []reference) ([]string, error) {
+	parents := make(map[string]map[string]bool, len(tables))
+	for _, r := range refs {
+		if parents[r.Child] == nil {
+			parents[r.Child] = make(map[string]bool)
+		}
+		parents[r.Child][r.Parent] = true
+	}
+
+	ordered := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+
+	for len(ordered) < len(tables) {
+		var next []string
+		for _, t := range tables {
+			if done[t] {
+				continue
+			}
+
+			ready := true
+			for p := range parents[t] {
+				ready = ready && done[p]
+			}
+
+			if ready {
+				next = append(next, t)
+			}
+		}
+
+		if len(next) == 0 {
+			return nil, errors.New("tables reference each other in a cycle")
+		}
+
+		sort.Strings(next)
+		for _, t := range next {
+			done[t] = true
+		}
+		ordered = append(ordered, next...)
+	}
+
+	return ordered, nil
+}
+
+// writeRows writes the statements inserting every row of the given table to s and
+// returns how many there were. Rows are written as JSON, which postgres turns back into
+// rows of the table as they were.
+func writeRows(ctx context.Context, tx *sqlx.Tx, s *scriptWriter, table string) (int, error) {
+	t := pq.QuoteIdentifier(table)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT row_to_json(t) FROM %s t;", t))
+	if err != nil {
+		return 0, errors.Wrap(err, "select rows")
+	}
+	defer rows.Close()
+
+	var (
+		n     int
+		batch []string
+	)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		// JSON escapes line breaks within strings, so the statement stays on one line.
+		s.statement("INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s, %[2]s);", t, quote("["+strings.Join(batch, ",")+"]"))
+		batch = batch[:0]
+	}
+
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return 0, errors.Wrap(err, "scan row")
+		}
+
+		batch = append(batch, row)
+		n++
+
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "select rows")
+	}
+	flush()
+
+	return n, s.err()
+}
+
+// writeSequences writes the statements setting every sequence back to its state to s, so
+// rows created after restoring get new identifiers.
+func writeSequences(tx *sqlx.Tx, s *scriptWriter) error {
+	var seqs []struct {
+		Name  string        `db:"sequencename"`
+		Start int64         `db:"start_value"`
+		Last  sql.NullInt64 `db:"last_value"`
+	}
+	if err := tx.Select(&seqs, selectSequences); err != nil {
+		return errors.Wrap(err, "select sequences")
+	}
+
+	for _, seq := range seqs {
+		if seq.Last.Valid {
+			s.statement("SELECT setval(%s, %d, true);", quote(pq.QuoteIdentifier(seq.Name)), seq.Last.Int64)
+		} else {
+			s.statement("SELECT setval(%s, %d, false);", quote(pq.QuoteIdentifier(seq.Name)), seq.Start)
+		}
+	}
+
+	return s.err()
+}
+
+// quote returns s as a string literal.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Restore replaces the rows of every table of dbc by those of the backup read from r and
+// returns its manifest. The backup is restored within a single transaction that is only
+// committed once the checksum of the backup matched and every table holds as many rows
+// as were backed up. The migrations applied to dbc must be those of the backup.
+func Restore(ctx context.Context, dbc *sqlx.DB, r io.Reader) (Manifest, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "decompress backup")
+	}
+	defer zr.Close()
+
+	s, err := newScriptReader(zr)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	statuses, err := db.Migrations(dbc)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "get migration status")
+	}
+
+	var applied []int
+	for _, st := range statuses {
+		if st.Applied != nil {
+			applied = append(applied, st.Version)
+		}
+	}
+
+	if !reflect.DeepEqual(applied, s.manifest.Migrations) {
+		return Manifest{}, errors.Errorf("the backup was taken with migrations %v applied but the database has %v, restore it with the listd version that took it", s.manifest.Migrations, applied)
+	}
+
+	tx, err := dbc.BeginTxx(ctx, nil)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	for {
+		stmt, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		// The transaction is the one of Restore.
+		if stmt == "BEGIN;" || stmt == "COMMIT;" {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return Manifest{}, errors.Wrapf(err, "line %d", s.n)
+		}
+	}
+
+	for table, want := range s.manifest.Rows {
+		var got int
+		if err := tx.GetContext(ctx, &got, fmt.Sprintf("SELECT count(*) FROM %s;", pq.QuoteIdentifier(table))); err != nil {
+			return Manifest{}, errors.Wrapf(err, "count rows of %s table", table)
+		}
+
+		if got != want {
+			return Manifest{}, errors.Errorf("restored %d rows into the %s table but the backup has %d", got, table, want)
+		}
+	}
+
+	return s.manifest, errors.Wrap(tx.Commit(), "commit transaction")
+}
@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrder(t *testing.T) {
+	tables := []string{"item", "item_history", "job", "list", "list_settings"}
+	refs := []reference{
+		{Child: "item", Parent: "list"},
+		{Child: "item_history", Parent: "item"},
+		{Child: "list_settings", Parent: "list"},
+	}
+
+	ordered, err := order(tables, refs)
+	if err != nil {
+		t.Fatalf("error ordering tables: %v", err)
+	}
+
+	if e, a := []string{"job", "list", "item", "list_settings", "item_history"}, ordered; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected order: %v, got order: %v", e, a)
+	}
+
+	if _, err := order([]string{"a", "b"}, []reference{{Child: "a", Parent: "b"}, {Child: "b", Parent: "a"}}); err == nil {
+		t.Error("expected an error ordering tables that reference each other")
+	}
+}
+
+// writeScript returns a script of the given statements described by m.
+func writeScript(t *testing.T, m Manifest, statements ...string) string {
+	t.Helper()
+
+	var b bytes.Buffer
+
+	s, err := newScriptWriter(&b, m)
+	if err != nil {
+		t.Fatalf("error writing heading: %v", err)
+	}
+
+	for _, stmt := range statements {
+		s.statement("%s", stmt)
+	}
+
+	if err := s.close(m); err != nil {
+		t.Fatalf("error writing trailer: %v", err)
+	}
+
+	return b.String()
+}
+
+// readScript returns the statements of script along with its manifest.
+func readScript(script string) ([]string, Manifest, error) {
+	s, err := newScriptReader(strings.NewReader(script))
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+
+	var stmts []string
+	for {
+		stmt, err := s.Next()
+		if err == io.EOF {
+			return stmts, s.manifest, nil
+		}
+		if err != nil {
+			return nil, Manifest{}, err
+		}
+
+		stmts = append(stmts, stmt)
+	}
+}
+
+func TestScript(t *testing.T) {
+	m := Manifest{
+		Created:    time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		Migrations: []int{1, 2, 3},
+		Rows:       map[string]int{"list": 2, "item": 0},
+	}
+
+	insert := `INSERT INTO "list" SELECT * FROM json_populate_recordset(NULL::"list", '[{"name":"Rock ''n'' roll"}]');`
+	script := writeScript(t, m, "BEGIN;", insert, "COMMIT;")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		stmts, got, err := readScript(script)
+		if err != nil {
+			t.Fatalf("error reading script: %v", err)
+		}
+
+		if e, a := []string{"BEGIN;", insert, "COMMIT;"}, stmts; !reflect.DeepEqual(e, a) {
+			t.Errorf("expected statements: %v, got statements: %v", e, a)
+		}
+
+		if !reflect.DeepEqual(m, got) {
+			t.Errorf("expected manifest: %+v, got manifest: %+v", m, got)
+		}
+	})
+
+	tests := []struct {
+		Name     string
+		Script   string
+		Expected string
+	}{
+		{
+			Name:     "Corrupt",
+			Script:   strings.Replace(script, "Rock", "Rick", 1),
+			Expected: "the backup is corrupt",
+		},
+		{
+			Name:     "CutShort",
+			Script:   script[:strings.Index(script, "COMMIT;")],
+			Expected: "cut short",
+		},
+		{
+			Name:     "WithoutChecksum",
+			Script:   script[:strings.Index(script, checksumPrefix)],
+			Expected: "cut short",
+		},
+		{
+			Name:     "UnexpectedStatement",
+			Script:   writeScript(t, m, "BEGIN;", "DROP TABLE list;", "COMMIT;"),
+			Expected: "unexpected statement",
+		},
+		{
+			Name:     "NotABackup",
+			Script:   "SELECT 1;\n",
+			Expected: "read heading",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			_, _, err := readScript(test.Script)
+			if err == nil || !strings.Contains(err.Error(), test.Expected) {
+				t.Errorf("expected an error containing %q, got: %v", test.Expected, err)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
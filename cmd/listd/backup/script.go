@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// These lines head and trail the script of a backup. Every other line is a statement.
+const (
+	magicLine      = "-- listd backup"
+	createdPrefix  = "-- created: "
+	versionPrefix  = "-- migrations: "
+	rowsPrefix     = "-- rows: "
+	checksumPrefix = "-- sha256: "
+)
+
+// statements are the beginnings of the statements a script may hold, anything else is
+// rejected when restoring.
+var statements = []string{
+	"BEGIN;",
+	"SET LOCAL ",
+	"ALTER TABLE ",
+	"TRUNCATE ",
+	"INSERT INTO ",
+	"SELECT setval(",
+	"COMMIT;",
+}
+
+// Manifest describes a backup. It is written as the comments heading and trailing its
+// script.
+type Manifest struct {
+	Created time.Time
+
+	// Migrations are the versions of the migrations applied to the database that was
+	// backed up, a backup is only restored to a database with the same.
+	Migrations []int
+
+	// Rows is the amount of rows backed up by table.
+	Rows map[string]int
+}
+
+// scriptWriter writes the script of a backup, one statement per line, summing up every
+// line for the checksum trailing it.
+type scriptWriter struct {
+	w   *bufio.Writer
+	sum hash.Hash
+}
+
+// newScriptWriter writes the heading of the script described by m to w.
+func newScriptWriter(w io.Writer, m Manifest) (*scriptWriter, error) {
+	s := scriptWriter{w: bufio.NewWriter(w), sum: sha256.New()}
+
+	versions := make([]string, len(m.Migrations))
+	for i, v := range m.Migrations {
+		versions[i] = strconv.Itoa(v)
+	}
+
+	s.line(magicLine)
+	s.line(createdPrefix + m.Created.UTC().Format(time.RFC3339))
+	s.line(versionPrefix + strings.Join(versions, " "))
+
+	return &s, errors.Wrap(s.err(), "write heading")
+}
+
+// line writes a single line to the script. Errors are kept by the bufio.Writer until err
+// is called.
+func (s *scriptWriter) line(l string) {
+	s.w.WriteString(l)
+	s.w.WriteByte('\n')
+
+	s.sum.Write([]byte(l))
+	s.sum.Write([]byte{'\n'})
+}
+
+// statement writes a statement of the script, which must not span lines.
+func (s *scriptWriter) statement(format string, args ...interface{}) {
+	s.line(fmt.Sprintf(format, args...))
+}
+
+// err returns the first error writing the script ran into.
+func (s *scriptWriter) err() error {
+	return s.w.Flush()
+}
+
+// close writes the trailer of the script, the rows of m and the checksum of every line.
+func (s *scriptWriter) close(m Manifest) error {
+	s.line(rowsPrefix + formatRows(m.Rows))
+	s.w.WriteString(checksumPrefix + hex.EncodeToString(s.sum.Sum(nil)) + "\n")
+
+	return errors.Wrap(s.err(), "write trailer")
+}
+
+// formatRows formats the amount of rows by table as table=n pairs ordered by table.
+func formatRows(rows map[string]int) string {
+	tables := make([]string, 0, len(rows))
+	for t := range rows {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	pairs := make([]string, len(tables))
+	for i, t := range tables {
+		pairs[i] = fmt.Sprintf("%s=%d", t, rows[t])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// scriptReader reads the statements of the script of a backup, checking its lines add
+// up to the checksum trailing it.
+type scriptReader struct {
+	sc       *bufio.Scanner
+	sum      hash.Hash
+	n        int
+	manifest Manifest
+}
+
+// maxLine is the longest line of a script that is read, statements insert the rows of a
+// table in batches so they stay well below it.
+const maxLine = 64 << 20
+
+// newScriptReader reads the heading of the script read by r.
+func newScriptReader(r io.Reader) (*scriptReader, error) {
+	s := scriptReader{sc: bufio.NewScanner(r), sum: sha256.New()}
+	s.sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+
+	heading := make([]string, 3)
+	for i := range heading {
+		l, err := s.line()
+		if err != nil {
+			return nil, errors.Wrap(err, "read heading")
+		}
+		heading[i] = l
+	}
+
+	if heading[0] != magicLine {
+		return nil, errors.New("not a backup written by listd")
+	}
+
+	created, err := time.Parse(time.RFC3339, strings.TrimPrefix(heading[1], createdPrefix))
+	if err != nil || !strings.HasPrefix(heading[1], createdPrefix) {
+		return nil, errors.Errorf("line 2: expected the time the backup was created, got %q", heading[1])
+	}
+	s.manifest.Created = created
+
+	if !strings.HasPrefix(heading[2], versionPrefix) {
+		return nil, errors.Errorf("line 3: expected the migrations of the backup, got %q", heading[2])
+	}
+
+	for _, f := range strings.Fields(strings.TrimPrefix(heading[2], versionPrefix)) {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, errors.Errorf("line 3: invalid migration version %q", f)
+		}
+		s.manifest.Migrations = append(s.manifest.Migrations, v)
+	}
+
+	return &s, nil
+}
+
+// line reads the next line of the script, adding it to the checksum.
+func (s *scriptReader) line() (string, error) {
+	if !s.sc.Scan() {
+		if err := s.sc.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+	s.n++
+
+	l := s.sc.Text()
+	if !strings.HasPrefix(l, checksumPrefix) {
+		s.sum.Write([]byte(l))
+		s.sum.Write([]byte{'\n'})
+	}
+
+	return l, nil
+}
+
+// Next returns the next statement of the script, io.EOF once its trailer was read and
+// found to match the statements. A script that ends early, holds anything but the
+// statements of a backup, or doesn't add up to its checksum is rejected.
+func (s *scriptReader) Next() (string, error) {
+	l, err := s.line()
+	if err == io.ErrUnexpectedEOF {
+		return "", errors.New("the backup ends before its trailer, it was cut short")
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "read backup")
+	}
+
+	if strings.HasPrefix(l, rowsPrefix) {
+		return "", s.trailer(l)
+	}
+
+	for _, prefix := range statements {
+		if strings.HasPrefix(l, prefix) {
+			return l, nil
+		}
+	}
+
+	return "", errors.Errorf("line %d: unexpected statement %.40q", s.n, l)
+}
+
+// trailer reads the trailer of the script starting with the rows line l, returning
+// io.EOF if the checksum matches.
+func (s *scriptReader) trailer(l string) error {
+	rows := make(map[string]int)
+	for _, pair := range strings.Fields(strings.TrimPrefix(l, rowsPrefix)) {
+		i := strings.LastIndexByte(pair, '=')
+		n, err := strconv.Atoi(pair[i+1:])
+		if i < 1 || err != nil {
+			return errors.Errorf("line %d: invalid row count %q", s.n, pair)
+		}
+		rows[pair[:i]] = n
+	}
+	s.manifest.Rows = rows
+
+	sum := hex.EncodeToString(s.sum.Sum(nil))
+
+	l, err := s.line()
+	if err != nil || !strings.HasPrefix(l, checksumPrefix) {
+		return errors.New("the backup ends before its checksum, it was cut short")
+	}
+
+	if got := strings.TrimPrefix(l, checksumPrefix); got != sum {
+		return errors.Errorf("the backup is corrupt, its checksum is %s but its contents sum up to %s", got, sum)
+	}
+
+	if s.sc.Scan() {
+		return errors.Errorf("line %d: unexpected content after the checksum", s.n+1)
+	}
+
+	return io.EOF
+}
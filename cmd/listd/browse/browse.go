@@ -0,0 +1,106 @@
+package browse
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// Table is a table that can be browsed, along with the columns its rows can be filtered
+// by.
+type Table struct {
+	Name    string
+	Key     string
+	Filters []string
+}
+
+// Tables are the tables that can be browsed by the name they are browsed under.
+var Tables = map[string]Table{
+	"lists": {Name: "list", Key: "list_id", Filters: []string{"list_id", "name"}},
+	"items": {Name: "item", Key: "item_id", Filters: []string{"item_id", "list_id", "name", "unit"}},
+	"jobs":  {Name: "job", Key: "job_id", Filters: []string{"job_id", "kind", "status"}},
+}
+
+// Row is a row of a table as stored, keyed by column.
+type Row map[string]interface{}
+
+// Filterable reports whether the rows of t can be filtered by column.
+func (t Table) Filterable(column string) bool {
+	for _, f := range t.Filters {
+		if f == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectPage selects up to limit rows of t whose columns equal the values of filters,
+// ordered by the key of t and skipping the first offset rows, along with the total
+// amount of rows matching the filters. Every column of filters has to be filterable.
+func SelectPage(dbc db.Executor, t Table, filters map[string]string, limit, offset int) ([]Row, int, error) {
+	columns := make([]string, 0, len(filters))
+	for column := range filters {
+		if !t.Filterable(column) {
+			return nil, 0, errors.Errorf("column %q of table %s is not filterable", column, t.Name)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var where string
+	args := make([]interface{}, 0, len(columns)+2)
+
+	if len(columns) > 0 {
+		conds := make([]string, len(columns))
+		for i, column := range columns {
+			conds[i] = fmt.Sprintf(filter, column, i+1)
+			args = append(args, filters[column])
+		}
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := db.Get(dbc, &total, fmt.Sprintf(count, t.Name, where), args...); err != nil {
+		return nil, 0, errors.Wrapf(err, "count rows in %s table", t.Name)
+	}
+
+	query := fmt.Sprintf(selectPage, t.Name, where, t.Key, len(args)+1, len(args)+2)
+
+	rows, err := dbc.Queryx(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "select page of rows from %s table", t.Name)
+	}
+	defer rows.Close()
+
+	page := make([]Row, 0)
+	for rows.Next() {
+		row := make(Row)
+		if err := rows.MapScan(row); err != nil {
+			return nil, 0, errors.Wrapf(err, "scan row of %s table", t.Name)
+		}
+
+		// JSON columns are scanned as bytes, they are kept as JSON.
+		for column, v := range row {
+			if b, ok := v.([]byte); ok {
+				if json.Valid(b) {
+					row[column] = json.RawMessage(b)
+				} else {
+					row[column] = string(b)
+				}
+			}
+		}
+
+		page = append(page, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Wrapf(err, "select page of rows from %s table", t.Name)
+	}
+
+	return page, total, nil
+}
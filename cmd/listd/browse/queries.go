@@ -0,0 +1,19 @@
+package browse
+
+// PostgreSQL queries for the browsable tables, all used in the browse package. Table and
+// column names are only ever taken from Tables, filter values are passed as arguments.
+const (
+	// selectPage is the format of a query that selects a page of rows from a table, given
+	// the table, the WHERE clause of its filters, the key column the rows are ordered by,
+	// and the placeholders of the limit and offset of the page.
+	selectPage = "SELECT * FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d;"
+
+	// count is the format of a query that counts the rows in a table, given the table and
+	// the WHERE clause of its filters.
+	count = "SELECT count(*) FROM %s%s;"
+
+	// filter is the format of the condition of a filter, given its column and the
+	// placeholder of its value. Columns are compared as text so that any value can be
+	// given without failing the query.
+	filter = "%s::text = $%d"
+)
@@ -0,0 +1,92 @@
+// Package deadletter stores the notifications the webhook gave up on posting, so that
+// operators can list them and post them again through the admin endpoints.
+package deadletter
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/notify"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// DeadLetter is a notification that could not be posted.
+type DeadLetter struct {
+	ID        int             `db:"dead_letter_id" json:"id"`
+	EventID   string          `db:"event_id" json:"eventID"`
+	EventType string          `db:"event_type" json:"eventType"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Attempts  int             `db:"attempts" json:"attempts"`
+	LastError string          `db:"last_error" json:"lastError"`
+	Failed    time.Time       `db:"failed" json:"failed"`
+}
+
+// Store is a notify.DeadLetters keeping the notifications in the database.
+type Store struct {
+	DB *sqlx.DB
+}
+
+// Add implements the notify.DeadLetters interface.
+func (s Store) Add(d notify.Delivery) error {
+	return Insert(s.DB, d)
+}
+
+// Insert inserts a row into the webhook_dead_letter table for the given delivery.
+func Insert(dbc db.Executor, d notify.Delivery) error {
+	if _, err := dbc.Exec(insert, d.EventID, d.EventType, d.Payload, d.Attempts, d.Error); err != nil {
+		return errors.Wrap(err, "insert webhook_dead_letter row")
+	}
+
+	return nil
+}
+
+// SelectPage selects up to limit rows of the webhook_dead_letter table, the most
+// recently failed first and skipping the first offset rows, along with the total amount
+// of rows.
+func SelectPage(dbc db.Executor, limit, offset int) ([]DeadLetter, int, error) {
+	var total int
+	if err := db.Get(dbc, &total, count); err != nil {
+		return nil, 0, errors.Wrap(err, "count rows in webhook_dead_letter table")
+	}
+
+	letters := make([]DeadLetter, 0)
+
+	if err := db.Select(dbc, &letters, selectPage, limit, offset); err != nil {
+		return nil, 0, errors.Wrap(err, "select page of rows from webhook_dead_letter table")
+	}
+
+	return letters, total, nil
+}
+
+// Select selects a single row from the webhook_dead_letter table based off of a given
+// dead_letter_id.
+func Select(dbc db.Executor, id int) (DeadLetter, error) {
+	var d DeadLetter
+	if err := db.Get(dbc, &d, selectByID, id); err != nil {
+		return DeadLetter{}, errors.Wrap(err, "select row from webhook_dead_letter table")
+	}
+
+	return d, nil
+}
+
+// RecordAttempt counts another failed attempt at posting the dead letter with the given
+// id, which failed with err, and returns the updated dead letter.
+func RecordAttempt(dbc db.Executor, id int, err error) (DeadLetter, error) {
+	var d DeadLetter
+	if err := dbc.QueryRowx(recordAttempt, id, err.Error()).StructScan(&d); err != nil {
+		return DeadLetter{}, errors.Wrap(err, "update webhook_dead_letter row")
+	}
+
+	return d, nil
+}
+
+// Delete deletes the row of the webhook_dead_letter table with the given dead_letter_id.
+func Delete(dbc db.Executor, id int) error {
+	if _, err := dbc.Exec(deleteByID, id); err != nil {
+		return errors.Wrap(err, "delete webhook_dead_letter row")
+	}
+
+	return nil
+}
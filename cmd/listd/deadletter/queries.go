@@ -0,0 +1,35 @@
+package deadletter
+
+// PostgreSQL queries for the webhook_dead_letter table, all used in the deadletter
+// package.
+const (
+	// insert is a query that inserts a new row in the webhook_dead_letter table using the
+	// values given in order for event_id, event_type, payload, attempts, and last_error.
+	insert = `INSERT INTO webhook_dead_letter (event_id, event_type, payload, attempts, last_error)
+		VALUES ($1, $2, $3, $4, $5);`
+
+	// count is a query that counts the rows of the webhook_dead_letter table.
+	count = "SELECT COUNT(*) FROM webhook_dead_letter;"
+
+	// selectPage is a query that selects the given amount of rows of the
+	// webhook_dead_letter table, the most recently failed first, skipping the given
+	// offset.
+	selectPage = `SELECT dead_letter_id, event_id, event_type, payload, attempts, last_error, failed
+		FROM webhook_dead_letter ORDER BY failed DESC, dead_letter_id DESC LIMIT $1 OFFSET $2;`
+
+	// selectByID is a query that selects a row from the webhook_dead_letter table based
+	// off of the given dead_letter_id.
+	selectByID = `SELECT dead_letter_id, event_id, event_type, payload, attempts, last_error, failed
+		FROM webhook_dead_letter WHERE dead_letter_id = $1;`
+
+	// recordAttempt is a query that counts another failed attempt of the row of the
+	// webhook_dead_letter table with the given dead_letter_id, storing its error, and
+	// returns the updated row.
+	recordAttempt = `UPDATE webhook_dead_letter SET attempts = attempts + 1, last_error = $2, failed = NOW()
+		WHERE dead_letter_id = $1
+		RETURNING dead_letter_id, event_id, event_type, payload, attempts, last_error, failed;`
+
+	// deleteByID is a query that deletes the row of the webhook_dead_letter table with
+	// the given dead_letter_id.
+	deleteByID = "DELETE FROM webhook_dead_letter WHERE dead_letter_id = $1;"
+)
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/pkg/errors"
+)
+
+// export writes every list along with its items as a JSON document to stdout, or to
+// the file given by -out. With -anonymize the names are scrubbed so the export can be
+// shared safely.
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("out", "", "file to write the export to instead of stdout")
+	anon := fs.Bool("anonymize", false, "replace every list and item name with a deterministic fake")
+	salt := fs.String("anonymize-salt", "", "salt mixed into the fakes of -anonymize, the same salt yields the same fakes")
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	doc, err := exporter.Export(context.Background(), dbc, nil)
+	if err != nil {
+		return err
+	}
+
+	if *anon {
+		anonymize(&doc, *salt)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return errors.Wrap(err, "create export file")
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return errors.Wrap(err, "write export")
+	}
+
+	logger.WithField("lists", len(doc.Lists)).Info("exported lists")
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/buildinfo"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Document is the format of an export of every list.
+type Document struct {
+	Exported time.Time `json:"exported"`
+	Version  string    `json:"version"`
+	Lists    []List    `json:"lists"`
+}
+
+// List is a list along with all of its items.
+type List struct {
+	list.List
+	Items []item.Item `json:"items"`
+}
+
+// Export reads every list along with its items from the database. progress, if not nil,
+// is called after the items of each list are read with the amount of lists done so far.
+//
+// The lists are read within a read-only transaction bound to ctx, so the export is a
+// consistent snapshot and stops as soon as ctx is done, such as when the client that
+// asked for it goes away, releasing its connection right away.
+func Export(ctx context.Context, dbc *sqlx.DB, progress func(done, total int)) (Document, error) {
+	tx, err := dbc.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return Document{}, errors.Wrap(err, "begin transaction")
+	}
+
+	// Nothing is written, the transaction is only ever rolled back.
+	defer tx.Rollback()
+
+	lists, err := list.SelectLists(tx)
+	if err != nil {
+		return Document{}, errors.Wrap(err, "select lists")
+	}
+
+	doc := Document{
+		Exported: time.Now().UTC(),
+		Version:  buildinfo.Get().Version,
+		Lists:    make([]List, 0, len(lists)),
+	}
+
+	for i, l := range lists {
+		if err := ctx.Err(); err != nil {
+			return Document{}, errors.Wrapf(err, "export stopped after %d of %d lists", i, len(lists))
+		}
+
+		items, err := item.SelectItems(tx, l.ID)
+		if err != nil {
+			return Document{}, errors.Wrapf(err, "select items of list %d", l.ID)
+		}
+
+		doc.Lists = append(doc.Lists, List{
+			List:  l,
+			Items: items,
+		})
+
+		if progress != nil {
+			progress(i+1, len(lists))
+		}
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// check is a consistency check ran by the fsck command. The query selects a single
+// text column describing each inconsistent row, no rows means the check passed.
+type check struct {
+	name  string
+	query string
+}
+
+// checks contains every consistency check ran by the fsck command.
+var checks = []check{
+	{
+		name: "items without a list",
+		query: `SELECT 'item ' || i.item_id || ' references missing list ' || i.list_id
+			FROM item i LEFT JOIN list l ON l.list_id = i.list_id
+			WHERE l.list_id IS NULL ORDER BY i.item_id;`,
+	},
+	{
+		name: "items with a non-positive quantity",
+		query: `SELECT 'item ' || item_id || ' in list ' || list_id || ' has quantity ' || quantity
+			FROM item WHERE quantity <= 0 ORDER BY item_id;`,
+	},
+	{
+		name: "blank names",
+		query: `SELECT 'list ' || list_id || ' has a blank name' FROM list WHERE trim(name) = ''
+			UNION ALL
+			SELECT 'item ' || item_id || ' has a blank name' FROM item WHERE trim(name) = '';`,
+	},
+	{
+		name: "modified before created",
+		query: `SELECT 'list ' || list_id || ' was modified before it was created' FROM list WHERE modified < created
+			UNION ALL
+			SELECT 'item ' || item_id || ' was modified before it was created' FROM item WHERE modified < created;`,
+	},
+	{
+		name: "item counts that drifted",
+		query: `SELECT 'list ' || l.list_id || ' counts ' || l.item_count || ' items but has ' || count(i.item_id)
+			FROM list l LEFT JOIN item i ON i.list_id = l.list_id
+			GROUP BY l.list_id HAVING l.item_count <> count(i.item_id) ORDER BY l.list_id;`,
+	},
+}
+
+// fsck runs every consistency check against the database, printing each problem that
+// was found to stdout. An error is returned if any problem was found so the exit code
+// can be used in scripts.
+func fsck(args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	problems, err := runChecks(dbc, logger, func(c check, problem string) {
+		fmt.Fprintf(os.Stdout, "%s: %s\n", c.name, problem)
+	})
+	if err != nil {
+		return err
+	}
+
+	if problems > 0 {
+		return errors.Errorf("found %d problem(s)", problems)
+	}
+
+	logger.Info("no problems found")
+
+	return nil
+}
+
+// runChecks runs every check in checks, passing each problem found to report, and
+// returns the total amount of problems found.
+func runChecks(dbc *sqlx.DB, logger log.FieldLogger, report func(c check, problem string)) (int, error) {
+	var total int
+
+	for _, c := range checks {
+		var problems []string
+		if err := dbc.Select(&problems, c.query); err != nil {
+			return 0, errors.Wrapf(err, "check %s", c.name)
+		}
+
+		logger.WithFields(log.Fields{
+			"check":    c.name,
+			"problems": len(problems),
+		}).Debug("ran check")
+
+		for _, p := range problems {
+			report(c, p)
+		}
+
+		total += len(problems)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/attachment"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/storage"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// maxAttachmentSize is the maximum size of an uploaded attachment in bytes, which is
+// larger than the limit of other request bodies.
+const maxAttachmentSize = 10 << 20
+
+// defaultAttachmentURLTTL is how long the download URLs of attachments are valid for
+// when AttachmentURLTTL is 0.
+const defaultAttachmentURLTTL = 15 * time.Minute
+
+// attachmentPath returns the path of the attachment named name of the item given by
+// itemID of the list given by listID.
+func attachmentPath(listID, itemID int, name string) string {
+	return fmt.Sprintf("%s/attachment/%s", itemPath(listID, itemID), url.PathEscape(name))
+}
+
+// signedAttachment is an attachment along with the URL its file can be downloaded from
+// until it expires.
+type signedAttachment struct {
+	attachment.Attachment
+	DownloadURL string    `json:"downloadURL"`
+	Expires     time.Time `json:"downloadURLExpires"`
+}
+
+// writtenAttachment is the response to a request that attached a file to an item.
+type writtenAttachment struct {
+	signedAttachment
+	URL string `json:"url"`
+}
+
+// attachmentsMW is a middleware that answers the requests of the attachment routes with
+// 404 Not Found unless the attachments feature is enabled and a Storage is configured.
+func (a *Application) attachmentsMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if a.Storage == nil || !a.Features.Enabled(features.Attachments) {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// attachmentParams returns the list and item ids and the attachment name given by the
+// path of r. The name is empty for the route of all attachments of an item.
+func attachmentParams(r *http.Request) (listID, itemID int, name string, err error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	if listID, err = strconv.Atoi(params.ByName("lid")); err != nil {
+		return 0, 0, "", errors.Wrap(err, "convert list id to integer")
+	}
+
+	if itemID, err = strconv.Atoi(params.ByName("iid")); err != nil {
+		return 0, 0, "", errors.Wrap(err, "convert item id to integer")
+	}
+
+	return listID, itemID, params.ByName("name"), nil
+}
+
+// sign returns att along with a URL its file can be downloaded from. URLs relative to
+// the daemon, those of a storage.Local, are made absolute on the host r was made to.
+func (a *Application) sign(r *http.Request, att attachment.Attachment) (signedAttachment, error) {
+	ttl := a.AttachmentURLTTL
+	if ttl == 0 {
+		ttl = defaultAttachmentURLTTL
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+
+	u, err := a.Storage.SignedURL(att.Key, att.Name, expires)
+	if err != nil {
+		return signedAttachment{}, errors.Wrap(err, "sign download url")
+	}
+
+	if strings.HasPrefix(u, "/") {
+		u = web.BaseURL(r) + u
+	}
+
+	return signedAttachment{Attachment: att, DownloadURL: u, Expires: expires}, nil
+}
+
+// getAttachments is a handler that returns every attachment of an item, each along with
+// a URL it can be downloaded from.
+func (a *Application) getAttachments(w http.ResponseWriter, r *http.Request) {
+	listID, itemID, _, err := attachmentParams(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	attachments, err := attachment.SelectAttachments(a.database(r.Context()), itemID, listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select attachment rows"))
+		return
+	}
+
+	signed := make([]signedAttachment, 0, len(attachments))
+	for _, att := range attachments {
+		s, err := a.sign(r, att)
+		if err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		signed = append(signed, s)
+	}
+
+	web.Respond(w, r, http.StatusOK, signed)
+}
+
+// getAttachment is a handler that returns a single attachment of an item along with a
+// URL it can be downloaded from.
+func (a *Application) getAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, itemID, name, err := attachmentParams(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	att, err := attachment.SelectAttachment(a.database(r.Context()), name, itemID, listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select attachment row"))
+		return
+	}
+
+	signed, err := a.sign(r, att)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, signed)
+}
+
+// getAttachmentContent is a handler that responds with the file of an attachment,
+// read from the Storage by the daemon, for clients that can't reach the storage the
+// download URLs point to.
+func (a *Application) getAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	listID, itemID, name, err := attachmentParams(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	att, err := attachment.SelectAttachment(a.database(r.Context()), name, itemID, listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select attachment row"))
+		return
+	}
+
+	f, err := a.Storage.Open(r.Context(), att.Key)
+	if err != nil {
+		if errors.Cause(err) == storage.ErrNotFound {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "open attachment file"))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(att.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", att.Name))
+
+	if _, err := io.Copy(w, f); err != nil {
+		web.Logger(r.Context()).WithError(err).Warn("write attachment file")
+	}
+}
+
+// putAttachment is a handler that attaches the file given by the request body to an
+// item under the name given by the path, keeping the Content-Type of the request. An
+// attachment of the item with the same name has its file replaced. The file is stored
+// under a new key before the row is written and deleted again if that fails, so rows
+// never refer to missing files, and the replaced file is deleted once the change is
+// committed, see afterCommit. Dry runs don't store the file.
+func (a *Application) putAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, itemID, name, err := attachmentParams(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	name, err = a.Names.Normalize("name", name)
+	if err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if name == "" {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	att := attachment.Attachment{
+		ItemID:      itemID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(body)),
+		Key:         attachment.NewKey(),
+	}
+
+	dryRun := web.DryRun(r.Context())
+	if !dryRun {
+		if err := a.Storage.Put(r.Context(), att.Key, bytes.NewReader(body), att.Size, att.ContentType); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "store attachment file"))
+			return
+		}
+	}
+
+	inserted, replacedKey, err := a.writeAttachment(r, listID, &att)
+	if err != nil {
+		if !dryRun {
+			if err := a.Storage.Delete(r.Context(), att.Key); err != nil {
+				web.Logger(r.Context()).WithError(err).WithField("key", att.Key).Error("delete file of attachment that wasn't written")
+			}
+		}
+
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "write row of attachment table"))
+		return
+	}
+
+	if !dryRun && replacedKey != "" {
+		a.afterCommit(r.Context(), func() {
+			if err := a.Storage.Delete(r.Context(), replacedKey); err != nil {
+				web.Logger(r.Context()).WithError(err).WithField("key", replacedKey).Error("delete replaced file of attachment")
+			}
+		})
+	}
+
+	signed, err := a.sign(r, att)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	path := attachmentPath(listID, itemID, att.Name)
+	res := writtenAttachment{signedAttachment: signed, URL: resourceURL(r, path)}
+
+	if !inserted {
+		web.Respond(w, r, http.StatusOK, res)
+		return
+	}
+
+	w.Header().Set("Location", path)
+	web.Respond(w, r, http.StatusCreated, res)
+}
+
+// writeAttachment inserts or replaces the row of att, attached to an item of the list
+// given by listID, and commits it. It reports whether the row was inserted and returns
+// the key of the file it replaced otherwise.
+func (a *Application) writeAttachment(r *http.Request, listID int, att *attachment.Attachment) (bool, string, error) {
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		return false, "", errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	var (
+		inserted    bool
+		replacedKey string
+	)
+
+	if *att, inserted, replacedKey, err = attachment.PutAttachment(tx, listID, *att); err != nil {
+		return false, "", err
+	}
+
+	return inserted, replacedKey, errors.Wrap(a.commit(r.Context(), tx), "commit transaction")
+}
+
+// deleteAttachment is a handler that deletes an attachment of an item. Its file is
+// deleted once the deletion of its row is committed, see afterCommit, a file that can't
+// be deleted is left behind and logged.
+func (a *Application) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	listID, itemID, name, err := attachmentParams(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	att, err := attachment.DeleteAttachment(tx, name, itemID, listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "delete attachment row"))
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	a.deleteFiles(r.Context(), []string{att.Key})
+
+	if web.WantsRepresentation(w, r) {
+		web.Respond(w, r, http.StatusOK, att)
+		return
+	}
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// deleteFiles deletes the files stored under keys, those of deleted attachments, once the
+// deletion is committed, see afterCommit. Files that can't be deleted are left behind and
+// logged. Dry runs delete nothing.
+func (a *Application) deleteFiles(ctx context.Context, keys []string) {
+	if len(keys) == 0 || web.DryRun(ctx) {
+		return
+	}
+
+	a.afterCommit(ctx, func() {
+		for _, key := range keys {
+			if err := a.Storage.Delete(ctx, key); err != nil {
+				web.Logger(ctx).WithError(err).WithField("key", key).Error("delete file of deleted attachment")
+			}
+		}
+	})
+}
+
+// getFile is a handler serving the files of a storage.Local through the download URLs
+// it signed, which are checked by the storage. Other storages serve their files
+// themselves, so the route answers with 404 Not Found for them.
+func (a *Application) getFile(w http.ResponseWriter, r *http.Request) {
+	local, ok := a.Storage.(*storage.Local)
+	if !ok {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	key := strings.TrimPrefix(httprouter.ParamsFromContext(r.Context()).ByName("key"), "/")
+
+	switch err := local.Serve(w, r, key); errors.Cause(err) {
+	case nil:
+	case storage.ErrURLInvalid:
+		web.RespondError(w, r, http.StatusForbidden, web.NewError(codes.DownloadURLInvalid))
+	case storage.ErrNotFound:
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+	default:
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "serve attachment file"))
+	}
+}
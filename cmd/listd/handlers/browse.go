@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/browse"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// browseTable is a handler that returns a page of the raw rows of one of the browse.Tables,
+// for debugging data issues. Query parameters other than the page and pretty filter the
+// rows by the column they name, such as ?list_id=1.
+func (a *Application) browseTable(w http.ResponseWriter, r *http.Request) {
+	name := httprouter.ParamsFromContext(r.Context()).ByName("table")
+
+	t, ok := browse.Tables[name]
+	if !ok {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	filters := make(map[string]string)
+	for column, values := range r.URL.Query() {
+		switch column {
+		case "limit", "offset", "pretty":
+			continue
+		}
+
+		if !t.Filterable(column) {
+			web.RespondError(w, r, http.StatusBadRequest, web.NewFieldError(column, codes.FilterInvalid, name, strings.Join(t.Filters, ", "), column))
+			return
+		}
+
+		filters[column] = values[0]
+	}
+
+	rows, total, err := browse.SelectPage(a.database(r.Context()), t, filters, page.Limit, page.Offset)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrapf(err, "select page of %s", name))
+		return
+	}
+
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, rows, page)
+}
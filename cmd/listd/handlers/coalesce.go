@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/coalesce"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/pkg/errors"
+)
+
+// createCoalescedItem creates i along with the items other requests create in the same
+// list within the CoalesceWindow, using a single insert and transaction for all of them,
+// see flushItems. Errors are those of item.CreateItem, except for items whose name is
+// taken, which fail with item.ErrNameTaken.
+func (a *Application) createCoalescedItem(i item.Item) (item.Item, error) {
+	a.coalesceOnce.Do(func() {
+		a.coalescer = coalesce.New(a.CoalesceWindow, maxBatchSize, a.flushItems)
+	})
+
+	created, err := a.coalescer.Submit(strconv.Itoa(i.ListID), i)
+	if err != nil {
+		return item.Item{}, err
+	}
+
+	return created.(item.Item), nil
+}
+
+// flushItems creates the items of values, which belong to the same list, and records
+// their events within a single transaction. It is the coalesce.Flush of
+// createCoalescedItem. An error that keeps every item from being created is returned
+// for each of them.
+func (a *Application) flushItems(_ string, values []interface{}) ([]interface{}, []error) {
+	results := make([]interface{}, len(values))
+	errs := make([]error, len(values))
+
+	items := make([]item.Item, len(values))
+	for i, v := range values {
+		items[i] = v.(item.Item)
+	}
+
+	fail := func(err error) ([]interface{}, []error) {
+		for i := range errs {
+			errs[i] = err
+		}
+
+		return results, errs
+	}
+
+	// The transaction isn't bound to any of the requests, it serves all of them.
+	ctx := context.Background()
+
+	tx, err := a.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fail(errors.Wrap(err, "begin transaction"))
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	created, itemErrs, err := item.CreateItems(tx, items)
+	if err != nil {
+		return fail(err)
+	}
+
+	for i, c := range created {
+		if itemErrs[i] != nil {
+			errs[i] = itemErrs[i]
+			continue
+		}
+
+		if err := record(tx, events.ItemCreated, c.ListID, c); err != nil {
+			return fail(err)
+		}
+
+		results[i] = c
+	}
+
+	if err := a.commit(ctx, tx); err != nil {
+		return fail(errors.Wrap(err, "commit transaction"))
+	}
+
+	return results, errs
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/deadletter"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// getDeadLetters is a handler that returns a page of the notifications the Webhook gave
+// up on posting, the most recently failed first.
+func (a *Application) getDeadLetters(w http.ResponseWriter, r *http.Request) {
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	letters, total, err := deadletter.SelectPage(a.database(r.Context()), page.Limit, page.Offset)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select page of dead letters"))
+		return
+	}
+
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, letters, page)
+}
+
+// retryDeadLetter is a handler that posts the dead letter given by the id URL parameter
+// to the Webhook again, deleting it once the webhook accepts it. A failed post is counted
+// against the dead letter, which is returned along with the error.
+func (a *Application) retryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert dead letter id to integer"))
+		return
+	}
+
+	d, err := deadletter.Select(a.database(r.Context()), id)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select dead letter by id"))
+		return
+	}
+
+	if a.Webhook == nil {
+		web.RespondError(w, r, http.StatusConflict, web.NewError(codes.WebhookDisabled))
+		return
+	}
+
+	if err := a.Webhook.Redeliver(r.Context(), d.Payload); err != nil {
+		updated, uerr := deadletter.RecordAttempt(a.database(r.Context()), id, err)
+		if uerr != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(uerr, "record attempt of dead letter"))
+			return
+		}
+
+		web.Respond(w, r, http.StatusBadGateway, updated, web.NewError(codes.RedeliveryFailed, err))
+		return
+	}
+
+	if err := deadletter.Delete(a.database(r.Context()), id); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "delete dead letter"))
+		return
+	}
+
+	web.Logger(r.Context()).WithField("deadLetterID", id).Info("redelivered dead letter")
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
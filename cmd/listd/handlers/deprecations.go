@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// deprecations are the fields of responses that are deprecated, which NewApplication
+// announces by default, see web.Deprecation. A field is removed once its sunset passed
+// and its deprecation along with it.
+var deprecations = []web.Deprecation{
+	{
+		// The item count of lists was the only field not named in camel case.
+		Field:       "item_count",
+		Replacement: "itemCount",
+		Deprecated:  time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+// deprecationsMW is a middleware that makes responses announce the Deprecations of the
+// fields they use.
+func (a *Application) deprecationsMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithDeprecations(r, a.Deprecations))
+	}
+	return http.HandlerFunc(f)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPI is the OpenAPI 3 document describing every public route. It is maintained by
+// hand and kept in sync with the registered routes by Test_openAPI.
+//
+//go:embed docs/openapi.json
+var openAPI []byte
+
+// publicReadOpenAPI is the OpenAPI 3 document describing the public read API, see
+// PublicRead. It is kept in sync with its routes by Test_publicReadOpenAPI.
+//
+//go:embed docs/public-read.json
+var publicReadOpenAPI []byte
+
+// docsPage is the Swagger UI page rendering openAPI.
+//
+//go:embed docs/index.html
+var docsPage []byte
+
+// OpenAPI returns the OpenAPI 3 document describing every public route.
+func OpenAPI() []byte {
+	return openAPI
+}
+
+// getOpenAPI is a handler that returns the OpenAPI document of the API.
+func (a *Application) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+// getDocs is a handler that returns a Swagger UI page for the OpenAPI document.
+func (a *Application) getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docsPage)
+}
+
+// getPublicReadOpenAPI is a handler that returns the OpenAPI document of the public read
+// API.
+func (a *Application) getPublicReadOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(publicReadOpenAPI)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/pkg/errors"
+)
+
+// VerifySchema compares the schema of the database to the one its migrations built, see
+// db.Verify, and logs every difference. While the schema drifted write requests are
+// refused if RefuseWritesOnDrift is set, until it is verified again.
+func (a *Application) VerifySchema() (db.Verification, error) {
+	v, err := db.Verify(a.DB)
+	if err != nil {
+		return db.Verification{}, errors.Wrap(err, "verify schema")
+	}
+
+	a.driftMu.Lock()
+	a.drift = v
+	a.driftMu.Unlock()
+
+	for _, d := range v.Differences {
+		a.Log.WithField("difference", d).Warn("database schema drifted")
+	}
+
+	return v, nil
+}
+
+// driftMW is a middleware that rejects requests to the write endpoints with a 503 while
+// RefuseWritesOnDrift is set and the schema drifted when it was last verified. Reads are
+// always let through.
+func (a *Application) driftMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		a.driftMu.RLock()
+		drifted := a.drift.Drifted
+		a.driftMu.RUnlock()
+
+		if a.RefuseWritesOnDrift && drifted {
+			web.RespondError(w, r, http.StatusServiceUnavailable, web.NewError(codes.SchemaDrifted))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// verifySchema is a handler that verifies the schema of the database again and returns
+// every difference to the one its migrations built. Writes refused because the schema
+// drifted are let through again once it no longer does.
+func (a *Application) verifySchema(w http.ResponseWriter, r *http.Request) {
+	v, err := a.VerifySchema()
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, v)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// itemUnit returns the enum of the units item quantities can be given in, which are
+// configured by Units. Items without a unit are always accepted.
+func (a *Application) itemUnit() web.Enum {
+	return web.NewEnum("itemUnit", codes.UnitInvalid, true, a.Units...)
+}
+
+// enums returns every enum of the fields of request payloads, ordered by name.
+func (a *Application) enums() []web.Enum {
+	return []web.Enum{a.itemUnit(), settingsSort}
+}
+
+// getEnums is a handler that returns the values every enum field of request payloads can
+// take, so clients can build their forms from them rather than hard-code them.
+func (a *Application) getEnums(w http.ResponseWriter, r *http.Request) {
+	web.Respond(w, r, http.StatusOK, a.enums())
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/pkg/errors"
+)
+
+// exportLists is a handler that responds with every list along with its items, in the
+// format of the export command. The export is made in the background when the client
+// asks for it, see startJob, and stops otherwise as soon as the client goes away.
+func (a *Application) exportLists(w http.ResponseWriter, r *http.Request) {
+	if a.async(w, r) {
+		a.startJob(w, r, "export", func(ctx context.Context, progress progressFunc) (interface{}, error) {
+			return exporter.Export(ctx, a.DB, progress)
+		})
+		return
+	}
+
+	doc, err := exporter.Export(r.Context(), a.pool(r.Context()), nil)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "export lists"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, doc)
+}
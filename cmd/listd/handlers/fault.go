@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/pkg/errors"
+)
+
+// faultMW is a middleware that injects the fault of the Faults matching the route with
+// the given method and path pattern, if any, while the fault injection feature is
+// enabled. Requests are delayed by its latency and then failed with a 503 at its error
+// rate, which clients are expected to retry.
+func (a *Application) faultMW(method, path string, next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		fault, ok := a.Faults.Match(method, path)
+		if !ok || !a.Features.Enabled(features.FaultInjection) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fault.Latency > 0 {
+			t := time.NewTimer(fault.Latency)
+			defer t.Stop()
+
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				web.RespondError(w, r, http.StatusServiceUnavailable, errors.Wrap(r.Context().Err(), "inject latency"))
+				return
+			}
+		}
+
+		if rand.Float64() < fault.ErrorRate {
+			web.RespondError(w, r, http.StatusServiceUnavailable, web.NewError(codes.FaultInjected))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// getFeatures is a handler that returns every feature flag along with its state.
+func (a *Application) getFeatures(w http.ResponseWriter, r *http.Request) {
+	web.Respond(w, r, http.StatusOK, a.Features.List())
+}
+
+// setFeature is a handler that turns a runtime togglable feature flag on or off.
+func (a *Application) setFeature(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Enabled *bool `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.PayloadInvalid, err))
+		return
+	}
+
+	if payload.Enabled == nil {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.EnabledRequired))
+		return
+	}
+
+	f, err := a.Features.Set(httprouter.ParamsFromContext(r.Context()).ByName("name"), *payload.Enabled)
+	if err != nil {
+		switch errors.Cause(err) {
+		case features.ErrUnknown:
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		case features.ErrNotRuntime:
+			web.RespondError(w, r, http.StatusConflict, web.NewError(codes.FeatureNotRuntime))
+		default:
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "set feature flag"))
+		}
+		return
+	}
+
+	web.Logger(r.Context()).WithField("feature", f.Name).WithField("enabled", f.Enabled).Info("toggled feature flag")
+
+	web.Respond(w, r, http.StatusOK, f)
+}
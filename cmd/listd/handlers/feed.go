@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// feedEntries is the maximum amount of entries in an activity feed.
+const feedEntries = 50
+
+// atomFeed is the XML representation of an Atom feed as defined by RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomAuthor is the XML representation of the author of an Atom feed.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomLink is the XML representation of a link of an Atom feed.
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomEntry is the XML representation of an entry of an Atom feed.
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+// atomContent is the XML representation of the content of an Atom entry.
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// getListFeed is a handler that returns the recent changes made to a list and its items
+// as an Atom feed, newest first. The changes are read from the outbox, so they are
+// available for as long as the outbox retains them.
+func (a *Application) getListFeed(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	l, err := list.SelectList(a.database(r.Context()), listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by id"))
+		return
+	}
+
+	evts, err := outbox.SelectByList(a.database(r.Context()), listID, feedEntries)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list events"))
+		return
+	}
+
+	feed := atomFeed{
+		ID:      fmt.Sprintf("urn:listd:list:%d", l.ID),
+		Title:   l.Name,
+		Updated: l.Modified.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "listd"},
+		Link: atomLink{
+			Rel:  "self",
+			Href: resourceURL(r, listPath(l.ID)+"/feed.atom"),
+		},
+	}
+
+	// Events are sorted newest first, the feed was last updated by the first one.
+	if len(evts) > 0 {
+		feed.Updated = evts[0].Time.Format(time.RFC3339)
+	}
+
+	for _, e := range evts {
+		title := describe(e)
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      "urn:uuid:" + e.ID,
+			Title:   title,
+			Updated: e.Time.Format(time.RFC3339),
+			Content: atomContent{Type: "text", Body: title},
+		})
+	}
+
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "marshal atom feed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(b)
+}
+
+// describe returns a human readable description of the change e is about, such as
+// `Item "Milk" created`. Resources are referred to by ID when their name is unknown,
+// which is the case for deletions.
+func describe(e events.Event) string {
+	var data struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// The description falls back to the ID, which is 0 when the data can't be decoded.
+	_ = json.Unmarshal(e.Data, &data)
+
+	kind, action := e.Type, ""
+	if i := strings.Index(e.Type, "."); i != -1 {
+		kind, action = e.Type[:i], e.Type[i+1:]
+	}
+
+	if kind != "" {
+		kind = strings.ToUpper(kind[:1]) + kind[1:]
+	}
+
+	if data.Name != "" {
+		return fmt.Sprintf("%s %q %s", kind, data.Name, action)
+	}
+
+	return fmt.Sprintf("%s #%d %s", kind, data.ID, action)
+}
@@ -1,33 +1,266 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/buildinfo"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/cache"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/coalesce"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/debug"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/dedup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/inflight"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/metrics"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/notify"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/ratelimit"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/storage"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/worker"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
 	"github.com/jmoiron/sqlx"
 	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // Application is the struct that contains the server handler as well as
 // any references to services that the application needs.
 type Application struct {
-	DB      *sqlx.DB
-	handler http.Handler
+	DB       *sqlx.DB
+	Log      logrus.FieldLogger
+	Features *features.Service
+
+	// Units contains the units item quantities can be given in. Items without a unit
+	// are always accepted.
+	Units []string
+
+	// Paging holds the page sizes of paginated collections such as lists and items.
+	Paging web.Paging
+
+	// Names normalizes and checks the names of lists, items, and templates.
+	Names validate.Names
+
+	// Validation is the validation mode, which is reported by /version. In strict mode
+	// payloads with unknown fields are always rejected, otherwise only while the strict
+	// validation feature is enabled. Names are checked as set up by the caller.
+	Validation validate.Mode
+
+	// MaxBodySize is the maximum size of request bodies in bytes, web.DefaultMaxBodySize
+	// when 0. Imports have a limit of their own, see maxImportSize.
+	MaxBodySize int64
+
+	// RequestTimeout is the time requests are given to respond, after which their context
+	// is canceled, rolling back their transaction. 0 disables it. Routes can declare a
+	// timeout of their own, see withTimeout.
+	RequestTimeout time.Duration
+
+	// MaxRequestTimeout is the longest timeout clients may ask for with the
+	// X-Request-Timeout header, see clampTimeout. Clients may always shorten the timeout
+	// of a route, while it is 0 they can't lengthen it.
+	MaxRequestTimeout time.Duration
+
+	// Cache holds the responses of GET requests, nil disables caching. Write handlers
+	// purge it once their changes are committed, see commit.
+	Cache *cache.Cache
+
+	// Duplicates remembers the items created by recent requests, so a request that is
+	// submitted twice, such as by a double-click, gets the item of the first one instead
+	// of a conflict. Nil disables it.
+	Duplicates *dedup.Detector
+
+	// Workers run the imports and exports that clients ask to be made in the background,
+	// nil makes them all synchronous. The caller closes it once the application is no
+	// longer served.
+	Workers *worker.Pool
+
+	// ImportBatchSize is the amount of rows of a spreadsheet imported per transaction,
+	// defaultImportBatchSize when 0. Jobs report their progress once per batch.
+	ImportBatchSize int
+
+	// RewriteTrailingSlash serves paths with a trailing slash like the same path without
+	// it, instead of redirecting to the path without it.
+	RewriteTrailingSlash bool
+
+	// Requests holds the public requests being served, so they can be listed and canceled
+	// through the admin endpoints.
+	Requests *inflight.Registry
+
+	// Proxies are the reverse proxies whose forwarding headers are trusted to name the
+	// client of a request, which is logged along with it.
+	Proxies web.Proxies
+
+	// LogRules decide which public requests are logged and what they are logged along
+	// with, such as their headers and body.
+	LogRules web.LogRules
+
+	// PrettyJSON indents JSON responses unless the client asks for compact ones with
+	// ?pretty=false.
+	PrettyJSON bool
+
+	// Envelope is the envelope JSON responses are written in unless the client asks for
+	// another one through the Accept header.
+	Envelope web.Envelope
+
+	// StringIDs encodes the identifiers in JSON responses as strings, for JavaScript
+	// clients that lose precision on large integers. Requests may give them either way.
+	StringIDs bool
+
+	// Deprecations are the deprecated fields of responses, which responses using them
+	// announce. NewApplication sets them to the fields deprecated by this version.
+	Deprecations []web.Deprecation
+
+	// Faults are injected into the responses of the routes they match while the fault
+	// injection feature is enabled, see faultMW.
+	Faults web.Faults
+
+	// SlowRequest is the duration after which the plan of the slowest query of a request
+	// is logged while the query plans feature is enabled, see planMW. 0 disables it.
+	SlowRequest time.Duration
+
+	// RequestTx serves every write request within a single transaction, which is only
+	// committed when the request succeeds, see txMW.
+	RequestTx bool
+
+	// CoalesceWindow is how long items created in the same list are waited for, so that
+	// bursts of them are inserted at once, see createCoalescedItem. 0 inserts every item
+	// on its own.
+	CoalesceWindow time.Duration
+
+	// ReadOnly refuses every write request while it is set, such as while serving a
+	// replica of the database, see readOnlyMW.
+	ReadOnly web.ReadOnly
+
+	// RefuseWritesOnDrift rejects write requests with a 503 while the schema of the
+	// database drifted from the one its migrations built, as found when it was last
+	// verified, see VerifySchema.
+	RefuseWritesOnDrift bool
+
+	// Signatures verifies the signatures of signed requests, nil ignores signatures. See
+	// signatureMW.
+	Signatures *signing.Verifier
+
+	// RequireSignatures rejects unsigned requests with a 401, but for the routes that are
+	// declared withoutSignature. It takes effect only along with Signatures.
+	RequireSignatures bool
+
+	// Observe is told about every public request once it is served, along with the route
+	// that served it, the status of its response, and how long it took, such as by an
+	// apptest.Recorder reporting on the calls made by a test suite. Nil disables it.
+	Observe func(r *http.Request, route Route, status int, took time.Duration)
+
+	// Storage keeps the files attached to items, which are only served while the
+	// attachments feature is enabled. Nil disables attachments.
+	Storage storage.Storage
+
+	// AttachmentURLTTL is how long the download URLs of attachments are valid for,
+	// defaultAttachmentURLTTL when 0.
+	AttachmentURLTTL time.Duration
+
+	// Webhook posts the notifications of events, nil when notifications are disabled.
+	// Dead letters are posted to it again through the admin endpoints.
+	Webhook *notify.Webhook
+
+	// CORS are the origins whose pages may read the responses of the public read API,
+	// see PublicRead. The zero value allows none.
+	CORS web.CORS
+
+	// PublicReadLimit limits the requests every client makes to the public read API,
+	// nil leaves them unlimited.
+	PublicReadLimit *ratelimit.Limiter
+
+	// SharedLimit limits the requests made with every share token to the public read API,
+	// whoever makes them, nil leaves them unlimited.
+	SharedLimit *ratelimit.Limiter
+
+	// ShareTTL is how long a list is shared for once it is shared, 0 shares it for good.
+	ShareTTL time.Duration
+
+	handler    http.Handler
+	admin      http.Handler
+	publicRead http.Handler
+	routes     []Route
+
+	// publicReadRoutes are the routes of the public read API.
+	publicReadRoutes []Route
+
+	// unsigned are the routes declared withoutSignature.
+	unsigned []Route
+
+	coalesceOnce sync.Once
+	coalescer    *coalesce.Coalescer
+
+	driftMu sync.RWMutex
+	drift   db.Verification
+}
+
+// Route is a method and path pattern the public handler of an Application serves.
+type Route struct {
+	Method string
+	Path   string
 }
 
 // ServeHTTP implements the http.Handler interface for the Application type.
 func (a *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.Observe != nil {
+		a.observe(w, r)
+		return
+	}
+
 	a.handler.ServeHTTP(w, r)
 }
 
+// Admin returns the handler containing the operator facing routes, such as the
+// runtime debug endpoints. It is meant to be served on a separate listener that
+// is not publicly reachable.
+func (a *Application) Admin() http.Handler {
+	return a.admin
+}
+
+// PublicRead returns the handler of the public read API, which serves the lists that are
+// shared and the templates that are published to anyone, without authentication. It only
+// reads, lets pages of the origins allowed by CORS read its responses, and limits the
+// requests of every client. It is meant to be served on a separate listener, so it can be
+// exposed and reasoned about apart from the rest of the API.
+func (a *Application) PublicRead() http.Handler {
+	return a.publicRead
+}
+
+// Routes returns every route served by the public handler in the order they were
+// registered.
+func (a *Application) Routes() []Route {
+	return a.routes
+}
+
+// PublicReadRoutes returns every route served by the public read API in the order they
+// were registered, see PublicRead.
+func (a *Application) PublicReadRoutes() []Route {
+	return a.publicReadRoutes
+}
+
 // NewApplication returns a new pointer to Application with route definitions
-// initiated.
-func NewApplication(db *sqlx.DB) *Application {
+// initiated. Every request is logged to log and handlers consult feats for the
+// behavior that is toggled through feature flags.
+func NewApplication(db *sqlx.DB, log logrus.FieldLogger, feats *features.Service) *Application {
 	a := Application{
-		DB: db,
+		DB:           db,
+		Log:          log,
+		Features:     feats,
+		Requests:     inflight.New(),
+		Deprecations: deprecations,
 	}
 
-	router := httprouter.New()
+	router := newRouter()
 
 	probeHandler := func(w http.ResponseWriter, r *http.Request) {
 		if err := a.DB.Ping(); err == nil {
@@ -44,27 +277,419 @@ func NewApplication(db *sqlx.DB) *Application {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 
+	// handle registers a public route served as declared by opts and records it, see
+	// Routes.
+	handle := func(method, path string, h http.HandlerFunc, opts ...routeOption) {
+		var rc routeConfig
+		for _, opt := range opts {
+			opt(&rc)
+		}
+
+		router.Handler(method, path, a.faultMW(method, path, a.routeMW(rc, h)))
+		a.routes = append(a.routes, Route{Method: method, Path: path})
+
+		if rc.unsigned {
+			a.unsigned = append(a.unsigned, Route{Method: method, Path: path})
+		}
+	}
+
 	// Kubernetes Probes
-	router.HandlerFunc(http.MethodGet, "/ready", probeHandler)
-	router.HandlerFunc(http.MethodGet, "/healthy", probeHandler)
+	handle(http.MethodGet, "/ready", probeHandler, withoutSignature())
+	handle(http.MethodGet, "/healthy", probeHandler, withoutSignature())
+
+	// Build Information
+	handle(http.MethodGet, "/version", a.getVersion, withoutSignature())
+
+	// Enums
+	handle(http.MethodGet, "/meta/enums", a.getEnums)
+
+	// API Documentation
+	handle(http.MethodGet, "/openapi.json", a.getOpenAPI, withoutSignature())
+	handle(http.MethodGet, "/docs", a.getDocs, withoutSignature())
+
+	// Demo UI
+	handle(http.MethodGet, "/", a.getUI, withoutSignature())
+	handle(http.MethodGet, "/ui/*filepath", a.getUIAsset, withoutSignature())
 
 	// List Routes
-	router.HandlerFunc(http.MethodGet, "/list", a.getLists)
-	router.HandlerFunc(http.MethodPost, "/list", a.createList)
-	router.HandlerFunc(http.MethodGet, "/list/:lid", a.getList)
-	router.HandlerFunc(http.MethodPut, "/list/:lid", a.updateList)
-	router.HandlerFunc(http.MethodDelete, "/list/:lid", a.deleteList)
+	handle(http.MethodGet, "/list", a.getLists)
+	handle(http.MethodPost, "/list", a.createList)
+	handle(http.MethodDelete, "/list", a.deleteLists)
+	handle(http.MethodGet, "/list/:lid", a.getList)
+	handle(http.MethodPut, "/list/:lid", a.updateList)
+	handle(http.MethodDelete, "/list/:lid", a.deleteList)
+	handle(http.MethodGet, "/list/:lid/feed.atom", a.getListFeed)
+	handle(http.MethodGet, "/list/:lid/settings", a.getListSettings)
+	handle(http.MethodPut, "/list/:lid/settings", a.updateListSettings)
+	handle(http.MethodPost, "/list/:lid/restore", a.restoreList)
+
+	// Share Routes
+	handle(http.MethodGet, "/list/:lid/share", a.getListShare)
+	handle(http.MethodPut, "/list/:lid/share", a.shareList)
+	handle(http.MethodDelete, "/list/:lid/share", a.unshareList)
+
+	// Template Routes
+	handle(http.MethodPost, "/list/:lid/save-template", a.saveTemplate)
+	handle(http.MethodGet, "/template", a.getTemplates)
+	handle(http.MethodGet, "/template/:tid", a.getTemplate)
+	handle(http.MethodPost, "/template/:tid/instantiate", a.instantiateTemplate)
+	handle(http.MethodPut, "/template/:tid/public", a.publishTemplate(true))
+	handle(http.MethodDelete, "/template/:tid/public", a.publishTemplate(false))
+
+	// Import Routes
+	handle(http.MethodPost, "/import/trello", a.importTrello, withMaxBodySize(maxImportSize), withTimeout(noTimeout))
+	handle(http.MethodPost, "/import/todoist", a.importTodoist, withMaxBodySize(maxImportSize), withTimeout(noTimeout))
+	handle(http.MethodPost, "/import/csv", a.importCSV, withMaxBodySize(maxRowImportSize), withTimeout(noTimeout))
+	handle(http.MethodPost, "/import/xlsx", a.importXLSX, withMaxBodySize(maxRowImportSize), withTimeout(noTimeout))
+
+	// Export Routes
+	handle(http.MethodPost, "/export", a.exportLists, withTimeout(noTimeout))
+
+	// Job Routes
+	handle(http.MethodGet, "/job/:jid", a.getJob, withMiddleware(a.noStoreMW))
+	handle(http.MethodGet, "/job/:jid/result", a.getJobResult, withMiddleware(a.noStoreMW))
+	handle(http.MethodGet, "/job/:jid/rejected", a.getJobRejected, withMiddleware(a.noStoreMW))
 
 	// Item Routes
-	router.HandlerFunc(http.MethodGet, "/list/:lid/item", a.getItems)
-	router.HandlerFunc(http.MethodPost, "/list/:lid/item", a.createItem)
-	router.HandlerFunc(http.MethodGet, "/list/:lid/item/:iid", a.getItem)
-	router.HandlerFunc(http.MethodPut, "/list/:lid/item/:iid", a.updateItem)
-	router.HandlerFunc(http.MethodDelete, "/list/:lid/item/:iid", a.deleteItem)
+	handle(http.MethodGet, "/items", a.getItemsByIDs)
+	handle(http.MethodGet, "/list/:lid/item", a.getItems)
+	handle(http.MethodPost, "/list/:lid/item", a.createItem)
+	handle(http.MethodPost, "/list/:lid/item/batch", a.createItems)
+	handle(http.MethodGet, "/list/:lid/item/:iid", a.getItem)
+	handle(http.MethodPut, "/list/:lid/item/:iid", a.updateItem)
+	handle(http.MethodDelete, "/list/:lid/item/:iid", a.deleteItem)
+	handle(http.MethodGet, "/list/:lid/item/:iid/history", a.getItemHistory)
+
+	// Attachment Routes
+	handle(http.MethodGet, "/list/:lid/item/:iid/attachment", a.getAttachments, withMiddleware(a.attachmentsMW, a.noStoreMW))
+	handle(http.MethodGet, "/list/:lid/item/:iid/attachment/:name", a.getAttachment, withMiddleware(a.attachmentsMW, a.noStoreMW))
+	handle(http.MethodPut, "/list/:lid/item/:iid/attachment/:name", a.putAttachment, withMiddleware(a.attachmentsMW), withMaxBodySize(maxAttachmentSize))
+	handle(http.MethodDelete, "/list/:lid/item/:iid/attachment/:name", a.deleteAttachment, withMiddleware(a.attachmentsMW))
+	handle(http.MethodGet, "/list/:lid/item/:iid/attachment/:name/content", a.getAttachmentContent, withMiddleware(a.attachmentsMW, a.noStoreMW))
+	handle(http.MethodGet, "/files/*key", a.getFile, withMiddleware(a.attachmentsMW, a.noStoreMW), withoutSignature())
 
-	// Wrap the router in middleware used for logging requests and set the application
+	// Wrap the router in middleware used for logging requests, verifying signatures, and
+	// rejecting writes while read-only or during maintenance, and set the application
 	// handler to utilize the returned http.Handler from RequestMW.
-	a.handler = web.RequestMW(router)
+	a.handler = a.clientIPMW(a.logRulesMW(web.RequestMW(a.Log, a.scopeMW(a.planMW(a.inflightMW(a.prettyMW(a.envelopeMW(a.stringIDsMW(a.deprecationsMW(a.signatureMW(a.slashMW(a.readOnlyMW(a.maintenanceMW(a.driftMW(a.cacheMW(a.dryRunMW(a.txMW(router))))))))))))))))))
+
+	adminRouter := httprouter.New()
+
+	// Debug Routes
+	debug.Register(adminRouter)
+	adminRouter.Handler(http.MethodGet, "/metrics", metrics.Handler())
+
+	// Feature Flag Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/features", a.getFeatures)
+	adminRouter.HandlerFunc(http.MethodPut, "/admin/features/:name", a.setFeature)
+
+	// Maintenance Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/maintenance", a.getMaintenance)
+	adminRouter.HandlerFunc(http.MethodPut, "/admin/maintenance", a.setMaintenance)
+
+	// Quota Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/quota", a.getQuota)
+	adminRouter.HandlerFunc(http.MethodPut, "/admin/quota", a.setQuota)
+
+	// Request Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/requests", a.getRequests)
+	adminRouter.HandlerFunc(http.MethodDelete, "/admin/requests/:id", a.cancelRequest)
+
+	// Browse Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/browse/:table", a.browseTable)
+
+	// Schema Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/schema", a.getSchema)
+	adminRouter.HandlerFunc(http.MethodPost, "/admin/schema/verify", a.verifySchema)
+
+	// Webhook Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/webhooks/dead-letters", a.getDeadLetters)
+	adminRouter.HandlerFunc(http.MethodPost, "/admin/webhooks/dead-letters/:id/retry", a.retryDeadLetter)
+
+	// Share Routes
+	adminRouter.HandlerFunc(http.MethodGet, "/admin/shares", a.getShares)
+	adminRouter.HandlerFunc(http.MethodDelete, "/admin/shares/:token", a.revokeShare)
+
+	// Verifying the schema only reads it, and neither cancelling requests nor the debug
+	// endpoints touch the database, so they are still served while read-only.
+	a.admin = web.RequestMW(a.Log, a.scopeMW(a.readOnlyMW(adminRouter, "/admin/schema/verify", "/admin/requests/", "/debug/")))
+
+	publicReadRouter := newRouter()
+
+	// handlePublicRead registers a route of the public read API and records it, see
+	// PublicReadRoutes.
+	handlePublicRead := func(path string, h http.HandlerFunc) {
+		publicReadRouter.HandlerFunc(http.MethodGet, path, h)
+		a.publicReadRoutes = append(a.publicReadRoutes, Route{Method: http.MethodGet, Path: path})
+	}
+
+	// Public Read Routes
+	handlePublicRead("/openapi.json", a.getPublicReadOpenAPI)
+	handlePublicRead("/shared/:token", a.getSharedList)
+	handlePublicRead("/templates", a.getPublicTemplates)
+	handlePublicRead("/templates/:tid", a.getPublicTemplate)
+
+	// Preflight requests are answered before they count against the rate limit, which
+	// comes before anything that reaches the database.
+	a.publicRead = a.clientIPMW(web.RequestMW(a.Log, a.scopeMW(a.corsMW(a.publicLimitMW(a.prettyMW(a.envelopeMW(a.stringIDsMW(publicReadRouter))))))))
 
 	return &a
 }
+
+// newRouter returns a router that answers unknown paths with 404 and known paths
+// requested with the wrong method with 405 and an Allow header. Trailing slashes are
+// left to slashMW, since the router would redirect to paths it doesn't serve either.
+func newRouter() *httprouter.Router {
+	router := httprouter.New()
+	router.RedirectTrailingSlash = false
+	router.NotFound = http.HandlerFunc(web.NotFound)
+	router.MethodNotAllowed = http.HandlerFunc(web.MethodNotAllowed)
+
+	return router
+}
+
+// scopeMW is a middleware that completes the web.Scope of a request with the
+// dependencies of the application that it doesn't have yet.
+func (a *Application) scopeMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		s := web.ScopeOf(r.Context())
+		if s.DB == nil {
+			s.DB = a.DB
+		}
+
+		next.ServeHTTP(w, web.WithScope(r, s))
+	}
+	return http.HandlerFunc(f)
+}
+
+// database returns the database handle of the request that ctx belongs to, which traces
+// its queries when the request is traced, see planMW.
+func (a *Application) database(ctx context.Context) db.Executor {
+	if t := db.TraceOf(ctx); t != nil {
+		return db.Traced{Executor: a.pool(ctx), Trace: t}
+	}
+
+	return a.pool(ctx)
+}
+
+// pool returns the connection pool of the request that ctx belongs to, see web.Scope,
+// or DB outside of requests.
+func (a *Application) pool(ctx context.Context) *sqlx.DB {
+	if dbc := web.ScopeOf(ctx).DB; dbc != nil {
+		return dbc
+	}
+
+	return a.DB
+}
+
+// clientIPMW is a middleware that determines the IP address of the client of a request
+// from the Proxies that are trusted, see web.ClientIP.
+func (a *Application) clientIPMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithClientIP(r, a.Proxies.Resolve(r)))
+	}
+	return http.HandlerFunc(f)
+}
+
+// logRulesMW is a middleware that gives requests the LogRules they are logged by, see
+// web.RequestMW.
+func (a *Application) logRulesMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithLogRules(r, a.LogRules))
+	}
+	return http.HandlerFunc(f)
+}
+
+// dryRunMW is a middleware that turns writes into dry runs when the client asks for it,
+// see web.WantsDryRun. A dry run carries out the write within a transaction that is
+// rolled back instead of committed, so the response tells what would have happened.
+func (a *Application) dryRunMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if web.WantsDryRun(w, r) {
+				r = web.WithDryRun(r)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(f)
+}
+
+// prettyMW is a middleware that makes responses indented by default when PrettyJSON is
+// set.
+func (a *Application) prettyMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithPretty(r, a.PrettyJSON))
+	}
+	return http.HandlerFunc(f)
+}
+
+// envelopeMW is a middleware that makes responses written in the Envelope by default.
+func (a *Application) envelopeMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithEnvelope(r, a.Envelope))
+	}
+	return http.HandlerFunc(f)
+}
+
+// stringIDsMW is a middleware that makes identifiers encoded as strings in responses when
+// StringIDs is set.
+func (a *Application) stringIDsMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, web.WithStringIDs(r, a.StringIDs))
+	}
+	return http.HandlerFunc(f)
+}
+
+// slashMW is a middleware that treats paths with a trailing slash, such as /list/, the
+// same as the path without it. They are redirected to the path without the slash, or
+// served as that path when RewriteTrailingSlash is set.
+func (a *Application) slashMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		path := web.TrimSlash(r.URL.Path)
+		if path == r.URL.Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if a.RewriteTrailingSlash {
+			r = r.Clone(r.Context())
+			r.URL.Path, r.URL.RawPath = path, ""
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Methods other than GET and HEAD are redirected with 308 so clients repeat the
+		// request with the same method and body.
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+
+		u := *r.URL
+		u.Path, u.RawPath = path, ""
+		http.Redirect(w, r, u.String(), code)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// cacheMW is a middleware that serves GET requests from the response cache, if there is
+// one.
+func (a *Application) cacheMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		a.Cache.Serve(w, r, next)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// version is the response of getVersion.
+type version struct {
+	buildinfo.Info
+	Validation validate.Mode `json:"validation"`
+}
+
+// getVersion is a handler that returns the build information of the running binary along
+// with the validation mode it runs in.
+func (a *Application) getVersion(w http.ResponseWriter, r *http.Request) {
+	web.Respond(w, r, http.StatusOK, version{Info: buildinfo.Get(), Validation: a.Validation})
+}
+
+// respondPayloadError responds to a request whose payload couldn't be decoded because
+// of err, with 413 when the payload exceeds the size limit and with 400 otherwise.
+func (a *Application) respondPayloadError(w http.ResponseWriter, r *http.Request, err error) {
+	if web.BodyTooLarge(err) {
+		web.RespondError(w, r, http.StatusRequestEntityTooLarge, web.NewError(codes.PayloadTooLarge, web.BodyLimit(r)))
+		return
+	}
+
+	web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.PayloadInvalid, err))
+}
+
+// decode decodes the JSON request body into v. In strict validation mode, or when the
+// strict validation feature is enabled, bodies containing fields that v does not know
+// about are rejected. Identifiers may be given as integers or strings, see
+// web.UnquoteIDs, and a body that is a bare array of integers, such as the ids of
+// deleteLists, holds identifiers.
+func (a *Application) decode(r *http.Request, v interface{}) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.Wrap(err, "read body")
+	}
+
+	// Malformed bodies are left for the decoder to report.
+	_, ids := v.(*[]int)
+	if u, err := web.UnquoteIDs(b, ids); err == nil {
+		b = u
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	if a.Validation == validate.ModeStrict || a.Features.Enabled(features.StrictValidation) {
+		dec.DisallowUnknownFields()
+	}
+
+	return dec.Decode(v)
+}
+
+// change runs fn within a transaction and stores the event of the given type describing
+// the change fn made in the outbox as part of the same transaction, so either both or
+// neither are stored. fn returns the list the change is about along with the event data.
+// Errors returned by fn are returned as is. The transaction is rolled back once ctx is
+// canceled.
+func (a *Application) change(ctx context.Context, typ string, fn func(tx *sqlx.Tx) (int, interface{}, error)) error {
+	tx, err := a.begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(ctx, tx)
+
+	listID, data, err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := record(tx, typ, listID, data); err != nil {
+		return err
+	}
+
+	return errors.Wrap(a.commit(ctx, tx), "commit transaction")
+}
+
+// commit commits tx and purges the response cache, so reads after a write are never
+// served what it replaced. Write handlers commit through it instead of tx.Commit. tx is
+// rolled back instead when ctx belongs to a dry run, see web.DryRun.
+func (a *Application) commit(ctx context.Context, tx *sqlx.Tx) error {
+	// The transaction of the request is committed by txMW once the request is served.
+	if tx == web.ScopeOf(ctx).Tx {
+		return nil
+	}
+
+	if web.DryRun(ctx) {
+		return tx.Rollback()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	a.Cache.Purge()
+	return nil
+}
+
+// record stores an event of the given type carrying data in the outbox as part of tx,
+// for handlers making more than one change within a transaction. listID is the list the
+// event is about.
+func record(tx *sqlx.Tx, typ string, listID int, data interface{}) error {
+	e, err := events.New(typ, data)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(outbox.Add(tx, e, listID), "add event to outbox")
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/importer"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// maxImportSize is the maximum size of an uploaded export in bytes, which is larger than
+// the limit of other request bodies.
+const maxImportSize = 10 << 20
+
+// importReport is the response of the import handlers, describing what every entry of
+// the export was mapped to.
+type importReport struct {
+	Lists   []importedList     `json:"lists"`
+	Skipped []importer.Skipped `json:"skipped"`
+}
+
+// importedList is a list that was created by an import.
+type importedList struct {
+	Source string    `json:"source"`
+	List   list.List `json:"list"`
+	Items  int       `json:"items"`
+}
+
+// importTrello is a handler that creates a list from a Trello board export.
+func (a *Application) importTrello(w http.ResponseWriter, r *http.Request) {
+	a.importLists(w, r, importer.Trello)
+}
+
+// importTodoist is a handler that creates lists from the projects of a Todoist export.
+func (a *Application) importTodoist(w http.ResponseWriter, r *http.Request) {
+	a.importLists(w, r, importer.Todoist)
+}
+
+// importLists creates the lists and items parse maps the request body to and responds
+// with a report of the import. Lists whose name is already taken are skipped, since names
+// are unique regardless of case. The body is parsed right away, the lists are created in
+// the background when the client asks for it, see startJob.
+func (a *Application) importLists(w http.ResponseWriter, r *http.Request, parse importer.Parser) {
+	lists, skipped, err := parse(r.Body, a.Names)
+	if err != nil {
+		if web.BodyTooLarge(err) {
+			web.RespondError(w, r, http.StatusRequestEntityTooLarge, web.NewError(codes.PayloadTooLarge, web.BodyLimit(r)))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.ExportInvalid, err))
+		return
+	}
+
+	if a.async(w, r) {
+		a.startJob(w, r, "import", func(ctx context.Context, progress progressFunc) (interface{}, error) {
+			return a.runImport(ctx, lists, skipped, progress)
+		})
+		return
+	}
+
+	report, err := a.runImport(r.Context(), lists, skipped, nil)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, report)
+}
+
+// nameTakenReason is the reason lists of an import are skipped for when a list with the
+// same name already exists.
+const nameTakenReason = "a list with the same name already exists"
+
+// runImport creates the given lists along with their items within a single transaction
+// and returns the report of the import. progress, if not nil, is called whenever a list
+// has been created.
+func (a *Application) runImport(ctx context.Context, lists []importer.List, skipped []importer.Skipped, progress progressFunc) (importReport, error) {
+	report := importReport{
+		Lists:   make([]importedList, 0, len(lists)),
+		Skipped: append(make([]importer.Skipped, 0, len(skipped)), skipped...),
+	}
+
+	tx, err := a.begin(ctx)
+	if err != nil {
+		return importReport{}, errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(ctx, tx)
+
+	existing, err := list.SelectLists(tx)
+	if err != nil {
+		return importReport{}, errors.Wrap(err, "select all lists")
+	}
+
+	taken := make(map[string]bool, len(existing)+len(lists))
+	for _, l := range existing {
+		taken[strings.ToLower(l.Name)] = true
+	}
+
+	for n, il := range lists {
+		if progress != nil && n > 0 {
+			progress(n, len(lists))
+		}
+
+		if taken[strings.ToLower(il.Name)] {
+			report.Skipped = append(report.Skipped, importer.Skipped{Source: il.Source, Reason: nameTakenReason})
+			continue
+		}
+		taken[strings.ToLower(il.Name)] = true
+
+		l, err := list.CreateList(tx, list.List{Name: il.Name})
+		if err != nil {
+			// A list with the name was created since the lists were selected.
+			if errors.Cause(err) == list.ErrNameTaken {
+				report.Skipped = append(report.Skipped, importer.Skipped{Source: il.Source, Reason: nameTakenReason})
+				continue
+			}
+
+			return importReport{}, errors.Wrap(err, "insert row into list table")
+		}
+
+		if err := record(tx, events.ListCreated, l.ID, l); err != nil {
+			return importReport{}, err
+		}
+
+		// Entries with the same name are merged into a single item since names are unique
+		// within a list, each of them adds one to its quantity.
+		for _, name := range il.Items {
+			i, inserted, err := item.MergeItem(tx, item.Item{ListID: l.ID, Name: name, Quantity: 1})
+			if err != nil {
+				return importReport{}, errors.Wrap(err, "merge row into item table")
+			}
+
+			typ := events.ItemUpdated
+			if inserted {
+				typ = events.ItemCreated
+			}
+
+			if err := record(tx, typ, l.ID, i); err != nil {
+				return importReport{}, err
+			}
+		}
+
+		report.Lists = append(report.Lists, importedList{
+			Source: il.Source,
+			List:   l,
+			Items:  len(il.Items),
+		})
+	}
+
+	if err := a.commit(ctx, tx); err != nil {
+		return importReport{}, errors.Wrap(err, "commit transaction")
+	}
+
+	return report, nil
+}
+
+// maxRowImportSize is the maximum size of an uploaded spreadsheet in bytes. Spreadsheets
+// are read a row at a time, so they may be larger than other exports.
+const maxRowImportSize = 100 << 20
+
+// defaultImportBatchSize is the amount of rows of a spreadsheet imported per transaction
+// when ImportBatchSize is 0.
+const defaultImportBatchSize = 500
+
+// rowImportReport is the response of the spreadsheet import handlers, describing the lists
+// that were created and the rows that were rejected.
+type rowImportReport struct {
+	Lists    []importedList `json:"lists"`
+	Imported int            `json:"imported"`
+	Header   []string       `json:"header"`
+	Rejected []rejectedRow  `json:"rejected"`
+}
+
+// rejectedRow is a row of a spreadsheet that wasn't imported along with why.
+type rejectedRow struct {
+	Row    int      `json:"row"`
+	Reason string   `json:"reason"`
+	Fields []string `json:"fields"`
+}
+
+// importCSV is a handler that creates lists and their items from the rows of a CSV
+// spreadsheet.
+func (a *Application) importCSV(w http.ResponseWriter, r *http.Request) {
+	a.importRows(w, r, importer.CSV)
+}
+
+// importXLSX is a handler that creates lists and their items from the rows of the first
+// worksheet of an Excel workbook.
+func (a *Application) importXLSX(w http.ResponseWriter, r *http.Request) {
+	a.importRows(w, r, importer.XLSX)
+}
+
+// importRows creates the lists and items named by the rows of the spreadsheet in the
+// request body, which is kept in a temporary file while it is imported so it never has
+// to be held in memory. The rows are imported in batches of ImportBatchSize, each within
+// a transaction of its own, see runRowImport.
+func (a *Application) importRows(w http.ResponseWriter, r *http.Request, open importer.RowOpener) {
+	f, err := ioutil.TempFile("", "listd-import-")
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "create temporary file"))
+		return
+	}
+
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, r.Body)
+	if err != nil {
+		cleanup()
+
+		if web.BodyTooLarge(err) {
+			web.RespondError(w, r, http.StatusRequestEntityTooLarge, web.NewError(codes.PayloadTooLarge, web.BodyLimit(r)))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.PayloadInvalid, err))
+		return
+	}
+
+	rows, err := open(f, size, a.Names)
+	if err != nil {
+		cleanup()
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.ExportInvalid, err))
+		return
+	}
+
+	if a.async(w, r) {
+		started := a.startJob(w, r, "import", func(ctx context.Context, progress progressFunc) (interface{}, error) {
+			defer cleanup()
+			return a.runRowImport(ctx, rows, progress)
+		})
+
+		if !started {
+			cleanup()
+		}
+		return
+	}
+	defer cleanup()
+
+	report, err := a.runRowImport(r.Context(), rows, nil)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, report)
+}
+
+// runRowImport imports rows in batches and returns the report of the import. Every batch
+// is committed on its own, so a failing import keeps the batches before it. Like other
+// imports, rows naming a list that existed before the import are rejected. progress, if
+// not nil, is called whenever a batch has been committed.
+func (a *Application) runRowImport(ctx context.Context, rows *importer.Rows, progress progressFunc) (rowImportReport, error) {
+	report := rowImportReport{
+		Lists:    make([]importedList, 0),
+		Header:   rows.Header(),
+		Rejected: make([]rejectedRow, 0),
+	}
+
+	existing, err := list.SelectLists(a.database(ctx))
+	if err != nil {
+		return rowImportReport{}, errors.Wrap(err, "select all lists")
+	}
+
+	taken := make(map[string]bool, len(existing))
+	for _, l := range existing {
+		taken[strings.ToLower(l.Name)] = true
+	}
+
+	// The lists created by the import are looked up by the index of their report.
+	created := make(map[string]int)
+
+	size := a.ImportBatchSize
+	if size == 0 {
+		size = defaultImportBatchSize
+	}
+
+	// Dry runs keep nothing between batches, so the lists created by one batch wouldn't
+	// be there for the next. They are imported in a single batch instead.
+	if web.DryRun(ctx) {
+		size = int(^uint(0) >> 1)
+	}
+
+	batch := make([]importer.Row, 0, 64)
+	for done := false; !done; {
+		batch = batch[:0]
+
+		for len(batch) < size {
+			row, err := rows.Next()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return rowImportReport{}, web.NewError(codes.ExportInvalid, err)
+			}
+
+			batch = append(batch, row)
+		}
+
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := a.importBatch(ctx, batch, taken, created, &report); err != nil {
+			return rowImportReport{}, err
+		}
+
+		if progress != nil && !done {
+			if read, total := rows.Progress(); total > 0 {
+				progress(int(read), int(total))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// importBatch imports the given rows within a single transaction, adding the lists it
+// creates to created and report. A row that can't be imported is rejected without
+// affecting the other rows of the batch.
+func (a *Application) importBatch(ctx context.Context, batch []importer.Row, taken map[string]bool, created map[string]int, report *rowImportReport) error {
+	tx, err := a.begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(ctx, tx)
+
+	// The lists are only added to created once the batch is committed.
+	var lists []importedList
+	newLists := make(map[string]int)
+
+	for _, row := range batch {
+		reject := func(reason string) {
+			report.Rejected = append(report.Rejected, rejectedRow{Row: row.Number, Reason: reason, Fields: row.Fields})
+		}
+
+		key := strings.ToLower(row.List)
+		_, isNew := newLists[key]
+		_, isCreated := created[key]
+
+		unit, unitErr := a.itemUnit().Parse("unit", row.Unit)
+
+		switch {
+		case row.Rejected != "":
+			reject(row.Rejected)
+			continue
+		case taken[key] && !isNew && !isCreated:
+			reject(nameTakenReason)
+			continue
+		case unitErr != nil:
+			reject(unitErr.Error())
+			continue
+		}
+		row.Unit = unit
+
+		var l list.List
+		switch {
+		case isCreated:
+			l = report.Lists[created[key]].List
+		case isNew:
+			l = lists[newLists[key]].List
+		}
+
+		l, err := importRow(tx, l, row)
+		if err != nil {
+			if qerr := quotaError(err); qerr != nil {
+				reject(qerr.Error())
+				continue
+			}
+
+			if errors.Cause(err) == item.ErrUnitMismatch {
+				reject(item.ErrUnitMismatch.Error())
+				continue
+			}
+
+			// A list with the name was created since the lists were selected.
+			if errors.Cause(err) == list.ErrNameTaken {
+				taken[key] = true
+				reject(nameTakenReason)
+				continue
+			}
+
+			return err
+		}
+
+		switch {
+		case isCreated:
+			report.Lists[created[key]].Items++
+		case isNew:
+			lists[newLists[key]].Items++
+		default:
+			newLists[key] = len(lists)
+			lists = append(lists, importedList{Source: fmt.Sprintf("row:%d", row.Number), List: l, Items: 1})
+		}
+
+		report.Imported++
+	}
+
+	if err := a.commit(ctx, tx); err != nil {
+		return errors.Wrap(err, "commit transaction")
+	}
+
+	for key, i := range newLists {
+		taken[key] = true
+		created[key] = len(report.Lists) + i
+	}
+	report.Lists = append(report.Lists, lists...)
+
+	return nil
+}
+
+// importRow merges the item named by row into l as part of tx, creating l first when it
+// has no ID yet, and returns l. A failure only rolls back the changes made for row, so tx
+// can still be used for the remaining rows of a batch.
+func importRow(tx *sqlx.Tx, l list.List, row importer.Row) (list.List, error) {
+	err := savepoint(tx, "import_row", func() error {
+		var err error
+		l, err = mergeRow(tx, l, row)
+		return err
+	})
+	if err != nil {
+		return list.List{}, err
+	}
+
+	return l, nil
+}
+
+// mergeRow creates l unless it has an ID and merges the item named by row into it, along
+// with recording their events.
+func mergeRow(tx *sqlx.Tx, l list.List, row importer.Row) (list.List, error) {
+	if l.ID == 0 {
+		var err error
+		if l, err = list.CreateList(tx, list.List{Name: row.List}); err != nil {
+			return list.List{}, errors.Wrap(err, "insert row into list table")
+		}
+
+		if err := record(tx, events.ListCreated, l.ID, l); err != nil {
+			return list.List{}, err
+		}
+	}
+
+	i, inserted, err := item.MergeItem(tx, item.Item{ListID: l.ID, Name: row.Name, Quantity: row.Quantity, Unit: row.Unit})
+	if err != nil {
+		return list.List{}, errors.Wrap(err, "merge row into item table")
+	}
+
+	typ := events.ItemUpdated
+	if inserted {
+		typ = events.ItemCreated
+	}
+
+	return l, record(tx, typ, l.ID, i)
+}
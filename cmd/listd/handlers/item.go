@@ -2,17 +2,24 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
 
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/attachment"
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/dedup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/jmoiron/sqlx"
 	"github.com/julienschmidt/httprouter"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
-// getItems is a handler that returns all rows from the item table.
+// getItems is a handler that returns a page of rows from the item table given a list_id.
 func (a *Application) getItems(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -20,14 +27,20 @@ func (a *Application) getItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items, err := item.SelectItems(a.DB, listID)
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	items, total, err := item.SelectItemPage(a.database(r.Context()), listID, page.Limit, page.Offset)
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select all item rows"))
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select page of item rows"))
 		return
 	}
 
@@ -35,10 +48,44 @@ func (a *Application) getItems(w http.ResponseWriter, r *http.Request) {
 		items = make([]item.Item, 0)
 	}
 
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, items, page)
+}
+
+// getItemsByIDs is a handler that retrieves the rows from the item table with the ids
+// given by the ids query parameter regardless of their list, in the order they are
+// given. Items are only returned when every one of them exists.
+func (a *Application) getItemsByIDs(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDs(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	items, err := item.SelectItemsByIDs(a.database(r.Context()), ids)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select items by ids"))
+		return
+	}
+
+	found := make(map[int]bool, len(items))
+	for _, i := range items {
+		found[i.ID] = true
+	}
+
+	if missing := missingIDs(ids, found); missing != "" {
+		web.RespondError(w, r, http.StatusNotFound, web.NewError(codes.ItemsNotFound, missing))
+		return
+	}
+
 	web.Respond(w, r, http.StatusOK, items)
 }
 
-// getItems is a handler that creates a new row in the item table.
+// createItem is a handler that creates a new row in the item table. Names are unique within
+// a list regardless of case. With merge=true in the query string the quantity is added to
+// an existing item with the same name instead of responding with a conflict. A request
+// that repeats the one which created the item moments before is answered with that item
+// and a 200, see Duplicates.
 func (a *Application) createItem(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -47,27 +94,88 @@ func (a *Application) createItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload item.Item
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "unmarshal request payload"))
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
 		return
 	}
 
 	payload.ListID = listID
 
-	if payload.Name == "" {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("name is a required field"))
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := a.validateItem(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var params struct {
+		Merge bool `query:"merge"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if params.Merge {
+		a.mergeItem(w, r, payload)
 		return
 	}
 
-	if payload.Quantity <= 0 {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("quantity must be supplied and greater than 0"))
+	key, err := dedup.Key(payload)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
-	i, err := item.CreateItem(a.DB, payload)
+	var i item.Item
+	if a.CoalesceWindow > 0 && !web.DryRun(r.Context()) && web.ScopeOf(r.Context()).Tx == nil {
+		i, err = a.createCoalescedItem(payload)
+	} else {
+		err = a.change(r.Context(), events.ItemCreated, func(tx *sqlx.Tx) (int, interface{}, error) {
+			create := func() error {
+				var err error
+				i, err = item.CreateItem(tx, payload)
+				return err
+			}
+
+			var err error
+
+			// A name conflict can still be answered with the item it duplicates, which
+			// the transaction of the request has to be committed after, see txMW.
+			if tx == web.ScopeOf(r.Context()).Tx {
+				err = savepoint(tx, "create_item", create)
+			} else {
+				err = create()
+			}
+
+			return listID, i, err
+		})
+	}
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		if respondQuotaExceeded(w, r, err) {
+			return
+		}
+
+		taken := errors.Cause(err) == item.ErrNameTaken
+		if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
+			taken = string(pgerr.Code) == db.PSQLErrUniqueConstraint
+		}
+
+		if taken {
+			if a.respondDuplicateItem(w, r, key, listID) {
+				return
+			}
+
+			web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ItemNameTaken))
 			return
 		}
 
@@ -75,11 +183,241 @@ func (a *Application) createItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	web.Respond(w, r, http.StatusCreated, i)
+	if !web.DryRun(r.Context()) {
+		a.Duplicates.Remember(key, i.ID)
+	}
+
+	respondCreated(w, r, i)
+}
+
+// duplicateItem is the response to a request that repeats the one which created the item
+// moments before.
+type duplicateItem struct {
+	item.Item
+	Duplicate bool `json:"duplicate"`
+}
+
+// respondDuplicateItem responds with the item of the list given by listID that an
+// identical request, whose key is given, created within the window of the Duplicates
+// detector. It reports whether it responded, which it doesn't if there is no such item.
+func (a *Application) respondDuplicateItem(w http.ResponseWriter, r *http.Request, key string, listID int) bool {
+	id, ok := a.Duplicates.Created(key)
+	if !ok {
+		return false
+	}
+
+	i, err := item.SelectItem(a.database(r.Context()), id, listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return false
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select duplicated item"))
+		return true
+	}
+
+	web.Respond(w, r, http.StatusOK, duplicateItem{Item: i, Duplicate: true})
+	return true
+}
+
+// mergeItem creates the validated payload as a new item, or adds its quantity to the item
+// of the same list with the same name. It responds with a 201 or a 200 respectively.
+func (a *Application) mergeItem(w http.ResponseWriter, r *http.Request, payload item.Item) {
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	i, inserted, err := item.MergeItem(tx, payload)
+	if err != nil {
+		if respondQuotaExceeded(w, r, err) {
+			return
+		}
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		case item.ErrUnitMismatch:
+			web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ItemUnitMismatch))
+		default:
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "merge row into item table"))
+		}
+		return
+	}
+
+	typ := events.ItemUpdated
+	if inserted {
+		typ = events.ItemCreated
+	}
+
+	if err := record(tx, typ, i.ListID, i); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	if inserted {
+		respondCreated(w, r, i)
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, i)
+}
+
+// maxBatchSize is the maximum amount of entries of a batch request.
+const maxBatchSize = 100
+
+// createItems is a handler that creates every item of the payload in the list, responding
+// with the outcome of each entry as a 207 Multi-Status. Entries are created on a best
+// effort basis, with atomic=true in the query string either every entry or none is
+// created.
+func (a *Application) createItems(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	var payload []item.Item
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	if len(payload) == 0 || len(payload) > maxBatchSize {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.BatchSizeInvalid, maxBatchSize, len(payload)))
+		return
+	}
+
+	var params struct {
+		Atomic bool `query:"atomic"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	if _, err := list.SelectList(tx, listID); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by id"))
+		return
+	}
+
+	var batch web.Batch
+	for _, p := range payload {
+		p.ListID = listID
+
+		var err error
+		if p.Name, err = a.Names.Normalize("name", p.Name); err != nil {
+			batch.Fail(r, http.StatusUnprocessableEntity, err)
+			continue
+		}
+
+		if err := a.validateItem(&p); err != nil {
+			batch.Fail(r, http.StatusBadRequest, err)
+			continue
+		}
+
+		i, err := createBatchItem(tx, p)
+		if err != nil {
+			if qerr := quotaError(err); qerr != nil {
+				batch.Fail(r, http.StatusUnprocessableEntity, qerr)
+				continue
+			}
+
+			if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
+				if string(pgerr.Code) == db.PSQLErrUniqueConstraint {
+					batch.Fail(r, http.StatusConflict, web.NewError(codes.ItemNameTaken))
+					continue
+				}
+			}
+
+			batch.Fail(r, http.StatusInternalServerError, errors.Wrap(err, "insert row into item table"))
+			continue
+		}
+
+		batch.Succeed(http.StatusCreated, i)
+	}
+
+	if params.Atomic && batch.Failed() {
+		batch.Abort(r)
+		web.RespondMultiStatus(w, r, &batch)
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	web.RespondMultiStatus(w, r, &batch)
+}
+
+// createBatchItem creates i and records its event as part of tx. A failure only rolls
+// back the changes made for i, so tx can still be used for the remaining entries of a
+// batch.
+func createBatchItem(tx *sqlx.Tx, i item.Item) (item.Item, error) {
+	var created item.Item
+	err := savepoint(tx, "batch_entry", func() error {
+		var err error
+		if created, err = item.CreateItem(tx, i); err != nil {
+			return err
+		}
+
+		return record(tx, events.ItemCreated, i.ListID, created)
+	})
+	if err != nil {
+		return item.Item{}, err
+	}
+
+	return created, nil
+}
+
+// savepoint runs fn within the savepoint of tx with the given name, rolling back to it
+// when fn fails. A failed statement of fn only rolls back the changes fn made instead
+// of aborting tx, so tx can still be used and committed.
+func savepoint(tx *sqlx.Tx, name string, fn func() error) error {
+	if _, err := tx.Exec("SAVEPOINT " + name + ";"); err != nil {
+		return errors.Wrap(err, "create savepoint")
+	}
+
+	if err := fn(); err != nil {
+		if _, rerr := tx.Exec("ROLLBACK TO SAVEPOINT " + name + ";"); rerr != nil {
+			return errors.Wrap(rerr, "roll back to savepoint")
+		}
+
+		return err
+	}
+
+	_, err := tx.Exec("RELEASE SAVEPOINT " + name + ";")
+	return errors.Wrap(err, "release savepoint")
 }
 
 // getItem is a handler that returns a row from the item table based off of the lid and iid URL
-// parameters.
+// parameters. Clients can revalidate their copy with If-Modified-Since, see
+// web.NotModified.
 func (a *Application) getItem(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -93,10 +431,10 @@ func (a *Application) getItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	i, err := item.SelectItem(a.DB, itemID, listID)
+	i, err := item.SelectItem(a.database(r.Context()), itemID, listID)
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
@@ -104,9 +442,49 @@ func (a *Application) getItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if web.NotModified(w, r, i.Modified) {
+		return
+	}
+
 	web.Respond(w, r, http.StatusOK, i)
 }
 
+// getItemHistory is a handler that returns a page of the changes made to the item given by
+// the lid and iid URL parameters, newest first.
+func (a *Application) getItemHistory(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	itemID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("iid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert item id to integer"))
+		return
+	}
+
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	changes, total, err := item.SelectHistoryPage(a.database(r.Context()), itemID, listID, page.Limit, page.Offset)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select page of item history rows"))
+		return
+	}
+
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, changes, page)
+}
+
 // getItem is a handler that updates a row from the item table based off of the lid and iid URL
 // parameters as well as a given payload.
 func (a *Application) updateItem(w http.ResponseWriter, r *http.Request) {
@@ -123,30 +501,40 @@ func (a *Application) updateItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload item.Item
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "unmarshal request payload"))
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
 		return
 	}
 
 	payload.ID = itemID
 	payload.ListID = listID
 
-	if payload.Name == "" {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("name is a required field"))
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	if payload.Quantity <= 0 {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("quantity must be supplied and greater than 0"))
+	if err := a.validateItem(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = item.UpdateItem(a.DB, payload); err != nil {
+	err = a.change(r.Context(), events.ItemUpdated, func(tx *sqlx.Tx) (int, interface{}, error) {
+		return listID, payload, item.UpdateItem(tx, payload)
+	})
+	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
+		if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
+			if string(pgerr.Code) == db.PSQLErrUniqueConstraint {
+				web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ItemNameTaken))
+				return
+			}
+		}
+
 		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "update row in item table"))
 		return
 	}
@@ -154,8 +542,9 @@ func (a *Application) updateItem(w http.ResponseWriter, r *http.Request) {
 	web.Respond(w, r, http.StatusOK, payload)
 }
 
-// getItem is a handler that deletes a row from the item table based off of the lid and iid URL
-// parameters.
+// deleteItem is a handler that deletes a row from the item table based off of the lid
+// and iid URL parameters, along with the files of its attachments. The deleted item is
+// returned when the client asks for it, see web.WantsRepresentation.
 func (a *Application) deleteItem(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -169,9 +558,24 @@ func (a *Application) deleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = item.DeleteItem(a.DB, itemID, listID); err != nil {
+	var (
+		i    item.Item
+		keys []string
+	)
+	err = a.change(r.Context(), events.ItemDeleted, func(tx *sqlx.Tx) (int, interface{}, error) {
+		var err error
+		if a.Storage != nil {
+			if keys, err = attachment.SelectKeysOfItem(tx, itemID, listID); err != nil {
+				return listID, nil, err
+			}
+		}
+
+		i, err = item.DeleteItem(tx, itemID, listID)
+		return listID, map[string]interface{}{"id": itemID, "listID": listID, "name": i.Name}, err
+	})
+	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
@@ -179,5 +583,32 @@ func (a *Application) deleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	a.deleteFiles(r.Context(), keys)
+
+	if web.WantsRepresentation(w, r) {
+		web.Respond(w, r, http.StatusOK, i)
+		return
+	}
+
 	web.Respond(w, r, http.StatusNoContent, nil)
 }
+
+// validateItem returns an error describing the first invalid field of i, if any. The unit
+// of i is set to the spelling it has among the units, see web.Enum.
+func (a *Application) validateItem(i *item.Item) error {
+	if i.Name == "" {
+		return web.NewError(codes.ItemNameRequired)
+	}
+
+	if i.Quantity <= 0 {
+		return web.NewError(codes.QuantityInvalid)
+	}
+
+	unit, err := a.itemUnit().Parse("unit", i.Unit)
+	if err != nil {
+		return err
+	}
+	i.Unit = unit
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/job"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// progressFunc is called by the operation of a job whenever done out of total steps are
+// finished.
+type progressFunc func(done, total int)
+
+// jobFunc is an operation that is run as a job, returning the result of the job.
+type jobFunc func(ctx context.Context, progress progressFunc) (interface{}, error)
+
+// async reports whether the operation r asks for is run as a job, which it is when the
+// client asks for it and there are Workers to run it. Dry runs are never run as jobs,
+// since they have nothing to keep.
+func (a *Application) async(w http.ResponseWriter, r *http.Request) bool {
+	return a.Workers != nil && !web.DryRun(r.Context()) && web.WantsAsync(w, r)
+}
+
+// startJob creates a job of the given kind that runs fn on one of the Workers and
+// responds with a 202 and the job, whose status can be followed at the URL given by the
+// Location header. When the queue of the Workers is full the job fails right away and
+// a 503 is responded with. It reports whether fn was handed to the Workers.
+func (a *Application) startJob(w http.ResponseWriter, r *http.Request, kind string, fn jobFunc) bool {
+	j, err := job.CreateJob(a.database(r.Context()), kind)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "create job"))
+		return false
+	}
+
+	// The job outlives the request, but keeps its request ID in the logs.
+	log := web.Logger(r.Context()).WithField("jobID", j.ID)
+
+	task := func(ctx context.Context) {
+		a.runJob(ctx, log, j.ID, fn)
+	}
+
+	if !a.Workers.Submit(task) {
+		err := web.NewError(codes.JobQueueFull)
+		a.failJob(log, j.ID, err)
+
+		web.RespondError(w, r, http.StatusServiceUnavailable, err)
+		return false
+	}
+
+	w.Header().Set("Location", jobPath(j.ID))
+	web.Respond(w, r, http.StatusAccepted, j)
+
+	return true
+}
+
+// runJob runs fn as the job with the given id, recording its progress and outcome. The
+// errors of fn are logged, the job is only told which of them are user-facing since the
+// others may contain sensitive information.
+func (a *Application) runJob(ctx context.Context, log logrus.FieldLogger, id int, fn jobFunc) {
+	if ctx.Err() != nil {
+		a.failJob(log, id, web.NewError(codes.JobInterrupted))
+		return
+	}
+
+	if err := job.Start(a.DB, id); err != nil {
+		log.WithError(err).Error("start job")
+		return
+	}
+
+	progress := func(done, total int) {
+		if err := job.SetProgress(a.DB, id, done*100/total); err != nil {
+			log.WithError(err).Warn("update job progress")
+		}
+	}
+
+	result, err := fn(ctx, progress)
+	if err != nil {
+		log.WithError(err).Error("job failed")
+
+		var reason error = web.StatusError(http.StatusInternalServerError)
+		if ctx.Err() != nil {
+			reason = web.NewError(codes.JobInterrupted)
+		} else if e, ok := errors.Cause(err).(*web.Error); ok {
+			reason = e
+		}
+
+		a.failJob(log, id, reason)
+		return
+	}
+
+	if err := job.Succeed(a.DB, id, result); err != nil {
+		log.WithError(err).Error("record job result")
+	}
+}
+
+// failJob marks the job with the given id as failed for the given reason, which is
+// stored in i18n.DefaultLanguage.
+func (a *Application) failJob(log logrus.FieldLogger, id int, reason error) {
+	if err := job.Fail(a.DB, id, reason.Error()); err != nil {
+		log.WithError(err).Error("mark job as failed")
+	}
+}
+
+// noStoreMW is a middleware that keeps responses from being cached, for routes whose
+// resources change without being written to through the API, such as jobs while they run.
+func (a *Application) noStoreMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// getJob is a handler that returns a row from the job table based off of the jid URL
+// parameter.
+func (a *Application) getJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("jid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert job id to integer"))
+		return
+	}
+
+	j, err := job.SelectJob(a.database(r.Context()), jobID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select job by id"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, j)
+}
+
+// getJobResult is a handler that returns the result of the job given by the jid URL
+// parameter. Jobs that haven't succeeded have no result, which is responded to with a
+// 409.
+func (a *Application) getJobResult(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("jid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert job id to integer"))
+		return
+	}
+
+	j, err := job.SelectJob(a.database(r.Context()), jobID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select job by id"))
+		return
+	}
+
+	if j.Status != job.StatusSucceeded {
+		web.RespondError(w, r, http.StatusConflict, web.NewError(codes.JobResultUnavailable, j.Status))
+		return
+	}
+
+	result, err := job.SelectResult(a.database(r.Context()), jobID)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select job result"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, result)
+}
+
+// getJobRejected is a handler that returns the rows rejected by the spreadsheet import of
+// the job given by the jid URL parameter as a CSV file, made of the number of every row
+// and why it was rejected followed by its cells. Jobs that haven't succeeded have no
+// result, which is responded to with a 409.
+func (a *Application) getJobRejected(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("jid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert job id to integer"))
+		return
+	}
+
+	j, err := job.SelectJob(a.database(r.Context()), jobID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select job by id"))
+		return
+	}
+
+	if j.Kind != "import" {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	if j.Status != job.StatusSucceeded {
+		web.RespondError(w, r, http.StatusConflict, web.NewError(codes.JobResultUnavailable, j.Status))
+		return
+	}
+
+	result, err := job.SelectResult(a.database(r.Context()), jobID)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select job result"))
+		return
+	}
+
+	// Imports of other exports have neither, leaving only the header of the file.
+	var report rowImportReport
+	if err := json.Unmarshal(result, &report); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "decode job result"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="job-%d-rejected.csv"`, j.ID))
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(append([]string{"row", "reason"}, report.Header...))
+
+	for _, rr := range report.Rejected {
+		_ = cw.Write(append([]string{strconv.Itoa(rr.Row), rr.Reason}, rr.Fields...))
+	}
+
+	cw.Flush()
+}
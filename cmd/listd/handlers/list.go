@@ -2,23 +2,68 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/attachment"
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/jmoiron/sqlx"
 	"github.com/julienschmidt/httprouter"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
-// getLists is a handler that retrieves all rows from the list table.
+// getLists is a handler that retrieves a page of rows from the list table, or with ids
+// in the query string, the lists with those ids in the order they are given.
 func (a *Application) getLists(w http.ResponseWriter, r *http.Request) {
-	lists, err := list.SelectLists(a.DB)
+	if _, ok := r.URL.Query()["ids"]; ok {
+		a.getListsByIDs(w, r)
+		return
+	}
+
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var params struct {
+		Sort   string `query:"sort" default:"id" oneof:"id -id name -name"`
+		Locale string `query:"locale"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	sort := list.Sort{
+		ByName: strings.TrimPrefix(params.Sort, "-") == "name",
+		Desc:   strings.HasPrefix(params.Sort, "-"),
+	}
+
+	if params.Locale != "" {
+		sort.Collation, err = list.Collation(a.database(r.Context()), params.Locale)
+		if err != nil {
+			if errors.Cause(err) == list.ErrUnknownLocale {
+				web.RespondError(w, r, http.StatusBadRequest, web.NewFieldError("locale", codes.LocaleUnsupported, params.Locale))
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select collation"))
+			return
+		}
+	}
+
+	lists, total, err := list.SelectListPage(a.database(r.Context()), sort, page.Limit, page.Offset)
 	if err != nil {
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select all lists"))
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select page of lists"))
 		return
 	}
 
@@ -26,41 +71,131 @@ func (a *Application) getLists(w http.ResponseWriter, r *http.Request) {
 		lists = make([]list.List, 0)
 	}
 
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, lists, page)
+}
+
+// getListsByIDs is a handler that retrieves the rows from the list table with the ids
+// given by the ids query parameter, in the order they are given. Lists are only returned
+// when every one of them exists.
+func (a *Application) getListsByIDs(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDs(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	lists, err := list.SelectListsByIDs(a.database(r.Context()), ids)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select lists by ids"))
+		return
+	}
+
+	found := make(map[int]bool, len(lists))
+	for _, l := range lists {
+		found[l.ID] = true
+	}
+
+	if missing := missingIDs(ids, found); missing != "" {
+		web.RespondError(w, r, http.StatusNotFound, web.NewError(codes.ListsNotFound, missing))
+		return
+	}
+
 	web.Respond(w, r, http.StatusOK, lists)
 }
 
+// parseIDs returns the ids given by the comma-separated ids query parameter of r, with
+// repeated ones left out. An error is returned when it holds anything but ids, none, or
+// more than maxBatchSize of them, which callers should respond to with 400 Bad Request.
+func parseIDs(r *http.Request) ([]int, error) {
+	var params struct {
+		IDs []int `query:"ids" min:"1"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		return nil, err
+	}
+
+	if len(params.IDs) == 0 || len(params.IDs) > maxBatchSize {
+		return nil, web.NewError(codes.IDsInvalid, maxBatchSize, r.URL.Query().Get("ids"))
+	}
+
+	ids := make([]int, 0, len(params.IDs))
+	seen := make(map[int]bool, len(params.IDs))
+	for _, id := range params.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// missingIDs returns the ids that aren't found joined by commas, or "" when every one of
+// them is.
+func missingIDs(ids []int, found map[int]bool) string {
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, strconv.Itoa(id))
+		}
+	}
+
+	return strings.Join(missing, ", ")
+}
+
 // createList is a handler that inserts a new row into the list table.
 func (a *Application) createList(w http.ResponseWriter, r *http.Request) {
 	var payload list.List
 
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "unmarshal request payload"))
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	var err error
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
 	if payload.Name == "" {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("name key is required"))
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
+		return
+	}
+
+	if err := normalizeChrome(&payload); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	l, err := list.CreateList(a.DB, payload)
+	var l list.List
+	err = a.change(r.Context(), events.ListCreated, func(tx *sqlx.Tx) (int, interface{}, error) {
+		var err error
+		l, err = list.CreateList(tx, payload)
+		return l.ID, l, err
+	})
 	if err != nil {
-		if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
-			if string(pgerr.Code) == db.PSQLErrUniqueConstraint {
-				web.RespondError(w, r, http.StatusBadRequest, errors.Wrap(err, "attempting to break unique name constraint"))
-				return
-			}
+		if respondQuotaExceeded(w, r, err) {
+			return
+		}
+
+		if errors.Cause(err) == list.ErrNameTaken {
+			a.respondListNameTaken(w, r, payload.Name)
+			return
 		}
 
 		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into list table"))
 		return
 	}
 
-	web.Respond(w, r, http.StatusCreated, l)
+	respondCreated(w, r, l)
 }
 
 // getList is a handler that gets a single row from the list table using a given
-// list_id.
+// list_id. Clients can revalidate their copy with If-Modified-Since, see
+// web.NotModified. With embed=settings the settings of the list are sent along with it.
 func (a *Application) getList(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -68,10 +203,19 @@ func (a *Application) getList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	l, err := list.SelectList(a.DB, listID)
+	var params struct {
+		Embed string `query:"embed" oneof:"settings"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	l, err := list.SelectList(a.database(r.Context()), listID)
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
@@ -79,11 +223,28 @@ func (a *Application) getList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Settings change without modifying the list, so lists along with their settings are
+	// always sent in full.
+	if params.Embed == "settings" {
+		s, err := list.SelectSettings(a.database(r.Context()), listID)
+		if err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list settings"))
+			return
+		}
+
+		web.Respond(w, r, http.StatusOK, listWithSettings{List: l, Settings: s})
+		return
+	}
+
+	if web.NotModified(w, r, l.Modified) {
+		return
+	}
+
 	web.Respond(w, r, http.StatusOK, l)
 }
 
 // updateList is a handler that updates a row from the list table using a given
-// list_id.
+// list_id. An icon or color left out of the payload is kept, an empty one is removed.
 func (a *Application) updateList(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -91,28 +252,54 @@ func (a *Application) updateList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload list.List
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "unmarshal request payload"))
+	current, err := list.SelectList(a.database(r.Context()), listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by id"))
+		return
+	}
+
+	// The icon and color are kept unless the payload gives them, so clients that only
+	// rename lists don't remove them.
+	payload := list.List{Icon: current.Icon, Color: current.Color}
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
 		return
 	}
 
 	payload.ID = listID
 
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
 	if payload.Name == "" {
-		web.RespondError(w, r, http.StatusBadRequest, errors.New("name key is required"))
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
 		return
 	}
 
-	if err := list.UpdateList(a.DB, payload); err != nil {
+	if err := normalizeChrome(&payload); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = a.change(r.Context(), events.ListUpdated, func(tx *sqlx.Tx) (int, interface{}, error) {
+		return listID, payload, list.UpdateList(tx, payload)
+	})
+	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
 		if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
 			if string(pgerr.Code) == db.PSQLErrUniqueConstraint {
-				web.RespondError(w, r, http.StatusBadRequest, errors.Wrap(err, "attempting to break unique name constraint"))
+				a.respondListNameTaken(w, r, payload.Name)
 				return
 			}
 		}
@@ -124,8 +311,33 @@ func (a *Application) updateList(w http.ResponseWriter, r *http.Request) {
 	web.Respond(w, r, http.StatusOK, payload)
 }
 
+// normalizeChrome trims the icon and color of l, lowercasing the color, and returns a
+// web.Errors holding a field error for each of them that is given but invalid, or nil if
+// there is none. An icon is a single emoji and a color a hex triplet such as #ff8800.
+func normalizeChrome(l *list.List) error {
+	l.Icon = strings.TrimSpace(l.Icon)
+	l.Color = strings.ToLower(strings.TrimSpace(l.Color))
+
+	var errs web.Errors
+
+	if l.Icon != "" && !validate.Emoji(l.Icon) {
+		errs = append(errs, web.NewFieldError("icon", codes.ListIconInvalid, l.Icon))
+	}
+
+	if l.Color != "" && !colorPattern.MatchString(l.Color) {
+		errs = append(errs, web.NewFieldError("color", codes.ListColorInvalid, l.Color))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
 // deleteList is a handler that deletes a row from the list table using a given
-// list_id.
+// list_id, along with its items and the files of their attachments. The deleted list is
+// returned when the client asks for it, see web.WantsRepresentation.
 func (a *Application) deleteList(w http.ResponseWriter, r *http.Request) {
 	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
 	if err != nil {
@@ -133,9 +345,24 @@ func (a *Application) deleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := list.DeleteList(a.DB, listID); err != nil {
+	var (
+		l    list.List
+		keys []string
+	)
+	err = a.change(r.Context(), events.ListDeleted, func(tx *sqlx.Tx) (int, interface{}, error) {
+		var err error
+		if a.Storage != nil {
+			if keys, err = attachment.SelectKeysOfLists(tx, []int{listID}); err != nil {
+				return listID, nil, err
+			}
+		}
+
+		l, err = list.DeleteList(tx, listID)
+		return listID, map[string]interface{}{"id": listID, "name": l.Name}, err
+	})
+	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
-			web.RespondError(w, r, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
 			return
 		}
 
@@ -143,5 +370,118 @@ func (a *Application) deleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	a.deleteFiles(r.Context(), keys)
+
+	if web.WantsRepresentation(w, r) {
+		web.Respond(w, r, http.StatusOK, l)
+		return
+	}
+
 	web.Respond(w, r, http.StatusNoContent, nil)
 }
+
+// deletion describes the lists removed by a bulk deletion, or the lists that would be
+// removed in a dry run, along with the total amount of their items.
+type deletion struct {
+	Lists []list.List `json:"lists"`
+	Items int         `json:"items"`
+}
+
+// deleteLists is a handler that deletes every list whose ID is in the JSON array of the
+// payload, along with their items and the files of their attachments, within a single
+// transaction. Nothing is deleted if any of the lists doesn't exist. A dry run previews
+// what would be deleted, see dryRunMW.
+func (a *Application) deleteLists(w http.ResponseWriter, r *http.Request) {
+	var ids []int
+	if err := a.decode(r, &ids); err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	if len(ids) == 0 || len(ids) > maxBatchSize {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.ListIDsInvalid, maxBatchSize, len(ids)))
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	lists, err := list.SelectListsForUpdate(tx, ids)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select lists by ids"))
+		return
+	}
+
+	found := make(map[int]bool, len(lists))
+	d := deletion{Lists: lists}
+	for _, l := range lists {
+		found[l.ID] = true
+		d.Items += l.ItemCount
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, strconv.Itoa(id))
+			found[id] = true
+		}
+	}
+
+	if len(missing) > 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.NewError(codes.ListsNotFound, strings.Join(missing, ", ")))
+		return
+	}
+
+	var keys []string
+	if a.Storage != nil {
+		if keys, err = attachment.SelectKeysOfLists(tx, ids); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select attachments of lists"))
+			return
+		}
+	}
+
+	if err := list.DeleteLists(tx, ids); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "delete lists by ids"))
+		return
+	}
+
+	for _, l := range lists {
+		if err := record(tx, events.ListDeleted, l.ID, map[string]interface{}{"id": l.ID, "name": l.Name}); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	a.deleteFiles(r.Context(), keys)
+	web.Respond(w, r, http.StatusOK, d)
+}
+
+// respondListNameTaken responds with 409 Conflict to a request whose list name is
+// already taken, along with the ID of the list that has the name so clients can use it
+// instead.
+func (a *Application) respondListNameTaken(w http.ResponseWriter, r *http.Request, name string) {
+	l, err := list.SelectListByName(a.database(r.Context()), name)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			// The list has been renamed or deleted in the meantime.
+			web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ListNameTaken))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by name"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusConflict, map[string]int{"id": l.ID}, web.NewError(codes.ListNameTaken))
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/maintenance"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/pkg/errors"
+)
+
+// readOnlyMW is a middleware that refuses write requests while ReadOnly is set, see
+// web.ReadOnly.Refuse. It runs before maintenanceMW, so writes are refused without
+// reaching the database. Requests to the paths given by exempt, or below them for the
+// ones ending in a slash, are served regardless, for routes that never write to the
+// database although their methods may write.
+func (a *Application) readOnlyMW(next http.Handler, exempt ...string) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range exempt {
+			if r.URL.Path == path || strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if a.ReadOnly.Refuse(w, r) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// maintenanceMW is a middleware that rejects requests to the write endpoints with a
+// 503 while maintenance mode is enabled. Reads are always let through. The state is
+// read from the database on every write so that toggling it takes effect on every
+// replica at once.
+func (a *Application) maintenanceMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s, err := maintenance.SelectState(a.database(r.Context()))
+		if err != nil {
+
+			// Failing open, if the database can't be reached the write fails on its own.
+			web.Logger(r.Context()).WithError(err).Warn("check maintenance mode")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.Enabled {
+			w.Header().Set("Retry-After", "120")
+			web.Respond(w, r, http.StatusServiceUnavailable, s, errors.New(s.Message))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// getMaintenance is a handler that returns the current maintenance state.
+func (a *Application) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	s, err := maintenance.SelectState(a.database(r.Context()))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select maintenance state"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, s)
+}
+
+// setMaintenance is a handler that enables or disables maintenance mode.
+func (a *Application) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Enabled *bool  `json:"enabled"`
+		Message string `json:"message"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.PayloadInvalid, err))
+		return
+	}
+
+	if payload.Enabled == nil {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.EnabledRequired))
+		return
+	}
+
+	s, err := maintenance.UpdateState(a.database(r.Context()), maintenance.State{
+		Enabled: *payload.Enabled,
+		Message: payload.Message,
+	})
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "update maintenance state"))
+		return
+	}
+
+	web.Logger(r.Context()).WithField("enabled", s.Enabled).Info("toggled maintenance mode")
+
+	web.Respond(w, r, http.StatusOK, s)
+}
@@ -0,0 +1,580 @@
+package handlers
+
+import (
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+)
+
+// MockList is a list a Mock starts out with, along with its items.
+type MockList struct {
+	Name  string
+	Items []item.Item
+}
+
+// Mock serves the list and item routes of the API from memory instead of the database,
+// so clients can be developed without running Postgres. Responses go through the same
+// middleware as the ones of the Application the Mock is made from, so they are
+// enveloped, indented, and carry their identifiers the same way. The documentation,
+// the demo UI, and the probes are served as well, the rest of the routes respond with
+// 501 Not Implemented. Changes are lost once the process exits.
+type Mock struct {
+	// Latency delays every response by at least this long, and Jitter by up to this much
+	// longer at random, so clients can be tried against a slow connection.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	app     *Application
+	handler http.Handler
+
+	mu       sync.Mutex
+	lists    []list.List
+	items    []item.Item
+	lastList int
+	lastItem int
+}
+
+// NewMock returns a new pointer to Mock serving the lists given by seed, which are
+// configured by a such as its Paging and Envelope. The database of a isn't used and may
+// be nil.
+func NewMock(a *Application, seed []MockList) *Mock {
+	m := Mock{app: a}
+
+	now := time.Now()
+	for _, sl := range seed {
+		l := m.addList(list.List{Name: sl.Name}, now)
+
+		for _, i := range sl.Items {
+			i.ListID = l.ID
+			m.addItem(i, now)
+		}
+	}
+
+	router := newRouter()
+	served := make(map[Route]bool)
+
+	handle := func(method, path string, h http.HandlerFunc) {
+		router.HandlerFunc(method, path, h)
+		served[Route{Method: method, Path: path}] = true
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	// Kubernetes Probes
+	handle(http.MethodGet, "/ready", ok)
+	handle(http.MethodGet, "/healthy", ok)
+
+	// Build Information, Enums, API Documentation, and Demo UI
+	handle(http.MethodGet, "/version", a.getVersion)
+	handle(http.MethodGet, "/meta/enums", a.getEnums)
+	handle(http.MethodGet, "/openapi.json", a.getOpenAPI)
+	handle(http.MethodGet, "/docs", a.getDocs)
+	handle(http.MethodGet, "/", a.getUI)
+	handle(http.MethodGet, "/ui/*filepath", a.getUIAsset)
+
+	// List Routes
+	handle(http.MethodGet, "/list", m.getLists)
+	handle(http.MethodPost, "/list", m.createList)
+	handle(http.MethodGet, "/list/:lid", m.getList)
+	handle(http.MethodPut, "/list/:lid", m.updateList)
+	handle(http.MethodDelete, "/list/:lid", m.deleteList)
+
+	// Item Routes
+	handle(http.MethodGet, "/list/:lid/item", m.getItems)
+	handle(http.MethodPost, "/list/:lid/item", m.createItem)
+	handle(http.MethodGet, "/list/:lid/item/:iid", m.getItem)
+	handle(http.MethodPut, "/list/:lid/item/:iid", m.updateItem)
+	handle(http.MethodDelete, "/list/:lid/item/:iid", m.deleteItem)
+
+	for _, route := range a.Routes() {
+		if !served[route] {
+			router.HandlerFunc(route.Method, route.Path, mockUnsupported)
+		}
+	}
+
+	m.handler = a.clientIPMW(a.logRulesMW(web.RequestMW(a.Log, m.latencyMW(a.prettyMW(a.envelopeMW(a.stringIDsMW(a.deprecationsMW(a.slashMW(router)))))))))
+
+	return &m
+}
+
+// ServeHTTP implements the http.Handler interface for the Mock type.
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// latencyMW is a middleware that delays requests by Latency and up to Jitter more.
+func (m *Mock) latencyMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		delay := m.Latency
+		if m.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(m.Jitter)))
+		}
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(f)
+}
+
+// mockUnsupported is a handler that responds to the routes a Mock doesn't serve with 501
+// Not Implemented.
+func mockUnsupported(w http.ResponseWriter, r *http.Request) {
+	web.RespondError(w, r, http.StatusNotImplemented, web.NewError(codes.MockUnsupported, r.Method, r.URL.Path))
+}
+
+// addList adds l to the lists as created at now and returns it. The caller holds mu
+// unless the Mock isn't served yet.
+func (m *Mock) addList(l list.List, now time.Time) list.List {
+	m.lastList++
+	l.ID = m.lastList
+	l.Created, l.Modified = now, now
+
+	m.lists = append(m.lists, l)
+	return l
+}
+
+// addItem adds i to the items of its list as created at now and returns it, counting it
+// in the list. The caller holds mu unless the Mock isn't served yet.
+func (m *Mock) addItem(i item.Item, now time.Time) item.Item {
+	m.lastItem++
+	i.ID = m.lastItem
+	i.Created, i.Modified = now, now
+
+	m.items = append(m.items, i)
+	m.lists[m.findList(i.ListID)].ItemCount++
+	return i
+}
+
+// findList returns the index of the list given by listID, or -1 if there is none. The
+// caller holds mu.
+func (m *Mock) findList(listID int) int {
+	for n, l := range m.lists {
+		if l.ID == listID {
+			return n
+		}
+	}
+
+	return -1
+}
+
+// findItem returns the index of the item given by itemID of the list given by listID, or
+// -1 if there is none. The caller holds mu.
+func (m *Mock) findItem(itemID, listID int) int {
+	for n, i := range m.items {
+		if i.ID == itemID && i.ListID == listID {
+			return n
+		}
+	}
+
+	return -1
+}
+
+// listNamed returns the list whose name is name regardless of case, other than the one
+// given by exceptID, reporting whether there is one. The caller holds mu.
+func (m *Mock) listNamed(name string, exceptID int) (list.List, bool) {
+	for _, l := range m.lists {
+		if l.ID != exceptID && strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+
+	return list.List{}, false
+}
+
+// itemNamed reports whether the list given by listID has an item whose name is name
+// regardless of case, other than the one given by exceptID. The caller holds mu.
+func (m *Mock) itemNamed(listID int, name string, exceptID int) bool {
+	for _, i := range m.items {
+		if i.ListID == listID && i.ID != exceptID && strings.EqualFold(i.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mockPage returns the part of a collection of n results that page holds, as the
+// indices of its first result and of the one after its last result.
+func mockPage(page web.Page, n int) (int, int) {
+	start := page.Offset
+	if start > n {
+		start = n
+	}
+
+	end := start + page.Limit
+	if end > n {
+		end = n
+	}
+
+	return start, end
+}
+
+// mockID returns the identifier given by the URL parameter of r with the given name, or
+// 0, which no list or item has, when it isn't a number such as in /list/abc.
+func mockID(r *http.Request, name string) int {
+	id, _ := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName(name))
+	return id
+}
+
+// getLists is a handler that returns a page of the lists, like Application.getLists does
+// without a locale.
+func (m *Mock) getLists(w http.ResponseWriter, r *http.Request) {
+	page, err := m.app.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var params struct {
+		Sort string `query:"sort" default:"id" oneof:"id -id name -name"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	m.mu.Lock()
+	lists := append([]list.List{}, m.lists...)
+	m.mu.Unlock()
+
+	byName := strings.TrimPrefix(params.Sort, "-") == "name"
+	desc := strings.HasPrefix(params.Sort, "-")
+
+	sort.SliceStable(lists, func(i, j int) bool {
+		if desc {
+			i, j = j, i
+		}
+
+		if byName {
+			return strings.ToLower(lists[i].Name) < strings.ToLower(lists[j].Name)
+		}
+
+		return lists[i].ID < lists[j].ID
+	})
+
+	start, end := mockPage(page, len(lists))
+
+	page.Total = len(lists)
+	web.RespondPage(w, r, http.StatusOK, lists[start:end], page)
+}
+
+// createList is a handler that adds a list, like Application.createList.
+func (m *Mock) createList(w http.ResponseWriter, r *http.Request) {
+	var payload list.List
+
+	if err := m.app.decode(r, &payload); err != nil {
+		m.app.respondPayloadError(w, r, err)
+		return
+	}
+
+	var err error
+	if payload.Name, err = m.app.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if payload.Name == "" {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
+		return
+	}
+
+	if err := normalizeChrome(&payload); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if taken, ok := m.listNamed(payload.Name, 0); ok {
+		web.Respond(w, r, http.StatusConflict, map[string]int{"id": taken.ID}, web.NewError(codes.ListNameTaken))
+		return
+	}
+
+	respondCreated(w, r, m.addList(list.List{Name: payload.Name, Icon: payload.Icon, Color: payload.Color}, time.Now()))
+}
+
+// getList is a handler that returns the list given by the lid URL parameter, like
+// Application.getList does without embedding its settings.
+func (m *Mock) getList(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findList(listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	if web.NotModified(w, r, m.lists[n].Modified) {
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, m.lists[n])
+}
+
+// updateList is a handler that updates the list given by the lid URL parameter, like
+// Application.updateList.
+func (m *Mock) updateList(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findList(listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	payload := list.List{Icon: m.lists[n].Icon, Color: m.lists[n].Color}
+	if err := m.app.decode(r, &payload); err != nil {
+		m.app.respondPayloadError(w, r, err)
+		return
+	}
+
+	var err error
+	if payload.Name, err = m.app.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if payload.Name == "" {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
+		return
+	}
+
+	if err := normalizeChrome(&payload); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if taken, ok := m.listNamed(payload.Name, listID); ok {
+		web.Respond(w, r, http.StatusConflict, map[string]int{"id": taken.ID}, web.NewError(codes.ListNameTaken))
+		return
+	}
+
+	l := &m.lists[n]
+	l.Name, l.Icon, l.Color, l.Modified = payload.Name, payload.Icon, payload.Color, time.Now()
+
+	web.Respond(w, r, http.StatusOK, *l)
+}
+
+// deleteList is a handler that deletes the list given by the lid URL parameter along
+// with its items, like Application.deleteList.
+func (m *Mock) deleteList(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findList(listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	l := m.lists[n]
+	m.lists = append(m.lists[:n], m.lists[n+1:]...)
+
+	items := m.items[:0]
+	for _, i := range m.items {
+		if i.ListID != listID {
+			items = append(items, i)
+		}
+	}
+	m.items = items
+
+	if web.WantsRepresentation(w, r) {
+		web.Respond(w, r, http.StatusOK, l)
+		return
+	}
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// getItems is a handler that returns a page of the items of the list given by the lid
+// URL parameter, like Application.getItems.
+func (m *Mock) getItems(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+
+	page, err := m.app.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findList(listID) < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	items := make([]item.Item, 0)
+	for _, i := range m.items {
+		if i.ListID == listID {
+			items = append(items, i)
+		}
+	}
+
+	start, end := mockPage(page, len(items))
+
+	page.Total = len(items)
+	web.RespondPage(w, r, http.StatusOK, items[start:end], page)
+}
+
+// createItem is a handler that adds an item to the list given by the lid URL parameter,
+// like Application.createItem does without merging.
+func (m *Mock) createItem(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+
+	var payload item.Item
+	if err := m.app.decode(r, &payload); err != nil {
+		m.app.respondPayloadError(w, r, err)
+		return
+	}
+
+	payload.ListID = listID
+
+	var err error
+	if payload.Name, err = m.app.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := m.app.validateItem(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findList(listID) < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	if m.itemNamed(listID, payload.Name, 0) {
+		web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ItemNameTaken))
+		return
+	}
+
+	respondCreated(w, r, m.addItem(item.Item{ListID: listID, Name: payload.Name, Quantity: payload.Quantity, Unit: payload.Unit}, time.Now()))
+}
+
+// getItem is a handler that returns the item given by the lid and iid URL parameters,
+// like Application.getItem.
+func (m *Mock) getItem(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+	itemID := mockID(r, "iid")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findItem(itemID, listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	if web.NotModified(w, r, m.items[n].Modified) {
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, m.items[n])
+}
+
+// updateItem is a handler that updates the item given by the lid and iid URL
+// parameters, like Application.updateItem.
+func (m *Mock) updateItem(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+	itemID := mockID(r, "iid")
+
+	var payload item.Item
+	if err := m.app.decode(r, &payload); err != nil {
+		m.app.respondPayloadError(w, r, err)
+		return
+	}
+
+	var err error
+	if payload.Name, err = m.app.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := m.app.validateItem(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findItem(itemID, listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	if m.itemNamed(listID, payload.Name, itemID) {
+		web.RespondError(w, r, http.StatusConflict, web.NewError(codes.ItemNameTaken))
+		return
+	}
+
+	i := &m.items[n]
+	i.Name, i.Quantity, i.Unit, i.Modified = payload.Name, payload.Quantity, payload.Unit, time.Now()
+
+	web.Respond(w, r, http.StatusOK, *i)
+}
+
+// deleteItem is a handler that deletes the item given by the lid and iid URL
+// parameters, like Application.deleteItem.
+func (m *Mock) deleteItem(w http.ResponseWriter, r *http.Request) {
+	listID := mockID(r, "lid")
+	itemID := mockID(r, "iid")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.findItem(itemID, listID)
+	if n < 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	i := m.items[n]
+	m.items = append(m.items[:n], m.items[n+1:]...)
+	m.lists[m.findList(listID)].ItemCount--
+
+	if web.WantsRepresentation(w, r) {
+		web.Respond(w, r, http.StatusOK, i)
+		return
+	}
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// observe serves r with the public handler and tells Observe about it once it is served.
+func (a *Application) observe(w http.ResponseWriter, r *http.Request) {
+	ow := observedWriter{ResponseWriter: w}
+
+	start := time.Now()
+	a.handler.ServeHTTP(&ow, r)
+	took := time.Since(start)
+
+	if ow.status == 0 {
+		ow.status = http.StatusOK
+	}
+
+	a.Observe(r, a.routeOf(r.Method, r.URL.Path), ow.status, took)
+}
+
+// routeOf returns the route serving requests with the given method and path, or the zero
+// Route when there is none. Routes with fewer parameters are preferred, as the router
+// prefers static segments.
+func (a *Application) routeOf(method, path string) Route {
+	var found Route
+	params := -1
+
+	for _, route := range a.routes {
+		if route.Method != method || !matchRoute(route.Path, path) {
+			continue
+		}
+
+		if n := strings.Count(route.Path, ":") + strings.Count(route.Path, "*"); params == -1 || n < params {
+			found, params = route, n
+		}
+	}
+
+	return found
+}
+
+// observedWriter is an http.ResponseWriter that keeps the status of the response.
+type observedWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *observedWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *observedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements the http.Flusher interface when the wrapped writer does.
+func (w *observedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// explainTimeout is the time the database is given to plan the query of a slow request.
+const explainTimeout = 5 * time.Second
+
+// planMW is a middleware that traces the queries of requests while the query plans
+// feature is enabled, and logs the plan of the slowest query of every request that took
+// longer than SlowRequest at the debug level, along with its request ID. The query is
+// only planned again, never run, and its arguments are kept out of the logs.
+func (a *Application) planMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if a.SlowRequest <= 0 || !a.Features.Enabled(features.QueryPlans) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		trace := new(db.Trace)
+		start := time.Now()
+
+		next.ServeHTTP(w, r.WithContext(db.WithTrace(r.Context(), trace)))
+
+		elapsed := time.Since(start)
+		if elapsed < a.SlowRequest {
+			return
+		}
+
+		q, ok := trace.Slowest()
+		if !ok {
+			return
+		}
+
+		log := web.Logger(r.Context()).WithField("duration", elapsed).WithField("query", q.SQL).WithField("queryDuration", q.Duration)
+
+		// The request may have been canceled by now, the plan is wanted all the same.
+		ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+		defer cancel()
+
+		plan, err := db.Explain(ctx, a.pool(r.Context()), q)
+		if err != nil {
+			log.WithError(err).Debug("explain slow request")
+			return
+		}
+
+		log.WithField("plan", plan).Debug("slow request")
+	}
+
+	return http.HandlerFunc(f)
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/template"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/ratelimit"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// The public read API serves the lists that are shared and the templates that are
+// published to anyone, without authentication, see PublicRead. It only ever reads and
+// hands out nothing that isn't meant to be public, such as the ids of lists and items.
+
+// sharedPath returns the path of the list shared by token on the public read API.
+func sharedPath(token string) string {
+	return "/shared/" + token
+}
+
+// listShare is the Share of a list along with the path it is read at on the public read
+// API, see shareList.
+type listShare struct {
+	list.Share
+	Path string `json:"path"`
+}
+
+// sharedList is a list as it is read on the public read API, see getSharedList.
+type sharedList struct {
+	Name     string       `json:"name"`
+	Icon     string       `json:"icon"`
+	Color    string       `json:"color"`
+	Modified time.Time    `json:"modified"`
+	Items    []sharedItem `json:"items"`
+}
+
+// sharedItem is an item of a sharedList.
+type sharedItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Unit     string `json:"unit"`
+}
+
+// corsMW is a middleware that lets the pages of the origins allowed by CORS read the
+// responses of the public read API, answering their preflight requests on its own.
+func (a *Application) corsMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if a.CORS.Handle(w, r) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// publicLimitMW is a middleware that refuses the requests of clients over the rate limit
+// of the public read API, see allow. Clients are told apart by their IP address, see
+// clientIPMW.
+func (a *Application) publicLimitMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if !allow(w, r, a.PublicReadLimit, web.ClientIP(r.Context())) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// allow reports whether l allows the request r counted against key, and responds to it
+// with a 429 otherwise, telling the client when to try again by the Retry-After header.
+func allow(w http.ResponseWriter, r *http.Request, l *ratelimit.Limiter, key string) bool {
+	wait, ok := l.Allow(key, time.Now())
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		web.RespondError(w, r, http.StatusTooManyRequests, web.NewError(codes.RateLimited))
+	}
+
+	return ok
+}
+
+// getSharedList is a handler that returns the list shared by the token URL parameter
+// along with its items. The requests made with a token are limited by SharedLimit on top
+// of the limit of every client, so a token that was leaked can't be read at will.
+func (a *Application) getSharedList(w http.ResponseWriter, r *http.Request) {
+	token := httprouter.ParamsFromContext(r.Context()).ByName("token")
+	if !allow(w, r, a.SharedLimit, token) {
+		return
+	}
+
+	dbc := a.database(r.Context())
+
+	l, err := list.SelectShared(dbc, token)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select shared list"))
+		return
+	}
+
+	items, err := item.SelectItems(dbc, l.ID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select items of shared list"))
+		return
+	}
+
+	shared := sharedList{
+		Name:     l.Name,
+		Icon:     l.Icon,
+		Color:    l.Color,
+		Modified: l.Modified,
+		Items:    make([]sharedItem, len(items)),
+	}
+
+	for i, it := range items {
+		shared.Items[i] = sharedItem{Name: it.Name, Quantity: it.Quantity, Unit: it.Unit}
+	}
+
+	web.Respond(w, r, http.StatusOK, shared)
+}
+
+// getPublicTemplates is a handler that returns the published rows from the template
+// table, up to the most results a response holds. The rest are returned by following the
+// cursor of the response.
+func (a *Application) getPublicTemplates(w http.ResponseWriter, r *http.Request) {
+	after, err := web.ParseCursor(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	max := a.Paging.Results()
+
+	templates, err := template.SelectPublicTemplates(a.database(r.Context()), after, max+1)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select public templates"))
+		return
+	}
+
+	var cursor string
+	if len(templates) > max {
+		templates = templates[:max]
+		cursor = web.NewCursor(templates[max-1].ID)
+	}
+
+	web.RespondPartial(w, r, http.StatusOK, templates, cursor)
+}
+
+// getPublicTemplate is a handler that returns a published row from the template table
+// based off of the tid URL parameter. Templates that aren't published are not found.
+func (a *Application) getPublicTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("tid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	t, err := template.SelectTemplate(a.database(r.Context()), templateID)
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select template by id"))
+		return
+	}
+
+	if err != nil || !t.Public {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, t)
+}
+
+// shareList is a handler that shares the list given by the lid URL parameter on the
+// public read API for ShareTTL, responding with a 201 when it is handed out a new token.
+// A list that already is shared keeps its token, which is responded with a 200, and is
+// shared for ShareTTL from now on.
+func (a *Application) shareList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	s, created, err := list.ShareList(tx, listID, a.ShareTTL)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "share list"))
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+
+	web.Respond(w, r, code, listShare{Share: s, Path: sharedPath(s.Token)})
+}
+
+// getListShare is a handler that returns the share of the list given by the lid URL
+// parameter, which is not found while the list isn't shared or its share expired.
+func (a *Application) getListShare(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	s, err := list.SelectShare(a.database(r.Context()), listID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list share"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, listShare{Share: s, Path: sharedPath(s.Token)})
+}
+
+// unshareList is a handler that revokes the share of the list given by the lid URL
+// parameter, after which its token no longer reads the list. Sharing the list again
+// hands out a new token.
+func (a *Application) unshareList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	if err := list.Unshare(tx, listID); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "unshare list"))
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// getShares is a handler that returns a page of the shares that haven't expired, the most
+// recently created first, so operators can tell which lists can be read by whom.
+func (a *Application) getShares(w http.ResponseWriter, r *http.Request) {
+	page, err := a.Paging.Parse(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	shares, total, err := list.SelectShares(a.database(r.Context()), page.Limit, page.Offset)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select page of shares"))
+		return
+	}
+
+	page.Total = total
+	web.RespondPage(w, r, http.StatusOK, shares, page)
+}
+
+// revokeShare is a handler that revokes the share given by the token URL parameter, like
+// unshareList does for the list it shares.
+func (a *Application) revokeShare(w http.ResponseWriter, r *http.Request) {
+	if err := list.Revoke(a.database(r.Context()), httprouter.ParamsFromContext(r.Context()).ByName("token")); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "revoke share"))
+		return
+	}
+
+	a.Cache.Purge()
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
+
+// publishTemplate returns a handler that publishes the template given by the tid URL
+// parameter on the public read API when public is true, or withdraws it from it.
+func (a *Application) publishTemplate(public bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("tid"))
+		if err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert template id to integer"))
+			return
+		}
+
+		tx, err := a.begin(r.Context())
+		if err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+			return
+		}
+
+		// Rolling back after a commit is a no-op.
+		defer a.rollback(r.Context(), tx)
+
+		t, err := template.SetPublic(tx, templateID, public)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "set public of template"))
+			return
+		}
+
+		if err := a.commit(r.Context(), tx); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+			return
+		}
+
+		web.Respond(w, r, http.StatusOK, t)
+	}
+}
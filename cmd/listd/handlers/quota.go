@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/quota"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/pkg/errors"
+)
+
+// quotaError returns the error responded with when err comes from creating a list or
+// item beyond the quota, naming the limit, or nil when it doesn't.
+func quotaError(err error) *web.Error {
+	table, limit, ok := quota.Exceeded(err)
+	if !ok {
+		return nil
+	}
+
+	if table == "list" {
+		return web.NewError(codes.ListQuotaExceeded, limit)
+	}
+
+	return web.NewError(codes.ItemQuotaExceeded, limit)
+}
+
+// respondQuotaExceeded responds with 422 Unprocessable Entity when err comes from creating
+// a list or item beyond the quota, and reports whether it did, see quotaError.
+func respondQuotaExceeded(w http.ResponseWriter, r *http.Request, err error) bool {
+	qerr := quotaError(err)
+	if qerr == nil {
+		return false
+	}
+
+	web.RespondError(w, r, http.StatusUnprocessableEntity, qerr)
+	return true
+}
+
+// getQuota is a handler that returns the quota of the lists and items of the tenant.
+func (a *Application) getQuota(w http.ResponseWriter, r *http.Request) {
+	q, err := quota.SelectQuota(a.database(r.Context()))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select quota"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, q)
+}
+
+// setQuota is a handler that sets the limits of the lists and items of the tenant. A
+// limit that is null or left out of the payload goes back to the configured default.
+// Lists and items beyond a lowered limit are kept, only new ones are rejected.
+func (a *Application) setQuota(w http.ResponseWriter, r *http.Request) {
+	var payload quota.Quota
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.PayloadInvalid, err))
+		return
+	}
+
+	var errs web.Errors
+	if payload.MaxLists != nil && *payload.MaxLists < 0 {
+		errs = append(errs, web.NewFieldError("max_lists", codes.QuotaInvalid, "max_lists", *payload.MaxLists))
+	}
+	if payload.MaxItems != nil && *payload.MaxItems < 0 {
+		errs = append(errs, web.NewFieldError("max_items_per_list", codes.QuotaInvalid, "max_items_per_list", *payload.MaxItems))
+	}
+
+	if len(errs) > 0 {
+		web.RespondError(w, r, http.StatusBadRequest, errs)
+		return
+	}
+
+	q, err := quota.UpdateQuota(a.database(r.Context()), payload)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "update quota"))
+		return
+	}
+
+	web.Logger(r.Context()).WithField("maxLists", q.Lists()).WithField("maxItems", q.Items()).Info("set quota")
+
+	web.Respond(w, r, http.StatusOK, q)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+)
+
+// inflightMW is a middleware that keeps track of every request while it is served, see
+// Requests.
+func (a *Application) inflightMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		a.Requests.Serve(w, r, next)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// getRequests is a handler that returns the public requests being served, the longest
+// running first.
+func (a *Application) getRequests(w http.ResponseWriter, r *http.Request) {
+	web.Respond(w, r, http.StatusOK, a.Requests.List())
+}
+
+// cancelRequest is a handler that cancels the context of the public requests being served
+// with the given ID. Transactions of canceled requests are rolled back.
+func (a *Application) cancelRequest(w http.ResponseWriter, r *http.Request) {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	if a.Requests.Cancel(id) == 0 {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	web.Logger(r.Context()).WithField("canceledRequestID", id).Warn("canceled request")
+
+	web.Respond(w, r, http.StatusNoContent, nil)
+}
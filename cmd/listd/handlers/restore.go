@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// restoreList is a handler that creates a new list holding the list given by the lid URL
+// parameter and its items as they were at the time given by the at query parameter, now
+// when it isn't given. The list is reconstructed from the events of its changes kept in
+// the outbox, so it can be restored to any time within the outbox retention, even after
+// it has been deleted. The new list is named by the name query parameter, or after the
+// restored list otherwise.
+func (a *Application) restoreList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	var params struct {
+		At   time.Time `query:"at"`
+		Name string    `query:"name"`
+	}
+
+	if err := web.DecodeQuery(r, &params); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if params.At.IsZero() {
+		params.At = time.Now()
+	}
+
+	evts, err := outbox.SelectListHistory(a.database(r.Context()), listID)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list history"))
+		return
+	}
+
+	l, items, err := replayList(evts, listID, params.At)
+	if err != nil {
+		switch e := errors.Cause(err).(type) {
+		case *web.Error:
+			web.RespondError(w, r, http.StatusUnprocessableEntity, e)
+		default:
+			if e == sql.ErrNoRows {
+				web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "replay list history"))
+		}
+		return
+	}
+
+	if params.Name == "" {
+		params.Name = l.Name + " (restored)"
+	}
+
+	if l.Name, err = a.Names.Normalize("name", params.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	if l, err = list.CreateList(tx, l); err != nil {
+		if respondQuotaExceeded(w, r, err) {
+			return
+		}
+
+		if errors.Cause(err) == list.ErrNameTaken {
+			a.respondListNameTaken(w, r, l.Name)
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into list table"))
+		return
+	}
+
+	if err := record(tx, events.ListCreated, l.ID, l); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, i := range items {
+		i, err := item.CreateItem(tx, item.Item{ListID: l.ID, Name: i.Name, Quantity: i.Quantity, Unit: i.Unit})
+		if err != nil {
+			if respondQuotaExceeded(w, r, err) {
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into item table"))
+			return
+		}
+
+		if err := record(tx, events.ItemCreated, l.ID, i); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	l.ItemCount = len(items)
+
+	respondCreated(w, r, l)
+}
+
+// replayList applies the events of the list given by listID, oldest first, up to the given
+// time and returns the list along with its items as they were then, in the order they
+// were created. sql.ErrNoRows is returned when there are no events of the list, and an
+// *web.Error when the list didn't exist at the time or its events from back then have
+// been purged.
+func replayList(evts []events.Event, listID int, at time.Time) (list.List, []item.Item, error) {
+	if len(evts) == 0 {
+		return list.List{}, nil, sql.ErrNoRows
+	}
+
+	// Every list starts with its creation, any other first event means the ones before
+	// have been purged.
+	if evts[0].Type != events.ListCreated {
+		return list.List{}, nil, web.NewError(codes.ListHistoryUnavailable, evts[0].Time.Format(time.RFC3339))
+	}
+
+	if evts[0].Time.After(at) {
+		return list.List{}, nil, web.NewError(codes.ListAbsent, at.Format(time.RFC3339))
+	}
+
+	var l list.List
+	items := make(map[int]item.Item)
+
+	for _, e := range evts {
+		if e.Time.After(at) {
+			break
+		}
+
+		switch e.Type {
+		case events.ListCreated, events.ListUpdated:
+			if err := json.Unmarshal(e.Data, &l); err != nil {
+				return list.List{}, nil, errors.Wrapf(err, "unmarshal data of event %s", e.ID)
+			}
+
+		case events.ListDeleted:
+			return list.List{}, nil, web.NewError(codes.ListAbsent, at.Format(time.RFC3339))
+
+		case events.ItemCreated, events.ItemUpdated, events.ItemDeleted:
+			var i item.Item
+			if err := json.Unmarshal(e.Data, &i); err != nil {
+				return list.List{}, nil, errors.Wrapf(err, "unmarshal data of event %s", e.ID)
+			}
+
+			// Items moving to another list leave this one.
+			if e.Type == events.ItemDeleted || i.ListID != listID {
+				delete(items, i.ID)
+				continue
+			}
+
+			items[i.ID] = i
+		}
+	}
+
+	ordered := make([]item.Item, 0, len(items))
+	for _, i := range items {
+		ordered = append(ordered, i)
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	return l, ordered, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// noTimeout is the timeout of routes that are only bounded by the write timeout of the
+// server, see withTimeout.
+const noTimeout time.Duration = 0
+
+// routeOption declares how a single route is served, see NewApplication.
+type routeOption func(*routeConfig)
+
+// routeConfig is what a route declares for itself. Whatever it leaves unset falls back to
+// the settings of the Application.
+type routeConfig struct {
+	middleware  []func(http.Handler) http.Handler
+	timeout     *time.Duration
+	maxBodySize int64
+	unsigned    bool
+}
+
+// withMiddleware wraps the handler of a route in mws, the first of which is outermost.
+// They run after the middleware of every route.
+func withMiddleware(mws ...func(http.Handler) http.Handler) routeOption {
+	return func(rc *routeConfig) {
+		rc.middleware = append(rc.middleware, mws...)
+	}
+}
+
+// withTimeout gives a route d to respond instead of the RequestTimeout, or no timeout
+// but the write timeout of the server when d is noTimeout.
+func withTimeout(d time.Duration) routeOption {
+	return func(rc *routeConfig) {
+		rc.timeout = &d
+	}
+}
+
+// withMaxBodySize limits the request bodies of a route to n bytes instead of MaxBodySize.
+func withMaxBodySize(n int64) routeOption {
+	return func(rc *routeConfig) {
+		rc.maxBodySize = n
+	}
+}
+
+// routeMW is a middleware that serves a route as declared by rc. It limits the size of
+// the request body, see respondPayloadError, and gives the context of the request a
+// deadline, which clients may change with the X-Request-Timeout header, see
+// clampTimeout. The settings of the Application are read on every request, since they are
+// set after the routes are declared.
+func (a *Application) routeMW(rc routeConfig, next http.Handler) http.Handler {
+	for i := len(rc.middleware) - 1; i >= 0; i-- {
+		next = rc.middleware[i](next)
+	}
+
+	f := func(w http.ResponseWriter, r *http.Request) {
+		max := rc.maxBodySize
+		if max == 0 {
+			max = a.MaxBodySize
+		}
+		if max == 0 {
+			max = web.DefaultMaxBodySize
+		}
+
+		r = web.LimitBody(w, r, max)
+
+		timeout := a.RequestTimeout
+		if rc.timeout != nil {
+			timeout = *rc.timeout
+		}
+
+		asked, err := web.ParseTimeout(r)
+		if err != nil {
+			web.RespondError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if asked > 0 {
+			timeout = a.clampTimeout(asked, timeout)
+		}
+
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// clampTimeout returns the timeout of a request whose client asked for the given one to
+// a route with the given timeout, noTimeout if it has none. Clients may shorten the
+// timeout of a route as they like, so they fail fast, but only lengthen it up to
+// MaxRequestTimeout.
+func (a *Application) clampTimeout(asked, route time.Duration) time.Duration {
+	if route == noTimeout || asked <= route {
+		return asked
+	}
+
+	max := a.MaxRequestTimeout
+	if max < route {
+		max = route
+	}
+
+	if asked > max {
+		return max
+	}
+
+	return asked
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/pkg/errors"
+)
+
+// getSchema is a handler that returns the live schema of the database, its tables with
+// their columns and indexes, along with the migrations that built it.
+func (a *Application) getSchema(w http.ResponseWriter, r *http.Request) {
+	s, err := db.Inspect(a.pool(r.Context()))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "inspect schema"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, s)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+// settingsSort is the enum of the values the sort setting of a list can take.
+var settingsSort = web.NewEnum("settingsSort", codes.SettingsSortInvalid, false, "name", "-name", "quantity", "-quantity", "created", "-created", "modified", "-modified")
+
+// colorPattern matches the hex triplets the color setting of a list can take.
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// listWithSettings is a list along with its settings, see getList.
+type listWithSettings struct {
+	list.List
+	Settings list.Settings `json:"settings"`
+}
+
+// getListSettings is a handler that returns the settings of the list given by the lid
+// URL parameter.
+func (a *Application) getListSettings(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	if _, err := list.SelectList(a.database(r.Context()), listID); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by id"))
+		return
+	}
+
+	s, err := list.SelectSettings(a.database(r.Context()), listID)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list settings"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, s)
+}
+
+// updateListSettings is a handler that replaces the settings of the list given by the lid
+// URL parameter. Settings left out of the payload are reset to their default.
+func (a *Application) updateListSettings(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	payload := list.DefaultSettings
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	if err := validateSettings(&payload); err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	if err := list.UpdateSettings(tx, listID, payload); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "update list settings"))
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, payload)
+}
+
+// validateSettings returns a web.Errors holding a field error for every setting of s that
+// is invalid, or nil if there is none. The sort of s is set to its spelling among the
+// values of settingsSort.
+func validateSettings(s *list.Settings) error {
+	var errs web.Errors
+
+	if sort, err := settingsSort.Parse("sort", s.Sort); err != nil {
+		errs = append(errs, err)
+	} else {
+		s.Sort = sort
+	}
+
+	if s.Color != "" && !colorPattern.MatchString(s.Color) {
+		errs = append(errs, web.NewFieldError("color", codes.SettingsColorInvalid, s.Color))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
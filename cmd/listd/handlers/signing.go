@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
+	"github.com/pkg/errors"
+)
+
+// withoutSignature lets the requests of a route through unsigned even when
+// RequireSignatures is set, for the routes requested by browsers and orchestrators, such
+// as the probes and the documentation. Signed requests are still verified.
+func withoutSignature() routeOption {
+	return func(rc *routeConfig) {
+		rc.unsigned = true
+	}
+}
+
+// signatureMW is a middleware that verifies the signature of signed requests, see
+// package signing, and rejects unsigned requests while RequireSignatures is set. It runs
+// before the response cache, so cached responses aren't served to unsigned requests.
+// The body of a signed request is read to verify it, up to the largest size any route
+// accepts, and replaced so the handler can read it again.
+func (a *Application) signatureMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		if a.Signatures == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Header.Get(signing.Header) == "" {
+			if a.RequireSignatures && !a.unsignedRoute(r.Method, r.URL.Path) {
+				w.Header().Set("WWW-Authenticate", "Signature")
+				web.RespondError(w, r, http.StatusUnauthorized, web.NewError(codes.SignatureRequired, signing.Header))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = web.LimitBody(w, r, maxRowImportSize)
+
+		key, err := a.Signatures.Verify(r, time.Now())
+
+		var rejected *web.Error
+		switch errors.Cause(err) {
+		case nil:
+			next.ServeHTTP(w, r)
+			return
+		case signing.ErrExpired:
+			rejected = web.NewError(codes.SignatureExpired)
+		case signing.ErrReplayed:
+			rejected = web.NewError(codes.SignatureReplayed)
+		case signing.ErrMalformed, signing.ErrUnknownKey, signing.ErrMismatch:
+			rejected = web.NewError(codes.SignatureInvalid)
+		default:
+			a.respondPayloadError(w, r, err)
+			return
+		}
+
+		web.Logger(r.Context()).WithError(err).WithField("key", key).Warn("rejected request signature")
+
+		w.Header().Set("WWW-Authenticate", "Signature")
+		web.RespondError(w, r, http.StatusUnauthorized, rejected)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// unsignedRoute reports whether a request with the given method and path is served by a
+// route declared withoutSignature.
+func (a *Application) unsignedRoute(method, path string) bool {
+	for _, route := range a.unsigned {
+		if route.Method == method && matchRoute(route.Path, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchRoute reports whether path is matched by the httprouter path pattern, whose
+// :name parameters match a single segment and whose *name parameter matches the rest.
+func matchRoute(pattern, path string) bool {
+	patterns, segments := strings.Split(pattern, "/"), strings.Split(path, "/")
+
+	for i, p := range patterns {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+
+		if i >= len(segments) || (!strings.HasPrefix(p, ":") && p != segments[i]) {
+			return false
+		}
+	}
+
+	return len(patterns) == len(segments)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/template"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/julienschmidt/httprouter"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// templatePayload is the request body of the template handlers.
+type templatePayload struct {
+	Name string `json:"name"`
+}
+
+// getTemplates is a handler that returns the rows from the template table, up to the most
+// results a response holds. The rest are returned by following the cursor of the response.
+func (a *Application) getTemplates(w http.ResponseWriter, r *http.Request) {
+	after, err := web.ParseCursor(r)
+	if err != nil {
+		web.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	max := a.Paging.Results()
+
+	templates, err := template.SelectTemplates(a.database(r.Context()), after, max+1)
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select templates"))
+		return
+	}
+
+	var cursor string
+	if len(templates) > max {
+		templates = templates[:max]
+		cursor = web.NewCursor(templates[max-1].ID)
+	}
+
+	web.RespondPartial(w, r, http.StatusOK, templates, cursor)
+}
+
+// getTemplate is a handler that returns a row from the template table based off of the
+// tid URL parameter.
+func (a *Application) getTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("tid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert template id to integer"))
+		return
+	}
+
+	t, err := template.SelectTemplate(a.database(r.Context()), templateID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select template by id"))
+		return
+	}
+
+	web.Respond(w, r, http.StatusOK, t)
+}
+
+// saveTemplate is a handler that saves the items of the list given by the lid URL
+// parameter as a new template. The template is named after the list unless a name is
+// given in the payload.
+func (a *Application) saveTemplate(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("lid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert list id to integer"))
+		return
+	}
+
+	var payload templatePayload
+	if r.ContentLength != 0 {
+		if err := a.decode(r, &payload); err != nil {
+			a.respondPayloadError(w, r, err)
+			return
+		}
+	}
+
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	if payload.Name == "" {
+		l, err := list.SelectList(tx, listID)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select list by id"))
+			return
+		}
+
+		payload.Name = l.Name
+	}
+
+	t, err := template.FromList(tx, listID, payload.Name)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		if pgerr, ok := errors.Cause(err).(*pq.Error); ok {
+			if string(pgerr.Code) == db.PSQLErrUniqueConstraint {
+				web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.TemplateNameTaken))
+				return
+			}
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into template table"))
+		return
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	respondCreated(w, r, t)
+}
+
+// instantiateTemplate is a handler that creates a new list with the name given in the
+// payload, containing a copy of every item of the template given by the tid URL
+// parameter.
+func (a *Application) instantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.Atoi(httprouter.ParamsFromContext(r.Context()).ByName("tid"))
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "convert template id to integer"))
+		return
+	}
+
+	var payload templatePayload
+	if err := a.decode(r, &payload); err != nil {
+		a.respondPayloadError(w, r, err)
+		return
+	}
+
+	if payload.Name, err = a.Names.Normalize("name", payload.Name); err != nil {
+		web.RespondError(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if payload.Name == "" {
+		web.RespondError(w, r, http.StatusBadRequest, web.NewError(codes.NameRequired))
+		return
+	}
+
+	tx, err := a.begin(r.Context())
+	if err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin transaction"))
+		return
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer a.rollback(r.Context(), tx)
+
+	t, err := template.SelectTemplate(tx, templateID)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "select template by id"))
+		return
+	}
+
+	l, err := list.CreateList(tx, list.List{Name: payload.Name})
+	if err != nil {
+		if respondQuotaExceeded(w, r, err) {
+			return
+		}
+
+		if errors.Cause(err) == list.ErrNameTaken {
+			a.respondListNameTaken(w, r, payload.Name)
+			return
+		}
+
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into list table"))
+		return
+	}
+
+	if err := record(tx, events.ListCreated, l.ID, l); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, ti := range t.Items {
+		i, err := item.CreateItem(tx, item.Item{ListID: l.ID, Name: ti.Name, Quantity: ti.Quantity, Unit: ti.Unit})
+		if err != nil {
+			if respondQuotaExceeded(w, r, err) {
+				return
+			}
+
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "insert row into item table"))
+			return
+		}
+
+		if err := record(tx, events.ItemCreated, l.ID, i); err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err := a.commit(r.Context(), tx); err != nil {
+		web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit transaction"))
+		return
+	}
+
+	respondCreated(w, r, l)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// Tenants serves each request with the handler of the tenant named by the subdomain of
+// its Host, such as acme for acme.lists.example.com and the Domain lists.example.com.
+// Every tenant has an Application of its own whose database connection is for the schema
+// of the tenant, so neither their data nor their response caches are shared.
+type Tenants struct {
+	// Domain is the domain whose subdomains name tenants.
+	Domain string
+
+	// Handlers maps tenants to the handler serving their requests.
+	Handlers map[string]http.Handler
+
+	// Default serves the requests that don't name a tenant, such as those to Domain
+	// itself and the health checks of the orchestrator, which address the daemon by its
+	// IP address.
+	Default http.Handler
+}
+
+// ServeHTTP implements the http.Handler interface for the Tenants type. Requests naming
+// a tenant that doesn't exist are answered with a 404.
+func (t Tenants) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := web.Subdomain(r.Host, t.Domain)
+	if !ok {
+		t.Default.ServeHTTP(w, r)
+		return
+	}
+
+	h, ok := t.Handlers[tenant]
+	if !ok {
+		web.RespondError(w, r, http.StatusNotFound, web.StatusError(http.StatusNotFound))
+		return
+	}
+
+	h.ServeHTTP(w, r)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// txMW is a middleware that serves every write request within a transaction of its own
+// when RequestTx is set, see web.Scope. Handlers begin, roll back, and commit it through
+// begin, rollback, and commit like any other transaction, but it is only committed once
+// the handler responded with a 2xx, and rolled back otherwise. The response is held back
+// until then, so a failed commit is answered with a 500 instead. What handlers defer
+// until their changes are committed, see afterCommit, is run after the commit.
+func (a *Application) txMW(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !a.RequestTx {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tx, err := a.pool(r.Context()).BeginTxx(r.Context(), nil)
+		if err != nil {
+			web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "begin request transaction"))
+			return
+		}
+
+		// Rolling back after a commit is a no-op.
+		defer tx.Rollback()
+
+		s := web.ScopeOf(r.Context()).WithTx(tx)
+
+		rec := txRecorder{
+			header: make(http.Header),
+		}
+		next.ServeHTTP(&rec, web.WithScope(r, s))
+
+		if rec.code >= 200 && rec.code < 300 && !web.DryRun(r.Context()) {
+			if err := tx.Commit(); err != nil {
+				web.RespondError(w, r, http.StatusInternalServerError, errors.Wrap(err, "commit request transaction"))
+				return
+			}
+
+			a.Cache.Purge()
+			s.Committed()
+		}
+
+		rec.writeTo(w)
+	}
+
+	return http.HandlerFunc(f)
+}
+
+// begin begins a transaction for the request ctx belongs to, or returns the transaction
+// of the request in transaction mode, see txMW.
+func (a *Application) begin(ctx context.Context) (*sqlx.Tx, error) {
+	if tx := web.ScopeOf(ctx).Tx; tx != nil {
+		return tx, nil
+	}
+
+	return a.pool(ctx).BeginTxx(ctx, nil)
+}
+
+// afterCommit runs fn once the changes of the request ctx belongs to are committed, for
+// changes that can't be rolled back, such as deleting files. Handlers call it once they
+// committed, it runs fn right away unless the request is served within a transaction
+// that txMW is yet to commit, in which case fn isn't run if that commit fails.
+func (a *Application) afterCommit(ctx context.Context, fn func()) {
+	if !web.ScopeOf(ctx).OnCommit(fn) {
+		fn()
+	}
+}
+
+// rollback rolls tx back unless it is the transaction of the request ctx belongs to,
+// which txMW rolls back or commits once the request is served. Handlers defer it right
+// after begin.
+func (a *Application) rollback(ctx context.Context, tx *sqlx.Tx) {
+	if tx == web.ScopeOf(ctx).Tx {
+		return
+	}
+
+	_ = tx.Rollback()
+}
+
+// txRecorder is an http.ResponseWriter that keeps the response in memory until the
+// transaction of the request is committed.
+type txRecorder struct {
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// Header implements the http.ResponseWriter interface.
+func (r *txRecorder) Header() http.Header {
+	return r.header
+}
+
+// Write implements the http.ResponseWriter interface.
+func (r *txRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+
+	return r.body.Write(b)
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (r *txRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+// writeTo writes the recorded response to w.
+func (r *txRecorder) writeTo(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+
+	w.WriteHeader(r.code)
+	_, _ = w.Write(r.body.Bytes())
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// uiFiles holds the demo UI, a single page managing lists and their items through the
+// public API, so the daemon can be tried out in a browser.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiPage is the page of the demo UI, which loads the rest of its files from /ui.
+//
+//go:embed ui/index.html
+var uiPage []byte
+
+// uiAssets serves the files of the demo UI under /ui.
+var uiAssets = func() http.Handler {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix("/ui", http.FileServer(http.FS(sub)))
+}()
+
+// getUI is a handler that returns the page of the demo UI.
+func (a *Application) getUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(uiPage)
+}
+
+// getUIAsset is a handler that returns a script or stylesheet of the demo UI.
+func (a *Application) getUIAsset(w http.ResponseWriter, r *http.Request) {
+	uiAssets.ServeHTTP(w, r)
+}
@@ -0,0 +1,127 @@
+// The demo UI of the list daemon. It only uses the public API: items are checked off by
+// deleting them, since items have no checked state of their own.
+"use strict";
+
+const state = { lists: [], selected: null };
+
+const $ = (id) => document.getElementById(id);
+
+// api requests the given path of the API and returns the results of the response,
+// throwing the message of its first error if it failed.
+async function api(method, path, body) {
+  // The wrapped profile is asked for since the envelope is configurable.
+  const init = { method, headers: { Accept: 'application/json; profile="wrapped"' } };
+  if (body !== undefined) {
+    init.headers["Content-Type"] = "application/json";
+    init.body = JSON.stringify(body);
+  }
+
+  const resp = await fetch(path, init);
+  if (resp.status === 204) {
+    return null;
+  }
+
+  const doc = await resp.json();
+  if (!resp.ok) {
+    throw new Error(doc.errors && doc.errors.length ? doc.errors[0].message : resp.statusText);
+  }
+
+  return doc.results;
+}
+
+// run calls fn, showing the error it throws, if any.
+async function run(fn) {
+  $("error").hidden = true;
+  try {
+    await fn();
+  } catch (err) {
+    $("error").textContent = err.message;
+    $("error").hidden = false;
+  }
+}
+
+function row(label, count, onSelect, action, onAction) {
+  const li = document.createElement("li");
+
+  const name = document.createElement("button");
+  name.className = "name";
+  name.textContent = label;
+  if (onSelect) {
+    name.addEventListener("click", () => run(onSelect));
+  } else {
+    name.disabled = true;
+  }
+
+  const span = document.createElement("span");
+  span.className = "count";
+  span.textContent = count;
+
+  const button = document.createElement("button");
+  button.textContent = action;
+  button.addEventListener("click", () => run(onAction));
+
+  li.append(name, span, button);
+  return li;
+}
+
+async function loadLists() {
+  state.lists = await api("GET", "/list");
+
+  const ul = $("list-names");
+  ul.replaceChildren(...state.lists.map((l) => {
+    const li = row(l.name, l.item_count, () => selectList(l.id), "Delete", async () => {
+      await api("DELETE", `/list/${l.id}`);
+      if (state.selected === l.id) {
+        state.selected = null;
+        $("items").hidden = true;
+      }
+      await loadLists();
+    });
+    li.classList.toggle("selected", state.selected === l.id);
+    return li;
+  }));
+}
+
+async function selectList(id) {
+  state.selected = id;
+  await loadItems();
+  await loadLists();
+}
+
+async function loadItems() {
+  const list = await api("GET", `/list/${state.selected}`);
+  const items = await api("GET", `/list/${state.selected}/item`);
+
+  $("list-title").textContent = list.name;
+  $("item-names").replaceChildren(...items.map((i) =>
+    row(i.name, i.unit ? `${i.quantity} ${i.unit}` : i.quantity, null, "Check off", async () => {
+      await api("DELETE", `/list/${state.selected}/item/${i.id}`);
+      await loadItems();
+      await loadLists();
+    })));
+  $("items").hidden = false;
+}
+
+$("new-list").addEventListener("submit", (e) => {
+  e.preventDefault();
+  run(async () => {
+    const list = await api("POST", "/list", { name: e.target.elements.name.value });
+    e.target.reset();
+    await selectList(list.id);
+  });
+});
+
+$("new-item").addEventListener("submit", (e) => {
+  e.preventDefault();
+  run(async () => {
+    await api("POST", `/list/${state.selected}/item`, {
+      name: e.target.elements.name.value,
+      quantity: Number(e.target.elements.quantity.value),
+    });
+    e.target.reset();
+    await loadItems();
+    await loadLists();
+  });
+});
+
+run(loadLists);
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/template"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// The paths of the resources of the API, matching the routes of NewApplication. Every
+// link the API hands out to a resource is built from them, see resourceURL.
+
+// listPath returns the path of the list given by id.
+func listPath(id int) string {
+	return fmt.Sprintf("/list/%d", id)
+}
+
+// itemPath returns the path of the item given by itemID of the list given by listID.
+func itemPath(listID, itemID int) string {
+	return fmt.Sprintf("%s/item/%d", listPath(listID), itemID)
+}
+
+// templatePath returns the path of the template given by id.
+func templatePath(id int) string {
+	return fmt.Sprintf("/template/%d", id)
+}
+
+// jobPath returns the path of the job given by id.
+func jobPath(id int) string {
+	return fmt.Sprintf("/job/%d", id)
+}
+
+// resourceURL returns the canonical URL of the resource at path, on the host the request
+// was made to.
+func resourceURL(r *http.Request, path string) string {
+	return web.BaseURL(r) + path
+}
+
+// createdList is the response to a request that created the list, see respondCreated.
+type createdList struct {
+	list.List
+	URL string `json:"url"`
+}
+
+// createdItem is the response to a request that created the item, see respondCreated.
+type createdItem struct {
+	item.Item
+	URL string `json:"url"`
+}
+
+// createdTemplate is the response to a request that created the template, see
+// respondCreated.
+type createdTemplate struct {
+	template.Template
+	URL string `json:"url"`
+}
+
+// respondCreated responds with a 201 and the resource v, a list.List, item.Item or
+// template.Template, along with its canonical URL. The Location header points to it.
+func respondCreated(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var (
+		path string
+		data interface{}
+	)
+
+	switch v := v.(type) {
+	case list.List:
+		path = listPath(v.ID)
+		data = createdList{List: v, URL: resourceURL(r, path)}
+	case item.Item:
+		path = itemPath(v.ListID, v.ID)
+		data = createdItem{Item: v, URL: resourceURL(r, path)}
+	case template.Template:
+		path = templatePath(v.ID)
+		data = createdTemplate{Template: v, URL: resourceURL(r, path)}
+	default:
+		panic(fmt.Sprintf("respondCreated: unsupported resource %T", v))
+	}
+
+	w.Header().Set("Location", path)
+	web.Respond(w, r, http.StatusCreated, data)
+}
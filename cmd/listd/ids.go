@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ids prints the strategy the IDs of new rows are generated by, or switches to the one
+// given by -set.
+func ids(args []string) error {
+	fs := flag.NewFlagSet("ids", flag.ExitOnError)
+	set := fs.String("set", "", "switch to generating ids by the given strategy (serial, snowflake)")
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	if *set != "" {
+		return setIDStrategy(dbc, db.IDStrategy(*set), logger)
+	}
+
+	s, err := db.CurrentIDStrategy(dbc)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(s)
+
+	return nil
+}
+
+// applyIDStrategy switches the database to the strategy of the configuration, if it
+// names one and it isn't the current one already.
+func applyIDStrategy(cfg config.Config, dbc *sqlx.DB, logger log.FieldLogger) error {
+	if cfg.IDStrategy == "" {
+		return nil
+	}
+
+	return setIDStrategy(dbc, db.IDStrategy(cfg.IDStrategy), logger)
+}
+
+// setIDStrategy switches the database to generating IDs by s, warning when switching
+// back to serial IDs, which no longer sort after those generated before.
+func setIDStrategy(dbc *sqlx.DB, s db.IDStrategy, logger log.FieldLogger) error {
+	current, err := db.CurrentIDStrategy(dbc)
+	if err != nil {
+		return err
+	}
+
+	if current == s {
+		return nil
+	}
+
+	if err := db.SetIDStrategy(dbc, s); err != nil {
+		return errors.Wrap(err, "switch id strategy")
+	}
+
+	entry := logger.WithFields(log.Fields{
+		"from": current,
+		"to":   s,
+	})
+
+	if s == db.IDSerial {
+		entry.Warn("switched back to serial ids, new ids are smaller than the snowflake ids generated before")
+		return nil
+	}
+
+	entry.Info("switched id strategy")
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/pkg/errors"
+)
+
+// List is a list mapped from an export along with the names of its items.
+type List struct {
+	// Source identifies what the list was mapped from, e.g. trello:board:5c1a.
+	Source string
+	Name   string
+	Items  []string
+}
+
+// Skipped is an entry of an export that was not mapped along with why.
+type Skipped struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// Parser maps an export read from r to lists, reporting the entries it skipped. Names
+// are normalized and checked by names.
+type Parser func(r io.Reader, names validate.Names) ([]List, []Skipped, error)
+
+// name returns the normalized name of an entry, or why the entry has to be skipped when
+// its name can't be used.
+func name(names validate.Names, raw string) (string, string) {
+	n, err := names.Normalize("name", raw)
+	switch {
+	case err != nil:
+		return "", err.Error()
+	case n == "":
+		return "", "name is empty"
+	}
+
+	return n, ""
+}
+
+// id is an identifier of an exported entry, which some exports write as a number and
+// others as a string.
+type id string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (i *id) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*i = id(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return errors.Errorf("expected a string or number identifier, got %s", b)
+	}
+
+	*i = id(n.String())
+	return nil
+}
@@ -0,0 +1,207 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrello(t *testing.T) {
+	export := `{
+		"id": "5c1a",
+		"name": " Groceries ",
+		"lists": [{"id": "l1", "closed": false}, {"id": "l2", "closed": true}],
+		"cards": [
+			{"id": "c1", "name": "Milk", "idList": "l1"},
+			{"id": "c2", "name": "Eggs", "idList": "l1", "closed": true},
+			{"id": "c3", "name": "Bread", "idList": "l2"},
+			{"id": "c4", "name": "  ", "idList": "l1"}
+		]
+	}`
+
+	lists, skipped, err := Trello(strings.NewReader(export), validate.Names{})
+	if err != nil {
+		t.Fatalf("error mapping export: %v", err)
+	}
+
+	expectedLists := []List{
+		{Source: "trello:board:5c1a", Name: "Groceries", Items: []string{"Milk"}},
+	}
+
+	if d := cmp.Diff(expectedLists, lists); d != "" {
+		t.Errorf("unexpected difference in lists:\n%v", d)
+	}
+
+	expectedSkipped := []Skipped{
+		{Source: "trello:card:c2", Reason: "card is archived"},
+		{Source: "trello:card:c3", Reason: "card is archived"},
+		{Source: "trello:card:c4", Reason: "name is empty"},
+	}
+
+	if d := cmp.Diff(expectedSkipped, skipped); d != "" {
+		t.Errorf("unexpected difference in skipped entries:\n%v", d)
+	}
+}
+
+func TestTodoist(t *testing.T) {
+	// Older exports write identifiers as numbers, newer ones as strings.
+	export := `{
+		"projects": [
+			{"id": 1, "name": "Groceries"},
+			{"id": "2", "name": "Hardware"},
+			{"id": 3, "name": "Old", "is_archived": true}
+		],
+		"items": [
+			{"id": 10, "content": "Milk", "project_id": 1},
+			{"id": 11, "content": "Eggs", "project_id": 1, "checked": true},
+			{"id": "12", "content": "Nails", "project_id": "2"},
+			{"id": 13, "content": "Paint", "project_id": 3}
+		]
+	}`
+
+	lists, skipped, err := Todoist(strings.NewReader(export), validate.Names{})
+	if err != nil {
+		t.Fatalf("error mapping export: %v", err)
+	}
+
+	expectedLists := []List{
+		{Source: "todoist:project:1", Name: "Groceries", Items: []string{"Milk"}},
+		{Source: "todoist:project:2", Name: "Hardware", Items: []string{"Nails"}},
+	}
+
+	if d := cmp.Diff(expectedLists, lists); d != "" {
+		t.Errorf("unexpected difference in lists:\n%v", d)
+	}
+
+	expectedSkipped := []Skipped{
+		{Source: "todoist:project:3", Reason: "project is archived"},
+		{Source: "todoist:task:11", Reason: "task is completed"},
+		{Source: "todoist:task:13", Reason: "project was not imported"},
+	}
+
+	if d := cmp.Diff(expectedSkipped, skipped); d != "" {
+		t.Errorf("unexpected difference in skipped entries:\n%v", d)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for name, parse := range map[string]Parser{"Trello": Trello, "Todoist": Todoist} {
+		fn := func(t *testing.T) {
+			if _, _, err := parse(strings.NewReader(`["not", "an", "export"]`), validate.Names{}); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		}
+
+		t.Run(name, fn)
+	}
+}
+
+// readRows reads every row of rows.
+func readRows(t *testing.T, rows *Rows) []Row {
+	var read []Row
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return read
+		}
+		if err != nil {
+			t.Fatalf("error reading row: %v", err)
+		}
+
+		read = append(read, row)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	sheet := "Unit,List,Name,Quantity\n" +
+		",Groceries,Milk,\n" +
+		"\n" +
+		"kg,Groceries, Flour ,2.0\n" +
+		",Groceries,Eggs,1.5\n" +
+		",,Nails,1\n" +
+		",Hardware,Screws\n"
+
+	rows, err := CSV(strings.NewReader(sheet), int64(len(sheet)), validate.Names{})
+	if err != nil {
+		t.Fatalf("error opening spreadsheet: %v", err)
+	}
+
+	expected := []Row{
+		{Number: 2, Fields: []string{"", "Groceries", "Milk", ""}, List: "Groceries", Name: "Milk", Quantity: 1},
+		{Number: 3, Fields: []string{"kg", "Groceries", "Flour ", "2.0"}, List: "Groceries", Name: "Flour", Quantity: 2, Unit: "kg"},
+		{Number: 4, Fields: []string{"", "Groceries", "Eggs", "1.5"}, List: "Groceries", Name: "Eggs", Quantity: 1, Rejected: "quantity must be a positive whole number"},
+		{Number: 5, Fields: []string{"", "", "Nails", "1"}, Quantity: 1, Rejected: "list name is empty"},
+		{Number: 6, Fields: []string{"", "Hardware", "Screws"}, List: "Hardware", Name: "Screws", Quantity: 1},
+	}
+
+	if d := cmp.Diff(expected, readRows(t, rows)); d != "" {
+		t.Errorf("unexpected difference in rows:\n%v", d)
+	}
+
+	if done, total := rows.Progress(); done != total {
+		t.Errorf("expected the whole spreadsheet of %d bytes to be read, got %d", total, done)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	parts := map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>List</t></si><si><t>Name</t></si><si><r><t>Groc</t></r><r><t>eries</t></r></si></sst>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>ignored</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Quantity</t></is></c></row>
+			<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3" t="inlineStr"><is><t>Milk</t></is></c><c r="D3"><v>3</v></c></row>
+		</sheetData></worksheet>`,
+	}
+
+	for name, content := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("error creating %s: %v", name, err)
+		}
+
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("error closing workbook: %v", err)
+	}
+
+	rows, err := XLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()), validate.Names{})
+	if err != nil {
+		t.Fatalf("error opening workbook: %v", err)
+	}
+
+	if e, a := []string{"List", "", "Name", "Quantity"}, rows.Header(); !cmp.Equal(e, a) {
+		t.Errorf("expected header %q, got %q", e, a)
+	}
+
+	expected := []Row{
+		{Number: 3, Fields: []string{"Groceries", "", "Milk", "3"}, List: "Groceries", Name: "Milk", Quantity: 3},
+	}
+
+	if d := cmp.Diff(expected, readRows(t, rows)); d != "" {
+		t.Errorf("unexpected difference in rows:\n%v", d)
+	}
+}
+
+func TestRowsHeader(t *testing.T) {
+	for name, sheet := range map[string]string{"Empty": "", "MissingColumn": "List,Quantity\nGroceries,1\n"} {
+		fn := func(t *testing.T) {
+			if _, err := CSV(strings.NewReader(sheet), int64(len(sheet)), validate.Names{}); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		}
+
+		t.Run(name, fn)
+	}
+}
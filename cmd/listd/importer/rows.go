@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/pkg/errors"
+)
+
+// Row is a row of a spreadsheet that names an item and the list it belongs to, mapped
+// from the columns named by the header row of the spreadsheet.
+type Row struct {
+	// Number is the number of the row within the spreadsheet, the header row is 1.
+	Number int
+
+	// Fields are the cells of the row as they were read.
+	Fields []string
+
+	List     string
+	Name     string
+	Quantity int
+	Unit     string
+
+	// Rejected is why the row can't be imported, empty if it can.
+	Rejected string
+}
+
+// RowOpener opens the spreadsheet held by r, which is size bytes long, for its rows to
+// be read one at a time. Names are normalized and checked by names.
+type RowOpener func(r io.ReaderAt, size int64, names validate.Names) (*Rows, error)
+
+// Rows reads the rows of a spreadsheet one at a time, so spreadsheets of any size can be
+// imported without holding them in memory.
+type Rows struct {
+	src    rowSource
+	read   *countingReader
+	total  int64
+	names  validate.Names
+	header []string
+	cols   map[string]int
+}
+
+// rowSource returns the cells of the next row of a spreadsheet along with its number,
+// io.EOF after the last one.
+type rowSource func() (int, []string, error)
+
+// columns are the columns of a spreadsheet that are mapped, named by its header row
+// regardless of case. The list and name columns are required.
+var columns = []string{"list", "name", "quantity", "unit"}
+
+// newRows returns the rows of src, reading its header row right away. read counts the
+// bytes of the spreadsheet read so far out of total.
+func newRows(src rowSource, read *countingReader, total int64, names validate.Names) (*Rows, error) {
+	_, header, err := src()
+	if err == io.EOF {
+		return nil, errors.New("the spreadsheet has no header row")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read header row")
+	}
+
+	cols := make(map[string]int, len(columns))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, ok := cols[h]; !ok {
+			cols[h] = i
+		}
+	}
+
+	for _, c := range columns[:2] {
+		if _, ok := cols[c]; !ok {
+			return nil, errors.Errorf("the header row has no %s column", c)
+		}
+	}
+
+	return &Rows{
+		src:    src,
+		read:   read,
+		total:  total,
+		names:  names,
+		header: header,
+		cols:   cols,
+	}, nil
+}
+
+// Header returns the cells of the header row.
+func (r *Rows) Header() []string {
+	return r.header
+}
+
+// Progress returns the amount of bytes of the spreadsheet read so far out of its total.
+func (r *Rows) Progress() (int64, int64) {
+	return r.read.n, r.total
+}
+
+// Next returns the next row that isn't empty, io.EOF after the last one. Rows that can't
+// be imported are returned along with why, see Row.Rejected.
+func (r *Rows) Next() (Row, error) {
+	for {
+		n, fields, err := r.src()
+		if err != nil {
+			return Row{}, err
+		}
+
+		if !blank(fields) {
+			return r.row(n, fields), nil
+		}
+	}
+}
+
+// row maps the cells of the row with the given number to a Row.
+func (r *Rows) row(n int, fields []string) Row {
+	row := Row{Number: n, Fields: fields, Quantity: 1}
+
+	var reason string
+	if row.List, reason = name(r.names, r.cell(fields, "list")); reason != "" {
+		row.Rejected = "list " + reason
+		return row
+	}
+
+	if row.Name, reason = name(r.names, r.cell(fields, "name")); reason != "" {
+		row.Rejected = reason
+		return row
+	}
+
+	if q := strings.TrimSpace(r.cell(fields, "quantity")); q != "" {
+		// Spreadsheets may write whole numbers as decimals, such as 2.0.
+		f, err := strconv.ParseFloat(q, 64)
+		if err != nil || f < 1 || f > math.MaxInt32 || f != math.Trunc(f) {
+			row.Rejected = "quantity must be a positive whole number"
+			return row
+		}
+
+		row.Quantity = int(f)
+	}
+
+	row.Unit = strings.TrimSpace(r.cell(fields, "unit"))
+
+	return row
+}
+
+// cell returns the cell of fields in the given column, empty if the spreadsheet or the
+// row has none.
+func (r *Rows) cell(fields []string, column string) string {
+	i, ok := r.cols[column]
+	if !ok || i >= len(fields) {
+		return ""
+	}
+
+	return fields[i]
+}
+
+// blank reports whether every cell of fields is empty.
+func blank(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CSV opens a CSV spreadsheet whose first row names its columns. Rows may have any amount
+// of cells.
+func CSV(r io.ReaderAt, size int64, names validate.Names) (*Rows, error) {
+	read := &countingReader{r: io.NewSectionReader(r, 0, size)}
+
+	cr := csv.NewReader(read)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var n int
+	src := func() (int, []string, error) {
+		fields, err := cr.Read()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		n++
+		return n, fields, nil
+	}
+
+	return newRows(src, read, size, names)
+}
+
+// countingReader is an io.Reader counting the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/pkg/errors"
+)
+
+// todoistExport is the subset of a Todoist JSON export, in the format of the Sync API,
+// that is mapped.
+type todoistExport struct {
+	Projects []struct {
+		ID         id     `json:"id"`
+		Name       string `json:"name"`
+		IsArchived bool   `json:"is_archived"`
+	} `json:"projects"`
+	Items []struct {
+		ID        id     `json:"id"`
+		Content   string `json:"content"`
+		ProjectID id     `json:"project_id"`
+		Checked   bool   `json:"checked"`
+	} `json:"items"`
+}
+
+// Todoist maps a Todoist JSON export to a list for every project with an item for every
+// open task of the project. Archived projects and completed tasks are skipped.
+func Todoist(r io.Reader, names validate.Names) ([]List, []Skipped, error) {
+	var e todoistExport
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return nil, nil, errors.Wrap(err, "decode todoist export")
+	}
+
+	var (
+		lists   []List
+		skipped []Skipped
+	)
+
+	// Tasks are looked up by the index of their project in lists.
+	projects := make(map[id]int, len(e.Projects))
+	for _, p := range e.Projects {
+		source := "todoist:project:" + string(p.ID)
+
+		if p.IsArchived {
+			skipped = append(skipped, Skipped{Source: source, Reason: "project is archived"})
+			continue
+		}
+
+		n, reason := name(names, p.Name)
+		if reason != "" {
+			skipped = append(skipped, Skipped{Source: source, Reason: reason})
+			continue
+		}
+
+		projects[p.ID] = len(lists)
+		lists = append(lists, List{
+			Source: source,
+			Name:   n,
+		})
+	}
+
+	for _, t := range e.Items {
+		source := "todoist:task:" + string(t.ID)
+
+		i, ok := projects[t.ProjectID]
+		switch {
+		case !ok:
+			skipped = append(skipped, Skipped{Source: source, Reason: "project was not imported"})
+			continue
+		case t.Checked:
+			skipped = append(skipped, Skipped{Source: source, Reason: "task is completed"})
+			continue
+		}
+
+		n, reason := name(names, t.Content)
+		if reason != "" {
+			skipped = append(skipped, Skipped{Source: source, Reason: reason})
+			continue
+		}
+
+		lists[i].Items = append(lists[i].Items, n)
+	}
+
+	return lists, skipped, nil
+}
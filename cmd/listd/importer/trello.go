@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/pkg/errors"
+)
+
+// trelloBoard is the subset of a Trello board JSON export that is mapped.
+type trelloBoard struct {
+	ID    id     `json:"id"`
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     id   `json:"id"`
+		Closed bool `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID     id     `json:"id"`
+		Name   string `json:"name"`
+		IDList id     `json:"idList"`
+		Closed bool   `json:"closed"`
+	} `json:"cards"`
+}
+
+// Trello maps a Trello board JSON export, as downloaded through "Print and export", to
+// a list named after the board with an item for every open card. Archived cards and
+// cards on archived lists are skipped.
+func Trello(r io.Reader, names validate.Names) ([]List, []Skipped, error) {
+	var b trelloBoard
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, nil, errors.Wrap(err, "decode trello board")
+	}
+
+	source := "trello:board:" + string(b.ID)
+
+	n, reason := name(names, b.Name)
+	if reason != "" {
+		return nil, []Skipped{{Source: source, Reason: reason}}, nil
+	}
+
+	closed := make(map[id]bool, len(b.Lists))
+	for _, l := range b.Lists {
+		closed[l.ID] = l.Closed
+	}
+
+	l := List{
+		Source: source,
+		Name:   n,
+	}
+
+	var skipped []Skipped
+	for _, c := range b.Cards {
+		cardSource := "trello:card:" + string(c.ID)
+
+		if c.Closed || closed[c.IDList] {
+			skipped = append(skipped, Skipped{Source: cardSource, Reason: "card is archived"})
+			continue
+		}
+
+		n, reason := name(names, c.Name)
+		if reason != "" {
+			skipped = append(skipped, Skipped{Source: cardSource, Reason: reason})
+			continue
+		}
+
+		l.Items = append(l.Items, n)
+	}
+
+	return []List{l}, skipped, nil
+}
@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/pkg/errors"
+)
+
+// maxXLSXColumns is the amount of columns of an Excel worksheet.
+const maxXLSXColumns = 16384
+
+// xlsxStrings is the part of an XLSX workbook holding the strings its cells refer to.
+const xlsxStrings = "xl/sharedStrings.xml"
+
+// XLSX opens the first worksheet of an Excel workbook whose first row names its columns.
+// The worksheet is read a row at a time, only the strings shared by its cells are held in
+// memory.
+func XLSX(r io.ReaderAt, size int64, names validate.Names) (*Rows, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "open workbook")
+	}
+
+	var (
+		sheet  *zip.File
+		shared []string
+	)
+
+	for _, f := range zr.File {
+		switch {
+		case f.Name == xlsxStrings:
+			if shared, err = readSharedStrings(f); err != nil {
+				return nil, err
+			}
+
+		// Worksheets are numbered in the order of the workbook.
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && strings.HasSuffix(f.Name, ".xml"):
+			if sheet == nil || sheetNumber(f.Name) < sheetNumber(sheet.Name) {
+				sheet = f
+			}
+		}
+	}
+
+	if sheet == nil {
+		return nil, errors.New("the workbook has no worksheet")
+	}
+
+	rc, err := sheet.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "open worksheet")
+	}
+
+	read := &countingReader{r: rc}
+	dec := xml.NewDecoder(read)
+
+	var last int
+	src := func() (int, []string, error) {
+		n, fields, err := nextXLSXRow(dec, shared)
+		if err != nil {
+			rc.Close()
+			return 0, nil, err
+		}
+
+		// Rows without a number follow the one before.
+		if n == 0 {
+			n = last + 1
+		}
+		last = n
+
+		return n, fields, nil
+	}
+
+	return newRows(src, read, int64(sheet.UncompressedSize64), names)
+}
+
+// sheetNumber returns the number of the worksheet stored under the given name, such as 2
+// for xl/worksheets/sheet2.xml.
+func sheetNumber(name string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "xl/worksheets/sheet"), ".xml"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+
+	return n
+}
+
+// xlsxText is a run of text in an XLSX workbook, which is either plain or made of runs of
+// rich text.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String returns the text of t.
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+
+	return b.String()
+}
+
+// readSharedStrings reads the strings shared by the cells of a workbook from f, in the
+// order the cells refer to them.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "open shared strings")
+	}
+	defer rc.Close()
+
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+
+	if err := xml.NewDecoder(rc).Decode(&sst); err != nil {
+		return nil, errors.Wrap(err, "decode shared strings")
+	}
+
+	shared := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		shared[i] = si.String()
+	}
+
+	return shared, nil
+}
+
+// xlsxRow is a row of an XLSX worksheet, whose cells are only written when they aren't
+// empty.
+type xlsxRow struct {
+	Number int `xml:"r,attr"`
+	Cells  []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// nextXLSXRow decodes the next row of the worksheet read by dec, io.EOF after the last
+// one. The cells are returned in the order of their columns, with the empty cells in
+// between filled in.
+func nextXLSXRow(dec *xml.Decoder, shared []string) (int, []string, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "decode worksheet")
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return 0, nil, errors.Wrap(err, "decode worksheet row")
+		}
+
+		var fields []string
+		for _, c := range row.Cells {
+			// Cells without a reference follow the one before.
+			col := len(fields)
+			if n := columnIndex(c.Ref); n >= 0 {
+				col = n
+			}
+
+			if col >= maxXLSXColumns {
+				return 0, nil, errors.Errorf("cell %s is beyond the last column of a worksheet", c.Ref)
+			}
+
+			var v string
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return 0, nil, errors.Errorf("cell %s refers to shared string %q which does not exist", c.Ref, c.Value)
+				}
+				v = shared[n]
+			case "inlineStr":
+				v = c.Inline.String()
+			default:
+				v = c.Value
+			}
+
+			for len(fields) <= col {
+				fields = append(fields, "")
+			}
+			fields[col] = v
+		}
+
+		return row.Number, fields, nil
+	}
+}
+
+// columnIndex returns the zero-based index of the column of the given cell reference,
+// such as 27 for AB3, or -1 when it names no column.
+func columnIndex(ref string) int {
+	var col int
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' || col > maxXLSXColumns {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+
+	return col - 1
+}
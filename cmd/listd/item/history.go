@@ -0,0 +1,79 @@
+package item
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// Change is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of a change made to a field of an item. The values keep the JSON type
+// of the field.
+type Change struct {
+	ID       int             `json:"id" db:"history_id"`
+	Field    string          `json:"field" db:"field"`
+	OldValue json.RawMessage `json:"old" db:"old_value"`
+	NewValue json.RawMessage `json:"new" db:"new_value"`
+	Changed  time.Time       `json:"changed" db:"changed"`
+}
+
+// SelectHistoryPage selects up to limit changes made to the item with the given item_id
+// and list_id, newest first, skipping the first offset changes, along with the total
+// amount of changes made to the item. sql.ErrNoRows is returned if the list does not
+// contain the item.
+func SelectHistoryPage(dbc db.Executor, itemID, listID, limit, offset int) ([]Change, int, error) {
+	if _, err := SelectItem(dbc, itemID, listID); errors.Cause(err) == sql.ErrNoRows {
+		return nil, 0, sql.ErrNoRows
+	}
+
+	var total int
+	if err := db.Get(dbc, &total, countHistory, itemID); err != nil {
+		return nil, 0, errors.Wrap(err, "count rows in item_history table given an item_id")
+	}
+
+	changes := make([]Change, 0)
+
+	if err := db.Select(dbc, &changes, selectHistoryPage, itemID, limit, offset); err != nil {
+		return nil, 0, errors.Wrap(err, "select page of rows from item_history table given an item_id")
+	}
+
+	return changes, total, nil
+}
+
+// recordChanges inserts a row into the item_history table for every field that differs
+// between old and updated, as of updated.Modified.
+func recordChanges(dbc db.Executor, old, updated Item) error {
+	fields := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"name", old.Name, updated.Name},
+		{"quantity", old.Quantity, updated.Quantity},
+		{"unit", old.Unit, updated.Unit},
+	}
+
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+
+		o, err := json.Marshal(f.old)
+		if err != nil {
+			return errors.Wrapf(err, "marshal old %s", f.name)
+		}
+
+		n, err := json.Marshal(f.new)
+		if err != nil {
+			return errors.Wrapf(err, "marshal new %s", f.name)
+		}
+
+		if _, err := dbc.Exec(insertChange, updated.ID, f.name, o, n, updated.Modified); err != nil {
+			return errors.Wrapf(err, "insert item_history row for %s", f.name)
+		}
+	}
+
+	return nil
+}
@@ -5,7 +5,8 @@ import (
 	"time"
 
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
-	"github.com/jmoiron/sqlx"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -17,53 +18,91 @@ type Item struct {
 	ListID   int       `json:"listID" db:"list_id"`
 	Name     string    `json:"name" db:"name"`
 	Quantity int       `json:"quantity" db:"quantity"`
+	Unit     string    `json:"unit" db:"unit"`
 	Created  time.Time `json:"created" db:"created"`
 	Modified time.Time `json:"modified" db:"modified"`
 }
 
 // SelectItems selects all appropriate rows from the item table given a list_id.
-func SelectItems(dbc *sqlx.DB, listID int) ([]Item, error) {
+func SelectItems(dbc db.Executor, listID int) ([]Item, error) {
 	if _, err := list.SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
 		return nil, sql.ErrNoRows
 	}
 
 	items := make([]Item, 0)
 
-	if err := dbc.Select(&items, selectAll, listID); err != nil {
+	if err := db.Select(dbc, &items, selectAll, listID); err != nil {
 		return nil, errors.Wrap(err, "select all rows from item table given a list_id")
 	}
 
 	return items, nil
 }
 
+// SelectItemPage selects up to limit rows from the item table given a list_id, skipping
+// the first offset rows, along with the total amount of items of the list.
+func SelectItemPage(dbc db.Executor, listID, limit, offset int) ([]Item, int, error) {
+	if _, err := list.SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
+		return nil, 0, sql.ErrNoRows
+	}
+
+	var total int
+	if err := db.Get(dbc, &total, count, listID); err != nil {
+		return nil, 0, errors.Wrap(err, "count rows in item table given a list_id")
+	}
+
+	items := make([]Item, 0)
+
+	if err := db.Select(dbc, &items, selectPage, listID, limit, offset); err != nil {
+		return nil, 0, errors.Wrap(err, "select page of rows from item table given a list_id")
+	}
+
+	return items, total, nil
+}
+
+// SelectItemsByIDs selects the rows from the item table with the given ids regardless of
+// their list, in the order the ids are given. IDs without a row are left out, as are
+// repeated ones.
+func SelectItemsByIDs(dbc db.Executor, ids []int) ([]Item, error) {
+	items := make([]Item, 0, len(ids))
+
+	if err := db.Select(dbc, &items, selectByIDs, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "select rows from item table by ids")
+	}
+
+	return items, nil
+}
+
 // SelectItem selects a single row from the item table based off given list_id and
 // item_id.
-func SelectItem(dbc *sqlx.DB, iid, lid int) (Item, error) {
+func SelectItem(dbc db.Executor, iid, lid int) (Item, error) {
 	var i Item
 	stmt := selectByIDAndListID
 
-	pStmt, err := dbc.Preparex(stmt)
-	if err != nil {
-		return Item{}, errors.Wrap(err, "prepare select query")
-	}
-
-	defer func() {
-		if err := pStmt.Close(); err != nil {
-			logrus.WithError(errors.Wrap(err, "close psql statement")).Info("select item")
+	err := db.RetryRead(dbc, func() error {
+		pStmt, err := dbc.Preparex(stmt)
+		if err != nil {
+			return errors.Wrap(err, "prepare select query")
 		}
-	}()
 
-	row := pStmt.QueryRowx(iid, lid)
+		defer func() {
+			if err := pStmt.Close(); err != nil {
+				logrus.WithError(errors.Wrap(err, "close psql statement")).Info("select item")
+			}
+		}()
+
+		row := pStmt.QueryRowx(iid, lid)
 
-	if err := row.StructScan(&i); err != nil {
-		return Item{}, errors.Wrap(err, "select singular row from item table")
+		return errors.Wrap(row.StructScan(&i), "select singular row from item table")
+	})
+	if err != nil {
+		return Item{}, err
 	}
 
 	return i, nil
 }
 
 // CreateItem inserts a new row into the item table.
-func CreateItem(dbc *sqlx.DB, r Item) (Item, error) {
+func CreateItem(dbc db.Executor, r Item) (Item, error) {
 	r.Created = time.Now()
 	r.Modified = time.Now()
 
@@ -82,7 +121,7 @@ func CreateItem(dbc *sqlx.DB, r Item) (Item, error) {
 		}
 	}()
 
-	row := stmt.QueryRow(r.ListID, r.Name, r.Quantity, r.Created, r.Modified)
+	row := stmt.QueryRow(r.ListID, r.Name, r.Quantity, r.Unit, r.Created, r.Modified)
 
 	if err = row.Scan(&r.ID); err != nil {
 		return Item{}, errors.Wrap(err, "get inserted row id")
@@ -91,30 +130,157 @@ func CreateItem(dbc *sqlx.DB, r Item) (Item, error) {
 	return r, nil
 }
 
-// UpdateItem updates a row in the item table based off of item_id and list_id. The only fields
-// able to be updated are the name and quantity field.
-func UpdateItem(dbc *sqlx.DB, r Item) error {
-	if _, err := SelectItem(dbc, r.ID, r.ListID); errors.Cause(err) == sql.ErrNoRows {
-		return sql.ErrNoRows
+// ErrNameTaken is returned by CreateItems for the items whose name is already taken in
+// their list.
+var ErrNameTaken = errors.New("the list already contains an item with the same name")
+
+// CreateItems inserts new rows into the item table for items, which all have to belong to
+// the same list, with a single statement. The items are returned in the given order
+// along with an error per item, which is ErrNameTaken for items whose name is already
+// taken in the list, including by an earlier one of items. The returned error is set
+// when no item could be inserted, sql.ErrNoRows if the list doesn't exist.
+func CreateItems(dbc db.Executor, items []Item) ([]Item, []error, error) {
+	if len(items) == 0 {
+		return nil, nil, nil
+	}
+
+	listID := items[0].ListID
+	if _, err := list.SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
+		return nil, nil, sql.ErrNoRows
+	}
+
+	now := time.Now()
+
+	names := make([]string, len(items))
+	quantities := make([]int64, len(items))
+	units := make([]string, len(items))
+	for i, it := range items {
+		names[i], quantities[i], units[i] = it.Name, int64(it.Quantity), it.Unit
+	}
+
+	var rows []struct {
+		ID   int    `db:"item_id"`
+		Name string `db:"name"`
+	}
+	if err := dbc.Select(&rows, insertMany, listID, pq.Array(names), pq.Array(quantities), pq.Array(units), now); err != nil {
+		return nil, nil, errors.Wrap(err, "insert item rows")
+	}
+
+	// Every inserted row belongs to the first item with its name, the others were
+	// skipped.
+	ids := make(map[string]int, len(rows))
+	for _, row := range rows {
+		ids[row.Name] = row.ID
+	}
+
+	created := make([]Item, len(items))
+	errs := make([]error, len(items))
+	for i, it := range items {
+		id, ok := ids[it.Name]
+		if !ok {
+			errs[i] = ErrNameTaken
+			continue
+		}
+		delete(ids, it.Name)
+
+		it.ID, it.Created, it.Modified = id, now, now
+		created[i] = it
+	}
+
+	return created, errs, nil
+}
+
+// ErrUnitMismatch is returned by MergeItem when the item to merge into is in a different
+// unit than the merged item.
+var ErrUnitMismatch = errors.New("the existing item with the same name is in a different unit")
+
+// MergeItem inserts a new row into the item table, or when the list already contains an
+// item with the same name regardless of case, adds the quantity of r to that item. The
+// resulting item is returned along with whether it was inserted, the change of quantity
+// is recorded in the item_history table otherwise. ErrUnitMismatch is returned when the
+// existing item is in a different unit than r.
+func MergeItem(dbc db.Executor, r Item) (Item, bool, error) {
+	now := time.Now()
+
+	if _, err := list.SelectList(dbc, r.ListID); errors.Cause(err) == sql.ErrNoRows {
+		return Item{}, false, sql.ErrNoRows
+	}
+
+	var merged struct {
+		Item
+		Inserted bool `db:"inserted"`
+	}
+
+	err := dbc.QueryRowx(merge, r.ListID, r.Name, r.Quantity, r.Unit, now, now).StructScan(&merged)
+	if err == sql.ErrNoRows {
+		return Item{}, false, ErrUnitMismatch
+	}
+
+	if err != nil {
+		return Item{}, false, errors.Wrap(err, "merge item row")
+	}
+
+	if !merged.Inserted {
+		old := merged.Item
+		old.Quantity -= r.Quantity
+
+		if err := recordChanges(dbc, old, merged.Item); err != nil {
+			return Item{}, false, err
+		}
 	}
 
+	return merged.Item, merged.Inserted, nil
+}
+
+// UpdateItem updates a row in the item table based off of item_id and list_id. The only fields
+// able to be updated are the name, quantity, and unit field, whose changes are recorded in the
+// item_history table. sql.ErrNoRows is returned if the list does not contain the item.
+func UpdateItem(dbc db.Executor, r Item) error {
 	r.Modified = time.Now()
 
-	if _, err := dbc.Exec(update, r.Name, r.Quantity, r.Modified, r.ID, r.ListID); err != nil {
+	var old Item
+	if err := dbc.Get(&old, selectForUpdate, r.ID, r.ListID); err != nil {
+		if err == sql.ErrNoRows {
+			return sql.ErrNoRows
+		}
+
+		return errors.Wrap(err, "select item row for update")
+	}
+
+	res, err := dbc.Exec(update, r.Name, r.Quantity, r.Unit, r.Modified, r.ID, r.ListID)
+	if err != nil {
 		return errors.Wrap(err, "update item row")
 	}
 
-	return nil
+	if err := affected(res); err != nil {
+		return err
+	}
+
+	return recordChanges(dbc, old, r)
 }
 
-// DeleteItem deletes a row in the item table based off of item_id.
-func DeleteItem(dbc *sqlx.DB, itemID, listID int) error {
-	if _, err := SelectItem(dbc, itemID, listID); errors.Cause(err) == sql.ErrNoRows {
-		return sql.ErrNoRows
+// DeleteItem deletes a row in the item table based off of item_id and list_id and returns
+// the deleted row. sql.ErrNoRows is returned if the list does not contain the item.
+func DeleteItem(dbc db.Executor, itemID, listID int) (Item, error) {
+	var i Item
+	if err := dbc.Get(&i, del, itemID, listID); err != nil {
+		return Item{}, errors.Wrap(err, "delete item row")
+	}
+
+	return i, nil
+}
+
+// affected returns sql.ErrNoRows if no row was affected by the statement that returned res.
+// Statements filtering by both item_id and list_id affect no row when the item belongs to
+// another list, which is reported the same as a missing item.
+func affected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected row count")
 	}
 
-	if _, err := dbc.Exec(del, itemID); err != nil {
-		return errors.Wrap(err, "delete list row")
+	if n == 0 {
+		return sql.ErrNoRows
 	}
 
 	return nil
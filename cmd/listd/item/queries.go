@@ -6,20 +6,70 @@ const (
 	// by list_id.
 	selectAll = "SELECT * FROM item WHERE list_id = $1;"
 
+	// selectPage is a query that selects a page of rows in the item table filtered
+	// by list_id and ordered by item_id, given the limit and offset of the page.
+	selectPage = "SELECT * FROM item WHERE list_id = $1 ORDER BY item_id LIMIT $2 OFFSET $3;"
+
+	// count is a query that counts the rows in the item table filtered by list_id.
+	count = "SELECT count(*) FROM item WHERE list_id = $1;"
+
 	// selectByIDAndListID is a query that selects a row in the item table
 	// filtered by item_id and list_id.
 	selectByIDAndListID = "SELECT * FROM item WHERE item_id = $1 AND list_id = $2;"
 
+	// selectByIDs is a query that selects the rows from the item table with the given
+	// item_ids, in the order the item_ids are given.
+	selectByIDs = "SELECT * FROM item WHERE item_id = ANY($1) ORDER BY array_position($1, item_id);"
+
 	// insert is a query that inserts a row into the item table using the
-	// values given in order for list_id, name, quantity, created, and
-	// modified.
-	insert = "INSERT INTO item (list_id, name, quantity, created, modified) VALUES ($1, $2, $3, $4, $5) RETURNING item_id;"
+	// values given in order for list_id, name, quantity, unit, created,
+	// and modified.
+	insert = "INSERT INTO item (list_id, name, quantity, unit, created, modified) VALUES ($1, $2, $3, $4, $5, $6) RETURNING item_id;"
+
+	// insertMany is a query that inserts rows into the item table of the list given by
+	// list_id, one per element of the arrays of name, quantity, and unit, with the given
+	// created and modified. Rows whose name is already taken in the list, including by an
+	// earlier row of the arrays, are skipped. The item_id and name of every inserted row
+	// are returned.
+	insertMany = `INSERT INTO item (list_id, name, quantity, unit, created, modified)
+		SELECT $1, name, quantity, unit, $5, $5 FROM unnest($2::text[], $3::int[], $4::text[]) AS i (name, quantity, unit)
+		ON CONFLICT DO NOTHING
+		RETURNING item_id, name;`
+
+	// merge is a query that inserts a row into the item table like insert, or when
+	// the list already contains an item with the same name regardless of case and in
+	// the same unit, adds the quantity to that item instead. No row is returned when
+	// the existing item has a different unit. inserted reports which of both happened.
+	merge = `INSERT INTO item (list_id, name, quantity, unit, created, modified) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (list_id, lower(name)) DO UPDATE
+		SET quantity = item.quantity + EXCLUDED.quantity, modified = EXCLUDED.modified
+		WHERE item.unit = EXCLUDED.unit
+		RETURNING *, xmax = 0 AS inserted;`
 
 	// update is a query that updates a row in the item table based off of
 	// item_id and list_id. The values able to be updated are name,
-	// quantity, and modified.
-	update = "UPDATE item SET name = $1, quantity = $2, modified = $3 WHERE item_id = $4 AND list_id = $5;"
+	// quantity, unit, and modified.
+	update = "UPDATE item SET name = $1, quantity = $2, unit = $3, modified = $4 WHERE item_id = $5 AND list_id = $6;"
+
+	// del is a query that deletes a row in the item table given an item_id and
+	// list_id and returns the deleted row.
+	del = "DELETE FROM item WHERE item_id = $1 AND list_id = $2 RETURNING *;"
+
+	// selectForUpdate is a query that selects a row in the item table filtered by item_id
+	// and list_id, locking it until the end of the transaction.
+	selectForUpdate = "SELECT * FROM item WHERE item_id = $1 AND list_id = $2 FOR UPDATE;"
+
+	// insertChange is a query that inserts a row into the item_history table using the
+	// values given in order for item_id, field, old_value, new_value, and changed.
+	insertChange = "INSERT INTO item_history (item_id, field, old_value, new_value, changed) VALUES ($1, $2, $3, $4, $5);"
+
+	// selectHistoryPage is a query that selects a page of rows in the item_history table
+	// filtered by item_id and ordered from the newest change, given the limit and offset
+	// of the page.
+	selectHistoryPage = `SELECT history_id, field, old_value, new_value, changed FROM item_history
+		WHERE item_id = $1 ORDER BY history_id DESC LIMIT $2 OFFSET $3;`
 
-	// del is a query that deletes a row in the item table given an item_id.
-	del = "DELETE FROM item WHERE item_id = $1"
+	// countHistory is a query that counts the rows in the item_history table filtered by
+	// item_id.
+	countHistory = "SELECT count(*) FROM item_history WHERE item_id = $1;"
 )
@@ -0,0 +1,132 @@
+package job
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// Status is the state a job is in.
+type Status string
+
+// These constants define the states a job goes through. Jobs start out pending, run,
+// and end up either succeeded or failed.
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of an operation that runs in the background. Its result is kept apart
+// since it can be large, see SelectResult.
+type Job struct {
+	ID       int       `json:"id" db:"job_id"`
+	Kind     string    `json:"kind" db:"kind"`
+	Status   Status    `json:"status" db:"status"`
+	Progress int       `json:"progress" db:"progress"`
+	Error    string    `json:"error,omitempty" db:"error"`
+	Created  time.Time `json:"created" db:"created"`
+	Modified time.Time `json:"modified" db:"modified"`
+}
+
+// CreateJob inserts a new, pending row of the given kind into the job table.
+func CreateJob(dbc db.Executor, kind string) (Job, error) {
+	now := time.Now()
+
+	j := Job{
+		Kind:     kind,
+		Status:   StatusPending,
+		Created:  now,
+		Modified: now,
+	}
+
+	if err := dbc.Get(&j.ID, insert, j.Kind, j.Status, j.Created, j.Modified); err != nil {
+		return Job{}, errors.Wrap(err, "insert job row")
+	}
+
+	return j, nil
+}
+
+// SelectJob selects a single row from the job table based off of a given job_id.
+func SelectJob(dbc db.Executor, id int) (Job, error) {
+	var j Job
+	if err := db.Get(dbc, &j, selectByID, id); err != nil {
+		return Job{}, errors.Wrap(err, "select job row by id")
+	}
+
+	return j, nil
+}
+
+// SelectResult selects the result of the job with the given job_id, which is nil unless
+// the job succeeded.
+func SelectResult(dbc db.Executor, id int) (json.RawMessage, error) {
+	var result []byte
+	if err := db.Get(dbc, &result, selectResult, id); err != nil {
+		return nil, errors.Wrap(err, "select job result by id")
+	}
+
+	return result, nil
+}
+
+// Start marks the job with the given job_id as running.
+func Start(dbc db.Executor, id int) error {
+	return update(dbc, id, StatusRunning, 0, nil, "")
+}
+
+// SetProgress sets the progress of the running job with the given job_id, in percent.
+func SetProgress(dbc db.Executor, id, progress int) error {
+	if _, err := dbc.Exec(updateProgress, progress, time.Now(), id); err != nil {
+		return errors.Wrap(err, "update job progress")
+	}
+
+	return nil
+}
+
+// Succeed marks the job with the given job_id as succeeded and stores its result, which
+// is marshaled to JSON.
+func Succeed(dbc db.Executor, id int, result interface{}) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "marshal job result")
+	}
+
+	return update(dbc, id, StatusSucceeded, 100, b, "")
+}
+
+// Fail marks the job with the given job_id as failed with the given message.
+func Fail(dbc db.Executor, id int, message string) error {
+	return update(dbc, id, StatusFailed, 0, nil, message)
+}
+
+// update sets the status along with the progress, result, and error of the job with
+// the given job_id. The progress never decreases, so a failed job keeps the progress it
+// reached.
+func update(dbc db.Executor, id int, status Status, progress int, result []byte, message string) error {
+
+	// A nil slice would be stored as an empty result rather than NULL.
+	var r interface{}
+	if result != nil {
+		r = result
+	}
+
+	res, err := dbc.Exec(updateStatus, status, progress, r, message, time.Now(), id)
+	if err != nil {
+		return errors.Wrap(err, "update job row")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
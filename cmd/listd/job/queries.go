@@ -0,0 +1,24 @@
+package job
+
+// PostgreSQL queries for the job table, all used in the job package.
+const (
+	// insert is a query that inserts a new row into the job table and returns its job_id.
+	insert = "INSERT INTO job (kind, status, created, modified) VALUES ($1, $2, $3, $4) RETURNING job_id;"
+
+	// selectByID is a query that selects a row from the job table, without its result,
+	// based off of job_id.
+	selectByID = "SELECT job_id, kind, status, progress, error, created, modified FROM job WHERE job_id = $1;"
+
+	// selectResult is a query that selects the result of a row from the job table based
+	// off of job_id.
+	selectResult = "SELECT result FROM job WHERE job_id = $1;"
+
+	// updateProgress is a query that updates the progress of a row in the job table based
+	// off of job_id.
+	updateProgress = "UPDATE job SET progress = $1, modified = $2 WHERE job_id = $3;"
+
+	// updateStatus is a query that updates a row in the job table based off of job_id. The
+	// values able to be updated are status, progress, result, error, and modified. The
+	// progress is only ever increased.
+	updateStatus = "UPDATE job SET status = $1, progress = GREATEST(progress, $2), result = $3, error = $4, modified = $5 WHERE job_id = $6;"
+)
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/scheduler"
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+)
+
+// relayBatchSize is the maximum amount of events relayed within a single transaction.
+const relayBatchSize = 100
+
+// purged holds the amount of rows deleted by the retention purge, published through
+// expvar under the name retention and keyed by table.
+var purged = expvar.NewMap("retention")
+
+// jobs returns the background jobs ran by the serve command. Jobs with an interval of
+// 0 are disabled and left out.
+func jobs(cfg config.Config, dbc *sqlx.DB, pub events.Publisher, logger log.FieldLogger) []scheduler.Job {
+	// Events are relayed and purged by the daemons the outbox is written by, read-only
+	// ones only check the data.
+	if cfg.ReadOnly != "" {
+		cfg.EventsRelayInterval, cfg.PurgeInterval = 0, 0
+	}
+
+	all := []scheduler.Job{
+		{
+			Name:     "events_relay",
+			Interval: cfg.EventsRelayInterval,
+			Run: func(ctx context.Context) error {
+
+				// Relaying in batches until the outbox is drained keeps up with bursts
+				// without holding a single transaction open for long.
+				for {
+					n, err := outbox.Relay(ctx, dbc, pub, relayBatchSize)
+					if err != nil || n < relayBatchSize {
+						return err
+					}
+				}
+			},
+		},
+		{
+			Name:     "outbox_purge",
+			Interval: cfg.PurgeInterval,
+			Run: func(ctx context.Context) error {
+				before := time.Now().Add(-cfg.OutboxRetention)
+
+				// Like relaying, purging happens in batches so no single delete holds
+				// its locks for long. The job stops in between batches when canceled.
+				var total int64
+				for ctx.Err() == nil {
+					n, err := outbox.Purge(dbc, before, cfg.PurgeBatchSize)
+					if err != nil {
+						return err
+					}
+
+					total += n
+					purged.Add("outbox", n)
+
+					if n < int64(cfg.PurgeBatchSize) {
+						break
+					}
+				}
+
+				logger.WithField("events", total).Debug("purged published events")
+				return ctx.Err()
+			},
+		},
+		{
+			Name:     "consistency_check",
+			Interval: cfg.CheckInterval,
+			Run: func(ctx context.Context) error {
+				_, err := runChecks(dbc, logger, func(c check, problem string) {
+					logger.WithField("check", c.name).Warn(problem)
+				})
+				return err
+			},
+		},
+	}
+
+	enabled := make([]scheduler.Job, 0, len(all))
+	for _, j := range all {
+		if j.Interval > 0 {
+			enabled = append(enabled, j)
+		}
+	}
+
+	return enabled
+}
@@ -2,9 +2,11 @@ package list
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -16,46 +18,107 @@ type List struct {
 	Name     string    `json:"name" db:"name"`
 	Created  time.Time `json:"created" db:"created"`
 	Modified time.Time `json:"modified" db:"modified"`
+
+	// Icon is a single emoji clients show along with the name of the list, if any.
+	Icon string `json:"icon" db:"icon"`
+
+	// Color is the color clients present the list in as a lowercase hex triplet such as
+	// #ff8800, if any.
+	Color string `json:"color" db:"color"`
+
+	// ItemCount is the amount of items in the list. It is kept up to date by the
+	// database whenever items are added, moved, or removed. Responses carry it as
+	// itemCount as well until item_count is removed, see package handlers.
+	ItemCount int `json:"item_count" db:"item_count"`
 }
 
 // SelectLists selects all rows from the list table.
-func SelectLists(dbc *sqlx.DB) ([]List, error) {
+func SelectLists(dbc db.Executor) ([]List, error) {
 	lists := make([]List, 0)
 
-	if err := dbc.Select(&lists, selectAll); err != nil {
+	if err := db.Select(dbc, &lists, selectAll); err != nil {
 		return nil, errors.Wrap(err, "select all rows from list table")
 	}
 
 	return lists, nil
 }
 
+// SelectListPage selects up to limit rows from the list table in the order given by sort,
+// skipping the first offset rows, along with the total amount of rows in the table.
+func SelectListPage(dbc db.Executor, sort Sort, limit, offset int) ([]List, int, error) {
+	var total int
+	if err := db.Get(dbc, &total, count); err != nil {
+		return nil, 0, errors.Wrap(err, "count rows in list table")
+	}
+
+	lists := make([]List, 0)
+
+	if err := db.Select(dbc, &lists, fmt.Sprintf(selectPage, sort.orderBy()), limit, offset); err != nil {
+		return nil, 0, errors.Wrap(err, "select page of rows from list table")
+	}
+
+	return lists, total, nil
+}
+
+// SelectListsByIDs selects the rows from the list table with the given ids, in the order
+// the ids are given. IDs without a row are left out, as are repeated ones.
+func SelectListsByIDs(dbc db.Executor, ids []int) ([]List, error) {
+	lists := make([]List, 0, len(ids))
+
+	if err := db.Select(dbc, &lists, selectByIDs, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "select rows from list table by ids")
+	}
+
+	return lists, nil
+}
+
 // SelectList selects a single row from the list table based off of a given list_id.
-func SelectList(dbc *sqlx.DB, id int) (List, error) {
+func SelectList(dbc db.Executor, id int) (List, error) {
 	var list List
 	stmt := selectByID
 
-	pStmt, err := dbc.Preparex(stmt)
+	err := db.RetryRead(dbc, func() error {
+		pStmt, err := dbc.Preparex(stmt)
+		if err != nil {
+			return errors.Wrap(err, "prepare select query")
+		}
+
+		defer func() {
+			if err := pStmt.Close(); err != nil {
+				logrus.WithError(errors.Wrap(err, "close psql statement")).Info("select list")
+			}
+		}()
+
+		row := pStmt.QueryRowx(id)
+
+		return errors.Wrap(row.StructScan(&list), "select singular row from list table")
+	})
 	if err != nil {
-		return List{}, errors.Wrap(err, "prepare select query")
+		return List{}, err
 	}
 
-	defer func() {
-		if err := pStmt.Close(); err != nil {
-			logrus.WithError(errors.Wrap(err, "close psql statement")).Info("select list")
-		}
-	}()
-
-	row := pStmt.QueryRowx(id)
+	return list, nil
+}
 
-	if err := row.StructScan(&list); err != nil {
-		return List{}, errors.Wrap(err, "select singular row from list table")
+// SelectListByName selects the row from the list table whose name equals the given name
+// regardless of case, which is unique.
+func SelectListByName(dbc db.Executor, name string) (List, error) {
+	var list List
+	if err := db.Get(dbc, &list, selectByName, name); err != nil {
+		return List{}, errors.Wrap(err, "select list row by name")
 	}
 
 	return list, nil
 }
 
-// CreateList inserts a new row into the list table.
-func CreateList(dbc *sqlx.DB, r List) (List, error) {
+// ErrNameTaken is returned by CreateList when another list has the same name regardless
+// of case.
+var ErrNameTaken = errors.New("list name is taken")
+
+// CreateList inserts a new row into the list table. ErrNameTaken is returned when another
+// list has the name, including one created by a concurrent transaction that commits
+// first, which the insert waits for. Either way the transaction can still be used.
+func CreateList(dbc db.Executor, r List) (List, error) {
 	r.Created = time.Now()
 	r.Modified = time.Now()
 
@@ -70,43 +133,77 @@ func CreateList(dbc *sqlx.DB, r List) (List, error) {
 		}
 	}()
 
-	row := stmt.QueryRow(r.Name, r.Created, r.Modified)
+	row := stmt.QueryRow(r.Name, r.Icon, r.Color, r.Created, r.Modified)
 
 	if err = row.Scan(&r.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return List{}, ErrNameTaken
+		}
+
 		return List{}, errors.Wrap(err, "get inserted row id")
 	}
 
 	return r, nil
 }
 
-// UpdateList updates a row in the list table based off of a list_id. The only field
-// able to be updated is the name field.
-func UpdateList(dbc *sqlx.DB, r List) error {
+// UpdateList updates a row in the list table based off of a list_id. The fields able
+// to be updated are name, icon, and color.
+func UpdateList(dbc db.Executor, r List) error {
 	if _, err := SelectList(dbc, r.ID); errors.Cause(err) == sql.ErrNoRows {
 		return sql.ErrNoRows
 	}
 
 	r.Modified = time.Now()
 
-	if _, err := dbc.Exec(update, r.Name, r.Modified, r.ID); err != nil {
+	if _, err := dbc.Exec(update, r.Name, r.Icon, r.Color, r.Modified, r.ID); err != nil {
 		return errors.Wrap(err, "update list row")
 	}
 
 	return nil
 }
 
-// DeleteList deletes a row in the list table based off of list_id.
-func DeleteList(dbc *sqlx.DB, id int) error {
+// DeleteList deletes a row in the list table based off of list_id and returns the
+// deleted row.
+func DeleteList(dbc db.Executor, id int) (List, error) {
 	if _, err := SelectList(dbc, id); errors.Cause(err) == sql.ErrNoRows {
-		return sql.ErrNoRows
+		return List{}, sql.ErrNoRows
 	}
 
 	if _, err := dbc.Exec(delRelatedItems, id); err != nil && errors.Cause(err) != sql.ErrNoRows {
-		return errors.Wrap(err, "deleted related items to given list_id")
+		return List{}, errors.Wrap(err, "deleted related items to given list_id")
+	}
+
+	var l List
+	if err := dbc.Get(&l, del, id); err != nil {
+		return List{}, errors.Wrap(err, "delete list row")
+	}
+
+	return l, nil
+}
+
+// SelectListsForUpdate selects the rows from the list table with the given ids, ordered
+// by list_id. The rows are locked until the end of the transaction dbc belongs to, so
+// they can't change in between selecting and deleting them. IDs without a row are left
+// out.
+func SelectListsForUpdate(dbc db.Executor, ids []int) ([]List, error) {
+	lists := make([]List, 0, len(ids))
+
+	if err := dbc.Select(&lists, selectByIDsForUpdate, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "select rows from list table by ids")
+	}
+
+	return lists, nil
+}
+
+// DeleteLists deletes the rows in the list table with the given ids along with the rows
+// in the item table related to them.
+func DeleteLists(dbc db.Executor, ids []int) error {
+	if _, err := dbc.Exec(delRelatedItemsOfMany, pq.Array(ids)); err != nil {
+		return errors.Wrap(err, "delete items related to given list_ids")
 	}
 
-	if _, err := dbc.Exec(del, id); err != nil {
-		return errors.Wrap(err, "delete list row")
+	if _, err := dbc.Exec(delMany, pq.Array(ids)); err != nil {
+		return errors.Wrap(err, "delete list rows")
 	}
 
 	return nil
@@ -6,22 +6,102 @@ const (
 	// selectAll is a query that selects all rows from the list table.
 	selectAll = "SELECT * FROM list;"
 
+	// selectPage is a query that selects a page of rows from the list table, given the
+	// order of the rows formatted in as a string and the limit and offset of the page.
+	selectPage = "SELECT * FROM list ORDER BY %s LIMIT $1 OFFSET $2;"
+
+	// count is a query that counts the rows in the list table.
+	count = "SELECT count(*) FROM list;"
+
 	// selectByID is a query that selects a row from the list table based off of
 	// the given list_id.
 	selectByID = "SELECT * FROM list WHERE list_id = $1;"
 
+	// selectByName is a query that selects a row from the list table based off of
+	// the given name, regardless of case.
+	selectByName = "SELECT * FROM list WHERE lower(name) = lower($1);"
+
+	// selectByIDsForUpdate is a query that selects the rows from the list table with the
+	// given list_ids ordered by list_id, locking them until the end of the transaction.
+	selectByIDsForUpdate = "SELECT * FROM list WHERE list_id = ANY($1) ORDER BY list_id FOR UPDATE;"
+
+	// selectByIDs is a query that selects the rows from the list table with the given
+	// list_ids, in the order the list_ids are given.
+	selectByIDs = "SELECT * FROM list WHERE list_id = ANY($1) ORDER BY array_position($1, list_id);"
+
 	// insert is a query that inserts a new row in the list table using the values
-	// given in order for name, created, and modified.
-	insert = "INSERT INTO list (name, created, modified) VALUES ($1, $2, $3) RETURNING list_id;"
+	// given in order for name, icon, color, created, and modified. Nothing is inserted
+	// nor returned when another list has the name.
+	insert = `INSERT INTO list (name, icon, color, created, modified) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ((lower(name))) DO NOTHING RETURNING list_id;`
 
 	// update is a query that updates a row in the list table based off of list_id.
-	// The values able to be updated are name and modified.
-	update = "UPDATE list SET name = $1, modified = $2 WHERE list_id = $3;"
+	// The values able to be updated are name, icon, color, and modified.
+	update = "UPDATE list SET name = $1, icon = $2, color = $3, modified = $4 WHERE list_id = $5;"
 
 	// delRelatedItems deletes rows in the item table that are related to a list by
 	// a given list_id.
 	delRelatedItems = "DELETE FROM item WHERE list_id = $1"
 
-	// del is a query that deletes a row in the list table given a list_id.
-	del = "DELETE FROM list WHERE list_id = $1;"
+	// del is a query that deletes a row in the list table given a list_id and returns
+	// the deleted row.
+	del = "DELETE FROM list WHERE list_id = $1 RETURNING *;"
+
+	// delRelatedItemsOfMany deletes rows in the item table that are related to any of
+	// the lists with the given list_ids.
+	delRelatedItemsOfMany = "DELETE FROM item WHERE list_id = ANY($1);"
+
+	// delMany is a query that deletes the rows in the list table with the given list_ids.
+	delMany = "DELETE FROM list WHERE list_id = ANY($1);"
+
+	// selectSettings is a query that selects the settings of a list from the list_settings
+	// table based off of the given list_id.
+	selectSettings = "SELECT settings FROM list_settings WHERE list_id = $1;"
+
+	// upsertSettings is a query that inserts or replaces the settings of a list in the
+	// list_settings table using the values given in order for list_id, settings, and
+	// modified.
+	upsertSettings = `INSERT INTO list_settings (list_id, settings, modified) VALUES ($1, $2, $3)
+		ON CONFLICT (list_id) DO UPDATE SET settings = EXCLUDED.settings, modified = EXCLUDED.modified;`
+
+	// upsertShare is a query that inserts a row in the list_share table using the values
+	// given in order for list_id, token, created, and expires, returning it along with
+	// whether it holds the given token. When the list is already shared only its expires
+	// is replaced, along with its token and created once it has expired.
+	upsertShare = `INSERT INTO list_share (list_id, token, created, expires) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (list_id) DO UPDATE SET
+			token = CASE WHEN list_share.expires <= EXCLUDED.created THEN EXCLUDED.token ELSE list_share.token END,
+			created = CASE WHEN list_share.expires <= EXCLUDED.created THEN EXCLUDED.created ELSE list_share.created END,
+			expires = EXCLUDED.expires
+		RETURNING *, token = $2 AS fresh;`
+
+	// selectShare is a query that selects a row from the list_share table based off of
+	// the given list_id unless it expired by the given time.
+	selectShare = "SELECT * FROM list_share WHERE list_id = $1 AND (expires IS NULL OR expires > $2);"
+
+	// countShares is a query that counts the rows of the list_share table that didn't
+	// expire by the given time.
+	countShares = "SELECT COUNT(*) FROM list_share WHERE expires IS NULL OR expires > $1;"
+
+	// selectSharesPage is a query that selects the given amount of rows of the list_share
+	// table that didn't expire by the given time, the most recently created first,
+	// skipping the given offset.
+	selectSharesPage = `SELECT * FROM list_share WHERE expires IS NULL OR expires > $1
+		ORDER BY created DESC, list_id DESC LIMIT $2 OFFSET $3;`
+
+	// delShare is a query that deletes a row in the list_share table given a list_id.
+	delShare = "DELETE FROM list_share WHERE list_id = $1;"
+
+	// delShareByToken is a query that deletes a row in the list_share table given a
+	// token.
+	delShareByToken = "DELETE FROM list_share WHERE token = $1;"
+
+	// selectShared is a query that selects the row from the list table shared by the
+	// given token, unless the share expired by the given time.
+	selectShared = `SELECT list.* FROM list JOIN list_share USING (list_id)
+		WHERE list_share.token = $1 AND (list_share.expires IS NULL OR list_share.expires > $2);`
+
+	// selectCollation is a query that selects the longest name among the two given ICU
+	// collations that exists.
+	selectCollation = "SELECT collname FROM pg_collation WHERE collprovider = 'i' AND collname IN ($1, $2) ORDER BY length(collname) DESC LIMIT 1;"
 )
@@ -0,0 +1,72 @@
+package list
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// Settings are the preferences of a list, which clients use to present it. They are
+// stored as a single JSON document so new preferences don't need a migration.
+type Settings struct {
+	// Sort is the field items are sorted by, prefixed with - for descending order.
+	Sort string `json:"sort"`
+
+	// HideCompleted hides items that are completed.
+	HideCompleted bool `json:"hide_completed"`
+
+	// Color is the color of the list as a hex triplet such as #ff8800, if any.
+	Color string `json:"color"`
+
+	// Notify tells whether changes to the list should be notified about.
+	Notify bool `json:"notify"`
+}
+
+// DefaultSettings are the settings of a list that has never been given any, and the
+// values of the settings left out when they are given.
+var DefaultSettings = Settings{
+	Sort:   "created",
+	Notify: true,
+}
+
+// SelectSettings selects the settings of the list with the given list_id, which are the
+// DefaultSettings if the list has never been given any. It doesn't check whether the list
+// exists.
+func SelectSettings(dbc db.Executor, listID int) (Settings, error) {
+	var b []byte
+	if err := db.Get(dbc, &b, selectSettings, listID); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return DefaultSettings, nil
+		}
+
+		return Settings{}, errors.Wrap(err, "select list settings row")
+	}
+
+	s := DefaultSettings
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Settings{}, errors.Wrap(err, "unmarshal list settings")
+	}
+
+	return s, nil
+}
+
+// UpdateSettings replaces the settings of the list with the given list_id.
+func UpdateSettings(dbc db.Executor, listID int, s Settings) error {
+	if _, err := SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
+		return sql.ErrNoRows
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "marshal list settings")
+	}
+
+	if _, err := dbc.Exec(upsertSettings, listID, b, time.Now()); err != nil {
+		return errors.Wrap(err, "upsert list settings row")
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package list
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// Share is the link a list is shared by, which lets anyone holding its token read the
+// list through the public read API without authentication until it expires or is
+// revoked. Shares without Expires never expire.
+type Share struct {
+	ListID  int        `json:"listID" db:"list_id"`
+	Token   string     `json:"token" db:"token"`
+	Created time.Time  `json:"created" db:"created"`
+	Expires *time.Time `json:"expires" db:"expires"`
+}
+
+// tokenBytes is the amount of random bytes a share token is made of, enough for it to be
+// impossible to guess.
+const tokenBytes = 16
+
+// newToken returns a new random share token.
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "read random bytes")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// ShareList shares the list with the given list_id for ttl, or for good when ttl is 0,
+// and returns its Share along with whether its token is new. A list that already is
+// shared keeps its token and is shared for ttl from now on; a list whose share expired
+// is handed out a new token. sql.ErrNoRows is returned if the list does not exist.
+func ShareList(dbc db.Executor, listID int, ttl time.Duration) (Share, bool, error) {
+	if _, err := SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
+		return Share{}, false, sql.ErrNoRows
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return Share{}, false, err
+	}
+
+	now := time.Now()
+
+	var expires *time.Time
+	if ttl > 0 {
+		t := now.Add(ttl)
+		expires = &t
+	}
+
+	var row struct {
+		Share
+		Fresh bool `db:"fresh"`
+	}
+
+	if err := dbc.QueryRowx(upsertShare, listID, token, now, expires).StructScan(&row); err != nil {
+		return Share{}, false, errors.Wrap(err, "upsert list share row")
+	}
+
+	return row.Share, row.Fresh, nil
+}
+
+// SelectShare selects the Share of the list with the given list_id, sql.ErrNoRows is
+// returned if it isn't shared or its share expired.
+func SelectShare(dbc db.Executor, listID int) (Share, error) {
+	var s Share
+	if err := db.Get(dbc, &s, selectShare, listID, time.Now()); err != nil {
+		return Share{}, errors.Wrap(err, "select list share row")
+	}
+
+	return s, nil
+}
+
+// SelectShares selects the given amount of the Shares that haven't expired, the most
+// recently created first, skipping the given offset. The total amount of them is
+// returned along with them.
+func SelectShares(dbc db.Executor, limit, offset int) ([]Share, int, error) {
+	now := time.Now()
+
+	var total int
+	if err := db.Get(dbc, &total, countShares, now); err != nil {
+		return nil, 0, errors.Wrap(err, "count rows in list_share table")
+	}
+
+	shares := make([]Share, 0)
+
+	if err := db.Select(dbc, &shares, selectSharesPage, now, limit, offset); err != nil {
+		return nil, 0, errors.Wrap(err, "select page of rows from list_share table")
+	}
+
+	return shares, total, nil
+}
+
+// Unshare revokes the Share of the list with the given list_id, after which its token no
+// longer reads the list. sql.ErrNoRows is returned if the list isn't shared.
+func Unshare(dbc db.Executor, listID int) error {
+	res, err := dbc.Exec(delShare, listID)
+	if err != nil {
+		return errors.Wrap(err, "delete list share row")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrap(err, "count deleted list share rows")
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Revoke revokes the Share with the given token, like Unshare does for its list.
+// sql.ErrNoRows is returned if no list is shared by the token.
+func Revoke(dbc db.Executor, token string) error {
+	res, err := dbc.Exec(delShareByToken, token)
+	if err != nil {
+		return errors.Wrap(err, "delete list share row by token")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrap(err, "count deleted list share rows")
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SelectShared selects the list shared by the given token, sql.ErrNoRows is returned if
+// no list is shared by it or its share expired.
+func SelectShared(dbc db.Executor, token string) (List, error) {
+	var l List
+	if err := db.Get(dbc, &l, selectShared, token, time.Now()); err != nil {
+		return List{}, errors.Wrap(err, "select list by share token")
+	}
+
+	return l, nil
+}
@@ -0,0 +1,95 @@
+package list
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownLocale is returned by Collation for locales postgres has no collation for.
+var ErrUnknownLocale = errors.New("no collation for locale")
+
+// localePattern matches the BCP 47 language tags locales are given as, such as de or
+// pt-BR.
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// Sort is the order a page of lists is selected in. The zero value orders them by ID.
+type Sort struct {
+	// ByName orders the lists by name instead of by ID, lists with the same name by ID.
+	ByName bool
+
+	// Desc orders the lists in descending order.
+	Desc bool
+
+	// Collation is the postgres collation names are compared in, see Collation. The
+	// collation of the database is used when it is empty.
+	Collation string
+}
+
+// orderBy returns the ORDER BY clause of s, without the keywords.
+func (s Sort) orderBy() string {
+	dir := "ASC"
+	if s.Desc {
+		dir = "DESC"
+	}
+
+	if !s.ByName {
+		return "list_id " + dir
+	}
+
+	name := "name"
+	if s.Collation != "" {
+		name += " COLLATE " + pq.QuoteIdentifier(s.Collation)
+	}
+
+	return name + " " + dir + ", list_id " + dir
+}
+
+// Collation returns the ICU collation of postgres that compares names the way they are
+// sorted in locale, a BCP 47 language tag such as de or de-AT. A locale without a
+// collation of its own falls back to the one of its language. ErrUnknownLocale is
+// returned when there is neither, which is always the case when postgres was built
+// without ICU.
+func Collation(dbc db.Executor, locale string) (string, error) {
+	if !localePattern.MatchString(locale) {
+		return "", ErrUnknownLocale
+	}
+
+	tag := canonicalLocale(locale)
+	language := strings.SplitN(tag, "-", 2)[0]
+
+	var name string
+	if err := db.Get(dbc, &name, selectCollation, tag+"-x-icu", language+"-x-icu"); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", ErrUnknownLocale
+		}
+
+		return "", errors.Wrap(err, "select collation of locale")
+	}
+
+	return name, nil
+}
+
+// canonicalLocale returns locale in the case postgres names ICU collations in, such as
+// de-AT or zh-Hant-TW: lowercase languages, titlecase scripts, and uppercase regions.
+func canonicalLocale(locale string) string {
+	parts := strings.Split(locale, "-")
+	parts[0] = strings.ToLower(parts[0])
+
+	for i := 1; i < len(parts); i++ {
+		switch p := parts[i]; len(p) {
+		case 2:
+			parts[i] = strings.ToUpper(p)
+		case 4:
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+
+	return strings.Join(parts, "-")
+}
@@ -1,21 +1,40 @@
 package main
 
 import (
-	"context"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"strings"
 
-	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/logging"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// command is a subcommand of the listd binary.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands contains every subcommand of the listd binary. The first command is the
+// one that is ran when no subcommand is given.
+var commands = []command{
+	{name: "serve", usage: "start the HTTP server (default)", run: serve},
+	{name: "migrate", usage: "apply pending database migrations or show their status", run: migrate},
+	{name: "seed", usage: "insert demo lists and items into the database", run: seed},
+	{name: "fsck", usage: "check the database for inconsistent data", run: fsck},
+	{name: "export", usage: "export every list and its items as JSON", run: export},
+	{name: "backup", usage: "back up every table as a gzipped SQL script", run: backUp},
+	{name: "restore", usage: "replace every row by those of a backup", run: restore},
+	{name: "ids", usage: "show or switch the strategy ids are generated by", run: ids},
+	{name: "tenant", usage: "create, list, or migrate the schemas of tenants", run: tenant},
+}
+
 func main() {
 	var err error
 	defer func() {
@@ -28,83 +47,94 @@ func main() {
 		}
 	}()
 
-	// cfg is the struct type that contains fields that stores the necessary configuration
-	// gathered from the environment.
-	var cfg struct {
-		DaemonPort int `envconfig:"DAEMON_PORT" default:"3000"`
+	cmd, args := commands[0], os.Args[1:]
 
-		DBUser string `envconfig:"DB_USER" default:"root"`
-		DBPass string `envconfig:"DB_PASS" default:"root"`
-		DBName string `envconfig:"DB_NAME" default:"list"`
-		DBHost string `envconfig:"DB_USER" default:"db"`
-		DBPort int    `envconfig:"DB_USER" default:"5432"`
+	// Running listd without a subcommand, or with only flags, keeps starting the server
+	// so existing deployments don't have to change.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, err = lookup(args[0])
+		if err != nil {
+			usage()
+			return
+		}
 
-		ReadTimeout     time.Duration `envconfig:"READ_TIMEOUT" default:"5s"`
-		WriteTimeout    time.Duration `envconfig:"WRITE_TIMEOUT" default:"10s"`
-		ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"5s"`
+		args = args[1:]
 	}
-	if err := envconfig.Process("LIST", &cfg); err != nil {
-		err = errors.Wrap(err, "parse environment variables")
-		return
+
+	err = errors.Wrap(cmd.run(args), cmd.name)
+}
+
+// lookup returns the subcommand with the given name.
+func lookup(name string) (command, error) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, nil
+		}
 	}
 
-	dbCfg := db.Config{
-		User: cfg.DBUser,
-		Pass: cfg.DBPass,
-		Name: cfg.DBName,
-		Host: cfg.DBHost,
-		Port: cfg.DBPort,
+	return command{}, errors.Errorf("unknown command %q", name)
+}
+
+// usage prints the available subcommands to stderr.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: listd [command] [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.usage)
 	}
-	dbc, err := db.NewConnection(dbCfg)
+	fmt.Fprintf(os.Stderr, "\nRun 'listd [command] -h' to see the flags of a command.\n")
+}
+
+// setup loads the configuration from the given arguments using fs, which may already
+// have flags of the subcommand registered, and configures the logger accordingly.
+func setup(fs *flag.FlagSet, args []string) (config.Config, *log.Logger, error) {
+	w, logger, err := watch(fs, args)
 	if err != nil {
-		err = errors.Wrap(err, "connect to postgres db")
-		return
+		return config.Config{}, nil, err
 	}
 
-	defer func(){
-		if err := dbc.Close(); err != nil {
-			log.Printf("error closing database: %v", err)
-		}
-	}()
+	return w.Config(), logger, nil
+}
 
-	server := http.Server{
-		Addr:           fmt.Sprintf(":%d", cfg.DaemonPort),
-		Handler:        handlers.NewApplication(dbc),
-		ReadTimeout:    cfg.ReadTimeout,
-		WriteTimeout:   cfg.WriteTimeout,
-		MaxHeaderBytes: 1 << 20,
+// watch does the same as setup but returns a config.Watcher that is able to reload
+// the configuration.
+//
+// The standard logger is configured and returned rather than creating a new one so
+// that packages logging through logrus directly honor the configuration as well.
+func watch(fs *flag.FlagSet, args []string) (*config.Watcher, *log.Logger, error) {
+	w, err := config.Watch(fs, args)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load configuration")
 	}
 
-	// Start listening for requests made to the daemon and create a channel
-	// to collect non-HTTP related server errors on.
-	serverErrors := make(chan error, 1)
-	go func() {
-		log.Printf("server started, listening on %s", server.Addr)
-		serverErrors <- server.ListenAndServe()
-	}()
+	cfg := w.Config()
 
-	// Blocking main and waiting for shutdown of the daemon.
-	osSignals := make(chan os.Signal, 1)
-	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
+	logger := log.StandardLogger()
+	if err := logging.Configure(logger, cfg.LogLevel, cfg.LogFormat); err != nil {
+		return nil, nil, errors.Wrap(err, "configure logger")
+	}
 
-	// Waiting for an osSignal or a non-HTTP related server error.
-	select {
-	case e := <-serverErrors:
-		err = fmt.Errorf("server failed to start: %+v", e)
-		return
+	return w, logger, nil
+}
 
-	case <-osSignals:
+// connect opens a connection to the database described by the configuration.
+func connect(cfg config.Config, logger log.FieldLogger) (*sqlx.DB, error) {
+	dbc, err := db.NewConnection(db.Config{
+		User: cfg.DBUser,
+		Pass: cfg.DBPass,
+		Name: cfg.DBName,
+		Host: cfg.DBHost,
+		Port: cfg.DBPort,
+	}, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "connect to postgres db")
 	}
 
-	// Gracefully shutdown server once an exit signal or error is received.
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("shutdown : Graceful shutdown did not complete in %v : %v", cfg.ShutdownTimeout, err)
+	return dbc, nil
+}
 
-		if err := server.Close(); err != nil {
-			log.Printf("shutdown : Error killing server : %v", err)
-		}
+// closeDB closes the given database connection, logging any error encountered.
+func closeDB(dbc *sqlx.DB, logger log.FieldLogger) {
+	if err := dbc.Close(); err != nil {
+		logger.WithError(err).Error("close database")
 	}
 }
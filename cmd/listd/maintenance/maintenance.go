@@ -0,0 +1,46 @@
+package maintenance
+
+import (
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// State is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of the maintenance mode of the list daemon. The state is kept in the
+// database so every replica sharing it agrees on whether writes are allowed.
+type State struct {
+	Enabled  bool      `json:"enabled" db:"enabled"`
+	Message  string    `json:"message" db:"message"`
+	Modified time.Time `json:"modified" db:"modified"`
+}
+
+// DefaultMessage is the message used when maintenance mode is enabled without one.
+const DefaultMessage = "the service is undergoing maintenance, write requests are temporarily disabled"
+
+// SelectState selects the current maintenance state.
+func SelectState(dbc db.Executor) (State, error) {
+	var s State
+
+	if err := db.Get(dbc, &s, selectState); err != nil {
+		return State{}, errors.Wrap(err, "select row from maintenance table")
+	}
+
+	return s, nil
+}
+
+// UpdateState updates the maintenance state and returns it as stored.
+func UpdateState(dbc db.Executor, s State) (State, error) {
+	if s.Enabled && s.Message == "" {
+		s.Message = DefaultMessage
+	}
+
+	s.Modified = time.Now()
+
+	if _, err := dbc.Exec(update, s.Enabled, s.Message, s.Modified); err != nil {
+		return State{}, errors.Wrap(err, "update maintenance row")
+	}
+
+	return s, nil
+}
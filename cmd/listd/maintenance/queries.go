@@ -0,0 +1,11 @@
+package maintenance
+
+// PostgreSQL queries for the maintenance table, all used in the maintenance package.
+const (
+	// selectState is a query that selects the single row of the maintenance table.
+	selectState = "SELECT enabled, message, modified FROM maintenance;"
+
+	// update is a query that updates the single row of the maintenance table. The values
+	// able to be updated are enabled, message, and modified.
+	update = "UPDATE maintenance SET enabled = $1, message = $2, modified = $3;"
+)
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/metrics"
+)
+
+// Counters of the changes made to lists and items. They are counted as the change
+// events are relayed from the outbox, so every write path is covered and changes that
+// were rolled back never are.
+var (
+	listsCreated = metrics.NewCounter("lists_created_total", "Lists created, including imported and instantiated ones.")
+	listsDeleted = metrics.NewCounter("lists_deleted_total", "Lists deleted.")
+	itemsCreated = metrics.NewCounter("items_created_total", "Items created, including imported and instantiated ones.")
+	itemsDeleted = metrics.NewCounter("items_deleted_total", "Items deleted on their own, not along with their list.")
+)
+
+// counters maps event types to the counter incremented by each event of the type.
+var counters = map[string]*metrics.Counter{
+	events.ListCreated: listsCreated,
+	events.ListDeleted: listsDeleted,
+	events.ItemCreated: itemsCreated,
+	events.ItemDeleted: itemsDeleted,
+}
+
+// eventCounter is an events.Publisher that counts the events it is given in counters.
+type eventCounter struct{}
+
+// Publish implements the events.Publisher interface.
+func (eventCounter) Publish(_ context.Context, e events.Event) error {
+	if c, ok := counters[e.Type]; ok {
+		c.Inc()
+	}
+
+	return nil
+}
+
+// Close implements the events.Publisher interface.
+func (eventCounter) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+)
+
+func TestEventCounter(t *testing.T) {
+	created, deleted := listsCreated.Value(), itemsDeleted.Value()
+
+	for _, typ := range []string{events.ListCreated, events.ListCreated, events.ItemDeleted, events.ListUpdated} {
+		e, err := events.New(typ, map[string]int{"id": 1})
+		if err != nil {
+			t.Fatalf("error creating event: %v", err)
+		}
+
+		if err := (eventCounter{}).Publish(context.Background(), e); err != nil {
+			t.Errorf("error publishing event: %v", err)
+		}
+	}
+
+	if e, a := int64(2), listsCreated.Value()-created; e != a {
+		t.Errorf("expected %d lists created, got %d", e, a)
+	}
+
+	if e, a := int64(1), itemsDeleted.Value()-deleted; e != a {
+		t.Errorf("expected %d items deleted, got %d", e, a)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+)
+
+// migrate applies every pending database migration, or prints the status of every
+// migration when ran with -status.
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := fs.Bool("status", false, "print the status of every migration instead of applying pending ones")
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	if *status {
+		statuses, err := db.Migrations(dbc)
+		if err != nil {
+			return errors.Wrap(err, "get migration status")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied != nil {
+				applied = s.Applied.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+
+		return errors.Wrap(w.Flush(), "write migration status")
+	}
+
+	applied, err := db.Migrate(dbc, logger)
+	if err != nil {
+		return errors.Wrap(err, "migrate database")
+	}
+
+	logger.WithField("applied", len(applied)).Info("database is up to date")
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// newMock returns the handlers.Mock serving the demo lists of the seed command, configured
+// by cfg like the Application of serve. Responses are delayed by latency and up to jitter
+// more.
+func newMock(cfg config.Config, logger log.FieldLogger, latency, jitter time.Duration) (*handlers.Mock, error) {
+	feats, err := features.New(cfg.Features)
+	if err != nil {
+		return nil, errors.Wrap(err, "configure feature flags")
+	}
+
+	app := handlers.NewApplication(nil, logger, feats)
+	app.Units = cfg.ItemUnits
+	app.Validation = validate.Mode(cfg.Validation)
+	app.Names = validate.Names{MaxLength: cfg.NameMaxLength, Lenient: app.Validation == validate.ModeLenient}
+	app.RewriteTrailingSlash = cfg.TrailingSlash == "rewrite"
+	app.MaxBodySize = int64(cfg.MaxBodySize)
+	app.PrettyJSON = cfg.PrettyJSON
+	app.Envelope = web.Envelope(cfg.Envelope)
+	app.StringIDs = cfg.StringIDs
+	app.Paging = web.Paging{DefaultSize: cfg.PageSize, MaxSize: cfg.MaxPageSize, MaxResults: cfg.MaxResults}
+
+	seed := make([]handlers.MockList, 0, len(seedData))
+	for _, sd := range seedData {
+		seed = append(seed, handlers.MockList(sd))
+	}
+
+	mock := handlers.NewMock(app, seed)
+	mock.Latency = latency
+	mock.Jitter = jitter
+
+	return mock, nil
+}
+
+// serveMock serves the API from memory instead of the database on the daemon port, see
+// handlers.Mock, and blocks until the process is signaled to shut down. Nothing is
+// connected to, so clients can be developed without Postgres or Docker.
+func serveMock(cfg config.Config, logger *log.Logger, latency, jitter time.Duration) error {
+	mock, err := newMock(cfg, logger, latency, jitter)
+	if err != nil {
+		return err
+	}
+
+	server := http.Server{
+		Addr:           fmt.Sprintf(":%d", cfg.DaemonPort),
+		Handler:        mock,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		MaxHeaderBytes: 1 << 20,
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		logger.WithFields(log.Fields{
+			"addr":    server.Addr,
+			"latency": latency,
+			"jitter":  jitter,
+		}).Warn("mock server started, serving from memory and losing every change on shutdown")
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	osSignals := make(chan os.Signal, 1)
+	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(osSignals)
+
+	select {
+	case e := <-serverErrors:
+		return errors.Wrap(e, "server failed to start")
+	case <-osSignals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.WithError(err).WithField("timeout", cfg.ShutdownTimeout).Warn("graceful shutdown did not complete")
+
+		if err := server.Close(); err != nil {
+			logger.WithError(err).Error("kill server")
+		}
+	}
+
+	return nil
+}
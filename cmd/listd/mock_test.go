@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestMock(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+
+	mock, err := newMock(config.Default(), logger, 0, 0)
+	if err != nil {
+		t.Fatalf("error creating mock: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mock.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	// The mock starts out with the demo lists of the seed command.
+	expect.Status(http.StatusOK).
+		Len("results", len(seedData)).
+		JSONPath("page.total", len(seedData)).
+		JSONPath("results.0.name", seedData[0].Name).
+		JSONPath("results.0.item_count", len(seedData[0].Items)).
+		Assert(t, do(http.MethodGet, "/list", ""))
+
+	expect.Status(http.StatusOK).
+		Len("results", len(seedData[0].Items)).
+		JSONPath("results.0.name", seedData[0].Items[0].Name).
+		Assert(t, do(http.MethodGet, "/list/1/item", ""))
+
+	// Lists and items are created, read, updated, and deleted like in the database.
+	var listID int
+	expect.Status(http.StatusCreated).
+		JSONPath("results.name", "Camping").
+		Into("results.id", &listID).
+		Assert(t, do(http.MethodPost, "/list", `{"name":"Camping"}`))
+
+	expect.Status(http.StatusConflict).
+		JSONPath("results.id", listID).
+		JSONPath("errors.0.code", codes.ListNameTaken).
+		Assert(t, do(http.MethodPost, "/list", `{"name":"camping"}`))
+	expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.NameRequired).Assert(t, do(http.MethodPost, "/list", `{}`))
+
+	var itemID int
+	expect.Status(http.StatusCreated).
+		JSONPath("results.listID", listID).
+		Into("results.id", &itemID).
+		Assert(t, do(http.MethodPost, "/list/"+strconv.Itoa(listID)+"/item", `{"name":"Tent","quantity":1}`))
+
+	expect.Status(http.StatusConflict).JSONPath("errors.0.code", codes.ItemNameTaken).Assert(t, do(http.MethodPost, "/list/"+strconv.Itoa(listID)+"/item", `{"name":"tent","quantity":2}`))
+	expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.QuantityInvalid).Assert(t, do(http.MethodPost, "/list/"+strconv.Itoa(listID)+"/item", `{"name":"Stove"}`))
+	expect.Status(http.StatusNotFound).Assert(t, do(http.MethodPost, "/list/100/item", `{"name":"Stove","quantity":1}`))
+
+	itemPath := "/list/" + strconv.Itoa(listID) + "/item/" + strconv.Itoa(itemID)
+	expect.Status(http.StatusOK).JSONPath("results.quantity", 2).Assert(t, do(http.MethodPut, itemPath, `{"name":"Tent","quantity":2}`))
+	expect.Status(http.StatusOK).JSONPath("results.quantity", 2).Assert(t, do(http.MethodGet, itemPath, ""))
+	expect.Status(http.StatusOK).JSONPath("results.item_count", 1).Assert(t, do(http.MethodGet, "/list/"+strconv.Itoa(listID), ""))
+
+	expect.Status(http.StatusNoContent).Assert(t, do(http.MethodDelete, itemPath, ""))
+	expect.Status(http.StatusNotFound).Assert(t, do(http.MethodGet, itemPath, ""))
+
+	expect.Status(http.StatusOK).JSONPath("results.name", "Trip").Assert(t, do(http.MethodPut, "/list/"+strconv.Itoa(listID), `{"name":"Trip"}`))
+	expect.Status(http.StatusNoContent).Assert(t, do(http.MethodDelete, "/list/"+strconv.Itoa(listID), ""))
+	expect.Status(http.StatusNotFound).Assert(t, do(http.MethodGet, "/list/"+strconv.Itoa(listID), ""))
+	expect.Status(http.StatusNotFound).Assert(t, do(http.MethodGet, "/list/abc", ""))
+
+	// Documentation is served, routes that need the database aren't.
+	expect.Status(http.StatusOK).Header("Content-Type", "application/json").Assert(t, do(http.MethodGet, "/openapi.json", ""))
+	expect.Status(http.StatusNotImplemented).JSONPath("errors.0.code", codes.MockUnsupported).Assert(t, do(http.MethodPost, "/export", ""))
+	expect.Status(http.StatusNotFound).Assert(t, do(http.MethodGet, "/nope", ""))
+}
+
+func TestMockLatency(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+
+	mock, err := newMock(config.Default(), logger, 50*time.Millisecond, 0)
+	if err != nil {
+		t.Fatalf("error creating mock: %v", err)
+	}
+
+	start := time.Now()
+
+	w := httptest.NewRecorder()
+	mock.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/list", nil))
+	expect.Status(http.StatusOK).Assert(t, w)
+
+	if took := time.Since(start); took < 50*time.Millisecond {
+		t.Errorf("expected response to be delayed by at least 50ms, took %v", took)
+	}
+}
@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Add stores an event in the outbox. Called with the transaction that makes the change
+// the event is about, the event is stored if and only if the change is. listID is the
+// list the event is about, or 0 if there is none.
+func Add(dbc db.Executor, e events.Event, listID int) error {
+	lid := sql.NullInt64{
+		Int64: int64(listID),
+		Valid: listID != 0,
+	}
+
+	if _, err := dbc.Exec(insert, e.ID, e.Type, e.Version, lid, []byte(e.Data), e.Time); err != nil {
+		return errors.Wrap(err, "insert outbox row")
+	}
+
+	return nil
+}
+
+// row is the postgres representation of an event.
+type row struct {
+	ID      string    `db:"event_id"`
+	Type    string    `db:"type"`
+	Version int       `db:"version"`
+	Data    []byte    `db:"data"`
+	Created time.Time `db:"created"`
+}
+
+// event returns the event stored in r.
+func (r row) event() events.Event {
+	return events.Event{
+		ID:      r.ID,
+		Type:    r.Type,
+		Version: r.Version,
+		Time:    r.Created.UTC(),
+		Data:    r.Data,
+	}
+}
+
+// Relay publishes up to limit pending events in the order they were added and marks
+// them as published, returning how many were published. Delivery is at-least-once: an
+// event is published again if marking it fails, consumers deduplicate by event ID.
+func Relay(ctx context.Context, dbc *sqlx.DB, pub events.Publisher, limit int) (int, error) {
+	tx, err := dbc.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	var rows []row
+	if err := tx.Select(&rows, selectPending, limit); err != nil {
+		return 0, errors.Wrap(err, "select pending outbox rows")
+	}
+
+	var published int
+	for _, r := range rows {
+		e := r.event()
+
+		if err := pub.Publish(ctx, e); err != nil {
+
+			// Events are published in order, so the rest waits for the next relay.
+			err = errors.Wrapf(err, "publish event %s", e.ID)
+			if published > 0 {
+				if cerr := tx.Commit(); cerr != nil {
+					return 0, errors.Wrap(cerr, "commit transaction")
+				}
+			}
+
+			return published, err
+		}
+
+		if _, err := tx.Exec(markPublished, time.Now(), r.ID); err != nil {
+			return published, errors.Wrap(err, "mark outbox row as published")
+		}
+
+		published++
+	}
+
+	return published, errors.Wrap(tx.Commit(), "commit transaction")
+}
+
+// SelectByList selects up to limit of the most recent events about the list with the
+// given id, newest first. Only events that haven't been purged yet are returned.
+func SelectByList(dbc db.Executor, listID, limit int) ([]events.Event, error) {
+	var rows []row
+	if err := dbc.Select(&rows, selectByList, listID, limit); err != nil {
+		return nil, errors.Wrap(err, "select outbox rows by list id")
+	}
+
+	evts := make([]events.Event, 0, len(rows))
+	for _, r := range rows {
+		evts = append(evts, r.event())
+	}
+
+	return evts, nil
+}
+
+// SelectListHistory selects every event about the list with the given id, oldest first,
+// from which the list can be reconstructed as it was at any time. Only events that
+// haven't been purged yet are returned.
+func SelectListHistory(dbc db.Executor, listID int) ([]events.Event, error) {
+	var rows []row
+	if err := dbc.Select(&rows, selectListHistory, listID); err != nil {
+		return nil, errors.Wrap(err, "select outbox rows of list history")
+	}
+
+	evts := make([]events.Event, 0, len(rows))
+	for _, r := range rows {
+		evts = append(evts, r.event())
+	}
+
+	return evts, nil
+}
+
+// Purge deletes up to limit events that were published before the given time, the
+// oldest first. Purging in batches keeps each delete from locking the outbox for long,
+// callers purge again until fewer than limit events are deleted.
+func Purge(dbc db.Executor, before time.Time, limit int) (int64, error) {
+	res, err := dbc.Exec(purgePublished, before, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete published outbox rows")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get purged row count")
+	}
+
+	return n, nil
+}
@@ -0,0 +1,33 @@
+package outbox
+
+// PostgreSQL queries for the outbox table, all used in the outbox package.
+const (
+	// insert is a query that inserts a new row in the outbox table using the values
+	// given in order for event_id, type, version, list_id, data, and created.
+	insert = "INSERT INTO outbox (event_id, type, version, list_id, data, created) VALUES ($1, $2, $3, $4, $5, $6);"
+
+	// selectPending is a query that selects and locks the oldest unpublished rows of
+	// the outbox table, up to the given limit. Rows locked by another relay are skipped
+	// so multiple replicas can relay concurrently.
+	selectPending = `SELECT event_id, type, version, data, created FROM outbox
+		WHERE published IS NULL ORDER BY created LIMIT $1 FOR UPDATE SKIP LOCKED;`
+
+	// selectByList is a query that selects the most recent rows of the outbox table
+	// about the given list, published or not, up to the given limit.
+	selectByList = `SELECT event_id, type, version, data, created FROM outbox
+		WHERE list_id = $1 ORDER BY created DESC LIMIT $2;`
+
+	// selectListHistory is a query that selects every row of the outbox table about the
+	// given list, published or not, oldest first.
+	selectListHistory = `SELECT event_id, type, version, data, created FROM outbox
+		WHERE list_id = $1 ORDER BY created;`
+
+	// markPublished is a query that marks a row in the outbox table as published.
+	markPublished = "UPDATE outbox SET published = $1 WHERE event_id = $2;"
+
+	// purgePublished is a query that deletes up to the given amount of rows in the outbox
+	// table that were published before the given time, the oldest first.
+	purgePublished = `DELETE FROM outbox WHERE event_id IN (
+	SELECT event_id FROM outbox WHERE published < $1 ORDER BY published LIMIT $2
+);`
+)
@@ -0,0 +1,16 @@
+package quota
+
+// PostgreSQL queries for the quota table, all used in the quota package.
+const (
+	// selectQuota is a query that selects the single row of the quota table.
+	selectQuota = "SELECT max_lists, max_items, default_max_lists, default_max_items, modified FROM quota;"
+
+	// updateDefaults is a query that updates the default limits of the single row of the
+	// quota table, which are default_max_lists and default_max_items.
+	updateDefaults = "UPDATE quota SET default_max_lists = $1, default_max_items = $2;"
+
+	// update is a query that updates the limits of the single row of the quota table and
+	// returns it. The values able to be updated are max_lists, max_items, and modified.
+	update = `UPDATE quota SET max_lists = $1, max_items = $2, modified = $3
+		RETURNING max_lists, max_items, default_max_lists, default_max_items, modified;`
+)
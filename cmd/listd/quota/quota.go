@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Quota is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of the limits of the lists and items a database, and so a tenant, can
+// hold. The limits are enforced by the database whenever a list or item is inserted, so
+// every write path, such as imports and templates, abides by them. A limit of 0 is
+// unlimited.
+type Quota struct {
+	// MaxLists and MaxItems are the limits of the tenant, set through the admin
+	// endpoints, nil when the tenant has the default limit.
+	MaxLists *int `json:"max_lists" db:"max_lists"`
+	MaxItems *int `json:"max_items_per_list" db:"max_items"`
+
+	// DefaultMaxLists and DefaultMaxItems are the configured limits of tenants without
+	// limits of their own, see SetDefaults.
+	DefaultMaxLists int `json:"default_max_lists" db:"default_max_lists"`
+	DefaultMaxItems int `json:"default_max_items_per_list" db:"default_max_items"`
+
+	Modified time.Time `json:"modified" db:"modified"`
+}
+
+// Lists returns the maximum amount of lists, 0 when unlimited.
+func (q Quota) Lists() int {
+	if q.MaxLists != nil {
+		return *q.MaxLists
+	}
+
+	return q.DefaultMaxLists
+}
+
+// Items returns the maximum amount of items per list, 0 when unlimited.
+func (q Quota) Items() int {
+	if q.MaxItems != nil {
+		return *q.MaxItems
+	}
+
+	return q.DefaultMaxItems
+}
+
+// SelectQuota selects the quota of the database.
+func SelectQuota(dbc db.Executor) (Quota, error) {
+	var q Quota
+
+	if err := db.Get(dbc, &q, selectQuota); err != nil {
+		return Quota{}, errors.Wrap(err, "select row from quota table")
+	}
+
+	return q, nil
+}
+
+// SetDefaults sets the limits of the lists and items of the database that apply unless
+// it has limits of its own. They are set from the configuration at startup.
+func SetDefaults(dbc db.Executor, maxLists, maxItems int) error {
+	if _, err := dbc.Exec(updateDefaults, maxLists, maxItems); err != nil {
+		return errors.Wrap(err, "update quota defaults")
+	}
+
+	return nil
+}
+
+// UpdateQuota sets the limits of the database to those of q, a nil limit goes back to
+// the default one. The quota is returned as stored.
+func UpdateQuota(dbc db.Executor, q Quota) (Quota, error) {
+	var stored Quota
+
+	if err := dbc.Get(&stored, update, q.MaxLists, q.MaxItems, time.Now()); err != nil {
+		return Quota{}, errors.Wrap(err, "update quota row")
+	}
+
+	return stored, nil
+}
+
+// Exceeded reports whether err, or its cause, comes from inserting a list or item beyond
+// the quota. The table the row was inserted into, list or item, is returned along with
+// the limit that was exceeded.
+func Exceeded(err error) (table string, limit int, ok bool) {
+	pgerr, ok := errors.Cause(err).(*pq.Error)
+	if !ok || string(pgerr.Code) != db.PSQLErrQuotaExceeded {
+		return "", 0, false
+	}
+
+	limit, _ = strconv.Atoi(pgerr.Detail)
+
+	return pgerr.Table, limit, true
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// seedData contains the demo lists, keyed by name, along with their items that are
+// inserted by the seed command.
+var seedData = []struct {
+	Name  string
+	Items []item.Item
+}{
+	{
+		Name: "Grocery",
+		Items: []item.Item{
+			{Name: "Chocolate Milk", Quantity: 1},
+			{Name: "Mac and Cheese", Quantity: 2},
+			{Name: "Eggs", Quantity: 12},
+		},
+	},
+	{
+		Name: "To-do",
+		Items: []item.Item{
+			{Name: "Write Integration Tests", Quantity: 1},
+			{Name: "Water Plants", Quantity: 3},
+		},
+	},
+	{
+		Name: "Hardware Store",
+		Items: []item.Item{
+			{Name: "Wood Screws", Quantity: 50},
+		},
+	},
+}
+
+// seed inserts demo lists and items into the database. Lists that already exist are
+// skipped, which makes seeding an existing database safe.
+func seed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+
+	cfg, logger, err := setup(fs, args)
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	if _, err := db.Migrate(dbc, logger); err != nil {
+		return errors.Wrap(err, "migrate database")
+	}
+
+	for _, sd := range seedData {
+		l, err := list.CreateList(dbc, list.List{Name: sd.Name})
+		if err != nil {
+			if errors.Cause(err) == list.ErrNameTaken {
+				logger.WithField("list", sd.Name).Info("list already exists, skipping")
+				continue
+			}
+
+			return errors.Wrapf(err, "create list %q", sd.Name)
+		}
+
+		for _, i := range sd.Items {
+			i.ListID = l.ID
+
+			if _, err := item.CreateItem(dbc, i); err != nil {
+				return errors.Wrapf(err, "create item %q in list %q", i.Name, sd.Name)
+			}
+		}
+
+		logger.WithFields(log.Fields{
+			"list":  l.Name,
+			"id":    l.ID,
+			"items": len(sd.Items),
+		}).Info("seeded list")
+	}
+
+	return nil
+}
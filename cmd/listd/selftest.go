@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// selftest runs a sanity check against the running daemon and logs whether it passed.
+// The HTTP server is probed through addr, and a list and item are created, read, and
+// deleted within a transaction that is always rolled back so no data is left behind.
+func selftest(dbc *sqlx.DB, addr string, logger log.FieldLogger) {
+	logger = logger.WithField("selftest", addr)
+
+	if err := probe(dbc, addr); err != nil {
+		logger.WithError(err).Error("selftest FAIL")
+		return
+	}
+
+	logger.Info("selftest PASS")
+}
+
+// probe runs the checks of selftest and returns the first failure.
+func probe(dbc *sqlx.DB, addr string) error {
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s/ready", addr))
+	if err != nil {
+		return errors.Wrap(err, "request readiness probe")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("readiness probe responded with %d", resp.StatusCode)
+	}
+
+	tx, err := dbc.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	// Nothing the probe does is ever meant to be kept.
+	defer tx.Rollback()
+
+	l, err := list.CreateList(tx, list.List{Name: fmt.Sprintf("selftest-%d", time.Now().UnixNano())})
+	if err != nil {
+		return errors.Wrap(err, "create list")
+	}
+
+	if _, err := list.SelectList(tx, l.ID); err != nil {
+		return errors.Wrap(err, "read created list")
+	}
+
+	i, err := item.CreateItem(tx, item.Item{ListID: l.ID, Name: "selftest", Quantity: 1})
+	if err != nil {
+		return errors.Wrap(err, "create item")
+	}
+
+	if _, err := item.SelectItem(tx, i.ID, l.ID); err != nil {
+		return errors.Wrap(err, "read created item")
+	}
+
+	if _, err := list.DeleteList(tx, l.ID); err != nil {
+		return errors.Wrap(err, "delete list")
+	}
+
+	return nil
+}
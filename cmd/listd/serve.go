@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/deadletter"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/quota"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/buildinfo"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/cache"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/dedup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/handoff"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/logging"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/notify"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/ratelimit"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/scheduler"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/storage"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/worker"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// serve starts the HTTP server, and the admin and public read servers if configured,
+// and blocks until the process is signaled to shut down. With -mock only the HTTP server
+// is started, serving from memory, see serveMock.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	skipMigrate := fs.Bool("skip-migrate", false, "don't apply pending database migrations at startup")
+	runSelftest := fs.Bool("selftest", false, "run a sanity check against the daemon once it is listening and log whether it passed")
+	mock := fs.Bool("mock", false, "serve the lists and items of the API from memory, starting out with the demo lists, without connecting to the database")
+	mockLatency := fs.Duration("mock-latency", 0, "time every response is delayed by in mock mode")
+	mockJitter := fs.Duration("mock-jitter", 0, "maximum time every response is delayed by at random in mock mode, on top of -mock-latency")
+
+	w, logger, err := watch(fs, args)
+	if err != nil {
+		return err
+	}
+
+	cfg := w.Config()
+
+	bi := buildinfo.Get()
+	logger.WithFields(log.Fields{
+		"version":   bi.Version,
+		"commit":    bi.Commit,
+		"buildDate": bi.BuildDate,
+		"goVersion": bi.GoVersion,
+	}).Info("starting list daemon")
+
+	if *mock {
+		return serveMock(cfg, logger, *mockLatency, *mockJitter)
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	// A read-only daemon doesn't write to its database at all, which may be a replica or
+	// be migrated by another process.
+	if cfg.ReadOnly != "" {
+		*skipMigrate = true
+		logger.WithField("readOnly", cfg.ReadOnly).Warn("serving read-only, write requests are refused")
+	}
+
+	if !*skipMigrate {
+		if _, err := db.Migrate(dbc, logger); err != nil {
+			return errors.Wrap(err, "migrate database")
+		}
+	}
+
+	if cfg.ReadOnly == "" {
+		if err := applyIDStrategy(cfg, dbc, logger); err != nil {
+			return err
+		}
+	}
+
+	feats, err := features.New(cfg.Features)
+	if err != nil {
+		return errors.Wrap(err, "configure feature flags")
+	}
+
+	pub, err := events.Open(cfg.EventsDriver, cfg.EventsURL, logger)
+	if err != nil {
+		return errors.Wrap(err, "open events publisher")
+	}
+
+	defer func() {
+		if err := pub.Close(); err != nil {
+			logger.WithError(err).Error("close events publisher")
+		}
+	}()
+
+	// Dead letters are kept in the default schema even in multi-tenant mode, since events
+	// of every tenant are posted by the same webhook.
+	var hook *notify.Webhook
+	if cfg.NotifyURL != "" {
+		hook, err = notify.NewWebhook(notify.Options{
+			URL:         cfg.NotifyURL,
+			Format:      cfg.NotifyFormat,
+			Types:       cfg.NotifyEvents,
+			Template:    cfg.NotifyTemplate,
+			Version:     cfg.NotifyVersion,
+			PerMinute:   cfg.NotifyPerMinute,
+			Secret:      cfg.NotifySecret,
+			MaxAttempts: cfg.NotifyMaxAttempts,
+			DeadLetters: deadletter.Store{DB: dbc},
+		}, logger)
+		if err != nil {
+			return errors.Wrap(err, "configure notifications")
+		}
+
+		// Notifications come after the broker so an event that is published again after
+		// the broker failed isn't posted twice.
+		pub = events.Tee(pub, hook)
+	}
+
+	// Counting comes last so only events every other publisher accepted are counted.
+	pub = events.Tee(pub, eventCounter{})
+
+	app, err := newApplication(cfg, dbc, logger, feats)
+	if err != nil {
+		return err
+	}
+	app.Webhook = hook
+
+	sched := scheduler.New(logger)
+	for _, j := range jobs(cfg, dbc, pub, logger) {
+		sched.Add(j)
+	}
+
+	// In multi-tenant mode every tenant is served by an application and jobs of its own.
+	// The application of the default schema serves the requests that don't name a tenant.
+	apps := []*handlers.Application{app}
+	var handler, adminHandler, publicReadHandler http.Handler = app, app.Admin(), app.PublicRead()
+
+	if cfg.TenantDomain != "" {
+		tenants, err := serveTenants(cfg, dbc, *skipMigrate, logger, feats)
+		if err != nil {
+			return err
+		}
+		defer closeTenants(tenants, logger)
+
+		public := handlers.Tenants{Domain: cfg.TenantDomain, Handlers: make(map[string]http.Handler, len(tenants)), Default: handler}
+		private := handlers.Tenants{Domain: cfg.TenantDomain, Handlers: make(map[string]http.Handler, len(tenants)), Default: adminHandler}
+		publicRead := handlers.Tenants{Domain: cfg.TenantDomain, Handlers: make(map[string]http.Handler, len(tenants)), Default: publicReadHandler}
+
+		for _, t := range tenants {
+			for _, j := range jobs(cfg, t.db, pub, logger.WithField("tenant", t.name)) {
+				j.Name = t.name + "." + j.Name
+				sched.Add(j)
+			}
+
+			public.Handlers[t.name] = t.app
+			private.Handlers[t.name] = t.app.Admin()
+			publicRead.Handlers[t.name] = t.app.PublicRead()
+			apps = append(apps, t.app)
+		}
+
+		handler, adminHandler, publicReadHandler = public, private, publicRead
+	}
+
+	sched.Start()
+
+	server := http.Server{
+		Addr:           fmt.Sprintf(":%d", cfg.DaemonPort),
+		Handler:        handler,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		MaxHeaderBytes: 1 << 20,
+	}
+
+	// Binding happens up front so the selftest only starts once requests can be served.
+	// A process started by SIGUSR2 takes over the sockets of the one that started it.
+	ln, err := handoff.Listen("daemon", server.Addr)
+	if err != nil {
+		return err
+	}
+	listeners := map[string]net.Listener{"daemon": ln}
+	conns := []*handoff.Conns{handoff.Track(&server)}
+
+	// Start listening for requests made to the daemon and create a channel
+	// to collect non-HTTP related server errors on.
+	serverErrors := make(chan error, 3)
+	go func() {
+		logger.WithField("addr", server.Addr).Info("server started")
+		serverErrors <- server.Serve(ln)
+	}()
+
+	if *runSelftest {
+		go selftest(dbc, fmt.Sprintf("localhost:%d", cfg.DaemonPort), logger)
+	}
+
+	// The admin server is off by default. It doesn't get the write timeout of the main
+	// server since CPU profiles and traces are streamed for as long as requested.
+	var admin *http.Server
+	if cfg.AdminPort != 0 {
+		admin = &http.Server{
+			Addr:           fmt.Sprintf(":%d", cfg.AdminPort),
+			Handler:        adminHandler,
+			ReadTimeout:    cfg.ReadTimeout,
+			MaxHeaderBytes: 1 << 20,
+		}
+
+		adminLn, err := handoff.Listen("admin", admin.Addr)
+		if err != nil {
+			return err
+		}
+		listeners["admin"] = adminLn
+		conns = append(conns, handoff.Track(admin))
+
+		go func() {
+			logger.WithField("addr", admin.Addr).Info("admin server started")
+			serverErrors <- admin.Serve(adminLn)
+		}()
+	}
+
+	// The public read API is off by default. It is served apart from the rest of the API
+	// so it can be exposed, such as to browsers, without exposing anything else.
+	var publicRead *http.Server
+	if cfg.PublicReadPort != 0 {
+		publicRead = &http.Server{
+			Addr:           fmt.Sprintf(":%d", cfg.PublicReadPort),
+			Handler:        publicReadHandler,
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			MaxHeaderBytes: 1 << 20,
+		}
+
+		publicReadLn, err := handoff.Listen("public-read", publicRead.Addr)
+		if err != nil {
+			return err
+		}
+		listeners["public-read"] = publicReadLn
+		conns = append(conns, handoff.Track(publicRead))
+
+		go func() {
+			logger.WithField("addr", publicRead.Addr).Info("public read server started")
+			serverErrors <- publicRead.Serve(publicReadLn)
+		}()
+	}
+
+	if err := handoff.Ready(); err != nil {
+		return err
+	}
+
+	// Blocking main and waiting for shutdown of the daemon.
+	osSignals := make(chan os.Signal, 1)
+	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
+
+	// SIGHUP reloads the configuration instead of shutting down.
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	// SIGUSR2 starts a new process taking over the listening sockets, this one drains and
+	// shuts down once the new one is serving.
+	upgrades := make(chan os.Signal, 1)
+	signal.Notify(upgrades, syscall.SIGUSR2)
+	defer signal.Stop(upgrades)
+
+	var upgraded bool
+
+	// Waiting for an osSignal or a non-HTTP related server error.
+wait:
+	for {
+		select {
+		case e := <-serverErrors:
+			return errors.Wrap(e, "server failed to start")
+
+		case <-hangups:
+			reload(w, logger, feats, apps)
+
+		case <-upgrades:
+			proc, err := handoff.Upgrade(listeners, cfg.UpgradeTimeout)
+			if err != nil {
+				logger.WithError(err).Error("upgrade, keeping on serving")
+				continue
+			}
+
+			logger.WithField("pid", proc.Pid).Info("handed off listeners to new process, draining")
+			upgraded = true
+			break wait
+
+		case <-osSignals:
+			break wait
+		}
+	}
+
+	// Gracefully shutdown server once an exit signal or error is received.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// After an upgrade the new process accepts every connection, those this one already
+	// accepted are waited for so shutting down doesn't drop them.
+	if upgraded {
+		for _, l := range listeners {
+			l.Close()
+		}
+		for range listeners {
+			<-serverErrors
+		}
+
+		for _, c := range conns {
+			if err := c.Wait(ctx); err != nil {
+				logger.WithError(err).Warn("accepted connections did not send a request in time")
+			}
+		}
+	}
+
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			logger.WithError(err).Warn("graceful shutdown of admin server did not complete")
+
+			if err := admin.Close(); err != nil {
+				logger.WithError(err).Error("kill admin server")
+			}
+		}
+	}
+
+	if publicRead != nil {
+		if err := publicRead.Shutdown(ctx); err != nil {
+			logger.WithError(err).Warn("graceful shutdown of public read server did not complete")
+
+			if err := publicRead.Close(); err != nil {
+				logger.WithError(err).Error("kill public read server")
+			}
+		}
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.WithError(err).WithField("timeout", cfg.ShutdownTimeout).Warn("graceful shutdown did not complete")
+
+		if err := server.Close(); err != nil {
+			logger.WithError(err).Error("kill server")
+		}
+	}
+
+	// Background imports and exports that don't finish in time are marked as interrupted.
+	for _, a := range apps {
+		if err := a.Workers.Close(ctx); err != nil {
+			logger.WithError(err).Warn("background imports and exports did not finish in time")
+		}
+	}
+
+	// Jobs are stopped last since they may still be using the database, which is
+	// closed once serve returns.
+	if err := sched.Stop(ctx); err != nil {
+		logger.WithError(err).Warn("background jobs did not stop in time")
+	}
+
+	return nil
+}
+
+// newApplication returns the Application serving the requests to dbc, configured by cfg.
+func newApplication(cfg config.Config, dbc *sqlx.DB, logger log.FieldLogger, feats *features.Service) (*handlers.Application, error) {
+	var err error
+
+	app := handlers.NewApplication(dbc, logger, feats)
+	app.Units = cfg.ItemUnits
+	app.Validation = validate.Mode(cfg.Validation)
+	app.Names = validate.Names{MaxLength: cfg.NameMaxLength, Lenient: app.Validation == validate.ModeLenient}
+	app.RewriteTrailingSlash = cfg.TrailingSlash == "rewrite"
+	app.MaxBodySize = int64(cfg.MaxBodySize)
+	app.RequestTimeout = cfg.RequestTimeout
+	app.MaxRequestTimeout = cfg.MaxRequestTimeout
+	app.PrettyJSON = cfg.PrettyJSON
+	app.Envelope = web.Envelope(cfg.Envelope)
+	app.StringIDs = cfg.StringIDs
+	app.CoalesceWindow = cfg.CoalesceWindow
+	app.RequestTx = cfg.RequestTx
+	app.LogRules = web.LogRules{
+		SampleRate: cfg.LogSampleRate,
+		Headers:    cfg.LogHeaders,
+		Body:       cfg.LogBodies,
+		Redact:     cfg.LogRedact,
+	}
+	app.SlowRequest = cfg.SlowRequestThreshold
+	app.RefuseWritesOnDrift = cfg.SchemaDrift == "refuse"
+
+	if len(cfg.SigningKeys) > 0 {
+		keys := make([]signing.Key, len(cfg.SigningKeys))
+		for i, k := range cfg.SigningKeys {
+			keys[i].ID, keys[i].Secret, _ = config.SplitKey(k)
+		}
+
+		app.Signatures = signing.NewVerifier(keys, cfg.SignatureWindow)
+		app.RequireSignatures = cfg.RequireSignatures
+	}
+
+	// Every application limits its clients on its own, in multi-tenant mode a client may
+	// make as many requests to every tenant. The limiters are created even when unlimited
+	// so a reload is able to limit them.
+	app.CORS = web.CORS{Origins: cfg.PublicReadOrigins, MaxAge: cfg.PublicReadMaxAge}
+	app.PublicReadLimit = ratelimit.New(cfg.PublicReadPerMinute, cfg.PublicReadBurst)
+	app.SharedLimit = ratelimit.New(cfg.SharePerMinute, cfg.ShareBurst)
+	app.ShareTTL = cfg.ShareTTL
+
+	if app.Proxies, err = web.ParseProxies(cfg.TrustedProxies); err != nil {
+		return nil, errors.Wrap(err, "configure trusted proxies")
+	}
+
+	if app.Faults, err = web.ParseFaults(cfg.Faults); err != nil {
+		return nil, errors.Wrap(err, "configure faults")
+	}
+
+	// Every tenant shares the storage, the keys of files are unique regardless of tenant.
+	app.Storage, err = storage.Open(storage.Options{
+		Driver: cfg.StorageDriver,
+		Path:   cfg.StoragePath,
+		Secret: cfg.StorageSecret,
+		S3: storage.S3Options{
+			Endpoint:       cfg.StorageS3Endpoint,
+			PublicEndpoint: cfg.StorageS3PublicEndpoint,
+			Region:         cfg.StorageS3Region,
+			Bucket:         cfg.StorageS3Bucket,
+			AccessKey:      cfg.StorageS3AccessKey,
+			SecretKey:      cfg.StorageS3SecretKey,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "open attachment storage")
+	}
+	app.AttachmentURLTTL = cfg.StorageURLTTL
+
+	app.ReadOnly = web.ReadOnly(cfg.ReadOnly)
+
+	if app.ReadOnly == "" {
+		if err := quota.SetDefaults(dbc, cfg.QuotaMaxLists, cfg.QuotaMaxItems); err != nil {
+			return nil, errors.Wrap(err, "configure quota")
+		}
+	}
+
+	if cfg.CacheSize > 0 {
+		app.Cache = cache.New(cfg.CacheSize, cfg.CacheTTL)
+	}
+	if cfg.DuplicateWindow > 0 {
+		app.Duplicates = dedup.New(cfg.DuplicateWindow)
+	}
+	if cfg.Workers > 0 {
+		app.Workers = worker.New(cfg.Workers, cfg.JobQueueSize)
+	}
+	app.ImportBatchSize = cfg.ImportBatchSize
+	app.Paging = web.Paging{DefaultSize: cfg.PageSize, MaxSize: cfg.MaxPageSize, MaxResults: cfg.MaxResults}
+
+	// Drift is logged by VerifySchema, the schema is verified again through the admin
+	// endpoint once it was fixed.
+	if _, err := app.VerifySchema(); err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// reload reloads the configuration and applies the settings that can change while the
+// daemon is running to it and every application. The running configuration is kept if
+// the new one is invalid.
+func reload(w *config.Watcher, logger *log.Logger, feats *features.Service, apps []*handlers.Application) {
+	cfg, restart, err := w.Reload()
+	if err != nil {
+		logger.WithError(err).Error("reload configuration, keeping the active configuration")
+		return
+	}
+
+	if err := logging.Configure(logger, cfg.LogLevel, cfg.LogFormat); err != nil {
+		logger.WithError(err).Error("reconfigure logger")
+	}
+
+	if err := feats.Apply(cfg.Features); err != nil {
+		logger.WithError(err).Error("reconfigure feature flags")
+	}
+
+	for _, app := range apps {
+		app.PublicReadLimit.SetLimit(cfg.PublicReadPerMinute, cfg.PublicReadBurst)
+		app.SharedLimit.SetLimit(cfg.SharePerMinute, cfg.ShareBurst)
+	}
+
+	if len(restart) > 0 {
+		logger.WithField("settings", restart).Warn("changed settings only take effect after a restart")
+	}
+
+	logger.Info("reloaded configuration")
+}
@@ -0,0 +1,38 @@
+package template
+
+// PostgreSQL queries for the template and template_item tables, all used in the template
+// package.
+const (
+	// selectAfter is a query that selects at most the given amount of rows from the
+	// template table whose template_id comes after the given one.
+	selectAfter = "SELECT * FROM template WHERE template_id > $1 ORDER BY template_id LIMIT $2;"
+
+	// selectPublicAfter is a query that selects at most the given amount of rows from the
+	// template table that are public and whose template_id comes after the given one.
+	selectPublicAfter = "SELECT * FROM template WHERE public AND template_id > $1 ORDER BY template_id LIMIT $2;"
+
+	// selectByID is a query that selects a row from the template table based off of
+	// the given template_id.
+	selectByID = "SELECT * FROM template WHERE template_id = $1;"
+
+	// selectItemsOf is a query that selects all rows from the template_item table
+	// related to any of the given template_ids.
+	selectItemsOf = "SELECT * FROM template_item WHERE template_id = ANY($1) ORDER BY template_item_id;"
+
+	// selectItems is a query that selects all rows from the template_item table
+	// filtered by template_id.
+	selectItems = "SELECT * FROM template_item WHERE template_id = $1 ORDER BY template_item_id;"
+
+	// insert is a query that inserts a new row in the template table using the values
+	// given in order for name and created.
+	insert = "INSERT INTO template (name, created) VALUES ($1, $2) RETURNING template_id;"
+
+	// updatePublic is a query that sets whether the row from the template table with the
+	// given template_id is public.
+	updatePublic = "UPDATE template SET public = $1 WHERE template_id = $2;"
+
+	// insertItems is a query that copies the rows of the item table related to a list
+	// by a given list_id into the template_item table, for the given template_id.
+	insertItems = `INSERT INTO template_item (template_id, name, quantity, unit)
+		SELECT $1, name, quantity, unit FROM item WHERE list_id = $2 ORDER BY item_id;`
+)
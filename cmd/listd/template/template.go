@@ -0,0 +1,135 @@
+package template
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Template is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of a list template, a named copy of the items of a list that new lists
+// can be created from.
+type Template struct {
+	ID      int       `json:"id" db:"template_id"`
+	Name    string    `json:"name" db:"name"`
+	Created time.Time `json:"created" db:"created"`
+	Items   []Item    `json:"items" db:"-"`
+
+	// Public reports whether the template is published on the public API, where anyone
+	// can read it without authentication.
+	Public bool `json:"public" db:"public"`
+}
+
+// Item is a type that contains the proper struct tags for both a JSON and Postgres
+// representation of an item of a template.
+type Item struct {
+	ID         int    `json:"-" db:"template_item_id"`
+	TemplateID int    `json:"-" db:"template_id"`
+	Name       string `json:"name" db:"name"`
+	Quantity   int    `json:"quantity" db:"quantity"`
+	Unit       string `json:"unit" db:"unit"`
+}
+
+// SelectTemplates selects up to limit rows from the template table whose template_id
+// comes after the given one, ordered by template_id, along with their items.
+func SelectTemplates(dbc db.Executor, after, limit int) ([]Template, error) {
+	return selectTemplates(dbc, selectAfter, after, limit)
+}
+
+// SelectPublicTemplates selects up to limit rows from the template table that are public
+// and whose template_id comes after the given one, ordered by template_id, along with
+// their items.
+func SelectPublicTemplates(dbc db.Executor, after, limit int) ([]Template, error) {
+	return selectTemplates(dbc, selectPublicAfter, after, limit)
+}
+
+// selectTemplates selects the rows from the template table returned by query, given the
+// template_id they come after and the most rows it returns, along with their items.
+func selectTemplates(dbc db.Executor, query string, after, limit int) ([]Template, error) {
+	templates := make([]Template, 0)
+	if err := db.Select(dbc, &templates, query, after, limit); err != nil {
+		return nil, errors.Wrap(err, "select rows from template table")
+	}
+
+	ids := make([]int, 0, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.ID)
+	}
+
+	var items []Item
+	if err := db.Select(dbc, &items, selectItemsOf, pq.Array(ids)); err != nil {
+		return nil, errors.Wrap(err, "select rows from template_item table")
+	}
+
+	byTemplate := make(map[int][]Item, len(templates))
+	for _, i := range items {
+		byTemplate[i.TemplateID] = append(byTemplate[i.TemplateID], i)
+	}
+
+	for idx := range templates {
+		templates[idx].Items = byTemplate[templates[idx].ID]
+		if templates[idx].Items == nil {
+			templates[idx].Items = make([]Item, 0)
+		}
+	}
+
+	return templates, nil
+}
+
+// SelectTemplate selects a single row from the template table based off of a given
+// template_id along with its items.
+func SelectTemplate(dbc db.Executor, id int) (Template, error) {
+	var t Template
+	if err := db.Get(dbc, &t, selectByID, id); err != nil {
+		return Template{}, errors.Wrap(err, "select singular row from template table")
+	}
+
+	t.Items = make([]Item, 0)
+	if err := db.Select(dbc, &t.Items, selectItems, id); err != nil {
+		return Template{}, errors.Wrap(err, "select template items")
+	}
+
+	return t, nil
+}
+
+// SetPublic publishes the template with the given template_id on the public API, or
+// withdraws it from it, and returns it. sql.ErrNoRows is returned if the template does not
+// exist.
+func SetPublic(dbc db.Executor, id int, public bool) (Template, error) {
+	res, err := dbc.Exec(updatePublic, public, id)
+	if err != nil {
+		return Template{}, errors.Wrap(err, "update public of template row")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return Template{}, errors.Wrap(err, "count updated template rows")
+	} else if n == 0 {
+		return Template{}, sql.ErrNoRows
+	}
+
+	return SelectTemplate(dbc, id)
+}
+
+// FromList inserts a new row into the template table with the given name, copying the
+// items of the list with the given list_id into it. sql.ErrNoRows is returned if the
+// list does not exist.
+func FromList(dbc db.Executor, listID int, name string) (Template, error) {
+	if _, err := list.SelectList(dbc, listID); errors.Cause(err) == sql.ErrNoRows {
+		return Template{}, sql.ErrNoRows
+	}
+
+	var id int
+	if err := dbc.QueryRowx(insert, name, time.Now()).Scan(&id); err != nil {
+		return Template{}, errors.Wrap(err, "insert new template row")
+	}
+
+	if _, err := dbc.Exec(insertItems, id, listID); err != nil {
+		return Template{}, errors.Wrap(err, "copy list items into template")
+	}
+
+	return SelectTemplate(dbc, id)
+}
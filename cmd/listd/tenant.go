@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// tenant creates tenants, lists them, or applies pending migrations to their schemas,
+// depending on its first argument. The tenants to migrate may be named, every tenant is
+// migrated when none are.
+func tenant(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected a subcommand (create, list, migrate)")
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("tenant "+sub, flag.ExitOnError)
+
+	cfg, logger, err := setup(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	dbc, err := connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	switch sub {
+	case "create":
+		if fs.NArg() == 0 {
+			return errors.New("expected the names of the tenants to create")
+		}
+
+		for _, name := range fs.Args() {
+			if err := db.CreateTenant(dbc, name); err != nil {
+				return errors.Wrapf(err, "create tenant %s", name)
+			}
+
+			if err := migrateTenant(cfg, name, logger); err != nil {
+				return err
+			}
+
+			logger.WithField("tenant", name).Info("created tenant")
+		}
+
+		return nil
+
+	case "list":
+		tenants, err := db.Tenants(dbc)
+		if err != nil {
+			return errors.Wrap(err, "get tenants")
+		}
+
+		for _, t := range tenants {
+			fmt.Println(t)
+		}
+
+		return nil
+
+	case "migrate":
+		names := fs.Args()
+		if len(names) == 0 {
+			if names, err = db.Tenants(dbc); err != nil {
+				return errors.Wrap(err, "get tenants")
+			}
+		}
+
+		for _, name := range names {
+			if err := migrateTenant(cfg, name, logger); err != nil {
+				return err
+			}
+		}
+
+		logger.WithField("tenants", len(names)).Info("tenants are up to date")
+		return nil
+	}
+
+	return errors.Errorf("unknown subcommand %q, expected create, list, or migrate", sub)
+}
+
+// connectTenant opens a connection to the schema of the named tenant of the database
+// described by the configuration.
+func connectTenant(cfg config.Config, name string, logger log.FieldLogger) (*sqlx.DB, error) {
+	schema, err := db.TenantSchema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dbc, err := db.NewConnection(db.Config{
+		User:   cfg.DBUser,
+		Pass:   cfg.DBPass,
+		Name:   cfg.DBName,
+		Host:   cfg.DBHost,
+		Port:   cfg.DBPort,
+		Schema: schema,
+	}, logger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect to schema %s", schema)
+	}
+
+	return dbc, nil
+}
+
+// migrateTenant applies every pending migration to the schema of the named tenant.
+func migrateTenant(cfg config.Config, name string, logger log.FieldLogger) error {
+	logger = logger.WithField("tenant", name)
+
+	dbc, err := connectTenant(cfg, name, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(dbc, logger)
+
+	if _, err := db.Migrate(dbc, logger); err != nil {
+		return errors.Wrapf(err, "migrate tenant %s", name)
+	}
+
+	return nil
+}
+
+// served is a tenant being served along with the connection to its schema.
+type served struct {
+	name string
+	db   *sqlx.DB
+	app  *handlers.Application
+}
+
+// serveTenants connects to the schema of every tenant of dbc, applying pending
+// migrations unless skipMigrate is true, and returns them along with an Application
+// each. The caller closes the connections. Tenants created later are only served after
+// a restart.
+func serveTenants(cfg config.Config, dbc *sqlx.DB, skipMigrate bool, logger log.FieldLogger, feats *features.Service) ([]served, error) {
+	names, err := db.Tenants(dbc)
+	if err != nil {
+		return nil, errors.Wrap(err, "get tenants")
+	}
+
+	tenants := make([]served, 0, len(names))
+	for _, name := range names {
+		tlog := logger.WithField("tenant", name)
+
+		tdb, err := connectTenant(cfg, name, tlog)
+		if err != nil {
+			closeTenants(tenants, logger)
+			return nil, err
+		}
+		tenants = append(tenants, served{name: name, db: tdb})
+
+		if !skipMigrate {
+			if _, err := db.Migrate(tdb, tlog); err != nil {
+				closeTenants(tenants, logger)
+				return nil, errors.Wrapf(err, "migrate tenant %s", name)
+			}
+		}
+
+		if cfg.ReadOnly == "" {
+			if err := applyIDStrategy(cfg, tdb, tlog); err != nil {
+				closeTenants(tenants, logger)
+				return nil, errors.Wrapf(err, "tenant %s", name)
+			}
+		}
+
+		app, err := newApplication(cfg, tdb, tlog, feats)
+		if err != nil {
+			closeTenants(tenants, logger)
+			return nil, err
+		}
+		tenants[len(tenants)-1].app = app
+	}
+
+	logger.WithField("tenants", len(tenants)).Info("serving tenants")
+
+	return tenants, nil
+}
+
+// closeTenants closes the connections of the given tenants, logging any error
+// encountered.
+func closeTenants(tenants []served, logger log.FieldLogger) {
+	for _, t := range tenants {
+		closeDB(t.db, logger.WithField("tenant", t.name))
+	}
+}
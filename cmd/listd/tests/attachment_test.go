@@ -0,0 +1,367 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/storage"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+func Test_attachments(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	local, err := storage.NewLocal(dir, "0123456789abcdef")
+	if err != nil {
+		t.Fatalf("error creating storage: %v", err)
+	}
+
+	feats, err := features.New(nil)
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	attached := handlers.NewApplication(a.DB, a.Log, feats)
+	attached.Storage = local
+
+	path := fmt.Sprintf("/list/%d/item/%d/attachment", items[0].ListID, items[0].ID)
+
+	// upload attaches the body to the item at path as a file with the given name.
+	upload := func(path, name, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, path+"/"+url.PathEscape(name), strings.NewReader(body))
+		r.Header.Set("Content-Type", "text/plain")
+
+		w := httptest.NewRecorder()
+		attached.ServeHTTP(w, r)
+
+		return w
+	}
+
+	// files returns the amount of files in the storage.
+	files := func(t *testing.T) int {
+		entries, err := ioutil.ReadDir(dir + "/attachments")
+		if err != nil {
+			t.Fatalf("error reading storage directory: %v", err)
+		}
+
+		return len(entries)
+	}
+
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, attached, http.MethodGet, path, "", ""))
+
+	if _, err := feats.Set(features.Attachments, true); err != nil {
+		t.Fatalf("error enabling attachments: %v", err)
+	}
+
+	var downloadURL string
+	expect.Status(http.StatusCreated).
+		HeaderSet("Location").
+		JSONPath("results.name", "receipt.txt").
+		JSONPath("results.contentType", "text/plain").
+		JSONPath("results.size", 11).
+		Assert(t, upload(path, " receipt.txt ", "total: 9.99"))
+
+	expect.Status(http.StatusOK).
+		JSONPath("results.size", 12).
+		Into("results.downloadURL", &downloadURL).
+		Assert(t, upload(path, "receipt.txt", "total: 12.50"))
+
+	expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.NameRequired).Assert(t, upload(path, " ", "total: 12.50"))
+	expect.Status(http.StatusNotFound).Assert(t, upload(fmt.Sprintf("/list/%d/item/0/attachment", items[0].ListID), "receipt.txt", "total: 12.50"))
+
+	if e, a := 1, files(t); e != a {
+		t.Errorf("expected %d stored files, got %d", e, a)
+	}
+
+	expect.Status(http.StatusOK).Len("results", 1).JSONPath("results.0.name", "receipt.txt").Assert(t, serve(t, attached, http.MethodGet, path, "", ""))
+
+	download, err := url.Parse(downloadURL)
+	if err != nil {
+		t.Fatalf("error parsing download url: %v", err)
+	}
+
+	tampered := *download
+	tampered.RawQuery = strings.Replace(tampered.RawQuery, "filename=receipt.txt", "filename=invoice.txt", 1)
+
+	tests := []struct {
+		Name     string
+		Path     string
+		Expected expect.Response
+		Body     string
+	}{
+		{
+			Name:     "Download",
+			Path:     download.RequestURI(),
+			Expected: expect.Status(http.StatusOK).Header("Content-Disposition", "attachment; filename=receipt.txt"),
+			Body:     "total: 12.50",
+		},
+		{
+			Name:     "TamperedDownload",
+			Path:     tampered.RequestURI(),
+			Expected: expect.Status(http.StatusForbidden).JSONPath("errors.0.code", codes.DownloadURLInvalid),
+		},
+		{
+			Name:     "Content",
+			Path:     path + "/receipt.txt/content",
+			Expected: expect.Status(http.StatusOK).Header("Content-Type", "text/plain"),
+			Body:     "total: 12.50",
+		},
+		{
+			Name:     "OtherItem",
+			Path:     fmt.Sprintf("/list/%d/item/%d/attachment/receipt.txt", items[1].ListID, items[1].ID),
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := serve(t, attached, http.MethodGet, test.Path, "", "")
+			test.Expected.Assert(t, w)
+
+			if test.Body != "" {
+				if e, a := test.Body, w.Body.String(); e != a {
+					t.Errorf("expected body: %v, got body: %v", e, a)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, attached, http.MethodDelete, path+"/receipt.txt", "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, attached, http.MethodGet, path+"/receipt.txt", "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, attached, http.MethodGet, download.RequestURI(), "", ""))
+
+	if e, a := 0, files(t); e != a {
+		t.Errorf("expected %d stored files, got %d", e, a)
+	}
+}
+
+func Test_attachmentsRequestTx(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	local, err := storage.NewLocal(dir, "0123456789abcdef")
+	if err != nil {
+		t.Fatalf("error creating storage: %v", err)
+	}
+
+	feats, err := features.New(nil)
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	if _, err := feats.Set(features.Attachments, true); err != nil {
+		t.Fatalf("error enabling attachments: %v", err)
+	}
+
+	transactional := handlers.NewApplication(a.DB, a.Log, feats)
+	transactional.Storage = local
+	transactional.RequestTx = true
+
+	path := fmt.Sprintf("/list/%d/item/%d/attachment/receipt.txt", items[0].ListID, items[0].ID)
+
+	// upload attaches the body to the item as receipt.txt.
+	upload := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "text/plain")
+
+		w := httptest.NewRecorder()
+		transactional.ServeHTTP(w, r)
+
+		return w
+	}
+
+	// content asserts the content of the attachment, which reads its file.
+	content := func(t *testing.T, e string) {
+		w := serve(t, transactional, http.MethodGet, path+"/content", "", "")
+		if expect.Status(http.StatusOK).Assert(t, w); w.Body.String() != e {
+			t.Errorf("expected content: %v, got content: %v", e, w.Body.String())
+		}
+	}
+
+	expect.Status(http.StatusCreated).Assert(t, upload("total: 9.99"))
+
+	// A deferred constraint trigger makes the commit of the request transaction fail
+	// once the handler already responded.
+	if _, err := a.DB.Exec(`CREATE FUNCTION refuse_commit() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'commit refused';
+END;
+$$ LANGUAGE plpgsql;
+CREATE CONSTRAINT TRIGGER refuse_commit AFTER UPDATE OR DELETE ON attachment
+	DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE PROCEDURE refuse_commit();`); err != nil {
+		t.Fatalf("error creating trigger: %v", err)
+	}
+
+	defer func() {
+		if _, err := a.DB.Exec("DROP TRIGGER IF EXISTS refuse_commit ON attachment; DROP FUNCTION IF EXISTS refuse_commit();"); err != nil {
+			t.Errorf("error dropping trigger: %v", err)
+		}
+	}()
+
+	// The file of the attachment is kept as long as its row is.
+	expect.Status(http.StatusInternalServerError).Assert(t, upload("total: 12.50"))
+	content(t, "total: 9.99")
+
+	expect.Status(http.StatusInternalServerError).Assert(t, serve(t, transactional, http.MethodDelete, path, "", ""))
+	content(t, "total: 9.99")
+
+	if _, err := a.DB.Exec("DROP TRIGGER refuse_commit ON attachment; DROP FUNCTION refuse_commit();"); err != nil {
+		t.Fatalf("error dropping trigger: %v", err)
+	}
+
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, transactional, http.MethodDelete, path, "", ""))
+
+	// Only the file stored for the replacement that was rolled back is left behind.
+	entries, err := ioutil.ReadDir(dir + "/attachments")
+	if err != nil {
+		t.Fatalf("error reading storage directory: %v", err)
+	}
+
+	if e, a := 1, len(entries); e != a {
+		t.Errorf("expected %d stored files, got %d", e, a)
+	}
+}
+
+func Test_attachmentsOfDeleted(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	local, err := storage.NewLocal(dir, "0123456789abcdef")
+	if err != nil {
+		t.Fatalf("error creating storage: %v", err)
+	}
+
+	feats, err := features.New(nil)
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	if _, err := feats.Set(features.Attachments, true); err != nil {
+		t.Fatalf("error enabling attachments: %v", err)
+	}
+
+	attached := handlers.NewApplication(a.DB, a.Log, feats)
+	attached.Storage = local
+
+	// Items 0 and 1 are on list 0, item 2 on list 1.
+	for _, i := range items[:3] {
+		r := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/list/%d/item/%d/attachment/receipt.txt", i.ListID, i.ID), strings.NewReader("total: 9.99"))
+		r.Header.Set("Content-Type", "text/plain")
+
+		w := httptest.NewRecorder()
+		attached.ServeHTTP(w, r)
+		expect.Status(http.StatusCreated).Assert(t, w)
+	}
+
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		Status int
+		Files  int
+	}{
+		{
+			Name:   "DeleteItem",
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("/list/%d/item/%d", items[0].ListID, items[0].ID),
+			Status: http.StatusNoContent,
+			Files:  2,
+		},
+		{
+			Name:   "DeleteList",
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("/list/%d", lists[0].ID),
+			Status: http.StatusNoContent,
+			Files:  1,
+		},
+		{
+			Name:   "DeleteLists",
+			Method: http.MethodDelete,
+			Path:   "/list",
+			Body:   fmt.Sprintf("[%d]", lists[1].ID),
+			Status: http.StatusOK,
+			Files:  0,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			expect.Status(test.Status).Assert(t, serve(t, attached, test.Method, test.Path, test.Body, ""))
+
+			entries, err := ioutil.ReadDir(dir + "/attachments")
+			if err != nil {
+				t.Fatalf("error reading storage directory: %v", err)
+			}
+
+			if e, a := test.Files, len(entries); e != a {
+				t.Errorf("expected %d stored files, got %d", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/backup"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_backupRestore(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	ctx := context.Background()
+
+	before, err := exporter.Export(ctx, a.DB, nil)
+	if err != nil {
+		t.Fatalf("error exporting seeded database: %v", err)
+	}
+
+	var b bytes.Buffer
+	m, err := backup.Write(ctx, a.DB, &b)
+	if err != nil {
+		t.Fatalf("error backing up database: %v", err)
+	}
+
+	if e, a := len(lists), m.Rows["list"]; e != a {
+		t.Errorf("expected %d lists to be backed up, got %d", e, a)
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Fatalf("error wiping database: %v", err)
+	}
+
+	// A corrupt backup is rejected without touching the database.
+	corrupt := append([]byte(nil), b.Bytes()...)
+	corrupt[len(corrupt)/2] ^= 0xff
+
+	if _, err := backup.Restore(ctx, a.DB, bytes.NewReader(corrupt)); err == nil {
+		t.Error("expected an error restoring a corrupt backup")
+	}
+
+	if _, err := backup.Restore(ctx, a.DB, &b); err != nil {
+		t.Fatalf("error restoring database: %v", err)
+	}
+
+	after, err := exporter.Export(ctx, a.DB, nil)
+	if err != nil {
+		t.Fatalf("error exporting restored database: %v", err)
+	}
+
+	after.Exported = before.Exported
+	if d := cmp.Diff(before, after); d != "" {
+		t.Errorf("expected the restored database to equal the seeded one, diff (-seeded +restored):\n%s", d)
+	}
+
+	// Sequences are restored along with the rows, so new lists get new identifiers.
+	var id int
+	expect.Status(http.StatusCreated).Into("results.id", &id).Assert(t, serve(t, a, http.MethodPost, "/list", `{"name":"After restore"}`, ""))
+
+	for _, l := range lists {
+		if id <= l.ID {
+			t.Errorf("expected the new list to get an identifier after %d, got %d", l.ID, id)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_browseTable(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	tests := []struct {
+		Name          string
+		Path          string
+		ExpectedCode  int
+		ExpectedNames []string
+		ExpectedTotal int
+	}{
+		{
+			Name:          "Lists",
+			Path:          "/admin/browse/lists?limit=2",
+			ExpectedCode:  http.StatusOK,
+			ExpectedNames: []string{lists[0].Name, lists[1].Name},
+			ExpectedTotal: len(lists),
+		},
+		{
+			Name:          "FilteredItems",
+			Path:          fmt.Sprintf("/admin/browse/items?list_id=%d", lists[0].ID),
+			ExpectedCode:  http.StatusOK,
+			ExpectedNames: []string{items[0].Name, items[1].Name},
+			ExpectedTotal: 2,
+		},
+		{
+			Name:          "NoMatch",
+			Path:          "/admin/browse/items?name=Bread",
+			ExpectedCode:  http.StatusOK,
+			ExpectedNames: []string{},
+		},
+		{
+			Name:         "UnknownTable",
+			Path:         "/admin/browse/outbox",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:         "UnknownFilter",
+			Path:         "/admin/browse/lists?created=2020-01-01",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "InvalidLimit",
+			Path:         "/admin/browse/lists?limit=0",
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := serve(t, a.Admin(), http.MethodGet, test.Path, "", "")
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if test.ExpectedCode != http.StatusOK {
+				return
+			}
+
+			var rows []map[string]interface{}
+			resp := web.Response{
+				Results: &rows,
+			}
+
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			names := make([]string, 0)
+			for _, row := range rows {
+				names = append(names, fmt.Sprint(row["name"]))
+			}
+
+			if d := cmp.Diff(test.ExpectedNames, names); d != "" {
+				t.Errorf("unexpected difference in names:\n%v", d)
+			}
+
+			if e, a := test.ExpectedTotal, resp.Page.Total; e != a {
+				t.Errorf("expected a total of %d, got %d", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
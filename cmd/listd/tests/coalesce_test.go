@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+)
+
+func Test_coalescedItems(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	coalescing := handlers.NewApplication(a.DB, a.Log, a.Features)
+	coalescing.CoalesceWindow = 20 * time.Millisecond
+
+	names := []string{"Milk", "milk", "Bread", "Eggs", "Milk"}
+	paths := make([]string, len(names))
+	for i := range names {
+		paths[i] = fmt.Sprintf("/list/%d/item", lists[0].ID)
+	}
+	names = append(names, "Milk")
+	paths = append(paths, "/list/0/item")
+
+	codes := make([]int, len(names))
+
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			body := fmt.Sprintf(`{"name":%q,"quantity":1}`, names[i])
+			codes[i] = serve(t, coalescing, http.MethodPost, paths[i], body, "").Code
+		}(i)
+	}
+	wg.Wait()
+
+	created := make(map[int]int)
+	for _, code := range codes {
+		created[code]++
+	}
+
+	// One of the milks is created, the other two conflict, and the missing list isn't
+	// found.
+	if created[http.StatusCreated] != 3 || created[http.StatusConflict] != 2 || created[http.StatusNotFound] != 1 {
+		t.Errorf("expected 3 items created, 2 conflicts and 1 not found, got status codes %v", codes)
+	}
+
+	items, err := item.SelectItems(a.DB, lists[0].ID)
+	if err != nil {
+		t.Fatalf("error selecting items: %v", err)
+	}
+
+	if e, a := 3, len(items); e != a {
+		t.Errorf("expected %d items in the list, got %d", e, a)
+	}
+}
+
+// BenchmarkCreateItem posts items to a single list from many clients at once, inserting
+// them on their own and coalesced.
+func BenchmarkCreateItem(b *testing.B) {
+	for _, window := range []time.Duration{0, 5 * time.Millisecond} {
+		fn := func(b *testing.B) {
+			defer func() {
+				if err := testdb.Truncate(a.DB); err != nil {
+					b.Errorf("error truncating test database tables: %v", err)
+				}
+			}()
+
+			lists, err := testdb.SeedLists(a.DB)
+			if err != nil {
+				b.Fatalf("error seeding lists: %v", err)
+			}
+
+			app := handlers.NewApplication(a.DB, a.Log, a.Features)
+			app.CoalesceWindow = window
+
+			path := fmt.Sprintf("/list/%d/item", lists[0].ID)
+
+			var n int64
+			b.SetParallelism(16)
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					body := fmt.Sprintf(`{"name":"Item %d","quantity":1}`, atomic.AddInt64(&n, 1))
+
+					if w := serve(b, app, http.MethodPost, path, body, ""); w.Code != http.StatusCreated {
+						b.Errorf("expected status code: %v, got status code: %v: %s", http.StatusCreated, w.Code, w.Body)
+					}
+				}
+			})
+		}
+
+		name := "Single"
+		if window > 0 {
+			name = "Coalesced"
+		}
+
+		b.Run(name, fn)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/deadletter"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/notify"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/pborman/uuid"
+)
+
+func Test_deadLetters(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	// The webhook rejects every message mentioning Bread.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "Bread") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	store := deadletter.Store{DB: a.DB}
+	for _, text := range []string{"Groceries was created", "Bread was created"} {
+		if err := store.Add(notify.Delivery{
+			EventID:   uuid.New(),
+			EventType: "list.created",
+			Payload:   []byte(fmt.Sprintf(`{"text":%q}`, text)),
+			Attempts:  5,
+			Error:     "unexpected response status 500 Internal Server Error",
+		}); err != nil {
+			t.Fatalf("error adding dead letter: %v", err)
+		}
+	}
+
+	w := serve(t, a.Admin(), http.MethodGet, "/admin/webhooks/dead-letters", "", "")
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	var letters []deadletter.DeadLetter
+	resp := web.Response{
+		Results: &letters,
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response body: %v", err)
+	}
+
+	if e, a := 2, resp.Page.Total; e != a {
+		t.Fatalf("expected a total of %d, got %d", e, a)
+	}
+	rejected, accepted := letters[0], letters[1]
+
+	t.Run("Disabled", func(t *testing.T) {
+		w := serve(t, a.Admin(), http.MethodPost, fmt.Sprintf("/admin/webhooks/dead-letters/%d/retry", accepted.ID), "", "")
+		if e, a := http.StatusConflict, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+	})
+
+	hook, err := notify.NewWebhook(notify.Options{URL: srv.URL, Format: notify.FormatSlack, PerMinute: 60}, a.Log)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+	defer hook.Close()
+
+	a.Webhook = hook
+	defer func() { a.Webhook = nil }()
+
+	t.Run("Rejected", func(t *testing.T) {
+		w := serve(t, a.Admin(), http.MethodPost, fmt.Sprintf("/admin/webhooks/dead-letters/%d/retry", rejected.ID), "", "")
+		if e, a := http.StatusBadGateway, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		var updated deadletter.DeadLetter
+		resp := web.Response{
+			Results: &updated,
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+
+		if e, a := rejected.Attempts+1, updated.Attempts; e != a {
+			t.Errorf("expected %d attempts, got %d", e, a)
+		}
+
+		if len(resp.Errors) != 1 || resp.Errors[0].Code != codes.RedeliveryFailed {
+			t.Errorf("expected a %s error, got %v", codes.RedeliveryFailed, resp.Errors)
+		}
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		w := serve(t, a.Admin(), http.MethodPost, fmt.Sprintf("/admin/webhooks/dead-letters/%d/retry", accepted.ID), "", "")
+		if e, a := http.StatusNoContent, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if _, total, err := deadletter.SelectPage(a.DB, 10, 0); err != nil {
+			t.Fatalf("error selecting dead letters: %v", err)
+		} else if e, a := 1, total; e != a {
+			t.Errorf("expected %d dead letters left, got %d", e, a)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		w := serve(t, a.Admin(), http.MethodPost, fmt.Sprintf("/admin/webhooks/dead-letters/%d/retry", accepted.ID), "", "")
+		if e, a := http.StatusNotFound, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+	})
+}
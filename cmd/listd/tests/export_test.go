@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/pkg/errors"
+)
+
+func Test_exportCanceled(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	t.Run("MidExport", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// The client goes away once the first list is exported.
+		var done int
+		_, err := exporter.Export(ctx, a.DB, func(d, total int) {
+			done = d
+			cancel()
+		})
+
+		if errors.Cause(err) != context.Canceled {
+			t.Fatalf("expected the export to stop with %v, got %v", context.Canceled, err)
+		}
+
+		if e, a := 1, done; e != a {
+			t.Errorf("expected the export to stop after %d list, it did %d", e, a)
+		}
+	})
+
+	t.Run("ClientGone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, err := http.NewRequest(http.MethodPost, "/export", nil)
+		if err != nil {
+			t.Fatalf("error creating request: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, req.WithContext(ctx))
+
+		if e, a := http.StatusServiceUnavailable, w.Code; e != a {
+			t.Errorf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if reqs := a.Requests.List(); len(reqs) != 0 {
+			t.Errorf("expected no request to be in flight, got %+v", reqs)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+)
+
+// terminateIdle terminates the server side of the idle connections of the pool, as a
+// restart or failover of the database does.
+func terminateIdle(t *testing.T) {
+	ctx := context.Background()
+
+	killer, err := a.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("error getting connection: %v", err)
+	}
+	defer killer.Close()
+
+	stmt := "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = current_database() AND pid <> pg_backend_pid();"
+	if _, err := killer.ExecContext(ctx, stmt); err != nil {
+		t.Fatalf("error terminating connections: %v", err)
+	}
+}
+
+func Test_failover(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		terminateIdle(t)
+
+		if w := serve(t, a, http.MethodGet, "/list", "", ""); w.Code != http.StatusOK {
+			t.Errorf("expected the read to be retried with status code: %v, got status code: %v: %s", http.StatusOK, w.Code, w.Body)
+		}
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		terminateIdle(t)
+
+		w := serve(t, a, http.MethodPut, fmt.Sprintf("/list/%d", lists[0].ID), `{"name":"Groceries"}`, "")
+
+		switch w.Code {
+		case http.StatusOK, http.StatusServiceUnavailable:
+		default:
+			t.Errorf("expected the write to succeed or fail with status code: %v, got status code: %v: %s", http.StatusServiceUnavailable, w.Code, w.Body)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+func Test_faultInjection(t *testing.T) {
+	defer checkDBConnections(t)
+
+	feats, err := features.New([]string{features.FaultInjection})
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	faulty := handlers.NewApplication(a.DB, a.Log, feats)
+	faulty.Faults = web.Faults{
+		{Method: http.MethodGet, Path: "/version", ErrorRate: 1},
+		{Method: "*", Path: "/ready", Latency: 50 * time.Millisecond},
+	}
+
+	tests := []struct {
+		Name         string
+		Path         string
+		Enabled      bool
+		ExpectedCode int
+		MinLatency   time.Duration
+	}{
+		{
+			Name:         "Error",
+			Path:         "/version",
+			Enabled:      true,
+			ExpectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			Name:         "Latency",
+			Path:         "/ready",
+			Enabled:      true,
+			ExpectedCode: http.StatusOK,
+			MinLatency:   50 * time.Millisecond,
+		},
+		{
+			Name:         "NoMatch",
+			Path:         "/healthy",
+			Enabled:      true,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Disabled",
+			Path:         "/version",
+			ExpectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if _, err := feats.Set(features.FaultInjection, test.Enabled); err != nil {
+				t.Fatalf("error setting feature flag: %v", err)
+			}
+
+			start := time.Now()
+			w := serve(t, faulty, http.MethodGet, test.Path, "", "")
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if d := time.Since(start); d < test.MinLatency {
+				t.Errorf("expected a latency of at least %v, got %v", test.MinLatency, d)
+			}
+
+			if test.ExpectedCode == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+				t.Error("expected a Retry-After header")
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_fixture(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	expected, err := list.SelectLists(a.DB)
+	if err != nil {
+		t.Fatalf("error selecting lists: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	testdb.Dump(t, a.DB, path)
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Fatalf("error truncating test database tables: %v", err)
+	}
+
+	testdb.Load(t, a.DB, path)
+
+	loaded, err := list.SelectLists(a.DB)
+	if err != nil {
+		t.Fatalf("error selecting lists: %v", err)
+	}
+
+	if d := cmp.Diff(expected, loaded); d != "" {
+		t.Errorf("unexpected difference in loaded lists:\n%v", d)
+	}
+
+	// Rows created after loading get identifiers after the loaded ones.
+	i, err := item.CreateItem(a.DB, item.Item{ListID: lists[2].ID, Name: "Hire", Quantity: 1})
+	if err != nil {
+		t.Fatalf("error creating item after loading: %v", err)
+	}
+
+	if l, err := list.SelectList(a.DB, lists[2].ID); err != nil || l.ItemCount != 1 {
+		t.Errorf("expected the list of item %d to count 1 item, got %+v, %v", i.ID, l, err)
+	}
+}
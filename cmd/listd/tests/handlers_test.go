@@ -0,0 +1,1367 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/job"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/maintenance"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/buildinfo"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/cache"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/logging"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/validate"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_requestLogging(t *testing.T) {
+	defer checkDBConnections(t)
+
+	var logs bytes.Buffer
+
+	l, err := logging.New(&logs, "info", logging.FormatJSON)
+	if err != nil {
+		t.Fatalf("error creating logger: %v", err)
+	}
+
+	// Using 0 for the list ID because postgres serial type starts at 1 so 0 will never exist.
+	req, err := http.NewRequest(http.MethodGet, "/list/0", nil)
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handlers.NewApplication(a.DB, l, a.Features).ServeHTTP(w, req)
+
+	requestID := w.Header().Get("X-Request-Id")
+	if requestID == "" {
+		t.Fatal("expected X-Request-Id header to be set")
+	}
+
+	entries := make(map[string]map[string]interface{})
+
+	dec := json.NewDecoder(&logs)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("error decoding log entry: %v", err)
+		}
+
+		entries[entry["msg"].(string)] = entry
+	}
+
+	tests := []struct {
+		Name          string
+		Message       string
+		ExpectedLevel string
+	}{
+		{
+			Name:          "Error",
+			Message:       "error while serving request",
+			ExpectedLevel: "error",
+		},
+		{
+			Name:          "CompletedRequest",
+			Message:       "completed request",
+			ExpectedLevel: "info",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			entry, ok := entries[test.Message]
+			if !ok {
+				t.Fatalf("expected log entry with message %q, got entries: %v", test.Message, entries)
+			}
+
+			if e, a := test.ExpectedLevel, entry["level"]; e != a {
+				t.Errorf("expected log level: %v, got log level: %v", e, a)
+			}
+
+			if e, a := requestID, entry["requestID"]; e != a {
+				t.Errorf("expected request id: %v, got request id: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	if e, a := float64(http.StatusNotFound), entries["completed request"]["status"]; e != a {
+		t.Errorf("expected logged status code: %v, got logged status code: %v", e, a)
+	}
+}
+
+func Test_admin(t *testing.T) {
+	defer checkDBConnections(t)
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Handler      http.Handler
+		ExpectedCode int
+	}{
+		{
+			Name:         "Pprof",
+			Method:       http.MethodGet,
+			Path:         "/debug/pprof/",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "PprofNamedProfile",
+			Method:       http.MethodGet,
+			Path:         "/debug/pprof/goroutine?debug=1",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Expvar",
+			Method:       http.MethodGet,
+			Path:         "/debug/vars",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "GC",
+			Method:       http.MethodPost,
+			Path:         "/debug/gc",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Metrics",
+			Method:       http.MethodGet,
+			Path:         "/metrics",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Requests",
+			Method:       http.MethodGet,
+			Path:         "/admin/requests",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "CancelUnknownRequest",
+			Method:       http.MethodDelete,
+			Path:         "/admin/requests/does-not-exist",
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:         "BrowseNotPublic",
+			Method:       http.MethodGet,
+			Path:         "/admin/browse/lists",
+			Handler:      a,
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:         "NotPublic",
+			Method:       http.MethodGet,
+			Path:         "/debug/vars",
+			Handler:      a,
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, nil)
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			test.Handler.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_routing(t *testing.T) {
+	defer checkDBConnections(t)
+
+	rewrite := handlers.NewApplication(a.DB, a.Log, a.Features)
+	rewrite.RewriteTrailingSlash = true
+
+	tests := []struct {
+		Name             string
+		Method           string
+		Path             string
+		Handler          http.Handler
+		ExpectedCode     int
+		ExpectedLocation string
+		ExpectedAllow    string
+	}{
+		{
+			Name:             "RedirectTrailingSlash",
+			Method:           http.MethodGet,
+			Path:             "/list/?limit=1",
+			Handler:          a,
+			ExpectedCode:     http.StatusMovedPermanently,
+			ExpectedLocation: "/list?limit=1",
+		},
+		{
+			Name:             "RedirectTrailingSlashKeepsMethod",
+			Method:           http.MethodPost,
+			Path:             "/list/",
+			Handler:          a,
+			ExpectedCode:     http.StatusPermanentRedirect,
+			ExpectedLocation: "/list",
+		},
+		{
+			Name:         "RewriteTrailingSlash",
+			Method:       http.MethodGet,
+			Path:         "/list/",
+			Handler:      rewrite,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:          "MethodNotAllowed",
+			Method:        http.MethodPut,
+			Path:          "/list",
+			Handler:       a,
+			ExpectedCode:  http.StatusMethodNotAllowed,
+			ExpectedAllow: "DELETE, GET, OPTIONS, POST",
+		},
+		{
+			Name:          "MethodNotAllowedWithParameter",
+			Method:        http.MethodPost,
+			Path:          "/list/1",
+			Handler:       a,
+			ExpectedCode:  http.StatusMethodNotAllowed,
+			ExpectedAllow: "DELETE, GET, OPTIONS, PUT",
+		},
+		{
+			Name:         "NotFound",
+			Method:       http.MethodGet,
+			Path:         "/lists",
+			Handler:      a,
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, nil)
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			test.Handler.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := test.ExpectedLocation, w.Header().Get("Location"); e != a {
+				t.Errorf("expected Location header: %q, got: %q", e, a)
+			}
+
+			if e, a := test.ExpectedAllow, w.Header().Get("Allow"); e != a {
+				t.Errorf("expected Allow header: %q, got: %q", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_routeTimeouts(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	// Every request runs out of time right away, unless its route declares otherwise.
+	impatient := handlers.NewApplication(a.DB, a.Log, a.Features)
+	impatient.RequestTimeout = time.Nanosecond
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Body         string
+		ExpectedCode int
+	}{
+		{
+			Name:         "RequestTimeout",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Late"}`,
+			ExpectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			Name:         "RouteWithoutTimeout",
+			Method:       http.MethodPost,
+			Path:         "/import/todoist",
+			Body:         `{"projects":[{"id":1,"name":"Garden"}],"items":[]}`,
+			ExpectedCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := serve(t, impatient, test.Method, test.Path, test.Body, "")
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v (%s)", e, a, w.Body)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	t.Run("ClientTimeout", func(t *testing.T) {
+		// Clients may shorten the timeout of a route, but only lengthen it up to the
+		// maximum of the server.
+		bounded := handlers.NewApplication(a.DB, a.Log, a.Features)
+		bounded.RequestTimeout = 5 * time.Second
+		bounded.MaxRequestTimeout = 10 * time.Second
+
+		timeouts := []struct {
+			App      *handlers.Application
+			Path     string
+			Timeout  string
+			Expected expect.Response
+		}{
+			{App: bounded, Path: "/list", Timeout: "1ns", Expected: expect.Status(http.StatusServiceUnavailable)},
+			{App: bounded, Path: "/list", Timeout: "1m", Expected: expect.Status(http.StatusOK)},
+			{App: impatient, Path: "/list", Timeout: "1m", Expected: expect.Status(http.StatusServiceUnavailable)},
+			{App: bounded, Path: "/import/todoist", Timeout: "1ns", Expected: expect.Status(http.StatusServiceUnavailable)},
+			{App: bounded, Path: "/list", Timeout: "soon", Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.RequestTimeoutInvalid)},
+		}
+
+		for _, test := range timeouts {
+			method, body := http.MethodGet, ""
+			if test.Path == "/import/todoist" {
+				method, body = http.MethodPost, `{"projects":[{"id":1,"name":"Garden"}],"items":[]}`
+			}
+
+			req := httptest.NewRequest(method, test.Path, strings.NewReader(body))
+			req.Header.Set(web.TimeoutHeader, test.Timeout)
+
+			w := httptest.NewRecorder()
+			test.App.ServeHTTP(w, req)
+
+			test.Expected.Assert(t, w)
+		}
+	})
+
+	t.Run("RouteMiddleware", func(t *testing.T) {
+		j, err := job.CreateJob(a.DB, "export")
+		if err != nil {
+			t.Fatalf("error creating job: %v", err)
+		}
+
+		w := serve(t, a, http.MethodGet, fmt.Sprintf("/job/%d", j.ID), "", "")
+		if e, a := "no-store", w.Header().Get("Cache-Control"); e != a {
+			t.Errorf("expected Cache-Control %q, got %q", e, a)
+		}
+
+		w = serve(t, a, http.MethodGet, "/list", "", "")
+		if a := w.Header().Get("Cache-Control"); a != "" {
+			t.Errorf("expected no Cache-Control outside of the job routes, got %q", a)
+		}
+	})
+}
+
+func Test_cache(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	if _, err := testdb.SeedLists(a.DB); err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	cached := handlers.NewApplication(a.DB, a.Log, a.Features)
+	cached.Cache = cache.New(10, time.Minute)
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedCode   int
+		ExpectedXCache string
+		ExpectedTotal  int
+	}{
+		{
+			Name:           "Miss",
+			Method:         http.MethodGet,
+			Path:           "/list",
+			ExpectedCode:   http.StatusOK,
+			ExpectedXCache: "MISS",
+			ExpectedTotal:  3,
+		},
+		{
+			Name:           "Hit",
+			Method:         http.MethodGet,
+			Path:           "/list",
+			ExpectedCode:   http.StatusOK,
+			ExpectedXCache: "HIT",
+			ExpectedTotal:  3,
+		},
+		{
+			Name:         "Write",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Cached"}`,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:           "PurgedByWrite",
+			Method:         http.MethodGet,
+			Path:           "/list",
+			ExpectedCode:   http.StatusOK,
+			ExpectedXCache: "MISS",
+			ExpectedTotal:  4,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			cached.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := test.ExpectedXCache, w.Header().Get("X-Cache"); e != a {
+				t.Errorf("expected X-Cache header: %q, got: %q", e, a)
+			}
+
+			if test.Method == http.MethodGet {
+				var resp web.Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("error decoding response body: %v", err)
+				}
+
+				if e, a := test.ExpectedTotal, resp.Page.Total; e != a {
+					t.Errorf("expected %d lists, got %d", e, a)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_getVersion(t *testing.T) {
+	defer checkDBConnections(t)
+
+	req, err := http.NewRequest(http.MethodGet, "/version", nil)
+	if err != nil {
+		t.Errorf("error creating request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Errorf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	var info buildinfo.Info
+	resp := web.Response{
+		Results: &info,
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Errorf("error decoding response body: %v", err)
+	}
+
+	if d := cmp.Diff(buildinfo.Get(), info); d != "" {
+		t.Errorf("unexpected difference in response body:\n%v", d)
+	}
+}
+
+func Test_getEnums(t *testing.T) {
+	defer checkDBConnections(t)
+
+	expect.Status(http.StatusOK).
+		Len("results", 2).
+		JSONPath("results.0.name", "itemUnit").
+		JSONPath("results.0.values", a.Units).
+		JSONPath("results.0.optional", true).
+		JSONPath("results.1.name", "settingsSort").
+		JSONPath("results.1.optional", false).
+		Assert(t, serve(t, a, http.MethodGet, "/meta/enums", "", ""))
+}
+
+func Test_features(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if _, err := a.Features.Set(features.StrictValidation, false); err != nil {
+			t.Errorf("error resetting feature flag: %v", err)
+		}
+	}()
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Body         string
+		Handler      http.Handler
+		ExpectedCode int
+	}{
+		{
+			Name:         "LenientByDefault",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Lenient","color":"blue"}`,
+			Handler:      a,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "ToggleUnknown",
+			Method:       http.MethodPut,
+			Path:         "/admin/features/does_not_exist",
+			Body:         `{"enabled":true}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:         "ToggleMissingEnabled",
+			Method:       http.MethodPut,
+			Path:         "/admin/features/" + features.StrictValidation,
+			Body:         `{}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "EnableStrictValidation",
+			Method:       http.MethodPut,
+			Path:         "/admin/features/" + features.StrictValidation,
+			Body:         `{"enabled":true}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "StrictRejectsUnknownField",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Strict","color":"blue"}`,
+			Handler:      a,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "StrictAcceptsKnownFields",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Strict"}`,
+			Handler:      a,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "NotPublic",
+			Method:       http.MethodGet,
+			Path:         "/admin/features",
+			Handler:      a,
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			test.Handler.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/admin/features", nil)
+	if err != nil {
+		t.Errorf("error creating request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	a.Admin().ServeHTTP(w, req)
+
+	var flags []features.Flag
+	resp := web.Response{
+		Results: &flags,
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Errorf("error decoding response body: %v", err)
+	}
+
+	if d := cmp.Diff(a.Features.List(), flags); d != "" {
+		t.Errorf("unexpected difference in response body:\n%v", d)
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+}
+
+func Test_validationModes(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	modes := make(map[validate.Mode]*handlers.Application)
+	for _, mode := range []validate.Mode{validate.ModeStrict, validate.ModeLenient} {
+		app := handlers.NewApplication(a.DB, a.Log, a.Features)
+		app.Validation = mode
+		app.Names = validate.Names{MaxLength: 10, Lenient: mode == validate.ModeLenient}
+
+		modes[mode] = app
+	}
+
+	tests := []struct {
+		Name     string
+		Mode     validate.Mode
+		Method   string
+		Path     string
+		Body     string
+		Expected expect.Response
+	}{
+		{
+			Name:     "StrictVersion",
+			Mode:     validate.ModeStrict,
+			Method:   http.MethodGet,
+			Path:     "/version",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.validation", "strict"),
+		},
+		{
+			Name:     "StrictUnknownField",
+			Mode:     validate.ModeStrict,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Strict","color":"blue"}`,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "StrictName",
+			Mode:     validate.ModeStrict,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Far\u200bToo Long"}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity).Errors(2),
+		},
+		{
+			Name:     "LenientVersion",
+			Mode:     validate.ModeLenient,
+			Method:   http.MethodGet,
+			Path:     "/version",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.validation", "lenient"),
+		},
+		{
+			Name:     "LenientUnknownField",
+			Mode:     validate.ModeLenient,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Lenient","color":"blue"}`,
+			Expected: expect.Status(http.StatusCreated),
+		},
+		{
+			Name:     "LenientName",
+			Mode:     validate.ModeLenient,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Far\u200bToo Long"}`,
+			Expected: expect.Status(http.StatusCreated).JSONPath("results.name", "Far\u200bToo Long"),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, modes[test.Mode], test.Method, test.Path, test.Body, ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_maintenance(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if _, err := maintenance.UpdateState(a.DB, maintenance.State{}); err != nil {
+			t.Errorf("error disabling maintenance mode: %v", err)
+		}
+	}()
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Body         string
+		Handler      http.Handler
+		ExpectedCode int
+	}{
+		{
+			Name:         "Enable",
+			Method:       http.MethodPut,
+			Path:         "/admin/maintenance",
+			Body:         `{"enabled":true,"message":"upgrading the database"}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "WriteRejected",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Maintenance"}`,
+			Handler:      a,
+			ExpectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			Name:         "ReadAllowed",
+			Method:       http.MethodGet,
+			Path:         "/list",
+			Handler:      a,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Disable",
+			Method:       http.MethodPut,
+			Path:         "/admin/maintenance",
+			Body:         `{"enabled":false}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "WriteAllowed",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Maintenance"}`,
+			Handler:      a,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "MissingEnabled",
+			Method:       http.MethodPut,
+			Path:         "/admin/maintenance",
+			Body:         `{}`,
+			Handler:      a.Admin(),
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			test.Handler.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+}
+
+func Test_readOnly(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	replica := handlers.NewApplication(a.DB, a.Log, a.Features)
+	replica.ReadOnly = web.ReadOnlyReplica
+
+	migrating := handlers.NewApplication(a.DB, a.Log, a.Features)
+	migrating.ReadOnly = web.ReadOnlyMigrating
+
+	tests := []struct {
+		Name     string
+		App      http.Handler
+		Method   string
+		Path     string
+		Body     string
+		Expected expect.Response
+	}{
+		{
+			Name:     "ReplicaRead",
+			App:      replica,
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/list/%d", lists[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results.name", lists[0].Name),
+		},
+		{
+			Name:     "ReplicaCreate",
+			App:      replica,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Replica"}`,
+			Expected: expect.Status(http.StatusMethodNotAllowed).JSONPath("errors.0.code", codes.ReadOnlyReplica).HeaderSet("Allow"),
+		},
+		{
+			Name:     "ReplicaDelete",
+			App:      replica,
+			Method:   http.MethodDelete,
+			Path:     fmt.Sprintf("/list/%d", lists[0].ID),
+			Expected: expect.Status(http.StatusMethodNotAllowed).JSONPath("errors.0.code", codes.ReadOnlyReplica),
+		},
+		{
+			Name:     "ReplicaAdminRead",
+			App:      replica.Admin(),
+			Method:   http.MethodGet,
+			Path:     "/admin/maintenance",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.enabled", false),
+		},
+		{
+			Name:     "ReplicaAdminWrite",
+			App:      replica.Admin(),
+			Method:   http.MethodPut,
+			Path:     "/admin/maintenance",
+			Body:     `{"enabled":true,"message":"Replica"}`,
+			Expected: expect.Status(http.StatusMethodNotAllowed).JSONPath("errors.0.code", codes.ReadOnlyReplica).HeaderSet("Allow"),
+		},
+		{
+			Name:     "ReplicaSchemaVerify",
+			App:      replica.Admin(),
+			Method:   http.MethodPost,
+			Path:     "/admin/schema/verify",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.drifted", false),
+		},
+		{
+			Name:     "MigratingRead",
+			App:      migrating,
+			Method:   http.MethodGet,
+			Path:     "/list",
+			Expected: expect.Status(http.StatusOK).Len("results", len(lists)),
+		},
+		{
+			Name:     "MigratingUpdate",
+			App:      migrating,
+			Method:   http.MethodPut,
+			Path:     fmt.Sprintf("/list/%d", lists[0].ID),
+			Body:     `{"name":"Migrating"}`,
+			Expected: expect.Status(http.StatusServiceUnavailable).JSONPath("errors.0.code", codes.ReadOnlyMigrating).HeaderSet("Retry-After"),
+		},
+		{
+			Name:     "MigratingAdminWrite",
+			App:      migrating.Admin(),
+			Method:   http.MethodPut,
+			Path:     "/admin/quota",
+			Body:     `{"max_lists":1}`,
+			Expected: expect.Status(http.StatusServiceUnavailable).JSONPath("errors.0.code", codes.ReadOnlyMigrating),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, test.App, test.Method, test.Path, test.Body, ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Nothing was written by the refused requests.
+	expect.Status(http.StatusOK).JSONPath("results.name", lists[0].Name).
+		Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d", lists[0].ID), "", ""))
+	expect.Status(http.StatusOK).JSONPath("results.enabled", false).
+		Assert(t, serve(t, a.Admin(), http.MethodGet, "/admin/maintenance", "", ""))
+}
+
+func Test_openAPI(t *testing.T) {
+	defer checkDBConnections(t)
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+
+	if err := json.Unmarshal(handlers.OpenAPI(), &doc); err != nil {
+		t.Fatalf("error decoding OpenAPI document: %v", err)
+	}
+
+	// Every path parameter is written as {name} in OpenAPI and as :name in httprouter.
+	param := regexp.MustCompile(`{([^}]+)}`)
+
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+
+			documented = append(documented, strings.ToUpper(method)+" "+param.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	// A catch-all parameter is written as *name in httprouter, it is documented as a
+	// parameter spanning the rest of the path.
+	catchAll := regexp.MustCompile(`\*(\w+)$`)
+
+	var registered []string
+	for _, r := range a.Routes() {
+		registered = append(registered, r.Method+" "+catchAll.ReplaceAllString(r.Path, ":$1"))
+	}
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+
+	if d := cmp.Diff(registered, documented); d != "" {
+		t.Errorf("unexpected difference between registered and documented routes:\n%v", d)
+	}
+
+	tests := []struct {
+		Name                string
+		Path                string
+		ExpectedContentType string
+	}{
+		{
+			Name:                "Document",
+			Path:                "/openapi.json",
+			ExpectedContentType: "application/json",
+		},
+		{
+			Name:                "SwaggerUI",
+			Path:                "/docs",
+			ExpectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			Name:                "DemoUI",
+			Path:                "/",
+			ExpectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			Name:                "DemoUIScript",
+			Path:                "/ui/app.js",
+			ExpectedContentType: "text/javascript; charset=utf-8",
+		},
+		{
+			Name:                "DemoUIStylesheet",
+			Path:                "/ui/style.css",
+			ExpectedContentType: "text/css; charset=utf-8",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, test.Path, nil)
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, req)
+
+			if e, a := http.StatusOK, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := test.ExpectedContentType, w.Header().Get("Content-Type"); e != a {
+				t.Errorf("expected content type: %v, got content type: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+// recorder is an events.Publisher that records every published event.
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+// Publish implements the events.Publisher interface.
+func (r *recorder) Publish(_ context.Context, e events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, e)
+	return nil
+}
+
+// Close implements the events.Publisher interface.
+func (r *recorder) Close() error {
+	return nil
+}
+
+func Test_events(t *testing.T) {
+	defer checkDBConnections(t)
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+
+	tests := []struct {
+		Name          string
+		Method        string
+		Path          string
+		Body          string
+		ExpectedTypes []string
+	}{
+		{
+			Name:          "CreateList",
+			Method:        http.MethodPost,
+			Path:          "/list",
+			Body:          `{"name":"Events"}`,
+			ExpectedTypes: []string{events.ListCreated},
+		},
+		{
+			Name:   "ReadsDontPublish",
+			Method: http.MethodGet,
+			Path:   "/list",
+		},
+		{
+			Name:   "FailedWritesDontPublish",
+			Method: http.MethodPost,
+			Path:   "/list",
+			Body:   `{"name":"Events"}`,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			a.ServeHTTP(httptest.NewRecorder(), req)
+
+			var rec recorder
+			if _, err := outbox.Relay(context.Background(), a.DB, &rec, 100); err != nil {
+				t.Fatalf("error relaying events: %v", err)
+			}
+
+			var types []string
+			for _, e := range rec.events {
+				types = append(types, e.Type)
+
+				if e.ID == "" || e.Version != events.Version {
+					t.Errorf("expected event with an id and version %d, got: %+v", events.Version, e)
+				}
+			}
+
+			if d := cmp.Diff(test.ExpectedTypes, types); d != "" {
+				t.Errorf("unexpected difference in published events:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Every event is only relayed once.
+	var rec recorder
+	if n, err := outbox.Relay(context.Background(), a.DB, &rec, 100); err != nil || n != 0 {
+		t.Errorf("expected no events left to relay, got %d events and error: %v", n, err)
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+}
+
+func Test_outboxPurge(t *testing.T) {
+	defer checkDBConnections(t)
+
+	for _, name := range []string{"Purge A", "Purge B", "Purge C"} {
+		req, err := http.NewRequest(http.MethodPost, "/list", strings.NewReader(`{"name":"`+name+`"}`))
+		if err != nil {
+			t.Fatalf("error creating request: %v", err)
+		}
+
+		a.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Pending events are never purged.
+	if n, err := outbox.Purge(a.DB, time.Now().Add(time.Minute), 10); err != nil || n != 0 {
+		t.Errorf("expected no pending events to be purged, got %d events and error: %v", n, err)
+	}
+
+	var rec recorder
+	if _, err := outbox.Relay(context.Background(), a.DB, &rec, 100); err != nil {
+		t.Fatalf("error relaying events: %v", err)
+	}
+
+	if n, err := outbox.Purge(a.DB, time.Now().Add(-time.Minute), 10); err != nil || n != 0 {
+		t.Errorf("expected no events within the retention to be purged, got %d events and error: %v", n, err)
+	}
+
+	for _, expected := range []int64{2, 1, 0} {
+		n, err := outbox.Purge(a.DB, time.Now().Add(time.Minute), 2)
+		if err != nil {
+			t.Fatalf("error purging events: %v", err)
+		}
+
+		if n != expected {
+			t.Errorf("expected a batch of %d purged events, got %d", expected, n)
+		}
+	}
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+}
+
+func Test_dryRun(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Prefer       string
+		Body         string
+		ExpectedCode int
+	}{
+		{
+			Name:         "Create",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Prefer:       "dry-run",
+			Body:         `{"name":"Dry"}`,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "CreateInvalid",
+			Method:       http.MethodPost,
+			Path:         "/list?dry_run=true",
+			Body:         `{"name":"` + lists[0].Name + `"}`,
+			ExpectedCode: http.StatusConflict,
+		},
+		{
+			Name:         "Update",
+			Method:       http.MethodPut,
+			Path:         fmt.Sprintf("/list/%d?dry_run=true", lists[0].ID),
+			Body:         `{"name":"Renamed"}`,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Delete",
+			Method:       http.MethodDelete,
+			Path:         fmt.Sprintf("/list/%d", lists[1].ID),
+			Prefer:       "dry-run",
+			ExpectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			if test.Prefer != "" {
+				req.Header.Set("Prefer", test.Prefer)
+			}
+
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := "dry-run", w.Header().Get("Preference-Applied"); e != a {
+				t.Errorf("expected Preference-Applied %q, got %q", e, a)
+			}
+
+			got, err := list.SelectLists(a.DB)
+			if err != nil {
+				t.Fatalf("error selecting lists: %v", err)
+			}
+
+			sort.Slice(got, func(i, j int) bool {
+				return got[i].ID < got[j].ID
+			})
+
+			if d := cmp.Diff(lists, got); d != "" {
+				t.Errorf("expected lists to be unchanged by a dry run:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Nothing of a dry run is published.
+	var rec recorder
+	if n, err := outbox.Relay(context.Background(), a.DB, &rec, 100); err != nil || n != 0 {
+		t.Errorf("expected no events of dry runs, got %d events and error: %v", n, err)
+	}
+}
+
+func Test_localizedErrors(t *testing.T) {
+	defer checkDBConnections(t)
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		AcceptLanguage string
+		ExpectedCode   int
+		ExpectedError  web.ResponseError
+	}{
+		{
+			Name:          "Default",
+			Method:        http.MethodGet,
+			Path:          "/list/0",
+			ExpectedCode:  http.StatusNotFound,
+			ExpectedError: web.ResponseError{Code: "not_found", Message: "Not Found"},
+		},
+		{
+			Name:           "German",
+			Method:         http.MethodGet,
+			Path:           "/list/0",
+			AcceptLanguage: "de-DE,de;q=0.9,en;q=0.8",
+			ExpectedCode:   http.StatusNotFound,
+			ExpectedError:  web.ResponseError{Code: "not_found", Message: "Nicht gefunden"},
+		},
+		{
+			Name:           "Spanish",
+			Method:         http.MethodGet,
+			Path:           "/list?limit=0",
+			AcceptLanguage: "es",
+			ExpectedCode:   http.StatusBadRequest,
+			ExpectedError:  web.ResponseError{Code: "limit_invalid", Message: `limit debe ser un número entero entre 1 y 500, se recibió "0"`},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(test.Method, test.Path, nil)
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+			req.Header.Set("Accept-Language", test.AcceptLanguage)
+
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			var resp web.Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Errorf("error decoding response body: %v", err)
+			}
+
+			if d := cmp.Diff([]web.ResponseError{test.ExpectedError}, resp.Errors); d != "" {
+				t.Errorf("unexpected difference in response errors:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_observe(t *testing.T) {
+	defer checkDBConnections(t)
+
+	type observed struct {
+		Route  string
+		Status int
+	}
+
+	var got []observed
+
+	o := handlers.NewApplication(a.DB, a.Log, a.Features)
+	o.Observe = func(r *http.Request, route handlers.Route, status int, took time.Duration) {
+		got = append(got, observed{Route: route.Path, Status: status})
+	}
+
+	serve(t, o, http.MethodGet, "/list/0", "", "")
+	serve(t, o, http.MethodGet, "/version", "", "")
+	serve(t, o, http.MethodGet, "/nope", "", "")
+
+	expected := []observed{
+		{Route: "/list/:lid", Status: http.StatusNotFound},
+		{Route: "/version", Status: http.StatusOK},
+		{Status: http.StatusNotFound},
+	}
+
+	if d := cmp.Diff(expected, got); d != "" {
+		t.Errorf("unexpected difference in observed requests:\n%v", d)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+)
+
+func Test_stringIDs(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	a.StringIDs = true
+	defer func() { a.StringIDs = false }()
+
+	w := serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d", lists[0].ID), "", "")
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	var resp struct {
+		Results struct {
+			ID string `json:"id"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response body: %v", err)
+	}
+
+	if e, a := fmt.Sprint(lists[0].ID), resp.Results.ID; e != a {
+		t.Errorf("expected the id %q, got %q", e, a)
+	}
+
+	// Identifiers given as strings are taken as integers.
+	body := fmt.Sprintf(`["%d"]`, lists[0].ID)
+	if w := serve(t, a, http.MethodDelete, "/list", body, ""); w.Code != http.StatusOK {
+		t.Errorf("expected status code: %v, got status code: %v: %s", http.StatusOK, w.Code, w.Body)
+	}
+}
+
+func Test_snowflakeIDs(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	if err := db.SetIDStrategy(a.DB, db.IDSnowflake); err != nil {
+		t.Fatalf("error switching to snowflake ids: %v", err)
+	}
+
+	defer func() {
+		if err := db.SetIDStrategy(a.DB, db.IDSerial); err != nil {
+			t.Errorf("error switching back to serial ids: %v", err)
+		}
+	}()
+
+	var ids []int64
+	for _, name := range []string{"Grocery", "Hardware"} {
+		var id int64
+		expect.Status(http.StatusCreated).Into("results.id", &id).
+			Assert(t, serve(t, a, http.MethodPost, "/list", fmt.Sprintf(`{"name":%q}`, name), ""))
+
+		ids = append(ids, id)
+	}
+
+	if ids[0] < 1<<22 || ids[1] <= ids[0] {
+		t.Fatalf("expected increasing snowflake ids, got %v", ids)
+	}
+
+	if generated := db.SnowflakeTime(ids[1]); time.Since(generated) > time.Minute || time.Until(generated) > time.Minute {
+		t.Errorf("expected id %d to be generated about now, got %v", ids[1], generated)
+	}
+
+	// Items follow the strategy as well, and are found by their snowflake IDs.
+	var item int64
+	expect.Status(http.StatusCreated).Into("results.id", &item).
+		Assert(t, serve(t, a, http.MethodPost, fmt.Sprintf("/list/%d/item", ids[0]), `{"name":"Milk","quantity":1}`, ""))
+
+	if item < 1<<22 {
+		t.Errorf("expected a snowflake item id, got %d", item)
+	}
+
+	expect.Status(http.StatusOK).JSONPath("results.name", "Milk").
+		Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d/item/%d", ids[0], item), "", ""))
+}
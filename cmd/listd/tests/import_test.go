@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/importer"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_import(t *testing.T) {
+	defer checkDBConnections(t)
+
+	if err := testdb.Truncate(a.DB); err != nil {
+		t.Errorf("error truncating database: %v", err)
+	}
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	tests := []struct {
+		Name            string
+		Path            string
+		Body            string
+		ExpectedCode    int
+		ExpectedLists   map[string][]string
+		ExpectedSkipped []importer.Skipped
+	}{
+		{
+			Name:         "Trello",
+			Path:         "/import/trello",
+			Body:         `{"id":"b1","name":"Groceries","lists":[{"id":"l1"}],"cards":[{"id":"c1","name":"Milk","idList":"l1"},{"id":"c2","name":"Eggs","idList":"l1","closed":true}]}`,
+			ExpectedCode: http.StatusOK,
+			ExpectedLists: map[string][]string{
+				"Groceries": {"Milk"},
+			},
+			ExpectedSkipped: []importer.Skipped{
+				{Source: "trello:card:c2", Reason: "card is archived"},
+			},
+		},
+		{
+			Name:         "Todoist",
+			Path:         "/import/todoist",
+			Body:         `{"projects":[{"id":1,"name":"Groceries"},{"id":2,"name":"Hardware"}],"items":[{"id":10,"content":"Nails","project_id":2},{"id":11,"content":"Paint","project_id":2}]}`,
+			ExpectedCode: http.StatusOK,
+			ExpectedLists: map[string][]string{
+				"Hardware": {"Nails", "Paint"},
+			},
+			ExpectedSkipped: []importer.Skipped{
+				{Source: "todoist:project:1", Reason: "a list with the same name already exists"},
+			},
+		},
+		{
+			Name:         "Malformed",
+			Path:         "/import/trello",
+			Body:         `{"name":`,
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, test.Path, strings.NewReader(test.Body))
+			if err != nil {
+				t.Errorf("error creating request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, req)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var report struct {
+				Lists []struct {
+					Source string `json:"source"`
+					List   struct {
+						ID   int    `json:"id"`
+						Name string `json:"name"`
+					} `json:"list"`
+					Items int `json:"items"`
+				} `json:"lists"`
+				Skipped []importer.Skipped `json:"skipped"`
+			}
+
+			if err := json.NewDecoder(w.Body).Decode(&web.Response{Results: &report}); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			if d := cmp.Diff(test.ExpectedSkipped, report.Skipped); d != "" {
+				t.Errorf("unexpected difference in skipped entries:\n%v", d)
+			}
+
+			lists := make(map[string][]string)
+			for _, l := range report.Lists {
+				items, err := item.SelectItems(a.DB, l.List.ID)
+				if err != nil {
+					t.Fatalf("error selecting items of imported list: %v", err)
+				}
+
+				if e, a := l.Items, len(items); e != a {
+					t.Errorf("expected %d items, got %d", e, a)
+				}
+
+				names := []string{}
+				for _, i := range items {
+					names = append(names, i.Name)
+				}
+				sort.Strings(names)
+
+				lists[l.List.Name] = names
+			}
+
+			if d := cmp.Diff(test.ExpectedLists, lists); d != "" {
+				t.Errorf("unexpected difference in imported lists:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_importConcurrentList(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	// A list with the name of the board is created by a transaction the import doesn't
+	// see, which commits while the import waits to create its list.
+	tx, err := a.DB.Beginx()
+	if err != nil {
+		t.Fatalf("error beginning transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := list.CreateList(tx, list.List{Name: "Groceries"}); err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import/trello", strings.NewReader(`{"id":"b1","name":"Groceries","lists":[{"id":"l1"}],"cards":[{"id":"c1","name":"Milk","idList":"l1"}]}`)))
+		done <- w
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var waiting int
+		if err := a.DB.Get(&waiting, "SELECT count(*) FROM pg_stat_activity WHERE datname = current_database() AND wait_event_type = 'Lock';"); err != nil {
+			t.Fatalf("error selecting waiting backends: %v", err)
+		}
+
+		if waiting > 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected the import to wait for the list to be committed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %v", err)
+	}
+
+	expect.Status(http.StatusOK).
+		Len("results.lists", 0).
+		JSONPath("results.skipped.0.source", "trello:board:b1").
+		JSONPath("results.skipped.0.reason", "a list with the same name already exists").
+		Assert(t, <-done)
+}
+
+func Test_importRows(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	if _, err := testdb.SeedLists(a.DB); err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	var baking int
+	tests := []struct {
+		Name     string
+		Path     string
+		Body     string
+		Expected expect.Response
+	}{
+		{
+			// Rows with the same name are merged, adding up their quantities.
+			Name: "CSV",
+			Path: "/import/csv",
+			Body: "Name,List,Quantity\nFlour,Baking,2\nflour,Baking,1\nSugar,Baking,0\nMilk,Grocery,1\n",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results.imported", 2).
+				Len("results.lists", 1).
+				JSONPath("results.lists.0.list.name", "Baking").
+				Into("results.lists.0.list.id", &baking).
+				JSONPath("results.lists.0.items", 2).
+				Len("results.rejected", 2).
+				JSONPath("results.rejected.0.row", 4).
+				JSONPath("results.rejected.0.reason", "quantity must be a positive whole number").
+				JSONPath("results.rejected.1.fields", []string{"Milk", "Grocery", "1"}),
+		},
+		{
+			Name:     "MissingColumn",
+			Path:     "/import/csv",
+			Body:     "list,quantity\nBaking,1\n",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.ExportInvalid),
+		},
+		{
+			Name:     "NotAWorkbook",
+			Path:     "/import/xlsx",
+			Body:     "list,name\nBaking,Flour\n",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.ExportInvalid),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodPost, test.Path, test.Body, ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Both rows naming flour ended up in a single item.
+	expect.Status(http.StatusOK).
+		Len("results", 1).
+		JSONPath("results.0.quantity", 3).
+		Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d/item", baking), "", ""))
+}
@@ -1,20 +1,28 @@
 package tests
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/dedup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
 	"github.com/google/go-cmp/cmp"
 )
 
 func Test_getItems(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -32,63 +40,80 @@ func Test_getItems(t *testing.T) {
 	}
 
 	tests := []struct {
-		Name         string
-		ListID       int
-		ExpectedBody []item.Item
-		ExpectedCode int
+		Name     string
+		ListID   int
+		Expected expect.Response
 	}{
 		{
-			Name:   "OK",
-			ListID: expectedLists[0].ID,
-			ExpectedBody: []item.Item{
-				expectedItems[0],
-				expectedItems[1],
-			},
-			ExpectedCode: http.StatusOK,
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			Expected: expect.Status(http.StatusOK).JSONPath("results", expectedItems[:2]),
 		},
 		{
-			Name:         "NoContent",
-			ListID:       expectedLists[2].ID,
-			ExpectedBody: []item.Item{},
-			ExpectedCode: http.StatusOK,
+			Name:     "NoContent",
+			ListID:   expectedLists[2].ID,
+			Expected: expect.Status(http.StatusOK).Len("results", 0),
 		},
 		{
 			Name: "NotFound",
 			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID:       0,
-			ExpectedBody: nil,
-			ExpectedCode: http.StatusNotFound,
+			ListID:   0,
+			Expected: expect.Status(http.StatusNotFound),
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/list/%d/item", test.ListID), nil)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
-			}
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d/item", test.ListID), "", ""))
+		}
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
+		t.Run(test.Name, fn)
+	}
+}
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+func Test_getItemsByIDs(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
 
-			if test.ExpectedBody != nil {
-				var items []item.Item
-				resp := web.Response{
-					Results: items,
-				}
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
 
-				if d := cmp.Diff(test.ExpectedBody, items); d != "" {
-					t.Errorf("unexpected difference in response body:\n%v", d)
-				}
-			}
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected expect.Response
+	}{
+		{
+			Name:     "AcrossLists",
+			Query:    fmt.Sprintf("?ids=%d,%d", items[2].ID, items[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", []item.Item{items[2], items[0]}),
+		},
+		{
+			Name:     "NotFound",
+			Query:    fmt.Sprintf("?ids=%d,0", items[0].ID),
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "Missing",
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, "/items"+test.Query, "", ""))
 		}
 
 		t.Run(test.Name, fn)
@@ -96,6 +121,8 @@ func Test_getItems(t *testing.T) {
 }
 
 func Test_createItem(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -107,96 +134,325 @@ func Test_createItem(t *testing.T) {
 		t.Fatalf("error seeding lists: %v", err)
 	}
 
+	// created expects the given item to be in the first list, answered with code.
+	created := func(code int, name string, quantity int, unit string) expect.Response {
+		return expect.Status(code).
+			JSONPath("results.listID", expectedLists[0].ID).
+			JSONPath("results.name", name).
+			JSONPath("results.quantity", quantity).
+			JSONPath("results.unit", unit)
+	}
+
 	tests := []struct {
-		Name         string
-		ListID       int
-		RequestBody  item.Item
-		ExpectedCode int
+		Name     string
+		ListID   int
+		Query    string
+		Body     string
+		Expected expect.Response
 	}{
 		{
-			Name:   "OK",
-			ListID: expectedLists[0].ID,
-			RequestBody: item.Item{
-				Name:     "Foo",
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusCreated,
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Foo","quantity":1}`,
+			Expected: created(http.StatusCreated, "Foo", 1, "").HeaderSet("Location").NonZero("results.url"),
 		},
 		{
-			Name:   "NoName",
-			ListID: expectedLists[0].ID,
-			RequestBody: item.Item{
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "NoName",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"quantity":1}`,
+			Expected: expect.Status(http.StatusBadRequest),
 		},
 		{
-			Name:   "LessThanOneQuantity",
-			ListID: expectedLists[0].ID,
-			RequestBody: item.Item{
-				Name:     "Bar",
-				Quantity: 0,
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "InvisibleCharacters",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Foo\u200bBar","quantity":1}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity),
+		},
+		{
+			Name:     "PayloadTooLarge",
+			ListID:   expectedLists[0].ID,
+			Body:     fmt.Sprintf(`{"name":%q,"quantity":1}`, strings.Repeat("a", web.DefaultMaxBodySize)),
+			Expected: expect.Status(http.StatusRequestEntityTooLarge),
+		},
+		{
+			Name:     "LessThanOneQuantity",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Bar","quantity":0}`,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "Unit",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Flour","quantity":2,"unit":"kg"}`,
+			Expected: created(http.StatusCreated, "Flour", 2, "kg"),
+		},
+		{
+			Name:     "UnknownUnit",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Flour","quantity":2,"unit":"bushels"}`,
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.UnitInvalid).JSONPath("errors.0.field", "unit").JSONPath("errors.0.allowed", a.Units),
+		},
+		{
+			Name:     "UnitSpelling",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Sugar","quantity":1,"unit":" KG "}`,
+			Expected: created(http.StatusCreated, "Sugar", 1, "kg"),
+		},
+		{
+			Name:     "DuplicateName",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"foo","quantity":1}`,
+			Expected: expect.Status(http.StatusConflict),
+		},
+		{
+			Name:     "Merge",
+			ListID:   expectedLists[0].ID,
+			Query:    "?merge=true",
+			Body:     `{"name":"FOO","quantity":2}`,
+			Expected: created(http.StatusOK, "Foo", 3, ""),
+		},
+		{
+			Name:     "MergeNew",
+			ListID:   expectedLists[0].ID,
+			Query:    "?merge=true",
+			Body:     `{"name":"Baz","quantity":1}`,
+			Expected: created(http.StatusCreated, "Baz", 1, ""),
+		},
+		{
+			Name:     "MergeDifferentUnit",
+			ListID:   expectedLists[0].ID,
+			Query:    "?merge=true",
+			Body:     `{"name":"Flour","quantity":1,"unit":"g"}`,
+			Expected: expect.Status(http.StatusConflict),
 		},
 		{
 			Name: "NotFoundList",
 			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID: 0,
-			RequestBody: item.Item{
-				Name:     "Bar",
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusNotFound,
+			ListID:   0,
+			Body:     `{"name":"Bar","quantity":1}`,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodPost, fmt.Sprintf("/list/%d/item%s", test.ListID, test.Query), test.Body, ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_createItemDuplicate(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	deduped := handlers.NewApplication(a.DB, a.Log, a.Features)
+	deduped.Duplicates = dedup.New(time.Minute)
+
+	path := fmt.Sprintf("/list/%d/item", lists[0].ID)
+
+	tests := []struct {
+		Name              string
+		App               http.Handler
+		Body              string
+		ExpectedCode      int
+		ExpectedDuplicate bool
+	}{
+		{
+			Name:         "First",
+			App:          deduped,
+			Body:         `{"name":"Milk","quantity":2}`,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:              "Duplicate",
+			App:               deduped,
+			Body:              `{"name":"Milk","quantity":2}`,
+			ExpectedCode:      http.StatusOK,
+			ExpectedDuplicate: true,
+		},
+		{
+			Name:              "DuplicateAfterNormalization",
+			App:               deduped,
+			Body:              `{"name":"  Milk ","quantity":2}`,
+			ExpectedCode:      http.StatusOK,
+			ExpectedDuplicate: true,
+		},
+		{
+			Name:         "DifferentBody",
+			App:          deduped,
+			Body:         `{"name":"Milk","quantity":3}`,
+			ExpectedCode: http.StatusConflict,
+		},
+		{
+			Name:         "Disabled",
+			App:          a,
+			Body:         `{"name":"Milk","quantity":2}`,
+			ExpectedCode: http.StatusConflict,
 		},
 	}
 
+	var first int
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			var b bytes.Buffer
-			if err := json.NewEncoder(&b).Encode(test.RequestBody); err != nil {
-				t.Errorf("error encoding request body: %v", err)
+			var i struct {
+				item.Item
+				Duplicate bool `json:"duplicate"`
 			}
 
-			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/list/%d/item", test.ListID), &b)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
+			w := serve(t, test.App, http.MethodPost, path, test.Body, "")
+
+			expected := expect.Status(test.ExpectedCode)
+			if test.ExpectedCode < http.StatusMultipleChoices {
+				expected = expected.Into("results", &i)
 			}
 
-			defer func() {
-				if err := req.Body.Close(); err != nil {
-					t.Errorf("error encountered closing request body: %v", err)
-				}
-			}()
+			if expected.Assert(t, w); t.Failed() || w.Code >= http.StatusMultipleChoices {
+				return
+			}
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
+			if e, a := test.ExpectedDuplicate, i.Duplicate; e != a {
+				t.Errorf("expected duplicate %v, got %v", e, a)
+			}
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			if first == 0 {
+				first = i.ID
+			} else if i.ID != first {
+				t.Errorf("expected the item created first with id %d, got id %d", first, i.ID)
 			}
+		}
 
-			if test.ExpectedCode == http.StatusCreated {
-				var i item.Item
-				resp := web.Response{
-					Results: i,
-				}
+		t.Run(test.Name, fn)
+	}
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+	items, err := item.SelectItems(a.DB, lists[0].ID)
+	if err != nil {
+		t.Fatalf("error selecting items: %v", err)
+	}
 
-				if e, a := test.RequestBody.Name, i.Name; e != a {
-					t.Errorf("expected item name: %v, got item name: %v", e, a)
-				}
+	if len(items) != 1 {
+		t.Errorf("expected a single item to be created, got %d", len(items))
+	}
+}
 
-				if e, a := test.RequestBody.Quantity, i.Quantity; e != a {
-					t.Errorf("expected item quantity: %v, got item quantity: %v", e, a)
-				}
+func Test_createItems(t *testing.T) {
+	defer checkDBConnections(t)
 
-				if e, a := test.ListID, i.ListID; e != a {
-					t.Errorf("expected item list id: %v, got item list id: %v", e, a)
-				}
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	expectedLists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, expectedLists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	tests := []struct {
+		Name             string
+		ListID           int
+		Query            string
+		Body             string
+		ExpectedStatuses []int
+		ExpectedItems    int
+		ExpectedCode     int
+	}{
+		{
+			Name:             "BestEffort",
+			ListID:           expectedLists[2].ID,
+			Body:             `[{"name":"Stapler","quantity":1},{"name":"","quantity":1},{"name":"Paper","quantity":500}]`,
+			ExpectedStatuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusCreated},
+			ExpectedItems:    2,
+			ExpectedCode:     http.StatusMultiStatus,
+		},
+		{
+			Name:             "BestEffortDuplicate",
+			ListID:           expectedLists[0].ID,
+			Body:             `[{"name":"chocolate milk","quantity":1},{"name":"Bread","quantity":1}]`,
+			ExpectedStatuses: []int{http.StatusConflict, http.StatusCreated},
+			ExpectedItems:    3,
+			ExpectedCode:     http.StatusMultiStatus,
+		},
+		{
+			Name:             "AllOrNothing",
+			ListID:           expectedLists[1].ID,
+			Query:            "?atomic=true",
+			Body:             `[{"name":"Review Pull Request","quantity":1},{"name":"write integration tests","quantity":1}]`,
+			ExpectedStatuses: []int{http.StatusFailedDependency, http.StatusConflict},
+			ExpectedItems:    1,
+			ExpectedCode:     http.StatusMultiStatus,
+		},
+		{
+			Name:             "AllOrNothingSucceeded",
+			ListID:           expectedLists[1].ID,
+			Query:            "?atomic=true",
+			Body:             `[{"name":"Review Pull Request","quantity":1}]`,
+			ExpectedStatuses: []int{http.StatusCreated},
+			ExpectedItems:    2,
+			ExpectedCode:     http.StatusMultiStatus,
+		},
+		{
+			Name:         "Empty",
+			ListID:       expectedLists[1].ID,
+			Body:         `[]`,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name: "NotFound",
+			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
+			ListID:       0,
+			Body:         `[{"name":"Stapler","quantity":1}]`,
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var entries []web.EntryStatus
+
+			expected := expect.Status(test.ExpectedCode)
+			if test.ExpectedStatuses != nil {
+				expected = expected.Into("results", &entries)
+			}
+
+			expected.Assert(t, serve(t, a, http.MethodPost, fmt.Sprintf("/list/%d/item/batch%s", test.ListID, test.Query), test.Body, ""))
+
+			if test.ExpectedStatuses == nil {
+				return
+			}
+
+			statuses := make([]int, 0, len(entries))
+			for _, e := range entries {
+				statuses = append(statuses, e.Status)
+			}
+
+			if d := cmp.Diff(test.ExpectedStatuses, statuses); d != "" {
+				t.Errorf("unexpected difference in entry statuses:\n%v", d)
+			}
+
+			items, err := item.SelectItems(a.DB, test.ListID)
+			if err != nil {
+				t.Fatalf("error selecting items: %v", err)
+			}
+
+			if e, a := test.ExpectedItems, len(items); e != a {
+				t.Errorf("expected %d items in the list, got %d", e, a)
 			}
 		}
 
@@ -205,6 +461,8 @@ func Test_createItem(t *testing.T) {
 }
 
 func Test_getItem(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -222,26 +480,37 @@ func Test_getItem(t *testing.T) {
 	}
 
 	tests := []struct {
-		Name         string
-		ListID       int
-		ItemID       int
-		ExpectedBody item.Item
-		ExpectedCode int
+		Name            string
+		ListID          int
+		ItemID          int
+		IfModifiedSince string
+		Expected        expect.Response
 	}{
 		{
-			Name:         "OK",
-			ListID:       expectedLists[0].ID,
-			ItemID:       expectedItems[0].ID,
-			ExpectedBody: expectedItems[0],
-			ExpectedCode: http.StatusOK,
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Expected: expect.Status(http.StatusOK).HeaderSet("Last-Modified").JSONPath("results", expectedItems[0]),
+		},
+		{
+			Name:            "NotModified",
+			ListID:          expectedLists[0].ID,
+			ItemID:          expectedItems[0].ID,
+			IfModifiedSince: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			Expected:        expect.Status(http.StatusNotModified),
 		},
 		{
 			Name:   "NotFound",
 			ListID: expectedLists[0].ID,
 			// Using 0 for ItemID because postgres serial type starts at 1 so 0 will never exist.
-			ItemID:       0,
-			ExpectedBody: item.Item{},
-			ExpectedCode: http.StatusNotFound,
+			ItemID:   0,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "OtherList",
+			ListID:   expectedLists[1].ID,
+			ItemID:   expectedItems[0].ID,
+			Expected: expect.Status(http.StatusNotFound),
 		},
 	}
 
@@ -252,27 +521,14 @@ func Test_getItem(t *testing.T) {
 				t.Errorf("error creating request: %v", err)
 			}
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
-
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			if test.IfModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", test.IfModifiedSince)
 			}
 
-			if test.ExpectedCode != http.StatusNotFound {
-				var i item.Item
-				resp := web.Response{
-					Results: i,
-				}
-
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, req)
 
-				if d := cmp.Diff(test.ExpectedBody, i); d != "" {
-					t.Errorf("unexpected difference in response body:\n%v", d)
-				}
-			}
+			test.Expected.Assert(t, w)
 		}
 
 		t.Run(test.Name, fn)
@@ -280,6 +536,8 @@ func Test_getItem(t *testing.T) {
 }
 
 func Test_updateItem(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -297,173 +555,304 @@ func Test_updateItem(t *testing.T) {
 	}
 
 	tests := []struct {
-		Name         string
-		ListID       int
-		ItemID       int
-		RequestBody  item.Item
-		ExpectedCode int
+		Name     string
+		ListID   int
+		ItemID   int
+		Body     string
+		Expected expect.Response
 	}{
 		{
 			Name:   "OK",
 			ListID: expectedLists[0].ID,
 			ItemID: expectedItems[0].ID,
-			RequestBody: item.Item{
-				Name:     "Foo",
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusOK,
+			Body:   `{"name":"Foo","quantity":1}`,
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results.id", expectedItems[0].ID).
+				JSONPath("results.listID", expectedLists[0].ID).
+				JSONPath("results.name", "Foo").
+				JSONPath("results.quantity", 1).
+				JSONPath("results.unit", ""),
 		},
 		{
-			Name:   "NoName",
-			ListID: expectedLists[0].ID,
-			ItemID: expectedItems[0].ID,
-			RequestBody: item.Item{
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "NoName",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Body:     `{"quantity":1}`,
+			Expected: expect.Status(http.StatusBadRequest),
 		},
 		{
-			Name:   "LessThanOneQuantity",
-			ListID: expectedLists[0].ID,
-			ItemID: expectedItems[0].ID,
-			RequestBody: item.Item{
-				Name:     "Bar",
-				Quantity: 0,
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "LessThanOneQuantity",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Body:     `{"name":"Bar","quantity":0}`,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "UnknownUnit",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Body:     `{"name":"Bar","quantity":1,"unit":"bushels"}`,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "DuplicateName",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Body:     `{"name":"mac and cheese","quantity":1}`,
+			Expected: expect.Status(http.StatusConflict),
 		},
 		{
 			Name: "NotFoundList",
 			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID: 0,
-			ItemID: expectedItems[0].ID,
-			RequestBody: item.Item{
-				Name:     "Bar",
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusNotFound,
+			ListID:   0,
+			ItemID:   expectedItems[0].ID,
+			Body:     `{"name":"Bar","quantity":1}`,
+			Expected: expect.Status(http.StatusNotFound),
 		},
 		{
 			Name:   "NotFoundItem",
 			ListID: expectedLists[0].ID,
 			// Using 0 for ItemID because postgres serial type starts at 1 so 0 will never exist.
-			ItemID: 0,
-			RequestBody: item.Item{
-				Name:     "Bar",
-				Quantity: 1,
-			},
-			ExpectedCode: http.StatusNotFound,
+			ItemID:   0,
+			Body:     `{"name":"Bar","quantity":1}`,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "OtherList",
+			ListID:   expectedLists[1].ID,
+			ItemID:   expectedItems[1].ID,
+			Body:     `{"name":"Bar","quantity":1}`,
+			Expected: expect.Status(http.StatusNotFound),
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			var b bytes.Buffer
-			if err := json.NewEncoder(&b).Encode(test.RequestBody); err != nil {
-				t.Errorf("error encoding request body: %v", err)
-			}
+			test.Expected.Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("/list/%d/item/%d", test.ListID, test.ItemID), test.Body, ""))
+		}
 
-			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/list/%d/item/%d", test.ListID, test.ItemID), &b)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
-			}
+		t.Run(test.Name, fn)
+	}
+}
 
-			defer func() {
-				if err := req.Body.Close(); err != nil {
-					t.Errorf("error encountered closing request body: %v", err)
-				}
-			}()
+func Test_deleteItem(t *testing.T) {
+	defer checkDBConnections(t)
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+	expectedLists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
 
-			if test.ExpectedCode == http.StatusOK {
-				var i item.Item
-				resp := web.Response{
-					Results: i,
-				}
+	expectedItems, err := testdb.SeedItems(a.DB, expectedLists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+	tests := []struct {
+		Name     string
+		ListID   int
+		ItemID   int
+		Query    string
+		Prefer   string
+		Expected expect.Response
+	}{
+		{
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[0].ID,
+			Expected: expect.Status(http.StatusNoContent),
+		},
+		{
+			Name:   "NotFound",
+			ListID: expectedLists[0].ID,
+			// Using 0 for ItemID because postgres serial type starts at 1 so 0 will never exist.
+			ItemID:   0,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "OtherList",
+			ListID:   expectedLists[1].ID,
+			ItemID:   expectedItems[1].ID,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "Representation",
+			ListID:   expectedLists[0].ID,
+			ItemID:   expectedItems[1].ID,
+			Query:    "?return=representation",
+			Expected: expect.Status(http.StatusOK).JSONPath("results", expectedItems[1]),
+		},
+		{
+			Name:     "PreferRepresentation",
+			ListID:   expectedLists[1].ID,
+			ItemID:   expectedItems[2].ID,
+			Prefer:   "return=representation",
+			Expected: expect.Status(http.StatusOK).JSONPath("results", expectedItems[2]),
+		},
+	}
 
-				if e, a := test.RequestBody.Name, i.Name; e != a {
-					t.Errorf("expected item name: %v, got item name: %v", e, a)
-				}
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodDelete, fmt.Sprintf("/list/%d/item/%d%s", test.ListID, test.ItemID, test.Query), "", test.Prefer))
+		}
 
-				if e, a := test.RequestBody.Quantity, i.Quantity; e != a {
-					t.Errorf("expected item quantity: %v, got item quantity: %v", e, a)
-				}
+		t.Run(test.Name, fn)
+	}
+}
 
-				if e, a := test.ItemID, i.ID; e != a {
-					t.Errorf("expected item id: %v, got item id: %v", e, a)
-				}
+func Test_itemCount(t *testing.T) {
+	defer checkDBConnections(t)
 
-				if e, a := test.ListID, i.ListID; e != a {
-					t.Errorf("expected item list id: %v, got item list id: %v", e, a)
-				}
-			}
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
 		}
+	}()
 
-		t.Run(test.Name, fn)
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	items, err := testdb.SeedItems(a.DB, lists)
+	if err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	// Items are created in and deleted from the same list at the same time, every
+	// request increments or decrements the count of the list concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/list/%d/item", lists[0].ID), strings.NewReader(fmt.Sprintf(`{"name":"Concurrent %d","quantity":1}`, i)))
+			a.ServeHTTP(httptest.NewRecorder(), req)
+		}(i)
+	}
+
+	for _, i := range items[:2] {
+		wg.Add(1)
+		go func(i item.Item) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/list/%d/item/%d", i.ListID, i.ID), nil)
+			a.ServeHTTP(httptest.NewRecorder(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, l := range lists {
+		got, err := list.SelectList(a.DB, l.ID)
+		if err != nil {
+			t.Fatalf("error selecting list %d: %v", l.ID, err)
+		}
+
+		listItems, err := item.SelectItems(a.DB, l.ID)
+		if err != nil {
+			t.Fatalf("error selecting items of list %d: %v", l.ID, err)
+		}
+
+		if got.ItemCount != len(listItems) {
+			t.Errorf("expected list %d to count %d items, got %d", l.ID, len(listItems), got.ItemCount)
+		}
+	}
+
+	// The first list started with 2 items, both of which were deleted.
+	got, err := list.SelectList(a.DB, lists[0].ID)
+	if err != nil {
+		t.Fatalf("error selecting list %d: %v", lists[0].ID, err)
+	}
+
+	if e, a := 20, got.ItemCount; e != a {
+		t.Errorf("expected the first list to count %d items, got %d", e, a)
 	}
 }
 
-func Test_deleteItem(t *testing.T) {
+func Test_getItemHistory(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
 		}
 	}()
 
-	expectedLists, err := testdb.SeedLists(a.DB)
+	lists, err := testdb.SeedLists(a.DB)
 	if err != nil {
 		t.Fatalf("error seeding lists: %v", err)
 	}
 
-	expectedItems, err := testdb.SeedItems(a.DB, expectedLists)
+	items, err := testdb.SeedItems(a.DB, lists)
 	if err != nil {
 		t.Fatalf("error seeding items: %v", err)
 	}
 
+	i := items[0]
+	path := fmt.Sprintf("/list/%d/item/%d", i.ListID, i.ID)
+
+	// Renaming and then merging into the item changes the quantity twice and the name once,
+	// the unchanged unit isn't recorded.
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPut, path, `{"name":"Oat Milk","quantity":3}`},
+		{http.MethodPost, fmt.Sprintf("/list/%d/item?merge=true", i.ListID), `{"name":"Oat Milk","quantity":2}`},
+	} {
+		if expect.Status(http.StatusOK).Assert(t, serve(t, a, req.method, req.path, req.body, "")); t.Failed() {
+			t.FailNow()
+		}
+	}
+
+	type change struct {
+		Field string      `json:"field"`
+		Old   interface{} `json:"old"`
+		New   interface{} `json:"new"`
+	}
+
 	tests := []struct {
-		Name         string
-		ListID       int
-		ItemID       int
-		ExpectedCode int
+		Name     string
+		Path     string
+		Expected expect.Response
 	}{
 		{
-			Name:         "OK",
-			ListID:       expectedLists[0].ID,
-			ItemID:       expectedItems[0].ID,
-			ExpectedCode: http.StatusNoContent,
+			Name: "History",
+			Path: path + "/history",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results", []change{
+					{Field: "quantity", Old: 3.0, New: 5.0},
+					{Field: "quantity", Old: 1.0, New: 3.0},
+					{Field: "name", Old: "Chocolate Milk", New: "Oat Milk"},
+				}).
+				JSONPath("page.total", 3),
 		},
 		{
-			Name:   "NotFound",
-			ListID: expectedLists[0].ID,
-			// Using 0 for ItemID because postgres serial type starts at 1 so 0 will never exist.
-			ItemID:       0,
-			ExpectedCode: http.StatusNotFound,
+			Name: "Page",
+			Path: path + "/history?limit=1&offset=1",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results", []change{{Field: "quantity", Old: 1.0, New: 3.0}}).
+				JSONPath("page.total", 3),
+		},
+		{
+			Name:     "Unchanged",
+			Path:     fmt.Sprintf("/list/%d/item/%d/history", items[1].ListID, items[1].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", []change{}).JSONPath("page.total", 0),
+		},
+		{
+			Name:     "OtherList",
+			Path:     fmt.Sprintf("/list/%d/item/%d/history", lists[1].ID, i.ID),
+			Expected: expect.Status(http.StatusNotFound),
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/list/%d/item/%d", test.ListID, test.ItemID), nil)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
-			}
-
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
-
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, test.Path, "", ""))
 		}
 
 		t.Run(test.Name, fn)
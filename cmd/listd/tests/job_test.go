@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/exporter"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/job"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/worker"
+)
+
+// waitForJob polls the job at the given location until it is done and returns it.
+func waitForJob(t *testing.T, h http.Handler, location string) job.Job {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		var j job.Job
+		resp := web.Response{
+			Results: &j,
+		}
+
+		w := serve(t, h, http.MethodGet, location, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status code: %v, got status code: %v", http.StatusOK, w.Code)
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+
+		if j.Status == job.StatusSucceeded || j.Status == job.StatusFailed {
+			return j
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected job to be done within 5s, it is %s", j.Status)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_jobs(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	if _, err := testdb.SeedLists(a.DB); err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	async := handlers.NewApplication(a.DB, a.Log, a.Features)
+	async.Workers = worker.New(2, 10)
+	async.ImportBatchSize = 2
+	defer func() {
+		if err := async.Workers.Close(context.Background()); err != nil {
+			t.Errorf("error closing workers: %v", err)
+		}
+	}()
+
+	t.Run("Import", func(t *testing.T) {
+		body := `{"projects":[{"id":1,"name":"Garden"},{"id":2,"name":"Garage"}],"items":[{"id":10,"content":"Seeds","project_id":1}]}`
+
+		w := serve(t, async, http.MethodPost, "/import/todoist", body, "respond-async")
+		if e, a := http.StatusAccepted, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if e, a := "respond-async", w.Header().Get("Preference-Applied"); e != a {
+			t.Errorf("expected Preference-Applied %q, got %q", e, a)
+		}
+
+		j := waitForJob(t, async, w.Header().Get("Location"))
+		if j.Status != job.StatusSucceeded || j.Progress != 100 || j.Kind != "import" {
+			t.Fatalf("expected import job to succeed, got %+v", j)
+		}
+
+		var report struct {
+			Lists []struct {
+				Source string `json:"source"`
+				Items  int    `json:"items"`
+			} `json:"lists"`
+		}
+		resp := web.Response{
+			Results: &report,
+		}
+
+		w = serve(t, async, http.MethodGet, fmt.Sprintf("/job/%d/result", j.ID), "", "")
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+
+		if len(report.Lists) != 2 || report.Lists[0].Items != 1 {
+			t.Errorf("expected report of both imported lists, got %+v", report)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		w := serve(t, async, http.MethodPost, "/export?async=true", "", "")
+		if e, a := http.StatusAccepted, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		j := waitForJob(t, async, w.Header().Get("Location"))
+		if j.Status != job.StatusSucceeded || j.Kind != "export" {
+			t.Fatalf("expected export job to succeed, got %+v", j)
+		}
+
+		var doc exporter.Document
+		resp := web.Response{
+			Results: &doc,
+		}
+
+		w = serve(t, async, http.MethodGet, fmt.Sprintf("/job/%d/result", j.ID), "", "")
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+
+		// The seeded lists along with the imported ones.
+		if e, a := 5, len(doc.Lists); e != a {
+			t.Errorf("expected %d exported lists, got %d", e, a)
+		}
+	})
+
+	t.Run("Synchronous", func(t *testing.T) {
+		w := serve(t, a, http.MethodPost, "/export", "", "respond-async")
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if a := w.Header().Get("Preference-Applied"); a != "" {
+			t.Errorf("expected no preference to be applied without workers, got %q", a)
+		}
+
+		var doc exporter.Document
+		resp := web.Response{
+			Results: &doc,
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("error decoding response body: %v", err)
+		}
+
+		if e, a := 5, len(doc.Lists); e != a {
+			t.Errorf("expected %d exported lists, got %d", e, a)
+		}
+	})
+
+	t.Run("Spreadsheet", func(t *testing.T) {
+		body := "list,name,quantity\nShed,Rake,1\nShed,Hose,x\nShed,Saw,2\nGarden,Soil,3\n"
+
+		w := serve(t, async, http.MethodPost, "/import/csv", body, "respond-async")
+		if e, a := http.StatusAccepted, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		// Garden was imported before, the rows naming it are rejected.
+		j := waitForJob(t, async, w.Header().Get("Location"))
+		if j.Status != job.StatusSucceeded || j.Progress != 100 {
+			t.Fatalf("expected import job to succeed, got %+v", j)
+		}
+
+		w = serve(t, async, http.MethodGet, fmt.Sprintf("/job/%d/rejected", j.ID), "", "")
+		if e, a := http.StatusOK, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		expected := "row,reason,list,name,quantity\n" +
+			"3,quantity must be a positive whole number,Shed,Hose,x\n" +
+			"5,a list with the same name already exists,Garden,Soil,3\n"
+
+		if a := w.Body.String(); a != expected {
+			t.Errorf("expected rejected rows:\n%s\ngot:\n%s", expected, a)
+		}
+	})
+
+	t.Run("ResultUnavailable", func(t *testing.T) {
+		j, err := job.CreateJob(a.DB, "export")
+		if err != nil {
+			t.Fatalf("error creating job: %v", err)
+		}
+
+		w := serve(t, async, http.MethodGet, fmt.Sprintf("/job/%d/result", j.ID), "", "")
+		if e, a := http.StatusConflict, w.Code; e != a {
+			t.Errorf("expected status code: %v, got status code: %v", e, a)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, path := range []string{"/job/999999", "/job/999999/result"} {
+			w := serve(t, async, http.MethodGet, path, "", "")
+			if e, a := http.StatusNotFound, w.Code; e != a {
+				t.Errorf("%s: expected status code: %v, got status code: %v", path, e, a)
+			}
+		}
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		closed := handlers.NewApplication(a.DB, a.Log, a.Features)
+		closed.Workers = worker.New(1, 1)
+		if err := closed.Workers.Close(context.Background()); err != nil {
+			t.Fatalf("error closing workers: %v", err)
+		}
+
+		w := serve(t, closed, http.MethodPost, "/export", "", "respond-async")
+		if e, a := http.StatusServiceUnavailable, w.Code; e != a {
+			t.Errorf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After header")
+		}
+	})
+}
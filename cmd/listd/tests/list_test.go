@@ -1,47 +1,28 @@
 package tests
 
 import (
-	"bytes"
-	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
 	"github.com/google/go-cmp/cmp"
 )
 
 func Test_getLists(t *testing.T) {
-	// No Content (no seed data)
-	{
-		req, err := http.NewRequest(http.MethodGet, "/list", nil)
-		if err != nil {
-			t.Errorf("error creating request: %v", err)
-		}
-
-		w := httptest.NewRecorder()
-		a.ServeHTTP(w, req)
-
-		if e, a := http.StatusOK, w.Code; e != a {
-			t.Errorf("expected status code: %v, got status code: %v", e, a)
-		}
-
-		var lists []list.List
-		resp := web.Response{
-			Results: &lists,
-		}
-
-		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-			t.Errorf("error decoding response body: %v", err)
-		}
+	defer checkDBConnections(t)
 
-		if len(lists) > 0 {
-			t.Errorf("expected no lists to be returned, got %v lists", len(lists))
-		}
-	}
+	// No Content (no seed data)
+	expect.Status(http.StatusOK).Len("results", 0).Assert(t, serve(t, a, http.MethodGet, "/list", "", ""))
 
 	// Ok (database has been seeded)
 	{
@@ -56,34 +37,198 @@ func Test_getLists(t *testing.T) {
 			t.Fatalf("error seeding lists: %v", err)
 		}
 
-		req, err := http.NewRequest(http.MethodGet, "/list", nil)
-		if err != nil {
-			t.Errorf("error creating request: %v", err)
+		expect.Status(http.StatusOK).JSONPath("results", expectedLists).Assert(t, serve(t, a, http.MethodGet, "/list", "", ""))
+	}
+}
+
+func Test_getListsPage(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
 		}
+	}()
 
-		w := httptest.NewRecorder()
-		a.ServeHTTP(w, req)
+	expectedLists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
 
-		if e, a := http.StatusOK, w.Code; e != a {
-			t.Errorf("expected status code: %v, got status code: %v", e, a)
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected expect.Response
+	}{
+		{
+			Name: "Default",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results", expectedLists).
+				JSONPath("page", web.Page{Limit: web.DefaultPageSize, Offset: 0, Total: len(expectedLists)}),
+		},
+		{
+			Name:  "FirstPage",
+			Query: "?limit=2",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results", expectedLists[:2]).
+				JSONPath("page", web.Page{Limit: 2, Offset: 0, Total: len(expectedLists)}),
+		},
+		{
+			Name:  "LastPage",
+			Query: "?limit=2&offset=2",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results", expectedLists[2:]).
+				JSONPath("page", web.Page{Limit: 2, Offset: 2, Total: len(expectedLists)}),
+		},
+		{
+			Name:     "LimitAboveMax",
+			Query:    fmt.Sprintf("?limit=%d", web.MaxPageSize+1),
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "NegativeOffset",
+			Query:    "?offset=-1",
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, "/list"+test.Query, "", ""))
 		}
 
-		var lists []list.List
-		resp := web.Response{
-			Results: lists,
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_getListsSorted(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
 		}
+	}()
 
-		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-			t.Errorf("error decoding response body: %v", err)
+	for _, name := range []string{"Zucker", "Äpfel", "Birnen"} {
+		expect.Status(http.StatusCreated).Assert(t, serve(t, a, http.MethodPost, "/list", fmt.Sprintf(`{"name":%q}`, name), ""))
+	}
+
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected expect.Response
+	}{
+		{
+			Name:     "ByName",
+			Query:    "?sort=name&locale=de",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.0.name", "Äpfel").JSONPath("results.1.name", "Birnen").JSONPath("results.2.name", "Zucker"),
+		},
+		{
+			Name:     "ByNameDescending",
+			Query:    "?sort=-name&locale=de",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.0.name", "Zucker").JSONPath("results.2.name", "Äpfel"),
+		},
+		{
+			Name:     "RegionalLocale",
+			Query:    "?sort=name&locale=de-at",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.0.name", "Äpfel"),
+		},
+		{
+			Name:     "ByID",
+			Query:    "?sort=-id",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.0.name", "Birnen").JSONPath("results.2.name", "Zucker"),
+		},
+		{
+			Name:     "UnknownLocale",
+			Query:    "?sort=name&locale=qq",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.LocaleUnsupported),
+		},
+		{
+			Name:     "MalformedLocale",
+			Query:    "?sort=name&locale=de_DE.UTF-8",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.LocaleUnsupported),
+		},
+		{
+			Name:     "UnknownSort",
+			Query:    "?sort=created",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.QueryOneOfInvalid),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, "/list"+test.Query, "", ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_getListsByIDs(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
 		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected expect.Response
+	}{
+		{
+			Name:     "RequestOrder",
+			Query:    fmt.Sprintf("?ids=%d,%d", lists[2].ID, lists[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", []list.List{lists[2], lists[0]}).JSONPath("page", nil),
+		},
+		{
+			Name:     "Repeated",
+			Query:    fmt.Sprintf("?ids=%d,%d,%d", lists[1].ID, lists[1].ID, lists[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", []list.List{lists[1], lists[0]}).JSONPath("page", nil),
+		},
+		{
+			Name: "NotFound",
+			// Using 0 for the list id because postgres serial type starts at 1 so 0 will never exist.
+			Query:    fmt.Sprintf("?ids=%d,0", lists[0].ID),
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "Malformed",
+			Query:    "?ids=1,two",
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "Empty",
+			Query:    "?ids=",
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "TooMany",
+			Query:    "?ids=" + strings.Repeat("1,", 100) + "1",
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+	}
 
-		if d := cmp.Diff(expectedLists, lists); d != "" {
-			t.Errorf("unexpected difference in response body:\n%v", d)
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodGet, "/list"+test.Query, "", ""))
 		}
+
+		t.Run(test.Name, fn)
 	}
 }
 
 func Test_createList(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -91,77 +236,142 @@ func Test_createList(t *testing.T) {
 	}()
 
 	tests := []struct {
-		Name         string
-		RequestBody  list.List
-		ExpectedCode int
+		Name     string
+		Body     string
+		Expected expect.Response
 	}{
 		{
 			Name: "OK",
-			RequestBody: list.List{
-				Name: "Foo",
-			},
-			ExpectedCode: http.StatusCreated,
+			Body: `{"name":"Foo"}`,
+			Expected: expect.Status(http.StatusCreated).
+				JSONPath("results.name", "Foo").
+				HeaderSet("Location").
+				NonZero("results.url"),
 		},
 		{
 			Name: "BreakUniqueNameConstraint",
-			RequestBody: list.List{
-				Name: "Foo",
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Body: `{"name":"Foo"}`,
+			// The conflict carries the id of the list holding the name.
+			Expected: expect.Status(http.StatusConflict).NonZero("results.id"),
+		},
+		{
+			Name:     "BreakUniqueNameConstraintCase",
+			Body:     `{"name":"FOO"}`,
+			Expected: expect.Status(http.StatusConflict).NonZero("results.id"),
+		},
+		{
+			Name:     "NoName",
+			Body:     `{}`,
+			Expected: expect.Status(http.StatusBadRequest),
 		},
 		{
-			Name:         "NoName",
-			RequestBody:  list.List{},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "InvisibleCharacters",
+			Body:     `{"name":"Foo\u200bBar"}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity),
+		},
+		{
+			Name:     "TooLong",
+			Body:     fmt.Sprintf(`{"name":%q}`, strings.Repeat("a", 256)),
+			Expected: expect.Status(http.StatusUnprocessableEntity),
+		},
+		{
+			Name: "IconAndColor",
+			Body: "{\"name\":\"Bar\",\"icon\":\"\U0001F6D2\",\"color\":\" #FF8800 \"}",
+			Expected: expect.Status(http.StatusCreated).
+				JSONPath("results.icon", "\U0001F6D2").
+				JSONPath("results.color", "#ff8800"),
+		},
+		{
+			Name:     "InvalidIconAndColor",
+			Body:     `{"name":"Baz","icon":"cart","color":"orange"}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity).Errors(2).JSONPath("errors.0.code", codes.ListIconInvalid).JSONPath("errors.1.code", codes.ListColorInvalid),
+		},
+		{
+			Name:     "PayloadTooLarge",
+			Body:     fmt.Sprintf(`{"name":%q}`, strings.Repeat("a", web.DefaultMaxBodySize)),
+			Expected: expect.Status(http.StatusRequestEntityTooLarge),
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			var b bytes.Buffer
-			if err := json.NewEncoder(&b).Encode(test.RequestBody); err != nil {
-				t.Errorf("error encoding request body: %v", err)
-			}
+			test.Expected.Assert(t, serve(t, a, http.MethodPost, "/list", test.Body, ""))
+		}
 
-			req, err := http.NewRequest(http.MethodPost, "/list", &b)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
-			}
+		t.Run(test.Name, fn)
+	}
+}
 
-			defer func() {
-				if err := req.Body.Close(); err != nil {
-					t.Errorf("error encountered closing request body: %v", err)
-				}
-			}()
+func Test_createListConcurrently(t *testing.T) {
+	defer checkDBConnections(t)
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+	// Every creator posts the same name, in one case or another, at the same time. Exactly
+	// one of them gets to create the list, the others are told its ID.
+	const creators = 40
 
-			if test.ExpectedCode != http.StatusBadRequest {
-				var l list.List
-				resp := web.Response{
-					Results: l,
-				}
+	responses := make([]*httptest.ResponseRecorder, creators)
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range responses {
+		name := "Grocery"
+		if i%2 == 1 {
+			name = "grocery"
+		}
 
-				if e, a := test.RequestBody.Name, l.Name; e != a {
-					t.Errorf("expected list name: %v, got list name: %v", e, a)
-				}
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			<-start
+
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/list", strings.NewReader(fmt.Sprintf(`{"name":%q}`, name))))
+			responses[i] = w
+		}(i, name)
+	}
+	close(start)
+	wg.Wait()
+
+	var winner int
+	for _, w := range responses {
+		if w.Code == http.StatusCreated {
+			if winner != 0 {
+				t.Fatalf("expected a single list to be created, got a second one: %s", w.Body.String())
 			}
+
+			expect.Status(http.StatusCreated).Into("results.id", &winner).Assert(t, w)
 		}
+	}
 
-		t.Run(test.Name, fn)
+	if winner == 0 {
+		t.Fatal("expected a list to be created, got none")
+	}
+
+	for _, w := range responses {
+		if w.Code != http.StatusCreated {
+			expect.Status(http.StatusConflict).JSONPath("results.id", winner).JSONPath("errors.0.code", codes.ListNameTaken).Assert(t, w)
+		}
+	}
+
+	lists, err := list.SelectLists(a.DB)
+	if err != nil {
+		t.Fatalf("error selecting lists: %v", err)
+	}
+
+	if e, a := 1, len(lists); e != a {
+		t.Errorf("expected %d list, got %d", e, a)
 	}
 }
 
 func Test_getList(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -174,23 +384,43 @@ func Test_getList(t *testing.T) {
 	}
 
 	tests := []struct {
-		Name         string
-		ListID       int
-		ExpectedBody list.List
-		ExpectedCode int
+		Name            string
+		ListID          int
+		IfModifiedSince string
+		Expected        expect.Response
 	}{
 		{
-			Name:         "OK",
-			ListID:       expectedLists[0].ID,
-			ExpectedBody: expectedLists[0],
-			ExpectedCode: http.StatusOK,
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			Expected: expect.Status(http.StatusOK).HeaderSet("Last-Modified").JSONPath("results", expectedLists[0]),
+		},
+		{
+			Name:   "DeprecatedItemCount",
+			ListID: expectedLists[0].ID,
+			Expected: expect.Status(http.StatusOK).
+				Header("Deprecation", "@1792108800").
+				Header("Sunset", "Fri, 30 Apr 2027 00:00:00 GMT").
+				JSONPath("results.item_count", expectedLists[0].ItemCount).
+				JSONPath("results.itemCount", expectedLists[0].ItemCount).
+				JSONPath("deprecations.0.replacement", "itemCount"),
+		},
+		{
+			Name:            "NotModified",
+			ListID:          expectedLists[0].ID,
+			IfModifiedSince: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			Expected:        expect.Status(http.StatusNotModified).HeaderSet("Last-Modified"),
+		},
+		{
+			Name:            "Modified",
+			ListID:          expectedLists[0].ID,
+			IfModifiedSince: "Mon, 01 Jan 2018 00:00:00 GMT",
+			Expected:        expect.Status(http.StatusOK).HeaderSet("Last-Modified").JSONPath("results", expectedLists[0]),
 		},
 		{
 			Name: "NotFound",
 			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID:       0,
-			ExpectedBody: list.List{},
-			ExpectedCode: http.StatusNotFound,
+			ListID:   0,
+			Expected: expect.Status(http.StatusNotFound),
 		},
 	}
 
@@ -201,34 +431,115 @@ func Test_getList(t *testing.T) {
 				t.Errorf("error creating request: %v", err)
 			}
 
+			if test.IfModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", test.IfModifiedSince)
+			}
+
 			w := httptest.NewRecorder()
 			a.ServeHTTP(w, req)
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+			test.Expected.Assert(t, w)
+		}
 
-			if test.ExpectedCode != http.StatusNotFound {
-				var l list.List
-				resp := web.Response{
-					Results: l,
-				}
+		t.Run(test.Name, fn)
+	}
+}
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+func Test_updateList(t *testing.T) {
+	defer checkDBConnections(t)
 
-				if d := cmp.Diff(test.ExpectedBody, l); d != "" {
-					t.Errorf("unexpected difference in response body:\n%v", d)
-				}
-			}
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	expectedLists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	tests := []struct {
+		Name     string
+		ListID   int
+		Body     string
+		Expected expect.Response
+	}{
+		{
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Foo"}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.name", "Foo"),
+		},
+		{
+			Name:     "IconAndColor",
+			ListID:   expectedLists[0].ID,
+			Body:     "{\"name\":\"Foo\",\"icon\":\"\U0001F44D\U0001F3FD\",\"color\":\"#00aa55\"}",
+			Expected: expect.Status(http.StatusOK).JSONPath("results.icon", "\U0001F44D\U0001F3FD").JSONPath("results.color", "#00aa55"),
+		},
+		{
+			Name:     "KeepIconAndColor",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Foo"}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.icon", "\U0001F44D\U0001F3FD").JSONPath("results.color", "#00aa55"),
+		},
+		{
+			Name:     "RemoveIconAndColor",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"Foo","icon":"","color":""}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.icon", "").JSONPath("results.color", ""),
+		},
+		{
+			Name:     "InvalidIcon",
+			ListID:   expectedLists[0].ID,
+			Body:     "{\"name\":\"Foo\",\"icon\":\"\U0001F6D2\U0001F34E\"}",
+			Expected: expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.field", "icon"),
+		},
+		{
+			Name:     "BreakUniqueNameConstraint",
+			ListID:   expectedLists[1].ID,
+			Body:     `{"name":"Foo"}`,
+			Expected: expect.Status(http.StatusConflict),
+		},
+		{
+			Name:     "BreakUniqueNameConstraintCase",
+			ListID:   expectedLists[1].ID,
+			Body:     `{"name":"foo"}`,
+			Expected: expect.Status(http.StatusConflict),
+		},
+		{
+			Name:     "RenameCase",
+			ListID:   expectedLists[0].ID,
+			Body:     `{"name":"FOO"}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.name", "FOO"),
+		},
+		{
+			Name:     "NoName",
+			ListID:   expectedLists[0].ID,
+			Body:     `{}`,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name: "NotFound",
+			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
+			ListID:   0,
+			Body:     `{"name":"Bar"}`,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("/list/%d", test.ListID), test.Body, ""))
 		}
 
 		t.Run(test.Name, fn)
 	}
 }
 
-func Test_updateList(t *testing.T) {
+func Test_deleteList(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
@@ -241,134 +552,318 @@ func Test_updateList(t *testing.T) {
 	}
 
 	tests := []struct {
-		Name         string
-		ListID       int
-		RequestBody  list.List
-		ExpectedCode int
+		Name     string
+		ListID   int
+		Query    string
+		Prefer   string
+		Expected expect.Response
 	}{
 		{
-			Name:   "OK",
-			ListID: expectedLists[0].ID,
-			RequestBody: list.List{
-				Name: "Foo",
-			},
-			ExpectedCode: http.StatusOK,
+			Name:     "OK",
+			ListID:   expectedLists[0].ID,
+			Expected: expect.Status(http.StatusNoContent),
+		},
+		{
+			Name: "NotFound",
+			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
+			ListID:   0,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+		{
+			Name:     "Representation",
+			ListID:   expectedLists[1].ID,
+			Query:    "?return=representation",
+			Expected: expect.Status(http.StatusOK).JSONPath("results", expectedLists[1]),
 		},
 		{
-			Name:   "BreakUniqueNameConstraint",
-			ListID: expectedLists[1].ID,
-			RequestBody: list.List{
-				Name: "Foo",
-			},
-			ExpectedCode: http.StatusBadRequest,
+			Name:     "PreferRepresentation",
+			ListID:   expectedLists[2].ID,
+			Prefer:   "return=representation",
+			Expected: expect.Status(http.StatusOK).JSONPath("results", expectedLists[2]),
 		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodDelete, fmt.Sprintf("/list/%d%s", test.ListID, test.Query), "", test.Prefer))
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_deleteLists(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	// The seeded items belong to the first two lists.
+	lists[0].ItemCount = 2
+	lists[1].ItemCount = 1
+
+	type deletion struct {
+		Lists []list.List `json:"lists"`
+		Items int         `json:"items"`
+	}
+
+	tests := []struct {
+		Name              string
+		Query             string
+		Body              string
+		Expected          expect.Response
+		ExpectedRemaining int
+	}{
 		{
-			Name:         "NoName",
-			ListID:       expectedLists[0].ID,
-			RequestBody:  list.List{},
-			ExpectedCode: http.StatusBadRequest,
+			Name:              "DryRun",
+			Query:             "?dry_run=true",
+			Body:              fmt.Sprintf("[%d,%d]", lists[1].ID, lists[0].ID),
+			Expected:          expect.Status(http.StatusOK).JSONPath("results", deletion{Lists: lists[:2], Items: 3}),
+			ExpectedRemaining: 3,
 		},
 		{
 			Name: "NotFound",
-			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID: 0,
-			RequestBody: list.List{
-				Name: "Bar",
-			},
-			ExpectedCode: http.StatusNotFound,
+			// Using 0 because postgres serial type starts at 1 so 0 will never exist.
+			Body:              fmt.Sprintf("[%d,0]", lists[0].ID),
+			Expected:          expect.Status(http.StatusNotFound),
+			ExpectedRemaining: 3,
+		},
+		{
+			Name:              "Empty",
+			Body:              "[]",
+			Expected:          expect.Status(http.StatusBadRequest),
+			ExpectedRemaining: 3,
+		},
+		{
+			Name:              "InvalidPayload",
+			Body:              `{"id":1}`,
+			Expected:          expect.Status(http.StatusBadRequest),
+			ExpectedRemaining: 3,
+		},
+		{
+			Name:              "OK",
+			Body:              fmt.Sprintf("[%d,%d,%d]", lists[0].ID, lists[1].ID, lists[0].ID),
+			Expected:          expect.Status(http.StatusOK).JSONPath("results", deletion{Lists: lists[:2], Items: 3}),
+			ExpectedRemaining: 1,
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			var b bytes.Buffer
-			if err := json.NewEncoder(&b).Encode(test.RequestBody); err != nil {
-				t.Errorf("error encoding request body: %v", err)
-			}
+			test.Expected.Assert(t, serve(t, a, http.MethodDelete, "/list"+test.Query, test.Body, ""))
 
-			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/list/%d", test.ListID), &b)
+			remaining, err := list.SelectLists(a.DB)
 			if err != nil {
-				t.Errorf("error creating request: %v", err)
+				t.Fatalf("error selecting lists: %v", err)
 			}
 
-			defer func() {
-				if err := req.Body.Close(); err != nil {
-					t.Errorf("error encountered closing request body: %v", err)
-				}
-			}()
+			if e, a := test.ExpectedRemaining, len(remaining); e != a {
+				t.Errorf("expected %d lists to remain, got %d", e, a)
+			}
+		}
 
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_getListFeed(t *testing.T) {
+	defer checkDBConnections(t)
 
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	// The feed is built from the changes made through the API, so the list and its
+	// items are created through it rather than seeded.
+	var l list.List
+	for _, step := range []struct {
+		Path string
+		Body string
+	}{
+		{Path: "/list", Body: `{"name":"Feed"}`},
+		{Path: "/list/%d/item", Body: `{"name":"Milk","quantity":2}`},
+		{Path: "/list/%d/item", Body: `{"name":"Eggs","quantity":12}`},
+	} {
+		path, expected := step.Path, expect.Status(http.StatusCreated)
+		if l.ID == 0 {
+			expected = expected.Into("results", &l)
+		} else {
+			path = fmt.Sprintf(path, l.ID)
+		}
+
+		expected.Assert(t, serve(t, a, http.MethodPost, path, step.Body, ""))
+		if t.Failed() {
+			t.FailNow()
+		}
+	}
+
+	tests := []struct {
+		Name           string
+		ListID         int
+		Expected       expect.Response
+		ExpectedTitles []string
+	}{
+		{
+			Name:           "OK",
+			ListID:         l.ID,
+			Expected:       expect.Status(http.StatusOK).Header("Content-Type", "application/atom+xml; charset=utf-8"),
+			ExpectedTitles: []string{`Item "Eggs" created`, `Item "Milk" created`, `List "Feed" created`},
+		},
+		{
+			Name:     "NotFound",
+			ListID:   l.ID + 1,
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d/feed.atom", test.ListID), "", "")
+			test.Expected.Assert(t, w)
+
+			if w.Code != http.StatusOK {
+				return
 			}
 
-			if test.ExpectedCode == http.StatusOK {
-				var l list.List
-				resp := web.Response{
-					Results: l,
-				}
+			var feed struct {
+				Title   string `xml:"title"`
+				Entries []struct {
+					ID    string `xml:"id"`
+					Title string `xml:"title"`
+				} `xml:"entry"`
+			}
 
-				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-					t.Errorf("error decoding response body: %v", err)
-				}
+			if err := xml.NewDecoder(w.Body).Decode(&feed); err != nil {
+				t.Fatalf("error decoding feed: %v", err)
+			}
+
+			if e, a := l.Name, feed.Title; e != a {
+				t.Errorf("expected feed title: %v, got feed title: %v", e, a)
+			}
 
-				if e, a := test.RequestBody.Name, l.Name; e != a {
-					t.Errorf("expected list name: %v, got list name: %v", e, a)
+			var titles []string
+			for _, entry := range feed.Entries {
+				titles = append(titles, entry.Title)
+
+				if !strings.HasPrefix(entry.ID, "urn:uuid:") {
+					t.Errorf("expected entry id to be a uuid URN, got: %v", entry.ID)
 				}
 			}
+
+			if d := cmp.Diff(test.ExpectedTitles, titles); d != "" {
+				t.Errorf("unexpected difference in feed entries:\n%v", d)
+			}
 		}
 
 		t.Run(test.Name, fn)
 	}
 }
 
-func Test_deleteList(t *testing.T) {
+func Test_listSettings(t *testing.T) {
+	defer checkDBConnections(t)
+
 	defer func() {
 		if err := testdb.Truncate(a.DB); err != nil {
 			t.Errorf("error truncating test database tables: %v", err)
 		}
 	}()
 
-	expectedLists, err := testdb.SeedLists(a.DB)
+	lists, err := testdb.SeedLists(a.DB)
 	if err != nil {
 		t.Fatalf("error seeding lists: %v", err)
 	}
 
+	updated := list.Settings{Sort: "-name", HideCompleted: true, Color: "#FF8800"}
+
 	tests := []struct {
-		Name         string
-		ListID       int
-		ExpectedCode int
+		Name     string
+		Method   string
+		Path     string
+		Body     string
+		Expected expect.Response
 	}{
 		{
-			Name:         "OK",
-			ListID:       expectedLists[0].ID,
-			ExpectedCode: http.StatusNoContent,
+			Name:     "Default",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", list.DefaultSettings).Errors(0),
 		},
 		{
-			Name: "NotFound",
-			// Using 0 for ListID because postgres serial type starts at 1 so 0 will never exist.
-			ListID:       0,
-			ExpectedCode: http.StatusNotFound,
+			Name:     "Update",
+			Method:   http.MethodPut,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[0].ID),
+			Body:     `{"sort":"-name","hide_completed":true,"color":"#FF8800","notify":false}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results", updated).Errors(0),
+		},
+		{
+			Name:     "Updated",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[0].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", updated).Errors(0),
+		},
+		{
+			Name:     "OtherListUnchanged",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[1].ID),
+			Expected: expect.Status(http.StatusOK).JSONPath("results", list.DefaultSettings).Errors(0),
+		},
+		{
+			Name:     "Invalid",
+			Method:   http.MethodPut,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[0].ID),
+			Body:     `{"sort":"color","color":"orange"}`,
+			Expected: expect.Status(http.StatusBadRequest).Errors(2).JSONPath("errors.0.field", "sort").Len("errors.0.allowed", 8),
+		},
+		{
+			Name:     "SortSpelling",
+			Method:   http.MethodPut,
+			Path:     fmt.Sprintf("/list/%d/settings", lists[1].ID),
+			Body:     `{"sort":"-Modified"}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.sort", "-modified"),
+		},
+		{
+			Name:   "NotFound",
+			Method: http.MethodPut,
+			// Using 0 for the list id because postgres serial type starts at 1 so 0 will never exist.
+			Path:     "/list/0/settings",
+			Body:     `{"sort":"name"}`,
+			Expected: expect.Status(http.StatusNotFound).Errors(1),
+		},
+		{
+			Name:     "EmbedUnknown",
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/list/%d?embed=items", lists[0].ID),
+			Expected: expect.Status(http.StatusBadRequest).Errors(1),
 		},
 	}
 
 	for _, test := range tests {
 		fn := func(t *testing.T) {
-			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/list/%d", test.ListID), nil)
-			if err != nil {
-				t.Errorf("error creating request: %v", err)
-			}
-
-			w := httptest.NewRecorder()
-			a.ServeHTTP(w, req)
-
-			if e, a := test.ExpectedCode, w.Code; e != a {
-				t.Errorf("expected status code: %v, got status code: %v", e, a)
-			}
+			test.Expected.Assert(t, serve(t, a, test.Method, test.Path, test.Body, ""))
 		}
 
 		t.Run(test.Name, fn)
 	}
+
+	t.Run("Embedded", func(t *testing.T) {
+		expect.Status(http.StatusOK).
+			JSONPath("results.id", lists[0].ID).
+			JSONPath("results.name", lists[0].Name).
+			JSONPath("results.settings", updated).
+			Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d?embed=settings", lists[0].ID), "", ""))
+	})
 }
@@ -1,14 +1,27 @@
 package tests
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/apptest"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/leaktest"
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
 	log "github.com/sirupsen/logrus"
 )
 
+// reportEnv names the environment variable holding the directory a report of the calls
+// made by the suite is written to, see package apptest. The suite isn't recorded without
+// it.
+const reportEnv = "LIST_TEST_REPORT"
+
 // a is a reference to the main Application type. This is used for its database
 // connection that it harbours inside of the type as well as the route definitions
 // that are defined on the embedded handler.
@@ -23,16 +36,89 @@ func TestMain(m *testing.M) {
 
 // testMain returns an integer denoting an exit code to be returned and used in
 // TestMain. The exit code 0 denotes success, all other codes denote failure (1
-// and 2).
+// and 2). A passing suite is still failed if it leaked database connections or
+// goroutines.
 func testMain(m *testing.M) int {
-	dbc, err := testdb.Open()
+	dbc, err := testdb.Open(log.StandardLogger())
 	if err != nil {
 		log.WithError(err).Info("create test database connection")
 		return 1
 	}
-	defer dbc.Close()
 
-	a = handlers.NewApplication(dbc)
+	feats, err := features.New(nil)
+	if err != nil {
+		log.WithError(err).Info("create feature flags")
+		return 1
+	}
+
+	a = handlers.NewApplication(dbc, log.StandardLogger(), feats)
+	a.Units = config.Default().ItemUnits
+
+	var calls apptest.Recorder
+	dir := os.Getenv(reportEnv)
+	if dir != "" {
+		a.Observe = func(r *http.Request, route handlers.Route, status int, took time.Duration) {
+			calls.Record(apptest.Call{Method: r.Method, Path: r.URL.Path, Route: route.Path, Status: status, Duration: took})
+		}
+	}
+
+	code := m.Run()
+
+	if dir != "" {
+		routes := make([]string, 0, len(a.Routes()))
+		for _, route := range a.Routes() {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+
+		if err := calls.Report(routes).WriteFiles(dir); err != nil {
+			log.WithError(err).Error("write report of the calls made by the suite")
+			code = 1
+		}
+	}
+
+	if err := leaktest.DBConnections(dbc.DB); err != nil {
+		log.WithError(err).Error("check for leaked database connections, re-run with -run to narrow down the leaking test")
+		code = 1
+	}
+
+	if err := dbc.Close(); err != nil {
+		log.WithError(err).Error("close test database connection")
+		code = 1
+	}
+
+	// The database has to be closed before checking for leaked goroutines since the
+	// connection pool manages its own goroutines.
+	if err := leaktest.Goroutines(); err != nil {
+		log.WithError(err).Error("check for leaked goroutines")
+		code = 1
+	}
+
+	return code
+}
+
+// checkDBConnections fails the calling test if it leaves any database connections in
+// use, e.g. through an unclosed *sql.Rows. It is meant to be deferred at the very start
+// of a test so it runs after every other deferred cleanup of that test.
+func checkDBConnections(t *testing.T) {
+	if err := leaktest.DBConnections(a.DB.DB); err != nil {
+		t.Errorf("%s leaked database connections: %v", t.Name(), err)
+	}
+}
+
+// serve makes a request with the given method, path, and body against h, preferring
+// prefer if it isn't empty.
+func serve(t testing.TB, h http.Handler, method, path, body, prefer string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
 
-	return m.Run()
+	return w
 }
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_queryPlans(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	var logs bytes.Buffer
+
+	log := logrus.New()
+	log.Out = &logs
+	log.Level = logrus.DebugLevel
+	log.Formatter = &logrus.JSONFormatter{}
+
+	feats, err := features.New(nil)
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	// Every request is slow by this threshold.
+	traced := handlers.NewApplication(a.DB, log, feats)
+	traced.SlowRequest = time.Nanosecond
+
+	path := fmt.Sprintf("/list/%d", lists[0].ID)
+
+	expect.Status(http.StatusOK).Assert(t, serve(t, traced, http.MethodGet, path, "", ""))
+	if strings.Contains(logs.String(), `"plan"`) {
+		t.Errorf("expected no plan to be logged while the feature is disabled, got %s", logs.String())
+	}
+
+	if _, err := feats.Set(features.QueryPlans, true); err != nil {
+		t.Fatalf("error enabling query plans: %v", err)
+	}
+
+	logs.Reset()
+	w := serve(t, traced, http.MethodGet, path, "", "")
+	expect.Status(http.StatusOK).Assert(t, w)
+
+	var entry string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"msg":"slow request"`) {
+			entry = line
+		}
+	}
+
+	if entry == "" {
+		t.Fatalf("expected the plan of the slow request to be logged, got %s", logs.String())
+	}
+
+	for _, want := range []string{`"plan":"`, `"query":"SELECT`, `"requestID":"` + w.Header().Get("X-Request-Id") + `"`} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("expected %s in the logged plan, got %s", want, entry)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Test_proto checks the gRPC contract in api/proto against the REST API without protoc:
+// every rpc has to name a route that is served by its google.api.http option, and the
+// fields of List and Item have to be documented in the OpenAPI document.
+func Test_proto(t *testing.T) {
+	defer checkDBConnections(t)
+
+	b, err := ioutil.ReadFile("../../../api/proto/list/v1/list.proto")
+	if err != nil {
+		t.Fatalf("error reading protobuf definition: %v", err)
+	}
+	proto := string(b)
+
+	// Path parameters are named after fields in the definition and after URL parameters
+	// in httprouter, so only their positions are compared.
+	param := regexp.MustCompile(`{\w+}|:\w+`)
+
+	registered := make(map[string]bool)
+	for _, r := range a.Routes() {
+		registered[r.Method+" "+param.ReplaceAllString(r.Path, ":")] = true
+	}
+
+	rpcs := regexp.MustCompile(`rpc (\w+)\(`).FindAllStringSubmatch(proto, -1)
+	routes := regexp.MustCompile(`rpc (\w+)\([^)]*\) returns \([^)]*\) {\s*option \(google\.api\.http\) = {\s*(\w+): "([^"]+)"`).FindAllStringSubmatch(proto, -1)
+
+	if e, a := len(rpcs), len(routes); e != a {
+		t.Errorf("expected every one of %d rpcs to name its route, got %d", e, a)
+	}
+
+	for _, m := range routes {
+		route := strings.ToUpper(m[2]) + " " + param.ReplaceAllString(m[3], ":")
+		if !registered[route] {
+			t.Errorf("expected the route of rpc %s to be served: %s %s", m[1], strings.ToUpper(m[2]), m[3])
+		}
+	}
+
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(handlers.OpenAPI(), &doc); err != nil {
+		t.Fatalf("error decoding OpenAPI document: %v", err)
+	}
+
+	// Fields are compared regardless of their case and underscores, since JSON names them
+	// listID where protobuf names them list_id.
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	}
+
+	field := regexp.MustCompile(`(?m)^\s+(?:repeated )?[\w.]+ (\w+) = \d+;`)
+
+	for _, message := range []string{"List", "Item"} {
+		m := regexp.MustCompile(`(?s)message ` + message + ` {(.*?)}`).FindStringSubmatch(proto)
+		if m == nil {
+			t.Errorf("expected message %s in the protobuf definition", message)
+			continue
+		}
+
+		var defined []string
+		for _, f := range field.FindAllStringSubmatch(m[1], -1) {
+			defined = append(defined, normalize(f[1]))
+		}
+
+		documented := make(map[string]bool)
+		for name := range doc.Components.Schemas[message].Properties {
+			documented[normalize(name)] = true
+		}
+
+		var missing []string
+		for _, name := range defined {
+			if !documented[name] {
+				missing = append(missing, name)
+			}
+		}
+
+		sort.Strings(missing)
+		if d := cmp.Diff([]string(nil), missing); d != "" {
+			t.Errorf("unexpected fields of message %s missing from the OpenAPI schema:\n%v", message, d)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/ratelimit"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_publicRead(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	public := handlers.NewApplication(a.DB, a.Log, a.Features)
+	public.CORS = web.CORS{Origins: []string{"https://lists.example.com"}}
+
+	sharePath := fmt.Sprintf("/list/%d/share", lists[0].ID)
+
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodGet, sharePath, "", ""))
+
+	var token, path string
+	expect.Status(http.StatusCreated).
+		JSONPath("results.listID", lists[0].ID).
+		Into("results.token", &token).
+		Into("results.path", &path).
+		Assert(t, serve(t, a, http.MethodPut, sharePath, "", ""))
+
+	if e, a := "/shared/"+token, path; e != a {
+		t.Errorf("expected path: %v, got path: %v", e, a)
+	}
+
+	// Sharing a list again keeps its token.
+	expect.Status(http.StatusOK).JSONPath("results.token", token).Assert(t, serve(t, a, http.MethodPut, sharePath, "", ""))
+	expect.Status(http.StatusOK).JSONPath("results.token", token).Assert(t, serve(t, a, http.MethodGet, sharePath, "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("/list/%d/share", lists[2].ID+100), "", ""))
+
+	// The list is only read through the public read API.
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodGet, path, "", ""))
+
+	// Templates are published once they are saved.
+	var templateID int
+	expect.Status(http.StatusCreated).
+		JSONPath("results.public", false).
+		Into("results.id", &templateID).
+		Assert(t, serve(t, a, http.MethodPost, fmt.Sprintf("/list/%d/save-template", lists[1].ID), "", ""))
+
+	templatePath := fmt.Sprintf("/templates/%d", templateID)
+
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, public.PublicRead(), http.MethodGet, templatePath, "", ""))
+	expect.Status(http.StatusOK).Len("results", 0).Assert(t, serve(t, public.PublicRead(), http.MethodGet, "/templates", "", ""))
+
+	expect.Status(http.StatusOK).JSONPath("results.public", true).Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("/template/%d/public", templateID), "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("/template/%d/public", templateID+100), "", ""))
+
+	// request makes a request against the public read API from the given origin.
+	request := func(method, path, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		w := httptest.NewRecorder()
+		public.PublicRead().ServeHTTP(w, r)
+
+		return w
+	}
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Path     string
+		Origin   string
+		Expected expect.Response
+	}{
+		{
+			Name:   "SharedList",
+			Method: http.MethodGet,
+			Path:   path,
+			Origin: "https://lists.example.com",
+			Expected: expect.Status(http.StatusOK).
+				JSONPath("results.name", "Grocery").
+				JSONPath("results.items.0.name", "Chocolate Milk").
+				JSONPath("results.items.1.quantity", 2).
+				Len("results.items", 2).
+				Header("Access-Control-Allow-Origin", "https://lists.example.com"),
+		},
+		{
+			Name:     "UnknownToken",
+			Method:   http.MethodGet,
+			Path:     "/shared/nope",
+			Expected: expect.Status(http.StatusNotFound).JSONPath("errors.0.code", codes.NotFound),
+		},
+		{
+			Name:     "OtherOrigin",
+			Method:   http.MethodGet,
+			Path:     path,
+			Origin:   "https://example.com",
+			Expected: expect.Status(http.StatusOK).Header("Access-Control-Allow-Origin", ""),
+		},
+		{
+			Name:     "PublishedTemplate",
+			Method:   http.MethodGet,
+			Path:     templatePath,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.name", "To-do").Len("results.items", 1),
+		},
+		{
+			Name:     "PublishedTemplates",
+			Method:   http.MethodGet,
+			Path:     "/templates",
+			Expected: expect.Status(http.StatusOK).Len("results", 1).JSONPath("results.0.id", templateID),
+		},
+		{
+			Name:     "Write",
+			Method:   http.MethodDelete,
+			Path:     path,
+			Expected: expect.Status(http.StatusMethodNotAllowed).Header("Allow", "GET, OPTIONS"),
+		},
+		{
+			Name:     "AuthenticatedRoute",
+			Method:   http.MethodGet,
+			Path:     "/list",
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, request(test.Method, test.Path, test.Origin))
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	t.Run("Preflight", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, path, nil)
+		r.Header.Set("Origin", "https://lists.example.com")
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+		w := httptest.NewRecorder()
+		public.PublicRead().ServeHTTP(w, r)
+
+		expect.Status(http.StatusNoContent).
+			Header("Access-Control-Allow-Origin", "https://lists.example.com").
+			Header("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS").
+			Assert(t, w)
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		limited := handlers.NewApplication(a.DB, a.Log, a.Features)
+		limited.PublicReadLimit = ratelimit.New(60, 1)
+
+		expect.Status(http.StatusOK).Assert(t, serve(t, limited.PublicRead(), http.MethodGet, path, "", ""))
+		expect.Status(http.StatusTooManyRequests).
+			JSONPath("errors.0.code", codes.RateLimited).
+			Header("Retry-After", "1").
+			Assert(t, serve(t, limited.PublicRead(), http.MethodGet, path, "", ""))
+	})
+
+	t.Run("TokenRateLimited", func(t *testing.T) {
+		limited := handlers.NewApplication(a.DB, a.Log, a.Features)
+		limited.SharedLimit = ratelimit.New(60, 1)
+
+		expect.Status(http.StatusOK).Assert(t, serve(t, limited.PublicRead(), http.MethodGet, path, "", ""))
+		expect.Status(http.StatusTooManyRequests).
+			JSONPath("errors.0.code", codes.RateLimited).
+			Header("Retry-After", "1").
+			Assert(t, serve(t, limited.PublicRead(), http.MethodGet, path, "", ""))
+		expect.Status(http.StatusNotFound).Assert(t, serve(t, limited.PublicRead(), http.MethodGet, "/shared/nope", "", ""))
+	})
+
+	// Operators see every active share.
+	expect.Status(http.StatusOK).
+		Len("results", 1).
+		JSONPath("results.0.listID", lists[0].ID).
+		JSONPath("results.0.token", token).
+		Assert(t, serve(t, a, http.MethodGet, "/admin/shares", "", ""))
+
+	// Once revoked or withdrawn, lists and templates are no longer read.
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, a, http.MethodDelete, sharePath, "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodDelete, sharePath, "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, request(http.MethodGet, path, ""))
+
+	expect.Status(http.StatusOK).JSONPath("results.public", false).Assert(t, serve(t, a, http.MethodDelete, fmt.Sprintf("/template/%d/public", templateID), "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, request(http.MethodGet, templatePath, ""))
+
+	// Sharing the list again hands out a new token.
+	var again string
+	expect.Status(http.StatusCreated).Into("results.token", &again).Assert(t, serve(t, a, http.MethodPut, sharePath, "", ""))
+
+	if again == token {
+		t.Error("expected a new token once the list is shared again")
+	}
+
+	// Once expired, the share is no longer read, and sharing the list again hands out a
+	// new token.
+	if _, err := a.DB.Exec("UPDATE list_share SET expires = $1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("error expiring share: %v", err)
+	}
+
+	expect.Status(http.StatusNotFound).Assert(t, request(http.MethodGet, "/shared/"+again, ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodGet, sharePath, "", ""))
+	expect.Status(http.StatusOK).Len("results", 0).Assert(t, serve(t, a, http.MethodGet, "/admin/shares", "", ""))
+
+	var renewed string
+	expect.Status(http.StatusCreated).Into("results.token", &renewed).Assert(t, serve(t, a, http.MethodPut, sharePath, "", ""))
+
+	if renewed == again {
+		t.Error("expected a new token once the share expired")
+	}
+
+	// Operators revoke shares by their token.
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, a, http.MethodDelete, "/admin/shares/"+renewed, "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, serve(t, a, http.MethodDelete, "/admin/shares/"+renewed, "", ""))
+	expect.Status(http.StatusNotFound).Assert(t, request(http.MethodGet, "/shared/"+renewed, ""))
+}
+
+func Test_publicReadOpenAPI(t *testing.T) {
+	w := serve(t, a.PublicRead(), http.MethodGet, "/openapi.json", "", "")
+	expect.Status(http.StatusOK).Header("Content-Type", "application/json").Assert(t, w)
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("error decoding OpenAPI document: %v", err)
+	}
+
+	// Every path parameter is written as {name} in OpenAPI and as :name in httprouter.
+	param := regexp.MustCompile(`{([^}]+)}`)
+
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+
+			documented = append(documented, strings.ToUpper(method)+" "+param.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	var registered []string
+	for _, r := range a.PublicReadRoutes() {
+		registered = append(registered, r.Method+" "+r.Path)
+	}
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+
+	if d := cmp.Diff(registered, documented); d != "" {
+		t.Errorf("unexpected difference between registered and documented routes:\n%v", d)
+	}
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/quota"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+func Test_quota(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if _, err := quota.UpdateQuota(a.DB, quota.Quota{}); err != nil {
+			t.Errorf("error resetting quota: %v", err)
+		}
+
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	// Every seeded list is taken, along with a single item of the first one.
+	expect.Status(http.StatusOK).
+		JSONPath("results.max_lists", len(lists)).
+		JSONPath("results.max_items_per_list", 1).
+		Assert(t, serve(t, a.Admin(), http.MethodPut, "/admin/quota", fmt.Sprintf(`{"max_lists":%d,"max_items_per_list":1}`, len(lists)), ""))
+
+	itemPath := fmt.Sprintf("/list/%d/item", lists[0].ID)
+
+	tests := []struct {
+		Name     string
+		Handler  http.Handler
+		Method   string
+		Path     string
+		Body     string
+		Expected expect.Response
+	}{
+		{
+			Name:     "ListQuotaExceeded",
+			Handler:  a,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Beyond"}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.code", codes.ListQuotaExceeded),
+		},
+		{
+			Name:     "FirstItem",
+			Handler:  a,
+			Method:   http.MethodPost,
+			Path:     itemPath,
+			Body:     `{"name":"Milk","quantity":1}`,
+			Expected: expect.Status(http.StatusCreated),
+		},
+		{
+			Name:     "ItemQuotaExceeded",
+			Handler:  a,
+			Method:   http.MethodPost,
+			Path:     itemPath,
+			Body:     `{"name":"Eggs","quantity":1}`,
+			Expected: expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.code", codes.ItemQuotaExceeded),
+		},
+		{
+			// Merging into an existing item doesn't add one to the list.
+			Name:     "MergeWithinQuota",
+			Handler:  a,
+			Method:   http.MethodPost,
+			Path:     itemPath + "?merge=true",
+			Body:     `{"name":"Milk","quantity":1}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.quantity", 2),
+		},
+		{
+			Name:     "NegativeLimit",
+			Handler:  a.Admin(),
+			Method:   http.MethodPut,
+			Path:     "/admin/quota",
+			Body:     `{"max_lists":-1}`,
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.QuotaInvalid),
+		},
+		{
+			// Limits left out go back to the default, which is unlimited.
+			Name:     "Reset",
+			Handler:  a.Admin(),
+			Method:   http.MethodPut,
+			Path:     "/admin/quota",
+			Body:     `{}`,
+			Expected: expect.Status(http.StatusOK).JSONPath("results.max_lists", nil).JSONPath("results.default_max_lists", 0),
+		},
+		{
+			Name:     "ListWithinDefault",
+			Handler:  a,
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Body:     `{"name":"Beyond"}`,
+			Expected: expect.Status(http.StatusCreated),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, test.Handler, test.Method, test.Path, test.Body, ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func Test_quotaConcurrent(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if _, err := quota.UpdateQuota(a.DB, quota.Quota{}); err != nil {
+			t.Errorf("error resetting quota: %v", err)
+		}
+
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	const (
+		creators = 20
+		room     = 5
+	)
+
+	// The first list is empty, so there is room for as many lists as items on it.
+	expect.Status(http.StatusOK).Assert(t, serve(t, a.Admin(), http.MethodPut, "/admin/quota", fmt.Sprintf(`{"max_lists":%d,"max_items_per_list":%d}`, len(lists)+room, room), ""))
+
+	tests := []struct {
+		Name string
+		Path string
+		Body string
+		Code string
+	}{
+		{
+			Name: "Lists",
+			Path: "/list",
+			Body: `{"name":"Concurrent %d"}`,
+			Code: codes.ListQuotaExceeded,
+		},
+		{
+			Name: "Items",
+			Path: fmt.Sprintf("/list/%d/item", lists[0].ID),
+			Body: `{"name":"Concurrent %d","quantity":1}`,
+			Code: codes.ItemQuotaExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			// Every creator posts a name of its own at the same time, only as many of them as
+			// there is room for may succeed.
+			responses := make([]*httptest.ResponseRecorder, creators)
+
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := range responses {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+
+					body := fmt.Sprintf(test.Body, i)
+
+					w := httptest.NewRecorder()
+					a.ServeHTTP(w, httptest.NewRequest(http.MethodPost, test.Path, strings.NewReader(body)))
+					responses[i] = w
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			var created int
+			for _, w := range responses {
+				if w.Code == http.StatusCreated {
+					created++
+					continue
+				}
+
+				expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.code", test.Code).Assert(t, w)
+			}
+
+			if e, a := room, created; e != a {
+				t.Errorf("expected %d to be created, got %d", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
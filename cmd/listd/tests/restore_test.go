@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+func Test_restoreList(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	before := time.Now().Add(-time.Minute)
+
+	var listID, milkID, eggsID int
+	expect.Status(http.StatusCreated).Into("results.id", &listID).Assert(t, serve(t, a, http.MethodPost, "/list", `{"name":"Groceries"}`, ""))
+
+	listPath := fmt.Sprintf("/list/%d", listID)
+	expect.Status(http.StatusCreated).Into("results.id", &milkID).Assert(t, serve(t, a, http.MethodPost, listPath+"/item", `{"name":"Milk","quantity":1}`, ""))
+	expect.Status(http.StatusCreated).Into("results.id", &eggsID).Assert(t, serve(t, a, http.MethodPost, listPath+"/item", `{"name":"Eggs","quantity":12}`, ""))
+
+	// Events are stamped by the database clock, leave room on either side of the point
+	// that is restored to.
+	time.Sleep(time.Second)
+	at := url.QueryEscape(time.Now().Format(time.RFC3339Nano))
+	time.Sleep(time.Second)
+
+	expect.Status(http.StatusOK).Assert(t, serve(t, a, http.MethodPut, listPath, `{"name":"Errands"}`, ""))
+	expect.Status(http.StatusOK).Assert(t, serve(t, a, http.MethodPut, fmt.Sprintf("%s/item/%d", listPath, milkID), `{"name":"Milk","quantity":3}`, ""))
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, a, http.MethodDelete, fmt.Sprintf("%s/item/%d", listPath, eggsID), "", ""))
+	expect.Status(http.StatusNoContent).Assert(t, serve(t, a, http.MethodDelete, listPath, "", ""))
+
+	var restoredID int
+	tests := []struct {
+		Name     string
+		Path     string
+		Expected expect.Response
+	}{
+		{
+			Name: "PointInTime",
+			Path: listPath + "/restore?at=" + at,
+			Expected: expect.Status(http.StatusCreated).
+				HeaderSet("Location").
+				Into("results.id", &restoredID).
+				JSONPath("results.name", "Groceries (restored)").
+				JSONPath("results.item_count", 2),
+		},
+		{
+			Name:     "Named",
+			Path:     listPath + "/restore?name=Weekly&at=" + at,
+			Expected: expect.Status(http.StatusCreated).JSONPath("results.name", "Weekly"),
+		},
+		{
+			Name:     "NameTaken",
+			Path:     listPath + "/restore?name=Weekly&at=" + at,
+			Expected: expect.Status(http.StatusBadRequest),
+		},
+		{
+			Name:     "Deleted",
+			Path:     listPath + "/restore",
+			Expected: expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.code", codes.ListAbsent),
+		},
+		{
+			Name:     "BeforeCreation",
+			Path:     listPath + "/restore?at=" + url.QueryEscape(before.Format(time.RFC3339)),
+			Expected: expect.Status(http.StatusUnprocessableEntity).JSONPath("errors.0.code", codes.ListAbsent),
+		},
+		{
+			Name:     "InvalidTime",
+			Path:     listPath + "/restore?at=yesterday",
+			Expected: expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.QueryTimeInvalid),
+		},
+		{
+			Name:     "NotFound",
+			Path:     "/list/0/restore",
+			Expected: expect.Status(http.StatusNotFound),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			test.Expected.Assert(t, serve(t, a, http.MethodPost, test.Path, "", ""))
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// The items of the restored list are those of the list at the time, with the quantity
+	// they had then.
+	expect.Status(http.StatusOK).
+		JSONPath("results.0.name", "Milk").
+		JSONPath("results.0.quantity", 1).
+		JSONPath("results.1.name", "Eggs").
+		Assert(t, serve(t, a, http.MethodGet, fmt.Sprintf("/list/%d/item", restoredID), "", ""))
+}
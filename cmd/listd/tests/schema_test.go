@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+func Test_getSchema(t *testing.T) {
+	defer checkDBConnections(t)
+
+	w := serve(t, a.Admin(), http.MethodGet, "/admin/schema", "", "")
+	if e, a := http.StatusOK, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	var s db.Schema
+	resp := web.Response{
+		Results: &s,
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response body: %v", err)
+	}
+
+	for _, m := range s.Migrations {
+		if m.Applied == nil {
+			t.Errorf("expected migration %d to be applied", m.Version)
+		}
+	}
+
+	tables := make(map[string]db.Table, len(s.Tables))
+	for _, table := range s.Tables {
+		tables[table.Name] = table
+	}
+
+	items, ok := tables["item"]
+	if !ok {
+		t.Fatalf("expected the item table in %+v", s.Tables)
+	}
+
+	var unit bool
+	for _, c := range items.Columns {
+		if c.Name == "unit" {
+			unit = c.Type == "character varying" && !c.Nullable && c.Default != nil
+		}
+	}
+	if !unit {
+		t.Errorf("expected a non-null varchar unit column with a default in %+v", items.Columns)
+	}
+
+	var index bool
+	for _, i := range items.Indexes {
+		index = index || i.Name == "item_list_name"
+	}
+	if !index {
+		t.Errorf("expected the item_list_name index in %+v", items.Indexes)
+	}
+}
+
+func Test_verifySchema(t *testing.T) {
+	defer checkDBConnections(t)
+
+	feats, err := features.New(nil)
+	if err != nil {
+		t.Fatalf("error creating feature flags: %v", err)
+	}
+
+	guarded := handlers.NewApplication(a.DB, a.Log, feats)
+	guarded.RefuseWritesOnDrift = true
+
+	verify := func(t *testing.T) *httptest.ResponseRecorder {
+		return serve(t, guarded.Admin(), http.MethodPost, "/admin/schema/verify", "", "")
+	}
+
+	t.Run("Migrated", func(t *testing.T) {
+		expect.Status(http.StatusOK).JSONPath("results.drifted", false).Len("results.differences", 0).Assert(t, verify(t))
+	})
+
+	if _, err := a.DB.Exec("ALTER TABLE list ADD COLUMN drift_test text;"); err != nil {
+		t.Fatalf("error altering list table: %v", err)
+	}
+
+	defer func() {
+		if _, err := a.DB.Exec("ALTER TABLE list DROP COLUMN IF EXISTS drift_test;"); err != nil {
+			t.Errorf("error restoring list table: %v", err)
+		}
+	}()
+
+	t.Run("Drifted", func(t *testing.T) {
+		expect.Status(http.StatusOK).
+			JSONPath("results.drifted", true).
+			JSONPath("results.differences.0", "column list.drift_test is unexpected").
+			Assert(t, verify(t))
+
+		expect.Status(http.StatusServiceUnavailable).
+			JSONPath("errors.0.code", codes.SchemaDrifted).
+			Assert(t, serve(t, guarded, http.MethodPost, "/list", `{"name":"Drifted"}`, ""))
+
+		expect.Status(http.StatusOK).Assert(t, serve(t, guarded, http.MethodGet, "/list", "", ""))
+	})
+
+	if _, err := a.DB.Exec("ALTER TABLE list DROP COLUMN drift_test;"); err != nil {
+		t.Fatalf("error restoring list table: %v", err)
+	}
+
+	t.Run("Fixed", func(t *testing.T) {
+		defer func() {
+			if err := testdb.Truncate(a.DB); err != nil {
+				t.Errorf("error truncating test database tables: %v", err)
+			}
+		}()
+
+		expect.Status(http.StatusOK).JSONPath("results.drifted", false).Assert(t, verify(t))
+		expect.Status(http.StatusCreated).Assert(t, serve(t, guarded, http.MethodPost, "/list", `{"name":"Fixed"}`, ""))
+	})
+}
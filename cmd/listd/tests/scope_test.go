@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_scope(t *testing.T) {
+	defer checkDBConnections(t)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	// A request is served with the database handle it is given instead of the one of the
+	// application, here the schema of a tenant.
+	tdb, err := testdb.OpenTenant(a.DB, "scope", log.StandardLogger())
+	if err != nil {
+		t.Fatalf("error opening tenant: %v", err)
+	}
+
+	// The schema can only be dropped once its connections are closed, deferred calls run
+	// in reverse.
+	defer func() {
+		if err := testdb.DropTenant(a.DB, "scope"); err != nil {
+			t.Errorf("error dropping tenant: %v", err)
+		}
+	}()
+	defer tdb.Close()
+
+	req, err := http.NewRequest(http.MethodPost, "/list", strings.NewReader(`{"name":"Scoped"}`))
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, web.WithScope(req, web.Scope{DB: tdb}))
+
+	if e, a := http.StatusCreated, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	if _, err := list.SelectListByName(tdb, "Scoped"); err != nil {
+		t.Errorf("expected list in the database handle given to the request: %v", err)
+	}
+
+	// Requests without a handle of their own are served with the one of the application.
+	if lists, err := list.SelectLists(a.DB); err != nil {
+		t.Fatalf("error selecting lists: %v", err)
+	} else if len(lists) != 0 {
+		t.Errorf("expected no lists in the database of the application, got %d", len(lists))
+	}
+}
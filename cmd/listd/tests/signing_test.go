@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
+)
+
+func Test_signatures(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	key := signing.Key{ID: "billing", Secret: "0123456789abcdef"}
+
+	signed := handlers.NewApplication(a.DB, a.Log, a.Features)
+	signed.Signatures = signing.NewVerifier([]signing.Key{key}, time.Minute)
+	signed.RequireSignatures = true
+
+	// request returns a request creating a list, signed with key at the given time unless
+	// it is zero, that is changed by tamper before it is served.
+	request := func(t *testing.T, method, path string, at time.Time, tamper func(*http.Request)) *http.Request {
+		r := httptest.NewRequest(method, path, strings.NewReader(`{"name":"Grocery"}`))
+		if !at.IsZero() {
+			if err := signing.Sign(r, key, at); err != nil {
+				t.Fatalf("error signing request: %v", err)
+			}
+		}
+
+		if tamper != nil {
+			tamper(r)
+		}
+
+		return r
+	}
+
+	replayed := request(t, http.MethodPost, "/list", time.Now(), nil)
+	replay := func(r *http.Request) {
+		*r = *replayed
+		r.Body = ioutil.NopCloser(strings.NewReader(`{"name":"Grocery"}`))
+	}
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Path     string
+		At       time.Time
+		Tamper   func(*http.Request)
+		Expected expect.Response
+	}{
+		{
+			Name:     "Signed",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			At:       time.Now(),
+			Expected: expect.Status(http.StatusCreated).JSONPath("results.name", "Grocery"),
+		},
+		{
+			Name:     "Unsigned",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Expected: expect.Status(http.StatusUnauthorized).JSONPath("errors.0.code", codes.SignatureRequired).HeaderSet("WWW-Authenticate"),
+		},
+		{
+			Name:     "UnsignedRead",
+			Method:   http.MethodGet,
+			Path:     "/list",
+			Expected: expect.Status(http.StatusUnauthorized).JSONPath("errors.0.code", codes.SignatureRequired),
+		},
+		{
+			Name:     "UnsignedProbe",
+			Method:   http.MethodGet,
+			Path:     "/ready",
+			Expected: expect.Status(http.StatusOK),
+		},
+		{
+			Name:     "UnsignedAsset",
+			Method:   http.MethodGet,
+			Path:     "/ui/app.js",
+			Expected: expect.Status(http.StatusOK),
+		},
+		{
+			Name:     "ChangedBody",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			At:       time.Now(),
+			Tamper:   func(r *http.Request) { r.Body = ioutil.NopCloser(strings.NewReader(`{"name":"Hardware"}`)) },
+			Expected: expect.Status(http.StatusUnauthorized).JSONPath("errors.0.code", codes.SignatureInvalid),
+		},
+		{
+			Name:     "Expired",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			At:       time.Now().Add(-2 * time.Minute),
+			Expected: expect.Status(http.StatusUnauthorized).JSONPath("errors.0.code", codes.SignatureExpired),
+		},
+		{
+			Name:     "Replayed",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Tamper:   replay,
+			Expected: expect.Status(http.StatusCreated),
+		},
+		{
+			Name:     "ReplayedAgain",
+			Method:   http.MethodPost,
+			Path:     "/list",
+			Tamper:   replay,
+			Expected: expect.Status(http.StatusUnauthorized).JSONPath("errors.0.code", codes.SignatureReplayed),
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := httptest.NewRecorder()
+			signed.ServeHTTP(w, request(t, test.Method, test.Path, test.At, test.Tamper))
+
+			test.Expected.Assert(t, w)
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
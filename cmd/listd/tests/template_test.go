@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/template"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_templates(t *testing.T) {
+	defer checkDBConnections(t)
+
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	if _, err := testdb.SeedItems(a.DB, lists); err != nil {
+		t.Fatalf("error seeding items: %v", err)
+	}
+
+	// do makes a request against the application and decodes the results of the response
+	// into v when the expected status code is returned.
+	do := func(t *testing.T, method, path, body string, expectedCode int, v interface{}) {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error creating request: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, req)
+
+		if e, a := expectedCode, w.Code; e != a {
+			t.Fatalf("expected status code: %v, got status code: %v", e, a)
+		}
+
+		if v != nil {
+			if err := json.NewDecoder(w.Body).Decode(&web.Response{Results: v}); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+		}
+	}
+
+	expectedItems := []template.Item{
+		{Name: "Chocolate Milk", Quantity: 1},
+		{Name: "Mac and Cheese", Quantity: 2},
+	}
+
+	var saved template.Template
+	t.Run("Save", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/list/%d/save-template", lists[0].ID), "", http.StatusCreated, &saved)
+
+		if e, a := lists[0].Name, saved.Name; e != a {
+			t.Errorf("expected template name: %v, got template name: %v", e, a)
+		}
+
+		if d := cmp.Diff(expectedItems, saved.Items); d != "" {
+			t.Errorf("unexpected difference in template items:\n%v", d)
+		}
+	})
+
+	t.Run("SaveNamed", func(t *testing.T) {
+		var named template.Template
+		do(t, http.MethodPost, fmt.Sprintf("/list/%d/save-template", lists[1].ID), `{"name":"Chores"}`, http.StatusCreated, &named)
+
+		if e, a := "Chores", named.Name; e != a {
+			t.Errorf("expected template name: %v, got template name: %v", e, a)
+		}
+	})
+
+	t.Run("SaveDuplicateName", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/list/%d/save-template", lists[0].ID), "", http.StatusBadRequest, nil)
+	})
+
+	t.Run("SaveUnknownList", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/list/%d/save-template", lists[2].ID+100), `{"name":"Unknown"}`, http.StatusNotFound, nil)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		var templates []template.Template
+		do(t, http.MethodGet, "/template", "", http.StatusOK, &templates)
+
+		if e, a := 2, len(templates); e != a {
+			t.Errorf("expected %d templates, got %d", e, a)
+		}
+	})
+
+	t.Run("GetAllTruncated", func(t *testing.T) {
+		truncated := handlers.NewApplication(a.DB, a.Log, a.Features)
+		truncated.Paging.MaxResults = 1
+
+		var cursor string
+
+		w := httptest.NewRecorder()
+		truncated.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/template", nil))
+		expect.Status(http.StatusOK).Len("results", 1).JSONPath("truncated", true).Into("cursor", &cursor).Assert(t, w)
+
+		w = httptest.NewRecorder()
+		truncated.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/template?cursor="+cursor, nil))
+		expect.Status(http.StatusOK).Len("results", 1).JSONPath("results.0.name", "Chores").JSONPath("truncated", nil).Assert(t, w)
+
+		w = httptest.NewRecorder()
+		truncated.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/template?cursor=nope", nil))
+		expect.Status(http.StatusBadRequest).JSONPath("errors.0.code", codes.CursorInvalid).Assert(t, w)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		var got template.Template
+		do(t, http.MethodGet, fmt.Sprintf("/template/%d", saved.ID), "", http.StatusOK, &got)
+
+		if e, a := saved.Name, got.Name; e != a {
+			t.Errorf("expected template name: %v, got template name: %v", e, a)
+		}
+
+		if d := cmp.Diff(expectedItems, got.Items); d != "" {
+			t.Errorf("unexpected difference in template items:\n%v", d)
+		}
+	})
+
+	var l list.List
+	t.Run("Instantiate", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/template/%d/instantiate", saved.ID), `{"name":"Weekly Grocery"}`, http.StatusCreated, &l)
+
+		items, err := item.SelectItems(a.DB, l.ID)
+		if err != nil {
+			t.Fatalf("error selecting items of instantiated list: %v", err)
+		}
+
+		var copied []template.Item
+		for _, i := range items {
+			copied = append(copied, template.Item{Name: i.Name, Quantity: i.Quantity})
+		}
+
+		sort.Slice(copied, func(i, j int) bool {
+			return copied[i].Name < copied[j].Name
+		})
+
+		if d := cmp.Diff(expectedItems, copied); d != "" {
+			t.Errorf("unexpected difference in instantiated items:\n%v", d)
+		}
+	})
+
+	t.Run("InstantiateTakenName", func(t *testing.T) {
+		var conflict struct {
+			ID int `json:"id"`
+		}
+		do(t, http.MethodPost, fmt.Sprintf("/template/%d/instantiate", saved.ID), `{"name":"WEEKLY GROCERY"}`, http.StatusConflict, &conflict)
+
+		if e, a := l.ID, conflict.ID; e != a {
+			t.Errorf("expected id of conflicting list: %v, got id: %v", e, a)
+		}
+	})
+
+	t.Run("InstantiateWithoutName", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/template/%d/instantiate", saved.ID), `{}`, http.StatusBadRequest, nil)
+	})
+
+	t.Run("InstantiateUnknownTemplate", func(t *testing.T) {
+		do(t, http.MethodPost, fmt.Sprintf("/template/%d/instantiate", saved.ID+100), `{"name":"Unknown"}`, http.StatusNotFound, nil)
+	})
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	log "github.com/sirupsen/logrus"
+)
+
+func Test_tenants(t *testing.T) {
+	defer checkDBConnections(t)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	tenants := handlers.Tenants{
+		Domain:   "lists.test",
+		Handlers: make(map[string]http.Handler),
+		Default:  a,
+	}
+
+	for _, name := range []string{"acme", "globex"} {
+		tdb, err := testdb.OpenTenant(a.DB, name, log.StandardLogger())
+		if err != nil {
+			t.Fatalf("error opening tenant %s: %v", name, err)
+		}
+
+		// The schema can only be dropped once its connections are closed, deferred calls
+		// run in reverse.
+		defer func(name string) {
+			if err := testdb.DropTenant(a.DB, name); err != nil {
+				t.Errorf("error dropping tenant %s: %v", name, err)
+			}
+		}(name)
+		defer tdb.Close()
+
+		app := handlers.NewApplication(tdb, log.StandardLogger(), a.Features)
+		app.Units = a.Units
+		tenants.Handlers[name] = app
+	}
+
+	// Names are only unique within a tenant.
+	for _, host := range []string{"acme.lists.test", "GLOBEX.lists.test:3000"} {
+		w := serve(t, tenants, http.MethodPost, "http://"+host+"/list", `{"name":"Grocery"}`, "")
+		if e, a := http.StatusCreated, w.Code; e != a {
+			t.Fatalf("expected status code creating list at %s: %v, got status code: %v", host, e, a)
+		}
+	}
+
+	w := serve(t, tenants, http.MethodPost, "http://acme.lists.test/list", `{"name":"To-do"}`, "")
+	if e, a := http.StatusCreated, w.Code; e != a {
+		t.Fatalf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	tests := []struct {
+		Name          string
+		Host          string
+		ExpectedCode  int
+		ExpectedLists int
+	}{
+		{
+			Name:          "Acme",
+			Host:          "acme.lists.test",
+			ExpectedCode:  http.StatusOK,
+			ExpectedLists: 2,
+		},
+		{
+			Name:          "Globex",
+			Host:          "globex.lists.test",
+			ExpectedCode:  http.StatusOK,
+			ExpectedLists: 1,
+		},
+		{
+			Name:          "Default",
+			Host:          "lists.test",
+			ExpectedCode:  http.StatusOK,
+			ExpectedLists: 0,
+		},
+		{
+			Name:         "UnknownTenant",
+			Host:         "initech.lists.test",
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := serve(t, tenants, http.MethodGet, "http://"+test.Host+"/list", "", "")
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var lists []list.List
+			resp := web.Response{
+				Results: &lists,
+			}
+
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			if e, a := test.ExpectedLists, len(lists); e != a {
+				t.Errorf("expected %d lists, got %d: %+v", e, a, lists)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/item"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/list"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/dedup"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/expect"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+)
+
+func Test_requestTx(t *testing.T) {
+	defer checkDBConnections(t)
+	defer func() {
+		if err := testdb.Truncate(a.DB); err != nil {
+			t.Errorf("error truncating test database tables: %v", err)
+		}
+	}()
+
+	lists, err := testdb.SeedLists(a.DB)
+	if err != nil {
+		t.Fatalf("error seeding lists: %v", err)
+	}
+
+	transactional := handlers.NewApplication(a.DB, a.Log, a.Features)
+	transactional.RequestTx = true
+	transactional.Duplicates = dedup.New(time.Minute)
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Body         string
+		Prefer       string
+		ExpectedCode int
+		Committed    bool
+	}{
+		{
+			Name:         "Commit",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Committed"}`,
+			ExpectedCode: http.StatusCreated,
+			Committed:    true,
+		},
+		{
+			Name:         "DryRun",
+			Method:       http.MethodPost,
+			Path:         "/list",
+			Body:         `{"name":"Dry"}`,
+			Prefer:       "dry-run",
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "Rollback",
+			Method:       http.MethodPut,
+			Path:         "/list/0",
+			Body:         `{"name":"Missing"}`,
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			before, err := list.SelectLists(a.DB)
+			if err != nil {
+				t.Fatalf("error selecting lists: %v", err)
+			}
+
+			w := serve(t, transactional, test.Method, test.Path, test.Body, test.Prefer)
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Fatalf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			after, err := list.SelectLists(a.DB)
+			if err != nil {
+				t.Fatalf("error selecting lists: %v", err)
+			}
+
+			if e, a := test.Committed, len(after) > len(before); e != a {
+				t.Errorf("expected the write to be committed: %v, got: %v", e, a)
+			}
+		})
+	}
+	// A double-submitted item hits the unique name of items, which must not keep the
+	// transaction from being committed with the duplicated item as the response.
+	t.Run("DuplicateItem", func(t *testing.T) {
+		path := fmt.Sprintf("/list/%d/item", lists[0].ID)
+
+		var first, second int
+		expect.Status(http.StatusCreated).
+			Into("results.id", &first).
+			Assert(t, serve(t, transactional, http.MethodPost, path, `{"name":"Milk","quantity":2}`, ""))
+		expect.Status(http.StatusOK).
+			JSONPath("results.duplicate", true).
+			Into("results.id", &second).
+			Assert(t, serve(t, transactional, http.MethodPost, path, `{"name":"Milk","quantity":2}`, ""))
+
+		if first != second {
+			t.Errorf("expected the item created first with id %d, got id %d", first, second)
+		}
+
+		items, err := item.SelectItems(a.DB, lists[0].ID)
+		if err != nil {
+			t.Fatalf("error selecting items: %v", err)
+		}
+
+		if len(items) != 1 {
+			t.Errorf("expected a single item to be created, got %d", len(items))
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/projector/report"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/logging"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// projector consumes the events the list daemon publishes to NATS and maintains a read
+// model of them in postgres, the report_list table, see the report package.
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.WithError(err).Error("error in main")
+		os.Exit(1)
+	}
+}
+
+// queueGroup is the NATS queue group projectors subscribe in, so that replicas share the
+// events instead of each applying every one.
+const queueGroup = "projector"
+
+// run parses the flags, connects to the database, and keeps the read model up to date
+// until the process is signaled to shut down.
+func run(args []string) error {
+	fs := flag.NewFlagSet("projector", flag.ExitOnError)
+
+	natsURL := fs.String("nats-url", envOr("PROJECTOR_NATS_URL", "nats://nats:4222"), "URL of the NATS server events are consumed from (env: PROJECTOR_NATS_URL)")
+	listdURL := fs.String("listd-url", envOr("PROJECTOR_LISTD_URL", "http://listd:3000"), "base URL of the list daemon the read model is rebuilt from (env: PROJECTOR_LISTD_URL)")
+	dbUser := fs.String("db-user", envOr("PROJECTOR_DB_USER", "root"), "postgres database username (env: PROJECTOR_DB_USER)")
+	dbPass := fs.String("db-pass", envOr("PROJECTOR_DB_PASS", "root"), "postgres database password (env: PROJECTOR_DB_PASS)")
+	dbName := fs.String("db-name", envOr("PROJECTOR_DB_NAME", "list"), "postgres database name (env: PROJECTOR_DB_NAME)")
+	dbHost := fs.String("db-host", envOr("PROJECTOR_DB_HOST", "db"), "postgres database host name (env: PROJECTOR_DB_HOST)")
+	dbPort := fs.String("db-port", envOr("PROJECTOR_DB_PORT", "5432"), "postgres database port (env: PROJECTOR_DB_PORT)")
+	logLevel := fs.String("log-level", envOr("PROJECTOR_LOG_LEVEL", "info"), "minimum level of logged messages (env: PROJECTOR_LOG_LEVEL)")
+	logFormat := fs.String("log-format", envOr("PROJECTOR_LOG_FORMAT", "json"), "format of logged messages, json or text (env: PROJECTOR_LOG_FORMAT)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	logger := log.StandardLogger()
+	if err := logging.Configure(logger, *logLevel, *logFormat); err != nil {
+		return errors.Wrap(err, "configure logging")
+	}
+
+	port, err := strconv.Atoi(*dbPort)
+	if err != nil {
+		return errors.Errorf("expected a numeric database port, got %q", *dbPort)
+	}
+
+	dbc, err := db.NewConnection(db.Config{
+		User: *dbUser,
+		Pass: *dbPass,
+		Name: *dbName,
+		Host: *dbHost,
+		Port: port,
+	}, logger)
+	if err != nil {
+		return errors.Wrap(err, "connect to database")
+	}
+	defer dbc.Close()
+
+	if err := report.Setup(dbc); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	project(ctx, dbc, listclient.New(*listdURL), *natsURL, logger)
+
+	return nil
+}
+
+// resubscribeDelay is the wait before subscribing again after the subscription failed.
+const resubscribeDelay = 5 * time.Second
+
+// project subscribes to the events published on the NATS server at natsURL, rebuilds the
+// read model from client, and applies every event received after, until ctx is done. A
+// lost subscription is opened again, followed by another rebuild, since the events
+// published in between are never received.
+func project(ctx context.Context, dbc *sqlx.DB, client *listclient.Client, natsURL string, logger log.FieldLogger) {
+	for {
+		if err := consume(ctx, dbc, client, natsURL, logger); err != nil {
+			logger.WithError(err).Error("consume events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// consume runs a single subscription until ctx is done or the subscription is lost.
+func consume(ctx context.Context, dbc *sqlx.DB, client *listclient.Client, natsURL string, logger log.FieldLogger) error {
+	sub, err := events.SubscribeNATS(natsURL, queueGroup, logger)
+	if err != nil {
+		return errors.Wrap(err, "subscribe to events")
+	}
+
+	// Closing the subscription once ctx is done ends the loop over its events.
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-stop:
+		}
+	}()
+	defer sub.Close()
+
+	n, err := report.Rebuild(ctx, dbc, client)
+	if err != nil {
+		sub.Close()
+		for range sub.Events {
+		}
+
+		return errors.Wrap(err, "rebuild read model")
+	}
+	logger.WithField("lists", n).Info("rebuilt read model")
+
+	for e := range sub.Events {
+		if err := report.Apply(dbc, e); err != nil {
+			logger.WithError(err).WithFields(log.Fields{
+				"eventID":   e.ID,
+				"eventType": e.Type,
+			}).Error("apply event")
+		}
+	}
+
+	return sub.Err()
+}
+
+// envOr returns the value of the given environment variable, or def if it isn't set.
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+
+	return def
+}
@@ -0,0 +1,62 @@
+package report
+
+// PostgreSQL queries for the report_list and report_item tables, all used in the report
+// package.
+const (
+	// createTables is a query that creates the tables of the read model unless they
+	// exist. report_item holds the list of every item, so that counts stay right when
+	// items move between lists or events are received more than once.
+	createTables = `CREATE TABLE IF NOT EXISTS report_list (
+		list_id integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		item_count integer NOT NULL DEFAULT 0,
+		updated timestamp NOT NULL DEFAULT NOW()
+	);
+	CREATE TABLE IF NOT EXISTS report_item (
+		item_id integer PRIMARY KEY,
+		list_id integer NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS report_item_list_id_idx ON report_item (list_id);`
+
+	// truncate is a query that removes every row of the read model.
+	truncate = "TRUNCATE report_list, report_item;"
+
+	// upsertList is a query that inserts a row in the report_list table with the given
+	// list_id and name, or renames the row with that list_id.
+	upsertList = `INSERT INTO report_list (list_id, name) VALUES ($1, $2)
+		ON CONFLICT (list_id) DO UPDATE SET name = EXCLUDED.name, updated = NOW();`
+
+	// deleteList is a query that deletes the row of the report_list table with the given
+	// list_id.
+	deleteList = "DELETE FROM report_list WHERE list_id = $1;"
+
+	// deleteListItems is a query that deletes the rows of the report_item table with the
+	// given list_id.
+	deleteListItems = "DELETE FROM report_item WHERE list_id = $1;"
+
+	// selectItemList is a query that selects the list_id of the row of the report_item
+	// table with the given item_id.
+	selectItemList = "SELECT list_id FROM report_item WHERE item_id = $1;"
+
+	// upsertItem is a query that inserts a row in the report_item table with the given
+	// item_id and list_id, or moves the row with that item_id to the list_id.
+	upsertItem = `INSERT INTO report_item (item_id, list_id) VALUES ($1, $2)
+		ON CONFLICT (item_id) DO UPDATE SET list_id = EXCLUDED.list_id;`
+
+	// deleteItem is a query that deletes the row of the report_item table with the given
+	// item_id.
+	deleteItem = "DELETE FROM report_item WHERE item_id = $1;"
+
+	// count is a query that sets the item_count of the rows of the report_list table
+	// with the given list_ids to the amount of their rows in the report_item table.
+	count = `UPDATE report_list l SET item_count = (SELECT COUNT(*) FROM report_item i WHERE i.list_id = l.list_id),
+		updated = NOW() WHERE l.list_id = ANY($1);`
+
+	// countAll is a query that sets the item_count of every row of the report_list table
+	// to the amount of its rows in the report_item table.
+	countAll = `UPDATE report_list l SET item_count = (SELECT COUNT(*) FROM report_item i WHERE i.list_id = l.list_id),
+		updated = NOW();`
+
+	// selectAll is a query that selects every row of the report_list table.
+	selectAll = "SELECT list_id, name, item_count, updated FROM report_list ORDER BY list_id;"
+)
@@ -0,0 +1,163 @@
+// Package report maintains the read model of the projector: a row for every list with
+// the amount of items on it, derived from the events published by the list daemon.
+package report
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// List is a row of the read model.
+type List struct {
+	ID        int       `db:"list_id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	ItemCount int       `db:"item_count" json:"itemCount"`
+	Updated   time.Time `db:"updated" json:"updated"`
+}
+
+// Setup creates the tables of the read model unless they exist.
+func Setup(dbc *sqlx.DB) error {
+	_, err := dbc.Exec(createTables)
+	return errors.Wrap(err, "create report tables")
+}
+
+// Select selects every row of the read model, ordered by list id.
+func Select(dbc db.Executor) ([]List, error) {
+	lists := make([]List, 0)
+
+	if err := db.Select(dbc, &lists, selectAll); err != nil {
+		return nil, errors.Wrap(err, "select all rows from report_list table")
+	}
+
+	return lists, nil
+}
+
+// data holds the fields of event data the read model is derived from.
+type data struct {
+	ID     int    `json:"id"`
+	ListID int    `json:"listID"`
+	Name   string `json:"name"`
+}
+
+// Apply updates the read model with e in a single transaction. Events of unknown types
+// are ignored. Applying an event more than once has the same effect as applying it once,
+// so events that are delivered again are harmless.
+func Apply(dbc *sqlx.DB, e events.Event) error {
+	var d data
+	if err := json.Unmarshal(e.Data, &d); err != nil {
+		return errors.Wrapf(err, "unmarshal data of event %s", e.ID)
+	}
+
+	tx, err := dbc.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	switch e.Type {
+	case events.ListCreated, events.ListUpdated:
+		if _, err := tx.Exec(upsertList, d.ID, d.Name); err != nil {
+			return errors.Wrap(err, "upsert report_list row")
+		}
+
+	case events.ListDeleted:
+		if _, err := tx.Exec(deleteListItems, d.ID); err != nil {
+			return errors.Wrap(err, "delete report_item rows of list")
+		}
+
+		if _, err := tx.Exec(deleteList, d.ID); err != nil {
+			return errors.Wrap(err, "delete report_list row")
+		}
+
+	case events.ItemCreated, events.ItemUpdated, events.ItemDeleted:
+		// Items that move take their count along from the list they were on.
+		affected := []int64{int64(d.ListID)}
+
+		var from int
+		if err := tx.Get(&from, selectItemList, d.ID); err == nil && from != d.ListID {
+			affected = append(affected, int64(from))
+		} else if err != nil && err != sql.ErrNoRows {
+			return errors.Wrap(err, "select list of report_item row")
+		}
+
+		query, args := upsertItem, []interface{}{d.ID, d.ListID}
+		if e.Type == events.ItemDeleted {
+			query, args = deleteItem, []interface{}{d.ID}
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return errors.Wrap(err, "write report_item row")
+		}
+
+		if _, err := tx.Exec(count, pq.Array(affected)); err != nil {
+			return errors.Wrap(err, "count items of report_list rows")
+		}
+
+	default:
+		return nil
+	}
+
+	return errors.Wrap(tx.Commit(), "commit transaction")
+}
+
+// Rebuild replaces the read model with one derived from the lists and items the list
+// daemon serves through client, in a single transaction. The projector rebuilds after
+// subscribing, since events published while it wasn't subscribed are never received.
+// Events received during the rebuild are applied after it, which converges since they
+// are at least as recent as what the rebuild read.
+func Rebuild(ctx context.Context, dbc *sqlx.DB, client *listclient.Client) (int, error) {
+	lists, err := client.Lists(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "get lists")
+	}
+
+	tx, err := dbc.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(truncate); err != nil {
+		return 0, errors.Wrap(err, "truncate report tables")
+	}
+
+	for _, l := range lists {
+		if _, err := tx.Exec(upsertList, l.ID, l.Name); err != nil {
+			return 0, errors.Wrap(err, "insert report_list row")
+		}
+
+		items, err := client.Items(ctx, l.ID)
+		if listclient.IsNotFound(err) {
+			// The list was deleted since the lists were read, its event follows.
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "get items of list %d", l.ID)
+		}
+
+		for _, i := range items {
+			if _, err := tx.Exec(upsertItem, i.ID, l.ID); err != nil {
+				return 0, errors.Wrap(err, "insert report_item row")
+			}
+		}
+	}
+
+	if _, err := tx.Exec(countAll); err != nil {
+		return 0, errors.Wrap(err, "count items of report_list rows")
+	}
+
+	return len(lists), errors.Wrap(tx.Commit(), "commit transaction")
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/handlers"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/projector/report"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/config"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/features"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/leaktest"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+)
+
+// tenant is the schema of the test database the suite runs in, so it doesn't interfere
+// with the suite of the list daemon running against the same database.
+const tenant = "projector"
+
+var (
+	// dbc is the connection to the schema the list daemon and the read model share.
+	dbc *sqlx.DB
+
+	// client is a client of the list daemon served by the suite.
+	client *listclient.Client
+)
+
+// TestMain calls testMain and passes the returned exit code to os.Exit(), so that
+// deferred functions of testMain run.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+// testMain serves a list daemon in a schema of its own and sets up the read model next
+// to it, then runs the suite. A passing suite is still failed if it leaked database
+// connections or goroutines.
+func testMain(m *testing.M) int {
+	root, err := testdb.Open(log.StandardLogger())
+	if err != nil {
+		log.WithError(err).Info("create test database connection")
+		return 1
+	}
+
+	// A schema left behind by an interrupted run is started over.
+	if err := testdb.DropTenant(root, tenant); err != nil {
+		log.WithError(err).Info("drop test schema")
+		return 1
+	}
+
+	dbc, err = testdb.OpenTenant(root, tenant, log.StandardLogger())
+	if err != nil {
+		log.WithError(err).Info("create test schema")
+		return 1
+	}
+
+	if err := report.Setup(dbc); err != nil {
+		log.WithError(err).Info("set up read model")
+		return 1
+	}
+
+	feats, err := features.New(nil)
+	if err != nil {
+		log.WithError(err).Info("create feature flags")
+		return 1
+	}
+
+	app := handlers.NewApplication(dbc, log.StandardLogger(), feats)
+	app.Units = config.Default().ItemUnits
+
+	srv := httptest.NewServer(app)
+	client = listclient.New(srv.URL)
+
+	code := m.Run()
+
+	srv.Close()
+	client.HTTPClient.CloseIdleConnections()
+
+	if err := leaktest.DBConnections(dbc.DB); err != nil {
+		log.WithError(err).Error("check for leaked database connections, re-run with -run to narrow down the leaking test")
+		code = 1
+	}
+
+	if err := dbc.Close(); err != nil {
+		log.WithError(err).Error("close test schema connection")
+		code = 1
+	}
+
+	if err := testdb.DropTenant(root, tenant); err != nil {
+		log.WithError(err).Error("drop test schema")
+		code = 1
+	}
+
+	if err := root.Close(); err != nil {
+		log.WithError(err).Error("close test database connection")
+		code = 1
+	}
+
+	// The database has to be closed before checking for leaked goroutines since the
+	// connection pool manages its own goroutines.
+	if err := leaktest.Goroutines(); err != nil {
+		log.WithError(err).Error("check for leaked goroutines")
+		code = 1
+	}
+
+	return code
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/listd/outbox"
+	"github.com/george-e-shaw-iv/integration-tests-example/cmd/projector/report"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/testdb"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/listclient"
+	"github.com/google/go-cmp/cmp"
+)
+
+// projection is a publisher applying every event to the read model, standing in for the
+// projector consuming them from NATS.
+type projection struct {
+	events []events.Event
+}
+
+// Publish implements the events.Publisher interface.
+func (p *projection) Publish(_ context.Context, e events.Event) error {
+	p.events = append(p.events, e)
+	return report.Apply(dbc, e)
+}
+
+// Close implements the events.Publisher interface.
+func (p *projection) Close() error {
+	return nil
+}
+
+// reset removes every list and item from the list daemon and rebuilds the then empty read
+// model.
+func reset(t *testing.T) {
+	if err := testdb.Truncate(dbc); err != nil {
+		t.Fatalf("error truncating test schema tables: %v", err)
+	}
+
+	if _, err := report.Rebuild(context.Background(), dbc, client); err != nil {
+		t.Fatalf("error rebuilding read model: %v", err)
+	}
+}
+
+// counts returns the item count of every list of the read model by list name.
+func counts(t *testing.T) map[string]int {
+	lists, err := report.Select(dbc)
+	if err != nil {
+		t.Fatalf("error selecting read model: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, l := range lists {
+		counts[l.Name] = l.ItemCount
+	}
+
+	return counts
+}
+
+// changes makes changes to the lists of the list daemon through its API, leaving a
+// Groceries list of two items and a renamed Chores list of one.
+func changes(t *testing.T) {
+	ctx := context.Background()
+
+	groceries, err := client.CreateList(ctx, "Groceries")
+	if err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	chores, err := client.CreateList(ctx, "To-do")
+	if err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	trip, err := client.CreateList(ctx, "Trip")
+	if err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	for _, i := range []listclient.Item{
+		{ListID: groceries.ID, Name: "Milk", Quantity: 1},
+		{ListID: groceries.ID, Name: "Bread", Quantity: 2},
+		{ListID: groceries.ID, Name: "Eggs", Quantity: 12},
+		{ListID: chores.ID, Name: "Laundry", Quantity: 1},
+		{ListID: trip.ID, Name: "Tickets", Quantity: 2},
+	} {
+		created, err := client.CreateItem(ctx, i)
+		if err != nil {
+			t.Fatalf("error creating item: %v", err)
+		}
+
+		if i.Name == "Eggs" {
+			if err := client.DeleteItem(ctx, created.ListID, created.ID); err != nil {
+				t.Fatalf("error deleting item: %v", err)
+			}
+		}
+	}
+
+	if _, err := client.UpdateList(ctx, chores.ID, "Chores"); err != nil {
+		t.Fatalf("error renaming list: %v", err)
+	}
+
+	if err := client.DeleteList(ctx, trip.ID); err != nil {
+		t.Fatalf("error deleting list: %v", err)
+	}
+}
+
+func Test_apply(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	changes(t)
+
+	var p projection
+	if _, err := outbox.Relay(context.Background(), dbc, &p, 100); err != nil {
+		t.Fatalf("error relaying events: %v", err)
+	}
+
+	expected := map[string]int{"Groceries": 2, "Chores": 1}
+	if d := cmp.Diff(expected, counts(t)); d != "" {
+		t.Errorf("unexpected difference in read model:\n%v", d)
+	}
+
+	// Events delivered more than once are applied only once.
+	for _, e := range p.events {
+		if err := report.Apply(dbc, e); err != nil {
+			t.Fatalf("error applying event %s again: %v", e.Type, err)
+		}
+	}
+
+	if d := cmp.Diff(expected, counts(t)); d != "" {
+		t.Errorf("unexpected difference in read model after applying events again:\n%v", d)
+	}
+}
+
+func Test_rebuild(t *testing.T) {
+	reset(t)
+	defer reset(t)
+
+	// The events are never applied, as if the projector wasn't subscribed when they were
+	// published.
+	changes(t)
+
+	if got := counts(t); len(got) != 0 {
+		t.Fatalf("expected an empty read model before rebuilding, got %v", got)
+	}
+
+	n, err := report.Rebuild(context.Background(), dbc, client)
+	if err != nil {
+		t.Fatalf("error rebuilding read model: %v", err)
+	}
+
+	if e, a := 2, n; e != a {
+		t.Errorf("expected %d lists rebuilt, got %d", e, a)
+	}
+
+	if d := cmp.Diff(map[string]int{"Groceries": 2, "Chores": 1}, counts(t)); d != "" {
+		t.Errorf("unexpected difference in read model:\n%v", d)
+	}
+
+	// Events received during a rebuild are applied after it without changing the result.
+	var p projection
+	if _, err := outbox.Relay(context.Background(), dbc, &p, 100); err != nil {
+		t.Fatalf("error relaying events: %v", err)
+	}
+
+	if d := cmp.Diff(map[string]int{"Groceries": 2, "Chores": 1}, counts(t)); d != "" {
+		t.Errorf("unexpected difference in read model after applying events:\n%v", d)
+	}
+}
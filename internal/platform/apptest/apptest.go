@@ -0,0 +1,236 @@
+// Package apptest records the HTTP calls an integration suite makes to the application
+// under test and reports on them once the suite is done: which routes were called how
+// often and with which statuses, which routes were never called, and which calls were
+// the slowest. It helps contributors see the gaps and slow spots of the suite itself.
+package apptest
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// slowest is the amount of slowest calls a Report lists.
+const slowest = 10
+
+// Call is an HTTP call made to the application under test.
+type Call struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Route is the path pattern of the route that served the call, such as
+	// /list/:lid, or empty when no route matched it.
+	Route string `json:"route"`
+
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+}
+
+// endpoint returns the method and route of c, such as GET /list/:lid.
+func (c Call) endpoint() string {
+	if c.Route == "" {
+		return c.Method + " (unmatched)"
+	}
+
+	return c.Method + " " + c.Route
+}
+
+// Recorder records the calls made to the application under test. It is safe for
+// concurrent use, since tests make calls in parallel.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Record records c.
+func (r *Recorder) Record(c Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, c)
+}
+
+// Calls returns the calls recorded so far, in the order they were made.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Call(nil), r.calls...)
+}
+
+// Endpoint is the report of the calls made to a route.
+type Endpoint struct {
+	// Endpoint is the method and path pattern of the route, such as GET /list/:lid.
+	Endpoint string `json:"endpoint"`
+
+	Calls    int           `json:"calls"`
+	Statuses map[int]int   `json:"statuses"`
+	Total    time.Duration `json:"total"`
+	Max      time.Duration `json:"max"`
+}
+
+// Mean returns the mean duration of the calls made to the route.
+func (e Endpoint) Mean() time.Duration {
+	if e.Calls == 0 {
+		return 0
+	}
+
+	return e.Total / time.Duration(e.Calls)
+}
+
+// Report summarizes the calls made by a suite.
+type Report struct {
+	Calls    int           `json:"calls"`
+	Duration time.Duration `json:"duration"`
+
+	// Endpoints are the routes that were called, most called first.
+	Endpoints []Endpoint `json:"endpoints"`
+
+	// Uncovered are the routes that were never called, such as GET /version.
+	Uncovered []string `json:"uncovered"`
+
+	// Slowest are the slowest calls, slowest first.
+	Slowest []Call `json:"slowest"`
+}
+
+// Report returns the report of the calls recorded so far. routes are the method and path
+// pattern of every route of the application, such as GET /list/:lid, which tells the
+// routes that were never called.
+func (r *Recorder) Report(routes []string) Report {
+	calls := r.Calls()
+
+	rep := Report{
+		Calls:     len(calls),
+		Endpoints: make([]Endpoint, 0),
+		Uncovered: make([]string, 0),
+	}
+
+	byEndpoint := make(map[string]*Endpoint)
+	for _, c := range calls {
+		rep.Duration += c.Duration
+
+		e, ok := byEndpoint[c.endpoint()]
+		if !ok {
+			e = &Endpoint{Endpoint: c.endpoint(), Statuses: make(map[int]int)}
+			byEndpoint[c.endpoint()] = e
+		}
+
+		e.Calls++
+		e.Statuses[c.Status]++
+		e.Total += c.Duration
+		if c.Duration > e.Max {
+			e.Max = c.Duration
+		}
+	}
+
+	for _, e := range byEndpoint {
+		rep.Endpoints = append(rep.Endpoints, *e)
+	}
+	sort.Slice(rep.Endpoints, func(i, j int) bool {
+		if rep.Endpoints[i].Calls != rep.Endpoints[j].Calls {
+			return rep.Endpoints[i].Calls > rep.Endpoints[j].Calls
+		}
+		return rep.Endpoints[i].Endpoint < rep.Endpoints[j].Endpoint
+	})
+
+	for _, route := range routes {
+		if _, ok := byEndpoint[route]; !ok {
+			rep.Uncovered = append(rep.Uncovered, route)
+		}
+	}
+	sort.Strings(rep.Uncovered)
+
+	rep.Slowest = calls
+	sort.SliceStable(rep.Slowest, func(i, j int) bool {
+		return rep.Slowest[i].Duration > rep.Slowest[j].Duration
+	})
+	if len(rep.Slowest) > slowest {
+		rep.Slowest = rep.Slowest[:slowest]
+	}
+
+	return rep
+}
+
+// WriteJSON writes rep to w as indented JSON. Durations are given in nanoseconds.
+func (rep Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return errors.Wrap(enc.Encode(rep), "encode report")
+}
+
+// WriteHTML writes rep to w as a standalone HTML page.
+func (rep Report) WriteHTML(w io.Writer) error {
+	return errors.Wrap(page.Execute(w, rep), "render report")
+}
+
+// WriteFiles writes rep to report.json and report.html in dir, creating dir if needed.
+func (rep Report) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "create report directory")
+	}
+
+	write := func(name string, fn func(io.Writer) error) error {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return errors.Wrapf(err, "create %s", name)
+		}
+
+		if err := fn(f); err != nil {
+			f.Close()
+			return err
+		}
+
+		return errors.Wrapf(f.Close(), "close %s", name)
+	}
+
+	if err := write("report.json", rep.WriteJSON); err != nil {
+		return err
+	}
+
+	return write("report.html", rep.WriteHTML)
+}
+
+// page is the template of the HTML report.
+var page = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Integration suite calls</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+td.number { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Integration suite calls</h1>
+<p>{{.Calls}} calls taking {{.Duration}} in total.</p>
+
+<h2>Endpoints</h2>
+<table>
+<tr><th>Endpoint</th><th>Calls</th><th>Statuses</th><th>Mean</th><th>Max</th></tr>
+{{range .Endpoints}}<tr><td>{{.Endpoint}}</td><td class="number">{{.Calls}}</td><td>{{range $status, $n := .Statuses}}{{$status}}&times;{{$n}} {{end}}</td><td class="number">{{.Mean}}</td><td class="number">{{.Max}}</td></tr>
+{{end}}</table>
+
+<h2>Uncovered</h2>
+{{if .Uncovered}}<ul>
+{{range .Uncovered}}<li>{{.}}</li>
+{{end}}</ul>{{else}}<p>Every route was called.</p>{{end}}
+
+<h2>Slowest calls</h2>
+<table>
+<tr><th>Method</th><th>Path</th><th>Status</th><th>Duration</th></tr>
+{{range .Slowest}}<tr><td>{{.Method}}</td><td>{{.Path}}</td><td class="number">{{.Status}}</td><td class="number">{{.Duration}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
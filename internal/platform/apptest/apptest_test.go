@@ -0,0 +1,52 @@
+package apptest
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReport(t *testing.T) {
+	var r Recorder
+	r.Record(Call{Method: http.MethodGet, Path: "/list", Route: "/list", Status: http.StatusOK, Duration: 2 * time.Millisecond})
+	r.Record(Call{Method: http.MethodGet, Path: "/list/1", Route: "/list/:lid", Status: http.StatusOK, Duration: 5 * time.Millisecond})
+	r.Record(Call{Method: http.MethodGet, Path: "/list/2", Route: "/list/:lid", Status: http.StatusNotFound, Duration: time.Millisecond})
+	r.Record(Call{Method: http.MethodGet, Path: "/nope", Status: http.StatusNotFound, Duration: time.Millisecond})
+
+	calls := r.Calls()
+
+	rep := r.Report([]string{"GET /list", "GET /list/:lid", "DELETE /list/:lid", "GET /version"})
+
+	expected := Report{
+		Calls:    4,
+		Duration: 9 * time.Millisecond,
+		Endpoints: []Endpoint{
+			{Endpoint: "GET /list/:lid", Calls: 2, Statuses: map[int]int{200: 1, 404: 1}, Total: 6 * time.Millisecond, Max: 5 * time.Millisecond},
+			{Endpoint: "GET (unmatched)", Calls: 1, Statuses: map[int]int{404: 1}, Total: time.Millisecond, Max: time.Millisecond},
+			{Endpoint: "GET /list", Calls: 1, Statuses: map[int]int{200: 1}, Total: 2 * time.Millisecond, Max: 2 * time.Millisecond},
+		},
+		Uncovered: []string{"DELETE /list/:lid", "GET /version"},
+		Slowest:   []Call{calls[1], calls[0], calls[2], calls[3]},
+	}
+
+	if d := cmp.Diff(expected, rep); d != "" {
+		t.Errorf("unexpected difference in report:\n%v", d)
+	}
+
+	if e, a := 3*time.Millisecond, rep.Endpoints[0].Mean(); e != a {
+		t.Errorf("expected mean duration %v, got %v", e, a)
+	}
+
+	var html bytes.Buffer
+	if err := rep.WriteHTML(&html); err != nil {
+		t.Fatalf("error writing HTML report: %v", err)
+	}
+
+	if !strings.Contains(html.String(), "<li>DELETE /list/:lid</li>") {
+		t.Errorf("expected the HTML report to list uncovered routes, got:\n%s", html.String())
+	}
+}
@@ -0,0 +1,66 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// These variables are injected at build time through the linker, e.g.:
+//
+//	go build -ldflags "-X github.com/george-e-shaw-iv/integration-tests-example/internal/platform/buildinfo.Version=1.2.0"
+var (
+	// Version is the released version of the binary.
+	Version = "dev"
+
+	// Commit is the VCS revision the binary was built from. If it is not injected, the
+	// revision stamped by the go tool is used when available.
+	Commit = ""
+
+	// Date is the time the binary was built at, preferably in RFC 3339 format.
+	Date = ""
+)
+
+// Info is the format used to describe the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Compiler  string `json:"compiler"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: Date,
+		GoVersion: runtime.Version(),
+		Compiler:  runtime.Compiler,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	// Fall back to the VCS information the go tool stamps into binaries built from
+	// within a repository.
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+
+	return info
+}
@@ -0,0 +1,226 @@
+// Package cache keeps responses of GET requests in memory so repeated reads don't have
+// to reach the database.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// metrics holds the hit and miss counters of every cache, published through expvar
+// under the name cache.
+var (
+	metrics   = expvar.NewMap("cache")
+	hits      = new(expvar.Int)
+	misses    = new(expvar.Int)
+	evictions = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("hits", hits)
+	metrics.Set("misses", misses)
+	metrics.Set("evictions", evictions)
+}
+
+// entry is a cached response along with the key it is stored under.
+type entry struct {
+	key     string
+	expires time.Time
+
+	code   int
+	header http.Header
+	body   []byte
+}
+
+// Cache is a least recently used cache of responses, each of which is kept for a fixed
+// amount of time at most. It is safe for concurrent use and a nil *Cache caches nothing.
+type Cache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+
+	// generation is incremented by every purge, responses rendered before a purge are
+	// not stored after it.
+	generation uint64
+}
+
+// New returns a new Cache holding up to size responses, each for at most ttl.
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// keyOf returns the key the response to r is cached under, which is made up of its path,
+// query, subject, and Accept header. The subject is the Authorization header, so clients
+// with different credentials never share responses. The Accept header picks the envelope
+// of the response.
+func keyOf(r *http.Request) string {
+	return r.URL.Path + "?" + r.URL.RawQuery + "\x00" + r.Header.Get("Authorization") + "\x00" + r.Header.Get("Accept")
+}
+
+// get returns the unexpired response stored under key and marks it as recently used.
+func (c *Cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return e, true
+}
+
+// gen returns the current generation of the cache.
+func (c *Cache) gen() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// set stores e, evicting the least recently used responses while the cache is full. e
+// is dropped if the cache has been purged since generation gen.
+func (c *Cache) set(e *entry, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.generation {
+		return
+	}
+
+	if el, ok := c.entries[e.key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, e.key)
+	}
+
+	for c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+		evictions.Add(1)
+	}
+
+	c.entries[e.key] = c.order.PushFront(e)
+}
+
+// Purge removes every response from the cache. Write handlers call it once their changes
+// are committed so no reader is served what they replaced.
+func (c *Cache) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element, c.size)
+	c.generation++
+}
+
+// recorder is an http.ResponseWriter that keeps the response in memory.
+type recorder struct {
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// Header implements the http.ResponseWriter interface.
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// Write implements the http.ResponseWriter interface.
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+
+	return r.body.Write(b)
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+// Serve serves r from the cache if it holds a response to it and calls next otherwise,
+// caching its response when it is a 200 OK that isn't marked with Cache-Control: no-store.
+// Only GET requests without conditional or no-cache headers are cached, everything else
+// is passed on to next. The X-Cache header of the response tells whether it was a HIT or
+// a MISS.
+func (c *Cache) Serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if c == nil || r.Method != http.MethodGet || r.Header.Get("If-Modified-Since") != "" || r.Header.Get("Cache-Control") == "no-cache" {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := keyOf(r)
+
+	if e, ok := c.get(key); ok {
+		hits.Add(1)
+		write(w, e, "HIT")
+		return
+	}
+
+	misses.Add(1)
+
+	gen := c.gen()
+
+	rec := recorder{
+		header: make(http.Header),
+	}
+	next.ServeHTTP(&rec, r)
+
+	e := entry{
+		key:     key,
+		expires: time.Now().Add(c.ttl),
+		code:    rec.code,
+		header:  rec.header,
+		body:    rec.body.Bytes(),
+	}
+
+	if e.code == http.StatusOK && e.header.Get("Set-Cookie") == "" && e.header.Get("Cache-Control") != "no-store" {
+		c.set(&e, gen)
+	}
+
+	write(w, &e, "MISS")
+}
+
+// write sends the response e to w.
+func write(w http.ResponseWriter, e *entry, status string) {
+	for k, v := range e.header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.Header().Set("X-Cache", status)
+
+	code := e.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+
+	w.WriteHeader(code)
+	w.Write(e.body)
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// counter is a handler that responds with the amount of requests it has served,
+// answering /missing with 404 Not Found and marking the response to /volatile with
+// Cache-Control: no-store.
+type counter struct {
+	served int
+
+	// during is called while serving a request, if set.
+	during func()
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.served++
+
+	if c.during != nil {
+		c.during()
+	}
+
+	if r.URL.Path == "/missing" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.Path == "/volatile" {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, c.served)
+}
+
+// get makes a GET request for path with the given Authorization header against c.
+func get(c *Cache, next http.Handler, path, authorization string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+
+	w := httptest.NewRecorder()
+	c.Serve(w, r, next)
+
+	return w
+}
+
+func TestCacheServe(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Requests func(c *Cache, next *counter) *httptest.ResponseRecorder
+		Body     string
+		XCache   string
+	}{
+		{
+			Name: "Miss",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				return get(c, next, "/list", "")
+			},
+			Body:   "1",
+			XCache: "MISS",
+		},
+		{
+			Name: "Hit",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list", "")
+				return get(c, next, "/list", "")
+			},
+			Body:   "1",
+			XCache: "HIT",
+		},
+		{
+			Name: "DifferentQuery",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list?limit=1", "")
+				return get(c, next, "/list?limit=2", "")
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "DifferentSubject",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list", "Bearer a")
+				return get(c, next, "/list", "Bearer b")
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "DifferentAccept",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list", "")
+
+				r := httptest.NewRequest(http.MethodGet, "/list", nil)
+				r.Header.Set("Accept", `application/json; profile="raw"`)
+
+				w := httptest.NewRecorder()
+				c.Serve(w, r, next)
+
+				return w
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "ErrorsNotCached",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/missing", "")
+				get(c, next, "/missing", "")
+				return get(c, next, "/list", "")
+			},
+			Body:   "3",
+			XCache: "MISS",
+		},
+		{
+			Name: "NoStoreNotCached",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/volatile", "")
+				return get(c, next, "/volatile", "")
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "Purged",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list", "")
+				c.Purge()
+				return get(c, next, "/list", "")
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "PurgedWhileServing",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				next.during = c.Purge
+				get(c, next, "/list", "")
+				next.during = nil
+				return get(c, next, "/list", "")
+			},
+			Body:   "2",
+			XCache: "MISS",
+		},
+		{
+			Name: "LeastRecentlyUsedEvicted",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list/1", "")
+				get(c, next, "/list/2", "")
+				get(c, next, "/list/1", "")
+				get(c, next, "/list/3", "")
+				return get(c, next, "/list/2", "")
+			},
+			Body:   "4",
+			XCache: "MISS",
+		},
+		{
+			Name: "RecentlyUsedKept",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list/1", "")
+				get(c, next, "/list/2", "")
+				get(c, next, "/list/1", "")
+				get(c, next, "/list/3", "")
+				return get(c, next, "/list/1", "")
+			},
+			Body:   "1",
+			XCache: "HIT",
+		},
+		{
+			Name: "NoCache",
+			Requests: func(c *Cache, next *counter) *httptest.ResponseRecorder {
+				get(c, next, "/list", "")
+
+				r := httptest.NewRequest(http.MethodGet, "/list", nil)
+				r.Header.Set("Cache-Control", "no-cache")
+
+				w := httptest.NewRecorder()
+				c.Serve(w, r, next)
+				return w
+			},
+			Body: "2",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := test.Requests(New(2, time.Minute), new(counter))
+
+			if e, a := test.Body, w.Body.String(); e != a {
+				t.Errorf("expected body: %q, got body: %q", e, a)
+			}
+
+			if e, a := test.XCache, w.Header().Get("X-Cache"); e != a {
+				t.Errorf("expected X-Cache header: %q, got: %q", e, a)
+			}
+
+			if w.Code == http.StatusOK && w.Header().Get("Content-Type") != "text/plain" {
+				t.Errorf("expected the headers of the response to be kept, got %v", w.Header())
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := New(10, time.Millisecond)
+	next := new(counter)
+
+	get(c, next, "/list", "")
+	time.Sleep(5 * time.Millisecond)
+
+	if e, a := "2", get(c, next, "/list", "").Body.String(); e != a {
+		t.Errorf("expected expired response to be rendered again, got body: %q", a)
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	c := New(10, time.Minute)
+	next := new(counter)
+
+	h, m := hits.Value(), misses.Value()
+
+	get(c, next, "/list", "")
+	get(c, next, "/list", "")
+	get(c, next, "/list", "")
+
+	if e, a := int64(2), hits.Value()-h; e != a {
+		t.Errorf("expected %d hits, got %d", e, a)
+	}
+
+	if e, a := int64(1), misses.Value()-m; e != a {
+		t.Errorf("expected %d misses, got %d", e, a)
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	next := new(counter)
+
+	get(c, next, "/list", "")
+	c.Purge()
+
+	if e, a := "2", get(c, next, "/list", "").Body.String(); e != a {
+		t.Errorf("expected a nil cache to pass every request on, got body: %q", a)
+	}
+}
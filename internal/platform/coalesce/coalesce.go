@@ -0,0 +1,97 @@
+// Package coalesce groups the work submitted in bursts, such as many small inserts
+// within a few milliseconds, so it can be carried out at once, such as by a single
+// multi-row insert.
+package coalesce
+
+import (
+	"sync"
+	"time"
+)
+
+// Flush carries out the values submitted under key at once. It returns a result and an
+// error per value, in the order of values.
+type Flush func(key string, values []interface{}) ([]interface{}, []error)
+
+// group is the values submitted under a key that are flushed together.
+type group struct {
+	values   []interface{}
+	flushing bool
+
+	// done is closed once results and errs are set.
+	done    chan struct{}
+	results []interface{}
+	errs    []error
+}
+
+// Coalescer groups the values submitted under the same key within a window and flushes
+// every group at once. It is safe for concurrent use.
+type Coalescer struct {
+	window time.Duration
+	max    int
+	flush  Flush
+
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+// New returns a new Coalescer that flushes the values submitted under a key with flush
+// once window has passed since the first of them, or as soon as there are max of them.
+func New(window time.Duration, max int, flush Flush) *Coalescer {
+	return &Coalescer{
+		window: window,
+		max:    max,
+		flush:  flush,
+		groups: make(map[string]*group),
+	}
+}
+
+// Submit adds v to the group of key and returns its result and error once the group has
+// been flushed.
+func (c *Coalescer) Submit(key string, v interface{}) (interface{}, error) {
+	c.mu.Lock()
+
+	g, ok := c.groups[key]
+	if !ok {
+		g = &group{done: make(chan struct{})}
+		c.groups[key] = g
+
+		time.AfterFunc(c.window, func() {
+			c.flushGroup(key, g)
+		})
+	}
+
+	i := len(g.values)
+	g.values = append(g.values, v)
+	full := len(g.values) >= c.max
+
+	c.mu.Unlock()
+
+	if full {
+		c.flushGroup(key, g)
+	}
+
+	<-g.done
+	return g.results[i], g.errs[i]
+}
+
+// flushGroup flushes g, the group of key, unless it is already being flushed. No more
+// values are added to g once it is flushed.
+func (c *Coalescer) flushGroup(key string, g *group) {
+	c.mu.Lock()
+
+	if c.groups[key] == g {
+		delete(c.groups, key)
+	}
+
+	flushing := g.flushing
+	g.flushing = true
+
+	c.mu.Unlock()
+
+	if flushing {
+		return
+	}
+
+	g.results, g.errs = c.flush(key, g.values)
+	close(g.done)
+}
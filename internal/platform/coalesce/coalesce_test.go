@@ -0,0 +1,99 @@
+package coalesce
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCoalescer(t *testing.T) {
+	var mu sync.Mutex
+	var flushes []int
+
+	c := New(20*time.Millisecond, 3, func(key string, values []interface{}) ([]interface{}, []error) {
+		mu.Lock()
+		flushes = append(flushes, len(values))
+		mu.Unlock()
+
+		results := make([]interface{}, len(values))
+		errs := make([]error, len(values))
+		for i, v := range values {
+			if v.(int) < 0 {
+				errs[i] = errors.New("negative")
+				continue
+			}
+			results[i] = fmt.Sprintf("%s%d", key, v)
+		}
+
+		return results, errs
+	})
+
+	values := []int{1, 2, 3, 4, -5}
+
+	var wg sync.WaitGroup
+	wg.Add(len(values))
+
+	for _, v := range values {
+		go func(v int) {
+			defer wg.Done()
+
+			result, err := c.Submit("a", v)
+			if v < 0 {
+				if err == nil {
+					t.Errorf("expected an error submitting %d", v)
+				}
+				return
+			}
+
+			if e := fmt.Sprintf("a%d", v); result != e || err != nil {
+				t.Errorf("expected %q submitting %d, got %v, %v", e, v, result, err)
+			}
+		}(v)
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The first three values fill a group, the other two are flushed once the window
+	// passes.
+	total := 0
+	for _, n := range flushes {
+		if n > 3 {
+			t.Errorf("expected groups of at most 3 values, got %d", n)
+		}
+		total += n
+	}
+
+	if total != len(values) || len(flushes) < 2 {
+		t.Errorf("expected %d values flushed in at least 2 groups, got %v", len(values), flushes)
+	}
+}
+
+func TestCoalescerKeys(t *testing.T) {
+	c := New(10*time.Millisecond, 10, func(key string, values []interface{}) ([]interface{}, []error) {
+		if len(values) != 1 {
+			t.Errorf("expected values of key %s to be flushed on their own, got %v", key, values)
+		}
+
+		return []interface{}{key}, []error{nil}
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			if result, _ := c.Submit(key, 1); result != key {
+				t.Errorf("expected %q, got %v", key, result)
+			}
+		}(key)
+	}
+
+	wg.Wait()
+}
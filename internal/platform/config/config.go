@@ -0,0 +1,954 @@
+package config
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EnvPrefix is the prefix of every environment variable and config file key that
+// is read by Load.
+const EnvPrefix = "LIST_"
+
+// These constants define the names under which the location of the optional config
+// file can be given.
+const (
+	// fileFlag is the name of the command-line flag that points to the config file.
+	fileFlag = "config"
+
+	// fileEnv is the name of the environment variable that points to the config file.
+	fileEnv = EnvPrefix + "CONFIG_FILE"
+)
+
+// Config contains every setting of the list daemon. Each field can be set, from lowest
+// to highest precedence, by its default, the config file, the environment, and
+// command-line flags. The env struct tag holds the environment variable (and config
+// file key) without EnvPrefix and the flag struct tag holds the command-line flag name.
+// Fields tagged with reload:"true" are taken over by Watcher.Reload.
+type Config struct {
+	DaemonPort int `env:"DAEMON_PORT" flag:"daemon-port" usage:"port the list daemon listens on"`
+	AdminPort  int `env:"ADMIN_PORT" flag:"admin-port" usage:"port the admin and debug endpoints are served on, 0 disables them"`
+
+	PublicReadPort      int           `env:"PUBLIC_READ_PORT" flag:"public-read-port" usage:"port the public read API of shared lists and published templates is served on without authentication, 0 disables it"`
+	PublicReadOrigins   []string      `env:"PUBLIC_READ_ORIGINS" flag:"public-read-origins" usage:"comma separated list of origins whose pages may read the responses of the public read API, such as https://lists.example.com, or * for every origin"`
+	PublicReadMaxAge    time.Duration `env:"PUBLIC_READ_MAX_AGE" flag:"public-read-max-age" usage:"time browsers may reuse the answer to a preflight request of the public read API, 0 leaves it up to them"`
+	PublicReadPerMinute int           `env:"PUBLIC_READ_PER_MINUTE" flag:"public-read-per-minute" reload:"true" usage:"maximum amount of requests per minute every client makes to the public read API, 0 leaves them unlimited"`
+	PublicReadBurst     int           `env:"PUBLIC_READ_BURST" flag:"public-read-burst" reload:"true" usage:"maximum amount of requests every client makes to the public read API at once"`
+
+	ShareTTL       time.Duration `env:"SHARE_TTL" flag:"share-ttl" usage:"time lists are shared on the public read API for once they are shared, 0 shares them for good"`
+	SharePerMinute int           `env:"SHARE_PER_MINUTE" flag:"share-per-minute" reload:"true" usage:"maximum amount of requests per minute made with every share token to the public read API, whoever makes them, 0 leaves them unlimited"`
+	ShareBurst     int           `env:"SHARE_BURST" flag:"share-burst" reload:"true" usage:"maximum amount of requests made with every share token to the public read API at once"`
+
+	DBUser string `env:"DB_USER" flag:"db-user" usage:"postgres database username"`
+	DBPass string `env:"DB_PASS" flag:"db-pass" usage:"postgres database password"`
+	DBName string `env:"DB_NAME" flag:"db-name" usage:"postgres database name"`
+	DBHost string `env:"DB_HOST" flag:"db-host" usage:"postgres database host name"`
+	DBPort int    `env:"DB_PORT" flag:"db-port" usage:"postgres database port"`
+
+	TenantDomain string `env:"TENANT_DOMAIN" flag:"tenant-domain" usage:"domain whose subdomains name tenants, each served from a postgres schema of its own, empty disables multi-tenancy"`
+
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT" flag:"read-timeout" usage:"read timeout of the HTTP server"`
+	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT" flag:"write-timeout" usage:"write timeout of the HTTP server"`
+	ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"graceful shutdown timeout of the list daemon"`
+	UpgradeTimeout    time.Duration `env:"UPGRADE_TIMEOUT" flag:"upgrade-timeout" usage:"time the process started by SIGUSR2 is given to take over the listening sockets before the upgrade is given up on"`
+	RequestTimeout    time.Duration `env:"REQUEST_TIMEOUT" flag:"request-timeout" usage:"time requests are given to respond before their context is canceled, 0 disables it"`
+	MaxRequestTimeout time.Duration `env:"MAX_REQUEST_TIMEOUT" flag:"max-request-timeout" usage:"longest timeout clients may ask for with the X-Request-Timeout header, 0 only lets them shorten the request timeout"`
+
+	TrailingSlash string `env:"TRAILING_SLASH" flag:"trailing-slash" usage:"how paths with a trailing slash are handled (redirect, rewrite)"`
+	MaxBodySize   int    `env:"MAX_BODY_SIZE" flag:"max-body-size" usage:"maximum size of request bodies in bytes, imports have a fixed limit of 10 MiB"`
+	PrettyJSON    bool   `env:"PRETTY_JSON" flag:"pretty-json" usage:"indent JSON responses by default, clients can override it with ?pretty="`
+	Envelope      string `env:"ENVELOPE" flag:"envelope" usage:"envelope JSON responses are written in by default (wrapped, raw), clients can override it with the profile of the Accept header"`
+	StringIDs     bool   `env:"STRING_IDS" flag:"string-ids" usage:"encode identifiers in JSON responses as strings for JavaScript clients, requests may give them either way"`
+
+	SigningKeys       []string      `env:"SIGNING_KEYS" flag:"signing-keys" usage:"comma separated list of id:secret pairs of the keys machine clients sign requests with, see X-Signature, empty ignores signatures"`
+	RequireSignatures bool          `env:"REQUIRE_SIGNATURES" flag:"require-signatures" usage:"reject unsigned requests but for the probes, the documentation, and the demo UI"`
+	SignatureWindow   time.Duration `env:"SIGNATURE_WINDOW" flag:"signature-window" usage:"how far the Date of a signed request may be from the time it is received, signed requests are only accepted once within it"`
+
+	TrustedProxies []string `env:"TRUSTED_PROXIES" flag:"trusted-proxies" usage:"comma separated list of networks or IP addresses of reverse proxies whose forwarding headers name the client"`
+
+	Faults []string `env:"FAULTS" flag:"faults" usage:"comma separated list of faults injected while the fault_injection feature is enabled, each a method, path, latency, and error rate such as GET /list/:lid 200ms 0.1"`
+
+	LogLevel  string `env:"LOG_LEVEL" flag:"log-level" reload:"true" usage:"minimum level of logged messages (debug, info, warn, error)"`
+	LogFormat string `env:"LOG_FORMAT" flag:"log-format" reload:"true" usage:"format of logged messages (text, json)"`
+
+	LogSampleRate int      `env:"LOG_SAMPLE_RATE" flag:"log-sample-rate" usage:"log one in this many successful requests, failed requests are always logged"`
+	LogHeaders    []string `env:"LOG_HEADERS" flag:"log-headers" usage:"comma separated list of request headers logged with each request, Authorization and Cookie never are"`
+	LogBodies     bool     `env:"LOG_BODIES" flag:"log-bodies" usage:"log the JSON bodies of write requests with each request"`
+	LogRedact     []string `env:"LOG_REDACT" flag:"log-redact" usage:"comma separated list of fields of logged request bodies whose values are redacted, such as name"`
+
+	SlowRequestThreshold time.Duration `env:"SLOW_REQUEST_THRESHOLD" flag:"slow-request-threshold" usage:"duration after which the plan of the slowest query of a request is logged while the query_plans feature is enabled, 0 disables it"`
+
+	EventsDriver string `env:"EVENTS_DRIVER" flag:"events-driver" usage:"where change events are published to (none, log, nats)"`
+	EventsURL    string `env:"EVENTS_URL" flag:"events-url" usage:"URL of the message broker change events are published to, e.g. nats://nats:4222"`
+
+	EventsRelayInterval time.Duration `env:"EVENTS_RELAY_INTERVAL" flag:"events-relay-interval" usage:"interval at which pending change events are relayed from the outbox to the broker"`
+	OutboxRetention     time.Duration `env:"OUTBOX_RETENTION" flag:"outbox-retention" usage:"time published change events are kept in the outbox for"`
+
+	PurgeInterval  time.Duration `env:"PURGE_INTERVAL" flag:"purge-interval" usage:"interval at which data older than its retention is purged, 0 disables purging"`
+	PurgeBatchSize int           `env:"PURGE_BATCH_SIZE" flag:"purge-batch-size" usage:"maximum amount of rows deleted by a single statement of the purge"`
+
+	NotifyURL         string   `env:"NOTIFY_URL" flag:"notify-url" usage:"Slack or Discord webhook URL notifications of change events are posted to, empty disables them"`
+	NotifyFormat      string   `env:"NOTIFY_FORMAT" flag:"notify-format" usage:"chat service the notification webhook belongs to (slack, discord)"`
+	NotifyEvents      []string `env:"NOTIFY_EVENTS" flag:"notify-events" usage:"comma separated list of change event types notifications are posted for"`
+	NotifyTemplate    string   `env:"NOTIFY_TEMPLATE" flag:"notify-template" usage:"text/template notifications are rendered with, empty uses the built-in one"`
+	NotifyVersion     int      `env:"NOTIFY_EVENT_VERSION" flag:"notify-event-version" usage:"version of the events notifications are rendered from, 0 uses the latest one"`
+	NotifyPerMinute   int      `env:"NOTIFY_PER_MINUTE" flag:"notify-per-minute" usage:"maximum amount of notifications posted per minute"`
+	NotifySecret      string   `env:"NOTIFY_SECRET" flag:"notify-secret" usage:"secret notifications are signed with, empty leaves them unsigned"`
+	NotifyMaxAttempts int      `env:"NOTIFY_MAX_ATTEMPTS" flag:"notify-max-attempts" usage:"maximum amount of times a notification is posted before it is kept as a dead letter"`
+
+	StorageDriver string        `env:"STORAGE_DRIVER" flag:"storage-driver" usage:"where the files attached to items are stored (none, local, s3), none disables attachments"`
+	StoragePath   string        `env:"STORAGE_PATH" flag:"storage-path" usage:"directory the local storage keeps files in, shared by every replica"`
+	StorageSecret string        `env:"STORAGE_SECRET" flag:"storage-secret" usage:"secret the download URLs of the local storage are signed with, shared by every replica"`
+	StorageURLTTL time.Duration `env:"STORAGE_URL_TTL" flag:"storage-url-ttl" usage:"time the download URLs of attachments are valid for, at most a week"`
+
+	StorageS3Endpoint       string `env:"STORAGE_S3_ENDPOINT" flag:"storage-s3-endpoint" usage:"base URL of the S3 compatible service files are stored in, e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000"`
+	StorageS3PublicEndpoint string `env:"STORAGE_S3_PUBLIC_ENDPOINT" flag:"storage-s3-public-endpoint" usage:"base URL of the S3 compatible service in download URLs, when clients reach it under another address, empty uses the endpoint"`
+	StorageS3Region         string `env:"STORAGE_S3_REGION" flag:"storage-s3-region" usage:"region of the S3 bucket files are stored in"`
+	StorageS3Bucket         string `env:"STORAGE_S3_BUCKET" flag:"storage-s3-bucket" usage:"S3 bucket files are stored in"`
+	StorageS3AccessKey      string `env:"STORAGE_S3_ACCESS_KEY" flag:"storage-s3-access-key" usage:"access key ID of the credentials of the S3 bucket"`
+	StorageS3SecretKey      string `env:"STORAGE_S3_SECRET_KEY" flag:"storage-s3-secret-key" usage:"secret access key of the credentials of the S3 bucket"`
+
+	ItemUnits []string `env:"ITEM_UNITS" flag:"item-units" usage:"comma separated list of units item quantities can be given in"`
+
+	CacheSize int           `env:"CACHE_SIZE" flag:"cache-size" usage:"maximum amount of GET responses kept in memory, 0 disables the response cache"`
+	CacheTTL  time.Duration `env:"CACHE_TTL" flag:"cache-ttl" usage:"time a GET response is kept in memory for at most"`
+
+	DuplicateWindow time.Duration `env:"DUPLICATE_WINDOW" flag:"duplicate-window" usage:"time within which an item created again with the same payload is answered with the first one, 0 disables it"`
+	CoalesceWindow  time.Duration `env:"COALESCE_WINDOW" flag:"coalesce-window" usage:"time items created in the same list are waited for to be inserted at once, 0 inserts every item on its own"`
+
+	RequestTx bool `env:"REQUEST_TX" flag:"request-tx" usage:"serve every write request within a single transaction, committed only when it succeeds"`
+
+	QuotaMaxLists int `env:"QUOTA_MAX_LISTS" flag:"quota-max-lists" usage:"maximum amount of lists of a tenant without a quota of its own, 0 is unlimited"`
+	QuotaMaxItems int `env:"QUOTA_MAX_ITEMS" flag:"quota-max-items" usage:"maximum amount of items per list of a tenant without a quota of its own, 0 is unlimited"`
+
+	NameMaxLength int `env:"NAME_MAX_LENGTH" flag:"name-max-length" usage:"maximum amount of characters in the name of a list, item, or template"`
+
+	Validation string `env:"VALIDATION" flag:"validation" usage:"validation mode (strict, lenient), lenient keeps the legacy behavior of ignoring unknown fields and only limiting names to what can be stored"`
+
+	IDStrategy string `env:"ID_STRATEGY" flag:"id-strategy" usage:"strategy the ids of new lists, items, and templates are generated by (serial, snowflake), empty keeps the one stored in the database"`
+
+	ReadOnly string `env:"READ_ONLY" flag:"read-only" usage:"refuse every write request (replica, migrating), replica answers them with a 405 and migrating with a 503, empty serves them"`
+
+	SchemaDrift string `env:"SCHEMA_DRIFT" flag:"schema-drift" usage:"what happens to write requests while the database schema drifted from the one its migrations built (refuse, warn), drift is always logged"`
+
+	PageSize    int `env:"PAGE_SIZE" flag:"page-size" usage:"amount of results returned by paginated endpoints when no limit is given"`
+	MaxPageSize int `env:"MAX_PAGE_SIZE" flag:"max-page-size" usage:"largest limit accepted by paginated endpoints"`
+	MaxResults  int `env:"MAX_RESULTS" flag:"max-results" usage:"most results returned by endpoints that aren't paginated before their response is truncated"`
+
+	Workers      int `env:"WORKERS" flag:"workers" usage:"amount of imports and exports run in the background at once, 0 makes them all synchronous"`
+	JobQueueSize int `env:"JOB_QUEUE_SIZE" flag:"job-queue-size" usage:"maximum amount of background imports and exports waiting for a worker"`
+
+	ImportBatchSize int `env:"IMPORT_BATCH_SIZE" flag:"import-batch-size" usage:"amount of rows of a CSV or Excel import inserted per transaction"`
+
+	CheckInterval time.Duration `env:"CHECK_INTERVAL" flag:"check-interval" usage:"interval of the background database consistency check, 0 disables it"`
+
+	Features []string `env:"FEATURES" flag:"features" reload:"true" usage:"comma separated list of enabled feature flags"`
+}
+
+// Default returns the configuration used for any setting that is not supplied by
+// any other source.
+func Default() Config {
+	return Config{
+		DaemonPort: 3000,
+
+		PublicReadOrigins:   []string{"*"},
+		PublicReadMaxAge:    10 * time.Minute,
+		PublicReadPerMinute: 60,
+		PublicReadBurst:     20,
+
+		ShareTTL:       30 * 24 * time.Hour,
+		SharePerMinute: 120,
+		ShareBurst:     30,
+
+		DBUser: "root",
+		DBPass: "root",
+		DBName: "list",
+		DBHost: "db",
+		DBPort: 5432,
+
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+		UpgradeTimeout:  30 * time.Second,
+		RequestTimeout:  5 * time.Second,
+
+		TrailingSlash: "redirect",
+		MaxBodySize:   1 << 20,
+		Envelope:      "wrapped",
+
+		SignatureWindow: 5 * time.Minute,
+
+		LogLevel:  "info",
+		LogFormat: "text",
+
+		LogSampleRate: 1,
+
+		SlowRequestThreshold: time.Second,
+
+		EventsDriver:        "none",
+		EventsRelayInterval: time.Second,
+		OutboxRetention:     7 * 24 * time.Hour,
+
+		PurgeInterval:  time.Hour,
+		PurgeBatchSize: 1000,
+
+		NotifyFormat:      "slack",
+		NotifyEvents:      []string{"list.created", "list.deleted"},
+		NotifyPerMinute:   20,
+		NotifyMaxAttempts: 5,
+
+		StorageDriver:   "none",
+		StorageURLTTL:   15 * time.Minute,
+		StorageS3Region: "us-east-1",
+
+		ItemUnits: []string{"pcs", "pack", "g", "kg", "ml", "l"},
+
+		CacheTTL: 5 * time.Second,
+
+		DuplicateWindow: 5 * time.Second,
+
+		NameMaxLength: 255,
+
+		Validation: "strict",
+
+		SchemaDrift: "refuse",
+
+		PageSize:    50,
+		MaxPageSize: 500,
+		MaxResults:  1000,
+
+		Workers:      4,
+		JobQueueSize: 100,
+
+		ImportBatchSize: 500,
+
+		CheckInterval: time.Hour,
+	}
+}
+
+// Load returns the configuration gathered from the defaults, the optional config file,
+// the environment, and the command-line flags in args, in that order of precedence.
+// The config flags are registered on fs before it parses args, which allows callers to
+// register flags of their own on fs as well. The returned configuration is validated.
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	w, err := Watch(fs, args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return w.Config(), nil
+}
+
+// Watcher holds the active configuration and is able to reload it from the sources it
+// was loaded from. It is safe for concurrent use.
+type Watcher struct {
+	active atomic.Value
+
+	// mu serializes reloads.
+	mu sync.Mutex
+
+	// flagValues are the raw values of the flags given on the command-line, which
+	// can't change but keep taking precedence over the file and the environment.
+	flagValues map[string]string
+
+	// path is the location of the config file, empty when there is none.
+	path string
+}
+
+// Watch loads the configuration the same way Load does and returns a Watcher holding it.
+func Watch(fs *flag.FlagSet, args []string) (*Watcher, error) {
+	w := Watcher{
+		flagValues: make(map[string]string),
+	}
+
+	// Flag values are only recorded while parsing so they can be applied last.
+	fs.String(fileFlag, "", "path to a config file containing "+EnvPrefix+"KEY=VALUE lines (env: "+fileEnv+")")
+	for _, f := range fieldsOf(&Config{}) {
+		def := Default()
+		fs.Var(&recorder{name: f.flag, values: w.flagValues, def: f.of(&def).String()}, f.flag, fmt.Sprintf("%s (env: %s)", f.usage, f.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, errors.Wrap(err, "parse command-line flags")
+	}
+
+	w.path = os.Getenv(fileEnv)
+	if ff := fs.Lookup(fileFlag); ff != nil && ff.Value.String() != "" {
+		w.path = ff.Value.String()
+	}
+
+	cfg, err := w.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	w.active.Store(cfg)
+
+	return &w, nil
+}
+
+// Config returns the active configuration.
+func (w *Watcher) Config() Config {
+	return w.active.Load().(Config)
+}
+
+// Reload reads the config file and the environment again and atomically swaps the
+// active configuration for the result. Only fields with a reload struct tag are taken
+// over since the rest is used once at startup, the names of settings that changed but
+// require a restart are returned. The active configuration is kept if the new one is
+// invalid.
+func (w *Watcher) Reload() (Config, []string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := w.resolve()
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	cfg := w.Config()
+
+	var restart []string
+	for _, f := range fieldsOf(&cfg) {
+		lf := f.of(&loaded)
+		if reflect.DeepEqual(f.value.Interface(), lf.value.Interface()) {
+			continue
+		}
+
+		if !f.reload {
+			restart = append(restart, f.env)
+			continue
+		}
+
+		f.value.Set(lf.value)
+	}
+
+	w.active.Store(cfg)
+
+	return cfg, restart, nil
+}
+
+// resolve gathers the configuration from every source in order of precedence and
+// validates it.
+func (w *Watcher) resolve() (Config, error) {
+	cfg := Default()
+
+	fields := fieldsOf(&cfg)
+
+	if w.path != "" {
+		fileValues, err := readFile(w.path)
+		if err != nil {
+			return Config{}, errors.Wrap(err, "read config file")
+		}
+
+		for _, f := range fields {
+			if v, ok := fileValues[f.env]; ok {
+				if err := f.set(v); err != nil {
+					return Config{}, errors.Wrapf(err, "config file %s: key %s", w.path, f.env)
+				}
+
+				delete(fileValues, f.env)
+			}
+		}
+
+		// Anything left over is most likely a typo that would otherwise be silently ignored.
+		for key := range fileValues {
+			return Config{}, errors.Errorf("config file %s: unknown key %s", w.path, key)
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(v); err != nil {
+				return Config{}, errors.Wrapf(err, "environment variable %s", f.env)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := w.flagValues[f.flag]; ok {
+			if err := f.set(v); err != nil {
+				return Config{}, errors.Wrapf(err, "flag -%s", f.flag)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks every setting of the configuration and returns an error describing
+// all invalid settings at once, naming the environment variable and flag that can be
+// used to fix each of them.
+func (c Config) Validate() error {
+	var problems []string
+
+	invalid := func(field, problem string) {
+		f, _ := reflect.TypeOf(c).FieldByName(field)
+		problems = append(problems, fmt.Sprintf("%s%s (-%s): %s", EnvPrefix, f.Tag.Get("env"), f.Tag.Get("flag"), problem))
+	}
+
+	for field, port := range map[string]int{"DaemonPort": c.DaemonPort, "DBPort": c.DBPort} {
+		if port < 1 || port > 65535 {
+			invalid(field, fmt.Sprintf("must be a port between 1 and 65535, got %d", port))
+		}
+	}
+
+	if c.AdminPort < 0 || c.AdminPort > 65535 {
+		invalid("AdminPort", fmt.Sprintf("must be 0 or a port between 1 and 65535, got %d", c.AdminPort))
+	} else if c.AdminPort != 0 && c.AdminPort == c.DaemonPort {
+		invalid("AdminPort", fmt.Sprintf("must differ from the daemon port, got %d for both", c.AdminPort))
+	}
+
+	if c.PublicReadPort < 0 || c.PublicReadPort > 65535 {
+		invalid("PublicReadPort", fmt.Sprintf("must be 0 or a port between 1 and 65535, got %d", c.PublicReadPort))
+	} else if c.PublicReadPort != 0 && (c.PublicReadPort == c.DaemonPort || c.PublicReadPort == c.AdminPort) {
+		invalid("PublicReadPort", fmt.Sprintf("must differ from the daemon and admin ports, got %d", c.PublicReadPort))
+	}
+
+	for _, o := range c.PublicReadOrigins {
+		if !validOrigin(o) {
+			invalid("PublicReadOrigins", fmt.Sprintf("must only contain origins such as https://lists.example.com or *, got %q", o))
+		}
+	}
+
+	if c.PublicReadMaxAge < 0 {
+		invalid("PublicReadMaxAge", fmt.Sprintf("must be 0 or a positive duration such as 10m, got %v", c.PublicReadMaxAge))
+	}
+
+	if c.PublicReadPerMinute < 0 {
+		invalid("PublicReadPerMinute", fmt.Sprintf("must be 0 or a positive number, got %d", c.PublicReadPerMinute))
+	}
+
+	if c.PublicReadBurst <= 0 {
+		invalid("PublicReadBurst", fmt.Sprintf("must be a positive number, got %d", c.PublicReadBurst))
+	}
+
+	if c.ShareTTL < 0 {
+		invalid("ShareTTL", fmt.Sprintf("must be 0 or a positive duration such as 720h, got %v", c.ShareTTL))
+	}
+
+	if c.SharePerMinute < 0 {
+		invalid("SharePerMinute", fmt.Sprintf("must be 0 or a positive number, got %d", c.SharePerMinute))
+	}
+
+	if c.ShareBurst <= 0 {
+		invalid("ShareBurst", fmt.Sprintf("must be a positive number, got %d", c.ShareBurst))
+	}
+
+	for field, value := range map[string]string{"DBUser": c.DBUser, "DBName": c.DBName, "DBHost": c.DBHost} {
+		if value == "" {
+			invalid(field, "must not be empty")
+		}
+	}
+
+	for field, d := range map[string]time.Duration{"ReadTimeout": c.ReadTimeout, "WriteTimeout": c.WriteTimeout, "ShutdownTimeout": c.ShutdownTimeout, "UpgradeTimeout": c.UpgradeTimeout, "EventsRelayInterval": c.EventsRelayInterval, "OutboxRetention": c.OutboxRetention} {
+		if d <= 0 {
+			invalid(field, fmt.Sprintf("must be a positive duration such as 5s, got %v", d))
+		}
+	}
+
+	if c.RequestTimeout < 0 || c.RequestTimeout > c.WriteTimeout {
+		invalid("RequestTimeout", fmt.Sprintf("must be 0 or a positive duration no longer than the write timeout of %v, got %v", c.WriteTimeout, c.RequestTimeout))
+	}
+
+	if c.MaxRequestTimeout < 0 || c.MaxRequestTimeout > c.WriteTimeout {
+		invalid("MaxRequestTimeout", fmt.Sprintf("must be 0 or a positive duration no longer than the write timeout of %v, got %v", c.WriteTimeout, c.MaxRequestTimeout))
+	}
+
+	switch c.EventsDriver {
+	case "none", "log":
+	case "nats":
+		if c.EventsURL == "" {
+			invalid("EventsURL", "must be set when publishing events to nats")
+		}
+	default:
+		invalid("EventsDriver", fmt.Sprintf("must be one of none, log, or nats, got %q", c.EventsDriver))
+	}
+
+	switch c.StorageDriver {
+	case "none":
+	case "local":
+		if c.StoragePath == "" {
+			invalid("StoragePath", "must be set when storing files locally")
+		}
+
+		if len(c.StorageSecret) < 16 {
+			invalid("StorageSecret", "must be at least 16 characters long when storing files locally")
+		}
+	case "s3":
+		if c.StorageS3Endpoint == "" {
+			invalid("StorageS3Endpoint", "must be set when storing files in s3")
+		}
+
+		if c.StorageS3Bucket == "" {
+			invalid("StorageS3Bucket", "must be set when storing files in s3")
+		}
+
+		if c.StorageS3AccessKey == "" || c.StorageS3SecretKey == "" {
+			invalid("StorageS3AccessKey", "must be set along with the secret key when storing files in s3")
+		}
+	default:
+		invalid("StorageDriver", fmt.Sprintf("must be one of none, local, or s3, got %q", c.StorageDriver))
+	}
+
+	if c.StorageURLTTL <= 0 || c.StorageURLTTL > 7*24*time.Hour {
+		invalid("StorageURLTTL", fmt.Sprintf("must be a positive duration no longer than a week, got %v", c.StorageURLTTL))
+	}
+
+	if c.NotifyURL != "" {
+		switch c.NotifyFormat {
+		case "slack", "discord":
+		default:
+			invalid("NotifyFormat", fmt.Sprintf("must be one of slack or discord, got %q", c.NotifyFormat))
+		}
+
+		if c.NotifyPerMinute <= 0 {
+			invalid("NotifyPerMinute", fmt.Sprintf("must be a positive number, got %d", c.NotifyPerMinute))
+		}
+
+		if c.NotifyVersion < 0 {
+			invalid("NotifyVersion", fmt.Sprintf("must be 0 or a positive number, got %d", c.NotifyVersion))
+		}
+
+		if c.NotifyMaxAttempts <= 0 {
+			invalid("NotifyMaxAttempts", fmt.Sprintf("must be a positive number, got %d", c.NotifyMaxAttempts))
+		}
+	}
+
+	for _, unit := range c.ItemUnits {
+		if len(unit) > 32 {
+			invalid("ItemUnits", fmt.Sprintf("must only contain units of at most 32 characters, got %q", unit))
+		}
+	}
+
+	if c.CacheSize < 0 {
+		invalid("CacheSize", fmt.Sprintf("must be 0 or a positive number, got %d", c.CacheSize))
+	}
+
+	if c.CacheSize > 0 && c.CacheTTL <= 0 {
+		invalid("CacheTTL", fmt.Sprintf("must be a positive duration such as 5s when the cache is enabled, got %v", c.CacheTTL))
+	}
+
+	if c.DuplicateWindow < 0 {
+		invalid("DuplicateWindow", fmt.Sprintf("must be 0 or a positive duration such as 5s, got %v", c.DuplicateWindow))
+	}
+
+	if c.CoalesceWindow < 0 || c.CoalesceWindow > time.Second {
+		invalid("CoalesceWindow", fmt.Sprintf("must be between 0 and 1s such as 5ms, got %v", c.CoalesceWindow))
+	}
+
+	for field, limit := range map[string]int{"QuotaMaxLists": c.QuotaMaxLists, "QuotaMaxItems": c.QuotaMaxItems} {
+		if limit < 0 {
+			invalid(field, fmt.Sprintf("must be 0 or a positive number, got %d", limit))
+		}
+	}
+
+	// Names are stored in columns of 255 characters.
+	if c.NameMaxLength < 1 || c.NameMaxLength > 255 {
+		invalid("NameMaxLength", fmt.Sprintf("must be a number between 1 and 255, got %d", c.NameMaxLength))
+	}
+
+	switch c.Validation {
+	case "strict", "lenient":
+	default:
+		invalid("Validation", fmt.Sprintf("must be one of strict or lenient, got %q", c.Validation))
+	}
+
+	switch c.IDStrategy {
+	case "", "serial", "snowflake":
+	default:
+		invalid("IDStrategy", fmt.Sprintf("must be empty or one of serial or snowflake, got %q", c.IDStrategy))
+	}
+
+	switch c.ReadOnly {
+	case "", "replica", "migrating":
+	default:
+		invalid("ReadOnly", fmt.Sprintf("must be empty or one of replica or migrating, got %q", c.ReadOnly))
+	}
+
+	switch c.SchemaDrift {
+	case "refuse", "warn":
+	default:
+		invalid("SchemaDrift", fmt.Sprintf("must be one of refuse or warn, got %q", c.SchemaDrift))
+	}
+
+	if c.MaxPageSize < 1 {
+		invalid("MaxPageSize", fmt.Sprintf("must be a positive number, got %d", c.MaxPageSize))
+	}
+
+	if c.PageSize < 1 || c.PageSize > c.MaxPageSize {
+		invalid("PageSize", fmt.Sprintf("must be a positive number of at most the maximum page size %d, got %d", c.MaxPageSize, c.PageSize))
+	}
+
+	if c.MaxResults < 1 {
+		invalid("MaxResults", fmt.Sprintf("must be a positive number, got %d", c.MaxResults))
+	}
+
+	if c.Workers < 0 {
+		invalid("Workers", fmt.Sprintf("must be 0 or a positive number, got %d", c.Workers))
+	}
+
+	if c.Workers > 0 && c.JobQueueSize < 1 {
+		invalid("JobQueueSize", fmt.Sprintf("must be a positive number when workers are enabled, got %d", c.JobQueueSize))
+	}
+
+	if c.ImportBatchSize < 1 {
+		invalid("ImportBatchSize", fmt.Sprintf("must be a positive number, got %d", c.ImportBatchSize))
+	}
+
+	if c.CheckInterval < 0 {
+		invalid("CheckInterval", fmt.Sprintf("must be 0 or a positive duration such as 1h, got %v", c.CheckInterval))
+	}
+
+	if c.PurgeInterval < 0 {
+		invalid("PurgeInterval", fmt.Sprintf("must be 0 or a positive duration such as 1h, got %v", c.PurgeInterval))
+	}
+
+	if c.PurgeBatchSize < 1 {
+		invalid("PurgeBatchSize", fmt.Sprintf("must be a positive number, got %d", c.PurgeBatchSize))
+	}
+
+	if c.MaxBodySize < 1 {
+		invalid("MaxBodySize", fmt.Sprintf("must be a positive number of bytes, got %d", c.MaxBodySize))
+	}
+
+	keys := make(map[string]bool, len(c.SigningKeys))
+	for _, k := range c.SigningKeys {
+		id, secret, ok := SplitKey(k)
+		switch {
+		case !ok || !validKeyID(id):
+			invalid("SigningKeys", fmt.Sprintf("must only contain id:secret pairs whose id consists of letters, digits, dashes, underscores, and periods, got one with id %q", id))
+		case len(secret) < minSecretLength:
+			invalid("SigningKeys", fmt.Sprintf("must only contain secrets of at least %d characters, the one of %q is shorter", minSecretLength, id))
+		case keys[id]:
+			invalid("SigningKeys", fmt.Sprintf("must not contain the id %q more than once", id))
+		}
+		keys[id] = true
+	}
+
+	if c.RequireSignatures && len(c.SigningKeys) == 0 {
+		invalid("RequireSignatures", "requires at least one signing key")
+	}
+
+	if c.SignatureWindow <= 0 {
+		invalid("SignatureWindow", fmt.Sprintf("must be a positive duration such as 5m, got %v", c.SignatureWindow))
+	}
+
+	for _, p := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			invalid("TrustedProxies", fmt.Sprintf("must only contain networks such as 10.0.0.0/8 or IP addresses, got %q", p))
+		}
+	}
+
+	for _, f := range c.Faults {
+		if !validFault(f) {
+			invalid("Faults", fmt.Sprintf("must only contain a method, path, latency, and error rate between 0 and 1 such as GET /list/:lid 200ms 0.1, got %q", f))
+		}
+	}
+
+	if c.TenantDomain != "" && !validDomain(c.TenantDomain) {
+		invalid("TenantDomain", fmt.Sprintf("must be a domain name such as lists.example.com, got %q", c.TenantDomain))
+	}
+
+	switch c.TrailingSlash {
+	case "redirect", "rewrite":
+	default:
+		invalid("TrailingSlash", fmt.Sprintf("must be one of redirect or rewrite, got %q", c.TrailingSlash))
+	}
+
+	switch c.Envelope {
+	case "wrapped", "raw":
+	default:
+		invalid("Envelope", fmt.Sprintf("must be one of wrapped or raw, got %q", c.Envelope))
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("LogLevel", fmt.Sprintf("must be one of debug, info, warn, or error, got %q", c.LogLevel))
+	}
+
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		invalid("LogFormat", fmt.Sprintf("must be one of text or json, got %q", c.LogFormat))
+	}
+
+	if c.LogSampleRate < 1 {
+		invalid("LogSampleRate", fmt.Sprintf("must be a positive number, got %d", c.LogSampleRate))
+	}
+
+	if c.SlowRequestThreshold < 0 {
+		invalid("SlowRequestThreshold", fmt.Sprintf("must be 0 or a positive duration such as 1s, got %v", c.SlowRequestThreshold))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// Map iteration order is random, sorting keeps the message stable between runs.
+	sort.Strings(problems)
+
+	return errors.Errorf("invalid configuration:\n\t%s", strings.Join(problems, "\n\t"))
+}
+
+// minSecretLength is the least amount of characters of the secret of a signing key.
+const minSecretLength = 16
+
+// SplitKey splits a signing key of the SigningKeys into its id and secret, reporting
+// whether it is an id:secret pair.
+func SplitKey(k string) (id, secret string, ok bool) {
+	i := strings.IndexByte(k, ':')
+	if i < 0 {
+		return k, "", false
+	}
+
+	return k[:i], k[i+1:], true
+}
+
+// validKeyID reports whether id is a non-empty id of a signing key made up of letters,
+// digits, dashes, underscores, and periods, so it can be sent in the signature header as is.
+func validKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// validFault reports whether f is a method, path, latency, and error rate between 0 and 1
+// separated by spaces, such as GET /list/:lid 200ms 0.1.
+func validFault(f string) bool {
+	fields := strings.Fields(f)
+	if len(fields) != 4 {
+		return false
+	}
+
+	if d, err := time.ParseDuration(fields[2]); err != nil || d < 0 {
+		return false
+	}
+
+	rate, err := strconv.ParseFloat(fields[3], 64)
+	return err == nil && rate >= 0 && rate <= 1
+}
+
+// validOrigin reports whether o is * or the origin of an http or https URL, that is its
+// scheme, host, and optional port, such as https://lists.example.com.
+func validOrigin(o string) bool {
+	if o == "*" {
+		return true
+	}
+
+	u, err := url.Parse(o)
+	if err != nil || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// validDomain reports whether d is a domain name of at least two labels, each made up of
+// letters, digits, and inner hyphens, such as lists.example.com.
+func validDomain(d string) bool {
+	labels := strings.Split(d, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, l := range labels {
+		if l == "" || len(l) > 63 || strings.HasPrefix(l, "-") || strings.HasSuffix(l, "-") {
+			return false
+		}
+
+		for _, r := range l {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// field is a settable field of Config along with the names it can be set by.
+type field struct {
+	index  int
+	env    string
+	flag   string
+	usage  string
+	reload bool
+	value  reflect.Value
+}
+
+// fieldsOf returns every field of the given configuration that has an env struct tag.
+func fieldsOf(cfg *Config) []field {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		env, ok := t.Field(i).Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		fields = append(fields, field{
+			index:  i,
+			env:    EnvPrefix + env,
+			flag:   t.Field(i).Tag.Get("flag"),
+			usage:  t.Field(i).Tag.Get("usage"),
+			reload: t.Field(i).Tag.Get("reload") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+// of returns the same field of another configuration.
+func (f field) of(cfg *Config) field {
+	f.value = reflect.ValueOf(cfg).Elem().Field(f.index)
+	return f
+}
+
+// set parses s according to the type of the field and stores the result in it.
+func (f field) set(s string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(s)
+
+	case int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return errors.Errorf("expected an integer, got %q", s)
+		}
+		f.value.SetInt(int64(n))
+
+	case bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return errors.Errorf("expected true or false, got %q", s)
+		}
+		f.value.SetBool(b)
+
+	case time.Duration:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return errors.Errorf("expected a duration such as 5s or 1m30s, got %q", s)
+		}
+		f.value.SetInt(int64(d))
+
+	case []string:
+		var values []string
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		f.value.Set(reflect.ValueOf(values))
+
+	default:
+		return errors.Errorf("unsupported configuration type %s", f.value.Type())
+	}
+
+	return nil
+}
+
+// String returns the current value of the field formatted the same way set expects it.
+func (f field) String() string {
+	if values, ok := f.value.Interface().([]string); ok {
+		return strings.Join(values, ",")
+	}
+
+	return fmt.Sprint(f.value.Interface())
+}
+
+// recorder is a flag.Value that records the raw value of a flag when it is set
+// instead of parsing it right away.
+type recorder struct {
+	name   string
+	def    string
+	values map[string]string
+}
+
+// String implements the flag.Value interface.
+func (r *recorder) String() string {
+	if r == nil {
+		return ""
+	}
+
+	if v, ok := r.values[r.name]; ok {
+		return v
+	}
+
+	return r.def
+}
+
+// Set implements the flag.Value interface.
+func (r *recorder) Set(s string) error {
+	r.values[r.name] = s
+	return nil
+}
+
+// readFile reads KEY=VALUE lines from the file at the given path. Empty lines and
+// lines starting with # are ignored and values may optionally be quoted, which makes
+// the file compatible with docker-compose env files.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open config file")
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("line %d: expected KEY=VALUE, got %q", n, line)
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		values[key] = value
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "scan config file")
+	}
+
+	return values, nil
+}
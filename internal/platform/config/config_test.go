@@ -0,0 +1,383 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "listd.env")
+	contents := `# Settings shared by every environment.
+LIST_DAEMON_PORT=4000
+LIST_DB_HOST="file-host"
+LIST_DB_NAME=file-name
+LIST_READ_TIMEOUT=1m
+`
+	if err := ioutil.WriteFile(file, []byte(contents), 0600); err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	// Default (no other sources)
+	{
+		cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil)
+		if err != nil {
+			t.Fatalf("error loading configuration: %v", err)
+		}
+
+		if d := cmp.Diff(Default(), cfg); d != "" {
+			t.Errorf("unexpected difference in configuration:\n%v", d)
+		}
+	}
+
+	// Precedence (defaults < file < environment < flags)
+	{
+		t.Setenv("LIST_DB_HOST", "env-host")
+		t.Setenv("LIST_DB_NAME", "env-name")
+		t.Setenv("LIST_FEATURES", "a, b")
+
+		args := []string{"-config", file, "-db-name", "flag-name"}
+
+		cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+		if err != nil {
+			t.Fatalf("error loading configuration: %v", err)
+		}
+
+		expected := Default()
+		expected.DaemonPort = 4000
+		expected.ReadTimeout = time.Minute
+		expected.DBHost = "env-host"
+		expected.DBName = "flag-name"
+		expected.Features = []string{"a", "b"}
+
+		if d := cmp.Diff(expected, cfg); d != "" {
+			t.Errorf("unexpected difference in configuration:\n%v", d)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	unknown := filepath.Join(dir, "unknown.env")
+	if err := ioutil.WriteFile(unknown, []byte("LIST_DB_HSOT=db\n"), 0600); err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	tests := []struct {
+		Name     string
+		Args     []string
+		Env      map[string]string
+		Expected []string
+	}{
+		{
+			Name:     "UnknownFileKey",
+			Args:     []string{"-config", unknown},
+			Expected: []string{"unknown key LIST_DB_HSOT"},
+		},
+		{
+			Name:     "MissingFile",
+			Args:     []string{"-config", filepath.Join(dir, "missing.env")},
+			Expected: []string{"open config file"},
+		},
+		{
+			Name:     "MalformedEnv",
+			Env:      map[string]string{"LIST_READ_TIMEOUT": "5"},
+			Expected: []string{"environment variable LIST_READ_TIMEOUT", "expected a duration"},
+		},
+		{
+			Name:     "MalformedFlag",
+			Args:     []string{"-daemon-port", "http"},
+			Expected: []string{"flag -daemon-port", "expected an integer"},
+		},
+		{
+			Name: "InvalidValues",
+			Args: []string{"-daemon-port", "0", "-log-level", "verbose"},
+			Expected: []string{
+				"LIST_DAEMON_PORT (-daemon-port): must be a port between 1 and 65535, got 0",
+				`LIST_LOG_LEVEL (-log-level): must be one of debug, info, warn, or error, got "verbose"`,
+			},
+		},
+		{
+			Name:     "PageSizeAboveMax",
+			Args:     []string{"-page-size", "100", "-max-page-size", "10"},
+			Expected: []string{"LIST_PAGE_SIZE (-page-size): must be a positive number of at most the maximum page size 10, got 100"},
+		},
+		{
+			Name:     "ZeroMaxResults",
+			Args:     []string{"-max-results", "0"},
+			Expected: []string{"LIST_MAX_RESULTS (-max-results): must be a positive number, got 0"},
+		},
+		{
+			Name:     "CacheWithoutTTL",
+			Args:     []string{"-cache-size", "100", "-cache-ttl", "0s"},
+			Expected: []string{"LIST_CACHE_TTL (-cache-ttl): must be a positive duration such as 5s when the cache is enabled, got 0s"},
+		},
+		{
+			Name:     "ZeroMaxBodySize",
+			Args:     []string{"-max-body-size", "0"},
+			Expected: []string{"LIST_MAX_BODY_SIZE (-max-body-size): must be a positive number of bytes, got 0"},
+		},
+		{
+			Name:     "ZeroPurgeBatchSize",
+			Args:     []string{"-purge-batch-size", "0"},
+			Expected: []string{"LIST_PURGE_BATCH_SIZE (-purge-batch-size): must be a positive number, got 0"},
+		},
+		{
+			Name:     "NegativeQuota",
+			Args:     []string{"-quota-max-items", "-1"},
+			Expected: []string{"LIST_QUOTA_MAX_ITEMS (-quota-max-items): must be 0 or a positive number, got -1"},
+		},
+		{
+			Name:     "ZeroLogSampleRate",
+			Args:     []string{"-log-sample-rate", "0"},
+			Expected: []string{"LIST_LOG_SAMPLE_RATE (-log-sample-rate): must be a positive number, got 0"},
+		},
+		{
+			Name:     "NegativeSlowRequestThreshold",
+			Args:     []string{"-slow-request-threshold", "-1s"},
+			Expected: []string{"LIST_SLOW_REQUEST_THRESHOLD (-slow-request-threshold): must be 0 or a positive duration such as 1s, got -1s"},
+		},
+		{
+			Name:     "InvalidIDStrategy",
+			Args:     []string{"-id-strategy", "uuid"},
+			Expected: []string{`LIST_ID_STRATEGY (-id-strategy): must be empty or one of serial or snowflake, got "uuid"`},
+		},
+		{
+			Name:     "InvalidReadOnly",
+			Args:     []string{"-read-only", "true"},
+			Expected: []string{`LIST_READ_ONLY (-read-only): must be empty or one of replica or migrating, got "true"`},
+		},
+		{
+			Name:     "InvalidSchemaDrift",
+			Args:     []string{"-schema-drift", "ignore"},
+			Expected: []string{`LIST_SCHEMA_DRIFT (-schema-drift): must be one of refuse or warn, got "ignore"`},
+		},
+		{
+			Name:     "InvalidSigningKeyID",
+			Args:     []string{"-signing-keys", "billing:0123456789abcdef,ship ping:0123456789abcdef"},
+			Expected: []string{`LIST_SIGNING_KEYS (-signing-keys): must only contain id:secret pairs whose id consists of letters, digits, dashes, underscores, and periods, got one with id "ship ping"`},
+		},
+		{
+			Name:     "ShortSigningSecret",
+			Args:     []string{"-signing-keys", "billing:secret"},
+			Expected: []string{`LIST_SIGNING_KEYS (-signing-keys): must only contain secrets of at least 16 characters, the one of "billing" is shorter`},
+		},
+		{
+			Name:     "DuplicateSigningKey",
+			Args:     []string{"-signing-keys", "billing:0123456789abcdef,billing:fedcba9876543210"},
+			Expected: []string{`LIST_SIGNING_KEYS (-signing-keys): must not contain the id "billing" more than once`},
+		},
+		{
+			Name:     "SignaturesRequiredWithoutKeys",
+			Args:     []string{"-require-signatures", "true"},
+			Expected: []string{`LIST_REQUIRE_SIGNATURES (-require-signatures): requires at least one signing key`},
+		},
+		{
+			Name:     "InvalidTrustedProxy",
+			Args:     []string{"-trusted-proxies", "10.0.0.0/8,proxy.local"},
+			Expected: []string{`LIST_TRUSTED_PROXIES (-trusted-proxies): must only contain networks such as 10.0.0.0/8 or IP addresses, got "proxy.local"`},
+		},
+		{
+			Name:     "PublicReadPortOfDaemon",
+			Args:     []string{"-public-read-port", "3000"},
+			Expected: []string{"LIST_PUBLIC_READ_PORT (-public-read-port): must differ from the daemon and admin ports, got 3000"},
+		},
+		{
+			Name:     "InvalidPublicReadOrigin",
+			Args:     []string{"-public-read-origins", "https://lists.example.com,lists.example.com"},
+			Expected: []string{`LIST_PUBLIC_READ_ORIGINS (-public-read-origins): must only contain origins such as https://lists.example.com or *, got "lists.example.com"`},
+		},
+		{
+			Name:     "ZeroPublicReadBurst",
+			Args:     []string{"-public-read-burst", "0"},
+			Expected: []string{"LIST_PUBLIC_READ_BURST (-public-read-burst): must be a positive number, got 0"},
+		},
+		{
+			Name:     "NegativeShareTTL",
+			Args:     []string{"-share-ttl", "-1h"},
+			Expected: []string{"LIST_SHARE_TTL (-share-ttl): must be 0 or a positive duration such as 720h, got -1h0m0s"},
+		},
+		{
+			Name:     "InvalidFault",
+			Args:     []string{"-faults", "GET /list 200ms 0.1,GET /list/:lid 1s 2"},
+			Expected: []string{`LIST_FAULTS (-faults): must only contain a method, path, latency, and error rate between 0 and 1 such as GET /list/:lid 200ms 0.1, got "GET /list/:lid 1s 2"`},
+		},
+		{
+			Name:     "LocalStorageWithoutSecret",
+			Args:     []string{"-storage-driver", "local", "-storage-path", "/var/lib/listd/files"},
+			Expected: []string{"LIST_STORAGE_SECRET (-storage-secret): must be at least 16 characters long when storing files locally"},
+		},
+		{
+			Name:     "S3StorageWithoutBucket",
+			Args:     []string{"-storage-driver", "s3", "-storage-s3-endpoint", "http://minio:9000", "-storage-s3-access-key", "listd", "-storage-s3-secret-key", "0123456789abcdef"},
+			Expected: []string{"LIST_STORAGE_S3_BUCKET (-storage-s3-bucket): must be set when storing files in s3"},
+		},
+		{
+			Name:     "LongStorageURLTTL",
+			Args:     []string{"-storage-url-ttl", "192h"},
+			Expected: []string{"LIST_STORAGE_URL_TTL (-storage-url-ttl): must be a positive duration no longer than a week, got 192h0m0s"},
+		},
+		{
+			Name:     "ZeroNotifyMaxAttempts",
+			Args:     []string{"-notify-url", "https://hooks.slack.com/services/T0/B0/X", "-notify-max-attempts", "0"},
+			Expected: []string{"LIST_NOTIFY_MAX_ATTEMPTS (-notify-max-attempts): must be a positive number, got 0"},
+		},
+		{
+			Name:     "NegativeNotifyVersion",
+			Args:     []string{"-notify-url", "https://hooks.slack.com/services/T0/B0/X", "-notify-event-version", "-1"},
+			Expected: []string{"LIST_NOTIFY_EVENT_VERSION (-notify-event-version): must be 0 or a positive number, got -1"},
+		},
+		{
+			Name:     "InvalidTenantDomain",
+			Args:     []string{"-tenant-domain", "localhost"},
+			Expected: []string{`LIST_TENANT_DOMAIN (-tenant-domain): must be a domain name such as lists.example.com, got "localhost"`},
+		},
+		{
+			Name:     "UnknownTrailingSlash",
+			Args:     []string{"-trailing-slash", "ignore"},
+			Expected: []string{`LIST_TRAILING_SLASH (-trailing-slash): must be one of redirect or rewrite, got "ignore"`},
+		},
+		{
+			Name:     "NegativeDuplicateWindow",
+			Args:     []string{"-duplicate-window", "-1s"},
+			Expected: []string{"LIST_DUPLICATE_WINDOW (-duplicate-window): must be 0 or a positive duration such as 5s, got -1s"},
+		},
+		{
+			Name:     "LongCoalesceWindow",
+			Args:     []string{"-coalesce-window", "2s"},
+			Expected: []string{"LIST_COALESCE_WINDOW (-coalesce-window): must be between 0 and 1s such as 5ms, got 2s"},
+		},
+		{
+			Name:     "RequestTimeoutAboveWriteTimeout",
+			Args:     []string{"-request-timeout", "1m", "-write-timeout", "30s"},
+			Expected: []string{"LIST_REQUEST_TIMEOUT (-request-timeout): must be 0 or a positive duration no longer than the write timeout of 30s, got 1m0s"},
+		},
+		{
+			Name:     "MaxRequestTimeoutAboveWriteTimeout",
+			Args:     []string{"-max-request-timeout", "1m", "-write-timeout", "30s"},
+			Expected: []string{"LIST_MAX_REQUEST_TIMEOUT (-max-request-timeout): must be 0 or a positive duration no longer than the write timeout of 30s, got 1m0s"},
+		},
+		{
+			Name:     "NegativeWorkers",
+			Args:     []string{"-workers", "-1"},
+			Expected: []string{"LIST_WORKERS (-workers): must be 0 or a positive number, got -1"},
+		},
+		{
+			Name:     "ZeroJobQueueSize",
+			Args:     []string{"-job-queue-size", "0"},
+			Expected: []string{"LIST_JOB_QUEUE_SIZE (-job-queue-size): must be a positive number when workers are enabled, got 0"},
+		},
+		{
+			Name:     "ZeroImportBatchSize",
+			Args:     []string{"-import-batch-size", "0"},
+			Expected: []string{"LIST_IMPORT_BATCH_SIZE (-import-batch-size): must be a positive number, got 0"},
+		},
+		{
+			Name:     "UnknownEnvelope",
+			Args:     []string{"-envelope", "none"},
+			Expected: []string{`LIST_ENVELOPE (-envelope): must be one of wrapped or raw, got "none"`},
+		},
+		{
+			Name:     "UnknownValidation",
+			Args:     []string{"-validation", "loose"},
+			Expected: []string{`LIST_VALIDATION (-validation): must be one of strict or lenient, got "loose"`},
+		},
+		{
+			Name:     "NameMaxLengthAboveColumnSize",
+			Args:     []string{"-name-max-length", "256"},
+			Expected: []string{"LIST_NAME_MAX_LENGTH (-name-max-length): must be a number between 1 and 255, got 256"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			for k, v := range test.Env {
+				t.Setenv(k, v)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+
+			_, err := Load(fs, test.Args)
+			if err == nil {
+				t.Fatal("expected error loading configuration, got nil")
+			}
+
+			for _, e := range test.Expected {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("expected error to contain %q, got: %v", e, err)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "listd.env")
+
+	write := func(contents string) {
+		if err := ioutil.WriteFile(file, []byte(contents), 0600); err != nil {
+			t.Fatalf("error writing config file: %v", err)
+		}
+	}
+
+	write("LIST_LOG_LEVEL=info\nLIST_DAEMON_PORT=4000\n")
+
+	w, err := Watch(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", file, "-log-format", "json"})
+	if err != nil {
+		t.Fatalf("error loading configuration: %v", err)
+	}
+
+	// Reloadable settings are taken over, the rest requires a restart and flags keep
+	// taking precedence.
+	write("LIST_LOG_LEVEL=debug\nLIST_LOG_FORMAT=text\nLIST_DAEMON_PORT=5000\nLIST_FEATURES=a\n" +
+		"LIST_PUBLIC_READ_PER_MINUTE=30\nLIST_PUBLIC_READ_BURST=5\nLIST_SHARE_PER_MINUTE=0\nLIST_SHARE_BURST=10\n")
+
+	cfg, restart, err := w.Reload()
+	if err != nil {
+		t.Fatalf("error reloading configuration: %v", err)
+	}
+
+	expected := Default()
+	expected.DaemonPort = 4000
+	expected.LogLevel = "debug"
+	expected.LogFormat = "json"
+	expected.Features = []string{"a"}
+	expected.PublicReadPerMinute = 30
+	expected.PublicReadBurst = 5
+	expected.SharePerMinute = 0
+	expected.ShareBurst = 10
+
+	if d := cmp.Diff(expected, cfg); d != "" {
+		t.Errorf("unexpected difference in reloaded configuration:\n%v", d)
+	}
+
+	if d := cmp.Diff(expected, w.Config()); d != "" {
+		t.Errorf("unexpected difference in active configuration:\n%v", d)
+	}
+
+	if d := cmp.Diff([]string{"LIST_DAEMON_PORT"}, restart); d != "" {
+		t.Errorf("unexpected difference in settings requiring a restart:\n%v", d)
+	}
+
+	// An invalid configuration is rejected and the active one is kept.
+	write("LIST_LOG_LEVEL=verbose\n")
+
+	if _, _, err := w.Reload(); err == nil {
+		t.Error("expected error reloading invalid configuration, got nil")
+	}
+
+	if d := cmp.Diff(expected, w.Config()); d != "" {
+		t.Errorf("unexpected difference in active configuration:\n%v", d)
+	}
+}
@@ -1,38 +1,67 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
-	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus"
 )
 
 var (
 	// PSQLErrUniqueConstraint holds the error code that denotes a unique constraint is
 	// attempting to be violated.
 	PSQLErrUniqueConstraint = "23505"
+
+	// PSQLErrQuotaExceeded holds the error code raised by the quota triggers when a list
+	// or item is inserted beyond the quota of the database.
+	PSQLErrQuotaExceeded = "LQ001"
 )
 
+// Executor is the set of methods shared by *sqlx.DB and *sqlx.Tx, which allows the
+// functions querying the database to run either on their own or as part of a
+// transaction.
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Prepare(query string) (*sql.Stmt, error)
+	Preparex(query string) (*sqlx.Stmt, error)
+	QueryRowx(query string, args ...interface{}) *sqlx.Row
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
+// Config contains the settings needed to connect to the postgres database.
 type Config struct {
 	User string
 	Pass string
 	Name string
 	Host string
 	Port int
+
+	// Schema is the postgres schema tables are looked up and created in, see
+	// TenantSchema. The search_path of the user applies when it is empty.
+	Schema string
 }
 
-// NewConnection returns a new database connection with the schema applied, if not already
-// applied.
-func NewConnection(cfg Config) (*sqlx.DB, error) {
+// NewConnection returns a new database connection, waiting for the database to become
+// available. Progress of establishing the connection is logged to log. The schema is
+// not applied, see Migrate.
+func NewConnection(cfg Config, log logrus.FieldLogger) (*sqlx.DB, error) {
 	var db *sqlx.DB
 	var err error
 
 	conn := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%d sslmode=disable",
 		cfg.User, cfg.Pass, cfg.Name, cfg.Host, cfg.Port)
 
+	// The search_path is sent along when connecting, so every connection of the pool
+	// has it without running SET first.
+	if cfg.Schema != "" {
+		conn += " search_path=" + cfg.Schema
+	}
+
 	log.Info("connecting to postgres database...")
 	if db, err = sqlx.Connect("postgres", conn); err != nil {
 		ticker := time.NewTicker(time.Second * 1)
@@ -59,9 +88,5 @@ func NewConnection(cfg Config) (*sqlx.DB, error) {
 	}
 	log.Info("verified postgres connection")
 
-	if _, err = db.Exec(schema); err != nil {
-		return nil, errors.Wrap(err, "apply database schema")
-	}
-
 	return db, nil
 }
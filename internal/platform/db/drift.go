@@ -0,0 +1,209 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// selectRecorded is a query that selects what was recorded about every applied migration,
+// in the order they were applied in.
+const selectRecorded = `SELECT version, description, checksum, schema FROM schema_migration ORDER BY version;`
+
+// Verification is the outcome of comparing the schema of the database to the one its
+// migrations built.
+type Verification struct {
+	Drifted     bool      `json:"drifted"`
+	Differences []string  `json:"differences"`
+	Verified    time.Time `json:"verified"`
+}
+
+// Verify compares the schema of the database to the one its migrations built. The
+// database drifted when a migration is pending or unknown to this version of the daemon,
+// when the script of an applied migration was changed since, or when its tables, columns,
+// or indexes differ from those recorded once the last migration was applied. Every such
+// difference is described by the Verification.
+func Verify(dbc *sqlx.DB) (Verification, error) {
+	if _, err := dbc.Exec(createMigrationsTable); err != nil {
+		return Verification{}, errors.Wrap(err, "create schema_migration table")
+	}
+
+	var recorded []struct {
+		Version     int     `db:"version"`
+		Description string  `db:"description"`
+		Checksum    string  `db:"checksum"`
+		Schema      *string `db:"schema"`
+	}
+	if err := Select(dbc, &recorded, selectRecorded); err != nil {
+		return Verification{}, errors.Wrap(err, "select applied migrations")
+	}
+
+	v := Verification{
+		Differences: make([]string, 0),
+		Verified:    time.Now().UTC(),
+	}
+
+	known := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	applied := make(map[int]bool, len(recorded))
+	var schema *string
+
+	for _, r := range recorded {
+		applied[r.Version] = true
+		if r.Schema != nil {
+			schema = r.Schema
+		}
+
+		m, ok := known[r.Version]
+		switch {
+		case !ok:
+			v.Differences = append(v.Differences, fmt.Sprintf("migration %d (%s) is applied but unknown to this version", r.Version, r.Description))
+		case r.Checksum != "" && r.Checksum != m.Checksum():
+			v.Differences = append(v.Differences, fmt.Sprintf("migration %d (%s) was changed since it was applied", r.Version, r.Description))
+		}
+	}
+
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			v.Differences = append(v.Differences, fmt.Sprintf("migration %d (%s) is pending", m.Version, m.Description))
+		}
+	}
+
+	// Databases migrated before schemas were recorded have nothing to compare against
+	// until they are migrated again, see baseline.
+	if schema != nil {
+		var want []Table
+		if err := json.Unmarshal([]byte(*schema), &want); err != nil {
+			return Verification{}, errors.Wrap(err, "unmarshal recorded schema")
+		}
+
+		got, err := inspectTables(dbc)
+		if err != nil {
+			return Verification{}, err
+		}
+
+		v.Differences = append(v.Differences, diffTables(want, got)...)
+	}
+
+	v.Drifted = len(v.Differences) > 0
+
+	return v, nil
+}
+
+// diffTables describes every difference of the tables got to the tables want. The table
+// keeping track of migrations is left out, its columns are added as they are needed.
+func diffTables(want, got []Table) []string {
+	var diffs []string
+
+	wanted := make(map[string]Table, len(want))
+	for _, t := range want {
+		wanted[t.Name] = t
+	}
+
+	found := make(map[string]bool, len(got))
+	for _, g := range got {
+		found[g.Name] = true
+
+		if g.Name == "schema_migration" {
+			continue
+		}
+
+		w, ok := wanted[g.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("table %s is unexpected", g.Name))
+			continue
+		}
+
+		diffs = append(diffs, diffColumns(g.Name, w.Columns, g.Columns)...)
+		diffs = append(diffs, diffIndexes(g.Name, w.Indexes, g.Indexes)...)
+	}
+
+	for _, w := range want {
+		if !found[w.Name] && w.Name != "schema_migration" {
+			diffs = append(diffs, fmt.Sprintf("table %s is missing", w.Name))
+		}
+	}
+
+	return diffs
+}
+
+// diffColumns describes every difference of the columns got of a table to want.
+func diffColumns(table string, want, got []Column) []string {
+	var diffs []string
+
+	wanted := make(map[string]Column, len(want))
+	for _, c := range want {
+		wanted[c.Name] = c
+	}
+
+	found := make(map[string]bool, len(got))
+	for _, g := range got {
+		found[g.Name] = true
+
+		w, ok := wanted[g.Name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("column %s.%s is unexpected", table, g.Name))
+		case describeColumn(w) != describeColumn(g):
+			diffs = append(diffs, fmt.Sprintf("column %s.%s changed from %s to %s", table, g.Name, describeColumn(w), describeColumn(g)))
+		}
+	}
+
+	for _, w := range want {
+		if !found[w.Name] {
+			diffs = append(diffs, fmt.Sprintf("column %s.%s is missing", table, w.Name))
+		}
+	}
+
+	return diffs
+}
+
+// describeColumn returns the type of c along with whether it is nullable and its default.
+func describeColumn(c Column) string {
+	d := c.Type
+	if !c.Nullable {
+		d += " NOT NULL"
+	}
+	if c.Default != nil {
+		d += " DEFAULT " + *c.Default
+	}
+
+	return d
+}
+
+// diffIndexes describes every difference of the indexes got of a table to want.
+func diffIndexes(table string, want, got []Index) []string {
+	var diffs []string
+
+	wanted := make(map[string]Index, len(want))
+	for _, i := range want {
+		wanted[i.Name] = i
+	}
+
+	found := make(map[string]bool, len(got))
+	for _, g := range got {
+		found[g.Name] = true
+
+		w, ok := wanted[g.Name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("index %s on %s is unexpected", g.Name, table))
+		case w.Definition != g.Definition:
+			diffs = append(diffs, fmt.Sprintf("index %s on %s changed from %q to %q", g.Name, table, w.Definition, g.Definition))
+		}
+	}
+
+	for _, w := range want {
+		if !found[w.Name] {
+			diffs = append(diffs, fmt.Sprintf("index %s on %s is missing", w.Name, table))
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTables(t *testing.T) {
+	now := "now()"
+
+	want := []Table{
+		{
+			Name: "list",
+			Columns: []Column{
+				{Name: "list_id", Type: "integer"},
+				{Name: "name", Type: "character varying"},
+				{Name: "created", Type: "timestamp without time zone", Default: &now},
+			},
+			Indexes: []Index{{Name: "list_name", Definition: "CREATE UNIQUE INDEX list_name ON public.list USING btree (lower((name)::text))"}},
+		},
+		{Name: "job", Columns: []Column{{Name: "job_id", Type: "integer"}}},
+		{Name: "schema_migration", Columns: []Column{{Name: "version", Type: "integer"}}},
+	}
+
+	got := []Table{
+		{
+			Name: "list",
+			Columns: []Column{
+				{Name: "list_id", Type: "integer"},
+				{Name: "name", Type: "text", Nullable: true},
+				{Name: "note", Type: "text", Nullable: true},
+			},
+		},
+		{Name: "scratch", Columns: []Column{{Name: "id", Type: "integer"}}},
+		{Name: "schema_migration", Columns: []Column{{Name: "version", Type: "integer"}, {Name: "checksum", Type: "text"}}},
+	}
+
+	expected := []string{
+		"column list.name changed from character varying NOT NULL to text",
+		"column list.note is unexpected",
+		"column list.created is missing",
+		"index list_name on list is missing",
+		"table scratch is unexpected",
+		"table job is missing",
+	}
+
+	if d := diffTables(want, got); !reflect.DeepEqual(expected, d) {
+		t.Errorf("expected differences:\n%q\ngot differences:\n%q", expected, d)
+	}
+
+	if d := diffTables(want, want); len(d) != 0 {
+		t.Errorf("expected no differences of a schema to itself, got %q", d)
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	web.MapErrors(StatusOf)
+}
+
+// StatusOf returns the status code of the response to a request that failed with the
+// given database error, or 0 for errors that are the fault of the server. Timeouts,
+// lost connections, and conflicts between transactions are answered with a 503 since
+// retrying the request is expected to succeed. Violated constraints are the fault of
+// the request, answered with a 409 or a 400, and exceeded quotas with a 422.
+func StatusOf(err error) int {
+	err = errors.Cause(err)
+
+	switch err {
+	case context.DeadlineExceeded, context.Canceled:
+		return http.StatusServiceUnavailable
+	}
+
+	if Disconnected(err) {
+		return http.StatusServiceUnavailable
+	}
+
+	pgerr, ok := err.(*pq.Error)
+	if !ok {
+		return 0
+	}
+
+	switch pgerr.Code.Class() {
+	case "08", "53", "57": // Connection exceptions, insufficient resources, operator intervention.
+		return http.StatusServiceUnavailable
+	case "40": // Serialization failures and deadlocks.
+		return http.StatusServiceUnavailable
+	case "22": // Data exceptions such as values that are too long or out of range.
+		return http.StatusBadRequest
+	}
+
+	switch pgerr.Code {
+	case pq.ErrorCode(PSQLErrUniqueConstraint), "23503": // Unique and foreign key violations.
+		return http.StatusConflict
+	case "23502", "23514": // Not null and check violations.
+		return http.StatusBadRequest
+	case pq.ErrorCode(PSQLErrQuotaExceeded):
+		return http.StatusUnprocessableEntity
+	}
+
+	return 0
+}
+
+// Disconnected reports whether err, or its cause, comes from losing the connection to
+// the database, such as when the server restarts or fails over, rather than from the
+// query itself.
+func Disconnected(err error) bool {
+	err = errors.Cause(err)
+
+	switch err {
+	case nil:
+		return false
+	case sql.ErrConnDone, driver.ErrBadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	pgerr, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+
+	switch pgerr.Code {
+	case "57P01", "57P02", "57P03": // Admin shutdown, crash shutdown, cannot connect now.
+		return true
+	}
+
+	return pgerr.Code.Class() == "08" // Connection exceptions.
+}
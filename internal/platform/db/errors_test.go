@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected int
+	}{
+		{
+			Name:     "Unknown",
+			Err:      errors.New("boom"),
+			Expected: 0,
+		},
+		{
+			Name:     "Timeout",
+			Err:      errors.Wrap(context.DeadlineExceeded, "select list"),
+			Expected: http.StatusServiceUnavailable,
+		},
+		{
+			Name:     "AdminShutdown",
+			Err:      errors.Wrap(&pq.Error{Code: "57P01"}, "select list"),
+			Expected: http.StatusServiceUnavailable,
+		},
+		{
+			Name:     "UnexpectedEOF",
+			Err:      errors.Wrap(io.ErrUnexpectedEOF, "insert list"),
+			Expected: http.StatusServiceUnavailable,
+		},
+		{
+			Name:     "StatementTimeout",
+			Err:      &pq.Error{Code: "57014"},
+			Expected: http.StatusServiceUnavailable,
+		},
+		{
+			Name:     "Deadlock",
+			Err:      &pq.Error{Code: "40P01"},
+			Expected: http.StatusServiceUnavailable,
+		},
+		{
+			Name:     "UniqueViolation",
+			Err:      errors.Wrap(&pq.Error{Code: pq.ErrorCode(PSQLErrUniqueConstraint)}, "insert list"),
+			Expected: http.StatusConflict,
+		},
+		{
+			Name:     "CheckViolation",
+			Err:      &pq.Error{Code: "23514"},
+			Expected: http.StatusBadRequest,
+		},
+		{
+			Name:     "QuotaExceeded",
+			Err:      &pq.Error{Code: pq.ErrorCode(PSQLErrQuotaExceeded), Table: "list", Detail: "10"},
+			Expected: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:     "ValueTooLong",
+			Err:      &pq.Error{Code: "22001"},
+			Expected: http.StatusBadRequest,
+		},
+		{
+			Name:     "SyntaxError",
+			Err:      &pq.Error{Code: "42601"},
+			Expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if a := StatusOf(test.Err); a != test.Expected {
+				t.Errorf("expected status code %d, got %d", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestDisconnected(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Err      error
+		Expected bool
+	}{
+		{Name: "Nil"},
+		{Name: "BadConn", Err: driver.ErrBadConn, Expected: true},
+		{Name: "EOF", Err: errors.Wrap(io.EOF, "select list"), Expected: true},
+		{Name: "Network", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, Expected: true},
+		{Name: "ConnectionFailure", Err: &pq.Error{Code: "08006"}, Expected: true},
+		{Name: "CannotConnectNow", Err: &pq.Error{Code: "57P03"}, Expected: true},
+		{Name: "StatementTimeout", Err: &pq.Error{Code: "57014"}},
+		{Name: "NoRows", Err: sql.ErrNoRows},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if a := Disconnected(test.Err); a != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// These constants are the queries the ID strategy is read and switched with.
+const (
+	// selectIDStrategy is a query that selects the strategy IDs are generated by.
+	selectIDStrategy = "SELECT strategy FROM id_strategy;"
+
+	// updateIDStrategy is a query that switches the strategy IDs are generated by.
+	updateIDStrategy = "UPDATE id_strategy SET strategy = $1, modified = NOW();"
+)
+
+// IDStrategy is how the IDs of new lists, items, and templates are generated. It is kept
+// in the database, so every replica sharing it generates them alike, and applied by the
+// next_id function their ID columns default to, so every row follows it whether it is
+// inserted by the daemon, an import, or a SQL script.
+type IDStrategy string
+
+// These constants define the strategies IDs can be generated by.
+const (
+	// IDSerial numbers the rows of every table from 1 up in the order they are inserted.
+	IDSerial IDStrategy = "serial"
+
+	// IDSnowflake generates 63-bit IDs that sort by the time they were generated, which
+	// don't give away how many rows there are: the milliseconds since snowflakeEpoch make
+	// up their upper 41 bits, the sequence of the table the lower 22. They are larger
+	// than the integers JavaScript represents exactly, so browsers should ask for string
+	// IDs, see web.WithStringIDs.
+	IDSnowflake IDStrategy = "snowflake"
+)
+
+// snowflakeEpoch is the time the milliseconds of snowflake IDs are counted from, which
+// next_id hardcodes.
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// snowflakeShift is the amount of bits of snowflake IDs taken by the sequence.
+const snowflakeShift = 22
+
+// Valid reports whether s is one of the known strategies.
+func (s IDStrategy) Valid() bool {
+	return s == IDSerial || s == IDSnowflake
+}
+
+// SnowflakeTime returns the time the snowflake ID id was generated, to the millisecond.
+func SnowflakeTime(id int64) time.Time {
+	return snowflakeEpoch.Add(time.Duration(id>>snowflakeShift) * time.Millisecond)
+}
+
+// CurrentIDStrategy returns the strategy the IDs of new rows are generated by.
+func CurrentIDStrategy(dbc Executor) (IDStrategy, error) {
+	var s IDStrategy
+	if err := Get(dbc, &s, selectIDStrategy); err != nil {
+		return "", errors.Wrap(err, "select id strategy")
+	}
+
+	return s, nil
+}
+
+// SetIDStrategy switches to generating the IDs of new rows by s, rows keep the IDs they
+// have. Switching from IDSerial to IDSnowflake keeps IDs ordered by the time their rows
+// were inserted, since snowflake IDs are larger than serial ones, but switching back
+// doesn't: the serial IDs that follow are smaller than every snowflake ID.
+func SetIDStrategy(dbc Executor, s IDStrategy) error {
+	if !s.Valid() {
+		return errors.Errorf("unknown id strategy %q", s)
+	}
+
+	_, err := dbc.Exec(updateIDStrategy, string(s))
+	return errors.Wrap(err, "update id strategy")
+}
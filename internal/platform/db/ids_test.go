@@ -0,0 +1,21 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnowflakeTime(t *testing.T) {
+	generated := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+
+	// The ID next_id generates at that time when the sequence of the table is at 4242.
+	id := generated.Sub(snowflakeEpoch).Milliseconds()<<snowflakeShift | 4242
+
+	if e, a := generated, SnowflakeTime(id); !e.Equal(a) {
+		t.Errorf("expected time %v, got %v", e, a)
+	}
+
+	if e, a := snowflakeEpoch, SnowflakeTime(4242); !e.Equal(a) {
+		t.Errorf("expected time %v for a serial id, got %v", e, a)
+	}
+}
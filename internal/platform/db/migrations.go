@@ -0,0 +1,552 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Migration is a versioned change to the postgres database schema of the list daemon.
+type Migration struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Script      string `json:"-"`
+}
+
+// Checksum returns the SHA-256 checksum of the script of m, which is recorded when it is
+// applied so a script that was changed after its release can be told apart, see Verify.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Script))
+	return hex.EncodeToString(sum[:])
+}
+
+// migrations contains every migration of the database schema in the order they have to
+// be applied in. Migrations that have been released must never be changed, new changes
+// to the schema are appended as a new migration instead.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create list and item tables",
+		Script: `
+CREATE TABLE IF NOT EXISTS list (
+	list_id SERIAL PRIMARY KEY,
+	name varchar(255) NOT NULL UNIQUE,
+	created timestamp NOT NULL DEFAULT NOW(),
+	modified timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS item (
+	item_id SERIAL PRIMARY KEY,
+	list_id int NOT NULL,
+	name varchar(255) NOT NULL,
+	quantity int NOT NULL,
+	created timestamp NOT NULL DEFAULT NOW(),
+	modified timestamp NOT NULL DEFAULT NOW(),
+	FOREIGN KEY(list_id) REFERENCES list(list_id)
+);`,
+	},
+	{
+		Version:     2,
+		Description: "create maintenance table",
+		Script: `
+CREATE TABLE maintenance (
+	singleton boolean PRIMARY KEY DEFAULT true CHECK (singleton),
+	enabled boolean NOT NULL DEFAULT false,
+	message text NOT NULL DEFAULT '',
+	modified timestamp NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO maintenance DEFAULT VALUES;`,
+	},
+	{
+		Version:     3,
+		Description: "create outbox table",
+		Script: `
+CREATE TABLE outbox (
+	event_id uuid PRIMARY KEY,
+	type varchar(255) NOT NULL,
+	version int NOT NULL,
+	list_id int,
+	data jsonb NOT NULL,
+	created timestamp NOT NULL,
+	published timestamp
+);
+
+CREATE INDEX outbox_pending ON outbox (created) WHERE published IS NULL;`,
+	},
+	{
+		Version:     4,
+		Description: "index outbox by list",
+		Script: `
+CREATE INDEX outbox_list ON outbox (list_id, created);`,
+	},
+	{
+		Version:     5,
+		Description: "create template tables",
+		Script: `
+CREATE TABLE template (
+	template_id SERIAL PRIMARY KEY,
+	name varchar(255) NOT NULL UNIQUE,
+	created timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE template_item (
+	template_item_id SERIAL PRIMARY KEY,
+	template_id int NOT NULL REFERENCES template(template_id) ON DELETE CASCADE,
+	name varchar(255) NOT NULL,
+	quantity int NOT NULL
+);`,
+	},
+	{
+		Version:     6,
+		Description: "add unit to items",
+		Script: `
+ALTER TABLE item ADD COLUMN unit varchar(32) NOT NULL DEFAULT '';
+ALTER TABLE template_item ADD COLUMN unit varchar(32) NOT NULL DEFAULT '';`,
+	},
+	{
+		Version:     7,
+		Description: "make item names unique within a list",
+		Script: `
+UPDATE item SET name = left(name, 240) || ' (' || item_id || ')'
+	WHERE item_id NOT IN (SELECT min(item_id) FROM item GROUP BY list_id, lower(name));
+
+CREATE UNIQUE INDEX item_list_name ON item (list_id, lower(name));`,
+	},
+	{
+		Version:     8,
+		Description: "make list names unique regardless of case",
+		Script: `
+UPDATE list SET name = left(name, 240) || ' (' || list_id || ')'
+	WHERE list_id NOT IN (SELECT min(list_id) FROM list GROUP BY lower(name));
+
+ALTER TABLE list DROP CONSTRAINT list_name_key;
+
+CREATE UNIQUE INDEX list_name ON list (lower(name));`,
+	},
+	{
+		Version:     9,
+		Description: "count the items of every list",
+		Script: `
+ALTER TABLE list ADD COLUMN item_count int NOT NULL DEFAULT 0;
+
+UPDATE list SET item_count = (SELECT count(*) FROM item WHERE item.list_id = list.list_id);
+
+CREATE FUNCTION count_list_items() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		UPDATE list SET item_count = item_count + 1 WHERE list_id = NEW.list_id;
+	END IF;
+
+	IF TG_OP IN ('DELETE', 'UPDATE') THEN
+		UPDATE list SET item_count = item_count - 1 WHERE list_id = OLD.list_id;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER item_count AFTER INSERT OR DELETE OR UPDATE OF list_id ON item
+	FOR EACH ROW EXECUTE PROCEDURE count_list_items();`,
+	},
+	{
+		Version:     10,
+		Description: "create job table",
+		Script: `
+CREATE TABLE job (
+	job_id SERIAL PRIMARY KEY,
+	kind varchar(32) NOT NULL,
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	progress int NOT NULL DEFAULT 0,
+	result jsonb,
+	error text NOT NULL DEFAULT '',
+	created timestamp NOT NULL DEFAULT NOW(),
+	modified timestamp NOT NULL DEFAULT NOW()
+);`,
+	},
+	{
+		Version:     11,
+		Description: "create list settings table",
+		Script: `
+CREATE TABLE list_settings (
+	list_id int PRIMARY KEY REFERENCES list(list_id) ON DELETE CASCADE,
+	settings jsonb NOT NULL,
+	modified timestamp NOT NULL DEFAULT NOW()
+);`,
+	},
+	{
+		Version:     12,
+		Description: "create item history table",
+		Script: `
+CREATE TABLE item_history (
+	history_id serial PRIMARY KEY,
+	item_id int NOT NULL REFERENCES item(item_id) ON DELETE CASCADE,
+	field text NOT NULL,
+	old_value jsonb NOT NULL,
+	new_value jsonb NOT NULL,
+	changed timestamp NOT NULL DEFAULT NOW()
+);
+CREATE INDEX item_history_item ON item_history (item_id, history_id);`,
+	},
+	{
+		Version:     13,
+		Description: "create webhook dead letter table",
+		Script: `
+CREATE TABLE webhook_dead_letter (
+	dead_letter_id serial PRIMARY KEY,
+	event_id uuid NOT NULL,
+	event_type varchar(255) NOT NULL,
+	payload jsonb NOT NULL,
+	attempts int NOT NULL,
+	last_error text NOT NULL,
+	failed timestamp NOT NULL DEFAULT NOW()
+);`,
+	},
+	{
+		Version:     14,
+		Description: "create quota table",
+		Script: `
+CREATE TABLE quota (
+	singleton boolean PRIMARY KEY DEFAULT true CHECK (singleton),
+	default_max_lists int NOT NULL DEFAULT 0,
+	default_max_items int NOT NULL DEFAULT 0,
+	max_lists int CHECK (max_lists >= 0),
+	max_items int CHECK (max_items >= 0),
+	modified timestamp NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO quota DEFAULT VALUES;
+
+CREATE FUNCTION enforce_quota() RETURNS trigger AS $$
+DECLARE
+	lim int;
+	used int;
+BEGIN
+	IF TG_TABLE_NAME = 'list' THEN
+		SELECT COALESCE(max_lists, default_max_lists) INTO lim FROM quota;
+		SELECT count(*) INTO used FROM list;
+	ELSE
+		-- Merging into an item with the same name doesn't add an item to the list.
+		IF EXISTS (SELECT 1 FROM item WHERE list_id = NEW.list_id AND lower(name) = lower(NEW.name)) THEN
+			RETURN NEW;
+		END IF;
+
+		SELECT COALESCE(max_items, default_max_items) INTO lim FROM quota;
+		SELECT count(*) INTO used FROM item WHERE list_id = NEW.list_id;
+	END IF;
+
+	IF lim > 0 AND used >= lim THEN
+		RAISE EXCEPTION 'quota of % rows in % exceeded', lim, TG_TABLE_NAME
+			USING ERRCODE = 'LQ001', TABLE = TG_TABLE_NAME, DETAIL = lim::text;
+	END IF;
+
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER list_quota BEFORE INSERT ON list
+	FOR EACH ROW EXECUTE PROCEDURE enforce_quota();
+
+CREATE TRIGGER item_quota BEFORE INSERT ON item
+	FOR EACH ROW EXECUTE PROCEDURE enforce_quota();`,
+	},
+	{
+		Version:     15,
+		Description: "add icon and color to list table",
+		Script: `
+ALTER TABLE list
+	ADD COLUMN icon varchar(64) NOT NULL DEFAULT '',
+	ADD COLUMN color varchar(7) NOT NULL DEFAULT '' CHECK (color ~ '^(#[0-9a-f]{6})?$');`,
+	},
+	{
+		Version:     16,
+		Description: "generate list, item, and template ids by the id strategy",
+		Script: `
+CREATE TABLE id_strategy (
+	singleton boolean PRIMARY KEY DEFAULT true CHECK (singleton),
+	strategy varchar(32) NOT NULL DEFAULT 'serial' CHECK (strategy IN ('serial', 'snowflake')),
+	modified timestamp NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO id_strategy DEFAULT VALUES;
+
+CREATE FUNCTION next_id(seq regclass) RETURNS bigint AS $$
+	SELECT CASE (SELECT strategy FROM id_strategy)
+		WHEN 'snowflake' THEN
+			((floor(extract(epoch FROM clock_timestamp()) * 1000)::bigint - 1577836800000) << 22) | (nextval(seq) & 4194303)
+		ELSE nextval(seq)
+	END;
+$$ LANGUAGE sql VOLATILE;
+
+ALTER SEQUENCE list_list_id_seq AS bigint;
+ALTER SEQUENCE item_item_id_seq AS bigint;
+ALTER SEQUENCE template_template_id_seq AS bigint;
+
+ALTER TABLE list ALTER COLUMN list_id TYPE bigint, ALTER COLUMN list_id SET DEFAULT next_id('list_list_id_seq');
+ALTER TABLE item ALTER COLUMN item_id TYPE bigint, ALTER COLUMN item_id SET DEFAULT next_id('item_item_id_seq'),
+	ALTER COLUMN list_id TYPE bigint;
+ALTER TABLE template ALTER COLUMN template_id TYPE bigint, ALTER COLUMN template_id SET DEFAULT next_id('template_template_id_seq');
+ALTER TABLE template_item ALTER COLUMN template_id TYPE bigint;
+ALTER TABLE list_settings ALTER COLUMN list_id TYPE bigint;
+ALTER TABLE item_history ALTER COLUMN item_id TYPE bigint;
+ALTER TABLE outbox ALTER COLUMN list_id TYPE bigint;`,
+	},
+	{
+		Version:     17,
+		Description: "create attachment table",
+		Script: `
+CREATE SEQUENCE attachment_attachment_id_seq AS bigint;
+
+CREATE TABLE attachment (
+	attachment_id bigint PRIMARY KEY DEFAULT next_id('attachment_attachment_id_seq'),
+	item_id bigint NOT NULL REFERENCES item(item_id) ON DELETE CASCADE,
+	name varchar(255) NOT NULL,
+	content_type varchar(255) NOT NULL,
+	size bigint NOT NULL,
+	storage_key text NOT NULL UNIQUE,
+	created timestamp NOT NULL DEFAULT NOW(),
+	modified timestamp NOT NULL DEFAULT NOW(),
+	UNIQUE (item_id, name)
+);
+
+ALTER SEQUENCE attachment_attachment_id_seq OWNED BY attachment.attachment_id;`,
+	},
+	{
+		Version:     18,
+		Description: "create list_share table and add public to template table",
+		Script: `
+CREATE TABLE list_share (
+	list_id bigint PRIMARY KEY REFERENCES list(list_id) ON DELETE CASCADE,
+	token varchar(64) NOT NULL UNIQUE,
+	created timestamp NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE template ADD COLUMN public boolean NOT NULL DEFAULT false;`,
+	},
+	{
+		Version:     19,
+		Description: "check quotas against locked rows",
+		Script: `
+-- Concurrent inserts each counted the rows committed before them and could exceed a
+-- quota together. Lists are now checked while holding the quota row and items while
+-- holding their list, whose item_count is the amount of items checked against.
+CREATE OR REPLACE FUNCTION enforce_quota() RETURNS trigger AS $$
+DECLARE
+	lim int;
+	used int;
+BEGIN
+	IF TG_TABLE_NAME = 'list' THEN
+		SELECT COALESCE(max_lists, default_max_lists) INTO lim FROM quota;
+		IF lim = 0 THEN
+			RETURN NEW;
+		END IF;
+
+		SELECT COALESCE(max_lists, default_max_lists) INTO lim FROM quota FOR UPDATE;
+		SELECT count(*) INTO used FROM list;
+	ELSE
+		SELECT COALESCE(max_items, default_max_items) INTO lim FROM quota;
+		IF lim = 0 THEN
+			RETURN NEW;
+		END IF;
+
+		SELECT item_count INTO used FROM list WHERE list_id = NEW.list_id FOR UPDATE;
+
+		-- Merging into an item with the same name doesn't add an item to the list.
+		IF EXISTS (SELECT 1 FROM item WHERE list_id = NEW.list_id AND lower(name) = lower(NEW.name)) THEN
+			RETURN NEW;
+		END IF;
+	END IF;
+
+	IF used >= lim THEN
+		RAISE EXCEPTION 'quota of % rows in % exceeded', lim, TG_TABLE_NAME
+			USING ERRCODE = 'LQ001', TABLE = TG_TABLE_NAME, DETAIL = lim::text;
+	END IF;
+
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`,
+	},
+	{
+		Version:     20,
+		Description: "add expires to list_share table",
+		Script: `
+ALTER TABLE list_share ADD COLUMN expires timestamp;`,
+	},
+}
+
+// createMigrationsTable is the query that creates the table keeping track of which
+// migrations have been applied.
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migration (
+	version int PRIMARY KEY,
+	description text NOT NULL,
+	applied timestamp NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE schema_migration ADD COLUMN IF NOT EXISTS checksum text NOT NULL DEFAULT '';
+ALTER TABLE schema_migration ADD COLUMN IF NOT EXISTS schema jsonb;`
+
+// MigrationStatus is a migration along with the time it was applied at, which is nil
+// for pending migrations.
+type MigrationStatus struct {
+	Migration
+	Applied *time.Time `json:"applied" db:"applied"`
+}
+
+// Migrations returns the status of every known migration in the order they are applied
+// in.
+func Migrations(dbc *sqlx.DB) ([]MigrationStatus, error) {
+	if _, err := dbc.Exec(createMigrationsTable); err != nil {
+		return nil, errors.Wrap(err, "create schema_migration table")
+	}
+
+	var applied []struct {
+		Version int       `db:"version"`
+		Applied time.Time `db:"applied"`
+	}
+	if err := dbc.Select(&applied, "SELECT version, applied FROM schema_migration;"); err != nil {
+		return nil, errors.Wrap(err, "select applied migrations")
+	}
+
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedAt[a.Version] = a.Applied
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i].Migration = m
+
+		if t, ok := appliedAt[m.Version]; ok {
+			statuses[i].Applied = &t
+		}
+	}
+
+	return statuses, nil
+}
+
+// Migrate applies every pending migration, each in its own transaction, and returns
+// the migrations that were applied. Concurrent calls from multiple replicas are
+// serialized through an advisory lock.
+func Migrate(dbc *sqlx.DB, log logrus.FieldLogger) ([]Migration, error) {
+	// Advisory locks are held by a session, so a single connection is used throughout.
+	conn, err := dbc.DB.Conn(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "get connection")
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_lock($1);", migrationLockID); err != nil {
+		return nil, errors.Wrap(err, "acquire migration lock")
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockID); err != nil {
+			log.WithError(err).Error("release migration lock")
+		}
+	}()
+
+	statuses, err := Migrations(dbc)
+	if err != nil {
+		return nil, errors.Wrap(err, "get migration status")
+	}
+
+	var applied []Migration
+	for _, s := range statuses {
+		if s.Applied != nil {
+			continue
+		}
+
+		log.WithFields(logrus.Fields{
+			"version":     s.Version,
+			"description": s.Description,
+		}).Info("applying migration")
+
+		if err := apply(dbc, s.Migration); err != nil {
+			return applied, errors.Wrapf(err, "apply migration %d", s.Version)
+		}
+
+		applied = append(applied, s.Migration)
+	}
+
+	if err := baseline(dbc); err != nil {
+		return applied, errors.Wrap(err, "record baseline of schema")
+	}
+
+	return applied, nil
+}
+
+// baseline records the checksums of migrations and the schema they built for databases
+// migrated before they were recorded, taking the database as it is as the baseline that
+// Verify compares against.
+func baseline(dbc *sqlx.DB) error {
+	for _, m := range migrations {
+		if _, err := dbc.Exec("UPDATE schema_migration SET checksum = $2 WHERE version = $1 AND checksum = '';", m.Version, m.Checksum()); err != nil {
+			return errors.Wrapf(err, "record checksum of migration %d", m.Version)
+		}
+	}
+
+	var recorded bool
+	if err := dbc.Get(&recorded, "SELECT count(*) = 0 OR bool_or(schema IS NOT NULL) FROM schema_migration;"); err != nil {
+		return errors.Wrap(err, "check recorded schema")
+	}
+
+	if recorded {
+		return nil
+	}
+
+	tables, err := inspectTables(dbc)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(tables)
+	if err != nil {
+		return errors.Wrap(err, "marshal schema")
+	}
+
+	_, err = dbc.Exec("UPDATE schema_migration SET schema = $1 WHERE version = (SELECT max(version) FROM schema_migration);", string(b))
+	return errors.Wrap(err, "record schema")
+}
+
+// migrationLockID is the key of the postgres advisory lock held while migrating.
+const migrationLockID = 4242
+
+// apply runs the script of the given migration and records it as applied within a
+// single transaction, along with its checksum and the schema it built.
+func apply(dbc *sqlx.DB, m Migration) error {
+	tx, err := dbc.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	if _, err := tx.Exec(m.Script); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "run migration script")
+	}
+
+	tables, err := inspectTables(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "inspect migrated schema")
+	}
+
+	schema, err := json.Marshal(tables)
+	if err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "marshal migrated schema")
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migration (version, description, checksum, schema) VALUES ($1, $2, $3, $4);", m.Version, m.Description, m.Checksum(), string(schema)); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, "record migration")
+	}
+
+	return errors.Wrap(tx.Commit(), "commit transaction")
+}
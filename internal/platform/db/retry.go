@@ -0,0 +1,62 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// These variables define how reads that lost their connection are retried, see
+// RetryRead.
+var (
+	// ReadAttempts is the amount of times a read is attempted in total.
+	ReadAttempts = 3
+
+	// ReadBackoff is how long the first retry of a read waits, every further retry waits
+	// twice as long as the one before it.
+	ReadBackoff = 100 * time.Millisecond
+)
+
+// RetryRead runs the read fn, running it again with backoff when it fails because the
+// connection to the database was lost, such as when the server restarts or fails over,
+// up to ReadAttempts times in total. Reads are only retried when dbc is a *sqlx.DB, or a
+// Traced one, whose pool connects again, since a transaction is lost along with its
+// connection. fn must not write, writes that lost their connection are answered with a
+// 503 instead, see StatusOf.
+func RetryRead(dbc Executor, fn func() error) error {
+	err := fn()
+
+	if t, ok := dbc.(Traced); ok {
+		dbc = t.Executor
+	}
+
+	if _, ok := dbc.(*sqlx.DB); !ok {
+		return err
+	}
+
+	backoff := ReadBackoff
+	for attempt := 1; attempt < ReadAttempts && Disconnected(err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+
+		err = fn()
+	}
+
+	return err
+}
+
+// Get runs the read query with dbc.Get, retrying it if the connection was lost, see
+// RetryRead.
+func Get(dbc Executor, dest interface{}, query string, args ...interface{}) error {
+	return RetryRead(dbc, func() error {
+		return dbc.Get(dest, query, args...)
+	})
+}
+
+// Select runs the read query with dbc.Select, retrying it if the connection was lost,
+// see RetryRead.
+func Select(dbc Executor, dest interface{}, query string, args ...interface{}) error {
+	return RetryRead(dbc, func() error {
+		return dbc.Select(dest, query, args...)
+	})
+}
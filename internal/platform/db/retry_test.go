@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+func TestRetryRead(t *testing.T) {
+	defer func(backoff time.Duration) { ReadBackoff = backoff }(ReadBackoff)
+	ReadBackoff = time.Millisecond
+
+	// The pool is never used, fn stands in for the read.
+	pool := sqlx.NewDb(new(sql.DB), "postgres")
+
+	tests := []struct {
+		Name          string
+		DB            Executor
+		Errs          []error
+		ExpectedCalls int
+		ExpectedErr   bool
+	}{
+		{
+			Name:          "Success",
+			DB:            pool,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:          "Reconnected",
+			DB:            pool,
+			Errs:          []error{driver.ErrBadConn, errors.Wrap(&pq.Error{Code: "57P01"}, "select list")},
+			ExpectedCalls: 3,
+		},
+		{
+			Name:          "StillDisconnected",
+			DB:            pool,
+			Errs:          []error{driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn},
+			ExpectedCalls: 3,
+			ExpectedErr:   true,
+		},
+		{
+			Name:          "QueryError",
+			DB:            pool,
+			Errs:          []error{sql.ErrNoRows},
+			ExpectedCalls: 1,
+			ExpectedErr:   true,
+		},
+		{
+			Name:          "Traced",
+			DB:            Traced{Executor: pool, Trace: new(Trace)},
+			Errs:          []error{driver.ErrBadConn},
+			ExpectedCalls: 2,
+		},
+		{
+			Name:          "Transaction",
+			DB:            (*sqlx.Tx)(nil),
+			Errs:          []error{driver.ErrBadConn},
+			ExpectedCalls: 1,
+			ExpectedErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var calls int
+			err := RetryRead(test.DB, func() error {
+				calls++
+				if calls <= len(test.Errs) {
+					return test.Errs[calls-1]
+				}
+				return nil
+			})
+
+			if e, a := test.ExpectedCalls, calls; e != a {
+				t.Errorf("expected %d calls, got %d", e, a)
+			}
+
+			if test.ExpectedErr != (err != nil) {
+				t.Errorf("expected an error: %v, got %v", test.ExpectedErr, err)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
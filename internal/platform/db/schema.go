@@ -1,21 +1,109 @@
 package db
 
-// schema is the constant that contains the postgres database schema for
-// the list daemon.
-const schema = `
-CREATE TABLE IF NOT EXISTS list (
-	list_id SERIAL PRIMARY KEY,
-	name varchar(255) NOT NULL UNIQUE,
-	created timestamp NOT NULL DEFAULT NOW(),
-	modified timestamp NOT NULL DEFAULT NOW()
-);
-
-CREATE TABLE IF NOT EXISTS item (
-	item_id SERIAL PRIMARY KEY,
-	list_id int NOT NULL,
-	name varchar(255) NOT NULL,
-	quantity int NOT NULL,
-	created timestamp NOT NULL DEFAULT NOW(),
-	modified timestamp NOT NULL DEFAULT NOW(),
-	FOREIGN KEY(list_id) REFERENCES list(list_id)
-);`
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// These constants are the queries Inspect reads the live schema with.
+const (
+	// selectColumns is a query that selects every column of the tables of the current
+	// schema, which is public unless the connection is for a tenant, ordered by table and
+	// position.
+	selectColumns = `SELECT table_name, column_name, data_type, is_nullable = 'YES' AS nullable, column_default
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		ORDER BY table_name, ordinal_position;`
+
+	// selectIndexes is a query that selects every index of the tables of the current
+	// schema along with its definition, ordered by table and name.
+	selectIndexes = `SELECT tablename, indexname, indexdef FROM pg_indexes
+		WHERE schemaname = current_schema()
+		ORDER BY tablename, indexname;`
+)
+
+// Schema is the live schema of the database along with the migrations that built it.
+type Schema struct {
+	Migrations []MigrationStatus `json:"migrations"`
+	Tables     []Table           `json:"tables"`
+}
+
+// Table is a table of the database with its columns and indexes.
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+	Indexes []Index  `json:"indexes"`
+}
+
+// Column is a column of a table. Default is the expression of its default value, if it
+// has one.
+type Column struct {
+	Name     string  `json:"name" db:"column_name"`
+	Type     string  `json:"type" db:"data_type"`
+	Nullable bool    `json:"nullable" db:"nullable"`
+	Default  *string `json:"default" db:"column_default"`
+}
+
+// Index is an index of a table, along with the statement that creates it.
+type Index struct {
+	Name       string `json:"name" db:"indexname"`
+	Definition string `json:"definition" db:"indexdef"`
+}
+
+// Inspect returns the schema of the database as it is, read from information_schema and
+// the catalogs of postgres, with its tables ordered by name.
+func Inspect(dbc *sqlx.DB) (Schema, error) {
+	migrations, err := Migrations(dbc)
+	if err != nil {
+		return Schema{}, errors.Wrap(err, "get migration status")
+	}
+
+	tables, err := inspectTables(dbc)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return Schema{Migrations: migrations, Tables: tables}, nil
+}
+
+// inspectTables returns the tables of the database ordered by name, see Inspect.
+func inspectTables(dbc Executor) ([]Table, error) {
+	var columns []struct {
+		Table string `db:"table_name"`
+		Column
+	}
+	if err := Select(dbc, &columns, selectColumns); err != nil {
+		return nil, errors.Wrap(err, "select columns")
+	}
+
+	var indexes []struct {
+		Table string `db:"tablename"`
+		Index
+	}
+	if err := Select(dbc, &indexes, selectIndexes); err != nil {
+		return nil, errors.Wrap(err, "select indexes")
+	}
+
+	tables := make([]Table, 0)
+
+	// Columns are ordered by table, so every table starts with its first column.
+	at := make(map[string]int)
+	for _, c := range columns {
+		i, ok := at[c.Table]
+		if !ok {
+			i = len(tables)
+			at[c.Table] = i
+			tables = append(tables, Table{Name: c.Table, Indexes: make([]Index, 0)})
+		}
+
+		tables[i].Columns = append(tables[i].Columns, c.Column)
+	}
+
+	for _, idx := range indexes {
+		if i, ok := at[idx.Table]; ok {
+			tables[i].Indexes = append(tables[i].Indexes, idx.Index)
+		}
+	}
+
+	return tables, nil
+}
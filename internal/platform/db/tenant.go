@@ -0,0 +1,60 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// tenantPrefix is the prefix of the postgres schemas of tenants, which sets them apart
+// from public and the schemas of postgres itself.
+const tenantPrefix = "tenant_"
+
+// validTenant matches the names of tenants. They are used as subdomains, so they follow
+// the rules of DNS labels, and are short enough for the schema name to stay within the
+// 63 characters postgres allows for identifiers.
+var validTenant = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,46}[a-z0-9])?$`)
+
+// selectTenants is a query that selects the schemas of every tenant ordered by name.
+const selectTenants = `SELECT nspname FROM pg_namespace WHERE nspname LIKE 'tenant\_%' ORDER BY nspname;`
+
+// TenantSchema returns the name of the postgres schema holding the tables of the given
+// tenant, such as tenant_acme for acme. Hyphens are replaced by underscores so the name
+// never has to be quoted, tenants can't contain underscores so no two share a schema.
+func TenantSchema(tenant string) (string, error) {
+	if !validTenant.MatchString(tenant) {
+		return "", errors.Errorf("invalid tenant %q, expected at most 48 lowercase letters, digits, and inner hyphens", tenant)
+	}
+
+	return tenantPrefix + strings.ReplaceAll(tenant, "-", "_"), nil
+}
+
+// CreateTenant creates the postgres schema of the given tenant unless it already exists.
+// Its tables are created by migrating a connection for the schema, see Config.Schema.
+func CreateTenant(dbc *sqlx.DB, tenant string) error {
+	schema, err := TenantSchema(tenant)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbc.Exec("CREATE SCHEMA IF NOT EXISTS " + schema + ";")
+	return errors.Wrapf(err, "create schema %s", schema)
+}
+
+// Tenants returns every tenant with a postgres schema in alphabetical order of their
+// schemas.
+func Tenants(dbc *sqlx.DB) ([]string, error) {
+	var schemas []string
+	if err := dbc.Select(&schemas, selectTenants); err != nil {
+		return nil, errors.Wrap(err, "select tenant schemas")
+	}
+
+	tenants := make([]string, 0, len(schemas))
+	for _, s := range schemas {
+		tenants = append(tenants, strings.ReplaceAll(strings.TrimPrefix(s, tenantPrefix), "_", "-"))
+	}
+
+	return tenants, nil
+}
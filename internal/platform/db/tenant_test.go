@@ -0,0 +1,63 @@
+package db
+
+import "testing"
+
+func TestTenantSchema(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Tenant   string
+		Expected string
+	}{
+		{
+			Name:     "Letters",
+			Tenant:   "acme",
+			Expected: "tenant_acme",
+		},
+		{
+			Name:     "Hyphens",
+			Tenant:   "acme-eu-1",
+			Expected: "tenant_acme_eu_1",
+		},
+		{
+			Name:   "Underscore",
+			Tenant: "acme_eu",
+		},
+		{
+			Name:   "Uppercase",
+			Tenant: "Acme",
+		},
+		{
+			Name:   "LeadingHyphen",
+			Tenant: "-acme",
+		},
+		{
+			Name:   "Quote",
+			Tenant: `acme"; DROP SCHEMA public; --`,
+		},
+		{
+			Name: "Empty",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			schema, err := TenantSchema(test.Tenant)
+			if test.Expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got schema %q", schema)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error getting schema: %v", err)
+			}
+
+			if schema != test.Expected {
+				t.Errorf("expected schema %q, got %q", test.Expected, schema)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
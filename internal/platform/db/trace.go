@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Query is a query run against the database along with how long it took.
+type Query struct {
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+}
+
+// Trace remembers the slowest query of those run through a Traced executor, which is
+// the one worth explaining when the work they were run for turned out slow. It is safe
+// for concurrent use.
+type Trace struct {
+	mu      sync.Mutex
+	slowest Query
+	seen    bool
+}
+
+// Slowest returns the slowest query traced so far, and false if there was none.
+func (t *Trace) Slowest() (Query, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.slowest, t.seen
+}
+
+// observe records the query q that started at the given time.
+func (t *Trace) observe(start time.Time, q string, args []interface{}) {
+	d := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.seen || d > t.slowest.Duration {
+		t.slowest = Query{SQL: q, Args: args, Duration: d}
+		t.seen = true
+	}
+}
+
+// traceKey is the context key of the Trace of a context.
+type traceKey struct{}
+
+// WithTrace returns a copy of ctx carrying t, see TraceOf.
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceOf returns the Trace carried by ctx, or nil if it carries none.
+func TraceOf(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+// Traced is an Executor recording the queries run through it to Trace. Queries run within
+// transactions begun elsewhere aren't traced.
+type Traced struct {
+	Executor
+	Trace *Trace
+}
+
+// Exec implements the Executor interface.
+func (t Traced) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer t.Trace.observe(time.Now(), query, args)
+	return t.Executor.Exec(query, args...)
+}
+
+// Get implements the Executor interface.
+func (t Traced) Get(dest interface{}, query string, args ...interface{}) error {
+	defer t.Trace.observe(time.Now(), query, args)
+	return t.Executor.Get(dest, query, args...)
+}
+
+// Select implements the Executor interface.
+func (t Traced) Select(dest interface{}, query string, args ...interface{}) error {
+	defer t.Trace.observe(time.Now(), query, args)
+	return t.Executor.Select(dest, query, args...)
+}
+
+// QueryRowx implements the Executor interface.
+func (t Traced) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	defer t.Trace.observe(time.Now(), query, args)
+	return t.Executor.QueryRowx(query, args...)
+}
+
+// Queryx implements the Executor interface. Only the time until the first rows are
+// returned is traced.
+func (t Traced) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	defer t.Trace.observe(time.Now(), query, args)
+	return t.Executor.Queryx(query, args...)
+}
+
+// Explain returns the plan postgres chooses for q, one node per line. The query is only
+// planned, not run, since EXPLAIN is given without ANALYZE, and only queries that read
+// are explained at all. Planning is given up on once ctx is done.
+func Explain(ctx context.Context, dbc *sqlx.DB, q Query) (string, error) {
+	if !reads(q.SQL) {
+		return "", errors.New("only queries that read are explained")
+	}
+
+	var lines []string
+	if err := dbc.SelectContext(ctx, &lines, "EXPLAIN "+q.SQL, q.Args...); err != nil {
+		return "", errors.Wrap(err, "explain query")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// reads reports whether q is a single statement that only reads. Queries that start
+// with WITH are not considered reads, since their statements may write.
+func reads(q string) bool {
+	q = strings.TrimRight(strings.TrimSpace(q), ";")
+
+	fields := strings.Fields(q)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT") && !strings.Contains(q, ";")
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// sleeper is an Executor whose queries take as long as their first argument says.
+type sleeper struct {
+	Executor
+}
+
+// Get implements the Executor interface.
+func (sleeper) Get(dest interface{}, query string, args ...interface{}) error {
+	time.Sleep(args[0].(time.Duration))
+	return nil
+}
+
+func TestTrace(t *testing.T) {
+	var trace Trace
+	if _, ok := trace.Slowest(); ok {
+		t.Error("expected no slowest query before any was traced")
+	}
+
+	dbc := Traced{Executor: sleeper{}, Trace: &trace}
+
+	for _, q := range []struct {
+		SQL      string
+		Duration time.Duration
+	}{
+		{"SELECT 1;", time.Millisecond},
+		{"SELECT 2;", 20 * time.Millisecond},
+		{"SELECT 3;", 5 * time.Millisecond},
+	} {
+		if err := dbc.Get(nil, q.SQL, q.Duration); err != nil {
+			t.Fatalf("error running query: %v", err)
+		}
+	}
+
+	slowest, ok := trace.Slowest()
+	if !ok {
+		t.Fatal("expected a slowest query")
+	}
+
+	if e, a := "SELECT 2;", slowest.SQL; e != a {
+		t.Errorf("expected slowest query %q, got %q", e, a)
+	}
+
+	if slowest.Duration < 20*time.Millisecond {
+		t.Errorf("expected the slowest query to take at least 20ms, got %v", slowest.Duration)
+	}
+}
+
+func TestExplainOnlyReads(t *testing.T) {
+	for _, q := range []string{
+		"DELETE FROM list WHERE list_id = $1;",
+		"WITH deleted AS (DELETE FROM list RETURNING *) SELECT * FROM deleted;",
+		"SELECT 1; DELETE FROM list;",
+		"",
+	} {
+		if _, err := Explain(context.Background(), nil, Query{SQL: q}); err == nil {
+			t.Errorf("expected %q not to be explained", q)
+		}
+	}
+
+	if !reads("  select * FROM list WHERE list_id = $1;\n") {
+		t.Error("expected a single SELECT to be explained")
+	}
+}
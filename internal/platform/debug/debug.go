@@ -0,0 +1,88 @@
+package debug
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/julienschmidt/httprouter"
+)
+
+// MemStats is the format used to report heap statistics before and after a forced
+// garbage collection.
+type MemStats struct {
+	HeapAlloc   uint64 `json:"heapAlloc"`
+	HeapInuse   uint64 `json:"heapInuse"`
+	HeapObjects uint64 `json:"heapObjects"`
+	HeapSys     uint64 `json:"heapSys"`
+	NumGC       uint32 `json:"numGC"`
+}
+
+// GCResult is the format used for the response of the GC trigger.
+type GCResult struct {
+	Before MemStats `json:"before"`
+	After  MemStats `json:"after"`
+}
+
+// Register adds the runtime debug endpoints to the given router:
+//
+//	GET  /debug/pprof/*   net/http/pprof profiles
+//	GET  /debug/vars      expvar variables
+//	POST /debug/gc        forces a garbage collection and returns heap statistics
+//
+// These endpoints must never be registered on a publicly reachable router.
+func Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, "/debug/pprof/*profile", profile)
+	router.HandlerFunc(http.MethodPost, "/debug/pprof/*profile", profile)
+
+	router.Handler(http.MethodGet, "/debug/vars", expvar.Handler())
+	router.HandlerFunc(http.MethodPost, "/debug/gc", gc)
+}
+
+// profile is a handler that dispatches to the net/http/pprof handler matching the
+// profile URL parameter. Named profiles such as heap, goroutine, and block are served
+// by pprof.Index based off of the path.
+func profile(w http.ResponseWriter, r *http.Request) {
+	switch httprouter.ParamsFromContext(r.Context()).ByName("profile") {
+	case "/cmdline":
+		pprof.Cmdline(w, r)
+	case "/profile":
+		pprof.Profile(w, r)
+	case "/symbol":
+		pprof.Symbol(w, r)
+	case "/trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
+
+// gc is a handler that forces a garbage collection, returns as much memory to the
+// operating system as possible, and responds with the heap statistics from before
+// and after doing so.
+func gc(w http.ResponseWriter, r *http.Request) {
+	var res GCResult
+
+	res.Before = memStats()
+	debug.FreeOSMemory()
+	res.After = memStats()
+
+	web.Respond(w, r, http.StatusOK, res)
+}
+
+// memStats returns the current heap statistics.
+func memStats() MemStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return MemStats{
+		HeapAlloc:   m.HeapAlloc,
+		HeapInuse:   m.HeapInuse,
+		HeapObjects: m.HeapObjects,
+		HeapSys:     m.HeapSys,
+		NumGC:       m.NumGC,
+	}
+}
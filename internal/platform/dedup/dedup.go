@@ -0,0 +1,91 @@
+// Package dedup detects requests that are submitted twice in quick succession, such as
+// a form that is double-clicked, so the resource the first one created can be returned
+// instead of failing or creating another one.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// entry is the resource a request created, along with the time it expires at.
+type entry struct {
+	id      int
+	expires time.Time
+}
+
+// Detector remembers the resources created by requests for a fixed window of time. It is
+// safe for concurrent use and a nil *Detector detects nothing.
+type Detector struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// New returns a new Detector remembering each resource for window.
+func New(window time.Duration) *Detector {
+	return &Detector{
+		window:  window,
+		entries: make(map[string]entry),
+	}
+}
+
+// Key returns the key a request is remembered under, which is the hash of the JSON
+// representation of v. v should hold everything that identifies the request, such as the
+// resource it is about and its normalized payload.
+func Key(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal request")
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Remember stores the ID of the resource the request with the given key created.
+// Expired entries are dropped along the way.
+func (d *Detector) Remember(key string, id int) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range d.entries {
+		if now.After(e.expires) {
+			delete(d.entries, k)
+		}
+	}
+
+	d.entries[key] = entry{
+		id:      id,
+		expires: now.Add(d.window),
+	}
+}
+
+// Created returns the ID of the resource a request with the given key created within the
+// window, if there is one.
+func (d *Detector) Created(key string) (int, bool) {
+	if d == nil {
+		return 0, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return 0, false
+	}
+
+	return e.id, true
+}
@@ -0,0 +1,61 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	d := New(time.Hour)
+
+	key, err := Key(map[string]interface{}{"list": 1, "name": "Milk"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := d.Created(key); ok {
+		t.Error("expected unknown key to not be found")
+	}
+
+	d.Remember(key, 42)
+
+	if id, ok := d.Created(key); !ok || id != 42 {
+		t.Errorf("expected 42 to be found, got %d, %v", id, ok)
+	}
+
+	other, err := Key(map[string]interface{}{"list": 2, "name": "Milk"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := d.Created(other); ok {
+		t.Error("expected key of another request to not be found")
+	}
+}
+
+func TestDetectorExpiry(t *testing.T) {
+	d := New(time.Nanosecond)
+
+	d.Remember("a", 1)
+	time.Sleep(time.Millisecond)
+
+	if _, ok := d.Created("a"); ok {
+		t.Error("expected expired key to not be found")
+	}
+
+	d.Remember("b", 2)
+
+	if n := len(d.entries); n != 1 {
+		t.Errorf("expected expired entries to be dropped, got %d entries", n)
+	}
+}
+
+func TestDetectorNil(t *testing.T) {
+	var d *Detector
+
+	d.Remember("a", 1)
+
+	if _, ok := d.Created("a"); ok {
+		t.Error("expected nil detector to detect nothing")
+	}
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// These constants define the type of every event emitted by the list daemon.
+const (
+	ListCreated = "list.created"
+	ListUpdated = "list.updated"
+	ListDeleted = "list.deleted"
+	ItemCreated = "item.created"
+	ItemUpdated = "item.updated"
+	ItemDeleted = "item.deleted"
+)
+
+// Types contains every event type in the order they are documented in.
+var Types = []string{ListCreated, ListUpdated, ListDeleted, ItemCreated, ItemUpdated, ItemDeleted}
+
+// Version is the version of the event format and of the data schemas of every event
+// type. It is bumped whenever a change is made to them, see Revisions.
+const Version = 2
+
+// Event is the format of every message published by the list daemon. Data holds the
+// JSON representation of the resource the event is about, or for deletions only its
+// identifiers.
+type Event struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// New returns a new event of the given type carrying data.
+func New(typ string, data interface{}) (Event, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, errors.Wrap(err, "marshal event data")
+	}
+
+	return Event{
+		ID:      uuid.New(),
+		Type:    typ,
+		Version: Version,
+		Time:    time.Now().UTC(),
+		Data:    b,
+	}, nil
+}
+
+// Publisher publishes events to a message broker.
+type Publisher interface {
+	// Publish publishes a single event. Events with the same ID may be published more
+	// than once, consumers use the ID to deduplicate them.
+	Publish(ctx context.Context, e Event) error
+
+	// Close releases the resources held by the publisher.
+	Close() error
+}
+
+// These constants define the supported publisher drivers.
+const (
+	// DriverNone discards every event.
+	DriverNone = "none"
+
+	// DriverLog logs every event, which is useful during development.
+	DriverLog = "log"
+
+	// DriverNATS publishes every event to a NATS server.
+	DriverNATS = "nats"
+)
+
+// Open returns the publisher of the given driver. The url is only used by drivers
+// connecting to a broker.
+func Open(driver, url string, log logrus.FieldLogger) (Publisher, error) {
+	switch driver {
+	case DriverNone, "":
+		return Discard, nil
+	case DriverLog:
+		return &logPublisher{log: log}, nil
+	case DriverNATS:
+		return DialNATS(url, log)
+	}
+
+	return nil, errors.Errorf("unknown events driver %q", driver)
+}
+
+// Discard is a publisher that discards every event.
+var Discard Publisher = discard{}
+
+// discard is the type of Discard.
+type discard struct{}
+
+// Publish implements the Publisher interface.
+func (discard) Publish(context.Context, Event) error { return nil }
+
+// Close implements the Publisher interface.
+func (discard) Close() error { return nil }
+
+// logPublisher is a publisher that logs every event.
+type logPublisher struct {
+	log logrus.FieldLogger
+}
+
+// Publish implements the Publisher interface.
+func (p *logPublisher) Publish(_ context.Context, e Event) error {
+	p.log.WithFields(logrus.Fields{
+		"eventID":   e.ID,
+		"eventType": e.Type,
+		"data":      string(e.Data),
+	}).Info("published event")
+
+	return nil
+}
+
+// Close implements the Publisher interface.
+func (p *logPublisher) Close() error {
+	return nil
+}
+
+// Tee returns a publisher that publishes every event to each of pubs in order, stopping
+// at the first one that fails. Since failed events are published again, publishers
+// later in pubs only see events that every publisher before them accepted.
+func Tee(pubs ...Publisher) Publisher {
+	return tee(pubs)
+}
+
+// tee is the type returned by Tee.
+type tee []Publisher
+
+// Publish implements the Publisher interface.
+func (t tee) Publish(ctx context.Context, e Event) error {
+	for _, p := range t {
+		if err := p.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close implements the Publisher interface. Every publisher is closed, the first error
+// is returned.
+func (t tee) Close() error {
+	var first error
+	for _, p := range t {
+		if err := p.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
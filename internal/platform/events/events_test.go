@@ -0,0 +1,350 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeNATS is a NATS server speaking just enough of the protocol to accept published
+// messages, which are sent to msgs as subject and payload and to every subscriber.
+type fakeNATS struct {
+	ln   net.Listener
+	msgs chan [2]string
+
+	mu   sync.Mutex
+	subs []net.Conn
+}
+
+// newFakeNATS starts a fake NATS server on a random local port.
+func newFakeNATS(t *testing.T) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+
+	s := fakeNATS{
+		ln:   ln,
+		msgs: make(chan [2]string, 10),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return &s
+}
+
+// serve handles a single client connection.
+func (s *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte(`INFO {"server_id":"fake"}` + "\r\n"))
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "PING":
+			_, _ = conn.Write([]byte("PONG\r\n"))
+		case fields[0] == "PUB" && len(fields) == 3:
+			n, _ := strconv.Atoi(fields[2])
+
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+
+			s.msgs <- [2]string{fields[1], string(payload[:n])}
+
+			s.mu.Lock()
+			for _, sub := range s.subs {
+				_, _ = sub.Write([]byte("MSG " + fields[1] + " 1 " + fields[2] + "\r\n" + string(payload)))
+			}
+			s.mu.Unlock()
+		case fields[0] == "SUB":
+			s.mu.Lock()
+			s.subs = append(s.subs, conn)
+			s.mu.Unlock()
+		}
+	}
+}
+
+func TestNATS(t *testing.T) {
+	s := newFakeNATS(t)
+	defer s.ln.Close()
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	p, err := Open(DriverNATS, "nats://"+s.ln.Addr().String(), log)
+	if err != nil {
+		t.Fatalf("error connecting to fake nats server: %v", err)
+	}
+	defer p.Close()
+
+	e, err := New(ListCreated, map[string]interface{}{"id": 1, "name": "Grocery"})
+	if err != nil {
+		t.Fatalf("error creating event: %v", err)
+	}
+
+	if err := p.Publish(context.Background(), e); err != nil {
+		t.Fatalf("error publishing event: %v", err)
+	}
+
+	select {
+	case msg := <-s.msgs:
+		if e, a := "listd.list.created", msg[0]; e != a {
+			t.Errorf("expected subject: %v, got subject: %v", e, a)
+		}
+
+		var got Event
+		if err := json.Unmarshal([]byte(msg[1]), &got); err != nil {
+			t.Fatalf("error decoding published event: %v", err)
+		}
+
+		if got.ID != e.ID || got.Type != ListCreated || got.Version != Version {
+			t.Errorf("expected event: %+v, got event: %+v", e, got)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("expected event to be published within a second")
+	}
+}
+
+func TestSubscribeNATS(t *testing.T) {
+	s := newFakeNATS(t)
+	defer s.ln.Close()
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	sub, err := SubscribeNATS("nats://"+s.ln.Addr().String(), "", log)
+	if err != nil {
+		t.Fatalf("error subscribing to fake nats server: %v", err)
+	}
+
+	p, err := DialNATS("nats://"+s.ln.Addr().String(), log)
+	if err != nil {
+		t.Fatalf("error connecting to fake nats server: %v", err)
+	}
+	defer p.Close()
+
+	// The subscription is registered asynchronously, so events are published until one
+	// is received.
+	e, err := New(ListDeleted, map[string]interface{}{"id": 1, "name": "Grocery"})
+	if err != nil {
+		t.Fatalf("error creating event: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for received := false; !received; {
+		if err := p.Publish(context.Background(), e); err != nil {
+			t.Fatalf("error publishing event: %v", err)
+		}
+		<-s.msgs
+
+		select {
+		case got := <-sub.Events:
+			if got.ID != e.ID || got.Type != ListDeleted || string(got.Data) != string(e.Data) {
+				t.Errorf("expected event: %+v, got event: %+v", e, got)
+			}
+			received = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("expected event to be received within a second")
+		}
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Errorf("error closing subscription: %v", err)
+	}
+
+	for range sub.Events {
+	}
+
+	if err := sub.Err(); err != nil {
+		t.Errorf("expected no error once closed, got: %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Driver      string
+		URL         string
+		ExpectedErr bool
+	}{
+		{
+			Name:   "None",
+			Driver: DriverNone,
+		},
+		{
+			Name:   "Log",
+			Driver: DriverLog,
+		},
+		{
+			Name:        "Unknown",
+			Driver:      "kafka",
+			ExpectedErr: true,
+		},
+		{
+			Name:        "MalformedNATSURL",
+			Driver:      DriverNATS,
+			URL:         "localhost:4222",
+			ExpectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			p, err := Open(test.Driver, test.URL, logrus.New())
+			if e, a := test.ExpectedErr, err != nil; e != a {
+				t.Fatalf("expected error: %v, got error: %v", e, err)
+			}
+
+			if p != nil {
+				p.Close()
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+// failing is a publisher recording every event it is given and failing when err is set.
+type failing struct {
+	err    error
+	events []Event
+}
+
+// Publish implements the Publisher interface.
+func (f *failing) Publish(_ context.Context, e Event) error {
+	f.events = append(f.events, e)
+	return f.err
+}
+
+// Close implements the Publisher interface.
+func (f *failing) Close() error {
+	return f.err
+}
+
+func TestTee(t *testing.T) {
+	e, err := New(ListCreated, nil)
+	if err != nil {
+		t.Fatalf("error creating event: %v", err)
+	}
+
+	first, second := &failing{}, &failing{}
+	if err := Tee(first, second).Publish(context.Background(), e); err != nil {
+		t.Errorf("error publishing event: %v", err)
+	}
+
+	if len(first.events) != 1 || len(second.events) != 1 {
+		t.Errorf("expected both publishers to receive the event, got %d and %d events", len(first.events), len(second.events))
+	}
+
+	// Publishers after a failing one don't receive the event.
+	first, second = &failing{err: io.EOF}, &failing{}
+	if err := Tee(first, second).Publish(context.Background(), e); err != io.EOF {
+		t.Errorf("expected error %v, got: %v", io.EOF, err)
+	}
+
+	if len(second.events) != 0 {
+		t.Errorf("expected the second publisher to receive no events, got %d", len(second.events))
+	}
+
+	if err := Tee(first, second).Close(); err != io.EOF {
+		t.Errorf("expected close error %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestDowngrade(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Event        Event
+		Version      int
+		ExpectedData string
+		ExpectedErr  bool
+	}{
+		{
+			Name:         "RemovesAddedFields",
+			Event:        Event{Type: ListDeleted, Version: 2, Data: json.RawMessage(`{"id":1,"name":"Groceries"}`)},
+			Version:      1,
+			ExpectedData: `{"id":1}`,
+		},
+		{
+			Name:         "UnrevisedType",
+			Event:        Event{Type: ListCreated, Version: 2, Data: json.RawMessage(`{"id":1,"name":"Groceries"}`)},
+			Version:      1,
+			ExpectedData: `{"id":1,"name":"Groceries"}`,
+		},
+		{
+			Name:         "Latest",
+			Event:        Event{Type: ListDeleted, Version: 2, Data: json.RawMessage(`{"id":1,"name":"Groceries"}`)},
+			Version:      2,
+			ExpectedData: `{"id":1,"name":"Groceries"}`,
+		},
+		{
+			Name:         "EarlierEvent",
+			Event:        Event{Type: ListDeleted, Version: 1, Data: json.RawMessage(`{"id":1}`)},
+			Version:      2,
+			ExpectedData: `{"id":1}`,
+		},
+		{
+			Name:        "UnknownVersion",
+			Event:       Event{Type: ListDeleted, Version: 2, Data: json.RawMessage(`{"id":1}`)},
+			Version:     Version + 1,
+			ExpectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			got, err := Downgrade(test.Event, test.Version)
+			if test.ExpectedErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error downgrading event: %v", err)
+			}
+
+			if e, a := test.ExpectedData, string(got.Data); e != a {
+				t.Errorf("expected data %s, got %s", e, a)
+			}
+
+			if test.Event.Version > test.Version && got.Version != test.Version {
+				t.Errorf("expected version %d, got %d", test.Version, got.Version)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
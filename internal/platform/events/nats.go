@@ -0,0 +1,368 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SubjectPrefix is prepended to the event type to form the NATS subject an event is
+// published on, e.g. listd.list.created.
+const SubjectPrefix = "listd."
+
+// natsPublisher publishes events to a NATS server. It speaks the small subset of the
+// NATS client protocol needed to publish: CONNECT, PUB, and answering PING.
+type natsPublisher struct {
+	addr string
+	log  logrus.FieldLogger
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+
+	// errs receives the errors the server reports on the current connection.
+	errs chan error
+}
+
+// DialNATS connects to the NATS server at the given URL, e.g. nats://localhost:4222.
+func DialNATS(rawurl string, log logrus.FieldLogger) (Publisher, error) {
+	addr, err := natsAddr(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	p := natsPublisher{
+		addr: addr,
+		log:  log,
+	}
+
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// connect establishes a new connection to the server, it has to be called with mu held
+// or before the publisher is shared.
+func (p *natsPublisher) connect() error {
+	conn, r, err := dialNATS(p.addr, "listd")
+	if err != nil {
+		return err
+	}
+
+	p.conn = conn
+	p.w = bufio.NewWriter(conn)
+	p.errs = make(chan error, 1)
+
+	go p.read(conn, r, p.errs)
+
+	return nil
+}
+
+// dialNATS connects to the NATS server at addr as the client with the given name and
+// returns the connection along with a reader of what the server sends on it.
+func dialNATS(addr, name string) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "dial nats server")
+	}
+
+	r := bufio.NewReader(conn)
+
+	// The server greets every client with an INFO line.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, nil, errors.Errorf("expected INFO from nats server, got %q: %v", line, err)
+	}
+
+	// Verbose mode is off so the server only answers with errors and PINGs, the PING
+	// sent right after CONNECT makes sure the connection was accepted.
+	connect, _ := json.Marshal(map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     name,
+		"lang":     "go",
+	})
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		conn.Close()
+		return nil, nil, errors.Wrap(err, "send CONNECT to nats server")
+	}
+
+	if line, err = r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "PONG") {
+		conn.Close()
+		return nil, nil, errors.Errorf("expected PONG from nats server, got %q: %v", strings.TrimSpace(line), err)
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	return conn, r, nil
+}
+
+// read handles the messages sent by the server on conn until it is closed.
+func (p *natsPublisher) read(conn net.Conn, r *bufio.Reader, errs chan<- error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			select {
+			case errs <- errors.Wrap(err, "nats connection closed"):
+			default:
+			}
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			p.mu.Lock()
+			if p.conn == conn {
+				_, _ = p.w.WriteString("PONG\r\n")
+				_ = p.w.Flush()
+			}
+			p.mu.Unlock()
+
+		case strings.HasPrefix(line, "-ERR"):
+			err := errors.Errorf("nats server: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+			p.log.WithError(err).Error("nats server reported an error")
+
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+	}
+}
+
+// Publish implements the Publisher interface. A broken connection is re-established
+// once before giving up.
+func (p *natsPublisher) Publish(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, e.Type, b); err != nil {
+		p.log.WithError(err).Warn("publish to nats failed, reconnecting")
+
+		if p.conn != nil {
+			p.conn.Close()
+			p.conn = nil
+		}
+
+		if err := p.connect(); err != nil {
+			return errors.Wrap(err, "reconnect to nats server")
+		}
+
+		return p.publish(ctx, e.Type, b)
+	}
+
+	return nil
+}
+
+// publish writes a single PUB message, it has to be called with mu held.
+func (p *natsPublisher) publish(ctx context.Context, typ string, payload []byte) error {
+	if p.conn == nil {
+		return errors.New("not connected")
+	}
+
+	select {
+	case err := <-p.errs:
+		return err
+	default:
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = p.conn.SetWriteDeadline(deadline)
+		defer p.conn.SetWriteDeadline(time.Time{})
+	}
+
+	if _, err := p.w.WriteString("PUB " + SubjectPrefix + typ + " " + strconv.Itoa(len(payload)) + "\r\n"); err != nil {
+		return errors.Wrap(err, "write PUB")
+	}
+
+	if _, err := p.w.Write(payload); err != nil {
+		return errors.Wrap(err, "write payload")
+	}
+
+	if _, err := p.w.WriteString("\r\n"); err != nil {
+		return errors.Wrap(err, "write payload")
+	}
+
+	return errors.Wrap(p.w.Flush(), "flush")
+}
+
+// Close implements the Publisher interface.
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn = nil
+
+	return err
+}
+
+// natsAddr returns the address of the NATS server at the given URL.
+func natsAddr(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return "", errors.Errorf("expected a URL such as nats://localhost:4222, got %q", rawurl)
+	}
+
+	return u.Host, nil
+}
+
+// Subscription receives the events published on a NATS server. Core NATS doesn't store
+// messages, so events published while no subscription is open are never received, and
+// subscribers rebuild what they derive from events after connecting, see
+// cmd/projector.
+type Subscription struct {
+	// Events receives every event in the order it was published. It is closed once the
+	// subscription is closed or its connection is lost, Err tells which.
+	Events <-chan Event
+
+	conn net.Conn
+	mu   sync.Mutex
+	err  error
+	once sync.Once
+}
+
+// subscriptionBuffer is the amount of received events waiting to be handled. The server
+// drops a subscriber that falls further behind.
+const subscriptionBuffer = 1000
+
+// SubscribeNATS subscribes to the events of every type published on the NATS server at
+// the given URL. Subscriptions in the same non-empty queue group share the events
+// between them, each event is only received by one of them.
+func SubscribeNATS(rawurl, queue string, log logrus.FieldLogger) (*Subscription, error) {
+	addr, err := natsAddr(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, r, err := dialNATS(addr, "listd-subscriber")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write([]byte("SUB " + SubjectPrefix + "> " + queue + " 1\r\n")); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "send SUB to nats server")
+	}
+
+	events := make(chan Event, subscriptionBuffer)
+	s := Subscription{
+		Events: events,
+		conn:   conn,
+	}
+
+	go s.read(r, events, log)
+
+	return &s, nil
+}
+
+// read handles the messages sent by the server until the connection is closed, sending
+// the events received to events.
+func (s *Subscription) read(r *bufio.Reader, events chan<- Event, log logrus.FieldLogger) {
+	defer close(events)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			s.fail(errors.Wrap(err, "nats connection closed"))
+			return
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+
+		case fields[0] == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				s.fail(errors.Wrap(err, "send PONG to nats server"))
+				return
+			}
+
+		case fields[0] == "-ERR":
+			err := errors.Errorf("nats server: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+			log.WithError(err).Error("nats server reported an error")
+
+		// MSG <subject> <sid> [reply-to] <#bytes>
+		case fields[0] == "MSG" && len(fields) >= 4:
+			n, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				s.fail(errors.Errorf("malformed MSG from nats server: %q", strings.TrimSpace(line)))
+				return
+			}
+
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				s.fail(errors.Wrap(err, "read MSG payload"))
+				return
+			}
+
+			var e Event
+			if err := json.Unmarshal(payload[:n], &e); err != nil {
+				log.WithError(err).WithField("subject", fields[1]).Warn("skip malformed event")
+				continue
+			}
+
+			events <- e
+		}
+	}
+}
+
+// fail records err as the reason the subscription ended, unless it was closed.
+func (s *Subscription) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns why Events was closed: nil when the subscription was closed, the error
+// the connection was lost with otherwise.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == errSubscriptionClosed {
+		return nil
+	}
+
+	return s.err
+}
+
+// errSubscriptionClosed is the reason of subscriptions ended by Close.
+var errSubscriptionClosed = errors.New("subscription closed")
+
+// Close closes the connection of the subscription. Events is closed once the events
+// that were received are handled.
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		s.fail(errSubscriptionClosed)
+		err = s.conn.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Revision is a change made to the data of an event type by a version. Data only
+// evolves additively: a version may add fields, but never removes, renames, or changes
+// the meaning of one, so consumers of an earlier version keep working as long as they
+// ignore unknown fields.
+type Revision struct {
+	Version int
+	Type    string
+
+	// Added contains the fields added to the data.
+	Added []string
+}
+
+// Revisions contains every change made to the data of events since version 1, the
+// oldest version first. Version is bumped along with every change added to it.
+var Revisions = []Revision{
+	{Version: 2, Type: ListDeleted, Added: []string{"name"}},
+	{Version: 2, Type: ItemDeleted, Added: []string{"name"}},
+}
+
+// Downgrade returns e as it was published in the given version, by removing the fields
+// added to its data since. Events of an earlier version than the given one are returned
+// unchanged, since consumers of additive data handle missing fields.
+func Downgrade(e Event, version int) (Event, error) {
+	if version < 1 || version > Version {
+		return Event{}, errors.Errorf("expected a version between 1 and %d, got %d", Version, version)
+	}
+
+	if e.Version <= version {
+		return e, nil
+	}
+
+	var added []string
+	for _, r := range Revisions {
+		if r.Type == e.Type && r.Version > version && r.Version <= e.Version {
+			added = append(added, r.Added...)
+		}
+	}
+
+	e.Version = version
+	if len(added) == 0 {
+		return e, nil
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return Event{}, errors.Wrap(err, "unmarshal event data")
+	}
+
+	for _, field := range added {
+		delete(data, field)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, errors.Wrap(err, "marshal event data")
+	}
+	e.Data = b
+
+	return e, nil
+}
@@ -0,0 +1,272 @@
+// Package expect asserts on the responses of HTTP handlers in tests. Expectations are
+// chained onto the status code of the response and checked at once by Assert:
+//
+//	expect.Status(http.StatusCreated).
+//		JSONPath("results.name", "Foo").
+//		NonZero("results.id").
+//		Assert(t, w)
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+// check is a single expectation about a response, doc is its body decoded as JSON.
+type check func(t testing.TB, w *httptest.ResponseRecorder, doc func() (interface{}, error))
+
+// Response is a set of expectations about an HTTP response, see Status. Every method
+// returns a copy carrying one more expectation, so a Response can be shared by test
+// cases and extended by each of them.
+type Response struct {
+	code   int
+	checks []check
+}
+
+// Status returns a Response that expects the given status code.
+func Status(code int) Response {
+	return Response{code: code}
+}
+
+// with returns a copy of r carrying c as well.
+func (r Response) with(c check) Response {
+	r.checks = append(r.checks[:len(r.checks):len(r.checks)], c)
+	return r
+}
+
+// Header expects the header key of the response to be want.
+func (r Response) Header(key, want string) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, _ func() (interface{}, error)) {
+		t.Helper()
+
+		if got := w.Header().Get(key); got != want {
+			t.Errorf("expected %s header: %q, got %s header: %q", key, want, key, got)
+		}
+	})
+}
+
+// HeaderSet expects the header key of the response to be set to any value.
+func (r Response) HeaderSet(key string) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, _ func() (interface{}, error)) {
+		t.Helper()
+
+		if w.Header().Get(key) == "" {
+			t.Errorf("expected %s header to be set", key)
+		}
+	})
+}
+
+// JSONPath expects the value at path in the JSON body of the response to equal want.
+// The value is decoded into the type of want before comparing them, so want may be a
+// string as well as a list.List or a []list.List. A nil want expects null or no value.
+func (r Response) JSONPath(path string, want interface{}) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, doc func() (interface{}, error)) {
+		t.Helper()
+
+		v, ok, err := lookup(doc, path)
+		if err != nil {
+			t.Errorf("error looking up %s: %v", path, err)
+			return
+		}
+
+		if want == nil {
+			if ok && v != nil {
+				t.Errorf("expected no value at %s, got %v", path, v)
+			}
+			return
+		}
+
+		if !ok {
+			t.Errorf("expected a value at %s, got none", path)
+			return
+		}
+
+		got := reflect.New(reflect.TypeOf(want))
+		if err := convert(v, got.Interface()); err != nil {
+			t.Errorf("error decoding %s: %v", path, err)
+			return
+		}
+
+		if d := cmp.Diff(want, got.Elem().Interface()); d != "" {
+			t.Errorf("unexpected difference at %s:\n%v", path, d)
+		}
+	})
+}
+
+// NonZero expects a value at path in the JSON body of the response that is neither null
+// nor the zero value of its JSON type, for values that aren't known in advance such as
+// the ID of a created resource.
+func (r Response) NonZero(path string) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, doc func() (interface{}, error)) {
+		t.Helper()
+
+		v, _, err := lookup(doc, path)
+		if err != nil {
+			t.Errorf("error looking up %s: %v", path, err)
+			return
+		}
+
+		var zero bool
+		switch v := v.(type) {
+		case nil:
+			zero = true
+		case bool:
+			zero = !v
+		case json.Number:
+			f, _ := v.Float64()
+			zero = f == 0
+		case string:
+			zero = v == ""
+		case []interface{}:
+			zero = len(v) == 0
+		case map[string]interface{}:
+			zero = len(v) == 0
+		}
+
+		if zero {
+			t.Errorf("expected a non-zero value at %s, got %v", path, v)
+		}
+	})
+}
+
+// Len expects the array or object at path in the JSON body of the response to have n
+// elements, no value counts as none.
+func (r Response) Len(path string, n int) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, doc func() (interface{}, error)) {
+		t.Helper()
+
+		v, _, err := lookup(doc, path)
+		if err != nil {
+			t.Errorf("error looking up %s: %v", path, err)
+			return
+		}
+
+		var got int
+		switch v := v.(type) {
+		case []interface{}:
+			got = len(v)
+		case map[string]interface{}:
+			got = len(v)
+		case nil:
+		default:
+			t.Errorf("expected an array or object at %s, got %v", path, v)
+			return
+		}
+
+		if got != n {
+			t.Errorf("expected %d element(s) at %s, got %d: %v", n, path, got, v)
+		}
+	})
+}
+
+// Errors expects the response to carry n errors in its envelope.
+func (r Response) Errors(n int) Response {
+	return r.Len("errors", n)
+}
+
+// Into decodes the value at path in the JSON body of the response into v, for values
+// that later requests of a test depend on such as the ID of a created resource.
+func (r Response) Into(path string, v interface{}) Response {
+	return r.with(func(t testing.TB, w *httptest.ResponseRecorder, doc func() (interface{}, error)) {
+		t.Helper()
+
+		got, ok, err := lookup(doc, path)
+		if err != nil || !ok {
+			t.Errorf("expected a value at %s, got none: %v", path, err)
+			return
+		}
+
+		if err := convert(got, v); err != nil {
+			t.Errorf("error decoding %s: %v", path, err)
+		}
+	})
+}
+
+// Assert checks the expectations of r against w. The other expectations are only
+// checked when the status code is the expected one, since they are rarely meaningful
+// otherwise.
+func (r Response) Assert(t testing.TB, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	if w.Code != r.code {
+		t.Errorf("expected status code: %v, got status code: %v, body: %s", r.code, w.Code, bytes.TrimSpace(w.Body.Bytes()))
+		return
+	}
+
+	var (
+		decoded bool
+		v       interface{}
+		err     error
+	)
+
+	doc := func() (interface{}, error) {
+		if !decoded {
+			decoded = true
+			dec := json.NewDecoder(bytes.NewReader(w.Body.Bytes()))
+			dec.UseNumber()
+			err = errors.Wrap(dec.Decode(&v), "decode response body")
+		}
+
+		return v, err
+	}
+
+	for _, c := range r.checks {
+		c(t, w, doc)
+	}
+}
+
+// lookup returns the value at path in the document, a dot separated list of object keys
+// and array indices such as results.0.name. An empty path denotes the whole document.
+// ok is false when there is no value at path.
+func lookup(doc func() (interface{}, error), path string) (v interface{}, ok bool, err error) {
+	if v, err = doc(); err != nil {
+		return nil, false, err
+	}
+
+	if path == "" {
+		return v, true, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if v, ok = node[key]; !ok {
+				return nil, false, nil
+			}
+
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, false, errors.Errorf("%q is not an index of an array", key)
+			}
+
+			if i < 0 || i >= len(node) {
+				return nil, false, nil
+			}
+			v = node[i]
+
+		default:
+			return nil, false, nil
+		}
+	}
+
+	return v, true, nil
+}
+
+// convert decodes the generic JSON value v into dst, a pointer.
+func convert(v, dst interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, dst)
+}
@@ -0,0 +1,123 @@
+package expect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorder is a testing.TB recording the failures reported to it.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+// Helper implements the testing.TB interface.
+func (r *recorder) Helper() {}
+
+// Errorf implements the testing.TB interface.
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+type list struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+func TestResponse(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Location", "/list/1")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"results":[{"id":1,"name":"Foo","created":"2020-01-01T00:00:00Z"}],"page":null}`)
+
+	base := Status(http.StatusCreated).Header("Location", "/list/1")
+
+	tests := []struct {
+		Name     string
+		Response Response
+		Failures int
+	}{
+		{
+			Name: "OK",
+			Response: base.
+				HeaderSet("Location").
+				JSONPath("results.0.name", "Foo").
+				JSONPath("results.0.id", 1).
+				JSONPath("results", []list{{ID: 1, Name: "Foo", Created: created}}).
+				JSONPath("page", nil).
+				JSONPath("errors", nil).
+				NonZero("results.0.id").
+				Len("results", 1).
+				Errors(0),
+		},
+		{
+			Name:     "Status",
+			Response: Status(http.StatusOK).JSONPath("results.0.name", "Bar"),
+			Failures: 1,
+		},
+		{
+			Name:     "Header",
+			Response: base.Header("Location", "/list/2").HeaderSet("ETag"),
+			Failures: 2,
+		},
+		{
+			Name: "JSONPath",
+			Response: base.
+				JSONPath("results.0.name", "Bar").
+				JSONPath("results.1.name", "Foo").
+				JSONPath("results.name", "Foo").
+				JSONPath("results", nil),
+			Failures: 4,
+		},
+		{
+			Name:     "Len",
+			Response: base.Len("results", 2).Len("results.0.name", 1),
+			Failures: 2,
+		},
+		{
+			Name:     "NonZero",
+			Response: base.NonZero("page").NonZero("errors").NonZero("results.0.id"),
+			Failures: 2,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var r recorder
+			test.Response.Assert(&r, w)
+
+			if e, a := test.Failures, len(r.failures); e != a {
+				t.Errorf("expected %d failures, got %d: %q", e, a, r.failures)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Extending a shared Response leaves it unchanged.
+	if e, a := 1, len(base.checks); e != a {
+		t.Errorf("expected the shared response to keep %d check, got %d", e, a)
+	}
+
+	t.Run("Into", func(t *testing.T) {
+		var got []list
+		var r recorder
+		Status(http.StatusCreated).Into("results", &got).Assert(&r, w)
+
+		if len(r.failures) != 0 {
+			t.Fatalf("unexpected failures: %q", r.failures)
+		}
+
+		if d := cmp.Diff([]list{{ID: 1, Name: "Foo", Created: created}}, got); d != "" {
+			t.Errorf("unexpected difference in decoded value:\n%v", d)
+		}
+	})
+}
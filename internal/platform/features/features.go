@@ -0,0 +1,186 @@
+package features
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// These constants define the names of every known feature flag.
+const (
+	// StrictValidation makes handlers reject request bodies containing fields that
+	// are unknown to the resource being created or updated.
+	StrictValidation = "strict_validation"
+
+	// FaultInjection makes the routes matching the configured faults respond with
+	// artificial latency and errors.
+	FaultInjection = "fault_injection"
+
+	// QueryPlans logs the plan of the slowest query of requests that take longer than
+	// the slow request threshold.
+	QueryPlans = "query_plans"
+
+	// Attachments serves the routes storing files along with items, which also need a
+	// storage to be configured.
+	Attachments = "attachments"
+)
+
+// Flag is a feature flag along with its current state.
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+
+	// Runtime denotes whether the flag can be toggled while the daemon is running.
+	// Flags that aren't runtime togglable can only be set through configuration.
+	Runtime bool `json:"runtime"`
+}
+
+// known contains every flag the daemon understands in their default, disabled state.
+var known = []Flag{
+	{
+		Name:        StrictValidation,
+		Description: "reject request bodies containing unknown fields",
+		Runtime:     true,
+	},
+	{
+		Name:        FaultInjection,
+		Description: "inject the configured latency and errors into the responses of matching routes",
+		Runtime:     true,
+	},
+	{
+		Name:        QueryPlans,
+		Description: "log the plan of the slowest query of requests slower than the slow request threshold",
+		Runtime:     true,
+	},
+	{
+		Name:        Attachments,
+		Description: "store files along with items in the configured storage",
+		Runtime:     true,
+	},
+}
+
+var (
+	// ErrUnknown is returned when referring to a flag that does not exist.
+	ErrUnknown = errors.New("unknown feature flag")
+
+	// ErrNotRuntime is returned when toggling a flag that can only be set through
+	// configuration.
+	ErrNotRuntime = errors.New("feature flag can only be changed through configuration")
+)
+
+// Service holds the state of every known feature flag. It is safe for concurrent use
+// and a nil *Service reports every flag as disabled.
+type Service struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// New returns a new Service with the flags in enabled turned on and every other flag
+// turned off. An error is returned if enabled contains an unknown flag.
+func New(enabled []string) (*Service, error) {
+	s := Service{
+		flags: make(map[string]Flag, len(known)),
+	}
+
+	for _, f := range known {
+		s.flags[f.Name] = f
+	}
+
+	if err := s.Apply(enabled); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Enabled reports whether the flag with the given name is turned on.
+func (s *Service) Enabled(name string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.flags[name].Enabled
+}
+
+// List returns every flag sorted by name.
+func (s *Service) List() []Flag {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		flags = append(flags, f)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+
+	return flags
+}
+
+// Set turns the runtime togglable flag with the given name on or off and returns the
+// flag in its new state.
+func (s *Service) Set(name string, enabled bool) (Flag, error) {
+	if s == nil {
+		return Flag{}, ErrUnknown
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.flags[name]
+	if !ok {
+		return Flag{}, ErrUnknown
+	}
+
+	if !f.Runtime {
+		return Flag{}, ErrNotRuntime
+	}
+
+	f.Enabled = enabled
+	s.flags[name] = f
+
+	return f, nil
+}
+
+// Apply turns on exactly the flags in enabled and turns off every other flag, which is
+// how configuration is applied. Nothing is changed if enabled contains an unknown flag.
+func (s *Service) Apply(enabled []string) error {
+	on := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		on[name] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range on {
+		if _, ok := s.flags[name]; !ok {
+			names := make([]string, 0, len(s.flags))
+			for n := range s.flags {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+
+			return errors.Wrapf(ErrUnknown, "%q (known flags: %s)", name, strings.Join(names, ", "))
+		}
+	}
+
+	for name, f := range s.flags {
+		f.Enabled = on[name]
+		s.flags[name] = f
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Enabled         []string
+		ExpectedErr     error
+		ExpectedEnabled bool
+	}{
+		{
+			Name:            "Defaults",
+			Enabled:         nil,
+			ExpectedEnabled: false,
+		},
+		{
+			Name:            "Enabled",
+			Enabled:         []string{StrictValidation},
+			ExpectedEnabled: true,
+		},
+		{
+			Name:        "Unknown",
+			Enabled:     []string{StrictValidation, "does_not_exist"},
+			ExpectedErr: ErrUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			s, err := New(test.Enabled)
+			if e, a := test.ExpectedErr, errors.Cause(err); e != a {
+				t.Fatalf("expected error: %v, got error: %v", e, a)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if e, a := test.ExpectedEnabled, s.Enabled(StrictValidation); e != a {
+				t.Errorf("expected enabled: %v, got enabled: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestSet(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatalf("error creating service: %v", err)
+	}
+
+	s.flags["config_only"] = Flag{Name: "config_only"}
+
+	tests := []struct {
+		Name        string
+		Flag        string
+		ExpectedErr error
+	}{
+		{
+			Name: "Runtime",
+			Flag: StrictValidation,
+		},
+		{
+			Name:        "NotRuntime",
+			Flag:        "config_only",
+			ExpectedErr: ErrNotRuntime,
+		},
+		{
+			Name:        "Unknown",
+			Flag:        "does_not_exist",
+			ExpectedErr: ErrUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			_, err := s.Set(test.Flag, true)
+			if e, a := test.ExpectedErr, err; e != a {
+				t.Fatalf("expected error: %v, got error: %v", e, a)
+			}
+
+			if e, a := err == nil, s.Enabled(test.Flag); e != a {
+				t.Errorf("expected enabled: %v, got enabled: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+
+	// Applying configuration turns off every flag that isn't listed.
+	if err := s.Apply(nil); err != nil {
+		t.Fatalf("error applying flags: %v", err)
+	}
+
+	if s.Enabled(StrictValidation) {
+		t.Errorf("expected %s to be disabled after applying an empty configuration", StrictValidation)
+	}
+}
+
+func TestNilService(t *testing.T) {
+	var s *Service
+
+	if s.Enabled(StrictValidation) {
+		t.Error("expected nil service to report flags as disabled")
+	}
+
+	if _, err := s.Set(StrictValidation, true); err != ErrUnknown {
+		t.Errorf("expected error: %v, got error: %v", ErrUnknown, err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package handoff lets a new process of the daemon take over its listening sockets, so
+// it can be deployed again without refusing a single connection: the new process accepts
+// connections on the sockets it inherited while the old one drains the requests it has
+// already accepted.
+//
+// The old process starts the new one with Upgrade, handing off its listeners. The new one
+// picks them up with Listen and calls Ready once it is serving, which is when Upgrade
+// returns and the old process can shut down.
+package handoff
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// envListeners is the environment variable naming the listeners handed off to a process,
+// in the order of their file descriptors.
+const envListeners = "LISTD_HANDOFF_LISTENERS"
+
+// readyFD is the file descriptor of the pipe a process that was handed off listeners
+// reports being ready on. The listeners follow it.
+const readyFD = 3
+
+// inherited are the names of the listeners handed off to this process, read once since
+// the environment variable is cleared so it isn't handed down to processes this process
+// starts in turn.
+var inherited = func() []string {
+	v, ok := os.LookupEnv(envListeners)
+	if !ok {
+		return nil
+	}
+	os.Unsetenv(envListeners)
+
+	if v == "" {
+		return []string{}
+	}
+
+	return strings.Split(v, ",")
+}()
+
+// Inherited reports whether this process was started by Upgrade.
+func Inherited() bool {
+	return inherited != nil
+}
+
+// Listen returns the listener handed off to this process under the given name, or a new
+// TCP listener on addr if there is none.
+func Listen(name, addr string) (net.Listener, error) {
+	for i, n := range inherited {
+		if n != name {
+			continue
+		}
+
+		f := os.NewFile(uintptr(readyFD+1+i), name)
+		if f == nil {
+			return nil, errors.Errorf("listener %s was handed off but is not open", name)
+		}
+		defer f.Close()
+
+		ln, err := net.FileListener(f)
+		return ln, errors.Wrapf(err, "inherit listener %s", name)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	return ln, errors.Wrap(err, "listen")
+}
+
+// Ready tells the process that started this one that it is serving, so the old process
+// can drain and shut down. It does nothing if this process wasn't started by Upgrade.
+func Ready() error {
+	if !Inherited() {
+		return nil
+	}
+
+	f := os.NewFile(readyFD, "ready")
+	if f == nil {
+		return errors.New("the ready pipe is not open")
+	}
+	defer f.Close()
+
+	_, err := f.Write([]byte{1})
+	return errors.Wrap(err, "report ready")
+}
+
+// filer is a listener whose socket can be handed off, such as *net.TCPListener.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade starts a new process running the executable of this one with the same arguments
+// and environment, handing it off the given listeners by name. It returns the new process
+// once it called Ready. If it exits or doesn't get ready within timeout first, it is
+// killed and an error is returned, and this process should go on serving.
+//
+// The listeners keep accepting connections in this process until it closes them, which
+// is safe since either process may accept any given connection.
+func Upgrade(listeners map[string]net.Listener, timeout time.Duration) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "find executable")
+	}
+
+	names := make([]string, 0, len(listeners))
+	for name := range listeners {
+		if strings.Contains(name, ",") {
+			return nil, errors.Errorf("listener name %q must not contain a comma", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "create ready pipe")
+	}
+	defer r.Close()
+
+	// Every file is a duplicate of the descriptor of this process, the new process gets
+	// its own once started, so all of them are closed once it was.
+	files := []*os.File{w}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, name := range names {
+		l, ok := listeners[name].(filer)
+		if !ok {
+			return nil, errors.Errorf("listener %s can't be handed off", name)
+		}
+
+		f, err := l.File()
+		if err != nil {
+			return nil, errors.Wrapf(err, "hand off listener %s", name)
+		}
+		files = append(files, f)
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), envListeners+"="+strings.Join(names, ","))
+	cmd.ExtraFiles = files
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "start new process")
+	}
+
+	// The write end of the pipe is closed in this process, so reading it ends as soon as
+	// the new process exits without having reported being ready.
+	w.Close()
+	files = files[1:]
+
+	if err := waitReady(r, timeout); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+
+	return cmd.Process, nil
+}
+
+// waitReady waits for a process to report being ready on r.
+func waitReady(r *os.File, timeout time.Duration) error {
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return errors.Wrap(err, "set ready deadline")
+	}
+
+	n, err := r.Read(make([]byte, 1))
+	switch {
+	case n == 1:
+		return nil
+	case os.IsTimeout(err):
+		return errors.Errorf("new process wasn't ready within %v", timeout)
+	default:
+		return errors.New("new process exited before it was ready")
+	}
+}
+
+// Conns keeps track of the connections of an http.Server that haven't sent a request yet.
+// http.Server.Shutdown closes those right away, dropping their requests, so a process that
+// handed off its listeners closes them, waits for these connections, and only then shuts
+// down its server. It is safe for concurrent use.
+type Conns struct {
+	mu    sync.Mutex
+	fresh map[net.Conn]struct{}
+}
+
+// Track returns the Conns of srv, which must not be serving yet.
+func Track(srv *http.Server) *Conns {
+	c := Conns{fresh: make(map[net.Conn]struct{})}
+
+	next := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		c.mu.Lock()
+		if state == http.StateNew {
+			c.fresh[conn] = struct{}{}
+		} else {
+			delete(c.fresh, conn)
+		}
+		c.mu.Unlock()
+
+		if next != nil {
+			next(conn, state)
+		}
+	}
+
+	return &c
+}
+
+// Wait waits until every connection accepted so far has sent a request or was closed, or
+// until ctx is done. The listeners of the server should be closed and it should have
+// stopped serving first, or new connections may keep coming in.
+func (c *Conns) Wait(ctx context.Context) error {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		c.mu.Lock()
+		n := len(c.fresh)
+		c.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "wait for %d connections to send a request", n)
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package handoff
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// envChild makes the test binary act as the new process of TestUpgrade.
+const envChild = "HANDOFF_TEST_CHILD"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(envChild) {
+	case "":
+		os.Exit(m.Run())
+	case "exit":
+		os.Exit(1)
+	}
+
+	ln, err := Listen("http", "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new")
+	}))
+
+	if err := Ready(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// The test kills this process once it is done, this is in case it doesn't.
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func TestUpgrade(t *testing.T) {
+	ln, err := Listen("http", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+
+	old := http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests take a while so some are still being served when the old process
+		// starts draining.
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, "old")
+	})}
+	conns := Track(&old)
+
+	serving := make(chan struct{})
+	go func() {
+		old.Serve(ln)
+		close(serving)
+	}()
+
+	url := "http://" + ln.Addr().String()
+
+	// Every request is made on a connection of its own, so the load keeps connecting to
+	// the socket throughout the upgrade.
+	client := http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+
+	var (
+		mu     sync.Mutex
+		served = make(map[string]int)
+		failed []error
+	)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				body, err := get(&client, url)
+
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, err)
+				} else {
+					served[body]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	os.Setenv(envChild, "1")
+	proc, err := Upgrade(map[string]net.Listener{"http": ln}, 10*time.Second)
+	os.Unsetenv(envChild)
+	if err != nil {
+		close(stop)
+		wg.Wait()
+		t.Fatalf("error upgrading: %v", err)
+	}
+
+	defer func() {
+		proc.Kill()
+		proc.Wait()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The old process stops accepting connections before it drains, see Conns.
+	ln.Close()
+	<-serving
+
+	if err := conns.Wait(ctx); err != nil {
+		t.Errorf("error waiting for connections: %v", err)
+	}
+
+	if err := old.Shutdown(ctx); err != nil {
+		t.Errorf("error draining the old server: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		t.Errorf("expected no request to fail during the upgrade, %d did, the first with: %v", len(failed), failed[0])
+	}
+
+	if served["old"] == 0 || served["new"] == 0 {
+		t.Errorf("expected requests to be served by both processes, got %v", served)
+	}
+}
+
+func TestUpgradeNotReady(t *testing.T) {
+	ln, err := Listen("http", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+
+	os.Setenv(envChild, "exit")
+	_, err = Upgrade(map[string]net.Listener{"http": ln}, 10*time.Second)
+	os.Unsetenv(envChild)
+
+	if err == nil {
+		t.Error("expected an error upgrading to a process that never got ready")
+	}
+}
+
+// get returns the body of the response to a GET request to url.
+func get(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	return string(body), err
+}
@@ -0,0 +1,140 @@
+// Package i18n translates user-facing messages, identified by codes that never change,
+// into the languages of the message catalogs embedded in the binary.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language messages are given in when the client accepts none of
+// the languages there are catalogs for. Its catalog contains every code.
+const DefaultLanguage = "en"
+
+// files contains a catalog per language, named after the language, mapping codes to
+// fmt format strings.
+//
+//go:embed catalogs/*.json
+var files embed.FS
+
+// catalogs maps languages to their catalog.
+var catalogs = load()
+
+// load reads every embedded catalog. A malformed catalog is a programming error, so it
+// panics instead of returning an error.
+func load() map[string]map[string]string {
+	entries, err := files.ReadDir("catalogs")
+	if err != nil {
+		panic(fmt.Sprintf("read catalogs: %v", err))
+	}
+
+	catalogs := make(map[string]map[string]string, len(entries))
+	for _, e := range entries {
+		b, err := files.ReadFile(path.Join("catalogs", e.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("read catalog %s: %v", e.Name(), err))
+		}
+
+		var catalog map[string]string
+		if err := json.Unmarshal(b, &catalog); err != nil {
+			panic(fmt.Sprintf("unmarshal catalog %s: %v", e.Name(), err))
+		}
+
+		catalogs[strings.TrimSuffix(e.Name(), ".json")] = catalog
+	}
+
+	return catalogs
+}
+
+// Languages returns the languages there are catalogs for in alphabetical order.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		languages = append(languages, l)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
+
+// Codes returns every code of the catalog of DefaultLanguage in alphabetical order.
+func Codes() []string {
+	codes := make([]string, 0, len(catalogs[DefaultLanguage]))
+	for c := range catalogs[DefaultLanguage] {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// Language returns the language with a catalog that is preferred the most by the given
+// Accept-Language header value, or DefaultLanguage when there is none. Regional variants
+// such as de-AT fall back to their primary language.
+func Language(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+
+		w := weighted{
+			tag: strings.ToLower(strings.TrimSpace(fields[0])),
+			q:   1,
+		}
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					w.q = q
+				}
+			}
+		}
+
+		if w.tag != "" && w.q > 0 {
+			tags = append(tags, w)
+		}
+	}
+
+	// Equally weighted languages keep the order of the header.
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	for _, t := range tags {
+		if _, ok := catalogs[t.tag]; ok {
+			return t.tag
+		}
+
+		if i := strings.Index(t.tag, "-"); i != -1 {
+			if _, ok := catalogs[t.tag[:i]]; ok {
+				return t.tag[:i]
+			}
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Translate returns the message identified by code in the given language, formatted
+// with args the same way fmt.Sprintf does. Codes missing from the catalog of the
+// language are given in DefaultLanguage, unknown codes are returned as is.
+func Translate(language, code string, args ...interface{}) string {
+	format, ok := catalogs[language][code]
+	if !ok {
+		if format, ok = catalogs[DefaultLanguage][code]; !ok {
+			return code
+		}
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,107 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// verbs matches the formatting verbs of a format string.
+var verbs = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	def := catalogs[DefaultLanguage]
+	if len(def) == 0 {
+		t.Fatalf("expected a catalog for the default language %s", DefaultLanguage)
+	}
+
+	for _, language := range Languages() {
+		fn := func(t *testing.T) {
+			catalog := catalogs[language]
+
+			for code, format := range def {
+				translated, ok := catalog[code]
+				if !ok {
+					t.Errorf("missing code %s", code)
+					continue
+				}
+
+				// Arguments are given in the same order for every language.
+				if d := cmp.Diff(verbs.FindAllString(format, -1), verbs.FindAllString(translated, -1)); d != "" {
+					t.Errorf("unexpected difference in formatting verbs of code %s:\n%v", code, d)
+				}
+			}
+
+			for code := range catalog {
+				if _, ok := def[code]; !ok {
+					t.Errorf("code %s is missing from the default catalog", code)
+				}
+			}
+		}
+
+		t.Run(language, fn)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AcceptLanguage string
+		Expected       string
+	}{
+		{
+			Name:     "Empty",
+			Expected: DefaultLanguage,
+		},
+		{
+			Name:           "Exact",
+			AcceptLanguage: "de",
+			Expected:       "de",
+		},
+		{
+			Name:           "Region",
+			AcceptLanguage: "es-MX",
+			Expected:       "es",
+		},
+		{
+			Name:           "Weighted",
+			AcceptLanguage: "de;q=0.5, es;q=0.8, en;q=0.2",
+			Expected:       "es",
+		},
+		{
+			Name:           "Unsupported",
+			AcceptLanguage: "fr-CH, fr;q=0.9, de;q=0.7",
+			Expected:       "de",
+		},
+		{
+			Name:           "Excluded",
+			AcceptLanguage: "de;q=0, ja",
+			Expected:       DefaultLanguage,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if e, a := test.Expected, Language(test.AcceptLanguage); e != a {
+				t.Errorf("expected language %s, got %s", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if e, a := "unit muss eine der folgenden Einheiten sein: g, kg, \"lb\" erhalten", Translate("de", "unit_invalid", "g, kg", "lb"); e != a {
+		t.Errorf("expected message %q, got %q", e, a)
+	}
+
+	if e, a := "Not Found", Translate("ja", "not_found"); e != a {
+		t.Errorf("expected message of the default language %q, got %q", e, a)
+	}
+
+	if e, a := "unknown_code", Translate("de", "unknown_code"); e != a {
+		t.Errorf("expected unknown code to be returned as is, got %q", a)
+	}
+}
@@ -0,0 +1,113 @@
+// Package inflight keeps track of the requests that are being served, so operators can
+// see which of them are stuck and cancel them.
+package inflight
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+// Request is a request that is being served.
+type Request struct {
+	ID      string    `json:"id"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Started time.Time `json:"started"`
+
+	cancel context.CancelFunc
+}
+
+// Registry holds the requests that are being served. It is safe for concurrent use and
+// a nil *Registry keeps track of nothing.
+type Registry struct {
+	mu       sync.Mutex
+	requests map[*Request]struct{}
+}
+
+// New returns a new, empty Registry.
+func New() *Registry {
+	return &Registry{
+		requests: make(map[*Request]struct{}),
+	}
+}
+
+// Serve calls next with r, keeping track of it until next returns. The context of the
+// request next is called with is canceled by Cancel. Requests are identified by the ID
+// given to them by web.RequestMW.
+func (g *Registry) Serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if g == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	req := Request{
+		ID:      web.RequestID(ctx),
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Started: time.Now().UTC(),
+		cancel:  cancel,
+	}
+
+	g.mu.Lock()
+	g.requests[&req] = struct{}{}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.requests, &req)
+		g.mu.Unlock()
+	}()
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// List returns the requests that are being served, the longest running first.
+func (g *Registry) List() []Request {
+	if g == nil {
+		return []Request{}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reqs := make([]Request, 0, len(g.requests))
+	for req := range g.requests {
+		reqs = append(reqs, *req)
+	}
+
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Started.Before(reqs[j].Started)
+	})
+
+	return reqs
+}
+
+// Cancel cancels the context of every request being served with the given ID and returns
+// how many there were. Clients can send their own request IDs, so more than one request
+// may share an ID.
+func (g *Registry) Cancel(id string) int {
+	if g == nil {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var n int
+	for req := range g.requests {
+		if req.ID == id {
+			req.cancel()
+			n++
+		}
+	}
+
+	return n
+}
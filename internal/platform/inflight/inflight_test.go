@@ -0,0 +1,89 @@
+package inflight
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRegistry(t *testing.T) {
+	g := New()
+
+	started := make(chan struct{})
+	done := make(chan error)
+
+	// next blocks until the context of the request it serves is canceled.
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		done <- r.Context().Err()
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	h := web.RequestMW(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Serve(w, r, next)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/list/1", nil)
+	r.Header.Set("X-Request-Id", "stuck")
+
+	finished := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		close(finished)
+	}()
+	<-started
+
+	reqs := g.List()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request in flight, got %d", len(reqs))
+	}
+
+	if reqs[0].ID != "stuck" || reqs[0].Method != http.MethodGet || reqs[0].Path != "/list/1" {
+		t.Errorf("unexpected request in flight: %+v", reqs[0])
+	}
+
+	if n := g.Cancel("unknown"); n != 0 {
+		t.Errorf("expected no request to be canceled by an unknown ID, got %d", n)
+	}
+
+	if n := g.Cancel("stuck"); n != 1 {
+		t.Errorf("expected 1 request to be canceled, got %d", n)
+	}
+
+	if err := <-done; err == nil {
+		t.Error("expected the context of the request to be canceled")
+	}
+	<-finished
+
+	if reqs := g.List(); len(reqs) != 0 {
+		t.Errorf("expected no request in flight once served, got %d", len(reqs))
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var g *Registry
+
+	var served bool
+	g.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/list", nil), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+
+	if !served {
+		t.Error("expected a nil registry to pass every request on")
+	}
+
+	if reqs := g.List(); len(reqs) != 0 {
+		t.Errorf("expected no request in flight, got %d", len(reqs))
+	}
+
+	if n := g.Cancel("stuck"); n != 0 {
+		t.Errorf("expected no request to be canceled, got %d", n)
+	}
+}
@@ -0,0 +1,134 @@
+package leaktest
+
+import (
+	"bytes"
+	"database/sql"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// These constants define how long the leak checks wait for goroutines and connections
+// to wind down before reporting them as leaked.
+const (
+	// retryAttempts is the amount of times the snapshot is retaken before giving up and
+	// reporting what remains as leaks.
+	retryAttempts = 40
+
+	// retryInterval is the time waited in between each retry attempt.
+	retryInterval = 50 * time.Millisecond
+)
+
+// ignoredFunctions contains the functions that, when found in any frame of a goroutine's
+// stack, denote a goroutine that is owned by the testing or os/signal package and is
+// therefore not considered a leak. Any frame is checked rather than just the top one
+// since these goroutines are usually parked further down in a channel operation, and
+// runtime.Stack hides the runtime frames that would otherwise be on top.
+var ignoredFunctions = []string{
+	"testing.Main(",
+	"testing.(*M).",
+	"testing.(*T).",
+	"testing.tRunner(",
+	"os/signal.signal_recv(",
+	"os/signal.loop(",
+}
+
+// Goroutines checks for goroutines that are still running besides the goroutine
+// calling it. Goroutines that are in the middle of shutting down are given a short
+// amount of time to exit before an error containing their stack traces is returned.
+func Goroutines() error {
+	var leaked []string
+
+	for i := 0; i < retryAttempts; i++ {
+		if leaked = interesting(stacks()); len(leaked) == 0 {
+			return nil
+		}
+
+		time.Sleep(retryInterval)
+	}
+
+	return errors.Errorf("found %d leaked goroutine(s):\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+}
+
+// DBConnections checks that no connections of the given database handle are still
+// in use, which would denote an unclosed *sql.Rows, *sql.Stmt, or *sql.Tx somewhere
+// in the code that has been exercised.
+func DBConnections(dbc *sql.DB) error {
+	var stats sql.DBStats
+
+	for i := 0; i < retryAttempts; i++ {
+		if stats = dbc.Stats(); stats.InUse == 0 {
+			return nil
+		}
+
+		time.Sleep(retryInterval)
+	}
+
+	return errors.Errorf("found %d leaked database connection(s) (open: %d, idle: %d)",
+		stats.InUse, stats.OpenConnections, stats.Idle)
+}
+
+// stacks returns the stack traces of all goroutines except for the goroutine calling
+// it, one element per goroutine.
+func stacks() []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+
+		buf = make([]byte, 2*len(buf))
+	}
+
+	// The first goroutine in the dump is always the one that called runtime.Stack.
+	gs := bytes.Split(buf, []byte("\n\n"))
+	if len(gs) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, len(gs)-1)
+	for _, g := range gs[1:] {
+		if s := strings.TrimSpace(string(g)); s != "" {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+// interesting filters out any stacks that contain a frame of one of the functions
+// defined in ignoredFunctions.
+func interesting(gs []string) []string {
+	var out []string
+
+	for _, g := range gs {
+		if !ignored(g) {
+			out = append(out, g)
+		}
+	}
+
+	return out
+}
+
+// ignored reports whether any function frame of the given goroutine stack matches
+// one of the functions defined in ignoredFunctions. The goroutine header, file
+// locations, and "created by" lines are not considered frames.
+func ignored(g string) bool {
+	for _, line := range strings.Split(g, "\n")[1:] {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "created by ") {
+			continue
+		}
+
+		for _, fn := range ignoredFunctions {
+			if strings.HasPrefix(line, fn) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
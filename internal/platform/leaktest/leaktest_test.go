@@ -0,0 +1,194 @@
+package leaktest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoroutines(t *testing.T) {
+	// Leaked (goroutine blocked on a channel)
+	{
+		block := make(chan struct{})
+		go func() {
+			<-block
+		}()
+
+		err := Goroutines()
+		close(block)
+
+		if err == nil {
+			t.Fatal("expected leaked goroutine to be reported, got nil error")
+		}
+
+		if !strings.Contains(err.Error(), "found 1 leaked goroutine(s)") {
+			t.Errorf("expected exactly one leaked goroutine to be reported, got: %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "leaktest.TestGoroutines") {
+			t.Errorf("expected report to contain the stack of the leaked goroutine, got: %v", err)
+		}
+	}
+
+	// Exits within retry window
+	{
+		go func() {
+			time.Sleep(time.Second)
+		}()
+
+		if err := Goroutines(); err != nil {
+			t.Errorf("expected goroutine exiting within the retry window not to be reported, got: %v", err)
+		}
+	}
+
+	// Shut down httptest server and client
+	{
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		client := srv.Client()
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("error making request: %v", err)
+		}
+
+		if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+			t.Errorf("error reading response body: %v", err)
+		}
+
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("error closing response body: %v", err)
+		}
+
+		client.CloseIdleConnections()
+		srv.Close()
+
+		if err := Goroutines(); err != nil {
+			t.Errorf("expected no leaked goroutines after shutdown, got: %v", err)
+		}
+	}
+}
+
+func TestDBConnections(t *testing.T) {
+	dbc, err := sql.Open(fakeDriverName, "")
+	if err != nil {
+		t.Fatalf("error opening fake database: %v", err)
+	}
+
+	defer func() {
+		if err := dbc.Close(); err != nil {
+			t.Errorf("error closing fake database: %v", err)
+		}
+	}()
+
+	rows, err := dbc.Query("SELECT true")
+	if err != nil {
+		t.Fatalf("error querying fake database: %v", err)
+	}
+
+	// Open *sql.Rows
+	{
+		err := DBConnections(dbc)
+		if err == nil {
+			t.Fatal("expected connection held by open rows to be reported, got nil error")
+		}
+
+		if !strings.Contains(err.Error(), "found 1 leaked database connection(s)") {
+			t.Errorf("expected exactly one leaked connection to be reported, got: %v", err)
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		t.Fatalf("error closing rows: %v", err)
+	}
+
+	// Closed *sql.Rows
+	if err := DBConnections(dbc); err != nil {
+		t.Errorf("expected no leaked connections after closing rows, got: %v", err)
+	}
+}
+
+// fakeDriverName is the name the fake database/sql driver is registered under.
+const fakeDriverName = "leaktest-fake"
+
+func init() {
+	sql.Register(fakeDriverName, fakeDriver{})
+}
+
+// fakeDriver is a database/sql driver whose queries return a single boolean column
+// that never runs out of rows, so that the returned *sql.Rows stays open until it is
+// explicitly closed.
+type fakeDriver struct{}
+
+// Open implements the driver.Driver interface.
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+// fakeConn is the driver.Conn returned by fakeDriver.
+type fakeConn struct{}
+
+// Prepare implements the driver.Conn interface.
+func (fakeConn) Prepare(string) (driver.Stmt, error) {
+	return fakeStmt{}, nil
+}
+
+// Close implements the driver.Conn interface.
+func (fakeConn) Close() error {
+	return nil
+}
+
+// Begin implements the driver.Conn interface.
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+// fakeStmt is the driver.Stmt returned by fakeConn.
+type fakeStmt struct{}
+
+// Close implements the driver.Stmt interface.
+func (fakeStmt) Close() error {
+	return nil
+}
+
+// NumInput implements the driver.Stmt interface.
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+// Exec implements the driver.Stmt interface.
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+// Query implements the driver.Stmt interface.
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+// fakeRows is the driver.Rows returned by fakeStmt.
+type fakeRows struct{}
+
+// Columns implements the driver.Rows interface.
+func (fakeRows) Columns() []string {
+	return []string{"bool"}
+}
+
+// Close implements the driver.Rows interface.
+func (fakeRows) Close() error {
+	return nil
+}
+
+// Next implements the driver.Rows interface.
+func (fakeRows) Next(dest []driver.Value) error {
+	dest[0] = true
+	return nil
+}
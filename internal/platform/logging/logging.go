@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// These constants define the supported output formats of a logger.
+const (
+	// FormatText formats log entries as human readable key=value pairs.
+	FormatText = "text"
+
+	// FormatJSON formats log entries as one JSON object per line.
+	FormatJSON = "json"
+)
+
+// New returns a new logger that writes entries of at least the given level to w in
+// the given format.
+func New(w io.Writer, level, format string) (*logrus.Logger, error) {
+	l := logrus.New()
+	l.SetOutput(w)
+
+	if err := Configure(l, level, format); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Configure changes the level and format of an existing logger, which is used to apply
+// the configuration to the logrus standard logger that packages without an injected
+// logger fall back to.
+func Configure(l *logrus.Logger, level, format string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return errors.Wrap(err, "parse log level")
+	}
+
+	switch format {
+	case FormatText:
+		l.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+
+	case FormatJSON:
+		l.SetFormatter(&logrus.JSONFormatter{})
+
+	default:
+		return errors.Errorf("unknown log format %q", format)
+	}
+
+	l.SetLevel(lvl)
+
+	return nil
+}
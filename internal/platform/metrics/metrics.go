@@ -0,0 +1,100 @@
+// Package metrics keeps counters of what the service does, exposed in the Prometheus
+// text format so dashboards can track more than the HTTP traffic. Every counter is
+// also published through expvar under the name metrics.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// published holds every counter, keyed by name.
+var published = expvar.NewMap("metrics")
+
+// registry holds every counter, keyed by name.
+var registry = struct {
+	sync.Mutex
+	counters map[string]*Counter
+}{
+	counters: make(map[string]*Counter),
+}
+
+// Counter is a monotonically increasing count, such as the amount of lists created. It is
+// safe for concurrent use.
+type Counter struct {
+	name string
+	help string
+	v    int64
+}
+
+// NewCounter creates and registers the counter with the given name, which is described
+// by help. It panics if a counter with the same name already exists, so counters are
+// meant to be created by package level variables.
+func NewCounter(name, help string) *Counter {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.counters[name]; ok {
+		panic(fmt.Sprintf("metrics: counter %s registered twice", name))
+	}
+
+	c := Counter{
+		name: name,
+		help: help,
+	}
+
+	registry.counters[name] = &c
+	published.Set(name, &c)
+
+	return &c
+}
+
+// Add adds n to the counter.
+func (c *Counter) Add(n int64) {
+	atomic.AddInt64(&c.v, n)
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the current count.
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.v)
+}
+
+// String implements the expvar.Var interface.
+func (c *Counter) String() string {
+	return strconv.FormatInt(c.Value(), 10)
+}
+
+// Handler returns a handler that writes every counter in the Prometheus text format,
+// ordered by name.
+func Handler() http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		registry.Lock()
+		counters := make([]*Counter, 0, len(registry.counters))
+		for _, c := range registry.counters {
+			counters = append(counters, c)
+		}
+		registry.Unlock()
+
+		sort.Slice(counters, func(i, j int) bool {
+			return counters[i].name < counters[j].name
+		})
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		for _, c := range counters {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.Value())
+		}
+	}
+
+	return http.HandlerFunc(f)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	b := NewCounter("test_b_total", "Second counter.")
+	a := NewCounter("test_a_total", "First counter.")
+
+	a.Inc()
+	b.Add(3)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	expected := `# HELP test_a_total First counter.
+# TYPE test_a_total counter
+test_a_total 1
+# HELP test_b_total Second counter.
+# TYPE test_b_total counter
+test_b_total 3
+`
+
+	if a := w.Body.String(); !strings.Contains(a, expected) {
+		t.Errorf("expected body to contain:\n%s\ngot:\n%s", expected, a)
+	}
+
+	if e, a := "3", published.Get("test_b_total").String(); e != a {
+		t.Errorf("expected expvar value %s, got %s", e, a)
+	}
+}
+
+func TestNewCounterTwice(t *testing.T) {
+	NewCounter("test_twice_total", "Registered twice.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a counter twice to panic")
+		}
+	}()
+
+	NewCounter("test_twice_total", "Registered twice.")
+}
@@ -0,0 +1,441 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// These constants define the chat services whose webhook payloads are supported.
+const (
+	// FormatSlack posts messages to a Slack incoming webhook.
+	FormatSlack = "slack"
+
+	// FormatDiscord posts messages to a Discord channel webhook.
+	FormatDiscord = "discord"
+)
+
+// DefaultTemplate is the template messages are rendered with when Options.Template
+// is empty.
+const DefaultTemplate = `{{.Type}}: {{with .Data.name}}"{{.}}"{{else}}#{{.Data.id}}{{end}}{{with .Data.listID}} on list #{{.}}{{end}}`
+
+// queueSize is the amount of messages waiting to be posted before new ones are dropped.
+const queueSize = 100
+
+// These constants define the headers messages are signed with when a secret is given,
+// see Sign.
+const (
+	// TimestampHeader holds the time the message was posted at in Unix seconds.
+	TimestampHeader = "X-Webhook-Timestamp"
+
+	// SignatureHeader holds the signature of the message, prefixed with sha256=.
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// defaultBackoff is the wait before the first retry of a message when Options.Backoff
+// is 0.
+const defaultBackoff = time.Second
+
+// deliveryFailures counts the notifications that were never delivered, either because
+// posting them failed or because they were dropped from a full queue.
+var deliveryFailures = metrics.NewCounter("webhook_delivery_failures_total", "Notifications that failed to be posted or were dropped from a full queue.")
+
+// Options configures a Webhook.
+type Options struct {
+	// URL is the webhook messages are posted to.
+	URL string
+
+	// Format is the chat service the webhook belongs to, FormatSlack or FormatDiscord.
+	Format string
+
+	// Types are the event types that are posted, every other event is ignored.
+	Types []string
+
+	// Template is the text/template messages are rendered with. It is executed with
+	// the event, Data being the decoded event data.
+	Template string
+
+	// Version is the version of the events the template is executed with, so templates
+	// written against an earlier version keep rendering the same, see events.Downgrade.
+	// 0 uses events.Version.
+	Version int
+
+	// PerMinute is the maximum amount of messages posted per minute, messages are
+	// spaced out evenly.
+	PerMinute int
+
+	// Secret, if non-empty, signs every message with HMAC-SHA256 so receivers can tell
+	// it was posted by the daemon, see Sign.
+	Secret string
+
+	// MaxAttempts is the amount of times posting a message is attempted before it is
+	// given up on and handed to DeadLetters. 0 makes a single attempt.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry of a message, it doubles with every
+	// retry after. 0 waits a second.
+	Backoff time.Duration
+
+	// DeadLetters, if non-nil, keeps the messages that were given up on, so they can be
+	// posted again through Webhook.Redeliver.
+	DeadLetters DeadLetters
+}
+
+// Delivery is a message that could not be posted.
+type Delivery struct {
+	EventID   string
+	EventType string
+
+	// Payload is the body that was posted.
+	Payload []byte
+
+	// Attempts is the amount of times posting the message was attempted.
+	Attempts int
+
+	// Error is the error of the last attempt.
+	Error string
+}
+
+// DeadLetters keeps the messages a Webhook gave up on.
+type DeadLetters interface {
+	Add(d Delivery) error
+}
+
+// Sign returns the signature of a message with the given body posted at the given time,
+// the hex encoded HMAC-SHA256 of the Unix seconds of the time, a period, and the body,
+// keyed with secret. It is sent in the SignatureHeader with the time in the
+// TimestampHeader, receivers compute it again to verify the message and reject messages
+// with old timestamps to prevent replays.
+func Sign(secret string, posted time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(posted.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook is an events.Publisher posting a message for selected events to a Slack or
+// Discord webhook. Messages are posted in the background on a best effort basis: they
+// are retried with exponential backoff and handed to the dead letters when they can't be
+// posted, instead of holding up the publishing of events.
+type Webhook struct {
+	url         string
+	format      string
+	types       map[string]bool
+	tmpl        *template.Template
+	version     int
+	interval    time.Duration
+	secret      string
+	maxAttempts int
+	backoff     time.Duration
+	deadLetters DeadLetters
+	client      *http.Client
+	log         logrus.FieldLogger
+
+	queue chan Delivery
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewWebhook validates opts and returns a Webhook that starts posting messages right
+// away. Close stops it.
+func NewWebhook(opts Options, log logrus.FieldLogger) (*Webhook, error) {
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return nil, errors.Errorf("expected an http(s) webhook URL, got %q", opts.URL)
+	}
+
+	if opts.Format != FormatSlack && opts.Format != FormatDiscord {
+		return nil, errors.Errorf("expected format %s or %s, got %q", FormatSlack, FormatDiscord, opts.Format)
+	}
+
+	if opts.PerMinute <= 0 {
+		return nil, errors.Errorf("expected a positive amount of messages per minute, got %d", opts.PerMinute)
+	}
+
+	if opts.Version < 0 || opts.Version > events.Version {
+		return nil, errors.Errorf("expected an event version between 1 and %d, got %d", events.Version, opts.Version)
+	}
+
+	if opts.MaxAttempts < 0 {
+		return nil, errors.Errorf("expected 0 or a positive amount of attempts, got %d", opts.MaxAttempts)
+	}
+
+	known := make(map[string]bool, len(events.Types))
+	for _, typ := range events.Types {
+		known[typ] = true
+	}
+
+	types := make(map[string]bool, len(opts.Types))
+	for _, typ := range opts.Types {
+		if !known[typ] {
+			return nil, errors.Errorf("unknown event type %q (known types: %s)", typ, strings.Join(events.Types, ", "))
+		}
+		types[typ] = true
+	}
+
+	text := opts.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	w := Webhook{
+		url:         opts.URL,
+		format:      opts.Format,
+		types:       types,
+		tmpl:        tmpl,
+		version:     opts.Version,
+		interval:    time.Minute / time.Duration(opts.PerMinute),
+		secret:      opts.Secret,
+		maxAttempts: opts.MaxAttempts,
+		backoff:     opts.Backoff,
+		deadLetters: opts.DeadLetters,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		log:         log,
+		queue:       make(chan Delivery, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	if w.version == 0 {
+		w.version = events.Version
+	}
+	if w.maxAttempts == 0 {
+		w.maxAttempts = 1
+	}
+	if w.backoff == 0 {
+		w.backoff = defaultBackoff
+	}
+
+	go w.run()
+
+	return &w, nil
+}
+
+// Publish implements the events.Publisher interface. It only queues the message, which
+// means it never fails.
+func (w *Webhook) Publish(_ context.Context, e events.Event) error {
+	if !w.types[e.Type] {
+		return nil
+	}
+
+	log := w.log.WithFields(logrus.Fields{
+		"eventID":   e.ID,
+		"eventType": e.Type,
+	})
+
+	e, err := events.Downgrade(e, w.version)
+	if err != nil {
+		log.WithError(err).Warn("downgrade event")
+		return nil
+	}
+
+	msg, err := w.render(e)
+	if err != nil {
+		log.WithError(err).Warn("render notification")
+		return nil
+	}
+
+	payload, err := w.payload(msg)
+	if err != nil {
+		log.WithError(err).Warn("marshal notification")
+		return nil
+	}
+
+	select {
+	case w.queue <- Delivery{EventID: e.ID, EventType: e.Type, Payload: payload}:
+	default:
+		deliveryFailures.Inc()
+		log.Warn("notification queue is full, dropping notification")
+	}
+
+	return nil
+}
+
+// Close implements the events.Publisher interface. Messages that are still queued are
+// dropped, a message being retried is handed to the dead letters.
+func (w *Webhook) Close() error {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+
+	return nil
+}
+
+// render renders the message of e.
+func (w *Webhook) render(e events.Event) (string, error) {
+	data := struct {
+		events.Event
+		Data map[string]interface{}
+	}{
+		Event: e,
+	}
+
+	if err := json.Unmarshal(e.Data, &data.Data); err != nil {
+		return "", errors.Wrap(err, "unmarshal event data")
+	}
+
+	var b strings.Builder
+	if err := w.tmpl.Execute(&b, data); err != nil {
+		return "", errors.Wrap(err, "execute template")
+	}
+
+	return b.String(), nil
+}
+
+// payload returns the body msg is posted with.
+func (w *Webhook) payload(msg string) ([]byte, error) {
+	payload := map[string]string{"text": msg}
+	if w.format == FormatDiscord {
+		payload = map[string]string{"content": msg}
+	}
+
+	b, err := json.Marshal(payload)
+	return b, errors.Wrap(err, "marshal payload")
+}
+
+// run posts queued messages, waiting at least interval in between two messages, until
+// the webhook is closed.
+func (w *Webhook) run() {
+	defer close(w.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-w.stop
+		cancel()
+	}()
+
+	var last time.Time
+	for {
+		var d Delivery
+		select {
+		case d = <-w.queue:
+		case <-w.stop:
+			return
+		}
+
+		if wait := w.interval - time.Since(last); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-w.stop:
+				return
+			}
+		}
+		last = time.Now()
+
+		w.deliver(ctx, d)
+	}
+}
+
+// deliver posts d, retrying with exponential backoff up to the maximum amount of
+// attempts. Messages that are given up on, or still being retried when the webhook is
+// closed, are handed to the dead letters.
+func (w *Webhook) deliver(ctx context.Context, d Delivery) {
+	log := w.log.WithFields(logrus.Fields{
+		"eventID":   d.EventID,
+		"eventType": d.EventType,
+	})
+
+	backoff := w.backoff
+	for {
+		err := w.post(ctx, d.Payload)
+		if err == nil {
+			return
+		}
+
+		// A post interrupted by Close may have been received, it is only kept when an
+		// earlier attempt failed for sure.
+		if ctx.Err() != nil && d.Attempts == 0 {
+			deliveryFailures.Inc()
+			return
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		d.Attempts++
+		d.Error = err.Error()
+		log.WithError(err).WithField("attempt", d.Attempts).Warn("post notification")
+
+		if d.Attempts >= w.maxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	deliveryFailures.Inc()
+
+	if w.deadLetters == nil {
+		return
+	}
+
+	if err := w.deadLetters.Add(d); err != nil {
+		log.WithError(err).Error("store undelivered notification")
+	}
+}
+
+// Redeliver posts the payload of a message that was given up on once more, see
+// DeadLetters. Unlike messages of events, it is posted right away and not retried.
+func (w *Webhook) Redeliver(ctx context.Context, payload []byte) error {
+	return w.post(ctx, payload)
+}
+
+// post posts a single message with the given body to the webhook, signed if there is a
+// secret.
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if w.secret != "" {
+		now := time.Now()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(SignatureHeader, Sign(w.secret, now, body))
+	}
+
+	res, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "do request")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errors.Errorf("unexpected response status %s", res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,445 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/events"
+	"github.com/sirupsen/logrus"
+)
+
+// server starts a webhook server sending the body of every post it receives to posts.
+func server(t *testing.T) (*httptest.Server, chan map[string]string) {
+	posts := make(chan map[string]string, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("error decoding posted body: %v", err)
+		}
+
+		posts <- body
+	}))
+
+	return srv, posts
+}
+
+// event returns a new event of the given type carrying data.
+func event(t *testing.T, typ string, data interface{}) events.Event {
+	e, err := events.New(typ, data)
+	if err != nil {
+		t.Fatalf("error creating event: %v", err)
+	}
+
+	return e
+}
+
+// receive returns the next post received by the server or fails the test after a second.
+func receive(t *testing.T, posts chan map[string]string) map[string]string {
+	select {
+	case p := <-posts:
+		return p
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for post")
+	}
+
+	return nil
+}
+
+func TestWebhook(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	tests := []struct {
+		Name     string
+		Format   string
+		Template string
+		Version  int
+		Event    events.Event
+		Expected map[string]string
+	}{
+		{
+			Name:     "Slack",
+			Format:   FormatSlack,
+			Event:    event(t, events.ListCreated, map[string]interface{}{"id": 1, "name": "Grocery"}),
+			Expected: map[string]string{"text": `list.created: "Grocery"`},
+		},
+		{
+			Name:     "Discord",
+			Format:   FormatDiscord,
+			Event:    event(t, events.ItemDeleted, map[string]int{"id": 2, "listID": 1}),
+			Expected: map[string]string{"content": "item.deleted: #2 on list #1"},
+		},
+		{
+			Name:     "Template",
+			Format:   FormatSlack,
+			Template: "{{.Data.name}} was created at {{.Time.Year}}",
+			Event:    event(t, events.ListCreated, map[string]interface{}{"id": 1, "name": "Grocery"}),
+			Expected: map[string]string{"text": "Grocery was created at " + time.Now().UTC().Format("2006")},
+		},
+		{
+			Name:     "Latest",
+			Format:   FormatSlack,
+			Event:    event(t, events.ItemDeleted, map[string]interface{}{"id": 2, "listID": 1, "name": "Bread"}),
+			Expected: map[string]string{"text": `item.deleted: "Bread" on list #1`},
+		},
+		{
+			Name:     "Version",
+			Format:   FormatSlack,
+			Version:  1,
+			Event:    event(t, events.ItemDeleted, map[string]interface{}{"id": 2, "listID": 1, "name": "Bread"}),
+			Expected: map[string]string{"text": "item.deleted: #2 on list #1"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			srv, posts := server(t)
+			defer srv.Close()
+
+			w, err := NewWebhook(Options{
+				URL:       srv.URL,
+				Format:    test.Format,
+				Types:     []string{events.ListCreated, events.ItemDeleted},
+				Template:  test.Template,
+				Version:   test.Version,
+				PerMinute: 60,
+			}, log)
+			if err != nil {
+				t.Fatalf("error creating webhook: %v", err)
+			}
+			defer w.Close()
+
+			// Events of types that weren't selected are ignored.
+			if err := w.Publish(context.Background(), event(t, events.ListUpdated, map[string]int{"id": 1})); err != nil {
+				t.Errorf("error publishing ignored event: %v", err)
+			}
+
+			if err := w.Publish(context.Background(), test.Event); err != nil {
+				t.Errorf("error publishing event: %v", err)
+			}
+
+			got := receive(t, posts)
+			if got["text"] != test.Expected["text"] || got["content"] != test.Expected["content"] || len(got) != 1 {
+				t.Errorf("expected post %v, got %v", test.Expected, got)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	srv, posts := server(t)
+	defer srv.Close()
+
+	// 600 messages per minute are spaced out by 100ms.
+	w, err := NewWebhook(Options{
+		URL:       srv.URL,
+		Format:    FormatSlack,
+		Types:     []string{events.ListCreated},
+		PerMinute: 600,
+	}, log)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+	defer w.Close()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := w.Publish(context.Background(), event(t, events.ListCreated, map[string]int{"id": i})); err != nil {
+			t.Errorf("error publishing event: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		receive(t, posts)
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected 3 posts to take at least 200ms, took %v", elapsed)
+	}
+}
+
+func TestWebhookDeliveryFailure(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	attempts := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		attempts <- struct{}{}
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(Options{
+		URL:       srv.URL,
+		Format:    FormatSlack,
+		Types:     []string{events.ListCreated},
+		PerMinute: 60,
+	}, log)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+
+	before := deliveryFailures.Value()
+
+	if err := w.Publish(context.Background(), event(t, events.ListCreated, map[string]int{"id": 1})); err != nil {
+		t.Errorf("error publishing event: %v", err)
+	}
+
+	select {
+	case <-attempts:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for post")
+	}
+
+	// Closing waits for the failed post to be counted.
+	w.Close()
+
+	if e, a := int64(1), deliveryFailures.Value()-before; e != a {
+		t.Errorf("expected %d delivery failure, got %d", e, a)
+	}
+}
+
+// deadLetters is a DeadLetters sending every delivery it is given to the channel.
+type deadLetters chan Delivery
+
+// Add implements the DeadLetters interface.
+func (d deadLetters) Add(delivery Delivery) error {
+	d <- delivery
+	return nil
+}
+
+func TestWebhookRetries(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	tests := []struct {
+		Name               string
+		Failures           int
+		ExpectedAttempts   int
+		ExpectedDeadLetter bool
+	}{
+		{
+			Name:             "Recovered",
+			Failures:         2,
+			ExpectedAttempts: 3,
+		},
+		{
+			Name:               "GivenUp",
+			Failures:           3,
+			ExpectedAttempts:   3,
+			ExpectedDeadLetter: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var n int32
+			attempts := make(chan time.Time, 10)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&n, 1) <= int32(test.Failures) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+				attempts <- time.Now()
+			}))
+			defer srv.Close()
+
+			dead := make(deadLetters, 1)
+
+			w, err := NewWebhook(Options{
+				URL:         srv.URL,
+				Format:      FormatSlack,
+				Types:       []string{events.ListCreated},
+				PerMinute:   600,
+				MaxAttempts: 3,
+				Backoff:     20 * time.Millisecond,
+				DeadLetters: dead,
+			}, log)
+			if err != nil {
+				t.Fatalf("error creating webhook: %v", err)
+			}
+
+			e := event(t, events.ListCreated, map[string]interface{}{"id": 1, "name": "Grocery"})
+			if err := w.Publish(context.Background(), e); err != nil {
+				t.Errorf("error publishing event: %v", err)
+			}
+
+			var times []time.Time
+			for len(times) < test.ExpectedAttempts {
+				select {
+				case at := <-attempts:
+					times = append(times, at)
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for attempt %d", len(times)+1)
+				}
+			}
+
+			var d *Delivery
+			if test.ExpectedDeadLetter {
+				select {
+				case got := <-dead:
+					d = &got
+				case <-time.After(time.Second):
+					t.Fatal("timed out waiting for dead letter")
+				}
+			}
+
+			// Messages are posted one by one, so once the next one is posted the first
+			// one isn't mistaken for one interrupted by Close.
+			if err := w.Publish(context.Background(), event(t, events.ListCreated, map[string]int{"id": 2})); err != nil {
+				t.Errorf("error publishing event: %v", err)
+			}
+
+			select {
+			case <-attempts:
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for the next message")
+			}
+
+			w.Close()
+
+			// The backoff doubles, so the second retry waits at least twice as long.
+			if wait := times[2].Sub(times[1]); wait < 40*time.Millisecond {
+				t.Errorf("expected the second retry to wait at least 40ms, waited %v", wait)
+			}
+
+			if !test.ExpectedDeadLetter {
+				if len(dead) != 0 {
+					t.Errorf("expected no dead letter, got %+v", <-dead)
+				}
+				return
+			}
+
+			if d.EventID != e.ID || d.EventType != e.Type || d.Attempts != test.ExpectedAttempts || d.Error == "" {
+				t.Errorf("unexpected dead letter %+v", d)
+			}
+
+			if e, a := `{"text":"list.created: \"Grocery\""}`, string(d.Payload); e != a {
+				t.Errorf("expected payload %s, got %s", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	verified := make(chan bool, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading body: %v", err)
+		}
+
+		unix, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("error parsing timestamp: %v", err)
+		}
+
+		verified <- hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(Sign("secret", time.Unix(unix, 0), body)))
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(Options{
+		URL:       srv.URL,
+		Format:    FormatSlack,
+		Types:     []string{events.ListCreated},
+		PerMinute: 600,
+		Secret:    "secret",
+	}, log)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Publish(context.Background(), event(t, events.ListCreated, map[string]int{"id": 1})); err != nil {
+		t.Errorf("error publishing event: %v", err)
+	}
+
+	if err := w.Redeliver(context.Background(), []byte(`{"text":"again"}`)); err != nil {
+		t.Errorf("error redelivering: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ok := <-verified:
+			if !ok {
+				t.Error("expected a valid signature")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for post")
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '1577836800.{}' | openssl dgst -sha256 -hmac secret
+	e := "sha256=fb3cd23aa4650f6a5fa5da8475709bf246f09163720d52e447c3482eb13c65e5"
+	a := Sign("secret", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), []byte("{}"))
+
+	if e != a {
+		t.Errorf("expected signature %s, got %s", e, a)
+	}
+}
+
+func TestNewWebhookInvalid(t *testing.T) {
+	tests := []struct {
+		Name string
+		Opts Options
+	}{
+		{
+			Name: "URL",
+			Opts: Options{URL: "hooks.slack.com", Format: FormatSlack, PerMinute: 1},
+		},
+		{
+			Name: "Format",
+			Opts: Options{URL: "https://hooks.slack.com", Format: "teams", PerMinute: 1},
+		},
+		{
+			Name: "PerMinute",
+			Opts: Options{URL: "https://hooks.slack.com", Format: FormatSlack},
+		},
+		{
+			Name: "Type",
+			Opts: Options{URL: "https://hooks.slack.com", Format: FormatSlack, PerMinute: 1, Types: []string{"list.shared"}},
+		},
+		{
+			Name: "MaxAttempts",
+			Opts: Options{URL: "https://hooks.slack.com", Format: FormatSlack, PerMinute: 1, MaxAttempts: -1},
+		},
+		{
+			Name: "Version",
+			Opts: Options{URL: "https://hooks.slack.com", Format: FormatSlack, PerMinute: 1, Version: events.Version + 1},
+		},
+		{
+			Name: "Template",
+			Opts: Options{URL: "https://hooks.slack.com", Format: FormatSlack, PerMinute: 1, Template: "{{.Type"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if _, err := NewWebhook(test.Opts, logrus.StandardLogger()); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
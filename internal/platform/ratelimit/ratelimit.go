@@ -0,0 +1,118 @@
+// Package ratelimit limits how many requests each client makes, such as the clients of an
+// API that is served without authentication, so none of them can crowd out the others.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket holds the requests a client may still make, refilled over time up to the burst
+// of the Limiter.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter limits the requests of every client to an amount per minute, allowing bursts of
+// up to an amount at once. Clients are told apart by a key, such as their IP address. It
+// is safe for concurrent use and a nil *Limiter allows every request.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	buckets  map[string]*bucket
+	swept    time.Time
+}
+
+// New returns a new Limiter allowing perMinute requests per minute to every client, of
+// which burst may be made at once. A burst below 1 allows a single request at once, a
+// perMinute of 0 allows every request.
+func New(perMinute, burst int) *Limiter {
+	l := Limiter{buckets: make(map[string]*bucket)}
+	l.SetLimit(perMinute, burst)
+
+	return &l
+}
+
+// SetLimit changes the limit of l to perMinute requests per minute, of which burst may be
+// made at once, the same way as New. Requests clients made before are still counted
+// against the new limit.
+func (l *Limiter) SetLimit(perMinute, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	var interval time.Duration
+	if perMinute > 0 {
+		interval = time.Minute / time.Duration(perMinute)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.interval = interval
+	l.burst = burst
+}
+
+// Allow reports whether the client given by key may make a request at now, which is then
+// counted against its limit. Refused requests aren't counted, the returned duration is
+// how long the client has to wait before it may make another one.
+func (l *Limiter) Allow(key string, now time.Time) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.interval == 0 {
+		return 0, true
+	}
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	l.refill(b, now)
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(l.interval)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
+
+// refill adds the tokens b earned since it was last updated, up to the burst.
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(l.interval)
+		b.updated = now
+	}
+
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+}
+
+// sweep drops the buckets that are full again, which are the same as no bucket at all,
+// at most once per the time a bucket takes to fill up so it doesn't run on every request.
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(l.burst) * l.interval
+	if now.Sub(l.swept) < full {
+		return
+	}
+	l.swept = now
+
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
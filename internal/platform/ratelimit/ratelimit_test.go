@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 60 requests per minute are one per second, three of which may be made at once.
+	l := New(60, 3)
+
+	for i := 0; i < 3; i++ {
+		if _, ok := l.Allow("10.0.0.1", start); !ok {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+
+	wait, ok := l.Allow("10.0.0.1", start)
+	if ok {
+		t.Fatal("expected request over the burst to be refused")
+	}
+
+	if e, a := time.Second, wait; e != a {
+		t.Errorf("expected wait of %v, got %v", e, a)
+	}
+
+	if _, ok := l.Allow("10.0.0.2", start); !ok {
+		t.Error("expected request of another client to be allowed")
+	}
+
+	if _, ok := l.Allow("10.0.0.1", start.Add(500*time.Millisecond)); ok {
+		t.Error("expected request before a request was earned to be refused")
+	}
+
+	if _, ok := l.Allow("10.0.0.1", start.Add(time.Second)); !ok {
+		t.Error("expected request once a request was earned to be allowed")
+	}
+
+	if _, ok := l.Allow("10.0.0.1", start.Add(time.Second)); ok {
+		t.Error("expected the earned request to be used up")
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	l := New(60, 2)
+
+	l.Allow("10.0.0.1", start)
+	l.Allow("10.0.0.2", start.Add(1500*time.Millisecond))
+	l.Allow("10.0.0.2", start.Add(1500*time.Millisecond))
+
+	// Buckets are swept two seconds after the first request, by then the bucket of the
+	// first client is full again while the one of the second client isn't.
+	l.Allow("10.0.0.3", start.Add(2*time.Second))
+
+	if _, ok := l.buckets["10.0.0.1"]; ok {
+		t.Error("expected full bucket to be dropped")
+	}
+
+	if _, ok := l.buckets["10.0.0.2"]; !ok {
+		t.Error("expected bucket that isn't full to be kept")
+	}
+}
+
+func TestLimiterNil(t *testing.T) {
+	var l *Limiter
+
+	for i := 0; i < 100; i++ {
+		if _, ok := l.Allow("10.0.0.1", time.Now()); !ok {
+			t.Fatal("expected nil limiter to allow every request")
+		}
+	}
+}
+
+func TestLimiterSetLimit(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	l := New(60, 2)
+
+	l.Allow("10.0.0.1", start)
+	l.Allow("10.0.0.1", start)
+
+	// Requests made before the limit changed are still counted against it.
+	l.SetLimit(120, 3)
+
+	if _, ok := l.Allow("10.0.0.1", start.Add(250*time.Millisecond)); ok {
+		t.Error("expected request before a request was earned to be refused")
+	}
+
+	if _, ok := l.Allow("10.0.0.1", start.Add(500*time.Millisecond)); !ok {
+		t.Error("expected request once a request was earned at the new rate to be allowed")
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, ok := l.Allow("10.0.0.2", start); !ok {
+			t.Fatalf("expected request %d of the new burst to be allowed", i+1)
+		}
+	}
+
+	// A limit of 0 requests per minute allows every request.
+	l.SetLimit(0, 1)
+
+	for i := 0; i < 100; i++ {
+		if _, ok := l.Allow("10.0.0.1", start); !ok {
+			t.Fatal("expected unlimited limiter to allow every request")
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// metrics holds the per-job metrics of every scheduler, published through expvar
+// under the name scheduler and keyed by job name.
+var metrics = expvar.NewMap("scheduler")
+
+// Job is a task that is ran periodically by a Scheduler.
+type Job struct {
+	// Name identifies the job in logs and metrics, it has to be unique.
+	Name string
+
+	// Interval is the time in between the start of two runs of the job. A run that
+	// takes longer than the interval delays the next run rather than overlapping it.
+	Interval time.Duration
+
+	// Run performs the job. The context is canceled when the scheduler is stopped.
+	Run func(ctx context.Context) error
+}
+
+// jobMetrics are the expvar metrics of a single job.
+type jobMetrics struct {
+	runs         *expvar.Int
+	failures     *expvar.Int
+	lastRun      *expvar.String
+	lastDuration *expvar.String
+	lastError    *expvar.String
+}
+
+// newJobMetrics creates the metrics of the job with the given name and publishes them.
+func newJobMetrics(name string) jobMetrics {
+	m := jobMetrics{
+		runs:         new(expvar.Int),
+		failures:     new(expvar.Int),
+		lastRun:      new(expvar.String),
+		lastDuration: new(expvar.String),
+		lastError:    new(expvar.String),
+	}
+
+	vars := new(expvar.Map).Init()
+	vars.Set("runs", m.runs)
+	vars.Set("failures", m.failures)
+	vars.Set("lastRun", m.lastRun)
+	vars.Set("lastDuration", m.lastDuration)
+	vars.Set("lastError", m.lastError)
+
+	metrics.Set(name, vars)
+
+	return m
+}
+
+// Scheduler runs jobs periodically in the background until it is stopped.
+type Scheduler struct {
+	log     logrus.FieldLogger
+	jobs    []Job
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+	started bool
+}
+
+// New returns a new Scheduler logging to log.
+func New(log logrus.FieldLogger) *Scheduler {
+	return &Scheduler{
+		log: log,
+	}
+}
+
+// Add adds a job to the scheduler. Jobs have to be added before the scheduler is
+// started.
+func (s *Scheduler) Add(j Job) {
+	s.jobs = append(s.jobs, j)
+}
+
+// Start starts running every job, each on its own goroutine. The first run of each job
+// happens after its interval has passed once.
+func (s *Scheduler) Start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j, newJobMetrics(j.Name))
+	}
+}
+
+// Stop cancels the context of every running job and waits for them to return, or for
+// ctx to be done, whichever happens first.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if !s.started {
+		return nil
+	}
+
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "wait for running jobs")
+	}
+}
+
+// loop runs the given job every interval until ctx is canceled.
+func (s *Scheduler) loop(ctx context.Context, j Job, m jobMetrics) {
+	defer s.wg.Done()
+
+	t := time.NewTicker(j.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.run(ctx, j, m)
+		}
+	}
+}
+
+// run runs the given job once, recording its outcome.
+func (s *Scheduler) run(ctx context.Context, j Job, m jobMetrics) {
+	log := s.log.WithField("job", j.Name)
+	st := time.Now()
+
+	err := func() (err error) {
+
+		// A panicking job must not take the whole daemon down with it.
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.Errorf("panic: %v", r)
+			}
+		}()
+
+		return j.Run(ctx)
+	}()
+
+	m.runs.Add(1)
+	m.lastRun.Set(st.UTC().Format(time.RFC3339))
+	m.lastDuration.Set(time.Since(st).String())
+
+	if err != nil {
+		m.failures.Add(1)
+		m.lastError.Set(err.Error())
+		log.WithError(err).Error("job failed")
+		return
+	}
+
+	m.lastError.Set("")
+	log.WithField("duration", time.Since(st)).Debug("job completed")
+}
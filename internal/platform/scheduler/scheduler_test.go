@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScheduler(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	s := New(log)
+
+	ran := make(chan struct{}, 100)
+	s.Add(Job{
+		Name:     "test_success",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			ran <- struct{}{}
+			return nil
+		},
+	})
+
+	s.Add(Job{
+		Name:     "test_failure",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			return errors.New("failed")
+		},
+	})
+
+	s.Add(Job{
+		Name:     "test_panic",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			panic("boom")
+		},
+	})
+
+	stopped := make(chan struct{})
+	s.Add(Job{
+		Name:     "test_blocking",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		},
+	})
+
+	s.Start()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("expected job to run within a second")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("error stopping scheduler: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("expected the context of a running job to be canceled on stop")
+	}
+
+	jobs := expvar.Get("scheduler").(*expvar.Map)
+
+	tests := []struct {
+		Name             string
+		ExpectedFailures bool
+		ExpectedError    string
+	}{
+		{
+			Name: "test_success",
+		},
+		{
+			Name:             "test_failure",
+			ExpectedFailures: true,
+			ExpectedError:    "failed",
+		},
+		{
+			Name:             "test_panic",
+			ExpectedFailures: true,
+			ExpectedError:    "panic: boom",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			m, ok := jobs.Get(test.Name).(*expvar.Map)
+			if !ok {
+				t.Fatalf("expected metrics of job %s to be published", test.Name)
+			}
+
+			runs := m.Get("runs").(*expvar.Int).Value()
+			if runs == 0 {
+				t.Error("expected job to have ran at least once")
+			}
+
+			if e, a := test.ExpectedFailures, m.Get("failures").(*expvar.Int).Value() == runs; e != a {
+				t.Errorf("expected every run failed: %v, got every run failed: %v", e, a)
+			}
+
+			if e, a := test.ExpectedError, m.Get("lastError").(*expvar.String).Value(); e != a {
+				t.Errorf("expected last error: %v, got last error: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+
+	s := New(log)
+	s.Add(Job{
+		Name:     "test_stuck",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := s.Stop(ctx); errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("expected error: %v, got error: %v", context.DeadlineExceeded, err)
+	}
+}
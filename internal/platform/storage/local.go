@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LocalPrefix is the path the daemon serves the files of a Local storage under, which its
+// signed URLs are relative to.
+const LocalPrefix = "/files/"
+
+// ErrURLInvalid is returned by Local.Serve for URLs it didn't sign or that expired.
+var ErrURLInvalid = errors.New("invalid or expired file url")
+
+// Local is a Storage keeping files in a directory of the local filesystem. Its signed
+// URLs point to the daemon itself, which serves them through Serve, so every replica has
+// to share the directory and the secret.
+type Local struct {
+	dir    string
+	secret []byte
+}
+
+// NewLocal returns a Local storage keeping files in dir, which is created if it doesn't
+// exist yet, and signing URLs with secret.
+func NewLocal(dir, secret string) (*Local, error) {
+	if dir == "" {
+		return nil, errors.New("local storage needs a directory")
+	}
+
+	if secret == "" {
+		return nil, errors.New("local storage needs a secret to sign urls with")
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, errors.Wrap(err, "create storage directory")
+	}
+
+	return &Local{dir: dir, secret: []byte(secret)}, nil
+}
+
+// file returns the path of the file stored under key. Keys can't refer to files outside
+// of the directory.
+func (l *Local) file(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", errors.Errorf("invalid storage key %q", key)
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put implements the Storage interface. The file is written next to its destination and
+// renamed once complete, so it is never read half written.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	name, err := l.file(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return errors.Wrap(err, "create file directory")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".put-")
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "write file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), name), "rename file")
+}
+
+// Open implements the Storage interface.
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	name, err := l.file(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return f, errors.Wrap(err, "open file")
+}
+
+// Delete implements the Storage interface.
+func (l *Local) Delete(_ context.Context, key string) error {
+	name, err := l.file(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove file")
+	}
+
+	return nil
+}
+
+// SignedURL implements the Storage interface. The URL is relative to the daemon.
+func (l *Local) SignedURL(key, filename string, expires time.Time) (string, error) {
+	if _, err := l.file(key); err != nil {
+		return "", err
+	}
+
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", exp)
+	q.Set("filename", filename)
+	q.Set("signature", l.sign(key, filename, exp))
+
+	return LocalPrefix + key + "?" + q.Encode(), nil
+}
+
+// sign returns the signature of the URL of key with the given filename and expiry.
+func (l *Local) sign(key, filename, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(strings.Join([]string{key, filename, expires}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Serve serves the file stored under key to r, which was made to a URL signed by
+// SignedURL. ErrURLInvalid is returned, with nothing written to w, when the URL wasn't
+// signed for key or expired, and ErrNotFound when there is no such file.
+func (l *Local) Serve(w http.ResponseWriter, r *http.Request, key string) error {
+	q := r.URL.Query()
+
+	exp, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > exp ||
+		!hmac.Equal([]byte(l.sign(key, q.Get("filename"), q.Get("expires"))), []byte(q.Get("signature"))) {
+		return ErrURLInvalid
+	}
+
+	name, err := l.file(key)
+	if err != nil {
+		return ErrURLInvalid
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "open file")
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat file")
+	}
+
+	if filename := q.Get("filename"); filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	http.ServeContent(w, r, q.Get("filename"), fi.ModTime(), f)
+	return nil
+}
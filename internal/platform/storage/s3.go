@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// These constants are used in the signatures of requests to S3, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html.
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3DateFormat     = "20060102T150405Z"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3MaxURLLifetime = 7 * 24 * time.Hour
+)
+
+// S3Options configure an S3 storage.
+type S3Options struct {
+	// Endpoint is the base URL of the service, such as https://s3.eu-central-1.amazonaws.com
+	// or http://minio:9000, and PublicEndpoint the one of signed URLs when clients reach
+	// the service under another address than the daemon. It defaults to Endpoint.
+	Endpoint       string
+	PublicEndpoint string
+
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 is a Storage keeping files in a bucket of S3, or of a service speaking its API such
+// as MinIO. Objects are addressed by path, <endpoint>/<bucket>/<key>, which every such
+// service supports, and requests are signed with AWS Signature Version 4. Signed URLs
+// are presigned URLs clients download the files from the service with directly.
+type S3 struct {
+	o        S3Options
+	endpoint *url.URL
+	public   *url.URL
+	client   *http.Client
+}
+
+// NewS3 returns an S3 storage configured by o.
+func NewS3(o S3Options) (*S3, error) {
+	if o.Bucket == "" || o.Region == "" || o.AccessKey == "" || o.SecretKey == "" {
+		return nil, errors.New("s3 storage needs a bucket, a region and credentials")
+	}
+
+	endpoint, err := url.Parse(o.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.Errorf("invalid s3 endpoint %q", o.Endpoint)
+	}
+
+	public := endpoint
+	if o.PublicEndpoint != "" {
+		if public, err = url.Parse(o.PublicEndpoint); err != nil || public.Host == "" {
+			return nil, errors.Errorf("invalid s3 public endpoint %q", o.PublicEndpoint)
+		}
+	}
+
+	return &S3{
+		o:        o,
+		endpoint: endpoint,
+		public:   public,
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// object returns the URL of the object stored under key at the given endpoint.
+func (s *S3) object(endpoint *url.URL, key string) (*url.URL, error) {
+	if key == "" {
+		return nil, errors.New("empty storage key")
+	}
+
+	u := *endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.o.Bucket + "/" + key
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = ""
+
+	return &u, nil
+}
+
+// do sends a request with the given method and body of the given size to the object
+// stored under key, signed at the current time, and returns the response, which the caller closes unless
+// an error is returned. Responses other than 2xx and 404 are returned as errors.
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	u, err := s.object(s.endpoint, key)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "create s3 request")
+	}
+	r = r.WithContext(ctx)
+
+	if body != nil {
+		r.ContentLength = size
+		r.Header.Set("Content-Type", contentType)
+	}
+	s.authorize(r, time.Now())
+
+	resp, err := s.client.Do(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "s3 %s %s", method, key)
+	}
+
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+
+		return nil, errors.Errorf("s3 %s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp, nil
+}
+
+// Put implements the Storage interface.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Errorf("s3 bucket %q not found", s.o.Bucket)
+	}
+
+	return nil
+}
+
+// Open implements the Storage interface.
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	return resp.Body, nil
+}
+
+// Delete implements the Storage interface.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// SignedURL implements the Storage interface. The URL is presigned for the public
+// endpoint and can't be valid for longer than a week, the most S3 allows.
+func (s *S3) SignedURL(key, filename string, expires time.Time) (string, error) {
+	now := time.Now()
+
+	lifetime := expires.Sub(now).Round(time.Second)
+	if lifetime <= 0 || lifetime > s3MaxURLLifetime {
+		return "", errors.Errorf("signed url lifetime %v out of range", lifetime)
+	}
+
+	u, err := s.object(s.public, key)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	if filename != "" {
+		q.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	return s.presign(http.MethodGet, u, q, now, lifetime), nil
+}
+
+// presign returns u with the query q and the query parameters authenticating a request
+// with the given method to it, signed at the given time, for the given lifetime.
+func (s *S3) presign(method string, u *url.URL, q url.Values, now time.Time, lifetime time.Duration) string {
+	now = now.UTC()
+
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.o.AccessKey+"/"+s.scope(now))
+	q.Set("X-Amz-Date", now.Format(s3DateFormat))
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(lifetime/time.Second), 10))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	signed := *u
+	signed.RawQuery = s3Query(q)
+
+	canonical := strings.Join([]string{
+		method,
+		signed.EscapedPath(),
+		signed.RawQuery,
+		"host:" + signed.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+
+	signed.RawQuery += "&X-Amz-Signature=" + s.signature(now, canonical)
+
+	return signed.String()
+}
+
+// authorize sets the headers authenticating r, signed at the given time. The body isn't
+// signed so it can be streamed.
+func (s *S3) authorize(r *http.Request, now time.Time) {
+	now = now.UTC()
+
+	r.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+	r.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		s3Query(r.URL.Query()),
+		"host:" + r.URL.Host,
+		"x-amz-content-sha256:" + s3UnsignedBody,
+		"x-amz-date:" + now.Format(s3DateFormat) + "\n",
+		signedHeaders,
+		s3UnsignedBody,
+	}, "\n")
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.o.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+// scope returns the scope of the credentials of a request signed at the given time.
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.o.Region + "/" + s3Service + "/aws4_request"
+}
+
+// signature returns the hex encoded signature of the canonical request, signed at the
+// given time.
+func (s *S3) signature(now time.Time, canonical string) string {
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{s3Algorithm, now.Format(s3DateFormat), s.scope(now), hex.EncodeToString(digest[:])}, "\n")
+
+	key := []byte("AWS4" + s.o.SecretKey)
+	for _, part := range []string{now.Format("20060102"), s.o.Region, s3Service, "aws4_request", toSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	return hex.EncodeToString(key)
+}
+
+// s3Query returns the canonical form of q, its parameters sorted and escaped.
+func s3Query(q url.Values) string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []string
+	for _, name := range names {
+		values := append([]string(nil), q[name]...)
+		sort.Strings(values)
+
+		for _, v := range values {
+			params = append(params, s3Escape(name, true)+"="+s3Escape(v, true))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes every byte of s other than the unreserved characters, and
+// slashes unless slash is set, as S3 expects in signed requests.
+func s3Escape(s string, slash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !slash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
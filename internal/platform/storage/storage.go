@@ -0,0 +1,77 @@
+// Package storage stores the contents of files, such as the attachments of items, outside
+// of the database. Files are written and read by the daemon through a Storage, and
+// downloaded by clients directly through the signed URLs it generates, so large downloads
+// don't go through the daemon when the storage can serve them itself.
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned for keys no file is stored under.
+var ErrNotFound = errors.New("file not found")
+
+// Storage stores files under keys, which are slash separated paths such as
+// attachments/5d1c…. Implementations are safe for concurrent use.
+type Storage interface {
+	// Put stores the size bytes read from r under key, replacing the file stored under
+	// it, if any. The content type is given to clients downloading the file.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open returns the contents of the file stored under key, which the caller closes.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete deletes the file stored under key. Deleting a file that doesn't exist is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL returns a URL the file stored under key can be downloaded from without
+	// any other credentials until the given time, as a file with the given name. The
+	// URL may be relative to the daemon, see Local.
+	SignedURL(key, filename string, expires time.Time) (string, error)
+}
+
+// These constants define the supported storage drivers.
+const (
+	// DriverNone stores no files, see Open.
+	DriverNone = "none"
+
+	// DriverLocal stores files in a directory of the local filesystem.
+	DriverLocal = "local"
+
+	// DriverS3 stores files in a bucket of S3 or of a service speaking its API, such as
+	// MinIO.
+	DriverS3 = "s3"
+)
+
+// Options configure the Storage returned by Open. Only the options of the driver are
+// used.
+type Options struct {
+	Driver string
+
+	// Path is the directory files are stored in by the local driver, and Secret the
+	// secret its URLs are signed with. See Local.
+	Path   string
+	Secret string
+
+	// S3 configures the s3 driver.
+	S3 S3Options
+}
+
+// Open returns the Storage of the driver given by o, or nil for DriverNone.
+func Open(o Options) (Storage, error) {
+	switch o.Driver {
+	case DriverNone, "":
+		return nil, nil
+	case DriverLocal:
+		return NewLocal(o.Path, o.Secret)
+	case DriverS3:
+		return NewS3(o.S3)
+	}
+
+	return nil, errors.Errorf("unknown storage driver %q", o.Driver)
+}
@@ -0,0 +1,132 @@
+package testdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// table is a table kept in fixtures along with the column its rows are ordered by.
+type table struct {
+	name   string
+	key    string
+	serial bool
+}
+
+// tables are the tables kept in fixtures, those referenced by others first so they can be
+// loaded in order.
+var tables = []table{
+	{name: "list", key: "list_id", serial: true},
+	{name: "list_settings", key: "list_id"},
+	{name: "list_share", key: "list_id"},
+	{name: "item", key: "item_id", serial: true},
+	{name: "item_history", key: "history_id", serial: true},
+	{name: "attachment", key: "attachment_id", serial: true},
+	{name: "template", key: "template_id", serial: true},
+	{name: "template_item", key: "template_item_id", serial: true},
+	{name: "job", key: "job_id", serial: true},
+	{name: "outbox", key: "event_id"},
+	{name: "webhook_dead_letter", key: "dead_letter_id", serial: true},
+}
+
+// fixture is the state of the test database as kept in a fixture file, the rows of every
+// table by table name.
+type fixture map[string][]json.RawMessage
+
+// Dump writes the rows of every table the tests seed to the fixture file at path, so that
+// a failing scenario can be frozen and loaded again by Load in a regression test.
+func Dump(t testing.TB, dbc *sqlx.DB, path string) {
+	t.Helper()
+
+	f := make(fixture, len(tables))
+	for _, tbl := range tables {
+		rows := make([]json.RawMessage, 0)
+
+		query := fmt.Sprintf("SELECT row_to_json(t) FROM %s t ORDER BY %s;", tbl.name, tbl.key)
+		if err := dbc.Select(&rows, query); err != nil {
+			t.Fatalf("error dumping %s table: %v", tbl.name, err)
+		}
+
+		f[tbl.name] = rows
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		t.Fatalf("error marshaling fixture: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		t.Fatalf("error writing fixture: %v", err)
+	}
+}
+
+// Load replaces the rows of every table the tests seed by those of the fixture file at
+// path written by Dump. Rows are inserted as they are, identifiers included, and new rows
+// get identifiers after the loaded ones.
+func Load(t testing.TB, dbc *sqlx.DB, path string) {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading fixture: %v", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatalf("error unmarshaling fixture %s: %v", path, err)
+	}
+
+	if err := load(dbc, f); err != nil {
+		t.Fatalf("error loading fixture %s: %v", path, err)
+	}
+}
+
+// load replaces the rows of every table by those of f within a single transaction.
+func load(dbc *sqlx.DB, f fixture) error {
+	tx, err := dbc.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	// Rolling back after a commit is a no-op.
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(truncate); err != nil {
+		return errors.Wrap(err, "truncate test database tables")
+	}
+
+	for _, tbl := range tables {
+		rows, ok := f[tbl.name]
+		if !ok || len(rows) == 0 {
+			continue
+		}
+
+		b, err := json.Marshal(rows)
+		if err != nil {
+			return errors.Wrapf(err, "marshal rows of %s table", tbl.name)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s, $1);", tbl.name)
+		if _, err := tx.Exec(query, string(b)); err != nil {
+			return errors.Wrapf(err, "insert rows into %s table", tbl.name)
+		}
+
+		if tbl.serial {
+			query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), coalesce(max(%[2]s), 0) + 1, false) FROM %[1]s;", tbl.name, tbl.key)
+			if _, err := tx.Exec(query); err != nil {
+				return errors.Wrapf(err, "reset sequence of %s table", tbl.name)
+			}
+		}
+	}
+
+	// The item_count trigger counted the loaded items on top of the loaded counts.
+	if _, err := tx.Exec("UPDATE list SET item_count = (SELECT count(*) FROM item WHERE item.list_id = list.list_id);"); err != nil {
+		return errors.Wrap(err, "count items of lists")
+	}
+
+	return errors.Wrap(tx.Commit(), "commit transaction")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/db"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 // These constants define the database connection variables for the test database
@@ -30,22 +31,78 @@ const (
 	databasePort = 5432
 )
 
-// Open returns a new database connection for the test database.
-func Open() (*sqlx.DB, error) {
-	return db.NewConnection(db.Config{
+// Open returns a new database connection for the test database with every migration
+// applied, logging the progress of connecting to log.
+func Open(log logrus.FieldLogger) (*sqlx.DB, error) {
+	dbc, err := db.NewConnection(db.Config{
 		User: databaseUser,
 		Pass: databasePass,
 		Name: databaseName,
 		Host: databaseHost,
 		Port: databasePort,
-	})
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(dbc, log); err != nil {
+		dbc.Close()
+		return nil, errors.Wrap(err, "migrate test database")
+	}
+
+	return dbc, nil
+}
+
+// OpenTenant creates the schema of the named tenant in the test database dbc and returns
+// a new database connection for it with every migration applied.
+func OpenTenant(dbc *sqlx.DB, name string, log logrus.FieldLogger) (*sqlx.DB, error) {
+	if err := db.CreateTenant(dbc, name); err != nil {
+		return nil, err
+	}
+
+	schema, err := db.TenantSchema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tdb, err := db.NewConnection(db.Config{
+		User:   databaseUser,
+		Pass:   databasePass,
+		Name:   databaseName,
+		Host:   databaseHost,
+		Port:   databasePort,
+		Schema: schema,
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(tdb, log); err != nil {
+		tdb.Close()
+		return nil, errors.Wrapf(err, "migrate schema %s", schema)
+	}
+
+	return tdb, nil
+}
+
+// DropTenant removes the schema of the named tenant from the test database dbc along with
+// every table in it.
+func DropTenant(dbc *sqlx.DB, name string) error {
+	schema, err := db.TenantSchema(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbc.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE;")
+	return errors.Wrapf(err, "drop schema %s", schema)
 }
 
+// truncate is the statement that removes all seed data from the test database.
+const truncate = "TRUNCATE TABLE list, list_settings, list_share, item, item_history, attachment, outbox, template, template_item, job, webhook_dead_letter;"
+
 // Truncate removes all seed data from the test database.
 func Truncate(dbc *sqlx.DB) error {
-	stmt := "TRUNCATE TABLE list, item;"
-
-	if _, err := dbc.Exec(stmt); err != nil {
+	if _, err := dbc.Exec(truncate); err != nil {
 		return errors.Wrap(err, "truncate test database tables")
 	}
 
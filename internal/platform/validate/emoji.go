@@ -0,0 +1,136 @@
+package validate
+
+import "unicode/utf8"
+
+// These runes make up emoji sequences along with the emoji themselves, see Emoji.
+const (
+	zwj          = '\u200d'
+	presentation = '\ufe0f'
+	keycap       = '\u20e3'
+	tagEnd       = '\U000e007f'
+)
+
+// maxEmojiRunes is the most runes an emoji may consist of, which is enough for the
+// longest sequences in use, such as families and subdivision flags.
+const maxEmojiRunes = 16
+
+// Emoji reports whether s is a single emoji, which is displayed as a single character: a
+// pictograph optionally followed by a variation selector, a skin tone, or tags, several of
+// those joined by zero-width joiners, a flag made of two regional indicators, or a keycap.
+// It follows Unicode Technical Standard #51 closely enough to accept the emoji keyboards
+// offer and reject text, but doesn't check that a sequence is recommended for general
+// interchange.
+func Emoji(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 || len(runes) > maxEmojiRunes || !utf8.ValidString(s) {
+		return false
+	}
+
+	switch {
+	case regionalIndicator(runes[0]):
+		return len(runes) == 2 && regionalIndicator(runes[1])
+
+	case keycapBase(runes[0]):
+		rest := runes[1:]
+		if len(rest) > 0 && rest[0] == presentation {
+			rest = rest[1:]
+		}
+		return len(rest) == 1 && rest[0] == keycap
+	}
+
+	for i := 0; i < len(runes); {
+		n := emojiElement(runes[i:])
+		if n == 0 {
+			return false
+		}
+		i += n
+
+		if i == len(runes) {
+			return true
+		}
+
+		// Elements are joined by zero-width joiners, which must be followed by another.
+		if runes[i] != zwj {
+			return false
+		}
+		i++
+
+		if i == len(runes) {
+			return false
+		}
+	}
+
+	return false
+}
+
+// emojiElement returns the amount of runes of the emoji element runes begin with, a
+// pictograph and its modifiers, or 0 if they don't begin with one.
+func emojiElement(runes []rune) int {
+	if !pictograph(runes[0]) {
+		return 0
+	}
+	n := 1
+
+	if n < len(runes) && runes[n] == presentation {
+		n++
+	}
+
+	if n < len(runes) && skinTone(runes[n]) {
+		n++
+	}
+
+	// A tag sequence, such as the one of the flag of a subdivision, ends with a cancel tag.
+	if n < len(runes) && tag(runes[n]) {
+		for n < len(runes) && tag(runes[n]) {
+			n++
+		}
+
+		if n == len(runes) || runes[n] != tagEnd {
+			return 0
+		}
+		n++
+	}
+
+	return n
+}
+
+// pictograph reports whether r is an emoji that can stand on its own, which are those in
+// the blocks of symbols and pictographs along with the few older symbols that have an
+// emoji presentation.
+func pictograph(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff && !regionalIndicator(r) && !skinTone(r):
+		return true
+	case r >= 0x2600 && r <= 0x27bf, r >= 0x2300 && r <= 0x23ff, r >= 0x2b00 && r <= 0x2bff:
+		return true
+	case r >= 0x2190 && r <= 0x21ff, r >= 0x25a0 && r <= 0x25ff, r >= 0x2934 && r <= 0x2935:
+		return true
+	}
+
+	switch r {
+	case 0x00a9, 0x00ae, 0x203c, 0x2049, 0x2122, 0x2139, 0x24c2, 0x3030, 0x303d, 0x3297, 0x3299:
+		return true
+	}
+
+	return false
+}
+
+// regionalIndicator reports whether r is one of the letters flags are made of.
+func regionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// skinTone reports whether r is one of the Fitzpatrick modifiers.
+func skinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// keycapBase reports whether r can be turned into a keycap emoji.
+func keycapBase(r rune) bool {
+	return r >= '0' && r <= '9' || r == '#' || r == '*'
+}
+
+// tag reports whether r is a tag character, other than the cancel tag ending a sequence.
+func tag(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007e
+}
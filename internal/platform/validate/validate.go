@@ -0,0 +1,90 @@
+// Package validate normalizes and checks the user supplied fields of request payloads,
+// reporting problems as field errors of the web package.
+package validate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// DefaultMaxNameLength is the maximum amount of characters in a name used by the zero
+// value of Names, which is the size of the name columns of the database.
+const DefaultMaxNameLength = 255
+
+// Mode is how strictly request payloads are validated.
+type Mode string
+
+// These constants define the validation modes. Consumers relying on the legacy behavior
+// run in lenient mode until they have migrated to strict mode.
+const (
+	// ModeStrict enforces every validation rule: names are checked for invisible
+	// characters and against the configured length, and payloads with unknown fields are
+	// rejected.
+	ModeStrict Mode = "strict"
+
+	// ModeLenient keeps the legacy behavior: names are only rejected when the database
+	// can't store them, see Names.Lenient, and unknown fields are ignored.
+	ModeLenient Mode = "lenient"
+)
+
+// Valid reports whether m is a known mode.
+func (m Mode) Valid() bool {
+	return m == ModeStrict || m == ModeLenient
+}
+
+// Names normalizes and checks the names of lists, items, and templates.
+type Names struct {
+	// MaxLength is the maximum amount of characters in a name, DefaultMaxNameLength
+	// when 0.
+	MaxLength int
+
+	// Lenient only rejects names the database can't store, which are those that aren't
+	// valid UTF-8 or are longer than DefaultMaxNameLength, as names were before the other
+	// rules were introduced. MaxLength is ignored.
+	Lenient bool
+}
+
+// Normalize returns name without surrounding whitespace. When name breaks a rule, the
+// returned error is a web.Errors holding a field error about field for every rule it
+// breaks: names must not contain control or invisible formatting characters, such as
+// zero-width spaces, and must not be longer than MaxLength. An empty name is not an
+// error, callers decide whether a name is required.
+func (n Names) Normalize(field, name string) (string, error) {
+	max := n.MaxLength
+	if max == 0 || n.Lenient {
+		max = DefaultMaxNameLength
+	}
+
+	name = strings.TrimSpace(name)
+
+	var errs web.Errors
+
+	if !utf8.ValidString(name) || (!n.Lenient && strings.IndexFunc(name, invisible) != -1) {
+		errs = append(errs, web.NewFieldError(field, codes.NameInvalidCharacters))
+	}
+
+	if l := utf8.RuneCountInString(name); l > max {
+		errs = append(errs, web.NewFieldError(field, codes.NameTooLong, max, l))
+	}
+
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	return name, nil
+}
+
+// invisible reports whether r is a control character or an invisible formatting
+// character. Zero-width joiners and non-joiners are allowed since emoji sequences and
+// some scripts depend on them.
+func invisible(r rune) bool {
+	if r == '\u200c' || r == '\u200d' {
+		return false
+	}
+
+	return unicode.Is(unicode.Cc, r) || unicode.Is(unicode.Cf, r)
+}
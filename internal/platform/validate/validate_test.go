@@ -0,0 +1,157 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/web"
+)
+
+func TestNamesNormalize(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Names    Names
+		Input    string
+		Expected string
+		Codes    []string
+	}{
+		{
+			Name:     "Trimmed",
+			Input:    "  Groceries\t\n",
+			Expected: "Groceries",
+		},
+		{
+			Name:  "Empty",
+			Input: "   ",
+		},
+		{
+			Name:     "ZeroWidthJoiner",
+			Input:    "Family \U0001F468\u200d\U0001F469\u200d\U0001F467",
+			Expected: "Family \U0001F468\u200d\U0001F469\u200d\U0001F467",
+		},
+		{
+			Name:  "ZeroWidthSpace",
+			Input: "Foo\u200bBar",
+			Codes: []string{"name_invalid_characters"},
+		},
+		{
+			Name:  "ControlCharacter",
+			Input: "Foo\x07Bar",
+			Codes: []string{"name_invalid_characters"},
+		},
+		{
+			Name:  "InvalidUTF8",
+			Input: "Foo\xffBar",
+			Codes: []string{"name_invalid_characters"},
+		},
+		{
+			Name:     "MaxLength",
+			Input:    strings.Repeat("ä", DefaultMaxNameLength),
+			Expected: strings.Repeat("ä", DefaultMaxNameLength),
+		},
+		{
+			Name:  "TooLong",
+			Input: strings.Repeat("a", DefaultMaxNameLength+1),
+			Codes: []string{"name_too_long"},
+		},
+		{
+			Name:  "ConfiguredMaxLength",
+			Names: Names{MaxLength: 3},
+			Input: "Milk",
+			Codes: []string{"name_too_long"},
+		},
+		{
+			Name:     "LenientCharacters",
+			Names:    Names{Lenient: true},
+			Input:    "Foo\u200bBar",
+			Expected: "Foo\u200bBar",
+		},
+		{
+			Name:     "LenientLength",
+			Names:    Names{MaxLength: 3, Lenient: true},
+			Input:    "Milk",
+			Expected: "Milk",
+		},
+		{
+			Name:  "LenientUnstorable",
+			Names: Names{Lenient: true},
+			Input: "Foo\xffBar" + strings.Repeat("a", DefaultMaxNameLength),
+			Codes: []string{"name_invalid_characters", "name_too_long"},
+		},
+		{
+			Name:  "EveryRule",
+			Names: Names{MaxLength: 3},
+			Input: "Foo\u200bBar",
+			Codes: []string{"name_invalid_characters", "name_too_long"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			name, err := test.Names.Normalize("name", test.Input)
+			if len(test.Codes) == 0 {
+				if err != nil {
+					t.Fatalf("error normalizing name: %v", err)
+				}
+
+				if name != test.Expected {
+					t.Errorf("expected name %q, got %q", test.Expected, name)
+				}
+				return
+			}
+
+			errs, ok := err.(web.Errors)
+			if !ok {
+				t.Fatalf("expected web.Errors, got %T: %v", err, err)
+			}
+
+			if len(errs) != len(test.Codes) {
+				t.Fatalf("expected %d errors, got %d: %v", len(test.Codes), len(errs), errs)
+			}
+
+			for i, e := range errs {
+				if e.Code != test.Codes[i] || e.Field != "name" {
+					t.Errorf("expected error %d to be %s of field name, got %s of field %q", i, test.Codes[i], e.Code, e.Field)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Expected bool
+	}{
+		{Name: "Pictograph", Input: "\U0001F6D2", Expected: true},
+		{Name: "Symbol", Input: "\u2615", Expected: true},
+		{Name: "PresentationSelector", Input: "\u2764\ufe0f", Expected: true},
+		{Name: "SkinTone", Input: "\U0001F44D\U0001F3FD", Expected: true},
+		{Name: "ZeroWidthJoined", Input: "\U0001F468\u200d\U0001F469\u200d\U0001F467", Expected: true},
+		{Name: "Flag", Input: "\U0001F1E9\U0001F1EA", Expected: true},
+		{Name: "SubdivisionFlag", Input: "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", Expected: true},
+		{Name: "Keycap", Input: "3\ufe0f\u20e3", Expected: true},
+		{Name: "Empty", Input: ""},
+		{Name: "Letter", Input: "A"},
+		{Name: "Digit", Input: "3"},
+		{Name: "Two", Input: "\U0001F6D2\U0001F34E"},
+		{Name: "Text", Input: "\U0001F6D2 cart"},
+		{Name: "TrailingJoiner", Input: "\U0001F468\u200d"},
+		{Name: "LoneSkinTone", Input: "\U0001F3FD"},
+		{Name: "HalfFlag", Input: "\U0001F1E9"},
+		{Name: "UnterminatedTags", Input: "\U0001F3F4\U000E0067\U000E0062"},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if e, a := test.Expected, Emoji(test.Input); e != a {
+				t.Errorf("expected Emoji(%+q) to be %v, got %v", test.Input, e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// EntryStatus is the outcome of a single entry of a batch request.
+type EntryStatus struct {
+	// Status is the status code the entry would have gotten as a request of its own.
+	Status int `json:"status"`
+
+	// Code identifies why the entry failed regardless of the language of Error, it is
+	// empty for entries that succeeded.
+	Code string `json:"code,omitempty"`
+
+	// Field names the field of the entry that failed validation, if any.
+	Field string `json:"field,omitempty"`
+
+	// Allowed lists the values Field can take when the entry gave another one.
+	Allowed []string `json:"allowed,omitempty"`
+
+	// Error describes why the entry failed, it is empty for entries that succeeded.
+	Error string `json:"error,omitempty"`
+
+	// Result is the resource the entry created or changed, if any.
+	Result interface{} `json:"result,omitempty"`
+}
+
+// Batch collects the outcome of every entry of a batch request, in the order of the
+// entries, to be sent with RespondMultiStatus.
+type Batch struct {
+	Entries []EntryStatus
+}
+
+// Succeed records the outcome of an entry that succeeded.
+func (b *Batch) Succeed(code int, result interface{}) {
+	b.Entries = append(b.Entries, EntryStatus{Status: code, Result: result})
+}
+
+// Fail records the outcome of an entry of the batch request r that failed. Errors with a
+// status code of 500 or above are logged and replaced by a generic message, the same way
+// RespondError does. Of Errors, only the first one is recorded.
+func (b *Batch) Fail(r *http.Request, code int, err error) {
+	if code >= http.StatusInternalServerError {
+		Logger(r.Context()).WithError(err).Error("error while serving batch entry")
+		err = StatusError(code)
+	}
+
+	e := responseErrors(r, code, err)[0]
+	b.Entries = append(b.Entries, EntryStatus{Status: code, Code: e.Code, Field: e.Field, Allowed: e.Allowed, Error: e.Message})
+}
+
+// Failed reports whether any entry failed.
+func (b *Batch) Failed() bool {
+	for _, e := range b.Entries {
+		if e.Status >= http.StatusBadRequest {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Abort marks every entry of the batch request r that succeeded with 424 Failed
+// Dependency, for all-or-nothing batches whose changes were discarded because another
+// entry failed.
+func (b *Batch) Abort(r *http.Request) {
+	e := responseErrors(r, http.StatusFailedDependency, NewError(codes.BatchEntryAborted))[0]
+
+	for i, entry := range b.Entries {
+		if entry.Status < http.StatusBadRequest {
+			b.Entries[i] = EntryStatus{
+				Status: http.StatusFailedDependency,
+				Code:   e.Code,
+				Error:  e.Message,
+			}
+		}
+	}
+}
+
+// RespondMultiStatus sends a 207 Multi-Status response with the outcome of every entry
+// of the batch as results.
+func RespondMultiStatus(w http.ResponseWriter, r *http.Request, b *Batch) {
+	entries := b.Entries
+	if entries == nil {
+		entries = make([]EntryStatus, 0)
+	}
+
+	writeResponse(w, r, http.StatusMultiStatus, &Response{Results: entries})
+}
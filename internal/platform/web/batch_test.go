@@ -0,0 +1,74 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Abort    bool
+		Expected []EntryStatus
+	}{
+		{
+			Name: "BestEffort",
+			Expected: []EntryStatus{
+				{Status: http.StatusCreated, Result: "Milk"},
+				{Status: http.StatusConflict, Code: "conflict", Error: "duplicate"},
+				{Status: http.StatusInternalServerError, Code: "internal_server_error", Error: http.StatusText(http.StatusInternalServerError)},
+			},
+		},
+		{
+			Name:  "AllOrNothing",
+			Abort: true,
+			Expected: []EntryStatus{
+				{Status: http.StatusFailedDependency, Code: "batch_entry_aborted", Error: "not applied since another entry of the batch failed"},
+				{Status: http.StatusConflict, Code: "conflict", Error: "duplicate"},
+				{Status: http.StatusInternalServerError, Code: "internal_server_error", Error: http.StatusText(http.StatusInternalServerError)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/list/1/item/batch", nil)
+
+			var b Batch
+			b.Succeed(http.StatusCreated, "Milk")
+			b.Fail(r, http.StatusConflict, errors.New("duplicate"))
+			b.Fail(r, http.StatusInternalServerError, errors.New("connection refused"))
+
+			if !b.Failed() {
+				t.Error("expected the batch to have failed")
+			}
+
+			if test.Abort {
+				b.Abort(r)
+			}
+
+			w := httptest.NewRecorder()
+			RespondMultiStatus(w, r, &b)
+
+			if e, a := http.StatusMultiStatus, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			var entries []EntryStatus
+			if err := json.NewDecoder(w.Body).Decode(&Response{Results: &entries}); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			if d := cmp.Diff(test.Expected, entries); d != "" {
+				t.Errorf("unexpected difference in entries:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
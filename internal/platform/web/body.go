@@ -0,0 +1,40 @@
+package web
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxBodySize is the maximum size of request bodies in bytes when no other limit
+// is configured.
+const DefaultMaxBodySize = 1 << 20
+
+// bodyTooLarge is the message of the error returned by readers of http.MaxBytesReader
+// once the body exceeds its limit.
+const bodyTooLarge = "http: request body too large"
+
+// LimitBody limits the body of r to max bytes and returns r along with the limit, see
+// BodyLimit. Reading beyond the limit fails with an error BodyTooLarge reports, and the
+// connection is closed once the response is sent.
+func LimitBody(w http.ResponseWriter, r *http.Request, max int64) *http.Request {
+	r.Body = http.MaxBytesReader(w, r.Body, max)
+	return r.WithContext(context.WithValue(r.Context(), bodyLimitKey, max))
+}
+
+// BodyLimit returns the limit LimitBody gave the body of r, or DefaultMaxBodySize if it
+// gave none.
+func BodyLimit(r *http.Request) int64 {
+	if max, ok := r.Context().Value(bodyLimitKey).(int64); ok {
+		return max
+	}
+
+	return DefaultMaxBodySize
+}
+
+// BodyTooLarge reports whether err, or its cause, comes from reading a body limited by
+// LimitBody beyond its limit. Handlers respond to such errors with 413 Payload Too Large.
+func BodyTooLarge(err error) bool {
+	return err != nil && errors.Cause(err).Error() == bodyTooLarge
+}
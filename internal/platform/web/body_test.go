@@ -0,0 +1,61 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Body     string
+		Expected bool
+	}{
+		{
+			Name: "WithinLimit",
+			Body: `{"name":"Foo"}`,
+		},
+		{
+			Name: "Malformed",
+			Body: `{"name":`,
+		},
+		{
+			Name:     "TooLarge",
+			Body:     `{"name":"` + strings.Repeat("a", 64) + `"}`,
+			Expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/list", strings.NewReader(test.Body))
+			LimitBody(httptest.NewRecorder(), r, 32)
+
+			var v map[string]string
+			err := errors.Wrap(json.NewDecoder(r.Body).Decode(&v), "decode payload")
+
+			if e, a := test.Expected, BodyTooLarge(err); e != a {
+				t.Errorf("expected body too large: %v, got: %v (%v)", e, a, err)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/import/trello", strings.NewReader("{}"))
+	if e, a := int64(DefaultMaxBodySize), BodyLimit(r); e != a {
+		t.Errorf("expected limit %d of an unlimited body, got %d", e, a)
+	}
+
+	r = LimitBody(httptest.NewRecorder(), r, 10<<20)
+	if e, a := int64(10<<20), BodyLimit(r); e != a {
+		t.Errorf("expected limit %d, got %d", e, a)
+	}
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/metrics"
+	"github.com/pkg/errors"
+)
+
+// These constants define the classes error responses fall into. Only server errors
+// count against the error budget of the service, client errors are the fault of the
+// request and unavailability is expected to pass on its own.
+const (
+	// ClassClient is the class of 4xx responses.
+	ClassClient = "client"
+
+	// ClassUnavailable is the class of 503 responses, such as to database timeouts or
+	// requests made during maintenance.
+	ClassUnavailable = "unavailable"
+
+	// ClassServer is the class of every other 5xx response.
+	ClassServer = "server"
+)
+
+// classCounters count the error responses of each class.
+var classCounters = map[string]*metrics.Counter{
+	ClassClient:      metrics.NewCounter("http_client_errors_total", "Error responses caused by the request."),
+	ClassUnavailable: metrics.NewCounter("http_unavailable_errors_total", "Error responses to requests that can be retried later."),
+	ClassServer:      metrics.NewCounter("http_server_errors_total", "Error responses caused by the server."),
+}
+
+// Class returns the class of an error response with the given status code.
+func Class(code int) string {
+	switch {
+	case code < http.StatusInternalServerError:
+		return ClassClient
+	case code == http.StatusServiceUnavailable:
+		return ClassUnavailable
+	default:
+		return ClassServer
+	}
+}
+
+// retryAfter is the Retry-After header of 503 responses that don't set their own, in
+// seconds.
+const retryAfter = "5"
+
+// StatusCoder is implemented by errors that know the status code they are responded
+// with, which takes precedence over the one given to RespondError.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// ErrorMapper returns the status code an error is responded with instead of a 500, or 0
+// if the error is none of its concern. Mappers let errors of packages that don't know
+// about HTTP, such as database drivers, be responded with the right status code.
+type ErrorMapper func(err error) int
+
+// mappers holds the mappers added by MapErrors.
+var mappers []ErrorMapper
+
+// MapErrors adds m to the mappers consulted by RespondError for errors responded with a
+// 500. It is not safe for concurrent use and meant to be called from init functions.
+func MapErrors(m ErrorMapper) {
+	mappers = append(mappers, m)
+}
+
+// statusOf returns the status code err is responded with when the handler chose code,
+// see StatusCoder and MapErrors. mapped reports whether a mapper chose the status code.
+func statusOf(code int, err error) (status int, mapped bool) {
+	if sc, ok := errors.Cause(err).(StatusCoder); ok {
+		return sc.StatusCode(), false
+	}
+
+	if code != http.StatusInternalServerError {
+		return code, false
+	}
+
+	for _, m := range mappers {
+		if c := m(err); c != 0 {
+			return c, true
+		}
+	}
+
+	return code, false
+}
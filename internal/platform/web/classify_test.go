@@ -0,0 +1,121 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// statusCoded is an error that knows its status code.
+type statusCoded int
+
+// Error implements the error interface.
+func (s statusCoded) Error() string {
+	return http.StatusText(int(s))
+}
+
+// StatusCode implements the StatusCoder interface.
+func (s statusCoded) StatusCode() int {
+	return int(s)
+}
+
+// errTimeout is an error mapped to a 503 by the mapper of TestRespondErrorClassified.
+var errTimeout = errors.New("pq: canceling statement due to statement timeout")
+
+func TestRespondErrorClassified(t *testing.T) {
+	defer func(m []ErrorMapper) {
+		mappers = m
+	}(mappers)
+
+	MapErrors(func(err error) int {
+		if errors.Cause(err) == errTimeout {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	})
+
+	tests := []struct {
+		Name               string
+		Code               int
+		Err                error
+		ExpectedCode       int
+		ExpectedRetryAfter string
+		ExpectedCounter    string
+	}{
+		{
+			Name:            "Client",
+			Code:            http.StatusNotFound,
+			Err:             StatusError(http.StatusNotFound),
+			ExpectedCode:    http.StatusNotFound,
+			ExpectedCounter: ClassClient,
+		},
+		{
+			Name:            "Server",
+			Code:            http.StatusInternalServerError,
+			Err:             errors.New("boom"),
+			ExpectedCode:    http.StatusInternalServerError,
+			ExpectedCounter: ClassServer,
+		},
+		{
+			Name:               "Mapped",
+			Code:               http.StatusInternalServerError,
+			Err:                errors.Wrap(errTimeout, "select list"),
+			ExpectedCode:       http.StatusServiceUnavailable,
+			ExpectedRetryAfter: retryAfter,
+			ExpectedCounter:    ClassUnavailable,
+		},
+		{
+			Name:            "MappedOnlyFromServerErrors",
+			Code:            http.StatusBadRequest,
+			Err:             errTimeout,
+			ExpectedCode:    http.StatusBadRequest,
+			ExpectedCounter: ClassClient,
+		},
+		{
+			Name:            "StatusCoder",
+			Code:            http.StatusInternalServerError,
+			Err:             errors.Wrap(statusCoded(http.StatusConflict), "insert list"),
+			ExpectedCode:    http.StatusConflict,
+			ExpectedCounter: ClassClient,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			before := classCounters[test.ExpectedCounter].Value()
+
+			w := httptest.NewRecorder()
+			RespondError(w, httptest.NewRequest(http.MethodGet, "/list/1", nil), test.Code, test.Err)
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := test.ExpectedRetryAfter, w.Header().Get("Retry-After"); e != a {
+				t.Errorf("expected Retry-After %q, got %q", e, a)
+			}
+
+			if a := classCounters[test.ExpectedCounter].Value() - before; a != 1 {
+				t.Errorf("expected the %s counter to be incremented once, got %d", test.ExpectedCounter, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestClass(t *testing.T) {
+	for code, expected := range map[int]string{
+		http.StatusBadRequest:          ClassClient,
+		http.StatusConflict:            ClassClient,
+		http.StatusServiceUnavailable:  ClassUnavailable,
+		http.StatusInternalServerError: ClassServer,
+		http.StatusGatewayTimeout:      ClassServer,
+	} {
+		if a := Class(code); a != expected {
+			t.Errorf("expected class %s for %d, got %s", expected, code, a)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Proxies are the networks of the reverse proxies in front of the service, whose
+// forwarding headers are trusted to name the client a request came from.
+type Proxies []*net.IPNet
+
+// ParseProxies parses the given networks in CIDR notation, such as 10.0.0.0/8, or single
+// IP addresses.
+func ParseProxies(networks []string) (Proxies, error) {
+	p := make(Proxies, 0, len(networks))
+
+	for _, n := range networks {
+		if !strings.Contains(n, "/") {
+			ip := net.ParseIP(n)
+			if ip == nil {
+				return nil, errors.Errorf("invalid IP address %q", n)
+			}
+
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+
+			p = append(p, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipnet, err := net.ParseCIDR(n)
+		if err != nil {
+			return nil, errors.Errorf("invalid network %q", n)
+		}
+
+		p = append(p, ipnet)
+	}
+
+	return p, nil
+}
+
+// trusts reports whether ip belongs to any of the networks of p.
+func (p Proxies) trusts(ip net.IP) bool {
+	for _, n := range p {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the IP address of the client r came from. The forwarding headers, that
+// is Forwarded, X-Forwarded-For, or X-Real-IP in that order of preference, are only
+// honored when the peer is a trusted proxy. The chain of addresses they carry is walked
+// from the peer towards the client and stops at the first address that isn't a trusted
+// proxy, so a client can't pose as another one by sending the headers itself.
+func (p Proxies) Resolve(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil || !p.trusts(peer) {
+		return host
+	}
+
+	var hops []string
+	switch {
+	case r.Header.Get("Forwarded") != "":
+		hops = forwardedFor(r.Header.Values("Forwarded"))
+	case r.Header.Get("X-Forwarded-For") != "":
+		for _, v := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	default:
+		hops = []string{r.Header.Get("X-Real-IP")}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+
+			// Obfuscated or unknown addresses end the chain, the last proxy that could be
+			// identified is the best guess.
+			break
+		}
+
+		client = ip
+		if !p.trusts(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// forwardedFor returns the for parameters of the elements of the given Forwarded
+// headers, see RFC 7239.
+func forwardedFor(headers []string) []string {
+	var hops []string
+
+	for _, h := range headers {
+		for _, element := range strings.Split(h, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hops = append(hops, strings.Trim(kv[1], `"`))
+				}
+			}
+		}
+	}
+
+	return hops
+}
+
+// parseHop parses an address of a forwarding header, which may carry a port and IPv6
+// addresses may be enclosed in brackets. nil is returned for anything that isn't an IP
+// address.
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
+
+// WithClientIP returns a shallow copy of r carrying the IP address of the client it came
+// from, see Proxies.Resolve and ClientIP.
+func WithClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey, ip))
+}
+
+// ClientIP returns the IP address of the client of the request that the given context
+// belongs to, or an empty string if it wasn't set with WithClientIP.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxiesResolve(t *testing.T) {
+	proxies, err := ParseProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("error parsing proxies: %v", err)
+	}
+
+	tests := []struct {
+		Name       string
+		RemoteAddr string
+		Header     http.Header
+		Expected   string
+	}{
+		{
+			Name:       "NoHeaders",
+			RemoteAddr: "203.0.113.7:51234",
+			Expected:   "203.0.113.7",
+		},
+		{
+			Name:       "UntrustedPeer",
+			RemoteAddr: "203.0.113.7:51234",
+			Header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			Expected:   "203.0.113.7",
+		},
+		{
+			Name:       "XForwardedFor",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			Expected:   "198.51.100.1",
+		},
+		{
+			Name:       "XForwardedForChain",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"X-Forwarded-For": {"198.51.100.1, 10.1.1.1", "192.0.2.1"}},
+			Expected:   "198.51.100.1",
+		},
+		{
+			Name:       "XForwardedForSpoofed",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1"}},
+			Expected:   "198.51.100.1",
+		},
+		{
+			Name:       "XForwardedForOnlyProxies",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"X-Forwarded-For": {"10.1.1.1"}},
+			Expected:   "10.1.1.1",
+		},
+		{
+			Name:       "XForwardedForGarbage",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"X-Forwarded-For": {"198.51.100.1, garbage"}},
+			Expected:   "10.0.0.2",
+		},
+		{
+			Name:       "Forwarded",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"Forwarded": {`for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}},
+			Expected:   "198.51.100.1",
+		},
+		{
+			Name:       "ForwardedPreferred",
+			RemoteAddr: "10.0.0.2:51234",
+			Header: http.Header{
+				"Forwarded":       {"for=198.51.100.1"},
+				"X-Forwarded-For": {"198.51.100.2"},
+			},
+			Expected: "198.51.100.1",
+		},
+		{
+			Name:       "ForwardedObfuscated",
+			RemoteAddr: "10.0.0.2:51234",
+			Header:     http.Header{"Forwarded": {"for=_hidden, for=10.1.1.1"}},
+			Expected:   "10.1.1.1",
+		},
+		{
+			Name:       "XRealIP",
+			RemoteAddr: "[2001:db8::2]:51234",
+			Header:     http.Header{"X-Real-Ip": {"198.51.100.1"}},
+			Expected:   "198.51.100.1",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list", nil)
+			r.RemoteAddr = test.RemoteAddr
+			for k, v := range test.Header {
+				r.Header[k] = v
+			}
+
+			if a := proxies.Resolve(r); a != test.Expected {
+				t.Errorf("expected client IP %s, got %s", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	for _, invalid := range []string{"proxy.local", "10.0.0.0/33", ""} {
+		if _, err := ParseProxies([]string{invalid}); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
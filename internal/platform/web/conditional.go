@@ -0,0 +1,31 @@
+package web
+
+import (
+	"net/http"
+	"time"
+)
+
+// NotModified sets the Last-Modified header of the response to modified and reports
+// whether the copy the client validates with the If-Modified-Since header of r is still
+// current. In that case 304 Not Modified has been sent and the caller must not respond
+// any further. HTTP dates have a precision of seconds, so modified is truncated.
+func NotModified(w http.ResponseWriter, r *http.Request, modified time.Time) bool {
+	if modified.IsZero() {
+		return false
+	}
+
+	modified = modified.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2018, time.December, 19, 10, 30, 15, 500, time.UTC)
+
+	tests := []struct {
+		Name            string
+		Method          string
+		IfModifiedSince string
+		Expected        bool
+	}{
+		{
+			Name:   "NoValidator",
+			Method: http.MethodGet,
+		},
+		{
+			Name:            "Unchanged",
+			Method:          http.MethodGet,
+			IfModifiedSince: "Wed, 19 Dec 2018 10:30:15 GMT",
+			Expected:        true,
+		},
+		{
+			Name:            "Later",
+			Method:          http.MethodHead,
+			IfModifiedSince: "Thu, 20 Dec 2018 00:00:00 GMT",
+			Expected:        true,
+		},
+		{
+			Name:            "Changed",
+			Method:          http.MethodGet,
+			IfModifiedSince: "Wed, 19 Dec 2018 10:30:14 GMT",
+		},
+		{
+			Name:            "MalformedDate",
+			Method:          http.MethodGet,
+			IfModifiedSince: "yesterday",
+		},
+		{
+			Name:            "Write",
+			Method:          http.MethodPut,
+			IfModifiedSince: "Thu, 20 Dec 2018 00:00:00 GMT",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(test.Method, "/list/1", nil)
+			if test.IfModifiedSince != "" {
+				r.Header.Set("If-Modified-Since", test.IfModifiedSince)
+			}
+
+			w := httptest.NewRecorder()
+			if e, a := test.Expected, NotModified(w, r, modified); e != a {
+				t.Errorf("expected not modified: %v, got: %v", e, a)
+			}
+
+			if e, a := "Wed, 19 Dec 2018 10:30:15 GMT", w.Header().Get("Last-Modified"); e != a {
+				t.Errorf("expected Last-Modified header: %q, got: %q", e, a)
+			}
+
+			if test.Expected && w.Code != http.StatusNotModified {
+				t.Errorf("expected status code: %v, got status code: %v", http.StatusNotModified, w.Code)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
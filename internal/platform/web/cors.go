@@ -0,0 +1,86 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CORS are the rules by which browsers let pages of other origins read the responses of
+// an API, see Handle. Credentials are never allowed, so they only suit APIs that are
+// served without authentication and only read.
+type CORS struct {
+	// Origins are the origins whose pages may read responses, such as
+	// https://lists.example.com, or * for every origin.
+	Origins []string
+
+	// MaxAge is how long browsers may reuse the answer to a preflight request, 0 leaves
+	// it up to them.
+	MaxAge time.Duration
+}
+
+// corsExposedHeaders are the headers of responses that pages are allowed to read beyond
+// the ones browsers always expose.
+const corsExposedHeaders = "Retry-After, X-Request-Id"
+
+// allows returns the value of the Access-Control-Allow-Origin header of responses to
+// origin, reporting whether origin is allowed at all.
+func (c CORS) allows(origin string) (string, bool) {
+	for _, o := range c.Origins {
+		if o == "*" {
+			return "*", true
+		}
+
+		if o == origin {
+			return origin, true
+		}
+	}
+
+	return "", false
+}
+
+// Handle adds the headers that allow the origin of r to read the response to it, if it
+// is allowed, and answers r itself when it is a preflight request, reporting whether it
+// did. Preflight requests only get the headers when they ask for one of the methods that
+// are only read with, any request headers are allowed.
+func (c CORS) Handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	// Responses depend on the origin unless every origin is allowed the same way.
+	allowed, ok := c.allows(origin)
+	if allowed != "*" {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	if origin == "" || !ok {
+		if preflight {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return preflight
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", allowed)
+
+	if !preflight {
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		return false
+	}
+
+	switch r.Header.Get("Access-Control-Request-Method") {
+	case http.MethodGet, http.MethodHead:
+		w.Header().Set("Access-Control-Allow-Methods", readMethods)
+
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+
+		if c.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
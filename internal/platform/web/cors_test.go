@@ -0,0 +1,138 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSHandle(t *testing.T) {
+	every := CORS{Origins: []string{"*"}, MaxAge: 10 * time.Minute}
+	some := CORS{Origins: []string{"https://lists.example.com"}}
+
+	tests := []struct {
+		Name            string
+		CORS            CORS
+		Method          string
+		Headers         map[string]string
+		ExpectedHandled bool
+		ExpectedHeaders map[string]string
+	}{
+		{
+			Name:    "AnyOrigin",
+			CORS:    every,
+			Method:  http.MethodGet,
+			Headers: map[string]string{"Origin": "https://example.com"},
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Expose-Headers": "Retry-After, X-Request-Id",
+				"Vary":                          "",
+			},
+		},
+		{
+			Name:    "AllowedOrigin",
+			CORS:    some,
+			Method:  http.MethodGet,
+			Headers: map[string]string{"Origin": "https://lists.example.com"},
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://lists.example.com",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			Name:    "OtherOrigin",
+			CORS:    some,
+			Method:  http.MethodGet,
+			Headers: map[string]string{"Origin": "https://example.com"},
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			Name:   "SameOrigin",
+			CORS:   every,
+			Method: http.MethodGet,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			Name:   "Preflight",
+			CORS:   every,
+			Method: http.MethodOptions,
+			Headers: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "Accept-Language",
+			},
+			ExpectedHandled: true,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "GET, HEAD, OPTIONS",
+				"Access-Control-Allow-Headers": "Accept-Language",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			Name:   "PreflightWrite",
+			CORS:   every,
+			Method: http.MethodOptions,
+			Headers: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			ExpectedHandled: true,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			Name:   "PreflightOtherOrigin",
+			CORS:   some,
+			Method: http.MethodOptions,
+			Headers: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			ExpectedHandled: true,
+			ExpectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			Name:   "Options",
+			CORS:   every,
+			Method: http.MethodOptions,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(test.Method, "/shared/abc", nil)
+			for k, v := range test.Headers {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+
+			if e, a := test.ExpectedHandled, test.CORS.Handle(w, r); e != a {
+				t.Fatalf("expected handled: %v, got handled: %v", e, a)
+			}
+
+			if test.ExpectedHandled && w.Code != http.StatusNoContent {
+				t.Errorf("expected status: %d, got status: %d", http.StatusNoContent, w.Code)
+			}
+
+			for k, e := range test.ExpectedHeaders {
+				if a := w.Header().Get(k); e != a {
+					t.Errorf("expected %s header: %q, got: %q", k, e, a)
+				}
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,217 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Deprecation describes a field of responses that is deprecated and removed at Sunset,
+// such as a field that was renamed. Until then responses keep the field and carry its
+// Replacement, if any, next to it with the same value, so integrators can move over at
+// their own pace. Responses using the field announce it by the Deprecation and Sunset
+// headers of RFC 9745 and RFC 8594, and by the deprecations of their envelope.
+type Deprecation struct {
+	// Field is the name of the deprecated field, wherever it appears in a response.
+	Field string `json:"field"`
+
+	// Replacement is the name of the field that replaces it, if any.
+	Replacement string `json:"replacement,omitempty"`
+
+	// Deprecated is when the field was deprecated.
+	Deprecated time.Time `json:"deprecated"`
+
+	// Sunset is when the field is removed.
+	Sunset time.Time `json:"sunset"`
+}
+
+// WithDeprecations returns a shallow copy of r whose response is checked for the fields
+// deprecated by ds, see Deprecation.
+func WithDeprecations(r *http.Request, ds []Deprecation) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), deprecationsKey, ds))
+}
+
+// deprecations returns the deprecations the response to r is checked for, see
+// WithDeprecations.
+func deprecations(r *http.Request) []Deprecation {
+	ds, _ := r.Context().Value(deprecationsKey).([]Deprecation)
+	return ds
+}
+
+// deprecate returns the marshaled response b with the replacement of every deprecated
+// field it uses added next to it, indented again when pretty is true. The deprecations of
+// the fields it uses are announced by the headers of w and, unless the response isn't
+// wrapped, by the deprecations of its envelope. Responses that use no deprecated field
+// are returned as they are.
+func deprecate(w http.ResponseWriter, r *http.Request, b []byte, wrapped, pretty bool) ([]byte, error) {
+	var candidates []Deprecation
+	for _, d := range deprecations(r) {
+		if bytes.Contains(b, []byte(strconv.Quote(d.Field)+":")) {
+			candidates = append(candidates, d)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return b, nil
+	}
+
+	out, used, err := replaceDeprecated(b, candidates, wrapped)
+	if err != nil || len(used) == 0 {
+		return b, err
+	}
+
+	deprecated, sunset := used[0].Deprecated, used[0].Sunset
+	for _, d := range used[1:] {
+		if d.Deprecated.Before(deprecated) {
+			deprecated = d.Deprecated
+		}
+
+		if d.Sunset.Before(sunset) {
+			sunset = d.Sunset
+		}
+	}
+
+	w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecated.Unix(), 10))
+	w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+
+	if !pretty {
+		return out, nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return nil, errors.Wrap(err, "indent response")
+	}
+	indented.WriteByte('\n')
+
+	return indented.Bytes(), nil
+}
+
+// replaceDeprecated returns the single JSON document b with the replacement of every
+// field deprecated by ds it holds added next to it, along with the deprecations of the
+// fields it holds. When wrapped is true, they are added to the envelope b is as well.
+func replaceDeprecated(b []byte, ds []Deprecation, wrapped bool) ([]byte, []Deprecation, error) {
+	byField := make(map[string]int, len(ds))
+	for i, d := range ds {
+		byField[d.Field] = i
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	// scope is an object or array being rewritten. Objects alternate between keys and
+	// values.
+	type scope struct {
+		object bool
+		value  bool
+		n      int
+	}
+
+	var (
+		out   bytes.Buffer
+		used  []Deprecation
+		found = make(map[int]bool)
+	)
+	stack := []scope{{}}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "read token")
+		}
+
+		s := &stack[len(stack)-1]
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			if len(stack) == 2 && d == '}' && wrapped && len(used) > 0 {
+				v, err := json.Marshal(used)
+				if err != nil {
+					return nil, nil, errors.Wrap(err, "write deprecations")
+				}
+
+				out.WriteString(`,"deprecations":`)
+				out.Write(v)
+			}
+
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if s.object && !s.value {
+			if s.n > 0 {
+				out.WriteByte(',')
+			}
+
+			key, _ := tok.(string)
+			k, _ := json.Marshal(key)
+			out.Write(k)
+			out.WriteByte(':')
+
+			i, ok := byField[key]
+			if !ok {
+				s.value = true
+				continue
+			}
+
+			// The value of a deprecated field is copied as a whole to its replacement.
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return nil, nil, errors.Wrap(err, "read deprecated field")
+			}
+			out.Write(v)
+			s.n++
+
+			d := ds[i]
+			if d.Replacement != "" {
+				r, _ := json.Marshal(d.Replacement)
+				out.WriteByte(',')
+				out.Write(r)
+				out.WriteByte(':')
+				out.Write(v)
+			}
+
+			if !found[i] {
+				found[i] = true
+				used = append(used, d)
+			}
+			continue
+		}
+
+		if !s.object && s.n > 0 {
+			out.WriteByte(',')
+		}
+
+		if s.object {
+			s.value = false
+		}
+		s.n++
+
+		if d, ok := tok.(json.Delim); ok {
+			out.WriteByte(byte(d))
+			stack = append(stack, scope{object: d == '{'})
+			continue
+		}
+
+		v, err := json.Marshal(tok)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "write token")
+		}
+		out.Write(v)
+	}
+
+	if len(stack) != 1 || stack[0].n != 1 {
+		return nil, nil, errors.New("expected a single JSON document")
+	}
+
+	return out.Bytes(), used, nil
+}
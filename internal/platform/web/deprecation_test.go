@@ -0,0 +1,103 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRespondDeprecations(t *testing.T) {
+	ds := []Deprecation{
+		{
+			Field:       "item_count",
+			Replacement: "itemCount",
+			Deprecated:  time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+			Sunset:      time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Field:      "legacy",
+			Deprecated: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Sunset:     time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	announced := `[{"field":"item_count","replacement":"itemCount","deprecated":"2026-10-16T00:00:00Z","sunset":"2027-04-30T00:00:00Z"}]`
+
+	tests := []struct {
+		Name        string
+		Query       string
+		Accept      string
+		Results     interface{}
+		Expected    string
+		Deprecation string
+		Sunset      string
+	}{
+		{
+			Name:     "Unused",
+			Results:  map[string]int{"id": 1},
+			Expected: `{"results":{"id":1}}`,
+		},
+		{
+			Name:        "Used",
+			Results:     []map[string]int{{"id": 1, "item_count": 2}, {"id": 2, "item_count": 0}},
+			Expected:    `{"results":[{"id":1,"item_count":2,"itemCount":2},{"id":2,"item_count":0,"itemCount":0}],"deprecations":` + announced + `}`,
+			Deprecation: "@1792108800",
+			Sunset:      "Fri, 30 Apr 2027 00:00:00 GMT",
+		},
+		{
+			Name:        "UsedWithoutReplacement",
+			Results:     map[string]interface{}{"item_count": 2, "legacy": []int{1, 2}},
+			Expected:    `{"results":{"item_count":2,"itemCount":2,"legacy":[1,2]},"deprecations":` + announced[:len(announced)-1] + `,{"field":"legacy","deprecated":"2026-01-01T00:00:00Z","sunset":"2027-06-30T00:00:00Z"}]}`,
+			Deprecation: "@1767225600",
+			Sunset:      "Fri, 30 Apr 2027 00:00:00 GMT",
+		},
+		{
+			Name:     "NameAsValue",
+			Results:  map[string]string{"field": "item_count"},
+			Expected: `{"results":{"field":"item_count"}}`,
+		},
+		{
+			Name:        "Raw",
+			Accept:      `application/json; profile="raw"`,
+			Results:     map[string]int{"item_count": 2},
+			Expected:    `{"item_count":2,"itemCount":2}`,
+			Deprecation: "@1792108800",
+			Sunset:      "Fri, 30 Apr 2027 00:00:00 GMT",
+		},
+		{
+			Name:        "Pretty",
+			Query:       "?pretty=true",
+			Results:     map[string]int{"item_count": 2},
+			Expected:    "{\n  \"results\": {\n    \"item_count\": 2,\n    \"itemCount\": 2\n  },\n  \"deprecations\": [\n    {\n      \"field\": \"item_count\",\n      \"replacement\": \"itemCount\",\n      \"deprecated\": \"2026-10-16T00:00:00Z\",\n      \"sunset\": \"2027-04-30T00:00:00Z\"\n    }\n  ]\n}\n",
+			Deprecation: "@1792108800",
+			Sunset:      "Fri, 30 Apr 2027 00:00:00 GMT",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list"+test.Query, nil)
+			if test.Accept != "" {
+				r.Header.Set("Accept", test.Accept)
+			}
+
+			w := httptest.NewRecorder()
+			Respond(w, WithDeprecations(r, ds), http.StatusOK, test.Results)
+
+			if e, a := test.Expected, w.Body.String(); e != a {
+				t.Errorf("expected body: %v, got body: %v", e, a)
+			}
+
+			if e, a := test.Deprecation, w.Header().Get("Deprecation"); e != a {
+				t.Errorf("expected Deprecation header: %q, got: %q", e, a)
+			}
+
+			if e, a := test.Sunset, w.Header().Get("Sunset"); e != a {
+				t.Errorf("expected Sunset header: %q, got: %q", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,54 @@
+package web
+
+import "strings"
+
+// Enum is a named set of the values a field can take, such as the units item quantities
+// are given in. Values are matched regardless of case and surrounding whitespace and
+// always kept in the spelling of the Enum, so a field serializes the same way however a
+// client spelled it. Enums are meant to be listed to clients, which build their forms from
+// them.
+type Enum struct {
+	// Name identifies the Enum to clients.
+	Name string `json:"name"`
+
+	// Values are the values the field can take, in the order clients should offer them.
+	Values []string `json:"values"`
+
+	// Optional reports whether the field can be left empty.
+	Optional bool `json:"optional"`
+
+	// code is the code of the error of values that aren't one of Values.
+	code string
+}
+
+// NewEnum returns an Enum of the given values, whose invalid values are reported with
+// code. The messages of code are given the allowed values and the invalid value.
+func NewEnum(name, code string, optional bool, values ...string) Enum {
+	return Enum{Name: name, Values: values, Optional: optional, code: code}
+}
+
+// Parse returns the value of e that v stands for, or a field error about field listing
+// the allowed values when there is none, which callers should respond to with 400 Bad
+// Request. An empty v is returned as is when e is Optional.
+func (e Enum) Parse(field, v string) (string, *Error) {
+	v = strings.TrimSpace(v)
+	if v == "" && e.Optional {
+		return "", nil
+	}
+
+	for _, value := range e.Values {
+		if strings.EqualFold(value, v) {
+			return value, nil
+		}
+	}
+
+	return "", e.invalid(field, v)
+}
+
+// invalid returns the error of the value v of field, which isn't one of the values of e.
+func (e Enum) invalid(field, v string) *Error {
+	err := NewFieldError(field, e.code, strings.Join(e.Values, ", "), v)
+	err.Allowed = e.Values
+
+	return err
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnumParse(t *testing.T) {
+	units := NewEnum("itemUnit", codes.UnitInvalid, true, "pcs", "kg", "ml")
+	sorts := NewEnum("settingsSort", codes.SettingsSortInvalid, false, "name", "-name")
+
+	tests := []struct {
+		Name     string
+		Enum     Enum
+		Field    string
+		Value    string
+		Expected string
+		Error    string
+	}{
+		{
+			Name:     "Value",
+			Enum:     units,
+			Field:    "unit",
+			Value:    "kg",
+			Expected: "kg",
+		},
+		{
+			Name:     "Spelling",
+			Enum:     units,
+			Field:    "unit",
+			Value:    " KG ",
+			Expected: "kg",
+		},
+		{
+			Name:  "Optional",
+			Enum:  units,
+			Field: "unit",
+			Value: "",
+		},
+		{
+			Name:  "Required",
+			Enum:  sorts,
+			Field: "sort",
+			Value: " ",
+			Error: `sort must be one of name, -name, got ""`,
+		},
+		{
+			Name:  "Invalid",
+			Enum:  units,
+			Field: "unit",
+			Value: "lb",
+			Error: `unit must be one of pcs, kg, ml, got "lb"`,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			v, err := test.Enum.Parse(test.Field, test.Value)
+			if test.Error == "" {
+				if err != nil {
+					t.Fatalf("error parsing value: %v", err)
+				}
+
+				if e, a := test.Expected, v; e != a {
+					t.Errorf("expected value: %q, got value: %q", e, a)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error: %v, got none", test.Error)
+			}
+
+			if e, a := test.Error, err.Error(); e != a {
+				t.Errorf("expected error: %v, got error: %v", e, a)
+			}
+
+			if e, a := test.Field, err.Field; e != a {
+				t.Errorf("expected field: %v, got field: %v", e, a)
+			}
+
+			if d := cmp.Diff(test.Enum.Values, err.Allowed); d != "" {
+				t.Errorf("unexpected difference in allowed values:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
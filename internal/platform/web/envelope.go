@@ -0,0 +1,92 @@
+package web
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Envelope is a style JSON responses are written in.
+type Envelope string
+
+// These constants define the envelopes responses can be written in.
+const (
+	// EnvelopeWrapped wraps the results of a response in a Response, next to the page
+	// and errors.
+	EnvelopeWrapped Envelope = "wrapped"
+
+	// EnvelopeRaw writes the results of a response as they are, such as a bare array of
+	// lists. The total of a page is given by the X-Total-Count header instead, and the
+	// cursor of a truncated response by the X-Cursor header. Responses with errors are
+	// wrapped regardless, since they have no results.
+	EnvelopeRaw Envelope = "raw"
+)
+
+// totalCountHeader is the header that holds the total of a page in raw responses.
+const totalCountHeader = "X-Total-Count"
+
+// Valid reports whether e is one of the known envelopes.
+func (e Envelope) Valid() bool {
+	return e == EnvelopeWrapped || e == EnvelopeRaw
+}
+
+// WithEnvelope returns a shallow copy of r whose response is written in the envelope e by
+// default. Clients choose for themselves with the profile parameter of the Accept header.
+func WithEnvelope(r *http.Request, e Envelope) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), envelopeKey, e))
+}
+
+// envelope returns the envelope the response to r is written in. It is given by the
+// profile parameter of a JSON media range of the Accept header, such as
+// application/json; profile="raw", or defaults to the setting of WithEnvelope and
+// EnvelopeWrapped otherwise.
+func envelope(r *http.Request) Envelope {
+	for _, header := range r.Header.Values("Accept") {
+		for _, mr := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(mr)
+			if err != nil {
+				continue
+			}
+
+			switch mediaType {
+			case "application/json", "application/*", "*/*":
+			default:
+				continue
+			}
+
+			if e := Envelope(params["profile"]); e.Valid() {
+				return e
+			}
+		}
+	}
+
+	if e, ok := r.Context().Value(envelopeKey).(Envelope); ok && e.Valid() {
+		return e
+	}
+
+	return EnvelopeWrapped
+}
+
+// unwrap returns what is written in response to r for resp, which is resp itself or its
+// bare results when r is answered in EnvelopeRaw. The content type and, for pages and
+// truncated responses, the total and cursor are set on w accordingly.
+func unwrap(w http.ResponseWriter, r *http.Request, resp *Response) interface{} {
+	w.Header().Add("Vary", "Accept")
+
+	if envelope(r) != EnvelopeRaw || len(resp.Errors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		return resp
+	}
+
+	w.Header().Set("Content-Type", `application/json; profile="raw"`)
+	if resp.Page != nil {
+		w.Header().Set(totalCountHeader, strconv.Itoa(resp.Page.Total))
+	}
+	if resp.Truncated {
+		w.Header().Set(cursorHeader, resp.Cursor)
+	}
+
+	return resp.Results
+}
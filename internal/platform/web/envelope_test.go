@@ -0,0 +1,149 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondEnvelope(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Accept      string
+		Default     Envelope
+		Respond     func(w http.ResponseWriter, r *http.Request)
+		Expected    string
+		ContentType string
+		TotalCount  string
+		Cursor      string
+	}{
+		{
+			Name:        "Wrapped",
+			Expected:    `{"results":[1,2]}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:        "Accept",
+			Accept:      `application/json; profile="raw"`,
+			Expected:    `[1,2]`,
+			ContentType: `application/json; profile="raw"`,
+		},
+		{
+			Name:        "AcceptAmongOthers",
+			Accept:      `text/html, */*;q=0.8;profile=raw`,
+			Expected:    `[1,2]`,
+			ContentType: `application/json; profile="raw"`,
+		},
+		{
+			Name:        "AcceptOtherMediaType",
+			Accept:      `text/html; profile="raw"`,
+			Expected:    `{"results":[1,2]}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:        "AcceptUnknownProfile",
+			Accept:      `application/json; profile="bare"`,
+			Expected:    `{"results":[1,2]}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:        "Default",
+			Default:     EnvelopeRaw,
+			Expected:    `[1,2]`,
+			ContentType: `application/json; profile="raw"`,
+		},
+		{
+			Name:        "DefaultOverridden",
+			Accept:      `application/json; profile="wrapped"`,
+			Default:     EnvelopeRaw,
+			Expected:    `{"results":[1,2]}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:   "Page",
+			Accept: `application/json; profile="raw"`,
+			Respond: func(w http.ResponseWriter, r *http.Request) {
+				RespondPage(w, r, http.StatusOK, []int{1, 2}, Page{Limit: 2, Total: 5})
+			},
+			Expected:    `[1,2]`,
+			ContentType: `application/json; profile="raw"`,
+			TotalCount:  "5",
+		},
+		{
+			Name: "Truncated",
+			Respond: func(w http.ResponseWriter, r *http.Request) {
+				RespondPartial(w, r, http.StatusOK, []int{1, 2}, NewCursor(2))
+			},
+			Expected:    `{"results":[1,2],"truncated":true,"cursor":"Mg"}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:   "TruncatedRaw",
+			Accept: `application/json; profile="raw"`,
+			Respond: func(w http.ResponseWriter, r *http.Request) {
+				RespondPartial(w, r, http.StatusOK, []int{1, 2}, NewCursor(2))
+			},
+			Expected:    `[1,2]`,
+			ContentType: `application/json; profile="raw"`,
+			Cursor:      "Mg",
+		},
+		{
+			Name: "Complete",
+			Respond: func(w http.ResponseWriter, r *http.Request) {
+				RespondPartial(w, r, http.StatusOK, []int{1, 2}, "")
+			},
+			Expected:    `{"results":[1,2]}`,
+			ContentType: "application/json",
+		},
+		{
+			Name:   "Error",
+			Accept: `application/json; profile="raw"`,
+			Respond: func(w http.ResponseWriter, r *http.Request) {
+				RespondError(w, r, http.StatusNotFound, StatusError(http.StatusNotFound))
+			},
+			Expected:    `{"results":null,"errors":[{"code":"not_found","message":"Not Found"}]}`,
+			ContentType: "application/json",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list", nil)
+			if test.Accept != "" {
+				r.Header.Set("Accept", test.Accept)
+			}
+			if test.Default != "" {
+				r = WithEnvelope(r, test.Default)
+			}
+
+			w := httptest.NewRecorder()
+			if test.Respond != nil {
+				test.Respond(w, r)
+			} else {
+				Respond(w, r, http.StatusOK, []int{1, 2})
+			}
+
+			if a := w.Body.String(); a != test.Expected {
+				t.Errorf("expected body %s, got %s", test.Expected, a)
+			}
+
+			if a := w.Header().Get("Content-Type"); a != test.ContentType {
+				t.Errorf("expected Content-Type %q, got %q", test.ContentType, a)
+			}
+
+			if a := w.Header().Get("X-Total-Count"); a != test.TotalCount {
+				t.Errorf("expected X-Total-Count %q, got %q", test.TotalCount, a)
+			}
+
+			if a := w.Header().Get("X-Cursor"); a != test.Cursor {
+				t.Errorf("expected X-Cursor %q, got %q", test.Cursor, a)
+			}
+
+			if a := w.Header().Get("Vary"); a != "Accept" {
+				t.Errorf("expected Vary Accept, got %q", a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
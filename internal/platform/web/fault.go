@@ -0,0 +1,69 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Fault is the artificial latency and errors injected into the responses of the routes
+// it matches, so that the retries of clients can be exercised against a real server.
+type Fault struct {
+	// Method is the method of the routes the fault matches, or * for every method.
+	Method string
+
+	// Path is the path pattern of the routes the fault matches, such as /list/:lid, or *
+	// for every path.
+	Path string
+
+	// Latency is how long responses are delayed.
+	Latency time.Duration
+
+	// ErrorRate is the fraction of requests, between 0 and 1, that are failed instead of
+	// being served.
+	ErrorRate float64
+}
+
+// Faults are the faults injected into the responses of routes.
+type Faults []Fault
+
+// ParseFaults parses the given faults, each a method, path pattern, latency, and error
+// rate separated by spaces, such as "GET /list/:lid 200ms 0.1".
+func ParseFaults(specs []string) (Faults, error) {
+	f := make(Faults, 0, len(specs))
+
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if len(fields) != 4 {
+			return nil, errors.Errorf("invalid fault %q, expected a method, path, latency, and error rate", spec)
+		}
+
+		latency, err := time.ParseDuration(fields[2])
+		if err != nil || latency < 0 {
+			return nil, errors.Errorf("invalid latency %q of fault %q", fields[2], spec)
+		}
+
+		rate, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, errors.Errorf("invalid error rate %q of fault %q, expected a number between 0 and 1", fields[3], spec)
+		}
+
+		f = append(f, Fault{Method: strings.ToUpper(fields[0]), Path: fields[1], Latency: latency, ErrorRate: rate})
+	}
+
+	return f, nil
+}
+
+// Match returns the first fault matching the route with the given method and path
+// pattern, if any.
+func (f Faults) Match(method, path string) (Fault, bool) {
+	for _, fault := range f {
+		if (fault.Method == "*" || fault.Method == method) && (fault.Path == "*" || fault.Path == path) {
+			return fault, true
+		}
+	}
+
+	return Fault{}, false
+}
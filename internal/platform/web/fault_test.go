@@ -0,0 +1,63 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFaults(t *testing.T) {
+	f, err := ParseFaults([]string{"get /list/:lid 200ms 0.1", "* * 0s 1"})
+	if err != nil {
+		t.Fatalf("error parsing faults: %v", err)
+	}
+
+	expected := Faults{
+		{Method: "GET", Path: "/list/:lid", Latency: 200 * time.Millisecond, ErrorRate: 0.1},
+		{Method: "*", Path: "*", ErrorRate: 1},
+	}
+
+	if d := cmp.Diff(expected, f); d != "" {
+		t.Errorf("unexpected difference in faults:\n%v", d)
+	}
+
+	for _, spec := range []string{"GET /list 200ms", "GET /list soon 0.1", "GET /list -1s 0.1", "GET /list 0s 2"} {
+		if _, err := ParseFaults([]string{spec}); err == nil {
+			t.Errorf("expected an error parsing %q", spec)
+		}
+	}
+}
+
+func TestFaultsMatch(t *testing.T) {
+	f := Faults{
+		{Method: "GET", Path: "/list/:lid", Latency: time.Second},
+		{Method: "*", Path: "/list", ErrorRate: 1},
+	}
+
+	tests := []struct {
+		Method   string
+		Path     string
+		Expected int
+	}{
+		{Method: "GET", Path: "/list/:lid", Expected: 0},
+		{Method: "PUT", Path: "/list/:lid", Expected: -1},
+		{Method: "POST", Path: "/list", Expected: 1},
+		{Method: "GET", Path: "/template", Expected: -1},
+	}
+
+	for _, test := range tests {
+		fault, ok := f.Match(test.Method, test.Path)
+
+		if test.Expected < 0 {
+			if ok {
+				t.Errorf("expected %s %s to match no fault, got %+v", test.Method, test.Path, fault)
+			}
+			continue
+		}
+
+		if !ok || fault != f[test.Expected] {
+			t.Errorf("expected %s %s to match %+v, got %+v", test.Method, test.Path, f[test.Expected], fault)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WithStringIDs returns a shallow copy of r whose response has its identifiers encoded as
+// strings when on is true, for JavaScript clients that lose the precision of identifiers
+// beyond 2^53. Identifiers are the integers of the fields named id or ids, or ending in
+// ID, IDs, _id or _ids, and of the arrays such fields hold.
+func WithStringIDs(r *http.Request, on bool) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), stringIDsKey, on))
+}
+
+// stringIDs reports whether the identifiers in the response to r are encoded as strings,
+// see WithStringIDs.
+func stringIDs(r *http.Request) bool {
+	on, _ := r.Context().Value(stringIDsKey).(bool)
+	return on
+}
+
+// QuoteIDs returns the JSON document b with the integer identifiers in it encoded as
+// strings, see WithStringIDs. The order of the fields is kept.
+func QuoteIDs(b []byte) ([]byte, error) {
+	return rewriteIDs(b, false, func(tok json.Token) json.Token {
+		if n, ok := tok.(json.Number); ok {
+			if _, err := n.Int64(); err == nil {
+				return n.String()
+			}
+		}
+
+		return tok
+	})
+}
+
+// UnquoteIDs returns the JSON document b with the identifiers in it that are encoded as
+// strings, see WithStringIDs, encoded as integers instead, so clients can give them in
+// either form. When root is true, the integers of a top-level array are identifiers too,
+// such as the ids of a bulk delete.
+func UnquoteIDs(b []byte, root bool) ([]byte, error) {
+	return rewriteIDs(b, root, func(tok json.Token) json.Token {
+		if s, ok := tok.(string); ok {
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+
+		return tok
+	})
+}
+
+// idKey reports whether the values of the field named key are identifiers.
+func idKey(key string) bool {
+	switch key {
+	case "id", "ids":
+		return true
+	}
+
+	for _, suffix := range []string{"ID", "IDs", "_id", "_ids"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewriteIDs returns the single JSON document b with the tokens of its identifiers
+// replaced by fn. When root is true, the values of a top-level array are identifiers.
+func rewriteIDs(b []byte, root bool, fn func(json.Token) json.Token) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	// scope is an object or array being rewritten. Objects alternate between keys and
+	// values, the key of the value to come is kept.
+	type scope struct {
+		object bool
+		value  bool
+		key    string
+		id     bool
+		n      int
+	}
+
+	var out bytes.Buffer
+	stack := []scope{{id: root}}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read token")
+		}
+
+		s := &stack[len(stack)-1]
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if s.object && !s.value {
+			if s.n > 0 {
+				out.WriteByte(',')
+			}
+
+			s.key, _ = tok.(string)
+			s.value = true
+
+			k, _ := json.Marshal(s.key)
+			out.Write(k)
+			out.WriteByte(':')
+			continue
+		}
+
+		if !s.object && s.n > 0 {
+			out.WriteByte(',')
+		}
+
+		id := s.id
+		if s.object {
+			id = idKey(s.key)
+			s.value = false
+		}
+		s.n++
+
+		if d, ok := tok.(json.Delim); ok {
+			out.WriteByte(byte(d))
+			stack = append(stack, scope{object: d == '{', id: id && d == '['})
+			continue
+		}
+
+		if id {
+			tok = fn(tok)
+		}
+
+		v, err := json.Marshal(tok)
+		if err != nil {
+			return nil, errors.Wrap(err, "write token")
+		}
+		out.Write(v)
+	}
+
+	if len(stack) != 1 || stack[0].n != 1 {
+		return nil, errors.New("expected a single JSON document")
+	}
+
+	return out.Bytes(), nil
+}
+
+// quoteIDs returns the marshaled response b with its identifiers encoded as strings,
+// indented again when pretty is true, see QuoteIDs.
+func quoteIDs(b []byte, pretty bool) ([]byte, error) {
+	q, err := QuoteIDs(b)
+	if err != nil || !pretty {
+		return q, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, q, "", "  "); err != nil {
+		return nil, errors.Wrap(err, "indent response")
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuoteIDs(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Body     string
+		Expected string
+	}{
+		{
+			Name:     "Fields",
+			Body:     `{"id":1,"listID":2,"project_id":3,"quantity":4,"name":"5"}`,
+			Expected: `{"id":"1","listID":"2","project_id":"3","quantity":4,"name":"5"}`,
+		},
+		{
+			Name:     "Nested",
+			Body:     `{"results":[{"id":9007199254740993,"items":[{"id":2}]}],"page":{"total":1}}`,
+			Expected: `{"results":[{"id":"9007199254740993","items":[{"id":"2"}]}],"page":{"total":1}}`,
+		},
+		{
+			Name:     "Arrays",
+			Body:     `{"ids":[1,2],"errors":[]}`,
+			Expected: `{"ids":["1","2"],"errors":[]}`,
+		},
+		{
+			Name:     "NotIntegers",
+			Body:     `{"id":"a","listID":null,"requestID":1.5,"ids":[true]}`,
+			Expected: `{"id":"a","listID":null,"requestID":1.5,"ids":[true]}`,
+		},
+		{
+			Name:     "EmptyKey",
+			Body:     `{"":1,"id":{}}`,
+			Expected: `{"":1,"id":{}}`,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			b, err := QuoteIDs([]byte(test.Body))
+			if err != nil {
+				t.Fatalf("error quoting ids: %v", err)
+			}
+
+			if a := string(b); a != test.Expected {
+				t.Errorf("expected %s, got %s", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestUnquoteIDs(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Body     string
+		Root     bool
+		Expected string
+	}{
+		{
+			Name:     "Fields",
+			Body:     `{"id":"1","listID":2,"name":"3"}`,
+			Expected: `{"id":1,"listID":2,"name":"3"}`,
+		},
+		{
+			Name:     "NotIntegers",
+			Body:     `{"id":"a","listID":"1.5"}`,
+			Expected: `{"id":"a","listID":"1.5"}`,
+		},
+		{
+			Name:     "Root",
+			Body:     `["1",2]`,
+			Root:     true,
+			Expected: `[1,2]`,
+		},
+		{
+			Name:     "NotRoot",
+			Body:     `["1",2]`,
+			Expected: `["1",2]`,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			b, err := UnquoteIDs([]byte(test.Body), test.Root)
+			if err != nil {
+				t.Fatalf("error unquoting ids: %v", err)
+			}
+
+			if a := string(b); a != test.Expected {
+				t.Errorf("expected %s, got %s", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestUnquoteIDsMalformed(t *testing.T) {
+	for _, body := range []string{``, `{"id":`, `{"id":1}{}`, `[1,]`} {
+		if _, err := UnquoteIDs([]byte(body), false); err == nil {
+			t.Errorf("expected an error unquoting %q", body)
+		}
+	}
+}
+
+func TestRespondStringIDs(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		On       bool
+		Expected string
+	}{
+		{
+			Name:     "Off",
+			Expected: `{"results":{"id":1}}`,
+		},
+		{
+			Name:     "On",
+			On:       true,
+			Expected: `{"results":{"id":"1"}}`,
+		},
+		{
+			Name:     "Pretty",
+			Query:    "?pretty=true",
+			On:       true,
+			Expected: "{\n  \"results\": {\n    \"id\": \"1\"\n  }\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := WithStringIDs(httptest.NewRequest(http.MethodGet, "/list/1"+test.Query, nil), test.On)
+
+			w := httptest.NewRecorder()
+			Respond(w, r, http.StatusOK, map[string]int{"id": 1})
+
+			if a := w.Body.String(); a != test.Expected {
+				t.Errorf("expected body %q, got %q", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
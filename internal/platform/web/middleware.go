@@ -2,17 +2,73 @@ package web
 
 import (
 	"bufio"
+	"context"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus"
 )
 
 const requestIDHeader = "X-Request-Id"
 
+// ctxKey is the type of the keys used to store values in a request context.
+type ctxKey int
+
+const (
+	// scopeKey is the context key the Scope of a request is stored under.
+	scopeKey ctxKey = iota
+
+	// prettyKey is the context key whether responses are indented by default is stored
+	// under.
+	prettyKey
+
+	// clientIPKey is the context key the IP address of the client is stored under.
+	clientIPKey
+
+	// dryRunKey is the context key whether the request is a dry run is stored under.
+	dryRunKey
+
+	// envelopeKey is the context key the envelope responses are written in by default is
+	// stored under.
+	envelopeKey
+
+	// bodyLimitKey is the context key the size limit of the request body is stored under.
+	bodyLimitKey
+
+	// stringIDsKey is the context key whether identifiers are encoded as strings in
+	// responses is stored under.
+	stringIDsKey
+
+	// logRulesKey is the context key the LogRules of a request are stored under.
+	logRulesKey
+
+	// deprecationsKey is the context key the deprecations responses are checked for are
+	// stored under.
+	deprecationsKey
+)
+
+// Logger returns the logger scoped to the request that the given context belongs to,
+// which has the request ID attached as a field. If the context does not belong to a
+// request that passed through RequestMW the logrus standard logger is returned.
+func Logger(ctx context.Context) logrus.FieldLogger {
+	return ScopeOf(ctx).Log
+}
+
+// RequestID returns the ID of the request that the given context belongs to, or an empty
+// string if it does not belong to a request that passed through RequestMW.
+func RequestID(ctx context.Context) string {
+	return ScopeOf(ctx).RequestID
+}
+
+// WithPretty returns a shallow copy of r whose response is indented by default when
+// pretty is true. Clients choose for themselves with the pretty query parameter.
+func WithPretty(r *http.Request, pretty bool) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), prettyKey, pretty))
+}
+
 // responseWriter wraps an http.ResponseWriter so we can
 // capture the status code.
 type responseWriter struct {
@@ -37,9 +93,16 @@ func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 }
 
 // RequestMW is a middleware that creates a request id for each request
-// and sets it on the header field X-Request-Id. Also logs the start and
-// end of each request.
-func RequestMW(next http.Handler) http.Handler {
+// and sets it on the header field X-Request-Id. Also logs the end of each
+// request, along with the IP address of the client given by WithClientIP or the peer,
+// and starts the Scope of the request with a logger carrying the request id, see Logger
+// and RequestID. Dependencies already in the Scope, such as those given by tests, are
+// kept. Which requests are logged, and with which headers and body, is decided by the
+// LogRules given by WithLogRules.
+func RequestMW(log logrus.FieldLogger, next http.Handler) http.Handler {
+	// successes counts the successful requests for sampling, see LogRules.SampleRate.
+	var successes uint64
+
 	f := func(w http.ResponseWriter, r *http.Request) {
 
 		st := time.Now()
@@ -55,10 +118,33 @@ func RequestMW(next http.Handler) http.Handler {
 			id = uuid.New()
 		}
 
+		rlog := log.WithField("requestID", id)
+
+		// Without proxies to trust, the peer is taken to be the client.
+		ip := ClientIP(r.Context())
+		if ip == "" {
+			ip = Proxies(nil).Resolve(r)
+		}
+
+		rules := LogRulesOf(r.Context())
+
+		var body *bodyCapture
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if rules.Body && r.Body != nil {
+				body = &bodyCapture{ReadCloser: r.Body}
+				r.Body = body
+			}
+		}
+
 		defer func() {
-			log.WithFields(log.Fields{
+			if !rules.sampled(ww.status, &successes) {
+				return
+			}
+
+			rlog.WithFields(rules.fields(r, body)).WithFields(logrus.Fields{
+				"clientIP":    ip,
 				"method":      r.Method,
-				"requestID":   id,
 				"requestURI":  r.RequestURI,
 				"requestTime": time.Since(st),
 				"status":      ww.status,
@@ -67,7 +153,11 @@ func RequestMW(next http.Handler) http.Handler {
 
 		ww.Header().Set(requestIDHeader, id)
 
-		next.ServeHTTP(ww, r)
+		scope := ScopeOf(r.Context())
+		scope.Log = rlog
+		scope.RequestID = id
+
+		next.ServeHTTP(ww, WithScope(r, scope))
 	}
 	return http.HandlerFunc(f)
 }
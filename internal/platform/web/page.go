@@ -0,0 +1,153 @@
+package web
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// These constants define the page sizes used by the zero value of Paging.
+const (
+	// DefaultPageSize is the amount of results returned when a request doesn't give a
+	// limit.
+	DefaultPageSize = 50
+
+	// MaxPageSize is the largest limit a request may give.
+	MaxPageSize = 500
+
+	// DefaultMaxResults is the most results a response for a collection that isn't
+	// paginated holds.
+	DefaultMaxResults = 1000
+)
+
+// Page describes the part of a collection a response contains. It is sent along with
+// the results of paginated responses.
+type Page struct {
+	// Limit is the maximum amount of results in the page.
+	Limit int `json:"limit"`
+
+	// Offset is the amount of results of the collection that come before the page.
+	Offset int `json:"offset"`
+
+	// Total is the amount of results in the whole collection.
+	Total int `json:"total"`
+}
+
+// Paging holds the page sizes requests for paginated collections are parsed with, and
+// the most results responses for collections that aren't paginated hold. Zero sizes are
+// replaced by DefaultPageSize, MaxPageSize, and DefaultMaxResults.
+type Paging struct {
+	DefaultSize int
+	MaxSize     int
+	MaxResults  int
+}
+
+// Parse returns the page requested by the limit and offset query parameters of r. The
+// limit defaults to the default page size, the offset to 0. An error is returned for
+// values that aren't integers or that are out of range, which callers should respond
+// to with 400 Bad Request.
+func (p Paging) Parse(r *http.Request) (Page, error) {
+	def, max := p.DefaultSize, p.MaxSize
+	if def == 0 {
+		def = DefaultPageSize
+	}
+	if max == 0 {
+		max = MaxPageSize
+	}
+
+	page := Page{
+		Limit: def,
+	}
+
+	q := r.URL.Query()
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > max {
+			return Page{}, NewError(codes.LimitInvalid, max, v)
+		}
+		page.Limit = limit
+	}
+
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return Page{}, NewError(codes.OffsetInvalid, v)
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// RespondPage sends a response with a status code containing a page of a collection.
+func RespondPage(w http.ResponseWriter, r *http.Request, code int, data interface{}, page Page) {
+	resp := Response{
+		Results: data,
+		Page:    &page,
+	}
+
+	writeResponse(w, r, code, &resp)
+}
+
+// Results returns the most results a response for a collection that isn't paginated
+// holds. Handlers select one more, so they know whether to cut the collection short, see
+// RespondPartial.
+func (p Paging) Results() int {
+	if p.MaxResults == 0 {
+		return DefaultMaxResults
+	}
+
+	return p.MaxResults
+}
+
+// cursorHeader is the header that holds the cursor of a truncated raw response.
+const cursorHeader = "X-Cursor"
+
+// NewCursor returns the cursor continuing a collection ordered by id after the result
+// with the given id. Cursors are opaque to clients, which send them back as they are.
+func NewCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// ParseCursor returns the id the results requested by r continue after, given by its
+// cursor query parameter, or 0 when it has none. An error is returned for cursors that
+// weren't returned by NewCursor, which callers should respond to with 400 Bad Request.
+func ParseCursor(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("cursor")
+	if v == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return 0, NewError(codes.CursorInvalid, v)
+	}
+
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id < 1 {
+		return 0, NewError(codes.CursorInvalid, v)
+	}
+
+	return id, nil
+}
+
+// RespondPartial sends a response with a status code containing results of a collection
+// that isn't paginated. A collection with more results than a response holds is cut
+// short, in which case the response is marked as truncated and cursor, see NewCursor,
+// continues it. An empty cursor sends the whole collection.
+func RespondPartial(w http.ResponseWriter, r *http.Request, code int, data interface{}, cursor string) {
+	resp := Response{
+		Results:   data,
+		Truncated: cursor != "",
+		Cursor:    cursor,
+	}
+
+	if resp.Truncated {
+		Logger(r.Context()).WithField("cursor", cursor).Warn("truncated response")
+	}
+
+	writeResponse(w, r, code, &resp)
+}
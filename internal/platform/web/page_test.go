@@ -0,0 +1,125 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagingParse(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Paging   Paging
+		Query    string
+		Expected Page
+		Error    bool
+	}{
+		{
+			Name:     "Defaults",
+			Expected: Page{Limit: DefaultPageSize},
+		},
+		{
+			Name:     "ConfiguredDefault",
+			Paging:   Paging{DefaultSize: 10, MaxSize: 20},
+			Expected: Page{Limit: 10},
+		},
+		{
+			Name:     "LimitAndOffset",
+			Paging:   Paging{DefaultSize: 10, MaxSize: 20},
+			Query:    "?limit=20&offset=40",
+			Expected: Page{Limit: 20, Offset: 40},
+		},
+		{
+			Name:   "LimitAboveMax",
+			Paging: Paging{DefaultSize: 10, MaxSize: 20},
+			Query:  "?limit=21",
+			Error:  true,
+		},
+		{
+			Name:  "ZeroLimit",
+			Query: "?limit=0",
+			Error: true,
+		},
+		{
+			Name:  "NegativeOffset",
+			Query: "?offset=-1",
+			Error: true,
+		},
+		{
+			Name:  "MalformedLimit",
+			Query: "?limit=all",
+			Error: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			page, err := test.Paging.Parse(httptest.NewRequest("GET", "/list"+test.Query, nil))
+			if test.Error {
+				if err == nil {
+					t.Errorf("expected an error, got page %+v", page)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error parsing page: %v", err)
+			}
+
+			if page != test.Expected {
+				t.Errorf("expected page %+v, got %+v", test.Expected, page)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected int
+		Error    bool
+	}{
+		{
+			Name: "None",
+		},
+		{
+			Name:     "Cursor",
+			Query:    "?cursor=" + NewCursor(1000),
+			Expected: 1000,
+		},
+		{
+			Name:  "Malformed",
+			Query: "?cursor=!",
+			Error: true,
+		},
+		{
+			Name:  "NotAnID",
+			Query: "?cursor=" + NewCursor(0),
+			Error: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			id, err := ParseCursor(httptest.NewRequest("GET", "/template"+test.Query, nil))
+			if test.Error {
+				if err == nil {
+					t.Errorf("expected an error, got id %d", id)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error parsing cursor: %v", err)
+			}
+
+			if id != test.Expected {
+				t.Errorf("expected id %d, got %d", test.Expected, id)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// DecodeQuery binds the query parameters of r to the fields of the struct v points to.
+// Every field with a query tag is bound to the parameter named by the tag, other fields
+// are left alone. Parameters that aren't given or are empty leave their field at the
+// value of its default tag, if any.
+//
+// Fields can be strings, booleans, integers, times in RFC 3339 format, or slices of them,
+// which are bound to the comma-separated values of their parameter. Integers are bounded
+// by the min and max tags, and strings limited to the space-separated values of the oneof
+// tag:
+//
+//	var params struct {
+//		Sort  string `query:"sort" default:"name" oneof:"name created"`
+//		Limit int    `query:"limit" default:"10" min:"1" max:"100"`
+//	}
+//
+// Every parameter that can't be bound is reported by a field error in the Errors
+// returned, which callers should respond to with 400 Bad Request. DecodeQuery panics
+// when v isn't a pointer to a struct or has tags it can't apply, since those are
+// programming errors.
+func DecodeQuery(r *http.Request, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("web: DecodeQuery of %T, expected a pointer to a struct", v))
+	}
+
+	q := r.URL.Query()
+	s := rv.Elem()
+
+	var errs Errors
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+
+		name, ok := f.Tag.Lookup("query")
+		if !ok {
+			continue
+		}
+
+		raw := strings.Join(q[name], ",")
+		if raw == "" {
+			raw = f.Tag.Get("default")
+		}
+
+		if raw == "" {
+			continue
+		}
+
+		if err := bindQuery(s.Field(i), f.Tag, name, raw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// bindQuery sets v to the raw value of the query parameter with the given name, checking
+// it against the tags of its field.
+func bindQuery(v reflect.Value, tag reflect.StructTag, name, raw string) *Error {
+	if v.Kind() == reflect.Slice {
+		parts := strings.Split(raw, ",")
+
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := bindQuery(s.Index(i), tag, name, strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+
+		v.Set(s)
+		return nil
+	}
+
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return NewFieldError(name, codes.QueryTimeInvalid, name, raw)
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if oneof, ok := tag.Lookup("oneof"); ok {
+			values := strings.Fields(oneof)
+			if !contains(values, raw) {
+				err := NewFieldError(name, codes.QueryOneOfInvalid, name, strings.Join(values, ", "), raw)
+				err.Allowed = values
+				return err
+			}
+		}
+
+		v.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return NewFieldError(name, codes.QueryBooleanInvalid, name, raw)
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return NewFieldError(name, codes.QueryIntegerInvalid, name, raw)
+		}
+
+		if min, ok := tagInt(tag, "min"); ok && n < min {
+			return NewFieldError(name, codes.QueryMinInvalid, name, min, raw)
+		}
+
+		if max, ok := tagInt(tag, "max"); ok && n > max {
+			return NewFieldError(name, codes.QueryMaxInvalid, name, max, raw)
+		}
+
+		v.SetInt(n)
+
+	default:
+		panic(fmt.Sprintf("web: DecodeQuery of unsupported field type %s", v.Type()))
+	}
+
+	return nil
+}
+
+// tagInt returns the integer value of the given key of tag, if any.
+func tagInt(tag reflect.StructTag, key string) (int64, bool) {
+	v, ok := tag.Lookup(key)
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("web: DecodeQuery of malformed %s tag %q", key, v))
+	}
+
+	return n, true
+}
+
+// contains reports whether values contains v.
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// queryParams are the parameters DecodeQuery is tested with.
+type queryParams struct {
+	Sort    string    `query:"sort" default:"name" oneof:"name created"`
+	Search  string    `query:"q"`
+	Limit   int       `query:"limit" default:"10" min:"1" max:"100"`
+	Deleted bool      `query:"deleted"`
+	IDs     []int     `query:"ids" min:"1"`
+	At      time.Time `query:"at"`
+	Ignored string
+}
+
+func TestDecodeQuery(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Expected queryParams
+		Errors   []string
+	}{
+		{
+			Name:     "Defaults",
+			Expected: queryParams{Sort: "name", Limit: 10},
+		},
+		{
+			Name:     "Given",
+			Query:    "?sort=created&q=milk&limit=100&deleted=true&ids=3,1&Ignored=x",
+			Expected: queryParams{Sort: "created", Search: "milk", Limit: 100, Deleted: true, IDs: []int{3, 1}},
+		},
+		{
+			Name:     "Empty",
+			Query:    "?sort=&limit=",
+			Expected: queryParams{Sort: "name", Limit: 10},
+		},
+		{
+			Name:     "RepeatedSlice",
+			Query:    "?ids=3&ids=1,2",
+			Expected: queryParams{Sort: "name", Limit: 10, IDs: []int{3, 1, 2}},
+		},
+		{
+			Name:     "Time",
+			Query:    "?at=2018-11-02T15:04:05.5Z",
+			Expected: queryParams{Sort: "name", Limit: 10, At: time.Date(2018, 11, 2, 15, 4, 5, 5e8, time.UTC)},
+		},
+		{
+			Name:  "Invalid",
+			Query: "?sort=size&limit=0&deleted=maybe&ids=1,x&at=yesterday",
+			Errors: []string{
+				`sort must be one of name, created, got "size"`,
+				`limit must be at least 1, got "0"`,
+				`deleted must be true or false, got "maybe"`,
+				`ids must be an integer, got "x"`,
+				`at must be a time such as 2006-01-02T15:04:05Z, got "yesterday"`,
+			},
+		},
+		{
+			Name:   "AboveMax",
+			Query:  "?limit=101",
+			Errors: []string{`limit must be at most 100, got "101"`},
+		},
+		{
+			Name:   "SliceElementBelowMin",
+			Query:  "?ids=1,0",
+			Errors: []string{`ids must be at least 1, got "0"`},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var params queryParams
+			err := DecodeQuery(httptest.NewRequest("GET", "/list"+test.Query, nil), &params)
+
+			if test.Errors == nil {
+				if err != nil {
+					t.Fatalf("error decoding query: %v", err)
+				}
+
+				if d := cmp.Diff(test.Expected, params); d != "" {
+					t.Errorf("unexpected difference in parameters:\n%v", d)
+				}
+				return
+			}
+
+			errs, ok := err.(Errors)
+			if !ok {
+				t.Fatalf("expected Errors, got %#v", err)
+			}
+
+			var msgs []string
+			for _, e := range errs {
+				if e.Field == "" {
+					t.Errorf("expected error %q to name its parameter", e.Code)
+				}
+				msgs = append(msgs, e.Error())
+			}
+
+			if d := cmp.Diff(test.Errors, msgs); d != "" {
+				t.Errorf("unexpected difference in errors:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestDecodeQueryPanics(t *testing.T) {
+	tests := []struct {
+		Name string
+		V    interface{}
+	}{
+		{
+			Name: "NotPointer",
+			V:    queryParams{},
+		},
+		{
+			Name: "UnsupportedType",
+			V: &struct {
+				Ratio float64 `query:"ratio"`
+			}{},
+		},
+		{
+			Name: "MalformedTag",
+			V: &struct {
+				Limit int `query:"limit" min:"one"`
+			}{},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected DecodeQuery to panic")
+				}
+			}()
+
+			_ = DecodeQuery(httptest.NewRequest("GET", "/?ratio=0.5&limit=5", nil), test.V)
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// ReadOnly is why a daemon refuses every write request, see Refuse. The zero value
+// serves them.
+type ReadOnly string
+
+// These constants define the reasons a daemon can be read-only for.
+const (
+	// ReadOnlyReplica is for daemons serving a replica of the database, such as one kept
+	// for disaster recovery, which can't be written to at all. Writes are answered with a
+	// 405 whose Allow header names the methods that are served.
+	ReadOnlyReplica ReadOnly = "replica"
+
+	// ReadOnlyMigrating is for daemons kept serving reads while their database is being
+	// migrated. Writes are answered with a 503, so clients retry them once it's done.
+	ReadOnlyMigrating ReadOnly = "migrating"
+)
+
+// readMethods are the methods that are served while read-only, which never write.
+const readMethods = "GET, HEAD, OPTIONS"
+
+// Refuse refuses r with an error explaining why, if its method may write and m is set,
+// and reports whether it did. Requests that weren't refused are left to be served.
+func (m ReadOnly) Refuse(w http.ResponseWriter, r *http.Request) bool {
+	if m == "" {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	if m == ReadOnlyMigrating {
+		RespondError(w, r, http.StatusServiceUnavailable, NewError(codes.ReadOnlyMigrating))
+		return true
+	}
+
+	w.Header().Set("Allow", readMethods)
+	RespondError(w, r, http.StatusMethodNotAllowed, NewError(codes.ReadOnlyReplica))
+
+	return true
+}
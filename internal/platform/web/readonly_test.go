@@ -0,0 +1,88 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnlyRefuse(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Mode          ReadOnly
+		Method        string
+		ExpectedCode  int
+		ExpectedError string
+		ExpectedAllow string
+	}{
+		{
+			Name:   "Writable",
+			Method: http.MethodPost,
+		},
+		{
+			Name:   "ReplicaRead",
+			Mode:   ReadOnlyReplica,
+			Method: http.MethodGet,
+		},
+		{
+			Name:   "ReplicaOptions",
+			Mode:   ReadOnlyReplica,
+			Method: http.MethodOptions,
+		},
+		{
+			Name:          "ReplicaWrite",
+			Mode:          ReadOnlyReplica,
+			Method:        http.MethodPut,
+			ExpectedCode:  http.StatusMethodNotAllowed,
+			ExpectedError: "read_only_replica",
+			ExpectedAllow: "GET, HEAD, OPTIONS",
+		},
+		{
+			Name:   "MigratingRead",
+			Mode:   ReadOnlyMigrating,
+			Method: http.MethodHead,
+		},
+		{
+			Name:          "MigratingWrite",
+			Mode:          ReadOnlyMigrating,
+			Method:        http.MethodDelete,
+			ExpectedCode:  http.StatusServiceUnavailable,
+			ExpectedError: "read_only_migrating",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			refused := test.Mode.Refuse(w, httptest.NewRequest(test.Method, "/list/1", nil))
+			if e, a := test.ExpectedCode != 0, refused; e != a {
+				t.Fatalf("expected refused: %v, got refused: %v", e, a)
+			}
+
+			if !refused {
+				return
+			}
+
+			if e, a := test.ExpectedCode, w.Code; e != a {
+				t.Errorf("expected status code: %v, got status code: %v", e, a)
+			}
+
+			if e, a := test.ExpectedAllow, w.Header().Get("Allow"); e != a {
+				t.Errorf("expected Allow header: %q, got: %q", e, a)
+			}
+
+			var resp Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			if len(resp.Errors) != 1 || resp.Errors[0].Code != test.ExpectedError || resp.Errors[0].Message == "" {
+				t.Errorf("expected a %s error with a message, got %+v", test.ExpectedError, resp.Errors)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
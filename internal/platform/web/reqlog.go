@@ -0,0 +1,172 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxLoggedBody is the amount of bytes of a request body that is logged at most, the
+// rest is cut off.
+const maxLoggedBody = 4 << 10
+
+// redacted replaces the values that LogRules keep out of the logs.
+const redacted = "[REDACTED]"
+
+// neverLogged are the request headers that carry credentials, which are kept out of the
+// logs whatever LogRules.Headers says.
+var neverLogged = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// LogRules decide which completed requests RequestMW logs and what they are logged along
+// with. The zero value logs every request without its headers or body.
+type LogRules struct {
+	// SampleRate logs one in SampleRate requests that succeeded, requests answered with
+	// a status of 400 or above are always logged. 0 and 1 log every request.
+	SampleRate int
+
+	// Headers are the request headers logged along with the request, except those that
+	// carry credentials such as Authorization, which are never logged.
+	Headers []string
+
+	// Body logs the JSON body of POST, PUT, PATCH, and DELETE requests, up to
+	// maxLoggedBody bytes.
+	Body bool
+
+	// Redact are the fields of logged bodies whose values are replaced, at any depth,
+	// such as name to keep the names of items out of the logs.
+	Redact []string
+}
+
+// WithLogRules returns a shallow copy of r whose completion is logged by RequestMW as
+// decided by rules.
+func WithLogRules(r *http.Request, rules LogRules) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), logRulesKey, rules))
+}
+
+// LogRulesOf returns the rules given to the request that the given context belongs to
+// with WithLogRules, or the zero value if it was given none.
+func LogRulesOf(ctx context.Context) LogRules {
+	rules, _ := ctx.Value(logRulesKey).(LogRules)
+	return rules
+}
+
+// sampled reports whether a request answered with status is logged, successes counts
+// the successful requests seen so far, including this one if it succeeded.
+func (l LogRules) sampled(status int, successes *uint64) bool {
+	if status >= http.StatusBadRequest || l.SampleRate <= 1 {
+		return true
+	}
+
+	return (atomic.AddUint64(successes, 1)-1)%uint64(l.SampleRate) == 0
+}
+
+// fields returns the log fields of the headers of r that are logged, keyed by their
+// canonical name, along with its body captured by b if it is logged.
+func (l LogRules) fields(r *http.Request, b *bodyCapture) logrus.Fields {
+	f := make(logrus.Fields)
+
+	for _, name := range l.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if v := r.Header.Get(name); v != "" && !credential(name) {
+			f["header."+name] = v
+		}
+	}
+
+	if b != nil && b.buf.Len() > 0 {
+		f["requestBody"] = l.redact(b.buf.Bytes())
+	}
+
+	return f
+}
+
+// redact returns body with the values of the Redact fields replaced, or the whole body
+// replaced if it isn't complete JSON, since fields can't be told apart then.
+func (l LogRules) redact(body []byte) string {
+	if len(l.Redact) == 0 {
+		return string(body)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redacted
+	}
+
+	out, err := json.Marshal(l.redactValue(v))
+	if err != nil {
+		return redacted
+	}
+
+	return string(out)
+}
+
+// redactValue replaces the values of the Redact fields within the objects of v, which
+// is a value decoded by encoding/json.
+func (l LogRules) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if l.redacts(key) {
+				v[key] = redacted
+				continue
+			}
+
+			v[key] = l.redactValue(value)
+		}
+
+	case []interface{}:
+		for i, value := range v {
+			v[i] = l.redactValue(value)
+		}
+	}
+
+	return v
+}
+
+// redacts reports whether the value of the field key is redacted.
+func (l LogRules) redacts(key string) bool {
+	for _, field := range l.Redact {
+		if strings.EqualFold(field, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// credential reports whether the header given by its canonical name carries credentials.
+func credential(name string) bool {
+	for _, h := range neverLogged {
+		if h == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bodyCapture wraps the body of a request and keeps the first maxLoggedBody bytes that
+// are read from it, so it can be logged once the request is served.
+type bodyCapture struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+// Read implements the io.Reader interface.
+func (b *bodyCapture) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	if room := maxLoggedBody - b.buf.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+
+	return n, err
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogRules(t *testing.T) {
+	rules := LogRules{
+		SampleRate: 3,
+		Headers:    []string{"user-agent", "Authorization", "Cookie"},
+		Body:       true,
+		Redact:     []string{"name"},
+	}
+
+	var logs bytes.Buffer
+
+	log := logrus.New()
+	log.Out = &logs
+	log.Formatter = &logrus.JSONFormatter{}
+
+	h := RequestMW(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			t.Errorf("error reading body: %v", err)
+		}
+
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	serve := func(path string) map[string]interface{} {
+		logs.Reset()
+
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"Pills","quantity":2,"items":[{"name":"Salt"}]}`))
+		r.Header.Set("User-Agent", "listctl")
+		r.Header.Set("Authorization", "Bearer secret")
+		r.Header.Set("Cookie", "session=secret")
+		h.ServeHTTP(httptest.NewRecorder(), WithLogRules(r, rules))
+
+		if logs.Len() == 0 {
+			return nil
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("error decoding log entry: %v", err)
+		}
+
+		return entry
+	}
+
+	entry := serve("/list")
+	if entry == nil {
+		t.Fatal("expected the first successful request to be logged")
+	}
+
+	if e, a := "listctl", entry["header.User-Agent"]; e != a {
+		t.Errorf("expected logged User-Agent header %q, got %v", e, a)
+	}
+
+	for _, header := range []string{"header.Authorization", "header.Cookie"} {
+		if v, ok := entry[header]; ok {
+			t.Errorf("expected %s never to be logged, got %v", header, v)
+		}
+	}
+
+	if e, a := `{"items":[{"name":"[REDACTED]"}],"name":"[REDACTED]","quantity":2}`, entry["requestBody"]; e != a {
+		t.Errorf("expected logged body %s, got %v", e, a)
+	}
+
+	// The next two successes fall between samples, failures never do.
+	for i := 0; i < 2; i++ {
+		if entry := serve("/list"); entry != nil {
+			t.Errorf("expected success %d to be left out of the sample, got %v", i+2, entry)
+		}
+
+		if entry := serve("/list?fail=1"); entry == nil {
+			t.Errorf("expected every failed request to be logged")
+		}
+	}
+
+	if entry := serve("/list"); entry == nil {
+		t.Errorf("expected the fourth successful request to be logged")
+	}
+}
+
+func TestLogRulesDefault(t *testing.T) {
+	var logs bytes.Buffer
+
+	log := logrus.New()
+	log.Out = &logs
+	log.Formatter = &logrus.JSONFormatter{}
+
+	h := RequestMW(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodPost, "/list", strings.NewReader(`{"name":"Foo"}`))
+	r.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if strings.Contains(logs.String(), "secret") || strings.Contains(logs.String(), "Foo") {
+		t.Errorf("expected neither headers nor bodies to be logged without rules, got %s", logs.String())
+	}
+
+	if !strings.Contains(logs.String(), "completed request") {
+		t.Errorf("expected the request to be logged without rules, got %s", logs.String())
+	}
+}
@@ -0,0 +1,34 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NotFound responds with 404 Not Found. Routers use it for paths they don't serve.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusNotFound, StatusError(http.StatusNotFound))
+}
+
+// MethodNotAllowed responds with 405 Method Not Allowed. Routers use it for paths they
+// serve with other methods only, after setting the Allow header to those methods. The
+// methods are sorted so the header doesn't depend on the order routes were matched in.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if allow := w.Header().Get("Allow"); allow != "" {
+		methods := strings.Split(allow, ", ")
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+	}
+
+	RespondError(w, r, http.StatusMethodNotAllowed, StatusError(http.StatusMethodNotAllowed))
+}
+
+// TrimSlash returns path without its trailing slashes. The root path is returned as is.
+func TrimSlash(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+
+	return "/"
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Allow", "POST, GET, OPTIONS")
+
+	MethodNotAllowed(w, httptest.NewRequest(http.MethodPatch, "/list", nil))
+
+	if e, a := http.StatusMethodNotAllowed, w.Code; e != a {
+		t.Errorf("expected status code: %v, got status code: %v", e, a)
+	}
+
+	if e, a := "GET, OPTIONS, POST", w.Header().Get("Allow"); e != a {
+		t.Errorf("expected Allow header: %q, got: %q", e, a)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response body: %v", err)
+	}
+
+	if len(resp.Errors) != 1 || resp.Errors[0].Code != "method_not_allowed" {
+		t.Errorf("expected a method_not_allowed error, got %+v", resp.Errors)
+	}
+}
+
+func TestTrimSlash(t *testing.T) {
+	tests := map[string]string{
+		"/list":    "/list",
+		"/list/":   "/list",
+		"/list//":  "/list",
+		"/list/1/": "/list/1",
+		"/":        "/",
+		"//":       "/",
+	}
+
+	for path, expected := range tests {
+		if a := TrimSlash(path); a != expected {
+			t.Errorf("expected %q to be trimmed to %q, got %q", path, expected, a)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Scope holds the dependencies a handler serves a single request with, so handlers take
+// them from the request rather than from the application or package-level state. It is
+// started by RequestMW and completed by the middleware of the application, which only
+// fills in what isn't set yet, so tests can give a request dependencies of its own, such
+// as a database handle to a fake, through WithScope.
+type Scope struct {
+	// Log is the logger of the request, carrying its ID.
+	Log logrus.FieldLogger
+
+	// RequestID is the ID of the request, see RequestMW.
+	RequestID string
+
+	// DB is the database handle the request is served with, nil when the application
+	// hasn't set it yet.
+	DB *sqlx.DB
+
+	// Tx is the transaction the write request is served within, nil unless the
+	// application serves requests in transaction mode, see WithTx.
+	Tx *sqlx.Tx
+
+	// committed are the functions to run once Tx is committed, see OnCommit. Copies of
+	// the scope share them.
+	committed *[]func()
+}
+
+// WithTx returns a copy of s whose request is served within tx. The functions given to
+// OnCommit of the copy are run by Committed once tx is committed.
+func (s Scope) WithTx(tx *sqlx.Tx) Scope {
+	s.Tx = tx
+	s.committed = new([]func())
+
+	return s
+}
+
+// OnCommit defers fn until Tx is committed, for changes that can't be rolled back along
+// with it, such as deleting files. It reports whether it did, which it doesn't when the
+// request isn't served within a transaction of its own, see WithTx.
+func (s Scope) OnCommit(fn func()) bool {
+	if s.committed == nil {
+		return false
+	}
+
+	*s.committed = append(*s.committed, fn)
+	return true
+}
+
+// Committed runs the functions given to OnCommit in the order they were given, once Tx
+// is committed.
+func (s Scope) Committed() {
+	if s.committed == nil {
+		return
+	}
+
+	for _, fn := range *s.committed {
+		fn()
+	}
+}
+
+// WithScope returns a shallow copy of r whose context carries s.
+func WithScope(r *http.Request, s Scope) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), scopeKey, s))
+}
+
+// ScopeOf returns the Scope of the request that the given context belongs to. Without
+// one, its logger is the logrus standard logger and everything else is unset.
+func ScopeOf(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey).(Scope)
+	if s.Log == nil {
+		s.Log = logrus.StandardLogger()
+	}
+
+	return s
+}
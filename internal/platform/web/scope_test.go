@@ -0,0 +1,74 @@
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScope(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+
+	// A handle that is never connected is enough to tell handles apart.
+	fake := &sqlx.DB{}
+
+	var got Scope
+	h := RequestMW(log, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ScopeOf(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestIDHeader, "abc")
+	h.ServeHTTP(httptest.NewRecorder(), WithScope(r, Scope{DB: fake}))
+
+	if got.DB != fake {
+		t.Errorf("expected the database handle given to the request to be kept, got %v", got.DB)
+	}
+
+	if e, a := "abc", got.RequestID; e != a {
+		t.Errorf("expected request ID %q, got %q", e, a)
+	}
+
+	if entry, ok := got.Log.(*logrus.Entry); !ok || entry.Data["requestID"] != "abc" {
+		t.Errorf("expected a logger carrying the request ID, got %#v", got.Log)
+	}
+
+	// Outside of requests there is only the standard logger.
+	if s := ScopeOf(r.Context()); s.Log != logrus.StandardLogger() || s.DB != nil || s.RequestID != "" {
+		t.Errorf("expected an empty scope with the standard logger, got %+v", s)
+	}
+}
+
+func TestScopeOnCommit(t *testing.T) {
+	var ran []int
+
+	if (Scope{}).OnCommit(func() { ran = append(ran, 0) }) {
+		t.Error("expected nothing to be deferred without a transaction")
+	}
+
+	s := Scope{}.WithTx(&sqlx.Tx{})
+
+	// Functions given to copies of the scope, such as the one of a request, are kept.
+	r := WithScope(httptest.NewRequest(http.MethodDelete, "/", nil), s)
+	for i := 1; i <= 2; i++ {
+		i := i
+		if !ScopeOf(r.Context()).OnCommit(func() { ran = append(ran, i) }) {
+			t.Errorf("expected function %d to be deferred", i)
+		}
+	}
+
+	if len(ran) != 0 {
+		t.Fatalf("expected no function to run before the commit, got %v", ran)
+	}
+
+	s.Committed()
+
+	if len(ran) != 2 || ran[0] != 1 || ran[1] != 2 {
+		t.Errorf("expected functions 1 and 2 to run in order, got %v", ran)
+	}
+}
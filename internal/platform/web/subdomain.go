@@ -0,0 +1,30 @@
+package web
+
+import (
+	"net"
+	"strings"
+)
+
+// Subdomain returns the label that precedes domain in host, the Host of a request that
+// may carry a port, such as acme for acme.lists.example.com:3000 and the domain
+// lists.example.com. It returns false when host isn't a direct subdomain of domain,
+// including when it is domain itself. Hosts are compared without regard to case.
+func Subdomain(host, domain string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	if !strings.HasSuffix(host, suffix) {
+		return "", false
+	}
+
+	label := strings.TrimSuffix(host, suffix)
+	if label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+
+	return label, true
+}
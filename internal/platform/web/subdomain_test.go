@@ -0,0 +1,62 @@
+package web
+
+import "testing"
+
+func TestSubdomain(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Host       string
+		Expected   string
+		ExpectedOK bool
+	}{
+		{
+			Name:       "Subdomain",
+			Host:       "acme.lists.example.com",
+			Expected:   "acme",
+			ExpectedOK: true,
+		},
+		{
+			Name:       "Port",
+			Host:       "acme.lists.example.com:3000",
+			Expected:   "acme",
+			ExpectedOK: true,
+		},
+		{
+			Name:       "Case",
+			Host:       "ACME.Lists.Example.com.",
+			Expected:   "acme",
+			ExpectedOK: true,
+		},
+		{
+			Name: "Domain",
+			Host: "lists.example.com",
+		},
+		{
+			Name: "Nested",
+			Host: "eu.acme.lists.example.com",
+		},
+		{
+			Name: "OtherDomain",
+			Host: "acme.example.org",
+		},
+		{
+			Name: "SuffixOfLabel",
+			Host: "acmelists.example.com",
+		},
+		{
+			Name: "IP",
+			Host: "127.0.0.1:3000",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			label, ok := Subdomain(test.Host, "lists.example.com")
+			if label != test.Expected || ok != test.ExpectedOK {
+				t.Errorf("expected %q, %v, got %q, %v", test.Expected, test.ExpectedOK, label, ok)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+)
+
+// These constants are the headers clients give the time they are willing to wait for a
+// response in, see ParseTimeout.
+const (
+	// TimeoutHeader holds a duration such as 500ms or 2m.
+	TimeoutHeader = "X-Request-Timeout"
+
+	// grpcTimeoutHeader holds a duration the way gRPC clients send it, at most eight
+	// digits followed by a unit, such as 500m or 2M.
+	grpcTimeoutHeader = "Grpc-Timeout"
+)
+
+// grpcTimeout matches the value of the grpc-timeout header.
+var grpcTimeout = regexp.MustCompile(`^([0-9]{1,8})([HMSmun])$`)
+
+// grpcUnits are the durations of the units of the grpc-timeout header.
+var grpcUnits = map[string]time.Duration{
+	"H": time.Hour,
+	"M": time.Minute,
+	"S": time.Second,
+	"m": time.Millisecond,
+	"u": time.Microsecond,
+	"n": time.Nanosecond,
+}
+
+// ParseTimeout returns the time the client of r is willing to wait for a response in,
+// given by its X-Request-Timeout header or, for gRPC clients, its grpc-timeout header,
+// or 0 when it has neither. An error is returned for timeouts that aren't positive
+// durations, which callers should respond to with 400 Bad Request. Callers clamp the
+// timeout to what they allow.
+func ParseTimeout(r *http.Request) (time.Duration, error) {
+	if v := r.Header.Get(TimeoutHeader); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, NewError(codes.RequestTimeoutInvalid, TimeoutHeader, v)
+		}
+
+		return d, nil
+	}
+
+	if v := r.Header.Get(grpcTimeoutHeader); v != "" {
+		m := grpcTimeout.FindStringSubmatch(v)
+		if m == nil {
+			return 0, NewError(codes.RequestTimeoutInvalid, grpcTimeoutHeader, v)
+		}
+
+		n, _ := strconv.ParseInt(m[1], 10, 64)
+		if n == 0 {
+			return 0, NewError(codes.RequestTimeoutInvalid, grpcTimeoutHeader, v)
+		}
+
+		return time.Duration(n) * grpcUnits[m[2]], nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Header   string
+		Value    string
+		Expected time.Duration
+		Invalid  bool
+	}{
+		{
+			Name: "None",
+		},
+		{
+			Name:     "Duration",
+			Header:   TimeoutHeader,
+			Value:    "250ms",
+			Expected: 250 * time.Millisecond,
+		},
+		{
+			Name:     "Minutes",
+			Header:   TimeoutHeader,
+			Value:    "2m",
+			Expected: 2 * time.Minute,
+		},
+		{
+			Name:    "Zero",
+			Header:  TimeoutHeader,
+			Value:   "0s",
+			Invalid: true,
+		},
+		{
+			Name:    "Negative",
+			Header:  TimeoutHeader,
+			Value:   "-1s",
+			Invalid: true,
+		},
+		{
+			Name:    "Seconds",
+			Header:  TimeoutHeader,
+			Value:   "5",
+			Invalid: true,
+		},
+		{
+			Name:     "GRPCMilliseconds",
+			Header:   "grpc-timeout",
+			Value:    "500m",
+			Expected: 500 * time.Millisecond,
+		},
+		{
+			Name:     "GRPCMinutes",
+			Header:   "grpc-timeout",
+			Value:    "2M",
+			Expected: 2 * time.Minute,
+		},
+		{
+			Name:    "GRPCTooManyDigits",
+			Header:  "grpc-timeout",
+			Value:   "123456789S",
+			Invalid: true,
+		},
+		{
+			Name:    "GRPCZero",
+			Header:  "grpc-timeout",
+			Value:   "0S",
+			Invalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list", nil)
+			if test.Header != "" {
+				r.Header.Set(test.Header, test.Value)
+			}
+
+			d, err := ParseTimeout(r)
+			if e, a := test.Invalid, err != nil; e != a {
+				t.Fatalf("expected invalid: %v, got error: %v", e, err)
+			}
+
+			if e, a := test.Expected, d; e != a {
+				t.Errorf("expected timeout: %v, got timeout: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
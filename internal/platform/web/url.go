@@ -0,0 +1,15 @@
+package web
+
+import "net/http"
+
+// BaseURL returns the scheme and host the request was made to, such as
+// https://lists.example.com, which absolute URLs of resources given in responses are
+// built upon.
+func BaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Host     string
+		TLS      bool
+		Expected string
+	}{
+		{
+			Name:     "HTTP",
+			Host:     "lists.example.com",
+			Expected: "http://lists.example.com",
+		},
+		{
+			Name:     "HTTPS",
+			Host:     "lists.example.com",
+			TLS:      true,
+			Expected: "https://lists.example.com",
+		},
+		{
+			Name:     "Port",
+			Host:     "localhost:3000",
+			Expected: "http://localhost:3000",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list/1", nil)
+			r.Host = test.Host
+			if test.TLS {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			if got := BaseURL(r); got != test.Expected {
+				t.Errorf("expected base URL: %q, got base URL: %q", test.Expected, got)
+			}
+		})
+	}
+}
@@ -1,22 +1,37 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/i18n"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 )
 
-// Response is the format used for all the responses.
+// Response is the format used for all the responses. Truncated responses hold only the
+// first results of a collection, which the Cursor continues, see RespondPartial.
+// Deprecations are added once the response is marshaled, for the deprecated fields its
+// results use, see Deprecation.
 type Response struct {
-	Results interface{}     `json:"results"`
-	Errors  []ResponseError `json:"errors,omitempty"`
+	Results      interface{}     `json:"results"`
+	Page         *Page           `json:"page,omitempty"`
+	Truncated    bool            `json:"truncated,omitempty"`
+	Cursor       string          `json:"cursor,omitempty"`
+	Errors       []ResponseError `json:"errors,omitempty"`
+	Deprecations []Deprecation   `json:"deprecations,omitempty"`
 }
 
-// ResponseError is the format used for response errors.
+// ResponseError is the format used for response errors. Code identifies the error
+// regardless of the language of Message. Allowed lists the values Field can take when it
+// was given another one.
 type ResponseError struct {
-	Message string `json:"message"`
+	Code    string   `json:"code"`
+	Field   string   `json:"field,omitempty"`
+	Message string   `json:"message"`
+	Allowed []string `json:"allowed,omitempty"`
 }
 
 // Error implements the error interface.
@@ -24,17 +39,102 @@ func (a ResponseError) Error() string {
 	return a.Message
 }
 
+// Error is a user-facing error identified by a code from the i18n catalogs. Responses
+// carry its message in the language the client accepts. Field names the field of the
+// request payload the error is about, if any, and Allowed the values it can take, if the
+// error is about a value that isn't one of them.
+type Error struct {
+	Code    string
+	Field   string
+	Args    []interface{}
+	Allowed []string
+}
+
+// NewError returns an *Error with the given code, whose message is formatted with args.
+func NewError(code string, args ...interface{}) *Error {
+	return &Error{
+		Code: code,
+		Args: args,
+	}
+}
+
+// NewFieldError returns an *Error about the given field of the request payload.
+func NewFieldError(field, code string, args ...interface{}) *Error {
+	return &Error{
+		Code:  code,
+		Field: field,
+		Args:  args,
+	}
+}
+
+// Error implements the error interface, giving the message in i18n.DefaultLanguage.
+func (e *Error) Error() string {
+	return i18n.Translate(i18n.DefaultLanguage, e.Code, e.Args...)
+}
+
+// Errors are several errors responded with at once, such as every field error of a
+// request payload.
+type Errors []*Error
+
+// Error implements the error interface, joining the messages in i18n.DefaultLanguage.
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// StatusError returns an *Error describing the given status code, such as not_found for
+// 404 Not Found.
+func StatusError(code int) *Error {
+	return NewError(strings.ReplaceAll(strings.ToLower(http.StatusText(code)), " ", "_"))
+}
+
+// responseErrors returns err as it is sent in response to r. *Error messages are
+// translated to the language preferred by the Accept-Language header of r, other errors
+// are identified by the code of their status. Errors are sent one by one.
+func responseErrors(r *http.Request, code int, err error) []ResponseError {
+	var errs Errors
+	switch e := errors.Cause(err).(type) {
+	case *Error:
+		errs = Errors{e}
+	case Errors:
+		errs = e
+	default:
+		return []ResponseError{
+			{
+				Code:    StatusError(code).Code,
+				Message: err.Error(),
+			},
+		}
+	}
+
+	language := i18n.Language(r.Header.Get("Accept-Language"))
+
+	resp := make([]ResponseError, 0, len(errs))
+	for _, e := range errs {
+		resp = append(resp, ResponseError{
+			Code:    e.Code,
+			Field:   e.Field,
+			Message: i18n.Translate(language, e.Code, e.Args...),
+			Allowed: e.Allowed,
+		})
+	}
+
+	return resp
+}
+
 // Respond sends a response with a status code.
 func Respond(w http.ResponseWriter, r *http.Request, code int, data interface{}, errs ...error) {
 	var respErrs []ResponseError
 
 	if len(errs) > 0 {
 		for _, err := range errs {
-			log.WithFields(log.Fields{
-				"error": err,
-			}).Error("error while serving request")
+			Logger(r.Context()).WithError(err).Error("error while serving request")
 
-			respErrs = append(respErrs, ResponseError{Message: err.Error()})
+			respErrs = append(respErrs, responseErrors(r, code, err)...)
 		}
 	}
 
@@ -47,32 +147,55 @@ func Respond(w http.ResponseWriter, r *http.Request, code int, data interface{},
 }
 
 // RespondError sends an error response with a status code. The error is automatically logged for you.
-// If the error implements StatusCoder, the provided status code will be used.
+// If the error implements StatusCoder, the status code it provides will be used instead, and
+// 500s are mapped to a better status code by the mappers given to MapErrors. Mapped errors are
+// responded with the generic message of their status code, and 503s are told when to retry.
 func RespondError(w http.ResponseWriter, r *http.Request, code int, err error) {
-	log.WithFields(log.Fields{
-		"error": err,
-	}).Error("error while serving request")
+	code, mapped := statusOf(code, err)
+	class := Class(code)
+
+	classCounters[class].Inc()
+	Logger(r.Context()).WithError(err).WithField("errorClass", class).Error("error while serving request")
+
+	if mapped {
+		err = StatusError(code)
+	}
+
+	if code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+		w.Header().Set("Retry-After", retryAfter)
+	}
 
 	if code >= http.StatusInternalServerError && code != http.StatusServiceUnavailable && code != http.StatusNotImplemented {
 
 		// Respond with generic error. Error messages and and codes may potentially contain
 		// sensitive information or help an attacker.
 		code = http.StatusInternalServerError
-		err = errors.New(http.StatusText(http.StatusInternalServerError))
+		err = StatusError(http.StatusInternalServerError)
 	}
 
 	resp := Response{
-		Errors: []ResponseError{
-			{
-				Message: err.Error(),
-			},
-		},
+		Errors: responseErrors(r, code, err),
 	}
 
 	writeResponse(w, r, code, &resp)
 }
 
+// pretty reports whether the response to r is indented, which is given by the pretty
+// query parameter or defaults to the setting of WithPretty.
+func pretty(r *http.Request) bool {
+	if p, err := strconv.ParseBool(r.URL.Query().Get("pretty")); err == nil {
+		return p
+	}
+
+	p, _ := r.Context().Value(prettyKey).(bool)
+	return p
+}
+
 // writeResponse marshals the response to json and writes it to the response writer.
+// Responses are compact unless indentation is asked for, see WithPretty, and wrapped
+// unless the raw envelope is asked for, see WithEnvelope. Identifiers are encoded as
+// strings when asked for, see WithStringIDs, and deprecated fields are announced, see
+// WithDeprecations.
 func writeResponse(w http.ResponseWriter, r *http.Request, code int, resp *Response) {
 	if code == http.StatusNoContent || resp == nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -80,16 +203,101 @@ func writeResponse(w http.ResponseWriter, r *http.Request, code int, resp *Respo
 		return
 	}
 
-	b, err := json.Marshal(resp)
+	body := unwrap(w, r, resp)
+
+	var b []byte
+	var err error
+	if pretty(r) {
+		if b, err = json.MarshalIndent(body, "", "  "); err == nil {
+			b = append(b, '\n')
+		}
+	} else {
+		b, err = json.Marshal(body)
+	}
+	if err == nil {
+		_, wrapped := body.(*Response)
+		b, err = deprecate(w, r, b, wrapped, pretty(r))
+	}
+	if err == nil && stringIDs(r) {
+		b, err = quoteIDs(b, pretty(r))
+	}
 	if err != nil {
 		RespondError(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
 	w.WriteHeader(code)
 
 	if _, err := w.Write(b); err != nil {
-		log.WithError(errors.Wrap(err, "write response body"))
+		Logger(r.Context()).WithError(err).Error("write response body")
+	}
+}
+
+// WantsRepresentation reports whether the client asked for the affected resource in the
+// response body, through return=representation in the query string or the Prefer header
+// as defined by RFC 7240. The preference is acknowledged in the Preference-Applied header,
+// so it has to be called before the response is written.
+func WantsRepresentation(w http.ResponseWriter, r *http.Request) bool {
+	wants := r.URL.Query().Get("return") == "representation" || prefers(r, "return=representation")
+
+	if wants {
+		w.Header().Add("Preference-Applied", "return=representation")
+	}
+
+	return wants
+}
+
+// WantsDryRun reports whether the client asked for a write to be carried out without
+// keeping its changes, through dry_run=true in the query string or the dry-run preference
+// of the Prefer header. The preference is acknowledged in the Preference-Applied header,
+// so it has to be called before the response is written.
+func WantsDryRun(w http.ResponseWriter, r *http.Request) bool {
+	wants := r.URL.Query().Get("dry_run") == "true" || prefers(r, "dry-run")
+
+	if wants {
+		w.Header().Add("Preference-Applied", "dry-run")
 	}
+
+	return wants
+}
+
+// WantsAsync reports whether the client asked for an operation to be carried out in the
+// background, through async=true in the query string or the respond-async preference of
+// the Prefer header. The preference is acknowledged in the Preference-Applied header, so
+// it has to be called before the response is written.
+func WantsAsync(w http.ResponseWriter, r *http.Request) bool {
+	wants := r.URL.Query().Get("async") == "true" || prefers(r, "respond-async")
+
+	if wants {
+		w.Header().Add("Preference-Applied", "respond-async")
+	}
+
+	return wants
+}
+
+// WithDryRun returns a shallow copy of r that is a dry run, see DryRun.
+func WithDryRun(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), dryRunKey, true))
+}
+
+// DryRun reports whether the request that the given context belongs to is a dry run,
+// whose changes must be rolled back rather than committed.
+func DryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey).(bool)
+	return dry
+}
+
+// prefers reports whether the Prefer header of r, as defined by RFC 7240, contains the
+// given preference.
+func prefers(r *http.Request, preference string) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), preference) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
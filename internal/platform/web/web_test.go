@@ -0,0 +1,287 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+func TestWantsRepresentation(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Prefer   string
+		Expected bool
+	}{
+		{
+			Name: "Default",
+		},
+		{
+			Name:     "Query",
+			Query:    "?return=representation",
+			Expected: true,
+		},
+		{
+			Name:     "Prefer",
+			Prefer:   "respond-async, Return=Representation",
+			Expected: true,
+		},
+		{
+			Name:   "PreferMinimal",
+			Prefer: "return=minimal",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/list/1"+test.Query, nil)
+			if test.Prefer != "" {
+				r.Header.Set("Prefer", test.Prefer)
+			}
+
+			w := httptest.NewRecorder()
+			if got := WantsRepresentation(w, r); got != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, got)
+			}
+
+			if applied := w.Header().Get("Preference-Applied") != ""; applied != test.Expected {
+				t.Errorf("expected Preference-Applied to be set: %v, got header %q", test.Expected, w.Header().Get("Preference-Applied"))
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWantsDryRun(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Prefer   string
+		Expected bool
+	}{
+		{
+			Name: "Default",
+		},
+		{
+			Name:     "Query",
+			Query:    "?dry_run=true",
+			Expected: true,
+		},
+		{
+			Name:     "Prefer",
+			Prefer:   "return=representation, Dry-Run",
+			Expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/list"+test.Query, nil)
+			if test.Prefer != "" {
+				r.Header.Set("Prefer", test.Prefer)
+			}
+
+			w := httptest.NewRecorder()
+			if got := WantsDryRun(w, r); got != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, got)
+			}
+
+			if applied := w.Header().Get("Preference-Applied") == "dry-run"; applied != test.Expected {
+				t.Errorf("expected Preference-Applied to be dry-run: %v, got header %q", test.Expected, w.Header().Get("Preference-Applied"))
+			}
+
+			if DryRun(r.Context()) || !DryRun(WithDryRun(r).Context()) {
+				t.Error("expected only requests given to WithDryRun to be dry runs")
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestWantsAsync(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Prefer   string
+		Expected bool
+	}{
+		{
+			Name: "Default",
+		},
+		{
+			Name:     "Query",
+			Query:    "?async=true",
+			Expected: true,
+		},
+		{
+			Name:     "Prefer",
+			Prefer:   "respond-async, wait=10",
+			Expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/export"+test.Query, nil)
+			if test.Prefer != "" {
+				r.Header.Set("Prefer", test.Prefer)
+			}
+
+			w := httptest.NewRecorder()
+			if got := WantsAsync(w, r); got != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, got)
+			}
+
+			if applied := w.Header().Get("Preference-Applied") == "respond-async"; applied != test.Expected {
+				t.Errorf("expected Preference-Applied to be respond-async: %v, got header %q", test.Expected, w.Header().Get("Preference-Applied"))
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestRespondErrorLanguage(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AcceptLanguage string
+		Code           int
+		Err            error
+		Expected       ResponseError
+	}{
+		{
+			Name:     "Default",
+			Code:     http.StatusNotFound,
+			Err:      StatusError(http.StatusNotFound),
+			Expected: ResponseError{Code: "not_found", Message: "Not Found"},
+		},
+		{
+			Name:           "Translated",
+			AcceptLanguage: "de-DE, en;q=0.5",
+			Code:           http.StatusBadRequest,
+			Err:            errors.Wrap(NewError("limit_invalid", 500, "all"), "parse page"),
+			Expected:       ResponseError{Code: "limit_invalid", Message: `limit muss eine ganze Zahl zwischen 1 und 500 sein, "all" erhalten`},
+		},
+		{
+			Name:           "Uncoded",
+			AcceptLanguage: "de",
+			Code:           http.StatusConflict,
+			Err:            errors.New("already exists"),
+			Expected:       ResponseError{Code: "conflict", Message: "already exists"},
+		},
+		{
+			Name:           "Internal",
+			AcceptLanguage: "es",
+			Code:           http.StatusInternalServerError,
+			Err:            errors.New("connection refused"),
+			Expected:       ResponseError{Code: "internal_server_error", Message: "Error interno del servidor"},
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list", nil)
+			r.Header.Set("Accept-Language", test.AcceptLanguage)
+
+			w := httptest.NewRecorder()
+			RespondError(w, r, test.Code, test.Err)
+
+			var resp Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("error decoding response body: %v", err)
+			}
+
+			if d := cmp.Diff([]ResponseError{test.Expected}, resp.Errors); d != "" {
+				t.Errorf("unexpected difference in response errors:\n%v", d)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestRespondErrorFields(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/list", nil)
+	r.Header.Set("Accept-Language", "de")
+
+	w := httptest.NewRecorder()
+	RespondError(w, r, http.StatusUnprocessableEntity, Errors{
+		NewFieldError("name", "name_invalid_characters"),
+		NewFieldError("name", "name_too_long", 3, 7),
+	})
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response body: %v", err)
+	}
+
+	expected := []ResponseError{
+		{Code: "name_invalid_characters", Field: "name", Message: "name darf keine Steuer- oder unsichtbaren Zeichen enthalten"},
+		{Code: "name_too_long", Field: "name", Message: "name darf höchstens 3 Zeichen lang sein, 7 erhalten"},
+	}
+
+	if d := cmp.Diff(expected, resp.Errors); d != "" {
+		t.Errorf("unexpected difference in response errors:\n%v", d)
+	}
+}
+
+func TestRespondPretty(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Default  bool
+		Expected string
+	}{
+		{
+			Name:     "Compact",
+			Expected: `{"results":{"id":1}}`,
+		},
+		{
+			Name:     "Query",
+			Query:    "?pretty=true",
+			Expected: "{\n  \"results\": {\n    \"id\": 1\n  }\n}\n",
+		},
+		{
+			Name:     "Default",
+			Default:  true,
+			Expected: "{\n  \"results\": {\n    \"id\": 1\n  }\n}\n",
+		},
+		{
+			Name:     "DefaultOverridden",
+			Query:    "?pretty=false",
+			Default:  true,
+			Expected: `{"results":{"id":1}}`,
+		},
+		{
+			Name:     "InvalidQuery",
+			Query:    "?pretty=maybe",
+			Expected: `{"results":{"id":1}}`,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			r := WithPretty(httptest.NewRequest(http.MethodGet, "/list/1"+test.Query, nil), test.Default)
+
+			w := httptest.NewRecorder()
+			Respond(w, r, http.StatusOK, map[string]int{"id": 1})
+
+			if a := w.Body.String(); a != test.Expected {
+				t.Errorf("expected body %q, got %q", test.Expected, a)
+			}
+
+			if e, a := strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"); e != a {
+				t.Errorf("expected Content-Length %s, got %q", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
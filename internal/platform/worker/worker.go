@@ -0,0 +1,111 @@
+// Package worker runs tasks in the background on a fixed amount of goroutines, so
+// expensive operations don't have to finish within the request that started them.
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Task is a function run by a Pool. Its context is canceled when the pool is closed and
+// its tasks don't finish in time.
+type Task func(ctx context.Context)
+
+// Pool runs the tasks submitted to it on a fixed amount of workers, queueing the ones
+// that don't get a worker right away. It is safe for concurrent use and a nil *Pool
+// accepts no tasks.
+type Pool struct {
+	tasks  chan Task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards closed, which keeps Submit from sending to the closed tasks channel.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New returns a new Pool running up to size tasks at once with room for queue tasks in
+// its queue. The workers are started right away.
+func New(size, queue int) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := Pool{
+		tasks:  make(chan Task, queue),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+
+	return &p
+}
+
+// work runs the tasks of the queue until it is closed and drained.
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for t := range p.tasks {
+		t(p.ctx)
+	}
+}
+
+// Submit queues t to be run by the next free worker. It reports whether t was accepted,
+// which it isn't when the queue is full or the pool is closed.
+func (p *Pool) Submit(t Task) bool {
+	if p == nil {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.tasks <- t:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting tasks and waits for the queued and running ones to finish, or
+// for ctx to be done, whichever happens first. Once ctx is done the context of every
+// task is canceled, tasks that are still queued then run with a canceled context so they
+// can record that they were interrupted.
+func (p *Pool) Close(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return errors.Wrap(ctx.Err(), "wait for running tasks")
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	p := New(2, 10)
+
+	var ran int32
+	for i := 0; i < 10; i++ {
+		if !p.Submit(func(ctx context.Context) { atomic.AddInt32(&ran, 1) }) {
+			t.Fatalf("expected task %d to be accepted", i)
+		}
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error closing pool: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&ran); n != 10 {
+		t.Errorf("expected every queued task to run before Close returns, got %d", n)
+	}
+
+	if p.Submit(func(ctx context.Context) {}) {
+		t.Error("expected closed pool to reject tasks")
+	}
+}
+
+func TestPoolQueueFull(t *testing.T) {
+	p := New(1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	p.Submit(func(ctx context.Context) {
+		close(started)
+		<-release
+	})
+	<-started
+
+	if !p.Submit(func(ctx context.Context) {}) {
+		t.Error("expected task to be queued")
+	}
+
+	if p.Submit(func(ctx context.Context) {}) {
+		t.Error("expected task to be rejected while the queue is full")
+	}
+
+	close(release)
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error closing pool: %v", err)
+	}
+}
+
+func TestPoolCloseTimeout(t *testing.T) {
+	p := New(1, 1)
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+
+	p.Submit(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.Close(ctx); err == nil {
+		t.Error("expected error when tasks don't finish in time")
+	}
+
+	select {
+	case <-canceled:
+	default:
+		t.Error("expected running task to be canceled once Close returns")
+	}
+}
+
+func TestPoolNil(t *testing.T) {
+	var p *Pool
+
+	if p.Submit(func(ctx context.Context) {}) {
+		t.Error("expected nil pool to reject tasks")
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Errorf("unexpected error closing nil pool: %v", err)
+	}
+}
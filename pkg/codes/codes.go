@@ -0,0 +1,202 @@
+// Package codes defines the codes of the errors the list daemon responds with. Every
+// error in a 4xx or 5xx response body carries one of them, so clients can tell errors
+// apart without parsing their messages, which are translated. Codes never change once
+// released.
+package codes
+
+// Codes of errors that are identified by the status of the response alone, named after
+// the status text.
+const (
+	BadRequest          = "bad_request"
+	NotFound            = "not_found"
+	MethodNotAllowed    = "method_not_allowed"
+	Conflict            = "conflict"
+	UnprocessableEntity = "unprocessable_entity"
+	InternalServerError = "internal_server_error"
+	ServiceUnavailable  = "service_unavailable"
+)
+
+// Codes of errors in the request payload.
+const (
+	// PayloadInvalid is given for payloads that aren't valid JSON of the expected shape.
+	PayloadInvalid = "payload_invalid"
+
+	// PayloadTooLarge is given for payloads over the size limit.
+	PayloadTooLarge = "payload_too_large"
+
+	// NameRequired is given for lists, templates, and attachments without a name.
+	NameRequired = "name_required"
+
+	// NameInvalidCharacters is given for names with control or invisible characters.
+	NameInvalidCharacters = "name_invalid_characters"
+
+	// NameTooLong is given for names over the length limit.
+	NameTooLong = "name_too_long"
+
+	// ItemNameRequired is given for items without a name.
+	ItemNameRequired = "item_name_required"
+
+	// QuantityInvalid is given for items with a missing or non-positive quantity.
+	QuantityInvalid = "quantity_invalid"
+
+	// UnitInvalid is given for items in a unit the list daemon doesn't know.
+	UnitInvalid = "unit_invalid"
+
+	// EnabledRequired is given for changes of feature flags and maintenance mode without
+	// the enabled field.
+	EnabledRequired = "enabled_required"
+
+	// ExportInvalid is given for imports of malformed exports.
+	ExportInvalid = "export_invalid"
+
+	// BatchSizeInvalid is given for batches with too few or too many entries.
+	BatchSizeInvalid = "batch_size_invalid"
+
+	// ListIDsInvalid is given for bulk requests with too few or too many list ids.
+	ListIDsInvalid = "list_ids_invalid"
+
+	// SettingsColorInvalid is given for settings with a color that isn't a hex triplet.
+	SettingsColorInvalid = "settings_color_invalid"
+
+	// SettingsSortInvalid is given for settings with an unknown sort order.
+	SettingsSortInvalid = "settings_sort_invalid"
+
+	// ListIconInvalid is given for lists with an icon that isn't a single emoji.
+	ListIconInvalid = "list_icon_invalid"
+
+	// ListColorInvalid is given for lists with a color that isn't a hex triplet.
+	ListColorInvalid = "list_color_invalid"
+
+	// QuotaInvalid is given for quotas with a negative limit.
+	QuotaInvalid = "quota_invalid"
+)
+
+// Codes of errors in the query of the request.
+const (
+	IDsInvalid          = "ids_invalid"
+	LimitInvalid        = "limit_invalid"
+	OffsetInvalid       = "offset_invalid"
+	CursorInvalid       = "cursor_invalid"
+	FilterInvalid       = "filter_invalid"
+	QueryBooleanInvalid = "query_boolean_invalid"
+	QueryIntegerInvalid = "query_integer_invalid"
+	QueryMinInvalid     = "query_min_invalid"
+	QueryMaxInvalid     = "query_max_invalid"
+	QueryOneOfInvalid   = "query_oneof_invalid"
+	QueryTimeInvalid    = "query_time_invalid"
+	LocaleUnsupported   = "locale_unsupported"
+)
+
+// Codes of errors in the headers of the request.
+const (
+	// RequestTimeoutInvalid is given for timeouts asked for by clients that aren't
+	// positive durations.
+	RequestTimeoutInvalid = "request_timeout_invalid"
+)
+
+// Codes of requests that conflict with the state of the list daemon.
+const (
+	// ListNameTaken is given when another list has the same name.
+	ListNameTaken = "list_name_taken"
+
+	// ItemNameTaken is given when the list already has an item with the same name.
+	ItemNameTaken = "item_name_taken"
+
+	// ItemUnitMismatch is given when an item is merged into one in a different unit.
+	ItemUnitMismatch = "item_unit_mismatch"
+
+	// TemplateNameTaken is given when another template has the same name.
+	TemplateNameTaken = "template_name_taken"
+
+	// ListsNotFound is given when some of several requested lists don't exist.
+	ListsNotFound = "lists_not_found"
+
+	// ItemsNotFound is given when some of several requested items don't exist.
+	ItemsNotFound = "items_not_found"
+
+	// BatchEntryAborted is given for the entries of a batch that weren't applied
+	// because another entry failed.
+	BatchEntryAborted = "batch_entry_aborted"
+
+	// ListQuotaExceeded is given when a list is created while there are as many lists
+	// as the quota allows.
+	ListQuotaExceeded = "list_quota_exceeded"
+
+	// ItemQuotaExceeded is given when an item is added to a list that has as many items
+	// as the quota allows.
+	ItemQuotaExceeded = "item_quota_exceeded"
+
+	// ListAbsent is given when a list is restored to a time at which it didn't exist.
+	ListAbsent = "list_absent"
+
+	// ListHistoryUnavailable is given when a list is restored to a time whose changes
+	// have already been purged.
+	ListHistoryUnavailable = "list_history_unavailable"
+
+	// FeatureNotRuntime is given when a feature flag that is only configurable at
+	// startup is changed.
+	FeatureNotRuntime = "feature_not_runtime"
+)
+
+// Codes of request signatures, see package signing.
+const (
+	// SignatureRequired is given for unsigned requests while signatures are required.
+	SignatureRequired = "signature_required"
+
+	// SignatureInvalid is given for signatures that are malformed, made with an unknown
+	// key, or don't match the request.
+	SignatureInvalid = "signature_invalid"
+
+	// SignatureExpired is given for requests whose Date is outside of the replay window.
+	SignatureExpired = "signature_expired"
+
+	// SignatureReplayed is given for signed requests that were already received.
+	SignatureReplayed = "signature_replayed"
+)
+
+// Codes of the download URLs of attachments.
+const (
+	// DownloadURLInvalid is given for download URLs that weren't signed by the list
+	// daemon or expired.
+	DownloadURLInvalid = "download_url_invalid"
+)
+
+// Codes of background jobs and transient failures.
+const (
+	// JobQueueFull is given when too many jobs are waiting to run.
+	JobQueueFull = "job_queue_full"
+
+	// JobInterrupted is the reason of jobs that were cut short by a shutdown.
+	JobInterrupted = "job_interrupted"
+
+	// JobResultUnavailable is given for results of jobs that haven't succeeded.
+	JobResultUnavailable = "job_result_unavailable"
+
+	// SchemaDrifted is given for write requests refused while the schema of the database
+	// drifted from the one its migrations built.
+	SchemaDrifted = "schema_drifted"
+
+	// ReadOnlyReplica is given for write requests refused by a daemon serving a replica
+	// of the database.
+	ReadOnlyReplica = "read_only_replica"
+
+	// ReadOnlyMigrating is given for write requests refused while the database is being
+	// migrated.
+	ReadOnlyMigrating = "read_only_migrating"
+
+	// RateLimited is given for requests over the rate limit of the public read API.
+	RateLimited = "rate_limited"
+
+	// FaultInjected is given for requests failed on purpose by fault injection.
+	FaultInjected = "fault_injected"
+
+	// WebhookDisabled is given when dead letters are posted again without a webhook to
+	// post them to.
+	WebhookDisabled = "webhook_disabled"
+
+	// RedeliveryFailed is given when the webhook rejects a dead letter posted again.
+	RedeliveryFailed = "redelivery_failed"
+
+	// MockUnsupported is given for requests to routes that aren't served in mock mode.
+	MockUnsupported = "mock_unsupported"
+)
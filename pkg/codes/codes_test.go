@@ -0,0 +1,38 @@
+package codes
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/internal/platform/i18n"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestCatalog ensures every code has a constant and every constant has a message.
+func TestCatalog(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatalf("error parsing codes.go: %v", err)
+	}
+
+	var consts []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			c, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatalf("error unquoting %s: %v", lit.Value, err)
+			}
+			consts = append(consts, c)
+		}
+		return true
+	})
+	sort.Strings(consts)
+
+	if d := cmp.Diff(i18n.Codes(), consts); d != "" {
+		t.Errorf("unexpected difference between the codes of the catalog and the constants:\n%v", d)
+	}
+}
@@ -0,0 +1,513 @@
+// Package listclient is a client for the HTTP API of the list daemon.
+package listclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// List is a list as returned by the list daemon.
+type List struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+
+	// Icon is a single emoji shown along with the name, and Color a hex triplet such as
+	// #ff8800 the list is presented in. Either may be empty.
+	Icon  string `json:"icon,omitempty"`
+	Color string `json:"color,omitempty"`
+}
+
+// Item is an item of a list as returned by the list daemon.
+type Item struct {
+	ID       int       `json:"id"`
+	ListID   int       `json:"listID"`
+	Name     string    `json:"name"`
+	Quantity int       `json:"quantity"`
+	Unit     string    `json:"unit"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+}
+
+// Error is returned for every response of the list daemon with a status code of 400 or
+// above, carrying the error codes and messages of the response envelope.
+type Error struct {
+	StatusCode int
+	Codes      []string
+	Messages   []string
+
+	// RetryAfter is the wait the list daemon asked for with the Retry-After header of
+	// the response, zero when it didn't.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("list daemon responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("list daemon responded with %d: %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// IsNotFound reports whether err is an *Error caused by a resource that does not exist.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// HasCode reports whether err is an *Error carrying the given error code, one of the
+// constants of package codes.
+func HasCode(err error, code string) bool {
+	e, ok := errors.Cause(err).(*Error)
+	if !ok {
+		return false
+	}
+
+	for _, c := range e.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Client is a client for a single list daemon. The zero values of the exported fields
+// are replaced by sensible defaults in New and may be changed before first use.
+type Client struct {
+	baseURL string
+
+	// HTTPClient is used to send every request.
+	HTTPClient *http.Client
+
+	// Retries is the amount of times an idempotent request (GET, PUT, and DELETE) is
+	// retried after a network error or a 429, 502, 503, or 504 response. POST requests
+	// are only retried after a 429 or 503 response, with which the list daemon turns
+	// requests away before handling them.
+	Retries int
+
+	// Backoff is the wait before the first retry, it doubles with every retry after. The
+	// Retry-After header of a response is waited for instead when it is given.
+	Backoff time.Duration
+
+	// MaxRetryAfter is the longest Retry-After header that is waited for, a request is
+	// not retried when the list daemon asks for a longer wait.
+	MaxRetryAfter time.Duration
+
+	// SigningKey, if non-nil, signs every request before it is sent, for list daemons
+	// that verify signatures, see package signing. Retries are signed again.
+	SigningKey *signing.Key
+
+	// OnRequest, if non-nil, is called with every request before it is sent, including
+	// retries. It may add headers to the request.
+	OnRequest func(*http.Request)
+
+	// OnAttempt, if non-nil, is called after every attempt at a request, for metrics and
+	// logging.
+	OnAttempt func(Attempt)
+}
+
+// Attempt describes a single attempt at a request, see Client.OnAttempt.
+type Attempt struct {
+	Method string
+	Path   string
+
+	// N is the number of the attempt, starting at 1.
+	N int
+
+	// StatusCode is the status code of the response, zero when none was received.
+	StatusCode int
+
+	Duration time.Duration
+	Err      error
+
+	// Retry reports whether the request is retried after the attempt.
+	Retry bool
+}
+
+// New returns a new Client for the list daemon at the given base URL, for example
+// http://localhost:3000.
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		Retries:       2,
+		Backoff:       100 * time.Millisecond,
+		MaxRetryAfter: 10 * time.Second,
+	}
+}
+
+// Lists returns every list, requesting as many pages as needed.
+func (c *Client) Lists(ctx context.Context) ([]List, error) {
+	var lists []List
+	for {
+		var results []List
+		p := paged{results: &results}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/list?offset=%d", len(lists)), nil, &p); err != nil {
+			return nil, errors.Wrap(err, "get lists")
+		}
+
+		lists = append(lists, results...)
+		if len(results) == 0 || len(lists) >= p.page.Total {
+			return lists, nil
+		}
+	}
+}
+
+// List returns the list with the given id.
+func (c *Client) List(ctx context.Context, id int) (List, error) {
+	var l List
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/list/%d", id), nil, &l); err != nil {
+		return List{}, errors.Wrapf(err, "get list %d", id)
+	}
+
+	return l, nil
+}
+
+// CreateList creates a list with the given name.
+func (c *Client) CreateList(ctx context.Context, name string) (List, error) {
+	var l List
+	if err := c.do(ctx, http.MethodPost, "/list", List{Name: name}, &l); err != nil {
+		return List{}, errors.Wrap(err, "create list")
+	}
+
+	return l, nil
+}
+
+// UpdateList renames the list with the given id, keeping its icon and color.
+func (c *Client) UpdateList(ctx context.Context, id int, name string) (List, error) {
+	var l List
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/list/%d", id), List{Name: name}, &l); err != nil {
+		return List{}, errors.Wrapf(err, "update list %d", id)
+	}
+
+	return l, nil
+}
+
+// DeleteList deletes the list with the given id along with its items.
+func (c *Client) DeleteList(ctx context.Context, id int) error {
+	return errors.Wrapf(c.do(ctx, http.MethodDelete, fmt.Sprintf("/list/%d", id), nil, nil), "delete list %d", id)
+}
+
+// Items returns every item of the list with the given id, requesting as many pages as
+// needed.
+func (c *Client) Items(ctx context.Context, listID int) ([]Item, error) {
+	var items []Item
+	for {
+		var results []Item
+		p := paged{results: &results}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/list/%d/item?offset=%d", listID, len(items)), nil, &p); err != nil {
+			return nil, errors.Wrapf(err, "get items of list %d", listID)
+		}
+
+		items = append(items, results...)
+		if len(results) == 0 || len(items) >= p.page.Total {
+			return items, nil
+		}
+	}
+}
+
+// Item returns a single item of a list.
+func (c *Client) Item(ctx context.Context, listID, itemID int) (Item, error) {
+	var i Item
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/list/%d/item/%d", listID, itemID), nil, &i); err != nil {
+		return Item{}, errors.Wrapf(err, "get item %d of list %d", itemID, listID)
+	}
+
+	return i, nil
+}
+
+// CreateItem adds the given item to the list denoted by its ListID.
+func (c *Client) CreateItem(ctx context.Context, i Item) (Item, error) {
+	var created Item
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/list/%d/item", i.ListID), i, &created); err != nil {
+		return Item{}, errors.Wrapf(err, "create item in list %d", i.ListID)
+	}
+
+	return created, nil
+}
+
+// UpdateItem updates the name and quantity of the item denoted by its ID and ListID.
+func (c *Client) UpdateItem(ctx context.Context, i Item) (Item, error) {
+	var updated Item
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/list/%d/item/%d", i.ListID, i.ID), i, &updated); err != nil {
+		return Item{}, errors.Wrapf(err, "update item %d of list %d", i.ID, i.ListID)
+	}
+
+	return updated, nil
+}
+
+// DeleteItem deletes a single item of a list.
+func (c *Client) DeleteItem(ctx context.Context, listID, itemID int) error {
+	return errors.Wrapf(c.do(ctx, http.MethodDelete, fmt.Sprintf("/list/%d/item/%d", listID, itemID), nil, nil), "delete item %d of list %d", itemID, listID)
+}
+
+// envelope is the format of every response body of the list daemon.
+type envelope struct {
+	Results json.RawMessage `json:"results"`
+	Page    *page           `json:"page"`
+	Errors  []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// page describes the part of a paginated collection a response contains.
+type page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+// paged is given to do as results by requests for paginated collections, the page of
+// the response is stored in it along with the results. Page stays zero when the list
+// daemon doesn't paginate the collection.
+type paged struct {
+	results interface{}
+	page    page
+}
+
+// do sends a request with the given method and JSON encoded body to the given path,
+// retrying requests on transient failures. POST requests are given an Idempotency-Key
+// header, which is kept across retries. If results is non-nil the results of the
+// response envelope are decoded into it.
+func (c *Client) do(ctx context.Context, method, path string, body, results interface{}) error {
+	var key string
+	if method == http.MethodPost {
+		key = uuid.New()
+	}
+
+	return c.doKey(ctx, method, path, key, body, results)
+}
+
+// doKey is do with the given Idempotency-Key, none is sent when key is empty.
+func (c *Client) doKey(ctx context.Context, method, path, key string, body, results interface{}) error {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "encode request body")
+		}
+	}
+
+	backoff := c.Backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		code, err := c.send(ctx, method, path, key, b, results)
+
+		wait, retry := backoff, false
+		if err != nil && attempt <= c.Retries && ctx.Err() == nil && c.retryable(method, err) {
+			retry = true
+			if e, ok := errors.Cause(err).(*Error); ok && e.RetryAfter > 0 {
+				wait = e.RetryAfter
+				retry = wait <= c.MaxRetryAfter
+			}
+		}
+
+		if c.OnAttempt != nil {
+			c.OnAttempt(Attempt{
+				Method:     method,
+				Path:       path,
+				N:          attempt,
+				StatusCode: code,
+				Duration:   time.Since(start),
+				Err:        err,
+				Retry:      retry,
+			})
+		}
+
+		if !retry {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), err.Error())
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a request with the given method that failed with err may be
+// retried.
+func (c *Client) retryable(method string, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return transient(err)
+
+	case http.MethodPost:
+		e, ok := errors.Cause(err).(*Error)
+		return ok && (e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable)
+	}
+
+	return false
+}
+
+// send sends a single request, see do, returning the status code of its response.
+func (c *Client) send(ctx context.Context, method, path, key string, body []byte, results interface{}) (int, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "create request")
+	}
+	req = req.WithContext(ctx)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	// The daemon gives up on the request once the caller would, rounded up to the
+	// millisecond so it never gives up first.
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline); d > 0 {
+			req.Header.Set("X-Request-Timeout", (d + time.Millisecond - 1).Truncate(time.Millisecond).String())
+		}
+	}
+
+	if c.SigningKey != nil {
+		if err := signing.Sign(req, *c.SigningKey, time.Now()); err != nil {
+			return 0, errors.Wrap(err, "sign request")
+		}
+	}
+
+	if c.OnRequest != nil {
+		c.OnRequest(req)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, &networkError{err: err}
+	}
+
+	defer func() {
+		// Draining the body allows the connection to be reused.
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil && resp.StatusCode < http.StatusBadRequest {
+		return resp.StatusCode, errors.Wrap(err, "decode response body")
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		e := Error{
+			StatusCode: resp.StatusCode,
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+
+		for _, m := range env.Errors {
+			e.Codes = append(e.Codes, m.Code)
+			e.Messages = append(e.Messages, m.Message)
+		}
+
+		return resp.StatusCode, &e
+	}
+
+	if p, ok := results.(*paged); ok {
+		if env.Page != nil {
+			p.page = *env.Page
+		}
+		results = p.results
+	}
+
+	if results == nil || len(env.Results) == 0 {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, errors.Wrap(json.Unmarshal(env.Results, results), "decode response results")
+}
+
+// retryAfter returns the wait asked for by the Retry-After header h, which is either a
+// number of seconds or an HTTP date, relative to now. It returns zero when h is empty,
+// malformed, or in the past.
+func retryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+
+	if s, err := strconv.Atoi(h); err == nil {
+		if s < 0 {
+			return 0
+		}
+		return time.Duration(s) * time.Second
+	}
+
+	t, err := http.ParseTime(h)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+
+	return t.Sub(now)
+}
+
+// networkError is returned when the list daemon could not be reached.
+type networkError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *networkError) Error() string {
+	return "send request: " + e.err.Error()
+}
+
+// unsent reports whether a request that failed with err certainly wasn't handled by the
+// list daemon, so it may be sent again even when it isn't idempotent: no connection could
+// be made to the daemon, or it answered with a 429 or 503, which it does before handling
+// a request.
+func unsent(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *networkError:
+		if u, ok := e.err.(*url.Error); ok {
+			op, ok := u.Err.(*net.OpError)
+			return ok && op.Op == "dial"
+		}
+
+	case *Error:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+	}
+
+	return false
+}
+
+// transient reports whether a failed request may succeed when retried.
+func transient(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *networkError:
+		return true
+
+	case *Error:
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
+	return false
+}
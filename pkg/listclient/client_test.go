@@ -0,0 +1,501 @@
+package listclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/codes"
+	"github.com/george-e-shaw-iv/integration-tests-example/pkg/signing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+// respond writes a response in the envelope format of the list daemon. Errors are
+// identified by the code of the status, the same as the list daemon does for errors
+// without a code of their own.
+func respond(w http.ResponseWriter, code int, results interface{}, messages ...string) {
+	resp := map[string]interface{}{
+		"results": results,
+	}
+
+	var errs []map[string]string
+	for _, m := range messages {
+		errs = append(errs, map[string]string{
+			"code":    strings.ReplaceAll(strings.ToLower(http.StatusText(code)), " ", "_"),
+			"message": m,
+		})
+	}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestClient(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			respond(w, http.StatusOK, []List{{ID: 1, Name: "Grocery", Created: created, Modified: created}})
+		case http.MethodPost:
+			var l List
+			if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+				respond(w, http.StatusBadRequest, nil, err.Error())
+				return
+			}
+
+			if l.Name == "" {
+				respond(w, http.StatusBadRequest, nil, "name key is required")
+				return
+			}
+
+			l.ID = 2
+			respond(w, http.StatusCreated, l)
+		}
+	})
+	mux.HandleFunc("/list/1", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			respond(w, http.StatusOK, List{ID: 1, Name: "Grocery", Created: created, Modified: created})
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("/list/1/item", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, []Item{{ID: 1, ListID: 1, Name: "Milk", Quantity: 2}})
+	})
+	mux.HandleFunc("/list/0", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusNotFound, nil, "Not Found")
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(srv.URL + "/")
+	ctx := context.Background()
+
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		t.Fatalf("error getting lists: %v", err)
+	}
+
+	if d := cmp.Diff([]List{{ID: 1, Name: "Grocery", Created: created, Modified: created}}, lists); d != "" {
+		t.Errorf("unexpected difference in lists:\n%v", d)
+	}
+
+	l, err := c.CreateList(ctx, "To-do")
+	if err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	if d := cmp.Diff(List{ID: 2, Name: "To-do"}, l); d != "" {
+		t.Errorf("unexpected difference in created list:\n%v", d)
+	}
+
+	items, err := c.Items(ctx, 1)
+	if err != nil {
+		t.Fatalf("error getting items: %v", err)
+	}
+
+	if d := cmp.Diff([]Item{{ID: 1, ListID: 1, Name: "Milk", Quantity: 2}}, items); d != "" {
+		t.Errorf("unexpected difference in items:\n%v", d)
+	}
+
+	if err := c.DeleteList(ctx, 1); err != nil {
+		t.Errorf("error deleting list: %v", err)
+	}
+
+	_, err = c.List(ctx, 0)
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+
+	_, err = c.CreateList(ctx, "")
+	if !HasCode(err, codes.BadRequest) {
+		t.Errorf("expected error with code %s, got: %v", codes.BadRequest, err)
+	}
+
+	e, ok := errors.Cause(err).(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got: %v", err)
+	}
+
+	if d := cmp.Diff(&Error{StatusCode: http.StatusBadRequest, Codes: []string{"bad_request"}, Messages: []string{"name key is required"}}, e); d != "" {
+		t.Errorf("unexpected difference in error:\n%v", d)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		Name             string
+		Method           string
+		Code             int
+		ExpectedAttempts int32
+	}{
+		{
+			Name:             "RetriedGet",
+			Method:           http.MethodGet,
+			Code:             http.StatusServiceUnavailable,
+			ExpectedAttempts: 3,
+		},
+		{
+			Name:             "RetriedTooManyRequests",
+			Method:           http.MethodGet,
+			Code:             http.StatusTooManyRequests,
+			ExpectedAttempts: 3,
+		},
+		{
+			Name:             "RetriedPost",
+			Method:           http.MethodPost,
+			Code:             http.StatusServiceUnavailable,
+			ExpectedAttempts: 3,
+		},
+		{
+			Name:             "NotRetriedPost",
+			Method:           http.MethodPost,
+			Code:             http.StatusBadGateway,
+			ExpectedAttempts: 1,
+		},
+		{
+			Name:             "NotRetriedClientError",
+			Method:           http.MethodGet,
+			Code:             http.StatusBadRequest,
+			ExpectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var attempts int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				respond(w, test.Code, nil, http.StatusText(test.Code))
+			}))
+			defer srv.Close()
+
+			c := New(srv.URL)
+			c.Backoff = time.Millisecond
+
+			if err := c.do(context.Background(), test.Method, "/list", nil, nil); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if e, a := test.ExpectedAttempts, atomic.LoadInt32(&attempts); e != a {
+				t.Errorf("expected attempts: %v, got attempts: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+// flaky returns a server that responds to the first n requests with code and the given
+// Retry-After header, and to every request after with 200 OK. Every request is passed to
+// record first.
+func flaky(n int32, code int, retryAfter string, record func(*http.Request)) *httptest.Server {
+	var requests int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+
+		if atomic.AddInt32(&requests, 1) <= n {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			respond(w, code, nil, http.StatusText(code))
+			return
+		}
+
+		respond(w, http.StatusOK, List{ID: 1, Name: "Grocery"})
+	}))
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	tests := []struct {
+		Name             string
+		RetryAfter       string
+		ExpectedErr      bool
+		ExpectedAttempts int32
+	}{
+		{
+			Name:             "Honored",
+			RetryAfter:       "1",
+			ExpectedAttempts: 2,
+		},
+		{
+			Name:             "TooLong",
+			RetryAfter:       "60",
+			ExpectedErr:      true,
+			ExpectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			var attempts int32
+
+			srv := flaky(1, http.StatusTooManyRequests, test.RetryAfter, func(*http.Request) {
+				atomic.AddInt32(&attempts, 1)
+			})
+			defer srv.Close()
+
+			// The backoff would outlast the context, so the request only succeeds when
+			// the Retry-After header is waited for instead.
+			c := New(srv.URL)
+			c.Backoff = time.Hour
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			_, err := c.List(ctx, 1)
+			if test.ExpectedErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", test.ExpectedErr, err)
+			}
+
+			if e, a := test.ExpectedAttempts, atomic.LoadInt32(&attempts); e != a {
+				t.Errorf("expected attempts: %v, got attempts: %v", e, a)
+			}
+
+			if err == nil && time.Since(start) < time.Second {
+				t.Errorf("expected to wait for the Retry-After header, took %v", time.Since(start))
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	var keys []string
+
+	srv := flaky(2, http.StatusServiceUnavailable, "", func(r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+	})
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.CreateList(context.Background(), "Grocery"); err != nil {
+			t.Fatalf("error creating list: %v", err)
+		}
+	}
+
+	if len(keys) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(keys))
+	}
+
+	if keys[0] == "" {
+		t.Fatal("expected an Idempotency-Key header")
+	}
+
+	if keys[0] != keys[1] || keys[0] != keys[2] {
+		t.Errorf("expected the key to be kept across retries, got %q", keys[:3])
+	}
+
+	if keys[3] == keys[0] {
+		t.Errorf("expected a new key for a new request, got %q again", keys[3])
+	}
+
+	if _, err := c.List(context.Background(), 1); err != nil {
+		t.Fatalf("error getting list: %v", err)
+	}
+
+	if k := keys[len(keys)-1]; k != "" {
+		t.Errorf("expected no Idempotency-Key header on GET, got %q", k)
+	}
+}
+
+func TestClientDeadline(t *testing.T) {
+	var timeouts []string
+
+	srv := flaky(0, http.StatusOK, "", func(r *http.Request) {
+		timeouts = append(timeouts, r.Header.Get("X-Request-Timeout"))
+	})
+	defer srv.Close()
+
+	c := New(srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := c.List(ctx, 1); err != nil {
+		t.Fatalf("error getting list: %v", err)
+	}
+
+	if _, err := c.List(context.Background(), 1); err != nil {
+		t.Fatalf("error getting list: %v", err)
+	}
+
+	if len(timeouts) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(timeouts))
+	}
+
+	d, err := time.ParseDuration(timeouts[0])
+	if err != nil || d <= 4*time.Second || d > 5*time.Second {
+		t.Errorf("expected a timeout of about 5s, got %q", timeouts[0])
+	}
+
+	if timeouts[1] != "" {
+		t.Errorf("expected no timeout without a deadline, got %q", timeouts[1])
+	}
+}
+
+func TestClientSigning(t *testing.T) {
+	key := signing.Key{ID: "billing", Secret: "0123456789abcdef"}
+	v := signing.NewVerifier([]signing.Key{key}, time.Minute)
+
+	var errs []error
+
+	srv := flaky(1, http.StatusServiceUnavailable, "", func(r *http.Request) {
+		_, err := v.Verify(r, time.Now())
+		errs = append(errs, err)
+	})
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+	c.SigningKey = &key
+
+	if _, err := c.CreateList(context.Background(), "Grocery"); err != nil {
+		t.Fatalf("error creating list: %v", err)
+	}
+
+	// The retry is signed again, otherwise it would be rejected as a replay.
+	if d := cmp.Diff([]error{nil, nil}, errs, cmp.Comparer(func(a, b error) bool { return a == b })); d != "" {
+		t.Errorf("unexpected difference in verification errors:\n%v", d)
+	}
+}
+
+func TestClientHooks(t *testing.T) {
+	var tokens []string
+
+	srv := flaky(1, http.StatusServiceUnavailable, "", func(r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+	})
+	defer srv.Close()
+
+	var attempts []Attempt
+
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+	c.OnRequest = func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer token")
+	}
+	c.OnAttempt = func(a Attempt) {
+		a.Duration = 0
+		a.Err = nil
+		attempts = append(attempts, a)
+	}
+
+	if _, err := c.List(context.Background(), 1); err != nil {
+		t.Fatalf("error getting list: %v", err)
+	}
+
+	if d := cmp.Diff([]string{"Bearer token", "Bearer token"}, tokens); d != "" {
+		t.Errorf("unexpected difference in authorization headers:\n%v", d)
+	}
+
+	expected := []Attempt{
+		{Method: http.MethodGet, Path: "/list/1", N: 1, StatusCode: http.StatusServiceUnavailable, Retry: true},
+		{Method: http.MethodGet, Path: "/list/1", N: 2, StatusCode: http.StatusOK},
+	}
+
+	if d := cmp.Diff(expected, attempts); d != "" {
+		t.Errorf("unexpected difference in attempts:\n%v", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name     string
+		Header   string
+		Expected time.Duration
+	}{
+		{
+			Name: "Empty",
+		},
+		{
+			Name:     "Seconds",
+			Header:   "120",
+			Expected: 2 * time.Minute,
+		},
+		{
+			Name:     "Date",
+			Header:   now.Add(30 * time.Second).Format(http.TimeFormat),
+			Expected: 30 * time.Second,
+		},
+		{
+			Name:   "PastDate",
+			Header: now.Add(-time.Minute).Format(http.TimeFormat),
+		},
+		{
+			Name:   "Negative",
+			Header: "-1",
+		},
+		{
+			Name:   "Malformed",
+			Header: "soon",
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			if a := retryAfter(test.Header, now); a != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestClientPages(t *testing.T) {
+	all := []List{{ID: 1, Name: "Grocery"}, {ID: 2, Name: "To-do"}, {ID: 3, Name: "Employees"}}
+
+	// The server returns pages of two lists.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil {
+			t.Errorf("error parsing offset: %v", err)
+		}
+
+		end := offset + 2
+		if end > len(all) {
+			end = len(all)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": all[offset:end],
+			"page":    map[string]int{"limit": 2, "offset": offset, "total": len(all)},
+		})
+	}))
+	defer srv.Close()
+
+	lists, err := New(srv.URL).Lists(context.Background())
+	if err != nil {
+		t.Fatalf("error getting lists: %v", err)
+	}
+
+	if d := cmp.Diff(all, lists); d != "" {
+		t.Errorf("unexpected difference in lists:\n%v", d)
+	}
+}
@@ -0,0 +1,397 @@
+package listclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// maxBatchSize is the largest amount of items the list daemon creates with a single batch
+// request.
+const maxBatchSize = 100
+
+// ErrQueued is returned by the mutations of a Queue that couldn't be sent because the
+// list daemon is unreachable, they are replayed by Flush instead.
+//
+// Creations are only queued when they certainly didn't reach the daemon, such as when no
+// connection could be made to it. The daemon doesn't deduplicate requests by their
+// Idempotency-Key, so a creation that may have been handled, such as one answered with a
+// 504 by a proxy, isn't sent again: its error is returned, or passed to OnDrop when it
+// was replayed.
+var ErrQueued = errors.New("list daemon unreachable, operation queued")
+
+// IsQueued reports whether err is caused by ErrQueued.
+func IsQueued(err error) bool {
+	return errors.Cause(err) == ErrQueued
+}
+
+// Operation is a mutation kept by a Queue until it is replayed.
+type Operation struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+
+	// Key is the Idempotency-Key the operation is sent with every time it is replayed,
+	// empty for methods that are idempotent on their own. Operations with a key are only
+	// replayed as long as they certainly weren't handled, see ErrQueued.
+	Key string `json:"key,omitempty"`
+
+	// Batch is the path of the batch endpoint the operation may be sent to along with the
+	// queued operations right after it that have the same Batch, empty when there is
+	// none.
+	Batch string `json:"batch,omitempty"`
+
+	Queued time.Time `json:"queued"`
+}
+
+// Queue buffers the mutations made through it while the list daemon is unreachable and
+// replays them in order once it can be reached again. Queued operations are kept in a
+// file, so they survive restarts of the program using the Queue.
+//
+// Mutations that are queued return ErrQueued along with the zero value of their result,
+// so resources created while offline can't be referenced until they are replayed.
+type Queue struct {
+	client *Client
+	path   string
+
+	mu  sync.Mutex
+	ops []Operation
+
+	// OnDrop, if non-nil, is called with every queued operation the list daemon rejected
+	// when it was replayed, or that failed such that it may have been handled, see
+	// ErrQueued. Those operations are dropped from the queue.
+	OnDrop func(Operation, error)
+}
+
+// NewQueue returns a Queue sending mutations through c, keeping the queued ones in the
+// file at path. Operations queued in the file before are loaded. An empty path keeps
+// them in memory only.
+func NewQueue(c *Client, path string) (*Queue, error) {
+	q := Queue{
+		client: c,
+		path:   path,
+	}
+
+	if path == "" {
+		return &q, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &q, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read queue")
+	}
+
+	if err := json.Unmarshal(b, &q.ops); err != nil {
+		return nil, errors.Wrap(err, "decode queue")
+	}
+
+	return &q, nil
+}
+
+// Len returns the amount of queued operations.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.ops)
+}
+
+// Operations returns the queued operations in the order they are replayed.
+func (q *Queue) Operations() []Operation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]Operation(nil), q.ops...)
+}
+
+// Flush replays the queued operations in order. It stops at the first operation that
+// can't be sent because the list daemon is unreachable and returns the error, the
+// operation and those after it stay queued. It also stops at an operation that is
+// dropped because it may have been handled, the ones after it stay queued.
+func (q *Queue) Flush(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.flush(ctx)
+}
+
+// CreateList creates a list with the given name, see Client.CreateList.
+func (q *Queue) CreateList(ctx context.Context, name string) (List, error) {
+	var l List
+	op, err := q.operation(http.MethodPost, "/list", "", List{Name: name})
+	if err != nil {
+		return List{}, errors.Wrap(err, "create list")
+	}
+
+	return l, errors.Wrap(q.send(ctx, op, &l), "create list")
+}
+
+// UpdateList renames the list with the given id, see Client.UpdateList.
+func (q *Queue) UpdateList(ctx context.Context, id int, name string) (List, error) {
+	var l List
+	op, err := q.operation(http.MethodPut, fmt.Sprintf("/list/%d", id), "", List{Name: name})
+	if err != nil {
+		return List{}, errors.Wrapf(err, "update list %d", id)
+	}
+
+	return l, errors.Wrapf(q.send(ctx, op, &l), "update list %d", id)
+}
+
+// DeleteList deletes the list with the given id along with its items, see
+// Client.DeleteList.
+func (q *Queue) DeleteList(ctx context.Context, id int) error {
+	op, err := q.operation(http.MethodDelete, fmt.Sprintf("/list/%d", id), "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "delete list %d", id)
+	}
+
+	return errors.Wrapf(q.send(ctx, op, nil), "delete list %d", id)
+}
+
+// CreateItem adds the given item to the list denoted by its ListID, see
+// Client.CreateItem. Items created in the same list one after another are replayed with
+// a single batch request.
+func (q *Queue) CreateItem(ctx context.Context, i Item) (Item, error) {
+	var created Item
+	path := fmt.Sprintf("/list/%d/item", i.ListID)
+
+	op, err := q.operation(http.MethodPost, path, path+"/batch", i)
+	if err != nil {
+		return Item{}, errors.Wrapf(err, "create item in list %d", i.ListID)
+	}
+
+	return created, errors.Wrapf(q.send(ctx, op, &created), "create item in list %d", i.ListID)
+}
+
+// UpdateItem updates the name and quantity of the item denoted by its ID and ListID, see
+// Client.UpdateItem.
+func (q *Queue) UpdateItem(ctx context.Context, i Item) (Item, error) {
+	var updated Item
+	op, err := q.operation(http.MethodPut, fmt.Sprintf("/list/%d/item/%d", i.ListID, i.ID), "", i)
+	if err != nil {
+		return Item{}, errors.Wrapf(err, "update item %d of list %d", i.ID, i.ListID)
+	}
+
+	return updated, errors.Wrapf(q.send(ctx, op, &updated), "update item %d of list %d", i.ID, i.ListID)
+}
+
+// DeleteItem deletes a single item of a list, see Client.DeleteItem.
+func (q *Queue) DeleteItem(ctx context.Context, listID, itemID int) error {
+	op, err := q.operation(http.MethodDelete, fmt.Sprintf("/list/%d/item/%d", listID, itemID), "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "delete item %d of list %d", itemID, listID)
+	}
+
+	return errors.Wrapf(q.send(ctx, op, nil), "delete item %d of list %d", itemID, listID)
+}
+
+// operation returns an Operation with the given method, path, batch path, and JSON
+// encoded body, which is given an Idempotency-Key when method is POST.
+func (q *Queue) operation(method, path, batch string, body interface{}) (Operation, error) {
+	op := Operation{
+		Method: method,
+		Path:   path,
+		Batch:  batch,
+	}
+
+	if method == http.MethodPost {
+		op.Key = uuid.New()
+	}
+
+	if body != nil {
+		var err error
+		if op.Body, err = json.Marshal(body); err != nil {
+			return Operation{}, errors.Wrap(err, "encode request body")
+		}
+	}
+
+	return op, nil
+}
+
+// send replays the queued operations and sends op after them, decoding the results of
+// its response into results. op is queued instead when the list daemon is unreachable.
+func (q *Queue) send(ctx context.Context, op Operation, results interface{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Operations are sent in order, so op has to wait for those queued before it.
+	if err := q.flush(ctx); err != nil {
+		if !transient(err) {
+			return err
+		}
+	} else if err := q.client.doKey(ctx, op.Method, op.Path, op.Key, op.body(), results); err == nil || !op.replayable(err) {
+		return err
+	}
+
+	op.Queued = time.Now()
+	q.ops = append(q.ops, op)
+
+	if err := q.save(); err != nil {
+		return err
+	}
+
+	return ErrQueued
+}
+
+// flush is Flush, q.mu has to be held.
+func (q *Queue) flush(ctx context.Context) error {
+	for len(q.ops) > 0 {
+		n, err := q.replay(ctx)
+
+		if n > 0 {
+			q.ops = q.ops[n:]
+			if err := q.save(); err != nil {
+				return err
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replay sends the first queued operation, along with those after it that can be sent
+// with the same batch request, and returns how many were sent. Operations the list
+// daemon rejected or that may have been handled are passed to OnDrop. The returned error
+// is set when the first operation stays queued because the list daemon couldn't be
+// reached, or when the sent ones were dropped because they may have been handled.
+func (q *Queue) replay(ctx context.Context) (int, error) {
+	op := q.ops[0]
+
+	n := 1
+	for op.Batch != "" && n < len(q.ops) && n < maxBatchSize && q.ops[n].Batch == op.Batch {
+		n++
+	}
+
+	if n == 1 {
+		err := q.client.doKey(ctx, op.Method, op.Path, op.Key, op.body(), nil)
+		switch {
+		case err == nil:
+			return 1, nil
+		case op.replayable(err):
+			return 0, err
+		}
+
+		q.drop(op, err)
+		if transient(err) {
+			return 1, err
+		}
+
+		return 1, nil
+	}
+
+	bodies := make([]json.RawMessage, n)
+	keys := make([]string, n)
+	for i, op := range q.ops[:n] {
+		bodies[i] = op.Body
+		keys[i] = op.Key
+	}
+
+	// The key of the batch request is derived from the keys of its operations, so the
+	// same batch is sent with the same key when it is replayed again.
+	key := uuid.NewSHA1(uuid.NameSpace_OID, []byte(strings.Join(keys, ","))).String()
+
+	var entries []struct {
+		Status int    `json:"status"`
+		Code   string `json:"code"`
+		Error  string `json:"error"`
+	}
+
+	err := q.client.doKey(ctx, http.MethodPost, op.Batch, key, bodies, &entries)
+	if err != nil {
+		if unsent(err) {
+			return 0, err
+		}
+
+		for _, op := range q.ops[:n] {
+			q.drop(op, err)
+		}
+
+		if transient(err) {
+			return n, err
+		}
+
+		return n, nil
+	}
+
+	for i, e := range entries {
+		if i < n && e.Status >= http.StatusBadRequest {
+			q.drop(q.ops[i], &Error{StatusCode: e.Status, Codes: []string{e.Code}, Messages: []string{e.Error}})
+		}
+	}
+
+	return n, nil
+}
+
+// drop reports the rejected operation op to OnDrop.
+func (q *Queue) drop(op Operation, err error) {
+	if q.OnDrop != nil {
+		q.OnDrop(op, err)
+	}
+}
+
+// save writes the queued operations to the file of the queue, replacing it at once so a
+// crash can't leave it half written.
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(q.ops)
+	if err != nil {
+		return errors.Wrap(err, "encode queue")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "create queue")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "write queue")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "write queue")
+	}
+
+	return errors.Wrap(os.Rename(f.Name(), q.path), "replace queue")
+}
+
+// replayable reports whether op, which failed with err, may be sent again later. Operations
+// with an Idempotency-Key may only be when they certainly weren't handled, see unsent, the
+// others whenever the failure is transient.
+func (op Operation) replayable(err error) bool {
+	if op.Key != "" {
+		return unsent(err)
+	}
+
+	return transient(err)
+}
+
+// body returns the body of op to be sent with doKey.
+func (op Operation) body() interface{} {
+	if op.Body == nil {
+		return nil
+	}
+
+	return op.Body
+}
@@ -0,0 +1,194 @@
+package listclient
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listclient")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "queue.json")
+
+	// The list daemon is unreachable until it is started below.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c := New(down.URL)
+	c.Retries = 0
+
+	q, err := NewQueue(c, path)
+	if err != nil {
+		t.Fatalf("error creating queue: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"Milk", "Eggs", "Bread"} {
+		if _, err := q.CreateItem(ctx, Item{ListID: 1, Name: name, Quantity: 1}); !IsQueued(err) {
+			t.Fatalf("expected the item to be queued, got %v", err)
+		}
+	}
+
+	if err := q.DeleteItem(ctx, 1, 4); !IsQueued(err) {
+		t.Fatalf("expected the deletion to be queued, got %v", err)
+	}
+
+	// The queued operations survive a restart.
+	q, err = NewQueue(c, path)
+	if err != nil {
+		t.Fatalf("error reopening queue: %v", err)
+	}
+
+	if e, a := 4, q.Len(); e != a {
+		t.Fatalf("expected %d queued operations, got %d", e, a)
+	}
+
+	var requests []string
+	var batch []Item
+	var keys []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list/1/item/batch", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			respond(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+
+		respond(w, http.StatusMultiStatus, []map[string]interface{}{
+			{"status": http.StatusCreated, "result": Item{ID: 2, ListID: 1, Name: "Milk"}},
+			{"status": http.StatusConflict, "code": "item_name_taken", "error": "taken"},
+			{"status": http.StatusCreated, "result": Item{ID: 3, ListID: 1, Name: "Bread"}},
+		})
+	})
+	mux.HandleFunc("/list/1/item/4", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c.baseURL = srv.URL
+
+	var dropped []string
+	q.OnDrop = func(op Operation, err error) {
+		if !HasCode(err, "item_name_taken") {
+			t.Errorf("expected the drop to carry the code of the entry, got %v", err)
+		}
+		dropped = append(dropped, string(op.Body))
+	}
+
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("error flushing queue: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"POST /list/1/item/batch", "DELETE /list/1/item/4"}, requests); diff != "" {
+		t.Errorf("unexpected requests (-want +got):\n%s", diff)
+	}
+
+	var names []string
+	for _, i := range batch {
+		names = append(names, i.Name)
+	}
+	if diff := cmp.Diff([]string{"Milk", "Eggs", "Bread"}, names); diff != "" {
+		t.Errorf("unexpected batch (-want +got):\n%s", diff)
+	}
+
+	if keys[0] == "" {
+		t.Error("expected an Idempotency-Key header on the batch")
+	}
+
+	if len(dropped) != 1 {
+		t.Fatalf("expected 1 dropped operation, got %d", len(dropped))
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("expected an empty queue, got %d operations", q.Len())
+	}
+
+	// Once flushed, mutations are sent right away.
+	if err := q.DeleteItem(ctx, 1, 4); err != nil {
+		t.Fatalf("error deleting item: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading queue: %v", err)
+	}
+
+	if e, a := "[]", string(b); e != a {
+		t.Errorf("expected the queue file to be emptied: %q, got %q", e, a)
+	}
+}
+
+func TestQueueGatewayTimeout(t *testing.T) {
+	// The list daemon is unreachable until it is started below.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c := New(down.URL)
+	c.Retries = 0
+
+	q, err := NewQueue(c, "")
+	if err != nil {
+		t.Fatalf("error creating queue: %v", err)
+	}
+
+	var dropped []error
+	q.OnDrop = func(op Operation, err error) {
+		dropped = append(dropped, err)
+	}
+
+	ctx := context.Background()
+	if _, err := q.CreateList(ctx, "Grocery"); !IsQueued(err) {
+		t.Fatalf("expected the list to be queued, got %v", err)
+	}
+
+	// A proxy answers with a 504 although the daemon may have created the lists.
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		respond(w, http.StatusGatewayTimeout, nil, "gateway timeout")
+	}))
+	defer srv.Close()
+	c.baseURL = srv.URL
+
+	if err := q.Flush(ctx); !HasCode(err, "gateway_timeout") {
+		t.Fatalf("expected the replay to fail with a 504, got %v", err)
+	}
+
+	if len(dropped) != 1 || !HasCode(dropped[0], "gateway_timeout") {
+		t.Fatalf("expected the replayed list to be dropped with a 504, got %v", dropped)
+	}
+
+	if _, err := q.CreateList(ctx, "Hardware"); IsQueued(err) || !HasCode(err, "gateway_timeout") {
+		t.Fatalf("expected the list to fail with a 504 instead of being queued, got %v", err)
+	}
+
+	// Neither list is sent again, where it could be rejected as taken if it was created.
+	if err := q.Flush(ctx); err != nil {
+		t.Fatalf("error flushing queue: %v", err)
+	}
+
+	if e, a := 0, q.Len(); e != a {
+		t.Errorf("expected %d queued operations, got %d", e, a)
+	}
+
+	if e, a := 2, requests; e != a {
+		t.Errorf("expected %d requests, got %d", e, a)
+	}
+}
@@ -0,0 +1,244 @@
+// Package signing signs requests to the list daemon with a secret shared between the
+// daemon and a machine client, and verifies them. It is an alternative to credentials for
+// server-to-server integrations: the signature proves who sent the request and that
+// neither its target nor its body were changed, and since it covers the time it was sent
+// and a nonce, a captured request can't be sent again.
+//
+// The signature is the hex encoded HMAC-SHA256, keyed with the secret, of the method, the
+// host, the request target, the Date header, the nonce, and the hex encoded SHA-256
+// digest of the body, each on a line of its own. It is sent in the Header along with the ID of the key
+// and the nonce:
+//
+//	X-Signature: keyId="billing",nonce="5d1c…",signature="9f86…"
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+// Header is the header holding the signature of a request.
+const Header = "X-Signature"
+
+// maxNonce is the longest nonce that is accepted.
+const maxNonce = 128
+
+// These errors are returned by Verifier.Verify for requests that aren't signed as they
+// should be.
+var (
+	ErrMissing    = errors.New("the request is not signed")
+	ErrMalformed  = errors.New("the signature header is malformed")
+	ErrUnknownKey = errors.New("the request is signed with an unknown key")
+	ErrExpired    = errors.New("the request was signed outside of the replay window")
+	ErrMismatch   = errors.New("the signature does not match the request")
+	ErrReplayed   = errors.New("the request was already received")
+)
+
+// Key is a secret shared between the list daemon and a client, identified by its ID.
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// Sign signs r with key at the given time, setting its Date and signature headers. The
+// body of r is read and replaced so it can still be sent. Every request needs to be
+// signed again when it is retried, since the daemon rejects a signature it has seen
+// before.
+func Sign(r *http.Request, key Key, now time.Time) error {
+	body, err := readBody(r)
+	if err != nil {
+		return errors.Wrap(err, "read request body")
+	}
+
+	nonce := uuid.New()
+	date := now.UTC().Format(http.TimeFormat)
+
+	r.Header.Set("Date", date)
+	r.Header.Set(Header, `keyId="`+key.ID+`",nonce="`+nonce+`",signature="`+signature(key.Secret, r.Method, host(r), target(r), date, nonce, body)+`"`)
+
+	return nil
+}
+
+// readBody reads the body of r and replaces it by a copy, so it can be read again.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, nil
+}
+
+// host returns the host r is sent to, which names the tenant it is for, so a request
+// can't be sent to another tenant than the one it was signed for.
+func host(r *http.Request) string {
+	if r.Host != "" {
+		return r.Host
+	}
+
+	return r.URL.Host
+}
+
+// target returns the request target of r as it was sent, the path and query of its URL.
+func target(r *http.Request) string {
+	if r.RequestURI != "" {
+		return r.RequestURI
+	}
+
+	return r.URL.RequestURI()
+}
+
+// signature returns the hex encoded signature of a request made up of the given parts.
+func signature(secret, method, host, target, date, nonce string, body []byte) string {
+	digest := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{method, host, target, date, nonce, hex.EncodeToString(digest[:])}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier verifies the signatures of requests. It remembers the nonces of the requests
+// it verified for as long as they are within the replay window, so a request that is
+// sent again is rejected. Nonces aren't shared between processes, a replay can only be
+// caught by the process that received the request first. It is safe for concurrent use.
+type Verifier struct {
+	keys   map[string]string
+	window time.Duration
+
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+// NewVerifier returns a Verifier accepting requests signed with any of keys whose Date
+// is no further than window from the time they are received.
+func NewVerifier(keys []Key, window time.Duration) *Verifier {
+	v := Verifier{
+		keys:   make(map[string]string, len(keys)),
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+
+	for _, k := range keys {
+		v.keys[k.ID] = k.Secret
+	}
+
+	return &v
+}
+
+// Verify verifies the signature of r, received at the given time, and returns the ID of
+// the key it was signed with. The body of r is read and replaced so it can be read
+// again. It returns one of the errors of this package for requests that aren't signed
+// as they should be, and any other error if the body can't be read.
+func (v *Verifier) Verify(r *http.Request, now time.Time) (string, error) {
+	h := r.Header.Get(Header)
+	if h == "" {
+		return "", ErrMissing
+	}
+
+	params, ok := parseHeader(h)
+	if !ok || params["keyId"] == "" || params["nonce"] == "" || len(params["nonce"]) > maxNonce || params["signature"] == "" {
+		return "", ErrMalformed
+	}
+
+	keyID, nonce := params["keyId"], params["nonce"]
+
+	secret, ok := v.keys[keyID]
+	if !ok {
+		return keyID, ErrUnknownKey
+	}
+
+	date := r.Header.Get("Date")
+	signed, err := http.ParseTime(date)
+	if err != nil {
+		return keyID, ErrMalformed
+	}
+
+	if d := now.Sub(signed); d > v.window || d < -v.window {
+		return keyID, ErrExpired
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return keyID, errors.Wrap(err, "read request body")
+	}
+
+	want := signature(secret, r.Method, host(r), target(r), date, nonce, body)
+	if !hmac.Equal([]byte(want), []byte(params["signature"])) {
+		return keyID, ErrMismatch
+	}
+
+	if !v.remember(keyID+"\x00"+nonce, signed, now) {
+		return keyID, ErrReplayed
+	}
+
+	return keyID, nil
+}
+
+// remember remembers the nonce of a request signed at the given time until it is out of
+// the replay window, reporting false if it already was.
+func (v *Verifier) remember(nonce string, signed, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Forgetting the nonces that are out of the window once per window keeps the memory
+	// bounded by the requests received within two windows.
+	if now.Sub(v.pruned) > v.window {
+		for n, expires := range v.seen {
+			if now.After(expires) {
+				delete(v.seen, n)
+			}
+		}
+		v.pruned = now
+	}
+
+	if _, ok := v.seen[nonce]; ok {
+		return false
+	}
+	v.seen[nonce] = signed.Add(v.window)
+
+	return true
+}
+
+// parseHeader parses the comma separated name="value" pairs of a signature header.
+func parseHeader(h string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	for _, pair := range strings.Split(h, ",") {
+		i := strings.IndexByte(pair, '=')
+		if i < 1 {
+			return nil, false
+		}
+
+		name, value := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+			return nil, false
+		}
+
+		params[name] = value[1 : len(value)-1]
+	}
+
+	return params, true
+}
@@ -0,0 +1,179 @@
+package signing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	signed := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := Key{ID: "billing", Secret: "0123456789abcdef"}
+
+	// request returns a request signed with k that is changed by tamper before it is
+	// received, as it arrives at the daemon.
+	request := func(t *testing.T, k Key, tamper func(*http.Request)) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/list?pretty=true", strings.NewReader(`{"name":"Grocery"}`))
+		if err := Sign(r, k, signed); err != nil {
+			t.Fatalf("error signing request: %v", err)
+		}
+
+		if tamper != nil {
+			tamper(r)
+		}
+
+		return r
+	}
+
+	tests := []struct {
+		Name     string
+		Key      Key
+		Tamper   func(*http.Request)
+		Received time.Time
+		Expected error
+	}{
+		{
+			Name:     "Signed",
+			Key:      key,
+			Received: signed.Add(time.Second),
+		},
+		{
+			Name:     "ClockSkew",
+			Key:      key,
+			Received: signed.Add(-4 * time.Minute),
+		},
+		{
+			Name:     "Unsigned",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.Header.Del(Header) },
+			Received: signed,
+			Expected: ErrMissing,
+		},
+		{
+			Name:     "Malformed",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.Header.Set(Header, "keyId=billing") },
+			Received: signed,
+			Expected: ErrMalformed,
+		},
+		{
+			Name:     "UnknownKey",
+			Key:      Key{ID: "shipping", Secret: key.Secret},
+			Received: signed,
+			Expected: ErrUnknownKey,
+		},
+		{
+			Name:     "WrongSecret",
+			Key:      Key{ID: key.ID, Secret: "fedcba9876543210"},
+			Received: signed,
+			Expected: ErrMismatch,
+		},
+		{
+			Name:     "Expired",
+			Key:      key,
+			Received: signed.Add(6 * time.Minute),
+			Expected: ErrExpired,
+		},
+		{
+			Name:     "ChangedBody",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.Body = ioutil.NopCloser(strings.NewReader(`{"name":"Hardware"}`)) },
+			Received: signed,
+			Expected: ErrMismatch,
+		},
+		{
+			Name:     "ChangedTarget",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.RequestURI = "/list/1?pretty=true" },
+			Received: signed,
+			Expected: ErrMismatch,
+		},
+		{
+			Name:     "ChangedHost",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.Host = "acme.lists.example.com" },
+			Received: signed,
+			Expected: ErrMismatch,
+		},
+		{
+			Name:     "ChangedDate",
+			Key:      key,
+			Tamper:   func(r *http.Request) { r.Header.Set("Date", signed.Add(time.Minute).Format(http.TimeFormat)) },
+			Received: signed,
+			Expected: ErrMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		fn := func(t *testing.T) {
+			v := NewVerifier([]Key{key}, 5*time.Minute)
+
+			r := request(t, test.Key, test.Tamper)
+
+			id, err := v.Verify(r, test.Received)
+			if e, a := test.Expected, err; e != a {
+				t.Fatalf("expected error: %v, got error: %v", e, a)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if e, a := key.ID, id; e != a {
+				t.Errorf("expected key: %v, got key: %v", e, a)
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("error reading body: %v", err)
+			}
+
+			if e, a := `{"name":"Grocery"}`, string(body); e != a {
+				t.Errorf("expected body: %v, got body: %v", e, a)
+			}
+		}
+
+		t.Run(test.Name, fn)
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	signed := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := Key{ID: "billing", Secret: "0123456789abcdef"}
+
+	v := NewVerifier([]Key{key}, time.Minute)
+
+	r := httptest.NewRequest(http.MethodDelete, "/list/1", nil)
+	if err := Sign(r, key, signed); err != nil {
+		t.Fatalf("error signing request: %v", err)
+	}
+
+	if _, err := v.Verify(r, signed); err != nil {
+		t.Fatalf("error verifying request: %v", err)
+	}
+
+	if e, a := ErrReplayed, func() error { _, err := v.Verify(r, signed.Add(30*time.Second)); return err }(); e != a {
+		t.Errorf("expected error: %v, got error: %v", e, a)
+	}
+
+	// Once out of the window the request is rejected as expired, and its nonce forgotten.
+	if e, a := ErrExpired, func() error { _, err := v.Verify(r, signed.Add(2*time.Minute)); return err }(); e != a {
+		t.Errorf("expected error: %v, got error: %v", e, a)
+	}
+
+	again := httptest.NewRequest(http.MethodDelete, "/list/1", nil)
+	if err := Sign(again, key, signed.Add(2*time.Minute)); err != nil {
+		t.Fatalf("error signing request: %v", err)
+	}
+
+	if _, err := v.Verify(again, signed.Add(2*time.Minute)); err != nil {
+		t.Errorf("error verifying request signed again: %v", err)
+	}
+
+	if e, a := 1, len(v.seen); e != a {
+		t.Errorf("expected %d remembered nonces, got %d", e, a)
+	}
+}